@@ -0,0 +1,253 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"nofx/auth"
+	"nofx/config"
+	"nofx/logger"
+	"nofx/logging"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accountExportVersion 个人数据导出包的格式版本
+const accountExportVersion = 1
+
+// accountExportMaxRows 导出订单、平仓记录的上限（实际上导出全部）
+const accountExportMaxRows = 1000000
+
+// accountFile 导出包中的一个JSON文件
+type accountFile struct {
+	name string
+	data interface{}
+}
+
+// collectAccountFiles 读取用户在配置数据库中的全部数据，API密钥等凭据不导出
+func (s *Server) collectAccountFiles(userID string) ([]accountFile, []*config.TraderRecord, error) {
+	user, err := s.database.GetUserByID(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("用户不存在")
+	}
+	export := AccountExport{Version: accountExportVersion, User: user, ExportedAt: time.Now().UTC()}
+	export.SignalSource, _ = s.database.GetUserSignalSource(userID)
+	export.Telegram, _ = s.database.GetTelegramLink(userID)
+	if export.DailyDigest, err = s.database.GetDailyDigest(userID); err != nil {
+		return nil, nil, err
+	}
+
+	models, err := s.database.GetAIModels(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range models {
+		m.APIKey = ""
+	}
+	exchanges, err := s.database.GetExchanges(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range exchanges {
+		e.APIKey, e.SecretKey, e.AsterPrivateKey = "", "", ""
+	}
+	traders, err := s.database.GetTraders(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	follows, err := s.database.GetFollows(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	webhooks, err := s.database.GetWebhooks(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	runs, err := s.database.GetBacktestRuns(userID, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, run := range runs {
+		if full, err := s.database.GetBacktestRun(userID, run.ID); err == nil {
+			runs[i] = full
+		}
+	}
+
+	files := []accountFile{
+		{"account.json", export},
+		{"ai_models.json", models},
+		{"exchanges.json", exchanges},
+		{"traders.json", traders},
+		{"follows.json", follows},
+		{"webhooks.json", webhooks},
+		{"backtests.json", runs},
+	}
+	for _, t := range traders {
+		dir := path.Join("traders", t.ID)
+		orders, err := s.database.GetTraderOrders(t.ID, "", accountExportMaxRows)
+		if err != nil {
+			return nil, nil, err
+		}
+		positions, _, err := s.database.GetPositionHistory(t.ID, "", accountExportMaxRows)
+		if err != nil {
+			return nil, nil, err
+		}
+		links, err := s.database.GetShareLinks(userID, t.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources, err := s.database.GetTraderSignalSources(t.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files,
+			accountFile{path.Join(dir, "orders.json"), orders},
+			accountFile{path.Join(dir, "positions.json"), positions},
+			accountFile{path.Join(dir, "share_links.json"), links},
+			accountFile{path.Join(dir, "signal_sources.json"), sources},
+		)
+	}
+	return files, traders, nil
+}
+
+// handleExportAccountData 导出当前用户的全部数据（配置、交易记录、决策日志）为zip包
+func (s *Server) handleExportAccountData(c *gin.Context) {
+	userID := c.GetString("user_id")
+	files, traders, err := s.collectAccountFiles(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("导出数据失败: %v", err)})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="nofx-data-%s.zip"`, time.Now().Format("20060102")))
+	c.Status(http.StatusOK)
+
+	// 响应头已发出，之后的错误只能中断下载
+	zw := zip.NewWriter(c.Writer)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err == nil {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(f.data)
+		}
+		if err != nil {
+			requestLog(c).Error("写入导出文件失败", "file", f.name, "error", err)
+			return
+		}
+	}
+	// 决策日志每行一条记录，逐条写入避免一次读入内存
+	for _, t := range traders {
+		name := path.Join("traders", t.ID, "decisions.jsonl")
+		w, err := zw.Create(name)
+		if err != nil {
+			requestLog(c).Error("写入导出文件失败", "file", name, "error", err)
+			return
+		}
+		records, err := logger.NewDecisionLogger(filepath.Join("decision_logs", t.ID)).GetRecordsBetween(time.Time{}, time.Time{})
+		if err != nil {
+			requestLog(c).Warn("读取决策日志失败", "trader_id", t.ID, "error", err)
+			continue
+		}
+		enc := json.NewEncoder(w)
+		for _, record := range records {
+			if err := enc.Encode(record); err != nil {
+				requestLog(c).Error("写入导出文件失败", "file", name, "error", err)
+				return
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		requestLog(c).Error("写入导出文件失败", "error", err)
+		return
+	}
+	requestLog(c).Info("用户导出个人数据", "user_id", userID, "traders", len(traders))
+}
+
+// hasOtherAdmin 除 userID 外是否还有未禁用的管理员
+func (s *Server) hasOtherAdmin(userID string) bool {
+	users, err := s.database.ListUsers()
+	if err != nil {
+		return false
+	}
+	for _, u := range users {
+		if u.ID != userID && u.Role == config.RoleAdmin && !u.Disabled {
+			return true
+		}
+	}
+	return false
+}
+
+// handleDeleteAccount 注销账户：停止并删除交易员和跟单、删除API密钥和全部数据，已归档的竞赛排名匿名化
+func (s *Server) handleDeleteAccount(c *gin.Context) {
+	if auth.IsAdminMode() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "管理员模式下无法注销账户"})
+		return
+	}
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	user, err := s.database.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+		return
+	}
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "密码错误"})
+		return
+	}
+	if user.OTPVerified && !auth.VerifyOTP(user.OTPSecret, req.OTPCode) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "OTP验证码错误"})
+		return
+	}
+	if user.Role == config.RoleAdmin && !s.hasOtherAdmin(userID) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "不能注销唯一的管理员账户"})
+		return
+	}
+
+	// 先停止交易员和跟单，避免删除数据期间继续下单
+	traders, err := s.database.GetTraders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	loaded := s.traderManager.GetAllTraders()
+	for _, t := range traders {
+		if at, ok := loaded[t.ID]; ok && at.IsRunning() {
+			if err := at.StopWithTimeout(traderStopTimeout); err != nil {
+				requestLog(c).Warn("停止交易员超时", "trader_id", t.ID, "error", err)
+			}
+		}
+	}
+	if follows, err := s.database.GetFollows(userID); err == nil {
+		for _, f := range follows {
+			s.traderManager.StopFollow(f.ID)
+		}
+	}
+
+	traderIDs, err := s.database.DeleteUserAccount(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("注销账户失败: %v", err)})
+		return
+	}
+	for _, id := range traderIDs {
+		s.traderManager.StopFollowsOf(id)
+		s.traderManager.RemoveTrader(id)
+		logging.DropTraderLogs(id)
+		if _, err := logger.NewDecisionLogger(filepath.Join("decision_logs", id)).Purge(); err != nil {
+			requestLog(c).Warn("删除决策日志失败", "trader_id", id, "error", err)
+		}
+	}
+
+	requestLog(c).Info("用户已注销账户", "user_id", userID, "traders", len(traderIDs))
+	c.JSON(http.StatusOK, MessageResponse{Message: "账户已注销，所有数据已删除"})
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"nofx/auth"
+	"nofx/config"
+	"nofx/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccountExportAndDelete(t *testing.T) {
+	s := newAdminTestServer(t)
+	hash, err := auth.HashPassword("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.database.CreateUser(&config.User{ID: "carol", Email: "carol@test.com", PasswordHash: hash}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.database.CreateAIModel("carol", "carol_deepseek", "DeepSeek", "deepseek", true, "sk-carol", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "carol_t1", UserID: "carol", Name: "趋势", AIModelID: "carol_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join("decision_logs", "carol_t1")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	logger.NewDecisionLogger(dir).LogDecision(&logger.DecisionRecord{CoTTrace: "趋势延续"})
+
+	season := &config.Season{Name: "S1", StartAt: time.Now().Add(-2 * time.Hour), EndAt: time.Now().Add(-time.Hour)}
+	if err := s.database.CreateSeason(season); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.database.ArchiveSeason(season.ID, []config.SeasonResult{{Rank: 1, TraderID: "carol_t1", TraderName: "趋势", PnLPct: 10}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 导出包含配置和决策日志，不含API密钥
+	w := doAs(t, s, "carol", http.MethodGet, "/api/user/export")
+	if w.Code != http.StatusOK {
+		t.Fatalf("导出失败: %d %s", w.Code, w.Body.String())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[f.Name] = string(data)
+	}
+	if !strings.Contains(contents["account.json"], "carol@test.com") || !strings.Contains(contents["traders.json"], "carol_t1") {
+		t.Errorf("导出内容错误: %v", contents)
+	}
+	if !strings.Contains(contents["traders/carol_t1/decisions.jsonl"], "趋势延续") {
+		t.Errorf("应导出决策日志: %v", contents)
+	}
+	for name, data := range contents {
+		if strings.Contains(data, "sk-carol") || strings.Contains(data, hash) {
+			t.Errorf("%s 不应包含密钥", name)
+		}
+	}
+
+	// 注销需要正确的密码
+	if w := doAsWithBody(t, s, "carol", http.MethodDelete, "/api/user", `{"password":"wrong"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("密码错误应返回401: %d", w.Code)
+	}
+	if w := doAsWithBody(t, s, "carol", http.MethodDelete, "/api/user", `{"password":"secret123"}`); w.Code != http.StatusOK {
+		t.Fatalf("注销失败: %d %s", w.Code, w.Body.String())
+	}
+	if _, err := s.database.GetUserByID("carol"); err == nil {
+		t.Error("用户应被删除")
+	}
+	if models, _ := s.database.GetAIModels("carol"); len(models) != 0 {
+		t.Errorf("API密钥应被删除: %+v", models)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("决策日志应被删除: %v", err)
+	}
+	results, err := s.database.GetSeasonResults(season.ID)
+	if err != nil || len(results) != 1 || results[0].TraderID == "carol_t1" || results[0].TraderName != config.DeletedTraderName {
+		t.Errorf("竞赛排名应匿名化: %+v %v", results, err)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"nofx/backup"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleListBackups 备份目录中的备份文件，最新的在前（管理员）
+func (s *Server) handleListBackups(c *gin.Context) {
+	cfg, err := backup.LoadConfig(s.database)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	backups, err := backup.List(cfg.Dir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "读取备份目录失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, backups)
+}
+
+// handleCreateBackup 立即创建备份，按 backup_config 上传到S3并清理旧备份（管理员）
+func (s *Server) handleCreateBackup(c *gin.Context) {
+	cfg, err := backup.LoadConfig(s.database)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	info, err := backup.Run(s.database, "decision_logs", cfg)
+	if err != nil {
+		if info == nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "创建备份失败: " + err.Error()})
+			return
+		}
+		// 备份文件已生成，只是上传或清理失败
+		requestLog(c).Warn("备份后续处理失败", "file", info.Name, "error", err)
+	}
+	requestLog(c).Info("管理员创建备份", "file", info.Name)
+	c.JSON(http.StatusCreated, info)
+}
+
+// handleDownloadBackup 下载备份文件（管理员）
+func (s *Server) handleDownloadBackup(c *gin.Context) {
+	cfg, err := backup.LoadConfig(s.database)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	path, err := backup.Path(cfg.Dir, c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "备份文件不存在"})
+		return
+	}
+	c.FileAttachment(path, c.Param("name"))
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/backup"
+	"path/filepath"
+	"testing"
+)
+
+func TestAdminBackups(t *testing.T) {
+	s := newAdminTestServer(t)
+	dir := filepath.ToSlash(filepath.Join(t.TempDir(), "backups"))
+	if err := s.database.SetSystemConfig("backup_config", `{"dir":"`+dir+`"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doAs(t, s, "alice", http.MethodPost, "/api/admin/backups"); w.Code != http.StatusForbidden {
+		t.Errorf("普通用户不能创建备份: %d", w.Code)
+	}
+
+	w := doAs(t, s, "boss", http.MethodPost, "/api/admin/backups")
+	var info backup.Info
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil || w.Code != http.StatusCreated || info.Name == "" {
+		t.Fatalf("创建备份失败: %d %s", w.Code, w.Body.String())
+	}
+
+	w = doAs(t, s, "boss", http.MethodGet, "/api/admin/backups")
+	var list []backup.Info
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].Name != info.Name {
+		t.Fatalf("备份列表错误: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doAs(t, s, "boss", http.MethodGet, "/api/admin/backups/"+info.Name); w.Code != http.StatusOK || w.Body.Len() != int(info.Size) {
+		t.Errorf("下载备份失败: %d %d", w.Code, w.Body.Len())
+	}
+	if w := doAs(t, s, "boss", http.MethodGet, "/api/admin/backups/config.db"); w.Code != http.StatusBadRequest {
+		t.Errorf("只能下载备份文件: %d", w.Code)
+	}
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"nofx/auth"
+	"nofx/backup"
+	"nofx/cache"
+	"nofx/config"
+	"nofx/logger"
+	"nofx/logging"
+	"nofx/market"
+	"nofx/pool"
+	"nofx/trader"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// systemConfigAuditLimit 修改记录默认返回条数
+const systemConfigAuditLimit = 100
+
+// parsePaperCosts 解析纸面交易费用配置，为空时使用默认值
+func parsePaperCosts(value string) (trader.SimulationCosts, error) {
+	costs := trader.DefaultSimulationCosts()
+	if value == "" {
+		return costs, nil
+	}
+	if err := json.Unmarshal([]byte(value), &costs); err != nil {
+		return costs, fmt.Errorf("解析纸面交易费用失败: %w", err)
+	}
+	costs.SlippageModel = strings.ToLower(strings.TrimSpace(costs.SlippageModel))
+	return costs, costs.Validate()
+}
+
+// parseSMTPConfig 解析SMTP配置，为空时返回 nil（邮件只写日志）
+func parseSMTPConfig(value string) (*auth.SMTPConfig, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var cfg auth.SMTPConfig
+	if err := json.Unmarshal([]byte(value), &cfg); err != nil {
+		return nil, fmt.Errorf("解析smtp_config失败: %w", err)
+	}
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("smtp_config 缺少 host")
+	}
+	return &cfg, nil
+}
+
+// validateStructuredConfig JSON类配置需要按具体结构校验，避免保存后启动时才发现无效
+func validateStructuredConfig(key, value string) error {
+	switch key {
+	case "paper_trading_costs":
+		_, err := parsePaperCosts(value)
+		return err
+	case "smtp_config":
+		_, err := parseSMTPConfig(value)
+		return err
+	case "backup_config":
+		_, err := backup.ParseConfig(value)
+		return err
+	case "decision_log_storage":
+		_, err := logger.ParseStorageConfig(value)
+		return err
+	case "redis_url":
+		_, err := cache.Open(value)
+		return err
+	case "proxy_url":
+		if value == "" {
+			return nil
+		}
+		return trader.ValidateProxyURL(value)
+	}
+	return nil
+}
+
+// applySystemConfig 把已保存的配置推送到运行中的模块
+// beta_mode、默认杠杆、password_reset_url 等每次使用时读取数据库，无需处理
+func (s *Server) applySystemConfig(key string) error {
+	get := func(k string) string {
+		v, _ := s.database.GetSystemConfig(k)
+		return v
+	}
+	value := get(key)
+
+	switch key {
+	case "use_default_coins":
+		pool.SetUseDefaultCoins(value == "true")
+	case "default_coins":
+		var coins []string
+		if err := json.Unmarshal([]byte(value), &coins); err != nil {
+			return err
+		}
+		pool.SetDefaultCoins(coins)
+	case "coin_pool_api_url":
+		pool.SetCoinPoolAPI(value)
+	case "oi_top_api_url":
+		pool.SetOITopAPI(value)
+	case "oi_top_provider":
+		return pool.SetOITopProvider(value)
+	case "news_feed_url":
+		market.SetNewsFeedURL(value)
+	case "candidate_max_age_minutes":
+		minutes, _ := strconv.Atoi(value)
+		pool.SetMaxDataAge(time.Duration(minutes) * time.Minute)
+	case "paper_trading_costs":
+		costs, err := parsePaperCosts(value)
+		if err != nil {
+			return err
+		}
+		return trader.SetPaperTradingCosts(costs)
+	case "orphan_position_policy":
+		return trader.SetOrphanPositionPolicy(value)
+	case "circuit_breaker_drop_pct", "circuit_breaker_minutes":
+		drop, _ := strconv.ParseFloat(get("circuit_breaker_drop_pct"), 64)
+		minutes, err := strconv.Atoi(get("circuit_breaker_minutes"))
+		if err != nil {
+			minutes = 30
+		}
+		return trader.SetEquityCircuitBreaker(drop, time.Duration(minutes)*time.Minute)
+	case "max_consecutive_losses", "loss_cooldown_minutes":
+		maxLosses, _ := strconv.Atoi(get("max_consecutive_losses"))
+		minutes, err := strconv.Atoi(get("loss_cooldown_minutes"))
+		if err != nil {
+			minutes = 60
+		}
+		return trader.SetLossCooldown(maxLosses, time.Duration(minutes)*time.Minute)
+	case "balance_drift_threshold_pct":
+		pct, _ := strconv.ParseFloat(value, 64)
+		return trader.SetBalanceDriftThreshold(pct)
+	case "funding_cost_close_pct":
+		pct, _ := strconv.ParseFloat(value, 64)
+		return trader.SetFundingCostLimit(pct)
+	case "max_net_delta_pct":
+		pct, _ := strconv.ParseFloat(value, 64)
+		return trader.SetMaxNetDelta(pct)
+	case "order_retry_attempts", "order_retry_backoff_ms":
+		attempts, _ := strconv.Atoi(get("order_retry_attempts"))
+		backoffMs, err := strconv.Atoi(get("order_retry_backoff_ms"))
+		if err != nil {
+			backoffMs = 1000
+		}
+		return trader.SetOrderRetryPolicy(trader.RetryPolicy{MaxAttempts: attempts, Backoff: time.Duration(backoffMs) * time.Millisecond})
+	case "limit_max_leverage", "limit_max_notional", "limit_max_traders":
+		s.traderManager.ApplyAllUserLimits(s.database)
+	case "ai_max_concurrent_calls":
+		limit, _ := strconv.Atoi(value)
+		s.traderManager.SetAICallLimit(limit)
+	case "log_level":
+		return logging.SetLevel(value)
+	case "smtp_config":
+		cfg, err := parseSMTPConfig(value)
+		if err != nil {
+			return err
+		}
+		if cfg == nil {
+			auth.SetMailer(auth.LogMailer{})
+		} else {
+			auth.SetMailer(auth.NewSMTPMailer(*cfg))
+		}
+	}
+	return nil
+}
+
+// handleGetAdminSystemConfig 可修改的系统配置及当前值（敏感项脱敏）
+func (s *Server) handleGetAdminSystemConfig(c *gin.Context) {
+	values, err := s.database.GetAllSystemConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("读取系统配置失败: %v", err)})
+		return
+	}
+
+	specs := config.SystemConfigSpecs()
+	items := make([]SystemConfigItem, 0, len(specs))
+	for _, spec := range specs {
+		items = append(items, SystemConfigItem{
+			SystemConfigSpec: spec,
+			Value:            config.MaskSystemConfig(spec.Key, values[spec.Key]),
+		})
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// handleUpdateAdminSystemConfig 批量修改系统配置：全部校验通过才保存，并记录修改人
+func (s *Server) handleUpdateAdminSystemConfig(c *gin.Context) {
+	var req UpdateSystemConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	changes := make(map[string]string, len(req.Values))
+	for key, raw := range req.Values {
+		spec, ok := config.LookupSystemConfigSpec(key)
+		// 敏感项提交占位符表示保持原值
+		if ok && spec.Secret && raw == config.SecretMask {
+			continue
+		}
+		value, err := config.ValidateSystemConfig(key, raw)
+		if err == nil {
+			err = validateStructuredConfig(key, value)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		changes[key] = value
+	}
+
+	changedBy := c.GetString("email")
+	if changedBy == "" {
+		changedBy = c.GetString("user_id")
+	}
+	applied, err := s.database.UpdateSystemConfigs(changes, changedBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存系统配置失败: %v", err)})
+		return
+	}
+
+	resp := UpdateSystemConfigResponse{Changed: applied, RestartRequired: []string{}, Warnings: []string{}}
+	for _, change := range applied {
+		requestLog(c).Info("修改系统配置", "changed_by", changedBy, "key", change.Key, "old", config.MaskSystemConfig(change.Key, change.OldValue), "new", config.MaskSystemConfig(change.Key, change.NewValue))
+		spec, _ := config.LookupSystemConfigSpec(change.Key)
+		if spec.RequiresRestart {
+			resp.RestartRequired = append(resp.RestartRequired, change.Key)
+			continue
+		}
+		if err := s.applySystemConfig(change.Key); err != nil {
+			requestLog(c).Warn("应用系统配置失败", "key", change.Key, "error", err)
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf("%s 已保存但未能立即生效: %v", change.Key, err))
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleGetSystemConfigAudit 系统配置修改记录
+func (s *Server) handleGetSystemConfigAudit(c *gin.Context) {
+	limit := systemConfigAuditLimit
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 && n <= 1000 {
+		limit = n
+	}
+	changes, err := s.database.GetSystemConfigAudit(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("读取修改记录失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"testing"
+)
+
+func TestUpdateSystemConfig(t *testing.T) {
+	s := newAdminTestServer(t)
+	const path = "/api/admin/system-config"
+
+	if w := doAsWithBody(t, s, "alice", http.MethodPut, path, `{"values":{"beta_mode":"true"}}`); w.Code != http.StatusForbidden {
+		t.Fatalf("普通用户不能修改系统配置, got %d", w.Code)
+	}
+
+	// 任一项无效时整批都不保存
+	for _, body := range []string{
+		`{"values":{"beta_mode":"true","btc_eth_leverage":"0"}}`,
+		`{"values":{"jwt_secret":"x"}}`,
+		`{"values":{"default_coins":"[\"BTC\"]"}}`,
+		`{"values":{"paper_trading_costs":"{\"slippage_model\":\"magic\"}"}}`,
+	} {
+		if w := doAsWithBody(t, s, "boss", http.MethodPut, path, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s 应校验失败, got %d", body, w.Code)
+		}
+	}
+	if v, _ := s.database.GetSystemConfig("beta_mode"); v != "false" {
+		t.Fatalf("校验失败时不应部分保存, beta_mode=%s", v)
+	}
+
+	w := doAsWithBody(t, s, "boss", http.MethodPut, path,
+		`{"values":{"beta_mode":"TRUE","altcoin_leverage":"3","default_coins":"[\"btcusdt\"]","rate_limit_ip":"100"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("修改失败: %d %s", w.Code, w.Body.String())
+	}
+	var resp UpdateSystemConfigResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Changed) != 4 || len(resp.RestartRequired) != 1 || resp.RestartRequired[0] != "rate_limit_ip" {
+		t.Errorf("返回结果错误: %+v", resp)
+	}
+	if v, _ := s.database.GetSystemConfig("default_coins"); v != `["BTCUSDT"]` {
+		t.Errorf("币种应规范化为大写, got %s", v)
+	}
+
+	audit, err := s.database.GetSystemConfigAudit(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit) != 4 || audit[0].ChangedBy != "boss@test.com" {
+		t.Errorf("修改记录错误: %+v", audit)
+	}
+
+	// 值未变化时不产生记录
+	doAsWithBody(t, s, "boss", http.MethodPut, path, `{"values":{"beta_mode":"true"}}`)
+	if audit, _ := s.database.GetSystemConfigAudit(10); len(audit) != 4 {
+		t.Errorf("未变化的值不应记录, got %d", len(audit))
+	}
+}
+
+func TestSystemConfigSecretMasked(t *testing.T) {
+	s := newAdminTestServer(t)
+	const smtp = `{"host":"smtp.example.com","password":"hunter2"}`
+	if _, err := s.database.UpdateSystemConfigs(map[string]string{"smtp_config": smtp}, "boss"); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doAs(t, s, "boss", http.MethodGet, "/api/admin/system-config")
+	var items []SystemConfigItem
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range items {
+		if item.Key == "smtp_config" && item.Value != config.SecretMask {
+			t.Errorf("SMTP配置应脱敏, got %s", item.Value)
+		}
+	}
+	if audit, _ := s.database.GetSystemConfigAudit(1); audit[0].NewValue != config.SecretMask {
+		t.Errorf("修改记录中的敏感值应脱敏, got %s", audit[0].NewValue)
+	}
+
+	// 原样提交占位符表示保持不变
+	doAsWithBody(t, s, "boss", http.MethodPut, "/api/admin/system-config", `{"values":{"smtp_config":"******"}}`)
+	if v, _ := s.database.GetSystemConfig("smtp_config"); v != smtp {
+		t.Errorf("提交占位符不应覆盖原值, got %s", v)
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	betaCodeLength   = 6
+	betaCodeCharset  = "23456789abcdefghjkmnpqrstuvwxyz" // 与 generate_beta_code.sh 相同，避免易混淆字符
+	maxBetaCodeCount = 1000
+)
+
+// handleRotateKey 用当前加密密钥重新加密数据库中的API密钥（管理员）
+// 需先以 NOFX_ENCRYPTION_KEY=新密钥、NOFX_ENCRYPTION_OLD_KEYS=旧密钥 重启服务
+func (s *Server) handleRotateKey(c *gin.Context) {
+	n, err := s.database.RotateEncryptionKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("重新加密失败: %v", err)})
+		return
+	}
+	requestLog(c).Info("已用新密钥重新加密API密钥", "rotated", n)
+	c.JSON(http.StatusOK, RotateKeyResponse{Rotated: n})
+}
+
+// handleGetBetaCodes 内测码统计（管理员）
+func (s *Server) handleGetBetaCodes(c *gin.Context) {
+	total, used, err := s.database.GetBetaCodeStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取内测码统计失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, BetaCodesResponse{Total: total, Used: used})
+}
+
+// handleCreateBetaCodes 生成新的内测码（管理员）
+func (s *Server) handleCreateBetaCodes(c *gin.Context) {
+	var req BetaCodesRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Count < 0 || req.Count > maxBetaCodeCount {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("count 必须在 1-%d 之间", maxBetaCodeCount)})
+		return
+	}
+
+	codes := make([]string, 0, req.Count)
+	seen := map[string]bool{}
+	for len(codes) < req.Count {
+		code, err := newBetaCode()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成内测码失败"})
+			return
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	// 与已有内测码重复的跳过（极少），只返回实际写入的
+	inserted, err := s.database.AddBetaCodes(codes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存内测码失败: %v", err)})
+		return
+	}
+
+	total, used, _ := s.database.GetBetaCodeStats()
+	requestLog(c).Info("已生成内测码", "count", len(inserted))
+	c.JSON(http.StatusCreated, BetaCodesResponse{Codes: inserted, Total: total, Used: used})
+}
+
+// newBetaCode 随机生成一个内测码
+func newBetaCode() (string, error) {
+	code := make([]byte, betaCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(betaCodeCharset))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = betaCodeCharset[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"testing"
+)
+
+func TestAdminListTraders(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "alice_t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper", IsRunning: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/admin/traders"); w.Code != http.StatusForbidden {
+		t.Errorf("普通用户访问应返回403: %d", w.Code)
+	}
+	w := doAs(t, s, "boss", http.MethodGet, "/api/admin/traders")
+	var traders []AdminTraderSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &traders); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("获取交易员列表失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(traders) != 1 || traders[0].Email != "alice@test.com" || !traders[0].IsRunning {
+		t.Errorf("交易员列表错误: %+v", traders)
+	}
+
+	if w := doAs(t, s, "boss", http.MethodPost, "/api/admin/traders/missing/stop"); w.Code != http.StatusNotFound {
+		t.Errorf("不存在的交易员应返回404: %d", w.Code)
+	}
+}
+
+func TestAdminBetaCodes(t *testing.T) {
+	s := newAdminTestServer(t)
+
+	w := doAsWithBody(t, s, "boss", http.MethodPost, "/api/admin/beta-codes", `{"count":3}`)
+	var resp BetaCodesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("生成内测码失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(resp.Codes) != 3 || resp.Total != 3 || len(resp.Codes[0]) != betaCodeLength {
+		t.Errorf("生成的内测码错误: %+v", resp)
+	}
+	if ok, _ := s.database.ValidateBetaCode(resp.Codes[0]); !ok {
+		t.Error("生成的内测码应可用于注册")
+	}
+
+	if w := doAsWithBody(t, s, "boss", http.MethodPost, "/api/admin/beta-codes", `{"count":5000}`); w.Code != http.StatusBadRequest {
+		t.Errorf("数量超出上限应返回400: %d", w.Code)
+	}
+	if w := doAs(t, s, "boss", http.MethodPost, "/api/admin/beta-codes"); w.Code != http.StatusCreated {
+		t.Errorf("不带请求体时默认生成1个: %d", w.Code)
+	}
+	w = doAs(t, s, "boss", http.MethodGet, "/api/admin/beta-codes")
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Total != 4 || resp.Used != 0 {
+		t.Errorf("内测码统计错误: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleAdminListTraders 所有用户的交易员和运行状态（管理员）
+func (s *Server) handleAdminListTraders(c *gin.Context) {
+	records, err := s.database.ListAllTraders()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员列表失败: %v", err)})
+		return
+	}
+
+	emails := map[string]string{}
+	if users, err := s.database.ListUsers(); err == nil {
+		for _, u := range users {
+			emails[u.ID] = u.Email
+		}
+	}
+
+	result := make([]AdminTraderSummary, 0, len(records))
+	for _, t := range records {
+		summary := AdminTraderSummary{
+			ID:         t.ID,
+			Name:       t.Name,
+			UserID:     t.UserID,
+			Email:      emails[t.UserID],
+			AIModelID:  t.AIModelID,
+			ExchangeID: t.ExchangeID,
+			IsRunning:  t.IsRunning,
+			CreatedAt:  t.CreatedAt,
+		}
+		// 已加载的交易员以实际状态为准
+		if at, err := s.traderManager.GetTrader(t.ID); err == nil {
+			summary.IsRunning, _ = at.GetStatus()["is_running"].(bool)
+		}
+		result = append(result, summary)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// handleAdminStopTrader 停止任意用户的交易员（管理员，用于处理失控的交易员）
+func (s *Server) handleAdminStopTrader(c *gin.Context) {
+	traderID := c.Param("id")
+	ownerID, err := s.database.GetTraderOwner(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在"})
+		return
+	}
+	at, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员未加载"})
+		return
+	}
+	if running, ok := at.GetStatus()["is_running"].(bool); ok && !running {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "交易员已停止"})
+		return
+	}
+
+	if stopErr := s.stopTrader(c, ownerID, at); stopErr != nil {
+		requestLog(c).Warn("管理员停止交易员超时，将在当前操作完成后退出", "trader_id", traderID, "owner", ownerID)
+		c.JSON(http.StatusOK, MessageResponse{Message: "交易员正在停止，当前操作完成后退出"})
+		return
+	}
+	requestLog(c).Info("管理员停止了交易员", "trader_id", traderID, "owner", ownerID, "name", at.GetName())
+	c.JSON(http.StatusOK, MessageResponse{Message: "交易员已停止"})
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"nofx/auth"
+	"nofx/config"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errAccountDisabled 被禁用的账户登录或访问时的提示
+const errAccountDisabled = "账户已被禁用，请联系管理员"
+
+// decisionLogSize 交易员决策日志占用的磁盘空间（字节）
+func decisionLogSize(traderID string) int64 {
+	var size int64
+	filepath.WalkDir(filepath.Join("decision_logs", traderID), func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// adminUserSummary 用户信息加资源占用
+func (s *Server) adminUserSummary(user *config.User, usage *config.UserUsage) AdminUserSummary {
+	summary := AdminUserSummary{
+		ID:          user.ID,
+		Email:       user.Email,
+		Role:        user.Role,
+		Disabled:    user.Disabled,
+		OTPVerified: user.OTPVerified,
+		CreatedAt:   user.CreatedAt,
+	}
+	if usage != nil {
+		summary.UserUsage = *usage
+	}
+	if traders, err := s.database.GetTraders(user.ID); err == nil {
+		for _, t := range traders {
+			summary.DecisionLogBytes += decisionLogSize(t.ID)
+		}
+	}
+	return summary
+}
+
+// handleListUsers 所有用户、角色、状态和资源占用
+func (s *Server) handleListUsers(c *gin.Context) {
+	users, err := s.database.ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取用户列表失败: %v", err)})
+		return
+	}
+	usage, err := s.database.GetUserUsage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("统计资源占用失败: %v", err)})
+		return
+	}
+
+	result := make([]AdminUserSummary, 0, len(users))
+	for _, user := range users {
+		result = append(result, s.adminUserSummary(user, usage[user.ID]))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// handleGetUser 单个用户的详情：交易员列表和生效的上限
+func (s *Server) handleGetUser(c *gin.Context) {
+	user, err := s.database.GetUserByID(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+		return
+	}
+	usage, err := s.database.GetUserUsage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("统计资源占用失败: %v", err)})
+		return
+	}
+	traders, err := s.database.GetTraders(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员列表失败: %v", err)})
+		return
+	}
+
+	detail := AdminUserDetail{
+		AdminUserSummary: s.adminUserSummary(user, usage[user.ID]),
+		TraderList:       make([]TraderSummary, 0, len(traders)),
+	}
+	for _, t := range traders {
+		detail.TraderList = append(detail.TraderList, TraderSummary{
+			TraderID:       t.ID,
+			TraderName:     t.Name,
+			AIModel:        t.AIModelID,
+			ExchangeID:     t.ExchangeID,
+			IsRunning:      t.IsRunning,
+			InitialBalance: t.InitialBalance,
+		})
+	}
+	if limits, err := s.database.GetEffectiveLimits(user.ID); err == nil {
+		detail.Limits = limits
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// adminTargetUser 管理员操作的目标用户，不允许操作自己
+func (s *Server) adminTargetUser(c *gin.Context) (*config.User, bool) {
+	targetID := c.Param("user_id")
+	if targetID == c.GetString("user_id") {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "不能对自己执行此操作"})
+		return nil, false
+	}
+	user, err := s.database.GetUserByID(targetID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+		return nil, false
+	}
+	return user, true
+}
+
+// handleDisableUser 禁用账户并停止其运行中的交易员（用户已无法登录自行停止）
+func (s *Server) handleDisableUser(c *gin.Context) {
+	user, ok := s.adminTargetUser(c)
+	if !ok {
+		return
+	}
+	if err := s.database.SetUserDisabled(user.ID, true); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("禁用账户失败: %v", err)})
+		return
+	}
+
+	stopped := 0
+	if traders, err := s.database.GetTraders(user.ID); err == nil {
+		for _, t := range traders {
+			at, err := s.traderManager.GetTrader(t.ID)
+			if err != nil {
+				continue
+			}
+			if running, ok := at.GetStatus()["is_running"].(bool); !ok || !running {
+				continue
+			}
+			at.Stop()
+			if err := s.database.UpdateTraderStatus(user.ID, t.ID, false); err != nil {
+				at.Logger().Warn("更新交易员状态失败", "error", err)
+			}
+			stopped++
+		}
+	}
+
+	requestLog(c).Info("账户已被禁用", "target_user_id", user.ID, "email", user.Email, "stopped", stopped)
+	c.JSON(http.StatusOK, MessageResponse{Message: fmt.Sprintf("账户已禁用，停止了%d个运行中的交易员", stopped)})
+}
+
+// handleEnableUser 重新启用账户（交易员需用户自行启动）
+func (s *Server) handleEnableUser(c *gin.Context) {
+	user, ok := s.adminTargetUser(c)
+	if !ok {
+		return
+	}
+	if err := s.database.SetUserDisabled(user.ID, false); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("启用账户失败: %v", err)})
+		return
+	}
+	requestLog(c).Info("账户已重新启用", "target_user_id", user.ID, "email", user.Email)
+	c.JSON(http.StatusOK, MessageResponse{Message: "账户已启用"})
+}
+
+// handleAdminResetOTP 重置用户的验证器，用户下次用密码登录时重新绑定
+func (s *Server) handleAdminResetOTP(c *gin.Context) {
+	user, ok := s.adminTargetUser(c)
+	if !ok {
+		return
+	}
+
+	secret, err := auth.GenerateOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "OTP密钥生成失败"})
+		return
+	}
+	if err := s.database.ResetUserOTP(user.ID, secret); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("重置OTP失败: %v", err)})
+		return
+	}
+
+	requestLog(c).Info("管理员重置了用户验证器", "target_user_id", user.ID, "email", user.Email)
+	c.JSON(http.StatusOK, MessageResponse{Message: "验证器已重置，用户下次登录时需重新绑定"})
+}
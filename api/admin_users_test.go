@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nofx/auth"
+	"nofx/config"
+	"nofx/manager"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newAdminTestServer 带临时数据库的服务器，包含管理员 boss 和普通用户 alice
+func newAdminTestServer(t *testing.T) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	auth.SetJWTSecret("test-secret")
+
+	database, err := config.NewDatabase(filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, u := range []*config.User{
+		{ID: "boss", Email: "boss@test.com", Role: config.RoleAdmin, OTPVerified: true},
+		{ID: "alice", Email: "alice@test.com", OTPVerified: true},
+	} {
+		if err := database.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &Server{router: gin.New(), database: database, traderManager: manager.NewTraderManager(), wsHub: newWSHub(nil)}
+	s.setupRoutes()
+	return s
+}
+
+func doAs(t *testing.T, s *Server, userID, method, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	return doAsWithBody(t, s, userID, method, path, "")
+}
+
+// doAsWithBody 以指定用户身份发送带JSON请求体的请求
+func doAsWithBody(t *testing.T, s *Server, userID, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	user, err := s.database.GetUserByID(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := auth.GenerateJWT(user.ID, user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminDisableUser(t *testing.T) {
+	s := newAdminTestServer(t)
+
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/admin/users"); w.Code != http.StatusForbidden {
+		t.Fatalf("普通用户访问管理接口应返回403, got %d", w.Code)
+	}
+	if w := doAs(t, s, "boss", http.MethodPost, "/api/admin/users/boss/disable"); w.Code != http.StatusBadRequest {
+		t.Errorf("管理员不能禁用自己, got %d", w.Code)
+	}
+
+	if w := doAs(t, s, "boss", http.MethodPost, "/api/admin/users/alice/disable"); w.Code != http.StatusOK {
+		t.Fatalf("禁用失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/user/limits"); w.Code != http.StatusForbidden {
+		t.Errorf("禁用后已签发的token应立即失效, got %d", w.Code)
+	}
+
+	if w := doAs(t, s, "boss", http.MethodPost, "/api/admin/users/alice/enable"); w.Code != http.StatusOK {
+		t.Fatalf("启用失败: %d", w.Code)
+	}
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/user/limits"); w.Code != http.StatusOK {
+		t.Errorf("重新启用后应可访问, got %d", w.Code)
+	}
+}
+
+func TestAdminResetOTP(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.ReplaceRecoveryCodes("alice", []string{"h1", "h2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doAs(t, s, "boss", http.MethodPost, "/api/admin/users/alice/reset-otp"); w.Code != http.StatusOK {
+		t.Fatalf("重置OTP失败: %d %s", w.Code, w.Body.String())
+	}
+	user, _ := s.database.GetUserByID("alice")
+	if user.OTPVerified || user.OTPSecret == "" {
+		t.Errorf("重置后应换新密钥并要求重新绑定: verified=%v", user.OTPVerified)
+	}
+	if n, _ := s.database.CountRecoveryCodes("alice"); n != 0 {
+		t.Errorf("重置后旧恢复码应作废, 剩余 %d", n)
+	}
+}
+
+func TestGetUserUsage(t *testing.T) {
+	s := newAdminTestServer(t)
+	for i, running := range []bool{true, false} {
+		if err := s.database.CreateTrader(&config.TraderRecord{
+			ID: []string{"t1", "t2"}[i], UserID: "alice", Name: "t", AIModelID: "deepseek", ExchangeID: "binance", IsRunning: running,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := s.database.GetUserUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := usage["alice"]; u == nil || u.Traders != 2 || u.RunningTraders != 1 {
+		t.Errorf("alice 的统计错误: %+v", u)
+	}
+	if usage["boss"] != nil {
+		t.Errorf("没有交易员的用户不应出现在统计中")
+	}
+}
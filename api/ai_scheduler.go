@@ -0,0 +1,12 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetAISchedulerStats 各AI密钥进行中和排队的调用数（管理员）
+func (s *Server) handleGetAISchedulerStats(c *gin.Context) {
+	c.JSON(http.StatusOK, s.traderManager.GetAISchedulerStats())
+}
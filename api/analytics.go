@@ -0,0 +1,12 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleModelAnalytics 按AI模型汇总全部交易员的表现（无需认证）
+func (s *Server) handleModelAnalytics(c *gin.Context) {
+	c.JSON(http.StatusOK, s.traderManager.GetModelAnalytics())
+}
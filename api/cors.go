@@ -0,0 +1,111 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"nofx/config"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsOriginsEnv 覆盖系统配置 cors_allowed_origins 的环境变量
+const corsOriginsEnv = "NOFX_CORS_ORIGINS"
+
+// corsOrigins 允许跨域访问的来源白名单
+type corsOrigins struct {
+	any     bool            // 包含 *，允许任意来源（不允许携带凭证）
+	origins map[string]bool // 规范化后的 scheme://host[:port]
+}
+
+// parseCORSOrigins 解析逗号分隔的来源列表
+func parseCORSOrigins(value string) (corsOrigins, error) {
+	normalized, err := config.ValidateSystemConfig("cors_allowed_origins", value)
+	if err != nil {
+		return corsOrigins{}, err
+	}
+	o := corsOrigins{origins: make(map[string]bool)}
+	for _, origin := range strings.Split(normalized, ",") {
+		if origin == "*" {
+			o.any = true
+		} else {
+			o.origins[origin] = true
+		}
+	}
+	return o, nil
+}
+
+// loadCORSOrigins 环境变量优先，其次系统配置；都未配置或无效时允许任意来源
+func loadCORSOrigins(database *config.Database) corsOrigins {
+	value, source := os.Getenv(corsOriginsEnv), corsOriginsEnv
+	if value == "" {
+		value, _ = database.GetSystemConfig("cors_allowed_origins")
+		source = "cors_allowed_origins"
+	}
+	if value == "" {
+		return corsOrigins{any: true}
+	}
+	o, err := parseCORSOrigins(value)
+	if err != nil {
+		slog.Warn("跨域来源配置无效，允许任意来源", "source", source, "error", err)
+		return corsOrigins{any: true}
+	}
+	if o.any {
+		slog.Warn("CORS允许任意来源，生产环境建议配置环境变量或 cors_allowed_origins", "env", corsOriginsEnv)
+	}
+	return o
+}
+
+// allowed 来源是否在白名单中
+func (o corsOrigins) allowed(origin string) bool {
+	return o.any || o.origins[strings.ToLower(origin)]
+}
+
+// listed 来源是否在白名单中被明确列出（不含 * 通配）
+func (o corsOrigins) listed(origin string) bool {
+	return o.origins[strings.ToLower(origin)]
+}
+
+// checkWebSocketOrigin WebSocket握手的来源校验：与CORS白名单一致，另外放行同源和无Origin的非浏览器客户端
+func (o corsOrigins) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || o.allowed(origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// corsMiddleware CORS中间件：白名单内的来源回显并允许携带凭证，其他来源不返回CORS头，预检请求直接拒绝
+func corsMiddleware(origins corsOrigins) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		header := c.Writer.Header()
+		switch {
+		case origins.any:
+			header.Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && origins.allowed(origin):
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !origins.any {
+			header.Add("Vary", "Origin")
+		}
+		header.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		header.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+requestIDHeader)
+		header.Set("Access-Control-Expose-Headers", requestIDHeader)
+
+		if c.Request.Method == "OPTIONS" {
+			if origin != "" && !origins.allowed(origin) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.AbortWithStatus(http.StatusOK)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseCORSOrigins(t *testing.T) {
+	o, err := parseCORSOrigins(" https://App.example.com/ , http://localhost:3000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.any || !o.allowed("https://app.example.com") || !o.allowed("http://localhost:3000") || o.allowed("https://evil.com") {
+		t.Errorf("白名单解析错误: %+v", o)
+	}
+	for _, bad := range []string{"", "example.com", "https://example.com/path", "ftp://example.com"} {
+		if _, err := parseCORSOrigins(bad); err == nil {
+			t.Errorf("%q 应校验失败", bad)
+		}
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	request := func(origins corsOrigins, method, origin string) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(corsMiddleware(origins))
+		r.GET("/x", func(c *gin.Context) { c.Status(http.StatusOK) })
+		req := httptest.NewRequest(method, "/x", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 默认允许任意来源，不允许携带凭证
+	w := request(corsOrigins{any: true}, http.MethodGet, "https://a.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("通配模式响应头错误: %v", w.Header())
+	}
+
+	whitelist, _ := parseCORSOrigins("https://app.example.com")
+	w = request(whitelist, http.MethodGet, "https://app.example.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || w.Header().Get("Access-Control-Allow-Credentials") != "true" || w.Header().Get("Vary") != "Origin" {
+		t.Errorf("白名单来源应回显并允许凭证: %v", w.Header())
+	}
+	w = request(whitelist, http.MethodGet, "https://evil.com")
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("非白名单来源不应返回CORS头: %d %v", w.Code, w.Header())
+	}
+	if w := request(whitelist, http.MethodOptions, "https://evil.com"); w.Code != http.StatusForbidden {
+		t.Errorf("非白名单来源的预检请求应拒绝: %d", w.Code)
+	}
+	if w := request(whitelist, http.MethodOptions, "https://app.example.com"); w.Code != http.StatusOK {
+		t.Errorf("白名单来源的预检请求应放行: %d", w.Code)
+	}
+}
+
+func TestCheckWebSocketOrigin(t *testing.T) {
+	whitelist, _ := parseCORSOrigins("https://app.example.com")
+	check := func(origin string) bool {
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/ws", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		return whitelist.checkWebSocketOrigin(req)
+	}
+	if !check("") || !check("https://app.example.com") || !check("http://api.example.com") {
+		t.Error("无Origin、白名单和同源的握手应放行")
+	}
+	if check("https://evil.com") {
+		t.Error("非白名单来源的握手应拒绝")
+	}
+}
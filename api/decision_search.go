@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/logger"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSearchLimit 未指定 limit 时返回的记录数
+const defaultSearchLimit = 50
+
+// handleSearchDecisions 检索交易员的思维链和决策理由（?q=funding&from=&to=&limit=）
+// q 中空格分隔的关键词需全部出现，双引号括起的短语作为一个词
+func (s *Server) handleSearchDecisions(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+	if !s.canViewTrader(userID, traderID) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	terms := logger.ParseSearchTerms(c.Query("q"))
+	if len(terms) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "缺少检索关键词 q"})
+		return
+	}
+	from, err := parseExportTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("无效的from参数: %v", err)})
+		return
+	}
+	to, err := parseExportTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("无效的to参数: %v", err)})
+		return
+	}
+	limit := defaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxOrderLimit {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit 必须在 1-" + strconv.Itoa(maxOrderLimit) + " 之间"})
+			return
+		}
+		limit = n
+	}
+
+	// 交易员未运行时也可以检索历史记录
+	hits, err := logger.NewDecisionLogger(filepath.Join("decision_logs", traderID)).Search(terms, from, to, limit)
+	if err != nil {
+		requestLog(c).Error("检索决策记录失败", "trader_id", traderID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("检索决策记录失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, DecisionSearchResponse{Terms: terms, Results: hits})
+}
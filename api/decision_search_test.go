@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"nofx/logger"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSearchDecisions(t *testing.T) {
+	s := newAdminTestServer(t)
+	logger.SetSearchIndex(s.database)
+	t.Cleanup(func() { logger.SetSearchIndex(nil) })
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "search_t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join("decision_logs", "search_t1")
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	l := logger.NewDecisionLogger(dir)
+	l.LogDecision(&logger.DecisionRecord{CoTTrace: "资金费率过高，funding 会吃掉利润"})
+	l.LogDecision(&logger.DecisionRecord{CoTTrace: "趋势延续"})
+
+	w := doAs(t, s, "alice", http.MethodGet, "/api/traders/search_t1/decisions/search?q=funding")
+	var resp DecisionSearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("检索失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(resp.Results) != 1 || resp.Results[0].CycleNumber != 1 || len(resp.Results[0].Snippets) != 1 {
+		t.Errorf("检索结果错误: %+v", resp.Results)
+	}
+
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/traders/search_t1/decisions/search"); w.Code != http.StatusBadRequest {
+		t.Errorf("缺少关键词应返回400: %d", w.Code)
+	}
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/traders/search_t1/decisions/search?q=x&limit=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("无效limit应返回400: %d", w.Code)
+	}
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/traders/missing/decisions/search?q=funding"); w.Code != http.StatusNotFound {
+		t.Errorf("不存在的交易员应返回404: %d", w.Code)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/digest"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dailyDigestSettings 当前用户的订阅状态
+func (s *Server) dailyDigestSettings(userID string) (DailyDigestSettings, error) {
+	enabled, err := s.database.GetDailyDigest(userID)
+	if err != nil {
+		return DailyDigestSettings{}, err
+	}
+	hour := 0
+	if v, err := s.database.GetSystemConfig("daily_digest_hour"); err == nil {
+		hour, _ = strconv.Atoi(v)
+	}
+	return DailyDigestSettings{Enabled: enabled, Hour: hour}, nil
+}
+
+// handleGetDailyDigest 每日邮件摘要订阅状态
+func (s *Server) handleGetDailyDigest(c *gin.Context) {
+	settings, err := s.dailyDigestSettings(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取摘要订阅失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// handleUpdateDailyDigest 开启或关闭每日邮件摘要
+func (s *Server) handleUpdateDailyDigest(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req UpdateDailyDigestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := s.database.SetDailyDigest(userID, *req.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("更新摘要订阅失败: %v", err)})
+		return
+	}
+
+	settings, err := s.dailyDigestSettings(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取摘要订阅失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// handlePreviewDailyDigest 按最近一个完整UTC日生成摘要（不发送邮件）
+func (s *Server) handlePreviewDailyDigest(c *gin.Context) {
+	from, to := digest.LastDay(time.Now())
+	report, err := digest.Build(s.database, s.traderManager, c.GetString("user_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("生成摘要失败: %v", err)})
+		return
+	}
+	subject, body := digest.Render(report)
+	c.JSON(http.StatusOK, DailyDigestPreview{Subject: subject, Body: body, Report: report})
+}
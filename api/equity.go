@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/logger"
+	"nofx/trader"
+	"sort"
+	"time"
+)
+
+// equityGranularities granularity 参数支持的聚合粒度
+var equityGranularities = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// parseEquityGranularity 解析 granularity 参数，为空或 raw 时返回0（不聚合）
+func parseEquityGranularity(value string) (time.Duration, error) {
+	if value == "" || value == "raw" {
+		return 0, nil
+	}
+	bucket, ok := equityGranularities[value]
+	if !ok {
+		return 0, fmt.Errorf("无效的granularity参数: %s（可选 raw、1m、5m、1h、1d）", value)
+	}
+	return bucket, nil
+}
+
+// equityBucket 时间点所在区间的起点，按天聚合时以本地零点为界
+func equityBucket(t time.Time, bucket time.Duration) time.Time {
+	if bucket >= 24*time.Hour {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+	return t.Truncate(bucket)
+}
+
+// downsampleEquity 按时间区间聚合净值点，每个区间保留最后一个点（区间收盘净值），points 需按时间正序
+func downsampleEquity(points []logger.ArchivedEquity, bucket time.Duration) []logger.ArchivedEquity {
+	if bucket <= 0 || len(points) == 0 {
+		return points
+	}
+	result := make([]logger.ArchivedEquity, 0, len(points))
+	var current time.Time
+	for _, p := range points {
+		start := equityBucket(p.Timestamp, bucket)
+		if len(result) > 0 && start.Equal(current) {
+			result[len(result)-1] = p
+			continue
+		}
+		current = start
+		result = append(result, p)
+	}
+	return result
+}
+
+// equityHistory 从决策日志和定时净值采样生成交易员的收益率历史，bucket > 0 时按时间区间聚合
+func (s *Server) equityHistory(at *trader.AutoTrader, bucket time.Duration) ([]EquityPoint, error) {
+	// 获取尽可能多的历史数据（几天的数据）
+	// 每3分钟一个周期：10000条 = 约20天的数据
+	records, err := at.GetDecisionLogger().GetLatestRecords(10000)
+	if err != nil {
+		return nil, fmt.Errorf("获取历史数据失败: %w", err)
+	}
+
+	// 已清理的旧记录只保留按小时降采样的净值，放在原始记录之前
+	archive, err := at.GetDecisionLogger().GetEquityArchive()
+	if err != nil {
+		return nil, fmt.Errorf("读取净值归档失败: %w", err)
+	}
+	points := make([]logger.ArchivedEquity, 0, len(archive)+len(records))
+	for _, p := range archive {
+		if len(records) == 0 || p.Timestamp.Before(records[0].Timestamp) {
+			points = append(points, p)
+		}
+	}
+	for _, record := range records {
+		points = append(points, logger.ArchivedEquity{Timestamp: record.Timestamp, CycleNumber: record.CycleNumber, AccountState: record.AccountState})
+	}
+
+	// 决策周期之间（以及AI循环停止或出错期间）的定时采样，只合并归档之后的部分
+	var since time.Time
+	if len(archive) > 0 {
+		since = archive[len(archive)-1].Timestamp
+	}
+	samples, err := s.database.GetEquitySamples(at.GetID(), since)
+	if err != nil {
+		return nil, fmt.Errorf("读取净值采样失败: %w", err)
+	}
+	for _, data := range samples {
+		var sample trader.EquitySample
+		if err := json.Unmarshal(data, &sample); err != nil {
+			continue
+		}
+		points = append(points, logger.ArchivedEquity{Timestamp: sample.Timestamp.Local(), CycleNumber: sample.CycleNumber, AccountState: sample.AccountState})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Timestamp.Before(points[j].Timestamp) })
+
+	// 从AutoTrader获取初始余额（用于计算盈亏百分比）
+	initialBalance := 0.0
+	if status := at.GetStatus(); status != nil {
+		if ib, ok := status["initial_balance"].(float64); ok && ib > 0 {
+			initialBalance = ib
+		}
+	}
+
+	// 如果无法从status获取，且有历史记录，则从第一条记录获取
+	if initialBalance == 0 && len(points) > 0 {
+		// 第一条记录的equity作为初始余额
+		initialBalance = points[0].AccountState.TotalBalance
+	}
+
+	// 如果还是无法获取，返回错误
+	if initialBalance == 0 {
+		return nil, fmt.Errorf("无法获取初始余额")
+	}
+
+	var history []EquityPoint
+	for _, record := range downsampleEquity(points, bucket) {
+		// TotalBalance字段实际存储的是TotalEquity
+		totalEquity := record.AccountState.TotalBalance
+		// TotalUnrealizedProfit字段实际存储的是TotalPnL（相对初始余额）
+		totalPnL := record.AccountState.TotalUnrealizedProfit
+
+		// 计算盈亏百分比
+		totalPnLPct := 0.0
+		if initialBalance > 0 {
+			totalPnLPct = (totalPnL / initialBalance) * 100
+		}
+
+		history = append(history, EquityPoint{
+			Timestamp:        record.Timestamp.Format("2006-01-02 15:04:05"),
+			TotalEquity:      totalEquity,
+			AvailableBalance: record.AccountState.AvailableBalance,
+			TotalPnL:         totalPnL,
+			TotalPnLPct:      totalPnLPct,
+			PositionCount:    record.AccountState.PositionCount,
+			MarginUsedPct:    record.AccountState.MarginUsedPct,
+			CycleNumber:      record.CycleNumber,
+		})
+	}
+
+	return history, nil
+}
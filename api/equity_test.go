@@ -0,0 +1,47 @@
+package api
+
+import (
+	"nofx/logger"
+	"testing"
+	"time"
+)
+
+func TestDownsampleEquity(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local)
+	var points []logger.ArchivedEquity
+	for i := 0; i < 12; i++ {
+		points = append(points, logger.ArchivedEquity{
+			Timestamp:    start.Add(time.Duration(i) * 20 * time.Minute),
+			CycleNumber:  i,
+			AccountState: logger.AccountSnapshot{TotalBalance: float64(1000 + i)},
+		})
+	}
+
+	// 10:00-13:40 按小时聚合为4个点，每小时保留最后一个
+	hourly := downsampleEquity(points, time.Hour)
+	if len(hourly) != 4 {
+		t.Fatalf("应聚合为4个点，实际 %d 个", len(hourly))
+	}
+	for i, p := range hourly {
+		if want := 3*i + 2; p.CycleNumber != want {
+			t.Errorf("第%d个区间应保留周期 %d，实际 %d", i, want, p.CycleNumber)
+		}
+	}
+	if daily := downsampleEquity(points, 24*time.Hour); len(daily) != 1 || daily[0].AccountState.TotalBalance != 1011 {
+		t.Errorf("按天聚合错误: %+v", daily)
+	}
+	if raw := downsampleEquity(points, 0); len(raw) != len(points) {
+		t.Errorf("不聚合时应返回全部点")
+	}
+}
+
+func TestParseEquityGranularity(t *testing.T) {
+	for value, want := range map[string]time.Duration{"": 0, "raw": 0, "5m": 5 * time.Minute, "1d": 24 * time.Hour} {
+		if got, err := parseEquityGranularity(value); err != nil || got != want {
+			t.Errorf("parseEquityGranularity(%q) = %v, %v", value, got, err)
+		}
+	}
+	if _, err := parseEquityGranularity("2h"); err == nil {
+		t.Error("不支持的粒度应返回错误")
+	}
+}
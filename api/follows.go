@@ -0,0 +1,238 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"nofx/config"
+	"nofx/manager"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// followInfo 附加领单交易员名称和运行状态
+func (s *Server) followInfo(f *config.Follow) FollowInfo {
+	info := FollowInfo{Follow: f}
+	if leader, err := s.traderManager.GetTrader(f.LeaderID); err == nil {
+		info.LeaderName = leader.GetName()
+	}
+	if status, ok := s.traderManager.GetFollowerStatus(f.ID); ok {
+		info.Status = &status
+	}
+	return info
+}
+
+// applyFollowLimits 校验倍数和风控上限，未设置的杠杆和仓位上限沿用管理员上限
+func (s *Server) applyFollowLimits(userID string, f *config.Follow, req FollowLimits) (int, error) {
+	if req.Multiplier <= 0 {
+		return http.StatusBadRequest, fmt.Errorf("跟单倍数必须大于0")
+	}
+	if req.MaxLeverage < 0 || req.MaxPositionUSD < 0 || req.MaxPositions < 0 {
+		return http.StatusBadRequest, fmt.Errorf("风控上限不能为负数")
+	}
+	if req.MaxDrawdownPct < 0 || req.MaxDrawdownPct >= 100 {
+		return http.StatusBadRequest, fmt.Errorf("最大回撤必须在0-100%%之间")
+	}
+
+	limits, err := s.database.GetEffectiveLimits(userID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if limits.MaxLeverage > 0 {
+		if req.MaxLeverage > limits.MaxLeverage {
+			return http.StatusForbidden, fmt.Errorf("杠杆上限不能超过管理员设置的 %d 倍", limits.MaxLeverage)
+		}
+		if req.MaxLeverage == 0 {
+			req.MaxLeverage = limits.MaxLeverage
+		}
+	}
+	if limits.MaxNotional > 0 {
+		if req.MaxPositionUSD > limits.MaxNotional {
+			return http.StatusForbidden, fmt.Errorf("单仓位上限不能超过管理员设置的 %.0f USDT", limits.MaxNotional)
+		}
+		if req.MaxPositionUSD == 0 {
+			req.MaxPositionUSD = limits.MaxNotional
+		}
+	}
+
+	f.Multiplier = req.Multiplier
+	f.MaxLeverage = req.MaxLeverage
+	f.MaxPositionUSD = req.MaxPositionUSD
+	f.MaxPositions = req.MaxPositions
+	f.MaxDrawdownPct = req.MaxDrawdownPct
+	if req.Enabled != nil {
+		f.Enabled = *req.Enabled
+	}
+	return http.StatusOK, nil
+}
+
+// startFollow 连接跟随者的交易所账户并开始跟单
+func (s *Server) startFollow(f *config.Follow) error {
+	exchanges, err := s.database.GetExchanges(f.UserID)
+	if err != nil {
+		return fmt.Errorf("获取交易所配置失败: %w", err)
+	}
+	for _, e := range exchanges {
+		if e.ID == f.ExchangeID && e.Enabled {
+			exchange, err := manager.NewFollowExchange(f, e)
+			if err != nil {
+				return fmt.Errorf("连接交易所失败: %w", err)
+			}
+			return s.traderManager.StartFollow(f, exchange)
+		}
+	}
+	return fmt.Errorf("交易所 %s 未配置或未启用", f.ExchangeID)
+}
+
+// handleListFollows 当前用户的跟单
+func (s *Server) handleListFollows(c *gin.Context) {
+	follows, err := s.database.GetFollows(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取跟单列表失败: %v", err)})
+		return
+	}
+	infos := make([]FollowInfo, 0, len(follows))
+	for _, f := range follows {
+		infos = append(infos, s.followInfo(f))
+	}
+	c.JSON(http.StatusOK, infos)
+}
+
+// handleCreateFollow 用自己的交易所账户跟随其他交易员
+// 一个交易所账户只能被一个交易员或一个跟单使用，否则持仓会互相干扰
+func (s *Server) handleCreateFollow(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req CreateFollowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	follow := &config.Follow{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		LeaderID:   req.LeaderID,
+		ExchangeID: req.ExchangeID,
+		Enabled:    true,
+		CreatedAt:  time.Now(),
+	}
+	if status, err := s.applyFollowLimits(userID, follow, req.FollowLimits); err != nil {
+		c.JSON(status, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if _, err := s.traderManager.GetTrader(req.LeaderID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "领单交易员不存在"})
+		return
+	}
+
+	if req.ExchangeID == "paper" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "跟单需要使用真实交易所账户"})
+		return
+	}
+	exchanges, err := s.database.GetExchanges(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易所配置失败: %v", err)})
+		return
+	}
+	configured := false
+	for _, e := range exchanges {
+		if e.ID == req.ExchangeID && e.Enabled {
+			configured = true
+		}
+	}
+	if !configured {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("交易所 %s 未配置或未启用", req.ExchangeID)})
+		return
+	}
+
+	traders, err := s.database.GetTraders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员列表失败: %v", err)})
+		return
+	}
+	for _, t := range traders {
+		if t.ExchangeID == req.ExchangeID {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("交易员 %s 正在使用该交易所账户", t.Name)})
+			return
+		}
+	}
+	follows, err := s.database.GetFollows(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取跟单列表失败: %v", err)})
+		return
+	}
+	for _, f := range follows {
+		if f.ExchangeID == req.ExchangeID {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "该交易所账户已用于其他跟单"})
+			return
+		}
+	}
+
+	if follow.Enabled {
+		if err := s.startFollow(follow); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if err := s.database.CreateFollow(follow); err != nil {
+		s.traderManager.StopFollow(follow.ID)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存跟单失败: %v", err)})
+		return
+	}
+
+	requestLog(c).Info("用户开始跟随交易员", "leader_id", req.LeaderID, "exchange_id", req.ExchangeID, "multiplier", follow.Multiplier)
+	c.JSON(http.StatusOK, s.followInfo(follow))
+}
+
+// handleUpdateFollow 修改跟单倍数和风控上限，enabled=false 暂停跟单
+func (s *Server) handleUpdateFollow(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req FollowLimits
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	follow, err := s.database.GetFollow(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "跟单不存在"})
+		return
+	}
+	if status, err := s.applyFollowLimits(userID, follow, req); err != nil {
+		c.JSON(status, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if !follow.Enabled {
+		s.traderManager.StopFollow(follow.ID)
+	} else if !s.traderManager.UpdateFollow(follow) {
+		if err := s.startFollow(follow); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if err := s.database.UpdateFollow(follow); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("更新跟单失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, s.followInfo(follow))
+}
+
+// handleDeleteFollow 取消跟单，跟随者已有持仓保留由用户自行处理
+func (s *Server) handleDeleteFollow(c *gin.Context) {
+	id := c.Param("id")
+	if err := s.database.DeleteFollow(c.GetString("user_id"), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "跟单不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("删除跟单失败: %v", err)})
+		return
+	}
+	s.traderManager.StopFollow(id)
+	c.JSON(http.StatusOK, MessageResponse{Message: "已取消跟单"})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"testing"
+)
+
+func TestFollowValidation(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.SetUserLimits(&config.UserLimits{UserID: "alice", MaxLeverage: 5}); err != nil {
+		t.Fatal(err)
+	}
+	body := func(req CreateFollowRequest) string {
+		b, _ := json.Marshal(req)
+		return string(b)
+	}
+
+	cases := []struct {
+		name string
+		req  CreateFollowRequest
+		code int
+	}{
+		{"倍数必须为正", CreateFollowRequest{LeaderID: "x", ExchangeID: "binance", FollowLimits: FollowLimits{Multiplier: -1}}, http.StatusBadRequest},
+		{"回撤上限越界", CreateFollowRequest{LeaderID: "x", ExchangeID: "binance", FollowLimits: FollowLimits{Multiplier: 1, MaxDrawdownPct: 100}}, http.StatusBadRequest},
+		{"杠杆超过管理员上限", CreateFollowRequest{LeaderID: "x", ExchangeID: "binance", FollowLimits: FollowLimits{Multiplier: 1, MaxLeverage: 10}}, http.StatusForbidden},
+		{"领单交易员不存在", CreateFollowRequest{LeaderID: "x", ExchangeID: "binance", FollowLimits: FollowLimits{Multiplier: 1}}, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if w := doAsWithBody(t, s, "alice", http.MethodPost, "/api/follows", body(tc.req)); w.Code != tc.code {
+			t.Errorf("%s: 期望 %d，实际 %d %s", tc.name, tc.code, w.Code, w.Body.String())
+		}
+	}
+
+	w := doAs(t, s, "alice", http.MethodGet, "/api/follows")
+	var follows []FollowInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &follows); err != nil || w.Code != http.StatusOK || len(follows) != 0 {
+		t.Errorf("不应创建任何跟单: %d %s", w.Code, w.Body.String())
+	}
+	if w := doAsWithBody(t, s, "alice", http.MethodPut, "/api/follows/nope", `{"multiplier":1}`); w.Code != http.StatusNotFound {
+		t.Errorf("修改不存在的跟单应返回404: %d", w.Code)
+	}
+	if w := doAs(t, s, "alice", http.MethodDelete, "/api/follows/nope"); w.Code != http.StatusNotFound {
+		t.Errorf("删除不存在的跟单应返回404: %d", w.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"nofx/health"
+	"nofx/market"
+	"nofx/mcp"
+	"nofx/trader"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthDBTimeout 数据库探测超时，避免健康检查被卡住的连接拖住
+const healthDBTimeout = 2 * time.Second
+
+// handleHealth 健康检查：只有unhealthy返回503，degraded仍返回200，避免AI或交易所抖动导致实例被负载均衡摘除
+func (s *Server) handleHealth(c *gin.Context) {
+	resp := s.checkHealth(c.Request.Context())
+	code := http.StatusOK
+	if resp.Status == health.StatusUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, resp)
+}
+
+// checkHealth 汇总各依赖状态：数据库不可用为unhealthy；行情过期或AI/交易所最近调用失败为degraded
+func (s *Server) checkHealth(ctx context.Context) HealthResponse {
+	resp := HealthResponse{
+		Time:        time.Now(),
+		Database:    s.checkDatabase(ctx),
+		MarketFeed:  checkMarketFeed(market.GetFeedStatus(), market.GetStaleThreshold()),
+		AIProviders: mcp.ProviderHealth.Snapshot(),
+		Exchanges:   trader.ExchangeHealth.Snapshot(),
+	}
+
+	statuses := []string{resp.Database.Status, resp.MarketFeed.Status}
+	for _, p := range resp.AIProviders {
+		statuses = append(statuses, p.Status)
+	}
+	for _, e := range resp.Exchanges {
+		statuses = append(statuses, e.Status)
+	}
+	resp.Status = health.Worst(statuses...)
+	return resp
+}
+
+// checkDatabase 数据库连通性；接口公开，错误详情只写日志
+func (s *Server) checkDatabase(ctx context.Context) HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthDBTimeout)
+	defer cancel()
+	if err := s.database.Ping(ctx); err != nil {
+		slog.Error("健康检查: 数据库不可用", "error", err)
+		return HealthCheck{Status: health.StatusUnhealthy, Message: "数据库不可用"}
+	}
+	return HealthCheck{Status: health.StatusOK}
+}
+
+// checkMarketFeed 行情新鲜度：超过一半币种过期或整体超过阈值未更新视为degraded
+func checkMarketFeed(feed market.FeedStatus, staleAfter time.Duration) MarketFeedHealth {
+	result := MarketFeedHealth{FeedStatus: feed}
+	switch {
+	case !feed.Running || feed.Symbols == 0:
+		result.Status = health.StatusUnknown
+		result.Message = "行情监控未启动或尚未收到数据"
+	case feed.LatestUpdate == nil || time.Since(*feed.LatestUpdate) > staleAfter:
+		result.Status = health.StatusDegraded
+		result.Message = "行情推送已停止更新"
+	case feed.StaleSymbols*2 > feed.Symbols:
+		result.Status = health.StatusDegraded
+		result.Message = "多数币种行情已过期"
+	default:
+		result.Status = health.StatusOK
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"nofx/health"
+	"nofx/market"
+	"nofx/trader"
+	"testing"
+	"time"
+)
+
+func getHealth(t *testing.T, s *Server) (int, HealthResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	var resp HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("响应不是合法JSON: %s", w.Body.String())
+	}
+	return w.Code, resp
+}
+
+func TestHealthEndpoint(t *testing.T) {
+	s := newAdminTestServer(t)
+
+	code, resp := getHealth(t, s)
+	if code != http.StatusOK || resp.Database.Status != health.StatusOK || resp.MarketFeed.Status != health.StatusUnknown {
+		t.Fatalf("依赖正常时应返回200: %d %+v", code, resp)
+	}
+
+	// 交易所调用失败只降级，不影响负载均衡判断
+	name := "health-test-exchange"
+	trader.ExchangeHealth.Record(name, errors.New("timeout"))
+	t.Cleanup(func() { trader.ExchangeHealth.Record(name, nil) })
+	code, resp = getHealth(t, s)
+	if code != http.StatusOK || resp.Status != health.StatusDegraded {
+		t.Errorf("交易所失败应为degraded且仍返回200: %d %s", code, resp.Status)
+	}
+
+	s.database.Close()
+	code, resp = getHealth(t, s)
+	if code != http.StatusServiceUnavailable || resp.Status != health.StatusUnhealthy || resp.Database.Status != health.StatusUnhealthy {
+		t.Errorf("数据库不可用应返回503: %d %+v", code, resp)
+	}
+}
+
+func TestCheckMarketFeed(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	cases := []struct {
+		feed market.FeedStatus
+		want string
+	}{
+		{market.FeedStatus{}, health.StatusUnknown},
+		{market.FeedStatus{Running: true, Symbols: 4, StaleSymbols: 1, LatestUpdate: &now}, health.StatusOK},
+		{market.FeedStatus{Running: true, Symbols: 4, StaleSymbols: 3, LatestUpdate: &now}, health.StatusDegraded},
+		{market.FeedStatus{Running: true, Symbols: 4, LatestUpdate: &old}, health.StatusDegraded},
+	}
+	for _, tc := range cases {
+		if got := checkMarketFeed(tc.feed, 5*time.Minute).Status; got != tc.want {
+			t.Errorf("%+v: 得到 %s，期望 %s", tc.feed, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requestIDHeader 请求ID头：客户端传入时沿用（便于串联上游日志），否则生成新的
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID 只接受简短的安全字符，避免日志注入
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestLogMiddleware 分配请求ID并输出访问日志（替代gin默认的文本日志）
+func requestLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Set("request_id", requestID)
+		c.Header(requestIDHeader, requestID)
+		c.Writer = &requestIDWriter{ResponseWriter: c.Writer, requestID: requestID}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		requestLog(c).Log(c.Request.Context(), level, "HTTP请求",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"ip", c.ClientIP(),
+		)
+	}
+}
+
+// requestIDWriter 在 ErrorResponse 中补上请求ID，不用修改每个返回错误的地方
+type requestIDWriter struct {
+	gin.ResponseWriter
+	requestID string
+	done      bool
+}
+
+func (w *requestIDWriter) Write(b []byte) (int, error) {
+	if w.done || w.Status() < http.StatusBadRequest || !bytes.HasPrefix(b, []byte(`{"error":`)) {
+		return w.ResponseWriter.Write(b)
+	}
+	w.done = true
+	// 请求ID只含安全字符，可以直接拼进JSON
+	if _, err := w.ResponseWriter.WriteString(`{"request_id":"` + w.requestID + `",`); err != nil {
+		return 0, err
+	}
+	n, err := w.ResponseWriter.Write(b[1:])
+	return n + 1, err
+}
+
+// recoveryMiddleware 处理函数panic时记录堆栈并返回带请求ID的500
+func recoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		requestLog(c).Error("处理请求时发生panic", "panic", recovered, "path", c.Request.URL.Path, "stack", string(debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{Error: "服务器内部错误"})
+	})
+}
+
+// requestLog 带请求ID和当前用户的日志
+func requestLog(c *gin.Context) *slog.Logger {
+	l := slog.With("request_id", c.GetString("request_id"))
+	if userID := c.GetString("user_id"); userID != "" {
+		l = l.With("user_id", userID)
+	}
+	return l
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestLogMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(requestLogMiddleware())
+	var seen string
+	r.GET("/x", func(c *gin.Context) { seen = c.GetString("request_id") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+	if id := w.Header().Get(requestIDHeader); id == "" || id != seen {
+		t.Errorf("应生成请求ID并写入响应头: header=%q context=%q", id, seen)
+	}
+
+	for incoming, keep := range map[string]bool{"upstream-123": true, "bad id\nfake=1": false} {
+		req := httptest.NewRequest(http.MethodGet, "/x", nil)
+		req.Header.Set(requestIDHeader, incoming)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Header().Get(requestIDHeader) == incoming; got != keep {
+			t.Errorf("传入的请求ID %q 沿用=%v，期望 %v", incoming, got, keep)
+		}
+	}
+}
+
+func TestErrorResponseCarriesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(requestLogMiddleware(), recoveryMiddleware())
+	r.GET("/ok", func(c *gin.Context) { c.JSON(http.StatusOK, MessageResponse{Message: "ok"}) })
+	r.GET("/bad", func(c *gin.Context) { c.JSON(http.StatusBadRequest, ErrorResponse{Error: "参数错误"}) })
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	get := func(path string) (*httptest.ResponseRecorder, map[string]string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(requestIDHeader, "req-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s 响应不是合法JSON: %s", path, w.Body.String())
+		}
+		return w, body
+	}
+
+	if _, body := get("/ok"); body["request_id"] != "" {
+		t.Errorf("成功响应不应改动: %v", body)
+	}
+	if w, body := get("/bad"); w.Code != http.StatusBadRequest || body["request_id"] != "req-1" || body["error"] != "参数错误" {
+		t.Errorf("错误响应应带请求ID: %d %v", w.Code, body)
+	}
+	if w, body := get("/panic"); w.Code != http.StatusInternalServerError || body["request_id"] != "req-1" {
+		t.Errorf("panic应返回带请求ID的500: %d %v", w.Code, body)
+	}
+}
@@ -0,0 +1,538 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"nofx/backup"
+	"nofx/config"
+	"nofx/logger"
+	"nofx/logging"
+	"nofx/manager"
+	"nofx/trader"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeDoc 接口说明，用于生成OpenAPI文档和启动时的接口列表
+type routeDoc struct {
+	Summary     string
+	Tag         string
+	Public      bool        // 无需认证
+	Query       []string    // 可选的query参数
+	Request     interface{} // 请求体（类型的零值，nil表示无请求体）
+	Response    interface{} // 成功时的响应体（nil表示无响应体）
+	Status      int         // 成功状态码，默认200
+	ContentType string      // 成功响应的类型，默认application/json
+}
+
+type (
+	anyObject = map[string]interface{}
+	anyList   = []map[string]interface{}
+)
+
+// routeDocs 所有路由的说明，key为 "METHOD /api/path"（与gin注册的路径一致）
+var routeDocs = map[string]routeDoc{
+	// 公开接口
+	"GET /api/health":                    {Summary: "健康检查", Tag: "system", Public: true, Response: HealthResponse{}},
+	"GET /api/openapi.json":              {Summary: "OpenAPI文档", Tag: "system", Public: true, Response: anyObject{}},
+	"GET /api/config":                    {Summary: "系统配置（默认币种、杠杆、内测模式等）", Tag: "system", Public: true, Response: SystemConfigResponse{}},
+	"GET /api/supported-models":          {Summary: "系统支持的AI模型", Tag: "system", Public: true, Response: []*config.AIModelConfig{}},
+	"GET /api/supported-exchanges":       {Summary: "系统支持的交易所", Tag: "system", Public: true, Response: []*config.ExchangeConfig{}},
+	"GET /api/prompt-templates":          {Summary: "系统提示词模板列表", Tag: "system", Public: true, Response: PromptTemplatesResponse{}},
+	"GET /api/prompt-templates/:name":    {Summary: "系统提示词模板内容", Tag: "system", Public: true, Response: PromptTemplateResponse{}},
+	"POST /api/register":                 {Summary: "注册，返回OTP绑定信息", Tag: "auth", Public: true, Request: RegisterRequest{}, Response: RegisterResponse{}},
+	"POST /api/complete-registration":    {Summary: "验证OTP完成注册", Tag: "auth", Public: true, Request: OTPRequest{}, Response: AuthResponse{}},
+	"POST /api/login":                    {Summary: "邮箱密码登录，之后需要验证OTP", Tag: "auth", Public: true, Request: LoginRequest{}, Response: LoginResponse{}},
+	"POST /api/login/recovery":           {Summary: "丢失验证器时用恢复码登录（恢复码用后作废）", Tag: "auth", Public: true, Request: RecoveryLoginRequest{}, Response: RecoveryLoginResponse{}},
+	"POST /api/password-reset/request":   {Summary: "发送密码重置邮件（无论邮箱是否注册都返回相同结果）", Tag: "auth", Public: true, Request: PasswordResetRequest{}, Response: MessageResponse{}},
+	"POST /api/password-reset/confirm":   {Summary: "使用邮件中的重置token设置新密码", Tag: "auth", Public: true, Request: PasswordResetConfirmRequest{}, Response: MessageResponse{}},
+	"POST /api/verify-otp":               {Summary: "验证OTP完成登录", Tag: "auth", Public: true, Request: OTPRequest{}, Response: AuthResponse{}},
+	"GET /api/traders":                   {Summary: "公开的AI交易员排行榜前50名", Tag: "competition", Public: true, Response: anyList{}},
+	"GET /api/competition":               {Summary: "公开的竞赛数据；season=<ID|current> 时返回赛季排名（SeasonLeaderboardResponse），tag=<标签> 时只包含带该标签的交易员，rank_by=pnl_pct|sharpe|calmar|drawdown_adjusted 和 min_trades 覆盖默认排名方式", Tag: "competition", Public: true, Query: []string{"season", "tag", "rank_by", "min_trades"}, Response: anyObject{}},
+	"GET /api/competition/seasons":       {Summary: "所有竞赛赛季", Tag: "competition", Public: true, Response: []*config.Season{}},
+	"GET /api/top-traders":               {Summary: "前5名交易员数据（表现对比用）", Tag: "competition", Public: true, Response: anyObject{}},
+	"GET /api/analytics/models":          {Summary: "按AI模型汇总全部交易员的表现（收益率中位数、回撤、交易频率）", Tag: "competition", Public: true, Response: []manager.ModelStats{}},
+	"GET /api/equity-history":            {Summary: "收益率历史数据，granularity=1m|5m|1h|1d 时按时间区间聚合", Tag: "competition", Public: true, Query: []string{"trader_id", "granularity"}, Response: []EquityPoint{}},
+	"POST /api/equity-history-batch":     {Summary: "批量获取收益率历史（最多20个交易员）", Tag: "competition", Public: true, Query: []string{"trader_ids"}, Request: EquityHistoryBatchRequest{}, Response: anyObject{}},
+	"GET /api/traders/:id/public-config": {Summary: "交易员的公开配置（不含敏感信息）", Tag: "competition", Public: true, Response: anyObject{}},
+
+	// 只读分享链接（token即凭证）
+	"GET /api/shared/:token/status":         {Summary: "分享的交易员运行状态", Tag: "shared", Public: true, Response: anyObject{}},
+	"GET /api/shared/:token/positions":      {Summary: "分享的交易员当前持仓", Tag: "shared", Public: true, Response: anyList{}},
+	"GET /api/shared/:token/decisions":      {Summary: "分享的交易员最近的决策（最新的在前）", Tag: "shared", Public: true, Query: []string{"limit"}, Response: anyList{}},
+	"GET /api/shared/:token/equity-history": {Summary: "分享的交易员收益率历史", Tag: "shared", Public: true, Query: []string{"granularity"}, Response: []EquityPoint{}},
+	"POST /api/signals":                     {Summary: "外部系统推送信号（X-NOFX-Timestamp/X-NOFX-Signature HMAC签名，与出站webhook相同）", Tag: "signals", Public: true, Request: SignalIngestRequest{}, Response: SignalIngestResponse{}, Status: http.StatusAccepted},
+	"POST /api/signal-sources/:id/push":     {Summary: "向webhook类型的信号源推送候选币种（HMAC签名，密钥为该信号源的密钥）", Tag: "signals", Public: true, Request: SignalSourcePushRequest{}, Response: SignalIngestResponse{}, Status: http.StatusAccepted},
+	"GET /api/ws":                           {Summary: "WebSocket实时推送（净值/决策/持仓/状态，按交易员订阅）", Tag: "stream", Query: []string{"token"}, Status: http.StatusSwitchingProtocols},
+	"GET /api/traders/:id/logs":             {Summary: "交易员最近的日志；follow=true 时以SSE推送新日志", Tag: "stream", Query: []string{"token", "limit", "level", "follow"}, Response: []logging.Entry{}},
+	"GET /api/traders/:id/events":           {Summary: "SSE推送决策周期各阶段事件", Tag: "stream", Query: []string{"token"}, Response: trader.Event{}, ContentType: "text/event-stream"},
+
+	// 交易员管理
+	"GET /api/my-traders":                          {Summary: "当前用户的交易员列表，tag=<标签> 时只返回带该标签的交易员", Tag: "traders", Query: []string{"tag"}, Response: []TraderSummary{}},
+	"GET /api/traders/:id/config":                  {Summary: "交易员详细配置", Tag: "traders", Response: anyObject{}},
+	"POST /api/traders":                            {Summary: "创建AI交易员", Tag: "traders", Request: CreateTraderRequest{}, Response: CreateTraderResponse{}, Status: http.StatusCreated},
+	"PUT /api/traders/:id":                         {Summary: "更新AI交易员", Tag: "traders", Request: UpdateTraderRequest{}, Response: UpdateTraderResponse{}},
+	"DELETE /api/traders/:id":                      {Summary: "删除AI交易员", Tag: "traders", Response: MessageResponse{}},
+	"POST /api/traders/:id/clone":                  {Summary: "以新名称复制交易员配置，可改用其他AI模型或交易所", Tag: "traders", Request: CloneTraderRequest{}, Response: CreateTraderResponse{}, Status: http.StatusCreated},
+	"GET /api/traders/:id/export":                  {Summary: "导出交易员配置为可移植的JSON文档（不含密钥）", Tag: "traders", Response: TraderExport{}},
+	"POST /api/traders/import":                     {Summary: "从导出的配置文档创建交易员", Tag: "traders", Request: ImportTraderRequest{}, Response: CreateTraderResponse{}, Status: http.StatusCreated},
+	"POST /api/traders/:id/start":                  {Summary: "启动AI交易员", Tag: "traders", Response: MessageResponse{}},
+	"POST /api/traders/:id/stop":                   {Summary: "停止AI交易员", Tag: "traders", Response: MessageResponse{}},
+	"POST /api/traders/:id/resume":                 {Summary: "解除净值熔断，恢复开仓", Tag: "traders", Response: MessageResponse{}},
+	"POST /api/traders/:id/pause":                  {Summary: "手动暂停决策（交易员保持运行，持仓保留）", Tag: "traders", Response: MessageResponse{}},
+	"POST /api/traders/:id/unpause":                {Summary: "恢复被手动暂停的决策", Tag: "traders", Response: MessageResponse{}},
+	"PUT /api/traders/:id/tags":                    {Summary: "替换交易员的标签", Tag: "traders", Request: TraderTagsRequest{}, Response: TraderTagsRequest{}},
+	"POST /api/traders/bulk":                       {Summary: "按标签批量启动/停止/暂停/恢复交易员", Tag: "traders", Request: BulkTraderActionRequest{}, Response: BulkTraderActionResponse{}},
+	"PUT /api/traders/:id/prompt":                  {Summary: "更新交易员自定义prompt", Tag: "traders", Request: UpdatePromptRequest{}, Response: MessageResponse{}},
+	"GET /api/traders/:id/schedule":                {Summary: "交易员的交易时段", Tag: "traders", Response: TraderScheduleResponse{}},
+	"PUT /api/traders/:id/schedule":                {Summary: "设置交易时段，时段外跳过决策周期（持仓保留）", Tag: "traders", Request: TraderScheduleRequest{}, Response: TraderScheduleResponse{}},
+	"DELETE /api/traders/:id/schedule":             {Summary: "删除交易时段，恢复全天运行", Tag: "traders", Response: MessageResponse{}},
+	"GET /api/traders/:id/signals":                 {Summary: "信号推送状态和当前未过期的外部信号", Tag: "signals", Response: TraderSignalsResponse{}},
+	"POST /api/traders/:id/signal-key":             {Summary: "开启信号推送或轮换签名密钥（明文只返回一次）", Tag: "signals", Response: SignalKeyResponse{}},
+	"DELETE /api/traders/:id/signal-key":           {Summary: "关闭信号推送", Tag: "signals", Response: MessageResponse{}},
+	"GET /api/traders/:id/shares":                  {Summary: "交易员共享给了哪些用户", Tag: "traders", Response: []*config.TraderShare{}},
+	"POST /api/traders/:id/shares":                 {Summary: "按邮箱将交易员只读共享给其他用户", Tag: "traders", Request: ShareTraderRequest{}, Response: MessageResponse{}},
+	"DELETE /api/traders/:id/shares/:user_id":      {Summary: "取消共享", Tag: "traders", Response: MessageResponse{}},
+	"GET /api/traders/:id/share-links":             {Summary: "交易员的只读分享链接", Tag: "traders", Response: []*config.ShareLink{}},
+	"POST /api/traders/:id/share-links":            {Summary: "生成只读分享链接，token只在创建时返回", Tag: "traders", Request: CreateShareLinkRequest{}, Response: CreateShareLinkResponse{}},
+	"DELETE /api/traders/:id/share-links/:link_id": {Summary: "撤销分享链接", Tag: "traders", Response: MessageResponse{}},
+	"GET /api/webhooks":                            {Summary: "已注册的交易事件webhook", Tag: "webhooks", Response: []*config.Webhook{}},
+	"POST /api/webhooks":                           {Summary: "注册webhook，签名密钥只在创建时返回", Tag: "webhooks", Request: CreateWebhookRequest{}, Response: CreateWebhookResponse{}},
+	"DELETE /api/webhooks/:id":                     {Summary: "删除webhook", Tag: "webhooks", Response: MessageResponse{}},
+	"POST /api/webhooks/:id/test":                  {Summary: "发送一条测试事件", Tag: "webhooks", Response: WebhookTestResponse{}},
+	"GET /api/telegram/link":                       {Summary: "Telegram绑定状态", Tag: "telegram", Response: TelegramLinkStatus{}},
+	"POST /api/telegram/link":                      {Summary: "生成Telegram绑定口令（10分钟有效）", Tag: "telegram", Response: TelegramLinkTokenResponse{}},
+	"DELETE /api/telegram/link":                    {Summary: "解除Telegram绑定", Tag: "telegram", Response: MessageResponse{}},
+	"GET /api/digest":                              {Summary: "每日邮件摘要订阅状态", Tag: "digest", Response: DailyDigestSettings{}},
+	"PUT /api/digest":                              {Summary: "开启或关闭每日邮件摘要", Tag: "digest", Request: UpdateDailyDigestRequest{}, Response: DailyDigestSettings{}},
+	"GET /api/digest/preview":                      {Summary: "预览最近一天的摘要内容", Tag: "digest", Response: DailyDigestPreview{}},
+	"GET /api/follows":                             {Summary: "我的跟单及运行状态", Tag: "follows", Response: []FollowInfo{}},
+	"POST /api/follows":                            {Summary: "用自己的交易所账户按倍数跟随其他交易员的决策", Tag: "follows", Request: CreateFollowRequest{}, Response: FollowInfo{}},
+	"PUT /api/follows/:id":                         {Summary: "修改跟单倍数、风控上限或暂停跟单", Tag: "follows", Request: FollowLimits{}, Response: FollowInfo{}},
+	"DELETE /api/follows/:id":                      {Summary: "取消跟单（已有持仓保留）", Tag: "follows", Response: MessageResponse{}},
+	"GET /api/models":                              {Summary: "获取AI模型配置", Tag: "settings", Response: []*config.AIModelConfig{}},
+	"PUT /api/models":                              {Summary: "更新AI模型配置", Tag: "settings", Request: UpdateModelConfigRequest{}, Response: MessageResponse{}},
+	"GET /api/exchanges":                           {Summary: "获取交易所配置", Tag: "settings", Response: []*config.ExchangeConfig{}},
+	"PUT /api/exchanges":                           {Summary: "更新交易所配置", Tag: "settings", Request: UpdateExchangeConfigRequest{}, Response: MessageResponse{}},
+	"GET /api/user/signal-sources":                 {Summary: "获取用户信号源配置", Tag: "settings", Response: SignalSource{}},
+	"POST /api/user/signal-sources":                {Summary: "保存用户信号源配置", Tag: "settings", Request: SignalSource{}, Response: MessageResponse{}},
+	"POST /api/user/otp/reset":                     {Summary: "生成新的OTP密钥（需确认密码，确认前旧验证器仍有效）", Tag: "auth", Request: PasswordRequest{}, Response: OTPResetResponse{}},
+	"POST /api/user/otp/confirm":                   {Summary: "用新验证器的验证码确认重新绑定，返回新的恢复码", Tag: "auth", Request: OTPCodeRequest{}, Response: RecoveryCodesResponse{}},
+	"GET /api/user/recovery-codes":                 {Summary: "剩余可用的恢复码数量", Tag: "auth", Response: RecoveryCodeStatusResponse{}},
+	"POST /api/user/recovery-codes":                {Summary: "重新生成恢复码（需确认密码，旧的全部作废）", Tag: "auth", Request: PasswordRequest{}, Response: RecoveryCodesResponse{}},
+	"GET /api/user/export":                         {Summary: "导出个人数据（配置、订单、平仓记录、决策日志，不含API密钥）", Tag: "auth", Response: "", ContentType: "application/zip"},
+	"DELETE /api/user":                             {Summary: "注销账户（需确认密码和OTP）：停止交易员、删除密钥和全部数据，竞赛排名匿名化", Tag: "auth", Request: DeleteAccountRequest{}, Response: MessageResponse{}},
+
+	// 用户为交易员注册的候选币种信号源
+	"GET /api/traders/:id/signal-sources":                 {Summary: "交易员注册的候选币种信号源", Tag: "signals", Response: []*config.TraderSignalSource{}},
+	"POST /api/traders/:id/signal-sources":                {Summary: "注册信号源（http_json/rss/webhook），webhook的签名密钥只返回一次", Tag: "signals", Request: CreateSignalSourceRequest{}, Response: CreateSignalSourceResponse{}},
+	"DELETE /api/traders/:id/signal-sources/:sourceId":    {Summary: "删除信号源", Tag: "signals", Response: MessageResponse{}},
+	"POST /api/traders/:id/signal-sources/:sourceId/test": {Summary: "立即请求一次信号源，查看取出的币种", Tag: "signals", Response: SignalSourceTestResponse{}},
+	"GET /api/traders/:id/candidates":                     {Summary: "最近一个决策周期的有效候选币种（来源、得分、是否发给AI及原因、过期丢弃的币种）", Tag: "signals", Response: trader.CandidateReport{}},
+
+	// 交易员数据（未指定trader_id时使用当前用户的第一个交易员）
+	"GET /api/status":                                        {Summary: "交易员运行状态", Tag: "trader-data", Query: []string{"trader_id"}, Response: anyObject{}},
+	"GET /api/account":                                       {Summary: "交易员账户信息", Tag: "trader-data", Query: []string{"trader_id"}, Response: anyObject{}},
+	"GET /api/positions":                                     {Summary: "交易员持仓列表", Tag: "trader-data", Query: []string{"trader_id"}, Response: anyList{}},
+	"GET /api/decisions":                                     {Summary: "交易员的全部决策日志", Tag: "trader-data", Query: []string{"trader_id"}, Response: []*logger.DecisionRecord{}},
+	"GET /api/decisions/latest":                              {Summary: "交易员最新5条决策", Tag: "trader-data", Query: []string{"trader_id"}, Response: []*logger.DecisionRecord{}},
+	"GET /api/statistics":                                    {Summary: "交易员统计信息", Tag: "trader-data", Query: []string{"trader_id"}, Response: logger.Statistics{}},
+	"GET /api/performance":                                   {Summary: "交易员AI学习表现分析", Tag: "trader-data", Query: []string{"trader_id"}, Response: logger.PerformanceAnalysis{}},
+	"GET /api/traders/:id/position-history":                  {Summary: "交易员的平仓记录（新的在前）及已实现盈亏汇总，symbol 按币种过滤", Tag: "trader-data", Query: []string{"limit", "symbol"}, Response: PositionHistoryResponse{}},
+	"GET /api/traders/:id/performance/breakdown":             {Summary: "平仓表现按币种和多空方向分组（盈亏、胜率、平均R，亏损最多的在前）", Tag: "trader-data", Query: []string{"from", "to"}, Response: config.PositionBreakdown{}},
+	"GET /api/traders/:id/decisions/search":                  {Summary: "检索思维链和决策理由，q 中的关键词需全部出现（双引号括起短语），返回命中片段", Tag: "trader-data", Query: []string{"q", "from", "to", "limit"}, Response: DecisionSearchResponse{}},
+	"POST /api/traders/:id/decisions/:record_id/annotations": {Summary: "给决策周期或某个动作添加复盘笔记/评分，已平仓交易标注其开仓动作，share_with_ai 的标注会提供给AI", Tag: "trader-data", Request: AnnotationRequest{}, Response: logger.Annotation{}, Status: http.StatusCreated},
+	"DELETE /api/traders/:id/decisions/:record_id/annotations/:annotation_id": {Summary: "删除决策标注", Tag: "trader-data", Response: MessageResponse{}},
+	"GET /api/traders/:id/orders": {Summary: "交易员的订单记录及状态变化（新的在前），status 按状态过滤", Tag: "trader-data", Query: []string{"limit", "status"}, Response: []*config.Order{}},
+
+	// 风险
+	"GET /api/portfolio":                       {Summary: "所有交易员的组合风险", Tag: "risk", Response: manager.Portfolio{}},
+	"GET /api/portfolio/delta":                 {Summary: "按交易员和用户汇总的多空净敞口", Tag: "risk", Response: manager.DeltaReport{}},
+	"GET /api/traders/:id/var":                 {Summary: "交易员及组合的1日VaR（参数法/历史模拟）", Tag: "risk", Response: manager.TraderVaR{}},
+	"GET /api/user/limits":                     {Summary: "当前用户生效的杠杆/名义价值/交易员数量上限", Tag: "risk", Response: config.UserLimits{}},
+	"GET /api/admin/users":                     {Summary: "所有用户的角色、状态和资源占用（管理员）", Tag: "admin", Response: []AdminUserSummary{}},
+	"GET /api/admin/users/:user_id":            {Summary: "单个用户的交易员列表、资源占用和生效上限（管理员）", Tag: "admin", Response: AdminUserDetail{}},
+	"POST /api/admin/users/:user_id/disable":   {Summary: "禁用账户并停止其运行中的交易员（管理员）", Tag: "admin", Response: MessageResponse{}},
+	"POST /api/admin/users/:user_id/enable":    {Summary: "重新启用账户（管理员）", Tag: "admin", Response: MessageResponse{}},
+	"POST /api/admin/users/:user_id/reset-otp": {Summary: "重置用户的验证器，下次登录时重新绑定（管理员）", Tag: "admin", Response: MessageResponse{}},
+	"PUT /api/admin/users/:user_id/role":       {Summary: "修改用户角色: admin / user / viewer（管理员）", Tag: "admin", Request: UpdateRoleRequest{}, Response: MessageResponse{}},
+	"GET /api/admin/system-config":             {Summary: "可修改的系统配置、取值范围和当前值（管理员）", Tag: "admin", Response: []SystemConfigItem{}},
+	"PUT /api/admin/system-config":             {Summary: "批量修改系统配置，校验后保存并记录修改人（管理员）", Tag: "admin", Request: UpdateSystemConfigRequest{}, Response: UpdateSystemConfigResponse{}},
+	"GET /api/admin/system-config/audit":       {Summary: "系统配置修改记录，最新的在前（管理员）", Tag: "admin", Response: []config.SystemConfigChange{}},
+	"GET /api/admin/ai-scheduler":              {Summary: "各AI密钥进行中和排队的调用数（管理员）", Tag: "admin", Response: []manager.AISchedulerStats{}},
+	"GET /api/admin/backups":                   {Summary: "备份文件列表，最新的在前（管理员）", Tag: "admin", Response: []backup.Info{}},
+	"POST /api/admin/backups":                  {Summary: "立即备份数据库和决策日志，按backup_config上传S3并清理旧备份（管理员）", Tag: "admin", Response: backup.Info{}, Status: http.StatusCreated},
+	"GET /api/admin/backups/:name":             {Summary: "下载备份文件（管理员）", Tag: "admin", Response: "", ContentType: "application/gzip"},
+	"GET /api/admin/traders":                   {Summary: "所有用户的交易员和运行状态（管理员）", Tag: "admin", Response: []AdminTraderSummary{}},
+	"POST /api/admin/traders/:id/stop":         {Summary: "停止任意用户的交易员（管理员）", Tag: "admin", Response: MessageResponse{}},
+	"POST /api/admin/rotate-key":               {Summary: "用当前加密密钥重新加密API密钥，需先带新旧密钥重启服务（管理员）", Tag: "admin", Response: RotateKeyResponse{}},
+	"GET /api/admin/beta-codes":                {Summary: "内测码总数和已使用数（管理员）", Tag: "admin", Response: BetaCodesResponse{}},
+	"POST /api/admin/beta-codes":               {Summary: "生成新的内测码（管理员）", Tag: "admin", Request: BetaCodesRequest{}, Response: BetaCodesResponse{}, Status: http.StatusCreated},
+	"GET /api/admin/trader-resume":             {Summary: "启动时恢复运行中交易员的报告（管理员）", Tag: "admin", Response: manager.ResumeReport{}},
+	"GET /api/admin/limits":                    {Summary: "系统级上限和所有用户的单独上限（管理员）", Tag: "admin", Response: LimitsOverviewResponse{}},
+	"PUT /api/admin/limits":                    {Summary: "设置系统级上限（管理员）", Tag: "admin", Request: config.UserLimits{}, Response: config.UserLimits{}},
+	"PUT /api/admin/limits/:user_id":           {Summary: "设置单个用户的上限（管理员）", Tag: "admin", Request: config.UserLimits{}, Response: config.UserLimits{}},
+	"DELETE /api/admin/limits/:user_id":        {Summary: "删除用户的单独上限，恢复为系统级上限（管理员）", Tag: "admin", Response: MessageResponse{}},
+	"POST /api/admin/seasons":                  {Summary: "创建竞赛赛季（管理员）", Tag: "admin", Request: SeasonRequest{}, Response: config.Season{}},
+	"PUT /api/admin/seasons/:id":               {Summary: "修改未归档的赛季（管理员）", Tag: "admin", Request: SeasonRequest{}, Response: config.Season{}},
+	"DELETE /api/admin/seasons/:id":            {Summary: "删除赛季及其归档排名（管理员）", Tag: "admin", Response: MessageResponse{}},
+
+	// 行情、AI测试、回测
+	"GET /api/market-data/:symbol/export": {Summary: "导出缓存的K线及指标", Tag: "market", Query: []string{"interval", "from", "to", "format"}, Response: KlineExportResponse{}},
+	"POST /api/ai-test/generate-prompt":   {Summary: "用交易员的真实数据生成User Prompt", Tag: "ai-test", Request: GenerateUserPromptRequest{}, Response: AITestResponse{}},
+	"POST /api/ai-test/get-decision":      {Summary: "用指定prompt测试AI决策", Tag: "ai-test", Request: TestAIDecisionRequest{}, Response: AITestResponse{}},
+	"POST /api/backtest/compare":          {Summary: "回放历史行情对比多个模板/模型（设置walk_forward时返回WalkForwardResponse）", Tag: "backtest", Request: BacktestCompareRequest{}, Response: BacktestCompareResponse{}},
+	"GET /api/backtest/runs":              {Summary: "回测记录列表", Tag: "backtest", Query: []string{"trader_id"}, Response: []*config.BacktestRun{}},
+	"GET /api/backtest/runs/:id":          {Summary: "回测记录详情", Tag: "backtest", Response: config.BacktestRun{}},
+	"DELETE /api/backtest/runs/:id":       {Summary: "删除回测记录", Tag: "backtest", Response: MessageResponse{}},
+}
+
+// queryDescs query参数说明
+var queryDescs = map[string]string{
+	"trader_id":  "交易员ID",
+	"trader_ids": "逗号分隔的交易员ID（请求体为空时使用）",
+	"token":      "JWT（浏览器无法为WebSocket/EventSource设置请求头）",
+	"interval":   "K线周期：3m 或 4h",
+	"from":       "开始时间（RFC3339或毫秒时间戳）",
+	"to":         "结束时间（RFC3339或毫秒时间戳）",
+	"format":     "json 或 csv",
+}
+
+// openAPISchema JSON Schema（OpenAPI 3.0子集）
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIOperation struct {
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	OperationID string                     `json:"operationId"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema `json:"schemas"`
+	SecuritySchemes map[string]interface{}    `json:"securitySchemes"`
+}
+
+// OpenAPIDocument OpenAPI 3 文档
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       map[string]string                       `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+// schemaBuilder 通过反射生成JSON Schema，结构体放入 components/schemas 并以$ref引用
+type schemaBuilder struct {
+	schemas map[string]*openAPISchema
+	names   map[reflect.Type]string
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{
+		schemas: make(map[string]*openAPISchema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schemaName 本包的类型直接用类型名，其他包加包名前缀（避免重名）
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "api" || pkg == "" {
+		return t.Name()
+	}
+	return pkg + "." + t.Name()
+}
+
+func (b *schemaBuilder) schemaFor(t reflect.Type) *openAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		return &openAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name, ok := b.names[t]
+		if !ok {
+			name = schemaName(t)
+			b.names[t] = name
+			b.schemas[name] = &openAPISchema{} // 先占位，支持递归类型
+			*b.schemas[name] = *b.structSchema(t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	// interface{} 等任意类型
+	return &openAPISchema{}
+}
+
+// structSchema 按 encoding/json 的规则展开字段（匿名嵌入的结构体字段提升到外层）
+func (b *schemaBuilder) structSchema(t reflect.Type) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded := b.structSchema(ft)
+				for k, v := range embedded.Properties {
+					schema.Properties[k] = v
+				}
+				schema.Required = append(schema.Required, embedded.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = b.schemaFor(field.Type)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// openAPIPath 把gin的 :param / *param 转换成 {param}，并返回路径参数
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// operationID 由方法和路径生成，如 GET /api/traders/:id/config -> get_traders_id_config
+func operationID(method, path string) string {
+	path = strings.TrimPrefix(path, "/api/")
+	r := strings.NewReplacer("/", "_", ":", "", "*", "", "-", "_", ".", "_")
+	return strings.ToLower(method) + "_" + r.Replace(path)
+}
+
+// buildOpenAPI 根据已注册的路由和 routeDocs 生成文档
+func buildOpenAPI(routes gin.RoutesInfo) *OpenAPIDocument {
+	b := newSchemaBuilder()
+	errorSchema := b.schemaFor(reflect.TypeOf(ErrorResponse{}))
+
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: map[string]string{
+			"title":       "NOFX API",
+			"version":     "1.0.0",
+			"description": "AI交易员管理与竞赛接口。需要认证的接口使用 Authorization: Bearer <token>（管理员模式下不需要）。",
+		},
+		Paths: make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			Schemas: b.schemas,
+			SecuritySchemes: map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+
+	for _, route := range apiRoutes(routes) {
+		path, pathParams := openAPIPath(route.Path)
+		info, ok := routeDocs[route.Method+" "+route.Path]
+		if !ok {
+			info = routeDoc{Summary: route.Handler}
+		}
+
+		op := &openAPIOperation{
+			Summary:     info.Summary,
+			OperationID: operationID(route.Method, route.Path),
+			Responses:   make(map[string]openAPIResponse),
+		}
+		if info.Tag != "" {
+			op.Tags = []string{info.Tag}
+		}
+		for _, p := range pathParams {
+			op.Parameters = append(op.Parameters, openAPIParameter{Name: p, In: "path", Required: true, Schema: &openAPISchema{Type: "string"}})
+		}
+		for _, q := range info.Query {
+			op.Parameters = append(op.Parameters, openAPIParameter{Name: q, In: "query", Description: queryDescs[q], Schema: &openAPISchema{Type: "string"}})
+		}
+		if info.Request != nil {
+			op.RequestBody = &openAPIRequestBody{
+				Required: true,
+				Content:  map[string]openAPIMediaType{"application/json": {Schema: b.schemaFor(reflect.TypeOf(info.Request))}},
+			}
+		}
+
+		status := info.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := openAPIResponse{Description: http.StatusText(status)}
+		if info.Response != nil {
+			contentType := info.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			success.Content = map[string]openAPIMediaType{contentType: {Schema: b.schemaFor(reflect.TypeOf(info.Response))}}
+		}
+		op.Responses[fmt.Sprintf("%d", status)] = success
+		op.Responses["default"] = openAPIResponse{
+			Description: "错误",
+			Content:     map[string]openAPIMediaType{"application/json": {Schema: errorSchema}},
+		}
+		if !info.Public {
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+	openAPIErr  error
+)
+
+// handleOpenAPI 返回OpenAPI文档（路由在启动后不再变化，只生成一次）
+func (s *Server) handleOpenAPI(c *gin.Context) {
+	openAPIOnce.Do(func() {
+		openAPIJSON, openAPIErr = json.Marshal(buildOpenAPI(s.router.Routes()))
+	})
+	if openAPIErr != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("生成OpenAPI文档失败: %v", openAPIErr)})
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPIJSON)
+}
+
+// apiRoutes 需要写入文档的路由：/api 下的路由，
+// 用 Any 注册的路径（如 /api/health）只保留有说明的方法
+func apiRoutes(routes gin.RoutesInfo) gin.RoutesInfo {
+	documented := make(map[string]bool)
+	for _, route := range routes {
+		if _, ok := routeDocs[route.Method+" "+route.Path]; ok {
+			documented[route.Path] = true
+		}
+	}
+
+	result := make(gin.RoutesInfo, 0, len(routes))
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api") {
+			continue
+		}
+		if _, ok := routeDocs[route.Method+" "+route.Path]; !ok && documented[route.Path] {
+			continue
+		}
+		result = append(result, route)
+	}
+	return result
+}
+
+// routeSummaries 启动日志中的接口列表，按路径排序
+func routeSummaries(routes gin.RoutesInfo) []string {
+	sorted := apiRoutes(routes)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	lines := make([]string, 0, len(sorted))
+	for _, route := range sorted {
+		info := routeDocs[route.Method+" "+route.Path]
+		line := fmt.Sprintf("%-6s %-40s %s", route.Method, route.Path, info.Summary)
+		if info.Public {
+			line += "（无需认证）"
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return lines
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newDocTestServer() *Server {
+	gin.SetMode(gin.TestMode)
+	s := &Server{router: gin.New()}
+	s.setupRoutes()
+	return s
+}
+
+func TestRouteDocsCoverAllRoutes(t *testing.T) {
+	s := newDocTestServer()
+
+	registered := make(map[string]bool)
+	for _, route := range apiRoutes(s.router.Routes()) {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if _, ok := routeDocs[key]; !ok {
+			t.Errorf("路由 %s 缺少 routeDocs 说明", key)
+		}
+	}
+	for key := range routeDocs {
+		if !registered[key] {
+			t.Errorf("routeDocs 中的 %s 没有对应的路由", key)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	s := newDocTestServer()
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d", w.Code)
+	}
+
+	var doc OpenAPIDocument
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("文档不是合法JSON: %v", err)
+	}
+
+	op := doc.Paths["/api/traders/{id}"]["put"]
+	if op == nil {
+		t.Fatal("缺少 PUT /api/traders/{id}")
+	}
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "id" || op.Parameters[0].In != "path" {
+		t.Errorf("路径参数错误: %+v", op.Parameters)
+	}
+	if len(op.Security) == 0 {
+		t.Error("需要认证的接口应声明bearerAuth")
+	}
+	if ref := op.RequestBody.Content["application/json"].Schema.Ref; ref != "#/components/schemas/UpdateTraderRequest" {
+		t.Errorf("请求体引用 = %q", ref)
+	}
+
+	login := doc.Paths["/api/login"]["post"]
+	if login == nil || len(login.Security) != 0 {
+		t.Fatal("登录接口应无需认证")
+	}
+	if _, ok := doc.Paths["/api/health"]["post"]; ok {
+		t.Error("Any注册的健康检查只应记录GET")
+	}
+	if _, ok := doc.Paths["/api/traders"]["post"].Responses["201"]; !ok {
+		t.Error("创建交易员应返回201")
+	}
+
+	// 引用的结构体都应出现在components中
+	for _, ref := range collectRefs(w.Body.String()) {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("缺少schema: %s", name)
+		}
+	}
+
+	loginSchema := doc.Components.Schemas["LoginRequest"]
+	if loginSchema == nil || loginSchema.Properties["email"].Type != "string" || len(loginSchema.Required) != 2 {
+		t.Errorf("LoginRequest schema错误: %+v", loginSchema)
+	}
+	if ts := doc.Components.Schemas["BacktestCompareResponse"].Properties["from"]; ts.Format != "date-time" {
+		t.Errorf("time.Time应为date-time: %+v", ts)
+	}
+}
+
+func collectRefs(body string) []string {
+	var refs []string
+	for _, part := range strings.Split(body, `"$ref":"`)[1:] {
+		refs = append(refs, part[:strings.Index(part, `"`)])
+	}
+	return refs
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	path, params := openAPIPath("/api/admin/limits/:user_id")
+	if path != "/api/admin/limits/{user_id}" || len(params) != 1 || params[0] != "user_id" {
+		t.Errorf("openAPIPath = %s %v", path, params)
+	}
+	if id := operationID("GET", "/api/market-data/:symbol/export"); id != "get_market_data_symbol_export" {
+		t.Errorf("operationID = %s", id)
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// issueRecoveryCodes 生成新的恢复码并替换旧的，明文只在本次响应中返回
+func (s *Server) issueRecoveryCodes(userID string) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(auth.RecoveryCodeCount)
+	if err != nil {
+		return nil, fmt.Errorf("生成恢复码失败: %w", err)
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+	if err := s.database.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, fmt.Errorf("保存恢复码失败: %w", err)
+	}
+	return codes, nil
+}
+
+// checkUserPassword 需要认证的敏感操作再次校验密码
+func (s *Server) checkUserPassword(c *gin.Context, password string) bool {
+	if auth.IsAdminMode() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "管理员模式下不使用OTP"})
+		return false
+	}
+	user, err := s.database.GetUserByID(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+		return false
+	}
+	if !auth.CheckPassword(password, user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "密码错误"})
+		return false
+	}
+	return true
+}
+
+// handleRecoveryLogin 丢失验证器时用邮箱、密码和一个恢复码登录（恢复码用后作废）
+func (s *Server) handleRecoveryLogin(c *gin.Context) {
+	var req RecoveryLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := s.database.GetUserByEmail(req.Email)
+	if err != nil || !auth.CheckPassword(req.Password, user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "邮箱或密码错误"})
+		return
+	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: errAccountDisabled})
+		return
+	}
+	if !user.OTPVerified {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "账户未完成OTP设置，请先完成注册"})
+		return
+	}
+
+	ok, err := s.database.UseRecoveryCode(user.ID, auth.HashRecoveryCode(req.RecoveryCode))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "验证恢复码失败"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "恢复码无效或已使用"})
+		return
+	}
+
+	token, err := auth.GenerateJWT(user.ID, user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成token失败"})
+		return
+	}
+	remaining, _ := s.database.CountRecoveryCodes(user.ID)
+	requestLog(c).Info("用户使用恢复码登录", "user_id", user.ID, "email", user.Email, "remaining", remaining)
+
+	c.JSON(http.StatusOK, RecoveryLoginResponse{
+		AuthResponse: AuthResponse{
+			Token:   token,
+			UserID:  user.ID,
+			Email:   user.Email,
+			Role:    user.Role,
+			Message: "已使用恢复码登录，请尽快重新绑定验证器",
+		},
+		RemainingRecoveryCodes: remaining,
+	})
+}
+
+// handleResetOTP 生成新的OTP密钥，确认前旧验证器仍然有效
+func (s *Server) handleResetOTP(c *gin.Context) {
+	var req PasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !s.checkUserPassword(c, req.Password) {
+		return
+	}
+
+	secret, err := auth.GenerateOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "OTP密钥生成失败"})
+		return
+	}
+	userID := c.GetString("user_id")
+	if err := s.database.SetPendingOTPSecret(userID, secret); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存OTP密钥失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, OTPResetResponse{
+		OTPSecret: secret,
+		QRCodeURL: auth.GetOTPQRCodeURL(secret, c.GetString("email")),
+		Message:   "请使用Google Authenticator扫描新的二维码并输入验证码确认",
+	})
+}
+
+// handleConfirmOTPReset 用新验证器的验证码确认重新绑定，同时生成新的恢复码
+func (s *Server) handleConfirmOTPReset(c *gin.Context) {
+	var req OTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	user, err := s.database.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+		return
+	}
+	if user.PendingOTP == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "没有待确认的验证器，请先重新生成"})
+		return
+	}
+	if !auth.VerifyOTP(user.PendingOTP, req.OTPCode) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "OTP验证码错误"})
+		return
+	}
+
+	if err := s.database.ConfirmPendingOTPSecret(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("更新OTP密钥失败: %v", err)})
+		return
+	}
+	codes, err := s.issueRecoveryCodes(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	requestLog(c).Info("用户已重新绑定验证器", "user_id", user.ID, "email", user.Email)
+	c.JSON(http.StatusOK, RecoveryCodesResponse{
+		RecoveryCodes: codes,
+		Message:       "验证器已重新绑定，旧的恢复码已失效，请妥善保存新的恢复码",
+	})
+}
+
+// handleGetRecoveryCodeStatus 剩余可用的恢复码数量
+func (s *Server) handleGetRecoveryCodeStatus(c *gin.Context) {
+	remaining, err := s.database.CountRecoveryCodes(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取恢复码失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, RecoveryCodeStatusResponse{Remaining: remaining})
+}
+
+// handleRegenerateRecoveryCodes 重新生成恢复码（旧的全部作废）
+func (s *Server) handleRegenerateRecoveryCodes(c *gin.Context) {
+	var req PasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !s.checkUserPassword(c, req.Password) {
+		return
+	}
+
+	codes, err := s.issueRecoveryCodes(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, RecoveryCodesResponse{
+		RecoveryCodes: codes,
+		Message:       "已生成新的恢复码，旧的恢复码已失效",
+	})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"nofx/auth"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// passwordResetRequestedMessage 无论邮箱是否注册都返回相同提示，避免泄露账户是否存在
+const passwordResetRequestedMessage = "如果该邮箱已注册，重置链接已发送，请在30分钟内完成重置"
+
+// passwordResetBaseURL 重置链接的前端地址：优先使用配置，其次是CORS白名单中明确列出的请求来源
+// 不信任任意 Origin 或 Host，否则攻击者伪造请求头即可让受害者收到指向其站点的真实重置链接
+func (s *Server) passwordResetBaseURL(c *gin.Context) (string, bool) {
+	if base, _ := s.database.GetSystemConfig("password_reset_url"); base != "" {
+		return strings.TrimRight(base, "/"), true
+	}
+	if origin := strings.TrimRight(c.GetHeader("Origin"), "/"); origin != "" && s.cors.listed(origin) {
+		return origin, true
+	}
+	return "", false
+}
+
+// handleRequestPasswordReset 发送密码重置邮件
+func (s *Server) handleRequestPasswordReset(c *gin.Context) {
+	if auth.IsAdminMode() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "管理员模式下不支持重置密码"})
+		return
+	}
+
+	var req PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, err := s.database.GetUserByEmail(req.Email)
+	if err != nil || user.Disabled {
+		c.JSON(http.StatusOK, MessageResponse{Message: passwordResetRequestedMessage})
+		return
+	}
+
+	baseURL, ok := s.passwordResetBaseURL(c)
+	if !ok {
+		// 与成功时返回相同提示，避免泄露账户是否存在
+		requestLog(c).Warn("未配置 password_reset_url 且请求来源不在CORS白名单中，无法发送密码重置邮件")
+		c.JSON(http.StatusOK, MessageResponse{Message: passwordResetRequestedMessage})
+		return
+	}
+
+	token, err := auth.GeneratePasswordResetToken(user.ID, user.PasswordHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成重置链接失败"})
+		return
+	}
+	link := fmt.Sprintf("%s/reset-password?token=%s", baseURL, url.QueryEscape(token))
+	body := fmt.Sprintf("您好，\n\n我们收到了重置 NOFX 账户 %s 密码的请求。请在%d分钟内打开以下链接设置新密码：\n\n%s\n\n如果这不是您本人的操作，请忽略此邮件，您的密码不会被修改。\n",
+		user.Email, int(auth.PasswordResetTTL.Minutes()), link)
+
+	// 异步发送，响应时间不随邮箱是否存在而变化
+	go func(to string) {
+		if err := auth.GetMailer().Send(to, "NOFX 密码重置", body); err != nil {
+			slog.Error("发送密码重置邮件失败", "to", to, "error", err)
+			return
+		}
+		slog.Info("已发送密码重置邮件", "to", to)
+	}(user.Email)
+
+	c.JSON(http.StatusOK, MessageResponse{Message: passwordResetRequestedMessage})
+}
+
+// handleConfirmPasswordReset 使用重置链接中的token设置新密码
+func (s *Server) handleConfirmPasswordReset(c *gin.Context) {
+	if auth.IsAdminMode() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "管理员模式下不支持重置密码"})
+		return
+	}
+
+	var req PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	claims, err := auth.ParsePasswordResetToken(req.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "重置链接无效或已过期"})
+		return
+	}
+	user, err := s.database.GetUserByID(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "重置链接无效或已过期"})
+		return
+	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: errAccountDisabled})
+		return
+	}
+	if err := claims.CheckFingerprint(user.PasswordHash); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "重置链接已使用，请重新申请"})
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "密码处理失败"})
+		return
+	}
+	if err := s.database.UpdateUserPassword(user.ID, passwordHash); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("更新密码失败: %v", err)})
+		return
+	}
+
+	requestLog(c).Info("用户已通过邮件重置密码", "user_id", user.ID, "email", user.Email)
+	c.JSON(http.StatusOK, MessageResponse{Message: "密码已重置，请使用新密码登录"})
+}
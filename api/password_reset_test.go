@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPasswordResetBaseURL(t *testing.T) {
+	s := newAdminTestServer(t)
+	baseURL := func(origin string) (string, bool) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "http://api.internal/api/password-reset/request", nil)
+		if origin != "" {
+			c.Request.Header.Set("Origin", origin)
+		}
+		return s.passwordResetBaseURL(c)
+	}
+
+	// 未配置地址时不使用 Host，也不信任任意 Origin
+	s.cors = corsOrigins{any: true}
+	for _, origin := range []string{"", "https://evil.example"} {
+		if got, ok := baseURL(origin); ok {
+			t.Errorf("Origin %q 不应作为重置链接地址, got %s", origin, got)
+		}
+	}
+
+	s.cors, _ = parseCORSOrigins("https://app.example.com")
+	if got, ok := baseURL("https://app.example.com/"); !ok || got != "https://app.example.com" {
+		t.Errorf("白名单中的来源应可用, got %q %v", got, ok)
+	}
+	if _, ok := baseURL("https://evil.example"); ok {
+		t.Error("白名单外的来源不应可用")
+	}
+
+	if err := s.database.SetSystemConfig("password_reset_url", "https://nofx.example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := baseURL("https://app.example.com"); !ok || got != "https://nofx.example.com" {
+		t.Errorf("应优先使用配置的地址, got %q %v", got, ok)
+	}
+}
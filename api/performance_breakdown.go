@@ -0,0 +1,37 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handlePerformanceBreakdown 交易员平仓表现按币种和多空方向的分组（?from=&to=，亏损最多的在前）
+func (s *Server) handlePerformanceBreakdown(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+	if !s.canViewTrader(userID, traderID) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	from, err := parseExportTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("无效的from参数: %v", err)})
+		return
+	}
+	to, err := parseExportTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("无效的to参数: %v", err)})
+		return
+	}
+
+	breakdown, err := s.database.GetPositionBreakdown(traderID, from, to)
+	if err != nil {
+		requestLog(c).Error("获取表现分组失败", "trader_id", traderID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "获取表现分组失败"})
+		return
+	}
+	c.JSON(http.StatusOK, breakdown)
+}
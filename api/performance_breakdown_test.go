@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPerformanceBreakdown(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for i, p := range []*config.ClosedPosition{
+		{Symbol: "BTCUSDT", Side: "long", RealizedPnL: 20, RMultiple: 2},
+		{Symbol: "BTCUSDT", Side: "short", RealizedPnL: -5, RMultiple: -1},
+		{Symbol: "ETHUSDT", Side: "short", RealizedPnL: -30},
+		{Symbol: "SOLUSDT", Side: "long", RealizedPnL: 8, RMultiple: 1},
+	} {
+		p.TraderID, p.UserID = "t1", "alice"
+		p.ClosedAt = now.Add(time.Duration(i-3) * time.Hour)
+		if err := s.database.RecordClosedPosition(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/performance/breakdown")
+	var resp config.PositionBreakdown
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("获取表现分组失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(resp.BySymbol) != 3 || resp.BySymbol[0].Key != "ETHUSDT" || resp.BySymbol[2].Key != "BTCUSDT" {
+		t.Fatalf("应按已实现盈亏从低到高排列: %+v", resp.BySymbol)
+	}
+	if btc := resp.BySymbol[2]; btc.Trades != 2 || btc.WinRate != 50 || btc.RealizedPnL != 15 || btc.AvgR != 0.5 || btc.RTrades != 2 {
+		t.Errorf("BTC汇总错误: %+v", btc)
+	}
+	if eth := resp.BySymbol[0]; eth.AvgR != 0 || eth.RTrades != 0 {
+		t.Errorf("无止损的交易不应计入平均R: %+v", eth)
+	}
+	if len(resp.BySide) != 2 || resp.BySide[0].Key != "short" || resp.BySide[0].RealizedPnL != -35 || resp.BySide[1].AvgPnL != 14 {
+		t.Errorf("多空分组错误: %+v", resp.BySide)
+	}
+
+	// 只统计最近90分钟内平仓的
+	from := strconv.FormatInt(now.Add(-90*time.Minute).UnixMilli(), 10)
+	w = doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/performance/breakdown?from="+from)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.BySymbol) != 2 || resp.BySymbol[0].Key != "ETHUSDT" {
+		t.Errorf("时间范围过滤错误: %s", w.Body.String())
+	}
+
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/performance/breakdown?to=bad"); w.Code != http.StatusBadRequest {
+		t.Errorf("无效to应返回400: %d", w.Code)
+	}
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/traders/missing/performance/breakdown"); w.Code != http.StatusNotFound {
+		t.Errorf("不存在的交易员应返回404: %d", w.Code)
+	}
+}
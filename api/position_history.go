@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPositionHistoryLimit 未指定 limit 时返回的平仓记录数
+const defaultPositionHistoryLimit = 100
+
+// handlePositionHistory 交易员的平仓记录（新的在前）及已实现盈亏汇总
+func (s *Server) handlePositionHistory(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+	if !s.canViewTrader(userID, traderID) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	limit := defaultPositionHistoryLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxOrderLimit {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit 必须在 1-" + strconv.Itoa(maxOrderLimit) + " 之间"})
+			return
+		}
+		limit = n
+	}
+	symbol := strings.ToUpper(c.Query("symbol"))
+
+	positions, summary, err := s.database.GetPositionHistory(traderID, symbol, limit)
+	if err != nil {
+		requestLog(c).Error("获取平仓记录失败", "trader_id", traderID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "获取平仓记录失败"})
+		return
+	}
+	c.JSON(http.StatusOK, PositionHistoryResponse{Positions: positions, Summary: summary})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"testing"
+	"time"
+)
+
+func TestPositionHistory(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	opened := now.Add(-2 * time.Hour)
+	for i, p := range []*config.ClosedPosition{
+		{TraderID: "t1", UserID: "alice", Symbol: "BTCUSDT", Side: "long", EntryPrice: 60000, ExitPrice: 61000, Quantity: 0.01, Fees: 0.5, FundingFee: -0.2, PnL: 10, RealizedPnL: 9.3, ExitReason: "take_profit", OpenedAt: &opened},
+		{TraderID: "t1", UserID: "alice", Symbol: "ETHUSDT", Side: "short", EntryPrice: 3000, ExitPrice: 3100, Quantity: 1, Fees: 2.4, PnL: -100, RealizedPnL: -102.4, ExitReason: "stop_loss"},
+		{TraderID: "t1", UserID: "alice", Symbol: "BTCUSDT", Side: "short", EntryPrice: 61000, ExitPrice: 60500, Quantity: 0.01, Fees: 0.5, PnL: 5, RealizedPnL: 4.5, ExitReason: "ai_close"},
+	} {
+		p.ClosedAt = now.Add(time.Duration(i) * time.Minute)
+		if err := s.database.RecordClosedPosition(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/position-history?symbol=btcusdt&limit=1")
+	var resp PositionHistoryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("获取平仓记录失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(resp.Positions) != 1 || resp.Positions[0].Side != "short" || resp.Positions[0].OpenedAt != nil {
+		t.Errorf("应返回最近一笔BTC平仓: %+v", resp.Positions)
+	}
+	if resp.Summary.Count != 2 || resp.Summary.Wins != 2 || resp.Summary.RealizedPnL != 13.8 || resp.Summary.Fees != 1 {
+		t.Errorf("汇总应包含全部匹配记录: %+v", resp.Summary)
+	}
+
+	w = doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/position-history")
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Positions) != 3 || resp.Summary.Wins != 2 {
+		t.Fatalf("获取全部平仓记录失败: %s", w.Body.String())
+	}
+	if first := resp.Positions[2]; first.OpenedAt == nil || !first.OpenedAt.Equal(opened) || first.FundingFee != -0.2 {
+		t.Errorf("开仓时间和资金费应保存: %+v", first)
+	}
+
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/position-history?limit=-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("无效limit应返回400: %d", w.Code)
+	}
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/traders/missing/position-history"); w.Code != http.StatusNotFound {
+		t.Errorf("不存在的交易员应返回404: %d", w.Code)
+	}
+}
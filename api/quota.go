@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"nofx/config"
+)
+
+// defaultScanIntervalMinutes 未指定扫描间隔时的默认值
+const defaultScanIntervalMinutes = 3
+
+// checkTraderCountQuota 创建交易员前检查数量上限
+func (s *Server) checkTraderCountQuota(userID string, limits *config.UserLimits) error {
+	if limits.MaxTraders <= 0 {
+		return nil
+	}
+	traders, err := s.database.GetTraders(userID)
+	if err != nil {
+		return nil
+	}
+	if len(traders) >= limits.MaxTraders {
+		return fmt.Errorf("交易员数量已达上限（%d个），请删除不用的交易员或联系管理员", limits.MaxTraders)
+	}
+	return nil
+}
+
+// checkScanIntervalQuota 扫描间隔不能低于管理员设置的下限
+func checkScanIntervalQuota(minutes int, limits *config.UserLimits) error {
+	if limits.MinScanInterval > 0 && minutes < limits.MinScanInterval {
+		return fmt.Errorf("扫描间隔不能小于%d分钟（当前%d分钟）", limits.MinScanInterval, minutes)
+	}
+	return nil
+}
+
+// checkRunningQuota 启动交易员前检查同时运行数量上限和扫描间隔下限
+func (s *Server) checkRunningQuota(userID, traderID string, limits *config.UserLimits) error {
+	traders, err := s.database.GetTraders(userID)
+	if err != nil {
+		return nil
+	}
+
+	running := 0
+	for _, t := range traders {
+		if t.ID == traderID {
+			if err := checkScanIntervalQuota(t.ScanIntervalMinutes, limits); err != nil {
+				return err
+			}
+			continue
+		}
+		if t.IsRunning {
+			running++
+		}
+	}
+	if limits.MaxRunningTraders > 0 && running >= limits.MaxRunningTraders {
+		return fmt.Errorf("同时运行的交易员已达上限（%d个），请先停止其他交易员", limits.MaxRunningTraders)
+	}
+	return nil
+}
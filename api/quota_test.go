@@ -0,0 +1,51 @@
+package api
+
+import (
+	"nofx/config"
+	"testing"
+)
+
+func TestCheckRunningQuota(t *testing.T) {
+	s := newAdminTestServer(t)
+	for _, tr := range []*config.TraderRecord{
+		{ID: "t1", ScanIntervalMinutes: 5, IsRunning: true},
+		{ID: "t2", ScanIntervalMinutes: 5},
+		{ID: "t3", ScanIntervalMinutes: 1},
+	} {
+		tr.UserID, tr.Name, tr.AIModelID, tr.ExchangeID = "alice", tr.ID, "deepseek", "paper"
+		if err := s.database.CreateTrader(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.database.SetUserLimits(&config.UserLimits{UserID: "alice", MaxRunningTraders: 2, MinScanInterval: 3}); err != nil {
+		t.Fatal(err)
+	}
+	limits, err := s.database.GetEffectiveLimits("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.MaxRunningTraders != 2 || limits.MinScanInterval != 3 {
+		t.Fatalf("用户单独上限未生效: %+v", limits)
+	}
+
+	if err := s.checkRunningQuota("alice", "t2", limits); err != nil {
+		t.Errorf("未达上限时应允许启动: %v", err)
+	}
+	if err := s.checkRunningQuota("alice", "t3", limits); err == nil {
+		t.Error("扫描间隔低于下限时不应允许启动")
+	}
+	if err := s.checkRunningQuota("alice", "t1", limits); err != nil {
+		t.Errorf("检查自身时不应计入运行数量: %v", err)
+	}
+
+	limits.MaxRunningTraders = 1
+	if err := s.checkRunningQuota("alice", "t2", limits); err == nil {
+		t.Error("同时运行数量达到上限时不应允许启动")
+	}
+
+	limits.MaxTraders = 3
+	if err := s.checkTraderCountQuota("alice", limits); err == nil {
+		t.Error("交易员数量达到上限时不应允许创建")
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"nofx/config"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimits 每分钟请求数上限（0 不限制）
+type RateLimits struct {
+	IPPerMinute     int // 每个IP对所有接口
+	UserPerMinute   int // 每个登录用户对需要认证的接口
+	PublicPerMinute int // 每个IP对公开竞赛接口
+	AuthPerMinute   int // 每个IP对登录/注册/OTP接口（每个接口单独计数），防止暴力破解
+}
+
+// defaultRateLimits 默认限流参数
+var defaultRateLimits = RateLimits{
+	IPPerMinute:     600,
+	UserPerMinute:   300,
+	PublicPerMinute: 120,
+	AuthPerMinute:   10,
+}
+
+// loadRateLimits 从系统配置读取限流参数，未配置或无效时使用默认值
+func loadRateLimits(database *config.Database) RateLimits {
+	limits := defaultRateLimits
+	for key, target := range map[string]*int{
+		"rate_limit_ip":     &limits.IPPerMinute,
+		"rate_limit_user":   &limits.UserPerMinute,
+		"rate_limit_public": &limits.PublicPerMinute,
+		"rate_limit_auth":   &limits.AuthPerMinute,
+	} {
+		value, err := database.GetSystemConfig(key)
+		if err != nil || value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			slog.Warn("限流配置无效，使用默认值", "key", key, "value", value, "default", *target)
+			continue
+		}
+		*target = n
+	}
+	return limits
+}
+
+const rateLimitIdleTTL = 10 * time.Minute // 超过此时间未访问的桶会被清理
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter 按key（IP/用户）分别计数的令牌桶
+type rateLimiter struct {
+	mu          sync.Mutex
+	rate        float64 // 每秒补充的令牌数
+	burst       float64 // 桶容量
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+// newRateLimiter 每分钟 perMinute 个请求，允许约12秒的突发量（至少3个）
+func newRateLimiter(perMinute int) *rateLimiter {
+	burst := math.Max(float64(perMinute)/5, 3)
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow 消耗一个令牌；不足时返回需要等待的时间
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastCleanup) > rateLimitIdleTTL {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > rateLimitIdleTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// rateLimitMiddleware 限流中间件，keyFunc 返回空字符串时不限流（perMinute<=0 时直接放行）
+func rateLimitMiddleware(perMinute int, keyFunc func(c *gin.Context) string) gin.HandlerFunc {
+	if perMinute <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	limiter := newRateLimiter(perMinute)
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if ok, wait := limiter.allow(key, time.Now()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: fmt.Sprintf("请求过于频繁，请 %.0f 秒后再试", math.Ceil(wait.Seconds()))})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// loadTrustedProxies 读取系统配置 trusted_proxies，未配置时不信任任何代理
+func loadTrustedProxies(database *config.Database) []string {
+	value, _ := database.GetSystemConfig("trusted_proxies")
+	var proxies []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// setTrustedProxies 只信任可信代理转发的 X-Forwarded-For / X-Real-IP，
+// 否则客户端可以伪造请求头，每次请求换一个IP绕过限流
+func setTrustedProxies(router *gin.Engine, proxies []string) {
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		slog.Warn("可信代理配置无效，不信任任何代理", "trusted_proxies", proxies, "error", err)
+		router.SetTrustedProxies(nil)
+	}
+}
+
+// ipKey 按客户端IP限流
+func ipKey(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// routeIPKey 按接口+IP限流（登录、注册等接口分别计数）
+func routeIPKey(c *gin.Context) string {
+	return c.FullPath() + "|" + c.ClientIP()
+}
+
+// userKey 按登录用户限流（需在 authMiddleware 之后）
+func userKey(c *gin.Context) string {
+	return c.GetString("user_id")
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := newRateLimiter(60) // 每秒1个，突发12个
+	now := time.Now()
+
+	for i := 0; i < 12; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("突发额度内第%d个请求应放行", i+1)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok || wait <= 0 || wait > time.Second {
+		t.Fatalf("额度用完应拒绝并在1秒内可重试: ok=%v wait=%v", ok, wait)
+	}
+	if ok, _ := l.allow("b", now); !ok {
+		t.Error("不同key应分别计数")
+	}
+	if ok, _ := l.allow("a", now.Add(time.Second)); !ok {
+		t.Error("1秒后应补充一个令牌")
+	}
+
+	// 长时间不访问的桶被清理，重新获得完整额度
+	later := now.Add(rateLimitIdleTTL + time.Minute)
+	l.allow("b", later)
+	if _, exists := l.buckets["a"]; exists {
+		t.Error("空闲的桶应被清理")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/login", rateLimitMiddleware(10, routeIPKey), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/open", rateLimitMiddleware(0, ipKey), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	codes := make([]int, 0, 4)
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+		codes = append(codes, w.Code)
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Error("429应带Retry-After")
+		}
+	}
+	if codes[2] != http.StatusOK || codes[3] != http.StatusTooManyRequests {
+		t.Errorf("每分钟10次应允许3次突发后拒绝: %v", codes)
+	}
+
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/open", nil))
+		if w.Code != http.StatusOK {
+			t.Fatal("上限为0时不应限流")
+		}
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	login := func(trusted []string) []int {
+		r := gin.New()
+		setTrustedProxies(r, trusted)
+		r.POST("/login", rateLimitMiddleware(10, routeIPKey), func(c *gin.Context) { c.Status(http.StatusOK) })
+		codes := make([]int, 0, 4)
+		for i := 0; i < 4; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/login", nil) // RemoteAddr 192.0.2.1
+			req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+			req.Header.Set("X-Real-IP", fmt.Sprintf("198.51.100.%d", i))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			codes = append(codes, w.Code)
+		}
+		return codes
+	}
+
+	if codes := login(nil); codes[3] != http.StatusTooManyRequests {
+		t.Errorf("未配置可信代理时伪造的 X-Forwarded-For 不应绕过限流: %v", codes)
+	}
+	if codes := login([]string{"192.0.2.0/24"}); codes[3] != http.StatusOK {
+		t.Errorf("可信代理转发的不同客户端应分别计数: %v", codes)
+	}
+}
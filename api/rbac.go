@@ -0,0 +1,130 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"nofx/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireRole 只允许指定角色访问（需在 authMiddleware 之后）
+func (s *Server) requireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "当前角色无权执行此操作"})
+		c.Abort()
+	}
+}
+
+// canViewTrader 管理员可以查看所有交易员，其他用户只能查看自己的或共享给自己的
+func (s *Server) canViewTrader(userID, traderID string) bool {
+	if user, err := s.database.GetUserByID(userID); err == nil && user.Role == config.RoleAdmin {
+		_, err := s.database.GetTraderOwner(traderID)
+		return err == nil
+	}
+	ok, err := s.database.CanViewTrader(userID, traderID)
+	return err == nil && ok
+}
+
+// handleGetTraderShares 交易员共享给了哪些用户
+func (s *Server) handleGetTraderShares(c *gin.Context) {
+	traderID := c.Param("id")
+	if _, _, _, err := s.database.GetTraderConfig(c.GetString("user_id"), traderID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	shares, err := s.database.GetTraderShares(traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取共享列表失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, shares)
+}
+
+// handleShareTrader 将自己的交易员只读共享给其他用户
+func (s *Server) handleShareTrader(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+	if _, _, _, err := s.database.GetTraderConfig(userID, traderID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	var req ShareTraderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	target, err := s.database.GetUserByEmail(req.Email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+		return
+	}
+	if target.ID == userID {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "不能共享给自己"})
+		return
+	}
+
+	if err := s.database.ShareTrader(traderID, target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("共享失败: %v", err)})
+		return
+	}
+	requestLog(c).Info("交易员已共享", "trader_id", traderID, "target_user_id", target.ID, "email", target.Email)
+	c.JSON(http.StatusOK, MessageResponse{Message: "已共享"})
+}
+
+// handleUnshareTrader 取消共享
+func (s *Server) handleUnshareTrader(c *gin.Context) {
+	traderID := c.Param("id")
+	if _, _, _, err := s.database.GetTraderConfig(c.GetString("user_id"), traderID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	if err := s.database.UnshareTrader(traderID, c.Param("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("取消共享失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "已取消共享"})
+}
+
+// handleUpdateUserRole 修改用户角色
+func (s *Server) handleUpdateUserRole(c *gin.Context) {
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !config.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "角色必须是 admin、user 或 viewer"})
+		return
+	}
+
+	targetID := c.Param("user_id")
+	// 防止管理员误操作把自己降级，导致系统没有管理员
+	if targetID == c.GetString("user_id") {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "不能修改自己的角色"})
+		return
+	}
+
+	if err := s.database.SetUserRole(targetID, req.Role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("修改角色失败: %v", err)})
+		return
+	}
+	requestLog(c).Info("用户角色已修改", "target_user_id", targetID, "role", req.Role)
+	c.JSON(http.StatusOK, MessageResponse{Message: "角色已更新"})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nofx/config"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{}
+
+	for _, tc := range []struct {
+		role string
+		want int
+	}{
+		{config.RoleAdmin, http.StatusOK},
+		{config.RoleUser, http.StatusOK},
+		{config.RoleViewer, http.StatusForbidden},
+		{"", http.StatusForbidden},
+	} {
+		r := gin.New()
+		r.POST("/traders", func(c *gin.Context) { c.Set("role", tc.role) },
+			s.requireRole(config.RoleAdmin, config.RoleUser),
+			func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/traders", nil))
+		if w.Code != tc.want {
+			t.Errorf("角色 %q: got %d, want %d", tc.role, w.Code, tc.want)
+		}
+	}
+}
+
+func TestCanViewTrader(t *testing.T) {
+	database, err := config.NewDatabase(filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	for _, u := range []*config.User{
+		{ID: "owner", Email: "owner@test.com"},
+		{ID: "viewer", Email: "viewer@test.com", Role: config.RoleViewer},
+		{ID: "other", Email: "other@test.com"},
+		{ID: "boss", Email: "boss@test.com", Role: config.RoleAdmin},
+	} {
+		if err := database.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "owner", Name: "t1", AIModelID: "deepseek", ExchangeID: "binance"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.ShareTrader("t1", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{database: database}
+	for userID, want := range map[string]bool{"owner": true, "viewer": true, "other": false, "boss": true} {
+		if got := s.canViewTrader(userID, "t1"); got != want {
+			t.Errorf("%s 查看 t1: got %v, want %v", userID, got, want)
+		}
+	}
+	if s.canViewTrader("boss", "missing") {
+		t.Error("不存在的交易员不应可见")
+	}
+
+	// 删除交易员后共享记录一并清理
+	if err := database.DeleteTrader("owner", "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if shared, _ := database.GetSharedTraders("viewer"); len(shared) != 0 {
+		t.Errorf("删除交易员后仍有 %d 个共享记录", len(shared))
+	}
+}
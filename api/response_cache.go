@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"nofx/cache"
+	"nofx/config"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPublicCacheTTL = 5 * time.Second
+	responseCachePrefix   = "nofx:api:"
+)
+
+// loadPublicCacheTTL 读取公开接口响应的缓存时长（public_cache_seconds，0 关闭缓存）
+func loadPublicCacheTTL(database *config.Database) time.Duration {
+	value, err := database.GetSystemConfig("public_cache_seconds")
+	if err != nil || value == "" {
+		return defaultPublicCacheTTL
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		slog.Warn("公开接口缓存时长无效，使用默认值", "value", value, "default", defaultPublicCacheTTL)
+		return defaultPublicCacheTTL
+	}
+	return time.Duration(n) * time.Second
+}
+
+// SetResponseCache 设置公开接口的响应缓存，多个API副本使用同一个Redis时共享缓存的响应
+func (s *Server) SetResponseCache(store cache.Store) {
+	s.respCache = store
+}
+
+// respondCached 命中缓存时直接返回缓存的JSON，否则调用 build 生成响应并写入缓存
+// build 失败时自行写入错误响应并返回 false，错误响应不缓存
+func (s *Server) respondCached(c *gin.Context, key string, build func() (interface{}, bool)) {
+	if s.respCache == nil || s.publicCacheTTL <= 0 {
+		if value, ok := build(); ok {
+			c.JSON(http.StatusOK, value)
+		}
+		return
+	}
+
+	key = responseCachePrefix + key
+	ctx := c.Request.Context()
+	data, ok, err := s.respCache.Get(ctx, key)
+	if err != nil {
+		requestLog(c).Warn("读取响应缓存失败", "key", key, "error", err)
+	}
+	if ok {
+		c.Header("X-Cache", "HIT")
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+		return
+	}
+
+	value, ok := build()
+	if !ok {
+		return
+	}
+	data, err = json.Marshal(value)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "序列化响应失败"})
+		return
+	}
+	if err := s.respCache.Set(ctx, key, data, s.publicCacheTTL); err != nil {
+		requestLog(c).Warn("写入响应缓存失败", "key", key, "error", err)
+	}
+	c.Header("X-Cache", "MISS")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// traderIDsKey 交易员ID列表的缓存键（与顺序无关）
+func traderIDsKey(ids []string) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
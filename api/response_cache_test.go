@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"nofx/cache"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	s := newAdminTestServer(t)
+	store := cache.NewMemory()
+	s.SetResponseCache(store)
+	s.publicCacheTTL = time.Minute
+
+	first := doAs(t, s, "alice", http.MethodGet, "/api/top-traders")
+	if first.Code != http.StatusOK || first.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("第一次请求应未命中缓存: %d %q", first.Code, first.Header().Get("X-Cache"))
+	}
+	second := doAs(t, s, "alice", http.MethodGet, "/api/top-traders")
+	if second.Header().Get("X-Cache") != "HIT" || second.Body.String() != first.Body.String() {
+		t.Errorf("第二次请求应返回缓存的响应: %q %s", second.Header().Get("X-Cache"), second.Body.String())
+	}
+
+	// 缓存内容直接返回，不再重新生成
+	store.Set(context.Background(), responseCachePrefix+"traders", []byte(`[{"trader_id":"cached"}]`), time.Minute)
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/traders"); w.Body.String() != `[{"trader_id":"cached"}]` {
+		t.Errorf("应返回缓存的交易员列表: %s", w.Body.String())
+	}
+
+	s.publicCacheTTL = 0
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/top-traders"); w.Code != http.StatusOK || w.Header().Get("X-Cache") != "" {
+		t.Errorf("关闭缓存后不应读取缓存: %d %q", w.Code, w.Header().Get("X-Cache"))
+	}
+}
+
+func TestTraderIDsKey(t *testing.T) {
+	if traderIDsKey([]string{"a", "b"}) != traderIDsKey([]string{"b", "a"}) {
+		t.Error("缓存键应与交易员顺序无关")
+	}
+	if traderIDsKey([]string{"a", "b"}) == traderIDsKey([]string{"a,b"}) {
+		t.Error("不同的交易员列表缓存键不应相同")
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"nofx/config"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// seasonLeaderboardLimit 赛季排名返回的名次数（与全时段排行榜一致）
+const seasonLeaderboardLimit = 50
+
+// resolveSeason 解析 ?season= 参数：赛季ID或 current
+func (s *Server) resolveSeason(param string) (*config.Season, error) {
+	if param == "current" {
+		return s.database.GetCurrentSeason(time.Now())
+	}
+	id, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return nil, sql.ErrNoRows
+	}
+	return s.database.GetSeason(id)
+}
+
+// handleSeasonCompetition 赛季排名（/api/competition?season=）
+func (s *Server) handleSeasonCompetition(c *gin.Context, param string) {
+	season, err := s.resolveSeason(param)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "赛季不存在或当前没有进行中的赛季"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取赛季失败: %v", err)})
+		return
+	}
+
+	results, err := s.traderManager.GetSeasonLeaderboard(s.database, season)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取赛季排名失败: %v", err)})
+		return
+	}
+	total := len(results)
+	if len(results) > seasonLeaderboardLimit {
+		results = results[:seasonLeaderboardLimit]
+	}
+	c.JSON(http.StatusOK, SeasonLeaderboardResponse{Season: season, Traders: results, Count: len(results), TotalCount: total})
+}
+
+// handleListSeasons 所有赛季（公开）
+func (s *Server) handleListSeasons(c *gin.Context) {
+	seasons, err := s.database.GetSeasons()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取赛季列表失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, seasons)
+}
+
+// bindSeason 校验赛季请求：结束时间晚于开始时间，且不与其他赛季重叠
+func (s *Server) bindSeason(c *gin.Context, id int64) (*config.Season, bool) {
+	var req SeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	season := &config.Season{ID: id, Name: strings.TrimSpace(req.Name), StartAt: req.StartAt.UTC(), EndAt: req.EndAt.UTC()}
+	if season.Name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "赛季名称不能为空"})
+		return nil, false
+	}
+	if !season.EndAt.After(season.StartAt) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "结束时间必须晚于开始时间"})
+		return nil, false
+	}
+
+	seasons, err := s.database.GetSeasons()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取赛季列表失败: %v", err)})
+		return nil, false
+	}
+	for _, other := range seasons {
+		if other.ID != id && season.Overlaps(other) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("与赛季 %s 时间重叠", other.Name)})
+			return nil, false
+		}
+	}
+	return season, true
+}
+
+// handleCreateSeason 创建赛季
+func (s *Server) handleCreateSeason(c *gin.Context) {
+	season, ok := s.bindSeason(c, 0)
+	if !ok {
+		return
+	}
+	if err := s.database.CreateSeason(season); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("创建赛季失败: %v", err)})
+		return
+	}
+	requestLog(c).Info("管理员创建了赛季", "season", season.Name)
+
+	created, err := s.database.GetSeason(season.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取赛季失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, created)
+}
+
+// seasonIDParam 解析路径中的赛季ID
+func seasonIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "赛季不存在"})
+		return 0, false
+	}
+	return id, true
+}
+
+// handleUpdateSeason 修改赛季（已归档的赛季不能修改）
+func (s *Server) handleUpdateSeason(c *gin.Context) {
+	id, ok := seasonIDParam(c)
+	if !ok {
+		return
+	}
+	existing, err := s.database.GetSeason(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "赛季不存在"})
+		return
+	}
+	if existing.Archived {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "赛季已结束并归档，不能修改"})
+		return
+	}
+
+	season, ok := s.bindSeason(c, id)
+	if !ok {
+		return
+	}
+	if err := s.database.UpdateSeason(season); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("修改赛季失败: %v", err)})
+		return
+	}
+	s.traderManager.InvalidateSeason(id)
+
+	updated, err := s.database.GetSeason(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取赛季失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// handleDeleteSeason 删除赛季及其归档排名
+func (s *Server) handleDeleteSeason(c *gin.Context) {
+	id, ok := seasonIDParam(c)
+	if !ok {
+		return
+	}
+	if err := s.database.DeleteSeason(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "赛季不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("删除赛季失败: %v", err)})
+		return
+	}
+	s.traderManager.InvalidateSeason(id)
+	requestLog(c).Info("管理员删除了赛季", "season_id", id)
+	c.JSON(http.StatusOK, MessageResponse{Message: "赛季已删除"})
+}
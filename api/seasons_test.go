@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"nofx/config"
+	"testing"
+	"time"
+)
+
+func TestSeasonAdminAndLeaderboard(t *testing.T) {
+	s := newAdminTestServer(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	body := func(name string, start, end time.Time) string {
+		b, _ := json.Marshal(SeasonRequest{Name: name, StartAt: start, EndAt: end})
+		return string(b)
+	}
+
+	if w := doAsWithBody(t, s, "alice", http.MethodPost, "/api/admin/seasons", body("S1", now, now.Add(time.Hour))); w.Code != http.StatusForbidden {
+		t.Errorf("普通用户不能创建赛季: %d", w.Code)
+	}
+	if w := doAsWithBody(t, s, "boss", http.MethodPost, "/api/admin/seasons", body("S1", now, now.Add(-time.Hour))); w.Code != http.StatusBadRequest {
+		t.Errorf("结束时间早于开始时间应拒绝: %d", w.Code)
+	}
+
+	w := doAsWithBody(t, s, "boss", http.MethodPost, "/api/admin/seasons", body("S1", now.Add(-time.Hour), now.Add(time.Hour)))
+	var s1 config.Season
+	if err := json.Unmarshal(w.Body.Bytes(), &s1); err != nil || w.Code != http.StatusOK || s1.ID == 0 {
+		t.Fatalf("创建赛季失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := doAsWithBody(t, s, "boss", http.MethodPost, "/api/admin/seasons", body("S2", now, now.Add(2*time.Hour))); w.Code != http.StatusConflict {
+		t.Errorf("重叠的赛季应拒绝: %d", w.Code)
+	}
+
+	// 公开接口：当前赛季
+	req := httptest.NewRequest(http.MethodGet, "/api/competition?season=current", nil)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	var board SeasonLeaderboardResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &board); err != nil || rec.Code != http.StatusOK || board.Season.ID != s1.ID {
+		t.Fatalf("获取当前赛季排名失败: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// 归档后返回保存的最终排名，且不能再修改
+	archived := []config.SeasonResult{{Rank: 1, TraderID: "t1", TraderName: "bot", StartEquity: 100, EndEquity: 110, PnL: 10, PnLPct: 10}}
+	if err := s.database.ArchiveSeason(s1.ID, archived); err != nil {
+		t.Fatal(err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/competition?season=1", nil)
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	board = SeasonLeaderboardResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &board); err != nil || board.TotalCount != 1 || board.Traders[0].TraderName != "bot" {
+		t.Errorf("应返回归档的排名: %d %s", rec.Code, rec.Body.String())
+	}
+	if w := doAsWithBody(t, s, "boss", http.MethodPut, "/api/admin/seasons/1", body("S1b", now, now.Add(time.Hour))); w.Code != http.StatusConflict {
+		t.Errorf("已归档的赛季不能修改: %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/competition?season=99", nil)
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("不存在的赛季应返回404: %d", rec.Code)
+	}
+
+	if w := doAs(t, s, "boss", http.MethodDelete, "/api/admin/seasons/1"); w.Code != http.StatusOK {
+		t.Errorf("删除赛季失败: %d", w.Code)
+	}
+	if results, _ := s.database.GetSeasonResults(1); len(results) != 0 {
+		t.Error("删除赛季应同时删除归档排名")
+	}
+}
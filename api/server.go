@@ -1,33 +1,52 @@
 package api
 
 import (
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"nofx/auth"
+	"nofx/backtest"
+	"nofx/cache"
 	"nofx/config"
 	"nofx/decision"
+	"nofx/logging"
 	"nofx/manager"
 	"nofx/market"
 	"nofx/mcp"
 	"nofx/pool"
+	"nofx/web"
 
 	// "nofx/trader" // 暂时注释掉，避免导入冲突
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/acme/autocert"
 )
 
 // Server HTTP API服务器
 type Server struct {
-	router        *gin.Engine
-	traderManager *manager.TraderManager
-	database      *config.Database
-	port          int
+	router         *gin.Engine
+	traderManager  *manager.TraderManager
+	database       *config.Database
+	port           int
+	wsHub          *wsHub
+	rateLimits     RateLimits
+	cors           corsOrigins
+	tls            tlsSettings
+	httpServer     *http.Server
+	redirectServer *http.Server      // HTTP重定向到HTTPS，未启用时为nil
+	certManager    *autocert.Manager // Let's Encrypt自动证书，未启用时为nil
+	shutdown       chan struct{}     // 退出时关闭，结束SSE等长连接
+	respCache      cache.Store       // 公开接口的响应缓存，为nil时不缓存
+	publicCacheTTL time.Duration
 }
 
 // NewServer 创建API服务器
@@ -35,53 +54,70 @@ func NewServer(traderManager *manager.TraderManager, database *config.Database,
 	// 设置为Release模式（减少日志输出）
 	gin.SetMode(gin.ReleaseMode)
 
-	router := gin.Default()
-
-	// 启用CORS
-	router.Use(corsMiddleware())
+	router := gin.New()
+	setTrustedProxies(router, loadTrustedProxies(database))
+	router.Use(requestLogMiddleware(), recoveryMiddleware())
 
 	s := &Server{
-		router:        router,
-		traderManager: traderManager,
-		database:      database,
-		port:          port,
-	}
+		router:         router,
+		traderManager:  traderManager,
+		database:       database,
+		port:           port,
+		wsHub:          newWSHub(traderManager),
+		rateLimits:     loadRateLimits(database),
+		cors:           loadCORSOrigins(database),
+		tls:            loadTLSSettings(database),
+		shutdown:       make(chan struct{}),
+		publicCacheTTL: loadPublicCacheTTL(database),
+	}
+	s.httpServer = &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: router}
+	s.setupTLS()
+	// Shutdown 不会等待已劫持的WebSocket连接，也不会打断SSE，需要主动结束
+	s.httpServer.RegisterOnShutdown(func() {
+		close(s.shutdown)
+		s.wsHub.closeAll()
+	})
+
+	// 启用CORS
+	router.Use(corsMiddleware(s.cors))
+	go s.wsHub.run()
 
 	// 设置路由
 	s.setupRoutes()
 
-	return s
-}
-
-// corsMiddleware CORS中间件
-func corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusOK)
-			return
+	// 内嵌的前端（-tags embedweb 构建时），单文件部署无需另配Web服务器
+	if files := web.Dist(); files != nil {
+		if err := s.setupSPA(files); err != nil {
+			slog.Warn("加载内嵌前端失败", "error", err)
+		} else {
+			slog.Info("已启用内嵌前端")
 		}
-
-		c.Next()
 	}
+
+	return s
 }
 
 // setupRoutes 设置路由
 func (s *Server) setupRoutes() {
-	// API路由组
-	api := s.router.Group("/api")
+	// API路由组，每个IP整体限流
+	api := s.router.Group("/api", rateLimitMiddleware(s.rateLimits.IPPerMinute, ipKey))
+	authLimit := rateLimitMiddleware(s.rateLimits.AuthPerMinute, routeIPKey)
+	publicLimit := rateLimitMiddleware(s.rateLimits.PublicPerMinute, ipKey)
 	{
 		// 健康检查
 		api.Any("/health", s.handleHealth)
 
-		// 认证相关路由（无需认证）
-		api.POST("/register", s.handleRegister)
-		api.POST("/login", s.handleLogin)
-		api.POST("/verify-otp", s.handleVerifyOTP)
-		api.POST("/complete-registration", s.handleCompleteRegistration)
+		// OpenAPI文档（由路由表和 routeDocs 生成）
+		api.GET("/openapi.json", s.handleOpenAPI)
+
+		// 认证相关路由（无需认证，严格限流防止暴力破解）
+		api.POST("/register", authLimit, s.handleRegister)
+		api.POST("/login", authLimit, s.handleLogin)
+		api.POST("/verify-otp", authLimit, s.handleVerifyOTP)
+		api.POST("/complete-registration", authLimit, s.handleCompleteRegistration)
+		api.POST("/login/recovery", authLimit, s.handleRecoveryLogin)
+		api.POST("/password-reset/request", authLimit, s.handleRequestPasswordReset)
+		api.POST("/password-reset/confirm", authLimit, s.handleConfirmPasswordReset)
 
 		// 系统支持的模型和交易所（无需认证）
 		api.GET("/supported-models", s.handleGetSupportedModels)
@@ -95,37 +131,115 @@ func (s *Server) setupRoutes() {
 		api.GET("/prompt-templates/:name", s.handleGetPromptTemplate)
 
 		// 公开的竞赛数据（无需认证）
-		api.GET("/traders", s.handlePublicTraderList)
-		api.GET("/competition", s.handlePublicCompetition)
-		api.GET("/top-traders", s.handleTopTraders)
-		api.GET("/equity-history", s.handleEquityHistory)
-		api.POST("/equity-history-batch", s.handleEquityHistoryBatch)
-		api.GET("/traders/:id/public-config", s.handleGetPublicTraderConfig)
+		api.GET("/traders", publicLimit, s.handlePublicTraderList)
+		api.GET("/competition", publicLimit, s.handlePublicCompetition)
+		api.GET("/competition/seasons", publicLimit, s.handleListSeasons)
+		api.GET("/top-traders", publicLimit, s.handleTopTraders)
+		api.GET("/analytics/models", publicLimit, s.handleModelAnalytics)
+		api.GET("/equity-history", publicLimit, s.handleEquityHistory)
+		api.POST("/equity-history-batch", publicLimit, s.handleEquityHistoryBatch)
+		api.GET("/traders/:id/public-config", publicLimit, s.handleGetPublicTraderConfig)
+
+		// 只读分享链接（凭token访问单个交易员，不需要登录）
+		api.GET("/shared/:token/status", publicLimit, s.handleSharedStatus)
+		api.GET("/shared/:token/positions", publicLimit, s.handleSharedPositions)
+		api.GET("/shared/:token/decisions", publicLimit, s.handleSharedDecisions)
+		api.GET("/shared/:token/equity-history", publicLimit, s.handleSharedEquityHistory)
+
+		// 外部信号推送（HMAC签名认证）
+		api.POST("/signals", publicLimit, s.handleIngestSignals)
+		api.POST("/signal-sources/:id/push", publicLimit, s.handlePushSignalSource)
+
+		// 实时推送（自行校验token，浏览器无法给WebSocket/EventSource设置Authorization头）
+		api.GET("/ws", s.handleWebSocket)
+		api.GET("/traders/:id/events", s.handleTraderEvents)
+		api.GET("/traders/:id/logs", s.handleTraderLogs)
 
 		// 需要认证的路由
-		protected := api.Group("/", s.authMiddleware())
+		protected := api.Group("/", s.authMiddleware(), rateLimitMiddleware(s.rateLimits.UserPerMinute, userKey))
+		// 观察者（viewer）只能查看共享给自己的交易员，不能管理交易员和密钥
+		editor := s.requireRole(config.RoleAdmin, config.RoleUser)
 		{
 			// AI交易员管理
 			protected.GET("/my-traders", s.handleTraderList)
 			protected.GET("/traders/:id/config", s.handleGetTraderConfig)
-			protected.POST("/traders", s.handleCreateTrader)
-			protected.PUT("/traders/:id", s.handleUpdateTrader)
-			protected.DELETE("/traders/:id", s.handleDeleteTrader)
-			protected.POST("/traders/:id/start", s.handleStartTrader)
-			protected.POST("/traders/:id/stop", s.handleStopTrader)
-			protected.PUT("/traders/:id/prompt", s.handleUpdateTraderPrompt)
+			protected.POST("/traders", editor, s.handleCreateTrader)
+			protected.PUT("/traders/:id", editor, s.handleUpdateTrader)
+			protected.DELETE("/traders/:id", editor, s.handleDeleteTrader)
+			protected.POST("/traders/:id/clone", editor, s.handleCloneTrader)
+			protected.GET("/traders/:id/export", editor, s.handleExportTrader)
+			protected.POST("/traders/import", editor, s.handleImportTrader)
+			protected.POST("/traders/:id/start", editor, s.handleStartTrader)
+			protected.POST("/traders/:id/stop", editor, s.handleStopTrader)
+			protected.POST("/traders/:id/resume", editor, s.handleResumeTrader)
+			protected.POST("/traders/:id/pause", editor, s.handlePauseTrader)
+			protected.POST("/traders/:id/unpause", editor, s.handleUnpauseTrader)
+			protected.PUT("/traders/:id/tags", editor, s.handleSetTraderTags)
+			protected.POST("/traders/bulk", editor, s.handleBulkTraderAction)
+			protected.PUT("/traders/:id/prompt", editor, s.handleUpdateTraderPrompt)
+			protected.GET("/traders/:id/schedule", editor, s.handleGetTraderSchedule)
+			protected.PUT("/traders/:id/schedule", editor, s.handleSetTraderSchedule)
+			protected.DELETE("/traders/:id/schedule", editor, s.handleDeleteTraderSchedule)
+			protected.GET("/traders/:id/signals", editor, s.handleGetTraderSignals)
+			protected.POST("/traders/:id/signal-key", editor, s.handleCreateSignalKey)
+			protected.DELETE("/traders/:id/signal-key", editor, s.handleDeleteSignalKey)
+			protected.GET("/traders/:id/signal-sources", editor, s.handleListSignalSources)
+			protected.POST("/traders/:id/signal-sources", editor, s.handleCreateSignalSource)
+			protected.DELETE("/traders/:id/signal-sources/:sourceId", editor, s.handleDeleteSignalSource)
+			protected.POST("/traders/:id/signal-sources/:sourceId/test", editor, s.handleTestSignalSource)
+			protected.GET("/traders/:id/candidates", editor, s.handleGetTraderCandidates)
+
+			// 交易员只读共享
+			protected.GET("/traders/:id/shares", editor, s.handleGetTraderShares)
+			protected.POST("/traders/:id/shares", editor, s.handleShareTrader)
+			protected.DELETE("/traders/:id/shares/:user_id", editor, s.handleUnshareTrader)
+			protected.GET("/traders/:id/share-links", editor, s.handleListShareLinks)
+			protected.POST("/traders/:id/share-links", editor, s.handleCreateShareLink)
+			protected.DELETE("/traders/:id/share-links/:link_id", editor, s.handleDeleteShareLink)
+
+			// 交易事件webhook
+			protected.GET("/webhooks", editor, s.handleListWebhooks)
+			protected.POST("/webhooks", editor, s.handleCreateWebhook)
+			protected.DELETE("/webhooks/:id", editor, s.handleDeleteWebhook)
+			protected.POST("/webhooks/:id/test", editor, s.handleTestWebhook)
+
+			// Telegram告警和命令机器人绑定
+			protected.GET("/telegram/link", s.handleGetTelegramLink)
+			protected.POST("/telegram/link", s.handleCreateTelegramLinkToken)
+			protected.DELETE("/telegram/link", s.handleDeleteTelegramLink)
+
+			// 每日邮件摘要
+			protected.GET("/digest", s.handleGetDailyDigest)
+			protected.PUT("/digest", s.handleUpdateDailyDigest)
+			protected.GET("/digest/preview", s.handlePreviewDailyDigest)
+
+			// 跟单：用自己的交易所账户镜像其他交易员
+			protected.GET("/follows", editor, s.handleListFollows)
+			protected.POST("/follows", editor, s.handleCreateFollow)
+			protected.PUT("/follows/:id", editor, s.handleUpdateFollow)
+			protected.DELETE("/follows/:id", editor, s.handleDeleteFollow)
 
 			// AI模型配置
-			protected.GET("/models", s.handleGetModelConfigs)
-			protected.PUT("/models", s.handleUpdateModelConfigs)
+			protected.GET("/models", editor, s.handleGetModelConfigs)
+			protected.PUT("/models", editor, s.handleUpdateModelConfigs)
 
 			// 交易所配置
-			protected.GET("/exchanges", s.handleGetExchangeConfigs)
-			protected.PUT("/exchanges", s.handleUpdateExchangeConfigs)
+			protected.GET("/exchanges", editor, s.handleGetExchangeConfigs)
+			protected.PUT("/exchanges", editor, s.handleUpdateExchangeConfigs)
 
 			// 用户信号源配置
 			protected.GET("/user/signal-sources", s.handleGetUserSignalSource)
-			protected.POST("/user/signal-sources", s.handleSaveUserSignalSource)
+			protected.POST("/user/signal-sources", editor, s.handleSaveUserSignalSource)
+
+			// 重新绑定验证器、恢复码
+			protected.POST("/user/otp/reset", s.handleResetOTP)
+			protected.POST("/user/otp/confirm", s.handleConfirmOTPReset)
+			protected.GET("/user/recovery-codes", s.handleGetRecoveryCodeStatus)
+			protected.POST("/user/recovery-codes", s.handleRegenerateRecoveryCodes)
+
+			// 个人数据导出、注销账户
+			protected.GET("/user/export", s.handleExportAccountData)
+			protected.DELETE("/user", s.handleDeleteAccount)
 
 			// 指定trader的数据（使用query参数 ?trader_id=xxx）
 			protected.GET("/status", s.handleStatus)
@@ -135,22 +249,66 @@ func (s *Server) setupRoutes() {
 			protected.GET("/decisions/latest", s.handleLatestDecisions)
 			protected.GET("/statistics", s.handleStatistics)
 			protected.GET("/performance", s.handlePerformance)
+			protected.GET("/traders/:id/orders", s.handleTraderOrders)
+			protected.GET("/traders/:id/position-history", s.handlePositionHistory)
+			protected.GET("/traders/:id/decisions/search", s.handleSearchDecisions)
+			protected.GET("/traders/:id/performance/breakdown", s.handlePerformanceBreakdown)
+			protected.POST("/traders/:id/decisions/:record_id/annotations", editor, s.handleAddAnnotation)
+			protected.DELETE("/traders/:id/decisions/:record_id/annotations/:annotation_id", editor, s.handleDeleteAnnotation)
+
+			// 用户所有交易员的组合风险
+			protected.GET("/portfolio", s.handlePortfolio)
+			protected.GET("/portfolio/delta", s.handleNetDelta)
+			protected.GET("/traders/:id/var", s.handleTraderVaR)
+
+			// 市场数据导出（核对AI看到的K线和指标）
+			protected.GET("/market-data/:symbol/export", s.handleExportMarketData)
 
 			// AI决策测试功能
-			protected.POST("/ai-test/generate-prompt", s.handleGenerateUserPrompt)
-			protected.POST("/ai-test/get-decision", s.handleTestAIDecision)
+			protected.POST("/ai-test/generate-prompt", editor, s.handleGenerateUserPrompt)
+			protected.POST("/ai-test/get-decision", editor, s.handleTestAIDecision)
+
+			// 回测：同一段历史对比多个提示词模板/模型
+			protected.POST("/backtest/compare", editor, s.handleBacktestCompare)
+			protected.GET("/backtest/runs", s.handleListBacktestRuns)
+			protected.GET("/backtest/runs/:id", s.handleGetBacktestRun)
+			protected.DELETE("/backtest/runs/:id", editor, s.handleDeleteBacktestRun)
+
+			// 管理员上限、用户角色
+			protected.GET("/user/limits", s.handleGetMyLimits)
+			admin := protected.Group("/admin", s.requireRole(config.RoleAdmin))
+			{
+				admin.GET("/users", s.handleListUsers)
+				admin.GET("/users/:user_id", s.handleGetUser)
+				admin.PUT("/users/:user_id/role", s.handleUpdateUserRole)
+				admin.POST("/users/:user_id/disable", s.handleDisableUser)
+				admin.POST("/users/:user_id/enable", s.handleEnableUser)
+				admin.POST("/users/:user_id/reset-otp", s.handleAdminResetOTP)
+				admin.GET("/system-config", s.handleGetAdminSystemConfig)
+				admin.PUT("/system-config", s.handleUpdateAdminSystemConfig)
+				admin.GET("/system-config/audit", s.handleGetSystemConfigAudit)
+				admin.GET("/trader-resume", s.handleGetTraderResumeReport)
+				admin.GET("/ai-scheduler", s.handleGetAISchedulerStats)
+				admin.GET("/backups", s.handleListBackups)
+				admin.POST("/backups", s.handleCreateBackup)
+				admin.GET("/backups/:name", s.handleDownloadBackup)
+				admin.GET("/traders", s.handleAdminListTraders)
+				admin.POST("/traders/:id/stop", s.handleAdminStopTrader)
+				admin.POST("/rotate-key", s.handleRotateKey)
+				admin.GET("/beta-codes", s.handleGetBetaCodes)
+				admin.POST("/beta-codes", s.handleCreateBetaCodes)
+				admin.GET("/limits", s.handleGetLimits)
+				admin.PUT("/limits", s.handleUpdateSystemLimits)
+				admin.PUT("/limits/:user_id", s.handleUpdateUserLimits)
+				admin.DELETE("/limits/:user_id", s.handleDeleteUserLimits)
+				admin.POST("/seasons", s.handleCreateSeason)
+				admin.PUT("/seasons/:id", s.handleUpdateSeason)
+				admin.DELETE("/seasons/:id", s.handleDeleteSeason)
+			}
 		}
 	}
 }
 
-// handleHealth 健康检查
-func (s *Server) handleHealth(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-		"time":   c.Request.Context().Value("time"),
-	})
-}
-
 // handleGetSystemConfig 获取系统配置（客户端需要知道的配置）
 func (s *Server) handleGetSystemConfig(c *gin.Context) {
 	// 获取默认币种
@@ -182,12 +340,12 @@ func (s *Server) handleGetSystemConfig(c *gin.Context) {
 	betaModeStr, _ := s.database.GetSystemConfig("beta_mode")
 	betaMode := betaModeStr == "true"
 
-	c.JSON(http.StatusOK, gin.H{
-		"admin_mode":       auth.IsAdminMode(),
-		"beta_mode":        betaMode,
-		"default_coins":    defaultCoins,
-		"btc_eth_leverage": btcEthLeverage,
-		"altcoin_leverage": altcoinLeverage,
+	c.JSON(http.StatusOK, SystemConfigResponse{
+		AdminMode:       auth.IsAdminMode(),
+		BetaMode:        betaMode,
+		DefaultCoins:    defaultCoins,
+		BTCETHLeverage:  btcEthLeverage,
+		AltcoinLeverage: altcoinLeverage,
 	})
 }
 
@@ -199,22 +357,29 @@ func (s *Server) getTraderFromQuery(c *gin.Context) (*manager.TraderManager, str
 	// 确保用户的交易员已加载到内存中
 	err := s.traderManager.LoadUserTraders(s.database, userID)
 	if err != nil {
-		log.Printf("⚠️ 加载用户 %s 的交易员失败: %v", userID, err)
+		requestLog(c).Warn("加载用户的交易员失败", "owner_id", userID, "error", err)
 	}
 
 	if traderID == "" {
-		// 如果没有指定trader_id，返回该用户的第一个trader
-		ids := s.traderManager.GetTraderIDs()
-		if len(ids) == 0 {
+		// 如果没有指定trader_id，优先返回用户自己的交易员，其次是共享给用户的
+		if userTraders, err := s.database.GetTraders(userID); err == nil && len(userTraders) > 0 {
+			traderID = userTraders[0].ID
+		} else if shared, err := s.database.GetSharedTraders(userID); err == nil && len(shared) > 0 {
+			traderID = shared[0].ID
+		} else if ids := s.traderManager.GetTraderIDs(); c.GetString("role") == config.RoleAdmin && len(ids) > 0 {
+			traderID = ids[0]
+		} else {
 			return nil, "", fmt.Errorf("没有可用的trader")
 		}
+	}
 
-		// 获取用户的交易员列表，优先返回用户自己的交易员
-		userTraders, err := s.database.GetTraders(userID)
-		if err == nil && len(userTraders) > 0 {
-			traderID = userTraders[0].ID
-		} else {
-			traderID = ids[0]
+	if !s.canViewTrader(userID, traderID) {
+		return nil, "", fmt.Errorf("交易员不存在或无访问权限")
+	}
+	// 共享的交易员属于其他用户，确保已加载
+	if ownerID, err := s.database.GetTraderOwner(traderID); err == nil && ownerID != userID {
+		if err := s.traderManager.LoadUserTraders(s.database, ownerID); err != nil {
+			requestLog(c).Warn("加载用户的交易员失败", "owner_id", ownerID, "error", err)
 		}
 	}
 
@@ -237,7 +402,12 @@ type CreateTraderRequest struct {
 	IsCrossMargin        *bool   `json:"is_cross_margin"`        // 指针类型，nil表示使用默认值true
 	UseCoinPool          bool    `json:"use_coin_pool"`
 	UseOITop             bool    `json:"use_oi_top"`
-	BinanceProxyURL      string  `json:"binance_proxy_url"` // 币安代理URL，如"http://proxy.example.com:8080"
+	BinanceProxyURL      string  `json:"binance_proxy_url"`     // 币安代理URL，如"http://proxy.example.com:8080"
+	MaxCandidates        int     `json:"max_candidates"`        // 每个周期发给AI的候选币种上限，0 使用默认值
+	PoolRefreshMinutes   int     `json:"pool_refresh_minutes"`  // 候选币种池刷新间隔（分钟），0 每个周期刷新
+	LiquidityFilter      string  `json:"liquidity_filter"`      // 流动性过滤方式：oi_value（默认）/volume/both/off
+	MinOIValueMillions   float64 `json:"min_oi_value_millions"` // 最低持仓价值（百万USDT），0 使用默认值15
+	MinVolumeMillions    float64 `json:"min_volume_millions"`   // 最低24h成交额（百万USDT），0 使用默认值50
 }
 
 type ModelConfig struct {
@@ -286,30 +456,32 @@ func (s *Server) handleCreateTrader(c *gin.Context) {
 	userID := c.GetString("user_id")
 	var req CreateTraderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	// 校验杠杆值
-	if req.BTCETHLeverage < 0 || req.BTCETHLeverage > 50 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "BTC/ETH杠杆必须在1-50倍之间"})
+	if err := validateTraderSettings(req.BTCETHLeverage, req.AltcoinLeverage, req.TradingSymbols); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
-	if req.AltcoinLeverage < 0 || req.AltcoinLeverage > 20 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "山寨币杠杆必须在1-20倍之间"})
+	if err := validateCandidateSettings(req.MaxCandidates, req.PoolRefreshMinutes); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validateLiquiditySettings(req.LiquidityFilter, req.MinOIValueMillions, req.MinVolumeMillions); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	// 校验交易币种格式
-	if req.TradingSymbols != "" {
-		symbols := strings.Split(req.TradingSymbols, ",")
-		for _, symbol := range symbols {
-			symbol = strings.TrimSpace(symbol)
-			if symbol != "" && !strings.HasSuffix(strings.ToUpper(symbol), "USDT") {
-				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的币种格式: %s，必须以USDT结尾", symbol)})
-				return
-			}
-		}
+	// 校验管理员设置的交易员数量上限
+	limits, err := s.database.GetEffectiveLimits(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := s.checkTraderCountQuota(userID, limits); err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
 	}
 
 	// 生成交易员ID
@@ -351,10 +523,14 @@ func (s *Server) handleCreateTrader(c *gin.Context) {
 		systemPromptTemplate = req.SystemPromptTemplate
 	}
 
-	// 设置扫描间隔默认值
+	// 设置扫描间隔默认值（不低于管理员设置的下限）
 	scanIntervalMinutes := req.ScanIntervalMinutes
 	if scanIntervalMinutes <= 0 {
-		scanIntervalMinutes = 3 // 默认3分钟
+		scanIntervalMinutes = max(defaultScanIntervalMinutes, limits.MinScanInterval)
+	}
+	if err := checkScanIntervalQuota(scanIntervalMinutes, limits); err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
 	}
 
 	// 创建交易员配置（数据库实体）
@@ -375,34 +551,77 @@ func (s *Server) handleCreateTrader(c *gin.Context) {
 		SystemPromptTemplate: systemPromptTemplate,
 		IsCrossMargin:        isCrossMargin,
 		BinanceProxyURL:      req.BinanceProxyURL,
+		MaxCandidates:        req.MaxCandidates,
+		PoolRefreshMinutes:   req.PoolRefreshMinutes,
+		LiquidityFilter:      req.LiquidityFilter,
+		MinOIValueMillions:   req.MinOIValueMillions,
+		MinVolumeMillions:    req.MinVolumeMillions,
 		ScanIntervalMinutes:  scanIntervalMinutes,
 		IsRunning:            false,
 	}
 
 	// 保存到数据库
-	err := s.database.CreateTrader(trader)
+	err = s.database.CreateTrader(trader)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建交易员失败: %v", err)})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("创建交易员失败: %v", err)})
 		return
 	}
 
 	// 立即将新交易员加载到TraderManager中
 	err = s.traderManager.LoadUserTraders(s.database, userID)
 	if err != nil {
-		log.Printf("⚠️ 加载用户交易员到内存失败: %v", err)
+		requestLog(c).Warn("加载用户交易员到内存失败", "error", err)
 		// 这里不返回错误，因为交易员已经成功创建到数据库
 	}
 
-	log.Printf("✓ 创建交易员成功: %s (模型: %s, 交易所: %s)", req.Name, req.AIModelID, req.ExchangeID)
+	requestLog(c).Info("创建交易员成功", "trader_id", traderID, "name", req.Name, "ai_model", req.AIModelID, "exchange", req.ExchangeID)
 
-	c.JSON(http.StatusCreated, gin.H{
-		"trader_id":   traderID,
-		"trader_name": req.Name,
-		"ai_model":    req.AIModelID,
-		"is_running":  false,
+	c.JSON(http.StatusCreated, CreateTraderResponse{
+		TraderID:   traderID,
+		TraderName: req.Name,
+		AIModel:    req.AIModelID,
+		IsRunning:  false,
 	})
 }
 
+// validateTraderSettings 校验杠杆范围（0表示使用默认值）和交易币种格式
+func validateTraderSettings(btcEthLeverage, altcoinLeverage int, tradingSymbols string) error {
+	if btcEthLeverage < 0 || btcEthLeverage > 50 {
+		return errors.New("BTC/ETH杠杆必须在1-50倍之间")
+	}
+	if altcoinLeverage < 0 || altcoinLeverage > 20 {
+		return errors.New("山寨币杠杆必须在1-20倍之间")
+	}
+	if tradingSymbols != "" {
+		for _, symbol := range strings.Split(tradingSymbols, ",") {
+			symbol = strings.TrimSpace(symbol)
+			if symbol != "" && !strings.HasSuffix(strings.ToUpper(symbol), "USDT") {
+				return fmt.Errorf("无效的币种格式: %s，必须以USDT结尾", symbol)
+			}
+		}
+	}
+	return nil
+}
+
+// maxPoolRefreshMinutes 候选币种池刷新间隔的上限（分钟）
+const maxPoolRefreshMinutes = 1440
+
+// validateCandidateSettings 校验候选币种上限和币种池刷新间隔
+func validateCandidateSettings(maxCandidates, poolRefreshMinutes int) error {
+	if maxCandidates < 0 || maxCandidates > decision.MaxCandidatesLimit {
+		return fmt.Errorf("候选币种上限必须在0-%d之间（0 使用默认值）", decision.MaxCandidatesLimit)
+	}
+	if poolRefreshMinutes < 0 || poolRefreshMinutes > maxPoolRefreshMinutes {
+		return fmt.Errorf("币种池刷新间隔必须在0-%d分钟之间（0 每个周期刷新）", maxPoolRefreshMinutes)
+	}
+	return nil
+}
+
+// validateLiquiditySettings 校验流动性过滤方式和门槛（百万USDT）
+func validateLiquiditySettings(mode string, minOIValueMillions, minVolumeMillions float64) error {
+	return decision.LiquidityFilter{Mode: mode, MinOIValue: minOIValueMillions, MinVolume24h: minVolumeMillions}.Validate()
+}
+
 // UpdateTraderRequest 更新交易员请求
 type UpdateTraderRequest struct {
 	Name                 string  `json:"name" binding:"required"`
@@ -420,6 +639,12 @@ type UpdateTraderRequest struct {
 	UseCoinPool          bool    `json:"use_coin_pool"`
 	UseOITop             bool    `json:"use_oi_top"`
 	BinanceProxyURL      string  `json:"binance_proxy_url"`
+	MaxCandidates        *int    `json:"max_candidates"`       // nil 保持原值
+	PoolRefreshMinutes   *int    `json:"pool_refresh_minutes"` // nil 保持原值
+
+	LiquidityFilter    *string  `json:"liquidity_filter"`      // nil 保持原值
+	MinOIValueMillions *float64 `json:"min_oi_value_millions"` // nil 保持原值
+	MinVolumeMillions  *float64 `json:"min_volume_millions"`   // nil 保持原值
 }
 
 // handleUpdateTrader 更新交易员配置
@@ -429,14 +654,14 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 
 	var req UpdateTraderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	// 检查交易员是否存在且属于当前用户
 	traders, err := s.database.GetTraders(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取交易员列表失败"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "获取交易员列表失败"})
 		return
 	}
 
@@ -449,7 +674,7 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 	}
 
 	if existingTrader == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在"})
 		return
 	}
 
@@ -459,6 +684,35 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 		isCrossMargin = *req.IsCrossMargin
 	}
 
+	// 候选币种设置，未传时保持原值
+	maxCandidates, poolRefreshMinutes := existingTrader.MaxCandidates, existingTrader.PoolRefreshMinutes
+	if req.MaxCandidates != nil {
+		maxCandidates = *req.MaxCandidates
+	}
+	if req.PoolRefreshMinutes != nil {
+		poolRefreshMinutes = *req.PoolRefreshMinutes
+	}
+	if err := validateCandidateSettings(maxCandidates, poolRefreshMinutes); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// 流动性门槛，未传时保持原值
+	liquidityFilter, minOIValue, minVolume := existingTrader.LiquidityFilter, existingTrader.MinOIValueMillions, existingTrader.MinVolumeMillions
+	if req.LiquidityFilter != nil {
+		liquidityFilter = *req.LiquidityFilter
+	}
+	if req.MinOIValueMillions != nil {
+		minOIValue = *req.MinOIValueMillions
+	}
+	if req.MinVolumeMillions != nil {
+		minVolume = *req.MinVolumeMillions
+	}
+	if err := validateLiquiditySettings(liquidityFilter, minOIValue, minVolume); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	// 设置杠杆默认值
 	btcEthLeverage := req.BTCETHLeverage
 	altcoinLeverage := req.AltcoinLeverage
@@ -473,6 +727,16 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 	scanIntervalMinutes := req.ScanIntervalMinutes
 	if scanIntervalMinutes <= 0 {
 		scanIntervalMinutes = existingTrader.ScanIntervalMinutes // 保持原值
+	} else if scanIntervalMinutes != existingTrader.ScanIntervalMinutes {
+		limits, err := s.database.GetEffectiveLimits(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err := checkScanIntervalQuota(scanIntervalMinutes, limits); err != nil {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+			return
+		}
 	}
 
 	// 更新交易员配置
@@ -495,28 +759,33 @@ func (s *Server) handleUpdateTrader(c *gin.Context) {
 		UseCoinPool:          req.UseCoinPool,
 		UseOITop:             req.UseOITop,
 		BinanceProxyURL:      req.BinanceProxyURL,
+		MaxCandidates:        maxCandidates,
+		PoolRefreshMinutes:   poolRefreshMinutes,
+		LiquidityFilter:      liquidityFilter,
+		MinOIValueMillions:   minOIValue,
+		MinVolumeMillions:    minVolume,
 	}
 
 	// 更新数据库
 	err = s.database.UpdateTrader(trader)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新交易员失败: %v", err)})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("更新交易员失败: %v", err)})
 		return
 	}
 
 	// 重新加载交易员到内存
 	err = s.traderManager.LoadUserTraders(s.database, userID)
 	if err != nil {
-		log.Printf("⚠️ 重新加载用户交易员到内存失败: %v", err)
+		requestLog(c).Warn("重新加载用户交易员到内存失败", "error", err)
 	}
 
-	log.Printf("✓ 更新交易员成功: %s (模型: %s, 交易所: %s)", req.Name, req.AIModelID, req.ExchangeID)
+	requestLog(c).Info("更新交易员成功", "trader_id", traderID, "name", req.Name, "ai_model", req.AIModelID, "exchange", req.ExchangeID)
 
-	c.JSON(http.StatusOK, gin.H{
-		"trader_id":   traderID,
-		"trader_name": req.Name,
-		"ai_model":    req.AIModelID,
-		"message":     "交易员更新成功",
+	c.JSON(http.StatusOK, UpdateTraderResponse{
+		TraderID:   traderID,
+		TraderName: req.Name,
+		AIModel:    req.AIModelID,
+		Message:    "交易员更新成功",
 	})
 }
 
@@ -528,7 +797,7 @@ func (s *Server) handleDeleteTrader(c *gin.Context) {
 	// 从数据库删除
 	err := s.database.DeleteTrader(userID, traderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("删除交易员失败: %v", err)})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("删除交易员失败: %v", err)})
 		return
 	}
 
@@ -537,12 +806,15 @@ func (s *Server) handleDeleteTrader(c *gin.Context) {
 		status := trader.GetStatus()
 		if isRunning, ok := status["is_running"].(bool); ok && isRunning {
 			trader.Stop()
-			log.Printf("⏹  已停止运行中的交易员: %s", traderID)
+			requestLog(c).Info("已停止运行中的交易员", "trader_id", traderID)
 		}
 	}
+	// 跟单记录已随交易员删除，同时停止运行中的跟单
+	s.traderManager.StopFollowsOf(traderID)
+	logging.DropTraderLogs(traderID)
 
-	log.Printf("✓ 交易员已删除: %s", traderID)
-	c.JSON(http.StatusOK, gin.H{"message": "交易员已删除"})
+	requestLog(c).Info("交易员已删除", "trader_id", traderID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "交易员已删除"})
 }
 
 // handleStartTrader 启动交易员
@@ -553,41 +825,35 @@ func (s *Server) handleStartTrader(c *gin.Context) {
 	// 校验交易员是否属于当前用户
 	_, _, _, err := s.database.GetTraderConfig(userID, traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在或无访问权限"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在"})
 		return
 	}
 
 	// 检查交易员是否已经在运行
 	status := trader.GetStatus()
 	if isRunning, ok := status["is_running"].(bool); ok && isRunning {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "交易员已在运行中"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "交易员已在运行中"})
 		return
 	}
 
-	// 启动交易员
-	go func() {
-		log.Printf("▶️  启动交易员 %s (%s)", traderID, trader.GetName())
-		if err := trader.Run(); err != nil {
-			log.Printf("❌ 交易员 %s 运行错误: %v", trader.GetName(), err)
-		}
-	}()
-
-	// 更新数据库中的运行状态
-	err = s.database.UpdateTraderStatus(userID, traderID, true)
-	if err != nil {
-		log.Printf("⚠️  更新交易员状态失败: %v", err)
+	if code, err := s.startTrader(c, userID, trader); err != nil {
+		c.JSON(code, ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	log.Printf("✓ 交易员 %s 已启动", trader.GetName())
-	c.JSON(http.StatusOK, gin.H{"message": "交易员已启动"})
+	requestLog(c).Info("交易员已启动", "trader_id", traderID, "name", trader.GetName())
+	c.JSON(http.StatusOK, MessageResponse{Message: "交易员已启动"})
 }
 
+// traderStopTimeout 停止交易员时等待当前周期退出的最长时间
+const traderStopTimeout = 10 * time.Second
+
 // handleStopTrader 停止交易员
 func (s *Server) handleStopTrader(c *gin.Context) {
 	userID := c.GetString("user_id")
@@ -596,34 +862,58 @@ func (s *Server) handleStopTrader(c *gin.Context) {
 	// 校验交易员是否属于当前用户
 	_, _, _, err := s.database.GetTraderConfig(userID, traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在或无访问权限"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在"})
 		return
 	}
 
 	// 检查交易员是否正在运行
 	status := trader.GetStatus()
 	if isRunning, ok := status["is_running"].(bool); ok && !isRunning {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "交易员已停止"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "交易员已停止"})
 		return
 	}
 
-	// 停止交易员
-	trader.Stop()
+	if stopErr := s.stopTrader(c, userID, trader); stopErr != nil {
+		// 正在执行下单等不可中断的操作，完成后自行退出
+		requestLog(c).Warn("交易员停止超时，将在当前操作完成后退出", "trader_id", traderID, "name", trader.GetName())
+		c.JSON(http.StatusOK, MessageResponse{Message: "交易员正在停止，当前操作完成后退出"})
+		return
+	}
+	requestLog(c).Info("交易员已停止", "trader_id", traderID, "name", trader.GetName())
+	c.JSON(http.StatusOK, MessageResponse{Message: "交易员已停止"})
+}
+
+// handleResumeTrader 手动解除交易员的净值熔断
+func (s *Server) handleResumeTrader(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	// 校验交易员是否属于当前用户
+	_, _, _, err := s.database.GetTraderConfig(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
 
-	// 更新数据库中的运行状态
-	err = s.database.UpdateTraderStatus(userID, traderID, false)
+	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		log.Printf("⚠️  更新交易员状态失败: %v", err)
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在"})
+		return
 	}
 
-	log.Printf("⏹  交易员 %s 已停止", trader.GetName())
-	c.JSON(http.StatusOK, gin.H{"message": "交易员已停止"})
+	if err := trader.ResumeFromCircuitBreaker(); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	requestLog(c).Info("交易员已解除熔断", "trader_id", traderID, "name", trader.GetName())
+	c.JSON(http.StatusOK, MessageResponse{Message: "已解除熔断，恢复开仓"})
 }
 
 // handleUpdateTraderPrompt 更新交易员自定义Prompt
@@ -631,20 +921,17 @@ func (s *Server) handleUpdateTraderPrompt(c *gin.Context) {
 	traderID := c.Param("id")
 	userID := c.GetString("user_id")
 
-	var req struct {
-		CustomPrompt       string `json:"custom_prompt"`
-		OverrideBasePrompt bool   `json:"override_base_prompt"`
-	}
+	var req UpdatePromptRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	// 更新数据库
 	err := s.database.UpdateTraderCustomPrompt(userID, traderID, req.CustomPrompt, req.OverrideBasePrompt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新自定义prompt失败: %v", err)})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("更新自定义prompt失败: %v", err)})
 		return
 	}
 
@@ -653,23 +940,21 @@ func (s *Server) handleUpdateTraderPrompt(c *gin.Context) {
 	if err == nil {
 		trader.SetCustomPrompt(req.CustomPrompt)
 		trader.SetOverrideBasePrompt(req.OverrideBasePrompt)
-		log.Printf("✓ 已更新交易员 %s 的自定义prompt (覆盖基础=%v)", trader.GetName(), req.OverrideBasePrompt)
+		requestLog(c).Info("已更新交易员的自定义prompt", "trader_id", traderID, "override_base", req.OverrideBasePrompt)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "自定义prompt已更新"})
+	c.JSON(http.StatusOK, MessageResponse{Message: "自定义prompt已更新"})
 }
 
 // handleGetModelConfigs 获取AI模型配置
 func (s *Server) handleGetModelConfigs(c *gin.Context) {
 	userID := c.GetString("user_id")
-	log.Printf("🔍 查询用户 %s 的AI模型配置", userID)
 	models, err := s.database.GetAIModels(userID)
 	if err != nil {
-		log.Printf("❌ 获取AI模型配置失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取AI模型配置失败: %v", err)})
+		requestLog(c).Error("获取AI模型配置失败", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取AI模型配置失败: %v", err)})
 		return
 	}
-	log.Printf("✅ 找到 %d 个AI模型配置", len(models))
 
 	c.JSON(http.StatusOK, models)
 }
@@ -679,7 +964,7 @@ func (s *Server) handleUpdateModelConfigs(c *gin.Context) {
 	userID := c.GetString("user_id")
 	var req UpdateModelConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -687,7 +972,7 @@ func (s *Server) handleUpdateModelConfigs(c *gin.Context) {
 	for modelID, modelData := range req.Models {
 		err := s.database.UpdateAIModel(userID, modelID, modelData.Enabled, modelData.APIKey, modelData.CustomAPIURL, modelData.CustomModelName)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新模型 %s 失败: %v", modelID, err)})
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("更新模型 %s 失败: %v", modelID, err)})
 			return
 		}
 	}
@@ -695,25 +980,23 @@ func (s *Server) handleUpdateModelConfigs(c *gin.Context) {
 	// 重新加载该用户的所有交易员，使新配置立即生效
 	err := s.traderManager.LoadUserTraders(s.database, userID)
 	if err != nil {
-		log.Printf("⚠️ 重新加载用户交易员到内存失败: %v", err)
+		requestLog(c).Warn("重新加载用户交易员到内存失败", "error", err)
 		// 这里不返回错误，因为模型配置已经成功更新到数据库
 	}
 
-	log.Printf("✓ AI模型配置已更新: %+v", req.Models)
-	c.JSON(http.StatusOK, gin.H{"message": "模型配置已更新"})
+	requestLog(c).Info("AI模型配置已更新", "count", len(req.Models))
+	c.JSON(http.StatusOK, MessageResponse{Message: "模型配置已更新"})
 }
 
 // handleGetExchangeConfigs 获取交易所配置
 func (s *Server) handleGetExchangeConfigs(c *gin.Context) {
 	userID := c.GetString("user_id")
-	log.Printf("🔍 查询用户 %s 的交易所配置", userID)
 	exchanges, err := s.database.GetExchanges(userID)
 	if err != nil {
-		log.Printf("❌ 获取交易所配置失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取交易所配置失败: %v", err)})
+		requestLog(c).Error("获取交易所配置失败", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易所配置失败: %v", err)})
 		return
 	}
-	log.Printf("✅ 找到 %d 个交易所配置", len(exchanges))
 
 	c.JSON(http.StatusOK, exchanges)
 }
@@ -723,7 +1006,7 @@ func (s *Server) handleUpdateExchangeConfigs(c *gin.Context) {
 	userID := c.GetString("user_id")
 	var req UpdateExchangeConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -731,7 +1014,7 @@ func (s *Server) handleUpdateExchangeConfigs(c *gin.Context) {
 	for exchangeID, exchangeData := range req.Exchanges {
 		err := s.database.UpdateExchange(userID, exchangeID, exchangeData.Enabled, exchangeData.APIKey, exchangeData.SecretKey, exchangeData.Testnet, exchangeData.HyperliquidWalletAddr, exchangeData.AsterUser, exchangeData.AsterSigner, exchangeData.AsterPrivateKey)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新交易所 %s 失败: %v", exchangeID, err)})
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("更新交易所 %s 失败: %v", exchangeID, err)})
 			return
 		}
 	}
@@ -739,12 +1022,12 @@ func (s *Server) handleUpdateExchangeConfigs(c *gin.Context) {
 	// 重新加载该用户的所有交易员，使新配置立即生效
 	err := s.traderManager.LoadUserTraders(s.database, userID)
 	if err != nil {
-		log.Printf("⚠️ 重新加载用户交易员到内存失败: %v", err)
+		requestLog(c).Warn("重新加载用户交易员到内存失败", "error", err)
 		// 这里不返回错误，因为交易所配置已经成功更新到数据库
 	}
 
-	log.Printf("✓ 交易所配置已更新: %+v", req.Exchanges)
-	c.JSON(http.StatusOK, gin.H{"message": "交易所配置已更新"})
+	requestLog(c).Info("交易所配置已更新", "count", len(req.Exchanges))
+	c.JSON(http.StatusOK, MessageResponse{Message: "交易所配置已更新"})
 }
 
 // handleGetUserSignalSource 获取用户信号源配置
@@ -753,60 +1036,80 @@ func (s *Server) handleGetUserSignalSource(c *gin.Context) {
 	source, err := s.database.GetUserSignalSource(userID)
 	if err != nil {
 		// 如果配置不存在，返回空配置而不是404错误
-		c.JSON(http.StatusOK, gin.H{
-			"coin_pool_url": "",
-			"oi_top_url":    "",
-		})
+		c.JSON(http.StatusOK, SignalSource{})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"coin_pool_url": source.CoinPoolURL,
-		"oi_top_url":    source.OITopURL,
+	c.JSON(http.StatusOK, SignalSource{
+		CoinPoolURL: source.CoinPoolURL,
+		OITopURL:    source.OITopURL,
 	})
 }
 
 // handleSaveUserSignalSource 保存用户信号源配置
 func (s *Server) handleSaveUserSignalSource(c *gin.Context) {
 	userID := c.GetString("user_id")
-	var req struct {
-		CoinPoolURL string `json:"coin_pool_url"`
-		OITopURL    string `json:"oi_top_url"`
-	}
+	var req SignalSource
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	err := s.database.CreateUserSignalSource(userID, req.CoinPoolURL, req.OITopURL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存用户信号源配置失败: %v", err)})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存用户信号源配置失败: %v", err)})
 		return
 	}
 
-	log.Printf("✓ 用户信号源配置已保存: user=%s, coin_pool=%s, oi_top=%s", userID, req.CoinPoolURL, req.OITopURL)
-	c.JSON(http.StatusOK, gin.H{"message": "用户信号源配置已保存"})
+	requestLog(c).Info("用户信号源配置已保存", "coin_pool", req.CoinPoolURL, "oi_top", req.OITopURL)
+	c.JSON(http.StatusOK, MessageResponse{Message: "用户信号源配置已保存"})
 }
 
 // handleTraderList trader列表
 func (s *Server) handleTraderList(c *gin.Context) {
 	userID := c.GetString("user_id")
+	tag, ok := tagFilter(c)
+	if !ok {
+		return
+	}
 	traders, err := s.database.GetTraders(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取交易员列表失败: %v", err)})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员列表失败: %v", err)})
+		return
+	}
+	// 共享给当前用户的交易员排在自己的之后（只读）
+	if shared, err := s.database.GetSharedTraders(userID); err == nil {
+		traders = append(traders, shared...)
+	}
+	traderIDs := make([]string, 0, len(traders))
+	for _, trader := range traders {
+		traderIDs = append(traderIDs, trader.ID)
+	}
+	tags, err := s.database.GetTraderTags(traderIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员标签失败: %v", err)})
 		return
 	}
 
-	result := make([]map[string]interface{}, 0, len(traders))
+	result := make([]TraderSummary, 0, len(traders))
 	for _, trader := range traders {
+		traderTags := tags[trader.ID]
+		if tag != "" && !slices.Contains(traderTags, tag) {
+			continue
+		}
+		if traderTags == nil {
+			traderTags = []string{}
+		}
+
 		// 获取实时运行状态
 		isRunning := trader.IsRunning
+		health := ""
+		paused := false
 		if at, err := s.traderManager.GetTrader(trader.ID); err == nil {
-			status := at.GetStatus()
-			if running, ok := status["is_running"].(bool); ok {
-				isRunning = running
-			}
+			isRunning = at.IsRunning()
+			health = at.GetHeartbeat(time.Now()).Health
+			paused = at.IsPaused()
 		}
 
 		// AIModelID 应该已经是 provider（如 "deepseek"），直接使用
@@ -818,13 +1121,17 @@ func (s *Server) handleTraderList(c *gin.Context) {
 			aiModelID = parts[len(parts)-1]
 		}
 
-		result = append(result, map[string]interface{}{
-			"trader_id":       trader.ID,
-			"trader_name":     trader.Name,
-			"ai_model":        aiModelID,
-			"exchange_id":     trader.ExchangeID,
-			"is_running":      isRunning,
-			"initial_balance": trader.InitialBalance,
+		result = append(result, TraderSummary{
+			TraderID:       trader.ID,
+			TraderName:     trader.Name,
+			AIModel:        aiModelID,
+			ExchangeID:     trader.ExchangeID,
+			IsRunning:      isRunning,
+			InitialBalance: trader.InitialBalance,
+			IsShared:       trader.UserID != userID,
+			Health:         health,
+			Paused:         paused,
+			Tags:           traderTags,
 		})
 	}
 
@@ -837,13 +1144,13 @@ func (s *Server) handleGetTraderConfig(c *gin.Context) {
 	traderID := c.Param("id")
 
 	if traderID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "交易员ID不能为空"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "交易员ID不能为空"})
 		return
 	}
 
 	traderConfig, _, _, err := s.database.GetTraderConfig(userID, traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("获取交易员配置失败: %v", err)})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("获取交易员配置失败: %v", err)})
 		return
 	}
 
@@ -877,6 +1184,11 @@ func (s *Server) handleGetTraderConfig(c *gin.Context) {
 		"is_running":             isRunning,
 		"binance_proxy_url":      traderConfig.BinanceProxyURL,
 		"system_prompt_template": traderConfig.SystemPromptTemplate,
+		"max_candidates":         traderConfig.MaxCandidates,
+		"pool_refresh_minutes":   traderConfig.PoolRefreshMinutes,
+		"liquidity_filter":       traderConfig.LiquidityFilter,
+		"min_oi_value_millions":  traderConfig.MinOIValueMillions,
+		"min_volume_millions":    traderConfig.MinVolumeMillions,
 	}
 
 	c.JSON(http.StatusOK, result)
@@ -886,13 +1198,13 @@ func (s *Server) handleGetTraderConfig(c *gin.Context) {
 func (s *Server) handleStatus(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -904,32 +1216,30 @@ func (s *Server) handleStatus(c *gin.Context) {
 func (s *Server) handleAccount(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	log.Printf("📊 收到账户信息请求 [%s]", trader.GetName())
+	tlog := trader.Logger().With("request_id", c.GetString("request_id"))
+	tlog.Debug("收到账户信息请求")
 	account, err := trader.GetAccountInfo()
 	if err != nil {
-		log.Printf("❌ 获取账户信息失败 [%s]: %v", trader.GetName(), err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取账户信息失败: %v", err),
-		})
+		tlog.Error("获取账户信息失败", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取账户信息失败: %v", err)})
 		return
 	}
 
-	log.Printf("✓ 返回账户信息 [%s]: 净值=%.2f, 可用=%.2f, 盈亏=%.2f (%.2f%%)",
-		trader.GetName(),
-		account["total_equity"],
-		account["available_balance"],
-		account["total_pnl"],
-		account["total_pnl_pct"])
+	tlog.Debug("返回账户信息",
+		"total_equity", account["total_equity"],
+		"available_balance", account["available_balance"],
+		"total_pnl", account["total_pnl"],
+		"total_pnl_pct", account["total_pnl_pct"])
 	c.JSON(http.StatusOK, account)
 }
 
@@ -937,47 +1247,116 @@ func (s *Server) handleAccount(c *gin.Context) {
 func (s *Server) handlePositions(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	positions, err := trader.GetPositions()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取持仓列表失败: %v", err),
-		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取持仓列表失败: %v", err)})
 		return
 	}
 
 	c.JSON(http.StatusOK, positions)
 }
 
+// handlePortfolio 汇总当前用户所有交易员的净值、敞口、相关性风险和最坏强平情景
+func (s *Server) handlePortfolio(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traders, err := s.database.GetTraders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员列表失败: %v", err)})
+		return
+	}
+
+	traderIDs := make([]string, 0, len(traders))
+	for _, t := range traders {
+		traderIDs = append(traderIDs, t.ID)
+	}
+
+	portfolio, err := s.traderManager.GetPortfolio(traderIDs)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, portfolio)
+}
+
+// handleNetDelta 当前用户每个交易员及整体的多空净敞口
+func (s *Server) handleNetDelta(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traders, err := s.database.GetTraders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员列表失败: %v", err)})
+		return
+	}
+
+	traderIDs := make([]string, 0, len(traders))
+	for _, t := range traders {
+		traderIDs = append(traderIDs, t.ID)
+	}
+
+	report, err := s.traderManager.GetDeltaReport(traderIDs)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// handleTraderVaR 交易员及用户组合的1日VaR
+func (s *Server) handleTraderVaR(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	// 校验交易员是否属于当前用户
+	if _, _, _, err := s.database.GetTraderConfig(userID, traderID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+	traders, err := s.database.GetTraders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员列表失败: %v", err)})
+		return
+	}
+	traderIDs := make([]string, 0, len(traders))
+	for _, t := range traders {
+		traderIDs = append(traderIDs, t.ID)
+	}
+
+	result, err := s.traderManager.GetTraderVaR(traderID, traderIDs)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // handleDecisions 决策日志列表
 func (s *Server) handleDecisions(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	// 获取所有历史决策记录（无限制）
 	records, err := trader.GetDecisionLogger().GetLatestRecords(10000)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取决策日志失败: %v", err),
-		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取决策日志失败: %v", err)})
 		return
 	}
 
@@ -988,21 +1367,19 @@ func (s *Server) handleDecisions(c *gin.Context) {
 func (s *Server) handleLatestDecisions(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	records, err := trader.GetDecisionLogger().GetLatestRecords(5)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取决策日志失败: %v", err),
-		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取决策日志失败: %v", err)})
 		return
 	}
 
@@ -1019,21 +1396,19 @@ func (s *Server) handleLatestDecisions(c *gin.Context) {
 func (s *Server) handleStatistics(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	stats, err := trader.GetDecisionLogger().GetStatistics()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取统计信息失败: %v", err),
-		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取统计信息失败: %v", err)})
 		return
 	}
 
@@ -1047,14 +1422,12 @@ func (s *Server) handleCompetition(c *gin.Context) {
 	// 确保用户的交易员已加载到内存中
 	err := s.traderManager.LoadUserTraders(s.database, userID)
 	if err != nil {
-		log.Printf("⚠️ 加载用户 %s 的交易员失败: %v", userID, err)
+		requestLog(c).Warn("加载用户的交易员失败", "owner_id", userID, "error", err)
 	}
 
 	competition, err := s.traderManager.GetCompetitionData()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取竞赛数据失败: %v", err),
-		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取竞赛数据失败: %v", err)})
 		return
 	}
 
@@ -1065,85 +1438,27 @@ func (s *Server) handleCompetition(c *gin.Context) {
 func (s *Server) handleEquityHistory(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	// 获取尽可能多的历史数据（几天的数据）
-	// 每3分钟一个周期：10000条 = 约20天的数据
-	records, err := trader.GetDecisionLogger().GetLatestRecords(10000)
+	bucket, err := parseEquityGranularity(c.Query("granularity"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取历史数据失败: %v", err),
-		})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
-
-	// 构建收益率历史数据点
-	type EquityPoint struct {
-		Timestamp        string  `json:"timestamp"`
-		TotalEquity      float64 `json:"total_equity"`      // 账户净值（wallet + unrealized）
-		AvailableBalance float64 `json:"available_balance"` // 可用余额
-		TotalPnL         float64 `json:"total_pnl"`         // 总盈亏（相对初始余额）
-		TotalPnLPct      float64 `json:"total_pnl_pct"`     // 总盈亏百分比
-		PositionCount    int     `json:"position_count"`    // 持仓数量
-		MarginUsedPct    float64 `json:"margin_used_pct"`   // 保证金使用率
-		CycleNumber      int     `json:"cycle_number"`
-	}
-
-	// 从AutoTrader获取初始余额（用于计算盈亏百分比）
-	initialBalance := 0.0
-	if status := trader.GetStatus(); status != nil {
-		if ib, ok := status["initial_balance"].(float64); ok && ib > 0 {
-			initialBalance = ib
-		}
-	}
-
-	// 如果无法从status获取，且有历史记录，则从第一条记录获取
-	if initialBalance == 0 && len(records) > 0 {
-		// 第一条记录的equity作为初始余额
-		initialBalance = records[0].AccountState.TotalBalance
-	}
-
-	// 如果还是无法获取，返回错误
-	if initialBalance == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "无法获取初始余额",
-		})
+	history, err := s.equityHistory(trader, bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	var history []EquityPoint
-	for _, record := range records {
-		// TotalBalance字段实际存储的是TotalEquity
-		totalEquity := record.AccountState.TotalBalance
-		// TotalUnrealizedProfit字段实际存储的是TotalPnL（相对初始余额）
-		totalPnL := record.AccountState.TotalUnrealizedProfit
-
-		// 计算盈亏百分比
-		totalPnLPct := 0.0
-		if initialBalance > 0 {
-			totalPnLPct = (totalPnL / initialBalance) * 100
-		}
-
-		history = append(history, EquityPoint{
-			Timestamp:        record.Timestamp.Format("2006-01-02 15:04:05"),
-			TotalEquity:      totalEquity,
-			AvailableBalance: record.AccountState.AvailableBalance,
-			TotalPnL:         totalPnL,
-			TotalPnLPct:      totalPnLPct,
-			PositionCount:    record.AccountState.PositionCount,
-			MarginUsedPct:    record.AccountState.MarginUsedPct,
-			CycleNumber:      record.CycleNumber,
-		})
-	}
-
 	c.JSON(http.StatusOK, history)
 }
 
@@ -1151,13 +1466,13 @@ func (s *Server) handleEquityHistory(c *gin.Context) {
 func (s *Server) handlePerformance(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -1165,15 +1480,88 @@ func (s *Server) handlePerformance(c *gin.Context) {
 	// 假设每3分钟一个周期，100个周期 = 5小时，足够覆盖大部分交易
 	performance, err := trader.GetDecisionLogger().AnalyzePerformance(100)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("分析历史表现失败: %v", err),
-		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("分析历史表现失败: %v", err)})
 		return
 	}
 
+	// 基准对比依赖行情接口，失败时只返回交易统计
+	if benchmark, err := trader.GetBenchmark(); err == nil {
+		performance.Benchmark = benchmark
+	} else {
+		requestLog(c).Warn("计算基准收益失败", "trader_id", traderID, "error", err)
+	}
+
 	c.JSON(http.StatusOK, performance)
 }
 
+// handleExportMarketData 导出缓存的K线及指标（?interval=3m|4h&from=&to=&format=json|csv）
+func (s *Server) handleExportMarketData(c *gin.Context) {
+	symbol := c.Param("symbol")
+	interval := c.DefaultQuery("interval", "3m")
+	format := c.DefaultQuery("format", "json")
+
+	from, err := parseExportTime(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("无效的from参数: %v", err)})
+		return
+	}
+	to, err := parseExportTime(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("无效的to参数: %v", err)})
+		return
+	}
+
+	rows, err := market.ExportKlines(symbol, interval, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if format != "csv" {
+		c.JSON(http.StatusOK, KlineExportResponse{
+			Symbol:   market.Normalize(symbol),
+			Interval: interval,
+			Klines:   rows,
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.csv", market.Normalize(symbol), interval)
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"open_time", "close_time", "open", "high", "low", "close", "volume", "ema20", "macd", "rsi7", "rsi14", "atr14"})
+	for _, r := range rows {
+		w.Write([]string{
+			strconv.FormatInt(r.OpenTime, 10),
+			strconv.FormatInt(r.CloseTime, 10),
+			strconv.FormatFloat(r.Open, 'f', -1, 64),
+			strconv.FormatFloat(r.High, 'f', -1, 64),
+			strconv.FormatFloat(r.Low, 'f', -1, 64),
+			strconv.FormatFloat(r.Close, 'f', -1, 64),
+			strconv.FormatFloat(r.Volume, 'f', -1, 64),
+			strconv.FormatFloat(r.EMA20, 'f', -1, 64),
+			strconv.FormatFloat(r.MACD, 'f', -1, 64),
+			strconv.FormatFloat(r.RSI7, 'f', -1, 64),
+			strconv.FormatFloat(r.RSI14, 'f', -1, 64),
+			strconv.FormatFloat(r.ATR14, 'f', -1, 64),
+		})
+	}
+	w.Flush()
+}
+
+// parseExportTime 解析导出时间参数，支持毫秒时间戳或RFC3339，空值表示不限制
+func parseExportTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 // authMiddleware JWT认证中间件
 func (s *Server) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -1181,13 +1569,14 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 		if auth.IsAdminMode() {
 			c.Set("user_id", "admin")
 			c.Set("email", "admin@localhost")
+			c.Set("role", config.RoleAdmin)
 			c.Next()
 			return
 		}
 
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "缺少Authorization头"})
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "缺少Authorization头"})
 			c.Abort()
 			return
 		}
@@ -1195,7 +1584,7 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 		// 检查Bearer token格式
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的Authorization格式"})
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "无效的Authorization格式"})
 			c.Abort()
 			return
 		}
@@ -1203,7 +1592,20 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 		// 验证JWT token
 		claims, err := auth.ValidateJWT(tokenParts[1])
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token: " + err.Error()})
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "无效的token: " + err.Error()})
+			c.Abort()
+			return
+		}
+
+		// 角色和禁用状态从数据库读取，管理员修改后立即生效
+		user, err := s.database.GetUserByID(claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "用户不存在"})
+			c.Abort()
+			return
+		}
+		if user.Disabled {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: errAccountDisabled})
 			c.Abort()
 			return
 		}
@@ -1211,20 +1613,17 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 		// 将用户信息存储到上下文中
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
+		c.Set("role", user.Role)
 		c.Next()
 	}
 }
 
 // handleRegister 处理用户注册请求
 func (s *Server) handleRegister(c *gin.Context) {
-	var req struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=6"`
-		BetaCode string `json:"beta_code"`
-	}
+	var req RegisterRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -1233,18 +1632,18 @@ func (s *Server) handleRegister(c *gin.Context) {
 	if betaModeStr == "true" {
 		// 内测模式下必须提供有效的内测码
 		if req.BetaCode == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "内测期间，注册需要提供内测码"})
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "内测期间，注册需要提供内测码"})
 			return
 		}
 
 		// 验证内测码
 		isValid, err := s.database.ValidateBetaCode(req.BetaCode)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "验证内测码失败"})
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "验证内测码失败"})
 			return
 		}
 		if !isValid {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "内测码无效或已被使用"})
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "内测码无效或已被使用"})
 			return
 		}
 	}
@@ -1252,21 +1651,21 @@ func (s *Server) handleRegister(c *gin.Context) {
 	// 检查邮箱是否已存在
 	_, err := s.database.GetUserByEmail(req.Email)
 	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "邮箱已被注册"})
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "邮箱已被注册"})
 		return
 	}
 
 	// 生成密码哈希
 	passwordHash, err := auth.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码处理失败"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "密码处理失败"})
 		return
 	}
 
 	// 生成OTP密钥
 	otpSecret, err := auth.GenerateOTPSecret()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "OTP密钥生成失败"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "OTP密钥生成失败"})
 		return
 	}
 
@@ -1282,7 +1681,7 @@ func (s *Server) handleRegister(c *gin.Context) {
 
 	err = s.database.CreateUser(user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "创建用户失败: " + err.Error()})
 		return
 	}
 
@@ -1291,158 +1690,173 @@ func (s *Server) handleRegister(c *gin.Context) {
 	if betaModeStr2 == "true" && req.BetaCode != "" {
 		err := s.database.UseBetaCode(req.BetaCode, req.Email)
 		if err != nil {
-			log.Printf("⚠️ 标记内测码为已使用失败: %v", err)
+			requestLog(c).Warn("标记内测码为已使用失败", "error", err)
 			// 这里不返回错误，因为用户已经创建成功
 		} else {
-			log.Printf("✓ 内测码 %s 已被用户 %s 使用", req.BetaCode, req.Email)
+			requestLog(c).Info("内测码已被使用", "beta_code", req.BetaCode, "email", req.Email)
 		}
 	}
 
+	// 生成恢复码，丢失验证器时用于登录并重新绑定
+	recoveryCodes, err := s.issueRecoveryCodes(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	// 返回OTP设置信息
 	qrCodeURL := auth.GetOTPQRCodeURL(otpSecret, req.Email)
-	c.JSON(http.StatusOK, gin.H{
-		"user_id":     userID,
-		"email":       req.Email,
-		"otp_secret":  otpSecret,
-		"qr_code_url": qrCodeURL,
-		"message":     "请使用Google Authenticator扫描二维码并验证OTP",
+	c.JSON(http.StatusOK, RegisterResponse{
+		UserID:        userID,
+		Email:         req.Email,
+		OTPSecret:     otpSecret,
+		QRCodeURL:     qrCodeURL,
+		RecoveryCodes: recoveryCodes,
+		Message:       "请使用Google Authenticator扫描二维码并验证OTP，并妥善保存恢复码",
 	})
 }
 
 // handleCompleteRegistration 完成注册（验证OTP）
 func (s *Server) handleCompleteRegistration(c *gin.Context) {
-	var req struct {
-		UserID  string `json:"user_id" binding:"required"`
-		OTPCode string `json:"otp_code" binding:"required"`
-	}
+	var req OTPRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	// 获取用户信息
 	user, err := s.database.GetUserByID(req.UserID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+		return
+	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: errAccountDisabled})
 		return
 	}
 
 	// 验证OTP
 	if !auth.VerifyOTP(user.OTPSecret, req.OTPCode) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "OTP验证码错误"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "OTP验证码错误"})
 		return
 	}
 
 	// 更新用户OTP验证状态
 	err = s.database.UpdateUserOTPVerified(req.UserID, true)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新用户状态失败"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "更新用户状态失败"})
 		return
 	}
 
 	// 生成JWT token
 	token, err := auth.GenerateJWT(user.ID, user.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成token失败"})
 		return
 	}
 
 	// 初始化用户的默认模型和交易所配置
 	err = s.initUserDefaultConfigs(user.ID)
 	if err != nil {
-		log.Printf("初始化用户默认配置失败: %v", err)
+		requestLog(c).Warn("初始化用户默认配置失败", "error", err)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token":   token,
-		"user_id": user.ID,
-		"email":   user.Email,
-		"message": "注册完成",
+	c.JSON(http.StatusOK, AuthResponse{
+		Token:   token,
+		UserID:  user.ID,
+		Email:   user.Email,
+		Role:    user.Role,
+		Message: "注册完成",
 	})
 }
 
 // handleLogin 处理用户登录请求
 func (s *Server) handleLogin(c *gin.Context) {
-	var req struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required"`
-	}
+	var req LoginRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	// 获取用户信息
 	user, err := s.database.GetUserByEmail(req.Email)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "邮箱或密码错误"})
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "邮箱或密码错误"})
 		return
 	}
 
 	// 验证密码
 	if !auth.CheckPassword(req.Password, user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "邮箱或密码错误"})
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "邮箱或密码错误"})
+		return
+	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: errAccountDisabled})
 		return
 	}
 
-	// 检查OTP是否已验证
+	// 检查OTP是否已验证（注册未完成或管理员重置了验证器）
 	if !user.OTPVerified {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":              "账户未完成OTP设置",
-			"user_id":            user.ID,
-			"requires_otp_setup": true,
+		c.JSON(http.StatusUnauthorized, OTPSetupRequiredResponse{
+			Error:            "账户未完成OTP设置",
+			UserID:           user.ID,
+			RequiresOTPSetup: true,
+			OTPSecret:        user.OTPSecret,
+			QRCodeURL:        auth.GetOTPQRCodeURL(user.OTPSecret, user.Email),
 		})
 		return
 	}
 
 	// 返回需要OTP验证的状态
-	c.JSON(http.StatusOK, gin.H{
-		"user_id":      user.ID,
-		"email":        user.Email,
-		"message":      "请输入Google Authenticator验证码",
-		"requires_otp": true,
+	c.JSON(http.StatusOK, LoginResponse{
+		UserID:      user.ID,
+		Email:       user.Email,
+		Message:     "请输入Google Authenticator验证码",
+		RequiresOTP: true,
 	})
 }
 
 // handleVerifyOTP 验证OTP并完成登录
 func (s *Server) handleVerifyOTP(c *gin.Context) {
-	var req struct {
-		UserID  string `json:"user_id" binding:"required"`
-		OTPCode string `json:"otp_code" binding:"required"`
-	}
+	var req OTPRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	// 获取用户信息
 	user, err := s.database.GetUserByID(req.UserID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+		return
+	}
+	if user.Disabled {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: errAccountDisabled})
 		return
 	}
 
 	// 验证OTP
 	if !auth.VerifyOTP(user.OTPSecret, req.OTPCode) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "验证码错误"})
 		return
 	}
 
 	// 生成JWT token
 	token, err := auth.GenerateJWT(user.ID, user.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成token失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token":   token,
-		"user_id": user.ID,
-		"email":   user.Email,
-		"message": "登录成功",
+	c.JSON(http.StatusOK, AuthResponse{
+		Token:   token,
+		UserID:  user.ID,
+		Email:   user.Email,
+		Role:    user.Role,
+		Message: "登录成功",
 	})
 }
 
@@ -1450,7 +1864,7 @@ func (s *Server) handleVerifyOTP(c *gin.Context) {
 func (s *Server) initUserDefaultConfigs(userID string) error {
 	// 注释掉自动创建默认配置，让用户手动添加
 	// 这样新用户注册后不会自动有配置项
-	log.Printf("用户 %s 注册完成，等待手动配置AI模型和交易所", userID)
+	slog.Info("用户注册完成，等待手动配置AI模型和交易所", "user_id", userID)
 	return nil
 }
 
@@ -1459,8 +1873,8 @@ func (s *Server) handleGetSupportedModels(c *gin.Context) {
 	// 返回系统支持的AI模型（从default用户获取）
 	models, err := s.database.GetAIModels("default")
 	if err != nil {
-		log.Printf("❌ 获取支持的AI模型失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取支持的AI模型失败"})
+		requestLog(c).Error("获取支持的AI模型失败", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "获取支持的AI模型失败"})
 		return
 	}
 
@@ -1472,8 +1886,8 @@ func (s *Server) handleGetSupportedExchanges(c *gin.Context) {
 	// 返回系统支持的交易所（从default用户获取）
 	exchanges, err := s.database.GetExchanges("default")
 	if err != nil {
-		log.Printf("❌ 获取支持的交易所失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取支持的交易所失败"})
+		requestLog(c).Error("获取支持的交易所失败", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "获取支持的交易所失败"})
 		return
 	}
 
@@ -1482,34 +1896,32 @@ func (s *Server) handleGetSupportedExchanges(c *gin.Context) {
 
 // Start 启动服务器
 func (s *Server) Start() error {
-	addr := fmt.Sprintf(":%d", s.port)
-	log.Printf("🌐 API服务器启动在 http://localhost%s", addr)
-	log.Printf("📊 API文档:")
-	log.Printf("  • GET  /api/health           - 健康检查")
-	log.Printf("  • GET  /api/traders          - 公开的AI交易员排行榜前50名（无需认证）")
-	log.Printf("  • GET  /api/competition      - 公开的竞赛数据（无需认证）")
-	log.Printf("  • GET  /api/top-traders      - 前5名交易员数据（无需认证，表现对比用）")
-	log.Printf("  • GET  /api/equity-history?trader_id=xxx - 公开的收益率历史数据（无需认证，竞赛用）")
-	log.Printf("  • GET  /api/equity-history-batch?trader_ids=a,b,c - 批量获取历史数据（无需认证，表现对比优化）")
-	log.Printf("  • GET  /api/traders/:id/public-config - 公开的交易员配置（无需认证，不含敏感信息）")
-	log.Printf("  • POST /api/traders          - 创建新的AI交易员")
-	log.Printf("  • DELETE /api/traders/:id    - 删除AI交易员")
-	log.Printf("  • POST /api/traders/:id/start - 启动AI交易员")
-	log.Printf("  • POST /api/traders/:id/stop  - 停止AI交易员")
-	log.Printf("  • GET  /api/models           - 获取AI模型配置")
-	log.Printf("  • PUT  /api/models           - 更新AI模型配置")
-	log.Printf("  • GET  /api/exchanges        - 获取交易所配置")
-	log.Printf("  • PUT  /api/exchanges        - 更新交易所配置")
-	log.Printf("  • GET  /api/status?trader_id=xxx     - 指定trader的系统状态")
-	log.Printf("  • GET  /api/account?trader_id=xxx    - 指定trader的账户信息")
-	log.Printf("  • GET  /api/positions?trader_id=xxx  - 指定trader的持仓列表")
-	log.Printf("  • GET  /api/decisions?trader_id=xxx  - 指定trader的决策日志")
-	log.Printf("  • GET  /api/decisions/latest?trader_id=xxx - 指定trader的最新决策")
-	log.Printf("  • GET  /api/statistics?trader_id=xxx - 指定trader的统计信息")
-	log.Printf("  • GET  /api/performance?trader_id=xxx - 指定trader的AI学习表现分析")
-	log.Println()
-
-	return s.router.Run(addr)
+	addr := s.httpServer.Addr
+	scheme := "http"
+	if s.tls.enabled() {
+		scheme = "https"
+		if s.redirectServer != nil {
+			slog.Info("HTTP重定向到HTTPS", "addr", s.redirectServer.Addr)
+		}
+	}
+	slog.Info("API服务器启动", "url", fmt.Sprintf("%s://localhost%s", scheme, addr))
+	slog.Info("API文档", "url", fmt.Sprintf("%s://localhost%s/api/openapi.json", scheme, addr))
+	for _, line := range routeSummaries(s.router.Routes()) {
+		slog.Debug("API路由", "route", line)
+	}
+
+	if err := s.listenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 停止接受新请求并等待进行中的请求完成，推送连接直接断开
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.redirectServer != nil {
+		s.redirectServer.Shutdown(ctx)
+	}
+	return s.httpServer.Shutdown(ctx)
 }
 
 // handleGetPromptTemplates 获取所有系统提示词模板列表
@@ -1518,16 +1930,12 @@ func (s *Server) handleGetPromptTemplates(c *gin.Context) {
 	templates := decision.GetAllPromptTemplates()
 
 	// 转换为响应格式
-	response := make([]map[string]interface{}, 0, len(templates))
+	response := make([]PromptTemplateInfo, 0, len(templates))
 	for _, tmpl := range templates {
-		response = append(response, map[string]interface{}{
-			"name": tmpl.Name,
-		})
+		response = append(response, PromptTemplateInfo{Name: tmpl.Name})
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"templates": response,
-	})
+	c.JSON(http.StatusOK, PromptTemplatesResponse{Templates: response})
 }
 
 // handleGetPromptTemplate 获取指定名称的提示词模板内容
@@ -1536,40 +1944,42 @@ func (s *Server) handleGetPromptTemplate(c *gin.Context) {
 
 	template, err := decision.GetPromptTemplate(templateName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("模板不存在: %s", templateName)})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("模板不存在: %s", templateName)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"name":    template.Name,
-		"content": template.Content,
+	c.JSON(http.StatusOK, PromptTemplateResponse{
+		Name:    template.Name,
+		Content: template.Content,
 	})
 }
 
 // handlePublicTraderList 获取公开的交易员列表（无需认证）
 func (s *Server) handlePublicTraderList(c *gin.Context) {
+	s.respondCached(c, "traders", func() (interface{}, bool) {
+		return s.publicTraderList(c)
+	})
+}
+
+// publicTraderList 公开的交易员基本信息，失败时写入错误响应
+func (s *Server) publicTraderList(c *gin.Context) (interface{}, bool) {
 	// 从所有用户获取交易员信息
 	competition, err := s.traderManager.GetCompetitionData()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取交易员列表失败: %v", err),
-		})
-		return
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员列表失败: %v", err)})
+		return nil, false
 	}
 
 	// 获取traders数组
 	tradersData, exists := competition["traders"]
 	if !exists {
-		c.JSON(http.StatusOK, []map[string]interface{}{})
-		return
+		return []map[string]interface{}{}, true
 	}
 
 	traders, ok := tradersData.([]map[string]interface{})
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "交易员数据格式错误",
-		})
-		return
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "交易员数据格式错误"})
+		return nil, false
 	}
 
 	// 返回交易员基本信息，过滤敏感信息
@@ -1589,40 +1999,82 @@ func (s *Server) handlePublicTraderList(c *gin.Context) {
 		})
 	}
 
-	c.JSON(http.StatusOK, result)
+	return result, true
 }
 
-// handlePublicCompetition 获取公开的竞赛数据（无需认证）
+// handlePublicCompetition 获取公开的竞赛数据（无需认证），带 season 参数时返回赛季排名
 func (s *Server) handlePublicCompetition(c *gin.Context) {
-	competition, err := s.traderManager.GetCompetitionData()
+	if season := c.Query("season"); season != "" {
+		s.handleSeasonCompetition(c, season)
+		return
+	}
+	tag, ok := tagFilter(c)
+	if !ok {
+		return
+	}
+	opts, ok := s.rankOptions(c)
+	if !ok {
+		return
+	}
+	if tag != "" {
+		ids, err := s.database.GetTraderIDsByTag("", tag)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取竞赛数据失败: %v", err)})
+			return
+		}
+		c.JSON(http.StatusOK, s.traderManager.GetCompetitionDataFor(ids, opts))
+		return
+	}
+
+	competition, err := s.traderManager.GetRankedCompetitionData(opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取竞赛数据失败: %v", err),
-		})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取竞赛数据失败: %v", err)})
 		return
 	}
 
 	c.JSON(http.StatusOK, competition)
 }
 
-// handleTopTraders 获取前5名交易员数据（无需认证，用于表现对比）
-func (s *Server) handleTopTraders(c *gin.Context) {
-	topTraders, err := s.traderManager.GetTopTradersData()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取前10名交易员数据失败: %v", err),
-		})
-		return
+// rankOptions 竞赛排名设置：rank_by 和 min_trades 参数覆盖系统配置的默认值
+func (s *Server) rankOptions(c *gin.Context) (manager.RankOptions, bool) {
+	opts := s.traderManager.DefaultRankOptions()
+	if mode := c.Query("rank_by"); mode != "" {
+		valid := false
+		for _, m := range manager.RankModes {
+			valid = valid || m == mode
+		}
+		if !valid {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("无效的rank_by参数: %s（可选 %s）", mode, strings.Join(manager.RankModes, "、"))})
+			return opts, false
+		}
+		opts.Mode = mode
+	}
+	if value := c.Query("min_trades"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "min_trades 必须是非负整数"})
+			return opts, false
+		}
+		opts.MinTrades = n
 	}
+	return opts, true
+}
 
-	c.JSON(http.StatusOK, topTraders)
+// handleTopTraders 获取前5名交易员数据（无需认证，用于表现对比）
+func (s *Server) handleTopTraders(c *gin.Context) {
+	s.respondCached(c, "top-traders", func() (interface{}, bool) {
+		topTraders, err := s.traderManager.GetTopTradersData()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取前10名交易员数据失败: %v", err)})
+			return nil, false
+		}
+		return topTraders, true
+	})
 }
 
 // handleEquityHistoryBatch 批量获取多个交易员的收益率历史数据（无需认证，用于表现对比）
 func (s *Server) handleEquityHistoryBatch(c *gin.Context) {
-	var requestBody struct {
-		TraderIDs []string `json:"trader_ids"`
-	}
+	var requestBody EquityHistoryBatchRequest
 
 	// 尝试解析POST请求的JSON body
 	if err := c.ShouldBindJSON(&requestBody); err != nil {
@@ -1630,30 +2082,28 @@ func (s *Server) handleEquityHistoryBatch(c *gin.Context) {
 		traderIDsParam := c.Query("trader_ids")
 		if traderIDsParam == "" {
 			// 如果没有指定trader_ids，则返回前5名的历史数据
-			topTraders, err := s.traderManager.GetTopTradersData()
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": fmt.Sprintf("获取前5名交易员失败: %v", err),
-				})
-				return
-			}
-
-			traders, ok := topTraders["traders"].([]map[string]interface{})
-			if !ok {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "交易员数据格式错误"})
-				return
-			}
+			s.respondCached(c, "equity-history-batch:top", func() (interface{}, bool) {
+				topTraders, err := s.traderManager.GetTopTradersData()
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取前5名交易员失败: %v", err)})
+					return nil, false
+				}
 
-			// 提取trader IDs
-			traderIDs := make([]string, 0, len(traders))
-			for _, trader := range traders {
-				if traderID, ok := trader["trader_id"].(string); ok {
-					traderIDs = append(traderIDs, traderID)
+				traders, ok := topTraders["traders"].([]map[string]interface{})
+				if !ok {
+					c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "交易员数据格式错误"})
+					return nil, false
 				}
-			}
 
-			result := s.getEquityHistoryForTraders(traderIDs)
-			c.JSON(http.StatusOK, result)
+				// 提取trader IDs
+				traderIDs := make([]string, 0, len(traders))
+				for _, trader := range traders {
+					if traderID, ok := trader["trader_id"].(string); ok {
+						traderIDs = append(traderIDs, traderID)
+					}
+				}
+				return s.getEquityHistoryForTraders(traderIDs), true
+			})
 			return
 		}
 
@@ -1669,8 +2119,9 @@ func (s *Server) handleEquityHistoryBatch(c *gin.Context) {
 		requestBody.TraderIDs = requestBody.TraderIDs[:20]
 	}
 
-	result := s.getEquityHistoryForTraders(requestBody.TraderIDs)
-	c.JSON(http.StatusOK, result)
+	s.respondCached(c, "equity-history-batch:"+traderIDsKey(requestBody.TraderIDs), func() (interface{}, bool) {
+		return s.getEquityHistoryForTraders(requestBody.TraderIDs), true
+	})
 }
 
 // getEquityHistoryForTraders 获取多个交易员的历史数据
@@ -1727,13 +2178,13 @@ func (s *Server) getEquityHistoryForTraders(traderIDs []string) map[string]inter
 func (s *Server) handleGetPublicTraderConfig(c *gin.Context) {
 	traderID := c.Param("id")
 	if traderID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "交易员ID不能为空"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "交易员ID不能为空"})
 		return
 	}
 
 	trader, err := s.traderManager.GetTrader(traderID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在"})
 		return
 	}
 
@@ -1754,15 +2205,361 @@ func (s *Server) handleGetPublicTraderConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+const (
+	defaultBacktestCycles = 50  // 默认回放的历史周期数
+	maxBacktestCycles     = 200 // 每个配置每周期都要调用一次AI，限制规模
+	maxBacktestVariants   = 5
+)
+
+// handleBacktestCompare 用交易员记录的历史行情回放多个提示词模板/模型，返回权益曲线和交易统计对比
+func (s *Server) handleBacktestCompare(c *gin.Context) {
+	var req BacktestCompareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "参数错误: " + err.Error()})
+		return
+	}
+	if len(req.Variants) == 0 || len(req.Variants) > maxBacktestVariants {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("对比配置数量必须在1-%d之间", maxBacktestVariants)})
+		return
+	}
+	if req.Cycles <= 0 {
+		req.Cycles = defaultBacktestCycles
+	}
+	if req.Cycles > maxBacktestCycles {
+		req.Cycles = maxBacktestCycles
+	}
+	if req.WalkForward != nil {
+		if err := req.WalkForward.Normalize(); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	costs := backtest.DefaultCosts()
+	if len(req.Costs) > 0 {
+		if err := json.Unmarshal(req.Costs, &costs); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "费用设置解析失败: " + err.Error()})
+			return
+		}
+	}
+	if err := costs.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "费用设置错误: " + err.Error()})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	traderCfg, traderModel, _, err := s.database.GetTraderConfig(userID, req.TraderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("交易员不存在: %v", err)})
+		return
+	}
+	at, err := s.traderManager.GetTrader(req.TraderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	records, err := at.GetDecisionLogger().GetLatestRecords(req.Cycles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("读取决策日志失败: %v", err)})
+		return
+	}
+	history := backtest.LoadHistory(records)
+	if len(history) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "该交易员没有可回放的历史记录"})
+		return
+	}
+
+	models, err := s.database.GetAIModels(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取AI模型配置失败: %v", err)})
+		return
+	}
+
+	variants := make([]backtest.Variant, 0, len(req.Variants))
+	for _, v := range req.Variants {
+		model := traderModel
+		if v.AIModelID != "" {
+			model = nil
+			for _, m := range models {
+				if m.ID == v.AIModelID {
+					model = m
+					break
+				}
+			}
+		}
+		if model == nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("AI模型不存在: %s", v.AIModelID)})
+			return
+		}
+
+		templateName := v.TemplateName
+		if templateName == "" {
+			templateName = traderCfg.SystemPromptTemplate
+		}
+		variants = append(variants, backtest.Variant{
+			Name:         fmt.Sprintf("%s / %s", templateName, model.Name),
+			TemplateName: templateName,
+			ModelName:    model.Name,
+			MCPClient:    newMCPClientForModel(model),
+		})
+	}
+
+	initialBalance := req.InitialBalance
+	if initialBalance <= 0 {
+		initialBalance = traderCfg.InitialBalance
+	}
+
+	runConfig := map[string]interface{}{
+		"initial_balance": initialBalance,
+		"costs":           costs,
+		"variants":        req.Variants,
+		"walk_forward":    req.WalkForward,
+	}
+	cfg := backtest.Config{
+		InitialBalance:  initialBalance,
+		BTCETHLeverage:  traderCfg.BTCETHLeverage,
+		AltcoinLeverage: traderCfg.AltcoinLeverage,
+		Costs:           costs,
+	}
+
+	if req.WalkForward != nil {
+		// 滚动回测每个窗口都要重复调用AI，与普通对比使用同一调用上限
+		if calls := backtest.CountAICalls(len(history), len(variants), *req.WalkForward); calls > maxBacktestCycles*maxBacktestVariants {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("滚动回测需要约 %d 次AI调用，超过上限 %d，请缩小窗口或减少配置", calls, maxBacktestCycles*maxBacktestVariants)})
+			return
+		}
+
+		requestLog(c).Info("开始滚动回测", "trader_id", req.TraderID, "cycles", len(history), "variants", len(variants))
+		walkForward, err := backtest.WalkForward(history, variants, cfg, *req.WalkForward)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		summary := map[string]interface{}{
+			"avg_train_return_pct": walkForward.AvgTrainReturnPct,
+			"avg_test_return_pct":  walkForward.AvgTestReturnPct,
+			"degradation":          walkForward.Degradation,
+			"selections":           walkForward.Selections,
+		}
+		runID := s.saveBacktestRun(userID, req.TraderID, "walk_forward", history, runConfig, summary, walkForward)
+
+		c.JSON(http.StatusOK, WalkForwardResponse{
+			RunID:          runID,
+			TraderID:       req.TraderID,
+			Cycles:         len(history),
+			InitialBalance: initialBalance,
+			Costs:          costs,
+			WalkForward:    walkForward,
+		})
+		return
+	}
+
+	requestLog(c).Info("开始回测对比", "trader_id", req.TraderID, "cycles", len(history), "variants", len(variants))
+	results := backtest.Compare(history, variants, cfg)
+
+	summary := make([]map[string]interface{}, 0, len(results))
+	for _, r := range results {
+		summary = append(summary, map[string]interface{}{
+			"variant":          r.Variant,
+			"return_pct":       r.ReturnPct,
+			"max_drawdown_pct": r.Metrics.MaxDrawdownPct,
+			"total_trades":     r.Metrics.TotalTrades,
+		})
+	}
+	runID := s.saveBacktestRun(userID, req.TraderID, "compare", history, runConfig, summary, results)
+
+	c.JSON(http.StatusOK, BacktestCompareResponse{
+		RunID:          runID,
+		TraderID:       req.TraderID,
+		Costs:          costs,
+		Cycles:         len(history),
+		From:           history[0].Time,
+		To:             history[len(history)-1].Time,
+		InitialBalance: initialBalance,
+		Results:        results,
+	})
+}
+
+// saveBacktestRun 保存回测结果，失败只记录日志（不影响本次返回），返回记录ID
+func (s *Server) saveBacktestRun(userID, traderID, mode string, history []backtest.Cycle, runConfig, summary, results interface{}) string {
+	blog := slog.With("user_id", userID, "trader_id", traderID)
+	configJSON, err := json.Marshal(runConfig)
+	if err != nil {
+		blog.Warn("序列化回测参数失败", "error", err)
+		return ""
+	}
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		blog.Warn("序列化回测摘要失败", "error", err)
+		return ""
+	}
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		blog.Warn("序列化回测结果失败", "error", err)
+		return ""
+	}
+
+	run := &config.BacktestRun{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		TraderID:   traderID,
+		Mode:       mode,
+		PeriodFrom: history[0].Time,
+		PeriodTo:   history[len(history)-1].Time,
+		Cycles:     len(history),
+		Config:     configJSON,
+		Summary:    summaryJSON,
+		Results:    resultsJSON,
+	}
+	if err := s.database.CreateBacktestRun(run); err != nil {
+		blog.Warn("保存回测记录失败", "error", err)
+		return ""
+	}
+	return run.ID
+}
+
+// handleListBacktestRuns 回测记录列表（?trader_id= 可选）
+func (s *Server) handleListBacktestRuns(c *gin.Context) {
+	userID := c.GetString("user_id")
+	runs, err := s.database.GetBacktestRuns(userID, c.Query("trader_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取回测记录失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// handleGetBacktestRun 获取单条回测记录（含权益曲线和交易明细）
+func (s *Server) handleGetBacktestRun(c *gin.Context) {
+	userID := c.GetString("user_id")
+	run, err := s.database.GetBacktestRun(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "回测记录不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// handleDeleteBacktestRun 删除回测记录
+func (s *Server) handleDeleteBacktestRun(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if err := s.database.DeleteBacktestRun(userID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "回测记录不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "回测记录已删除"})
+}
+
+// handleGetMyLimits 获取当前用户实际生效的上限
+func (s *Server) handleGetMyLimits(c *gin.Context) {
+	limits, err := s.database.GetEffectiveLimits(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, limits)
+}
+
+// handleGetLimits 获取系统级上限和所有用户的单独上限
+func (s *Server) handleGetLimits(c *gin.Context) {
+	users, err := s.database.GetAllUserLimits()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取用户上限失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, LimitsOverviewResponse{
+		System: s.database.GetSystemLimits(),
+		Users:  users,
+	})
+}
+
+// bindLimits 解析并校验上限参数
+func bindLimits(c *gin.Context) (*config.UserLimits, bool) {
+	var limits config.UserLimits
+	if err := c.ShouldBindJSON(&limits); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	if limits.MaxLeverage < 0 || limits.MaxNotional < 0 || limits.MaxTraders < 0 || limits.MaxRunningTraders < 0 || limits.MinScanInterval < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "上限不能为负数（0 表示不限制）"})
+		return nil, false
+	}
+	return &limits, true
+}
+
+// handleUpdateSystemLimits 设置系统级上限并同步到所有运行中的交易员
+func (s *Server) handleUpdateSystemLimits(c *gin.Context) {
+	limits, ok := bindLimits(c)
+	if !ok {
+		return
+	}
+	limits.UserID = ""
+	if err := s.database.SetSystemLimits(limits); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	s.traderManager.ApplyAllUserLimits(s.database)
+
+	requestLog(c).Info("系统级上限已更新", "max_leverage", limits.MaxLeverage, "max_notional", limits.MaxNotional, "max_traders", limits.MaxTraders)
+	c.JSON(http.StatusOK, limits)
+}
+
+// handleUpdateUserLimits 设置单个用户的上限
+func (s *Server) handleUpdateUserLimits(c *gin.Context) {
+	limits, ok := bindLimits(c)
+	if !ok {
+		return
+	}
+	limits.UserID = c.Param("user_id")
+	if _, err := s.database.GetUserByID(limits.UserID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "用户不存在"})
+		return
+	}
+	if err := s.database.SetUserLimits(limits); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存用户上限失败: %v", err)})
+		return
+	}
+	if err := s.traderManager.ApplyUserLimits(s.database, limits.UserID); err != nil {
+		requestLog(c).Warn("同步用户上限失败", "target_user", limits.UserID, "error", err)
+	}
+
+	requestLog(c).Info("用户上限已更新", "target_user", limits.UserID, "max_leverage", limits.MaxLeverage, "max_notional", limits.MaxNotional, "max_traders", limits.MaxTraders)
+	c.JSON(http.StatusOK, limits)
+}
+
+// handleDeleteUserLimits 删除用户的单独上限，恢复为系统级上限
+func (s *Server) handleDeleteUserLimits(c *gin.Context) {
+	userID := c.Param("user_id")
+	if err := s.database.DeleteUserLimits(userID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "该用户没有单独设置上限"})
+		return
+	}
+	if err := s.traderManager.ApplyUserLimits(s.database, userID); err != nil {
+		requestLog(c).Warn("同步用户上限失败", "target_user", userID, "error", err)
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "已恢复为系统级上限"})
+}
+
+// newMCPClientForModel 根据AI模型配置创建客户端
+func newMCPClientForModel(model *config.AIModelConfig) *mcp.Client {
+	client := mcp.New()
+	switch model.Provider {
+	case "deepseek":
+		client.SetDeepSeekAPIKey(model.APIKey, model.CustomAPIURL, model.CustomModelName)
+	case "qwen":
+		client.SetQwenAPIKey(model.APIKey, model.CustomAPIURL, model.CustomModelName)
+	default:
+		client.SetCustomAPI(model.CustomAPIURL, model.APIKey, model.CustomModelName)
+	}
+	return client
+}
+
 // handleGenerateUserPrompt 生成用户提示词（使用真实数据）
 func (s *Server) handleGenerateUserPrompt(c *gin.Context) {
-	var req struct {
-		Symbol   string `json:"symbol" binding:"required"`
-		TraderID string `json:"trader_id" binding:"required"` // 必须提供交易员ID
-	}
+	var req GenerateUserPromptRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "参数错误: " + err.Error()})
 		return
 	}
 
@@ -1771,7 +2568,7 @@ func (s *Server) handleGenerateUserPrompt(c *gin.Context) {
 	// 必须使用真实交易员配置获取数据
 	ctx, err := s.createRealContext(userID, req.TraderID, req.Symbol)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取真实数据失败: %v", err)})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取真实数据失败: %v", err)})
 		return
 	}
 
@@ -1798,35 +2595,29 @@ func (s *Server) handleGenerateUserPrompt(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data": gin.H{
-			"symbol":     req.Symbol,
-			"userPrompt": userPrompt,
-			"marketData": marketData,
-			"timestamp":  time.Now().UTC(),
+	c.JSON(http.StatusOK, AITestResponse{
+		Success: true,
+		Data: UserPromptData{
+			Symbol:     req.Symbol,
+			UserPrompt: userPrompt,
+			MarketData: marketData,
+			Timestamp:  time.Now().UTC(),
 		},
 	})
 }
 
 // handleTestAIDecision 测试AI决策（使用系统提示词和用户提示词）
 func (s *Server) handleTestAIDecision(c *gin.Context) {
-	var req struct {
-		Symbol       string `json:"symbol" binding:"required"`
-		SystemPrompt string `json:"system_prompt"`
-		UserPrompt   string `json:"user_prompt"`
-		TemplateName string `json:"template_name"` // 可选：使用指定的模板
-		TraderID     string `json:"trader_id"`     // 必须提供交易员ID
-	}
+	var req TestAIDecisionRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误: " + err.Error()})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "参数错误: " + err.Error()})
 		return
 	}
 
 	// 必须提供交易员ID才能使用真实数据
 	if req.TraderID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "必须提供交易员ID"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "必须提供交易员ID"})
 		return
 	}
 
@@ -1842,14 +2633,14 @@ func (s *Server) handleTestAIDecision(c *gin.Context) {
 		// 使用真实交易员配置创建上下文
 		ctx, err = s.createRealContext(userID, req.TraderID, req.Symbol)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取真实数据失败: %v", err)})
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取真实数据失败: %v", err)})
 			return
 		}
 	} else {
 		// 使用真实交易员配置生成新的用户提示词
 		ctx, err = s.createRealContext(userID, req.TraderID, req.Symbol)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取真实数据失败: %v", err)})
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取真实数据失败: %v", err)})
 			return
 		}
 		userPrompt = decision.BuildUserPrompt(ctx)
@@ -1901,7 +2692,7 @@ func (s *Server) handleTestAIDecision(c *gin.Context) {
 		// 获取用户的默认AI模型配置
 		models, err := s.database.GetAIModels(userID)
 		if err != nil || len(models) == 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "未找到AI模型配置"})
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "未找到AI模型配置"})
 			return
 		}
 		// 使用第一个可用的AI模型
@@ -1919,7 +2710,7 @@ func (s *Server) handleTestAIDecision(c *gin.Context) {
 				// 设置币安代理
 				if traderConfig.BinanceProxyURL != "" {
 					// 这里可以配置代理，但mcp客户端可能需要额外的代理支持
-					log.Printf("使用交易员代理配置: %s", traderConfig.BinanceProxyURL)
+					requestLog(c).Debug("使用交易员代理配置", "trader_id", req.TraderID, "proxy", traderConfig.BinanceProxyURL)
 				}
 			}
 		}
@@ -1940,7 +2731,7 @@ func (s *Server) handleTestAIDecision(c *gin.Context) {
 	response, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
 	duration := time.Since(startTime)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "AI调用失败: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "AI调用失败: " + err.Error()})
 		return
 	}
 
@@ -1960,16 +2751,16 @@ func (s *Server) handleTestAIDecision(c *gin.Context) {
 			jsonContent := strings.TrimSpace(response[jsonStart : arrayEnd+1])
 			if err := json.Unmarshal([]byte(jsonContent), &decisions); err != nil {
 				// JSON解析失败，尝试简化解析
-				c.JSON(http.StatusOK, gin.H{
-					"success": false,
-					"error":   "解析AI响应失败: " + err.Error(),
-					"data": gin.H{
-						"symbol":       req.Symbol,
-						"systemPrompt": systemPrompt,
-						"userPrompt":   userPrompt,
-						"aiResponse":   response,
-						"timestamp":    time.Now().UTC(),
-						"responseTime": duration.Milliseconds(),
+				c.JSON(http.StatusOK, AITestResponse{
+					Success: false,
+					Error:   "解析AI响应失败: " + err.Error(),
+					Data: AIDecisionData{
+						Symbol:       req.Symbol,
+						SystemPrompt: systemPrompt,
+						UserPrompt:   userPrompt,
+						AIResponse:   response,
+						Timestamp:    time.Now().UTC(),
+						ResponseTime: duration.Milliseconds(),
 					},
 				})
 				return
@@ -2002,20 +2793,20 @@ func (s *Server) handleTestAIDecision(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data": gin.H{
-			"symbol":       req.Symbol,
-			"decision":     decisionData["decision"],
-			"confidence":   decisionData["confidence"],
-			"reasoning":    decisionData["reasoning"],
-			"parameters":   decisionData["parameters"],
-			"systemPrompt": systemPrompt,
-			"userPrompt":   userPrompt,
-			"aiResponse":   response,
-			"cotTrace":     cotTrace,
-			"timestamp":    time.Now().UTC(),
-			"responseTime": duration.Milliseconds(),
+	c.JSON(http.StatusOK, AITestResponse{
+		Success: true,
+		Data: AIDecisionData{
+			Symbol:       req.Symbol,
+			Decision:     decisionData["decision"],
+			Confidence:   decisionData["confidence"],
+			Reasoning:    decisionData["reasoning"],
+			Parameters:   decisionData["parameters"],
+			SystemPrompt: systemPrompt,
+			UserPrompt:   userPrompt,
+			AIResponse:   response,
+			CoTTrace:     cotTrace,
+			Timestamp:    time.Now().UTC(),
+			ResponseTime: duration.Milliseconds(),
 		},
 	})
 }
@@ -2037,7 +2828,7 @@ func (s *Server) createRealContext(userID, traderID, symbol string) (*decision.C
 		return nil, fmt.Errorf("交易所配置为空")
 	}
 
-	log.Printf("✓ 使用交易员真实配置: %s (交易所: %s, AI模型: %s)", trader.Name, exchange.Name, aiModel.Name)
+	slog.Debug("使用交易员真实配置", "trader_id", trader.ID, "user_id", trader.UserID, "exchange", exchange.Name, "ai_model", aiModel.Name)
 
 	// 获取真实的账户数据
 	account, positions, err := s.getRealAccountData(trader, exchange)
@@ -2094,7 +2885,7 @@ func (s *Server) createRealContext(userID, traderID, symbol string) (*decision.C
 func (s *Server) getRealAccountData(trader *config.TraderRecord, exchange *config.ExchangeConfig) (decision.AccountInfo, []decision.PositionInfo, error) {
 	// 由于无法获取真实的交易接口，返回空的账户和持仓数据
 	// 在实际应用中，需要连接真实的交易所API来获取这些数据
-	log.Printf("获取真实账户数据: %s (交易所: %s) - 当前返回空数据", trader.Name, exchange.Name)
+	slog.Debug("获取真实账户数据，当前返回空数据", "trader_id", trader.ID, "exchange", exchange.Name)
 
 	// 返回空的账户和持仓数据
 	account := decision.AccountInfo{
@@ -2109,16 +2900,13 @@ func (s *Server) getRealAccountData(trader *config.TraderRecord, exchange *confi
 
 	positionInfos := []decision.PositionInfo{}
 
-	log.Printf("获取真实账户数据: %v", account)
-	log.Printf("获取真实持仓数据: %v", positionInfos)
-
 	return account, positionInfos, nil
 }
 
 // getRealMarketData 获取真实的市场数据
 func (s *Server) getRealMarketData(trader *config.TraderRecord, exchange *config.ExchangeConfig, symbol string) (map[string]*market.Data, error) {
 	// 获取真实的市场数据
-	log.Printf("获取真实市场数据: %s (交易所: %s)", symbol, exchange.Name)
+	slog.Debug("获取真实市场数据", "trader_id", trader.ID, "symbol", symbol, "exchange", exchange.Name)
 
 	// 使用市场数据接口获取真实数据
 	marketDataMap := make(map[string]*market.Data)
@@ -2127,7 +2915,7 @@ func (s *Server) getRealMarketData(trader *config.TraderRecord, exchange *config
 	data, err := market.Get(symbol)
 	if err != nil {
 		// 如果获取失败，记录错误但继续提供基础数据
-		log.Printf("⚠️  获取市场数据失败 %s: %v", symbol, err)
+		slog.Warn("获取市场数据失败", "trader_id", trader.ID, "symbol", symbol, "error", err)
 		// 返回空的数据结构，让调用者处理
 		return marketDataMap, nil
 	}
@@ -2142,7 +2930,7 @@ func (s *Server) getRealMarketData(trader *config.TraderRecord, exchange *config
 // getRealOITopData 获取真实的OI Top数据
 func (s *Server) getRealOITopData(trader *config.TraderRecord, exchange *config.ExchangeConfig, symbol string) (map[string]*decision.OITopData, error) {
 	// 获取真实的OI Top数据
-	log.Printf("获取真实OI Top数据: %s (交易所: %s)", symbol, exchange.Name)
+	slog.Debug("获取真实OI Top数据", "trader_id", trader.ID, "symbol", symbol, "exchange", exchange.Name)
 
 	oiTopDataMap := make(map[string]*decision.OITopData)
 
@@ -2150,7 +2938,7 @@ func (s *Server) getRealOITopData(trader *config.TraderRecord, exchange *config.
 	oiPositions, err := pool.GetOITopPositions()
 	if err != nil {
 		// 如果获取失败，记录错误但继续提供基础数据
-		log.Printf("⚠️  获取OI Top数据失败: %v", err)
+		slog.Warn("获取OI Top数据失败", "trader_id", trader.ID, "error", err)
 		return oiTopDataMap, nil
 	}
 
@@ -2177,7 +2965,7 @@ func (s *Server) getTraderInterface(trader *config.TraderRecord, exchange *confi
 	// 由于导入循环问题，这里返回一个模拟的交易接口
 	// 在实际应用中，应该返回真实的交易接口
 
-	log.Printf("创建交易接口: %s (交易所: %s)", trader.Name, exchange.Name)
+	slog.Debug("创建交易接口", "trader_id", trader.ID, "exchange", exchange.Name)
 
 	// 返回一个模拟的交易接口结构
 	return &MockTrader{
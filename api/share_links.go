@@ -0,0 +1,196 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"nofx/auth"
+	"nofx/config"
+	"nofx/trader"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	maxShareLinksPerTrader = 20  // 每个交易员最多的分享链接数量
+	maxShareLinkLabelLen   = 64  // 分享链接备注最大长度（字符）
+	defaultSharedDecisions = 20  // 分享页默认返回的决策条数
+	maxSharedDecisions     = 200 // 分享页最多返回的决策条数
+)
+
+// handleListShareLinks 交易员的只读分享链接
+func (s *Server) handleListShareLinks(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+	if _, _, _, err := s.database.GetTraderConfig(userID, traderID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	links, err := s.database.GetShareLinks(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取分享链接失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, links)
+}
+
+// handleCreateShareLink 生成只读分享链接，token只在创建时返回一次
+func (s *Server) handleCreateShareLink(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+	if _, _, _, err := s.database.GetTraderConfig(userID, traderID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	var req CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if len([]rune(req.Label)) > maxShareLinkLabelLen {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("备注不能超过%d个字符", maxShareLinkLabelLen)})
+		return
+	}
+
+	links, err := s.database.GetShareLinks(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取分享链接失败: %v", err)})
+		return
+	}
+	if len(links) >= maxShareLinksPerTrader {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: fmt.Sprintf("每个交易员最多%d个分享链接", maxShareLinksPerTrader)})
+		return
+	}
+
+	token, err := auth.GenerateShareToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成分享链接失败"})
+		return
+	}
+	link := &config.ShareLink{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		TraderID:  traderID,
+		Label:     req.Label,
+		CreatedAt: time.Now(),
+	}
+	if err := s.database.CreateShareLink(link, auth.HashShareToken(token)); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存分享链接失败: %v", err)})
+		return
+	}
+
+	requestLog(c).Info("创建了只读分享链接", "trader_id", traderID)
+	c.JSON(http.StatusOK, CreateShareLinkResponse{ShareLink: link, Token: token})
+}
+
+// handleDeleteShareLink 撤销分享链接，已发出的链接立即失效
+func (s *Server) handleDeleteShareLink(c *gin.Context) {
+	if err := s.database.DeleteShareLink(c.GetString("user_id"), c.Param("id"), c.Param("link_id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "分享链接不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("撤销分享链接失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "分享链接已撤销"})
+}
+
+// sharedTrader 按分享token找到交易员；失败时已写入响应
+func (s *Server) sharedTrader(c *gin.Context) (*trader.AutoTrader, bool) {
+	link, err := s.database.UseShareLink(auth.HashShareToken(c.Param("token")))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			requestLog(c).Warn("查询分享链接失败", "error", err)
+		}
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "分享链接无效或已撤销"})
+		return nil, false
+	}
+
+	// 交易员属于链接创建者，确保已加载
+	if err := s.traderManager.LoadUserTraders(s.database, link.UserID); err != nil {
+		requestLog(c).Warn("加载用户的交易员失败", "owner_id", link.UserID, "error", err)
+	}
+	at, err := s.traderManager.GetTrader(link.TraderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在"})
+		return nil, false
+	}
+	return at, true
+}
+
+// handleSharedStatus 分享的交易员运行状态
+func (s *Server) handleSharedStatus(c *gin.Context) {
+	at, ok := s.sharedTrader(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, at.GetStatus())
+}
+
+// handleSharedPositions 分享的交易员当前持仓
+func (s *Server) handleSharedPositions(c *gin.Context) {
+	at, ok := s.sharedTrader(c)
+	if !ok {
+		return
+	}
+	positions, err := at.GetPositions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取持仓列表失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, positions)
+}
+
+// handleSharedDecisions 分享的交易员最近的决策（最新的在前，?limit= 默认20条）
+func (s *Server) handleSharedDecisions(c *gin.Context) {
+	at, ok := s.sharedTrader(c)
+	if !ok {
+		return
+	}
+	limit := defaultSharedDecisions
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxSharedDecisions {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("limit必须在1-%d之间", maxSharedDecisions)})
+			return
+		}
+		limit = n
+	}
+
+	records, err := at.GetDecisionLogger().GetLatestRecords(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取决策日志失败: %v", err)})
+		return
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+// handleSharedEquityHistory 分享的交易员收益率历史
+func (s *Server) handleSharedEquityHistory(c *gin.Context) {
+	at, ok := s.sharedTrader(c)
+	if !ok {
+		return
+	}
+	bucket, err := parseEquityGranularity(c.Query("granularity"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	history, err := s.equityHistory(at, bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
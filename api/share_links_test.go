@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"nofx/config"
+	"testing"
+)
+
+func TestShareLinks(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.UpdateAIModel("alice", "deepseek", true, "sk-test", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.database.UpdateExchange("alice", "paper", true, "", "", false, "", "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "alice", Name: "t1", AIModelID: "alice_deepseek", ExchangeID: "paper", InitialBalance: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	public := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := doAs(t, s, "boss", http.MethodPost, "/api/traders/t1/share-links"); w.Code != http.StatusNotFound {
+		t.Errorf("只有交易员所有者能创建分享链接: %d", w.Code)
+	}
+	w := doAsWithBody(t, s, "alice", http.MethodPost, "/api/traders/t1/share-links", `{"label":"朋友"}`)
+	var created CreateShareLinkResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusOK || created.Token == "" {
+		t.Fatalf("创建分享链接失败: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := public("/api/shared/" + created.Token + "/status"); w.Code != http.StatusOK {
+		t.Fatalf("凭token应能查看状态: %d %s", w.Code, w.Body.String())
+	}
+	if w := public("/api/shared/" + created.Token + "/positions"); w.Code != http.StatusOK {
+		t.Errorf("凭token应能查看持仓: %d %s", w.Code, w.Body.String())
+	}
+	if w := public("/api/shared/" + created.Token + "/decisions?limit=1000"); w.Code != http.StatusBadRequest {
+		t.Errorf("limit超出范围应拒绝: %d", w.Code)
+	}
+	if w := public("/api/shared/wrong/status"); w.Code != http.StatusNotFound {
+		t.Errorf("无效token应返回404: %d", w.Code)
+	}
+
+	// 列表不返回token，记录最近访问时间
+	w = doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/share-links")
+	var links []config.ShareLink
+	if err := json.Unmarshal(w.Body.Bytes(), &links); err != nil || len(links) != 1 || links[0].LastUsedAt == nil || links[0].Label != "朋友" {
+		t.Fatalf("分享链接列表错误: %s", w.Body.String())
+	}
+
+	if w := doAs(t, s, "alice", http.MethodDelete, "/api/traders/t1/share-links/"+links[0].ID); w.Code != http.StatusOK {
+		t.Fatalf("撤销分享链接失败: %d", w.Code)
+	}
+	if w := public("/api/shared/" + created.Token + "/status"); w.Code != http.StatusNotFound {
+		t.Errorf("撤销后token应失效: %d", w.Code)
+	}
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"nofx/config"
+	"nofx/pool"
+	"nofx/trader"
+	"nofx/webhook"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// 用户信号源的限制
+const (
+	maxSignalSourcesPerTrader = 5
+	maxPushCoinsPerRequest    = 50
+	signalSourceTestTimeout   = 20 * time.Second
+)
+
+// signalSourcePushPath webhook 类型信号源的推送地址
+func signalSourcePushPath(id string) string {
+	return "/api/signal-sources/" + id + "/push"
+}
+
+// handleListSignalSources 交易员注册的信号源
+func (s *Server) handleListSignalSources(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	t, err := s.findOwnTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	sources, err := s.database.GetTraderSignalSources(traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取信号源失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, sources)
+}
+
+// handleGetTraderCandidates 最近一个决策周期的有效候选列表：每个币种的来源、得分，以及是否发给了AI
+func (s *Server) handleGetTraderCandidates(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	t, err := s.findOwnTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	var report *trader.CandidateReport
+	if at, err := s.traderManager.GetTrader(traderID); err == nil {
+		report = at.GetCandidateReport()
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员还没有完成过决策周期"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// handleCreateSignalSource 为交易员注册信号源，下一个决策周期开始合并到候选币种
+func (s *Server) handleCreateSignalSource(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	var req CreateSignalSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := pool.ValidateSourceName(req.Name); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := req.SourceConfig.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.URL != "" {
+		if err := webhook.ValidateURL(req.URL); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	t, err := s.findOwnTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+	existing, err := s.database.GetTraderSignalSources(traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取信号源失败: %v", err)})
+		return
+	}
+	if len(existing) >= maxSignalSourcesPerTrader {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: fmt.Sprintf("每个交易员最多注册%d个信号源", maxSignalSourcesPerTrader)})
+		return
+	}
+	for _, e := range existing {
+		if e.Name == req.Name {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("信号源 %q 已存在", req.Name)})
+			return
+		}
+	}
+
+	cfg, err := json.Marshal(req.SourceConfig)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "保存信号源失败"})
+		return
+	}
+	source := &config.TraderSignalSource{
+		ID:        uuid.New().String(),
+		TraderID:  traderID,
+		UserID:    userID,
+		Name:      req.Name,
+		Type:      req.Type,
+		Config:    cfg,
+		CreatedAt: time.Now().UTC(),
+	}
+	resp := CreateSignalSourceResponse{Source: source}
+	if req.Type == pool.SourceWebhook {
+		if source.Secret, err = webhook.GenerateSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成签名密钥失败"})
+			return
+		}
+		resp.Secret = source.Secret
+		resp.PushPath = signalSourcePushPath(source.ID)
+	}
+	if err := s.database.CreateTraderSignalSource(source); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存信号源失败: %v", err)})
+		return
+	}
+	if err := s.traderManager.ReloadTraderSignalSources(s.database, traderID); err != nil {
+		requestLog(c).Warn("同步信号源失败", "trader_id", traderID, "error", err)
+	}
+
+	requestLog(c).Info("已注册信号源", "trader_id", traderID, "source", source.Name, "type", source.Type)
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleDeleteSignalSource 删除信号源
+func (s *Server) handleDeleteSignalSource(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+	sourceID := c.Param("sourceId")
+
+	err := s.database.DeleteTraderSignalSource(userID, traderID, sourceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "信号源不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("删除信号源失败: %v", err)})
+		return
+	}
+	pool.DropWebhookSource(sourceID)
+	if err := s.traderManager.ReloadTraderSignalSources(s.database, traderID); err != nil {
+		requestLog(c).Warn("同步信号源失败", "trader_id", traderID, "error", err)
+	}
+
+	requestLog(c).Info("已删除信号源", "trader_id", traderID, "source_id", sourceID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "信号源已删除"})
+}
+
+// handleTestSignalSource 立即请求一次信号源，查看映射配置取出的币种
+func (s *Server) handleTestSignalSource(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	record, err := s.database.GetTraderSignalSource(c.Param("sourceId"))
+	if err != nil || record.TraderID != traderID || record.UserID != userID {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "信号源不存在"})
+		return
+	}
+	var cfg pool.SourceConfig
+	if err := json.Unmarshal(record.Config, &cfg); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("信号源配置无效: %v", err)})
+		return
+	}
+	source, err := pool.NewSignalSource(record.ID, record.Name, cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("信号源配置无效: %v", err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), signalSourceTestTimeout)
+	defer cancel()
+	resp := SignalSourceTestResponse{Coins: []pool.SourceCoin{}}
+	if coins, err := source.Fetch(ctx); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Coins = coins
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// handlePushSignalSource 外部系统向 webhook 类型的信号源推送币种（HMAC签名认证，不需要登录）
+func (s *Server) handlePushSignalSource(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignalBodyBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "读取请求失败"})
+		return
+	}
+	if len(body) > maxSignalBodyBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "请求体过大"})
+		return
+	}
+	timestamp, ok := signedTimestamp(c)
+	if !ok {
+		return
+	}
+	// 信号源不存在、不是webhook类型和签名错误返回同样的错误
+	record, err := s.database.GetTraderSignalSource(c.Param("id"))
+	if err != nil || record.Type != pool.SourceWebhook || !webhook.Verify(record.Secret, timestamp, body, c.GetHeader(webhook.SignatureHeader)) {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			requestLog(c).Error("读取信号源失败", "source_id", c.Param("id"), "error", err)
+		}
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "签名无效"})
+		return
+	}
+
+	var req SignalSourcePushRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求格式错误，需要 coins"})
+		return
+	}
+	if len(req.Coins) == 0 || len(req.Coins) > maxPushCoinsPerRequest {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("每次推送 1-%d 个币种", maxPushCoinsPerRequest)})
+		return
+	}
+	ttl := time.Duration(req.TTLMinutes) * time.Minute
+	if ttl == 0 {
+		ttl = defaultSignalTTL
+	}
+	if ttl < 0 || ttl > maxSignalTTL {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("有效期必须在1-%d分钟之间", int(maxSignalTTL.Minutes()))})
+		return
+	}
+	coins := make([]pool.SourceCoin, 0, len(req.Coins))
+	for i, coin := range req.Coins {
+		symbol := pool.CleanSymbol(coin.Symbol)
+		if symbol == "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("第%d个币种无效: %q", i+1, coin.Symbol)})
+			return
+		}
+		coins = append(coins, pool.SourceCoin{Symbol: symbol, Score: coin.Score})
+	}
+
+	active := pool.PushWebhookCoins(record.ID, coins, ttl)
+	c.JSON(http.StatusAccepted, SignalIngestResponse{Accepted: len(coins), Active: active})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"nofx/config"
+	"nofx/pool"
+	"nofx/webhook"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignalSources(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []string{
+		`{"name":"ai500","type":"webhook"}`,
+		`{"name":"feed","type":"rss"}`,
+		`{"name":"feed","type":"http_json","url":"http://127.0.0.1/coins"}`,
+		`{"name":"feed","type":"ftp","url":"https://example.com"}`,
+	}
+	for _, body := range invalid {
+		if w := doAsWithBody(t, s, "alice", http.MethodPost, "/api/traders/t1/signal-sources", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s 应返回400: %d %s", body, w.Code, w.Body.String())
+		}
+	}
+	if w := doAsWithBody(t, s, "boss", http.MethodPost, "/api/traders/t1/signal-sources", `{"name":"feed","type":"webhook"}`); w.Code != http.StatusNotFound {
+		t.Errorf("不能为其他用户的交易员注册信号源: %d", w.Code)
+	}
+
+	w := doAsWithBody(t, s, "alice", http.MethodPost, "/api/traders/t1/signal-sources", `{"name":"pushed","type":"webhook","max_coins":5}`)
+	var created CreateSignalSourceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusOK || created.Secret == "" || created.PushPath == "" {
+		t.Fatalf("注册webhook信号源失败: %d %s", w.Code, w.Body.String())
+	}
+	defer pool.DropWebhookSource(created.Source.ID)
+	if w := doAsWithBody(t, s, "alice", http.MethodPost, "/api/traders/t1/signal-sources", `{"name":"pushed","type":"webhook"}`); w.Code != http.StatusConflict {
+		t.Errorf("重名应返回409: %d", w.Code)
+	}
+
+	push := func(secret string, body string) *httptest.ResponseRecorder {
+		ts := time.Now().Unix()
+		req := httptest.NewRequest(http.MethodPost, created.PushPath, strings.NewReader(body))
+		req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(ts, 10))
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(secret, ts, []byte(body)))
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, req)
+		return rec
+	}
+	body := `{"coins":[{"symbol":"sol","score":80},{"symbol":"$arb"}]}`
+	if w := push("wrong", body); w.Code != http.StatusUnauthorized {
+		t.Errorf("错误的签名应返回401: %d", w.Code)
+	}
+	if w := push(created.Secret, `{"coins":[{"symbol":"a/b"}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("无效的币种应返回400: %d", w.Code)
+	}
+	if w := push(created.Secret, body); w.Code != http.StatusAccepted {
+		t.Fatalf("推送失败: %d %s", w.Code, w.Body.String())
+	}
+
+	w = doAs(t, s, "alice", http.MethodPost, "/api/traders/t1/signal-sources/"+created.Source.ID+"/test")
+	var tested SignalSourceTestResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &tested); err != nil || len(tested.Coins) != 2 || tested.Coins[0].Symbol != "SOLUSDT" {
+		t.Errorf("测试信号源应返回推送的币种: %d %s", w.Code, w.Body.String())
+	}
+
+	w = doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/signal-sources")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), created.Secret) {
+		t.Errorf("列表不应返回密钥: %d %s", w.Code, w.Body.String())
+	}
+	if w := doAs(t, s, "alice", http.MethodDelete, "/api/traders/t1/signal-sources/"+created.Source.ID); w.Code != http.StatusOK {
+		t.Errorf("删除信号源失败: %d", w.Code)
+	}
+	if w := push(created.Secret, body); w.Code != http.StatusUnauthorized {
+		t.Errorf("删除后应拒绝推送: %d", w.Code)
+	}
+
+	// 交易员未加载、还没有决策周期时没有候选报告
+	w = doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/candidates")
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "决策周期") {
+		t.Errorf("没有候选报告时应返回404: %d %s", w.Code, w.Body.String())
+	}
+	if w := doAs(t, s, "boss", http.MethodGet, "/api/traders/t1/candidates"); w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "决策周期") {
+		t.Errorf("不能查看其他用户交易员的候选币种: %d", w.Code)
+	}
+}
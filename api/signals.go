@@ -0,0 +1,213 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"nofx/decision"
+	"nofx/webhook"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 外部信号推送的限制
+const (
+	maxSignalBodyBytes    = 64 << 10
+	maxSignalsPerRequest  = 20
+	maxSignalNoteLen      = 500
+	defaultSignalTTL      = 60 * time.Minute
+	maxSignalTTL          = 24 * time.Hour
+	signalTimestampMaxAge = 5 * time.Minute // 签名时间戳与服务器时间的最大偏差，防止重放
+)
+
+// handleIngestSignals 外部系统推送候选币种或方向信号（HMAC签名认证，不需要登录）
+// 签名方式与出站webhook相同：X-NOFX-Signature = sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+func (s *Server) handleIngestSignals(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignalBodyBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "读取请求失败"})
+		return
+	}
+	if len(body) > maxSignalBodyBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "请求体过大"})
+		return
+	}
+
+	var req SignalIngestRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.TraderID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "请求格式错误，需要 trader_id 和 signals"})
+		return
+	}
+	timestamp, ok := signedTimestamp(c)
+	if !ok {
+		return
+	}
+	// 交易员不存在、未开启推送和签名错误返回同样的错误，不泄露交易员是否存在
+	key, err := s.database.GetSignalKey(req.TraderID)
+	if err != nil || !webhook.Verify(key.Secret, timestamp, body, c.GetHeader(webhook.SignatureHeader)) {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			requestLog(c).Error("读取信号密钥失败", "trader_id", req.TraderID, "error", err)
+		}
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "签名无效"})
+		return
+	}
+
+	signals, err := parseSignals(req.Signals, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	at, err := s.traderManager.GetTrader(req.TraderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员未加载"})
+		return
+	}
+	active := at.PushSignals(signals)
+	if err := s.database.TouchSignalKey(req.TraderID); err != nil {
+		requestLog(c).Warn("记录信号密钥使用时间失败", "trader_id", req.TraderID, "error", err)
+	}
+
+	c.JSON(http.StatusAccepted, SignalIngestResponse{Accepted: len(signals), Active: active})
+}
+
+// signedTimestamp 读取并检查签名时间戳，无效时已写入401响应
+func signedTimestamp(c *gin.Context) (int64, bool) {
+	timestamp, err := strconv.ParseInt(c.GetHeader(webhook.TimestampHeader), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "缺少或无效的 " + webhook.TimestampHeader})
+		return 0, false
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > signalTimestampMaxAge || age < -signalTimestampMaxAge {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "签名时间戳已过期，请检查推送方的系统时间"})
+		return 0, false
+	}
+	return timestamp, true
+}
+
+// parseSignals 校验推送的信号并填充时间，TTL 未指定时为 defaultSignalTTL
+func parseSignals(inputs []SignalInput, now time.Time) ([]decision.ExternalSignal, error) {
+	if len(inputs) == 0 || len(inputs) > maxSignalsPerRequest {
+		return nil, fmt.Errorf("每次推送 1-%d 条信号", maxSignalsPerRequest)
+	}
+	signals := make([]decision.ExternalSignal, 0, len(inputs))
+	for i, in := range inputs {
+		symbol := strings.ToUpper(strings.TrimSpace(in.Symbol))
+		if symbol == "" || strings.ContainsAny(symbol, " /:") {
+			return nil, fmt.Errorf("第%d条信号的币种无效: %q", i+1, in.Symbol)
+		}
+		side := strings.ToLower(in.Side)
+		if side != "" && side != "long" && side != "short" {
+			return nil, fmt.Errorf("第%d条信号的方向必须是 long、short 或为空: %q", i+1, in.Side)
+		}
+		if in.Confidence < 0 || in.Confidence > 100 {
+			return nil, fmt.Errorf("第%d条信号的置信度必须在0-100之间", i+1)
+		}
+		ttl := time.Duration(in.TTLMinutes) * time.Minute
+		if ttl == 0 {
+			ttl = defaultSignalTTL
+		}
+		if ttl < 0 || ttl > maxSignalTTL {
+			return nil, fmt.Errorf("第%d条信号的有效期必须在1-%d分钟之间", i+1, int(maxSignalTTL.Minutes()))
+		}
+		note := in.Note
+		if len([]rune(note)) > maxSignalNoteLen {
+			note = string([]rune(note)[:maxSignalNoteLen])
+		}
+		signals = append(signals, decision.ExternalSignal{
+			Symbol:     symbol,
+			Side:       side,
+			Confidence: in.Confidence,
+			Note:       note,
+			Source:     in.Source,
+			ReceivedAt: now,
+			ExpiresAt:  now.Add(ttl),
+		})
+	}
+	return signals, nil
+}
+
+// handleGetTraderSignals 交易员的信号推送状态和当前未过期的信号
+func (s *Server) handleGetTraderSignals(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	t, err := s.findOwnTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	resp := TraderSignalsResponse{Signals: []decision.ExternalSignal{}}
+	key, err := s.database.GetSignalKey(traderID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取信号密钥失败: %v", err)})
+		return
+	}
+	resp.Key = key
+	if at, err := s.traderManager.GetTrader(traderID); err == nil {
+		resp.Signals = at.ActiveSignals(time.Now())
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleCreateSignalKey 开启信号推送或轮换密钥，明文密钥只在本次响应中返回
+func (s *Server) handleCreateSignalKey(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	t, err := s.findOwnTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	secret, err := webhook.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成密钥失败"})
+		return
+	}
+	if err := s.database.SetSignalKey(userID, traderID, secret); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存密钥失败: %v", err)})
+		return
+	}
+
+	requestLog(c).Info("已生成信号推送密钥", "trader_id", traderID)
+	c.JSON(http.StatusOK, SignalKeyResponse{
+		TraderID: traderID,
+		Secret:   secret,
+		Message:  "请妥善保存密钥，它只显示一次；重新生成后旧密钥立即失效",
+	})
+}
+
+// handleDeleteSignalKey 关闭信号推送（已收到的信号保留到过期）
+func (s *Server) handleDeleteSignalKey(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	err := s.database.DeleteSignalKey(userID, traderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "未开启信号推送"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("删除密钥失败: %v", err)})
+		return
+	}
+
+	requestLog(c).Info("已关闭信号推送", "trader_id", traderID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "已关闭信号推送"})
+}
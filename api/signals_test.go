@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"nofx/config"
+	"nofx/webhook"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignalIngestion(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doAs(t, s, "alice", http.MethodPost, "/api/traders/t1/signal-key")
+	var key SignalKeyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &key); err != nil || w.Code != http.StatusOK || key.Secret == "" {
+		t.Fatalf("生成密钥失败: %d %s", w.Code, w.Body.String())
+	}
+	if w := doAs(t, s, "boss", http.MethodPost, "/api/traders/t1/signal-key"); w.Code != http.StatusNotFound {
+		t.Errorf("不能为其他用户的交易员生成密钥: %d", w.Code)
+	}
+
+	push := func(secret string, ts int64, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/signals", strings.NewReader(body))
+		req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(ts, 10))
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(secret, ts, []byte(body)))
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, req)
+		return rec
+	}
+	body := `{"trader_id":"t1","signals":[{"symbol":"SOL","side":"long"}]}`
+	now := time.Now().Unix()
+	if w := push("wrong", now, body); w.Code != http.StatusUnauthorized {
+		t.Errorf("错误的签名应返回401: %d", w.Code)
+	}
+	if w := push(key.Secret, now-600, body); w.Code != http.StatusUnauthorized {
+		t.Errorf("过期的时间戳应返回401: %d", w.Code)
+	}
+	if w := push(key.Secret, now, `{"trader_id":"t1","signals":[{"symbol":"SOL","side":"up"}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("无效的方向应返回400: %d", w.Code)
+	}
+	// 签名正确，但测试中交易员没有加载到内存
+	if w := push(key.Secret, now, body); w.Code != http.StatusNotFound {
+		t.Errorf("交易员未加载应返回404: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doAs(t, s, "alice", http.MethodDelete, "/api/traders/t1/signal-key"); w.Code != http.StatusOK {
+		t.Errorf("关闭信号推送失败: %d", w.Code)
+	}
+	if w := push(key.Secret, now, body); w.Code != http.StatusUnauthorized {
+		t.Errorf("关闭后应拒绝推送: %d", w.Code)
+	}
+}
+
+func TestParseSignals(t *testing.T) {
+	now := time.Now()
+	signals, err := parseSignals([]SignalInput{{Symbol: " sol ", Side: "SHORT", TTLMinutes: 30}}, now)
+	if err != nil || signals[0].Symbol != "SOL" || signals[0].Side != "short" || !signals[0].ExpiresAt.Equal(now.Add(30*time.Minute)) {
+		t.Errorf("解析信号错误: %+v %v", signals, err)
+	}
+	if signals, _ := parseSignals([]SignalInput{{Symbol: "BTC"}}, now); !signals[0].ExpiresAt.Equal(now.Add(defaultSignalTTL)) {
+		t.Errorf("默认有效期错误: %+v", signals)
+	}
+	for _, in := range []SignalInput{{Symbol: ""}, {Symbol: "BTC", Confidence: 101}, {Symbol: "BTC", TTLMinutes: 2000}} {
+		if _, err := parseSignals([]SignalInput{in}, now); err == nil {
+			t.Errorf("应拒绝无效信号: %+v", in)
+		}
+	}
+	if _, err := parseSignals(nil, now); err == nil {
+		t.Error("空信号列表应返回错误")
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// spaFile 内嵌的前端文件
+type spaFile struct {
+	data []byte
+	etag string
+}
+
+// spaHandler 提供内嵌的前端单页应用：存在的文件直接返回，其余页面路径返回 index.html 由前端路由处理
+type spaHandler struct {
+	files map[string]*spaFile
+}
+
+// newSPAHandler 读取全部前端文件并计算ETag（内嵌文件没有修改时间）
+func newSPAHandler(files fs.FS) (*spaHandler, error) {
+	h := &spaHandler{files: map[string]*spaFile{}}
+	err := fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		h.files[name] = &spaFile{data: data, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if h.files["index.html"] == nil {
+		return nil, fs.ErrNotExist
+	}
+	return h, nil
+}
+
+// setupSPA 未匹配API路由的请求交给前端
+func (s *Server) setupSPA(files fs.FS) error {
+	h, err := newSPAHandler(files)
+	if err != nil {
+		return err
+	}
+	s.router.NoRoute(h.serve)
+	return nil
+}
+
+func (h *spaHandler) serve(c *gin.Context) {
+	urlPath := path.Clean("/" + c.Request.URL.Path)
+	if strings.HasPrefix(urlPath, "/api/") || (c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "接口不存在"})
+		return
+	}
+
+	name := strings.TrimPrefix(urlPath, "/")
+	file, ok := h.files[name]
+	switch {
+	case ok && name != "index.html":
+		// Vite构建的 assets/ 文件名带内容哈希，可以长期缓存；其他文件（图标等）每次校验
+		if strings.HasPrefix(name, "assets/") {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			c.Header("Cache-Control", "no-cache")
+		}
+	case !ok && path.Ext(name) != "":
+		// 缺失的静态文件不回退到 index.html，避免浏览器把HTML当作脚本
+		c.String(http.StatusNotFound, "404 page not found")
+		return
+	default:
+		// 页面路径，index.html 引用的资源文件名随版本变化，必须每次校验
+		name, file = "index.html", h.files["index.html"]
+		c.Header("Cache-Control", "no-cache")
+	}
+
+	c.Header("ETag", file.etag)
+	http.ServeContent(c.Writer, c.Request, name, time.Time{}, bytes.NewReader(file.data))
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSPA(t *testing.T) {
+	s := newAdminTestServer(t)
+	err := s.setupSPA(fstest.MapFS{
+		"index.html":        {Data: []byte("<html>nofx</html>")},
+		"assets/app-a1.js":  {Data: []byte("console.log(1)")},
+		"icons/favicon.svg": {Data: []byte("<svg/>")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("/assets/app-a1.js"); w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+		t.Errorf("带哈希的资源应长期缓存: %d %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+	if w := get("/icons/favicon.svg"); w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("其他静态文件应每次校验: %d %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+	for _, path := range []string{"/", "/index.html", "/competition", "/traders/abc/decisions"} {
+		if w := get(path); w.Code != http.StatusOK || w.Body.String() != "<html>nofx</html>" || w.Header().Get("Cache-Control") != "no-cache" {
+			t.Errorf("%s 应返回 index.html: %d %q", path, w.Code, w.Body.String())
+		}
+	}
+
+	w := get("/competition")
+	if w := get("/competition", "If-None-Match", w.Header().Get("ETag")); w.Code != http.StatusNotModified {
+		t.Errorf("ETag未变化应返回304: %d", w.Code)
+	}
+	if w := get("/assets/missing.js"); w.Code != http.StatusNotFound {
+		t.Errorf("缺失的静态文件应返回404: %d", w.Code)
+	}
+	if w := get("/api/missing"); w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("未知API应返回JSON 404: %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w := get("/api/top-traders"); w.Code != http.StatusOK || w.Body.String() == "<html>nofx</html>" {
+		t.Errorf("API路由不应被前端覆盖: %d", w.Code)
+	}
+}
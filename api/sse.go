@@ -0,0 +1,55 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"nofx/trader"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sseHeartbeatInterval = 15 * time.Second // 保持连接，避免代理因空闲断开
+
+// handleTraderEvents 以SSE推送交易员决策周期的各阶段事件：
+// cycle_started → prompt_built → ai_responded → orders_executed
+func (s *Server) handleTraderEvents(c *gin.Context) {
+	userID, err := s.streamUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		return
+	}
+	traderID := c.Param("id")
+	if !s.canViewTrader(userID, traderID) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	events, unsubscribe := trader.SubscribeEvents(64)
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // 关闭nginx缓冲，事件立即送达
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-s.shutdown:
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			if event.TraderID == traderID && trader.IsCycleEvent(event.Type) {
+				c.SSEvent(event.Type, event)
+			}
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"nofx/telegram"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetTelegramLink 当前用户的Telegram绑定状态
+func (s *Server) handleGetTelegramLink(c *gin.Context) {
+	botUsername := telegram.BotUsername()
+	status := TelegramLinkStatus{Enabled: botUsername != "", BotUsername: botUsername}
+
+	link, err := s.database.GetTelegramLink(c.GetString("user_id"))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取Telegram绑定失败: %v", err)})
+		return
+	}
+	status.Link = link
+	c.JSON(http.StatusOK, status)
+}
+
+// handleCreateTelegramLinkToken 生成一次性绑定口令，之前未使用的口令作废
+func (s *Server) handleCreateTelegramLinkToken(c *gin.Context) {
+	botUsername := telegram.BotUsername()
+	if botUsername == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "服务端未配置Telegram机器人"})
+		return
+	}
+
+	token, err := telegram.GenerateLinkToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成绑定口令失败"})
+		return
+	}
+	expiresAt := time.Now().Add(telegram.LinkTokenTTL)
+	if err := s.database.CreateTelegramLinkToken(c.GetString("user_id"), telegram.HashLinkToken(token), expiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存绑定口令失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, TelegramLinkTokenResponse{
+		Token:       token,
+		BotUsername: botUsername,
+		URL:         fmt.Sprintf("https://t.me/%s?start=%s", botUsername, token),
+		ExpiresAt:   expiresAt,
+	})
+}
+
+// handleDeleteTelegramLink 解除Telegram绑定
+func (s *Server) handleDeleteTelegramLink(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if err := s.database.UnlinkTelegram(userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "尚未绑定Telegram"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("解除绑定失败: %v", err)})
+		return
+	}
+	requestLog(c).Info("用户解除了Telegram绑定")
+	c.JSON(http.StatusOK, MessageResponse{Message: "已解除Telegram绑定"})
+}
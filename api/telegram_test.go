@@ -0,0 +1,27 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestTelegramLinkWithoutBot(t *testing.T) {
+	s := newAdminTestServer(t)
+
+	w := doAs(t, s, "alice", http.MethodGet, "/api/telegram/link")
+	var status TelegramLinkStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("获取绑定状态失败: %d %s", w.Code, w.Body.String())
+	}
+	if status.Enabled || status.Link != nil {
+		t.Errorf("未配置机器人且未绑定: %+v", status)
+	}
+
+	if w := doAs(t, s, "alice", http.MethodPost, "/api/telegram/link"); w.Code != http.StatusBadRequest {
+		t.Errorf("未配置机器人时不应生成口令: %d", w.Code)
+	}
+	if w := doAs(t, s, "alice", http.MethodDelete, "/api/telegram/link"); w.Code != http.StatusNotFound {
+		t.Errorf("未绑定时解绑应返回404: %d", w.Code)
+	}
+}
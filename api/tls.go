@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"nofx/config"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsSettings HTTPS配置：证书文件和Let's Encrypt自动证书二选一，都未配置时使用HTTP
+type tlsSettings struct {
+	certFile     string
+	keyFile      string
+	domains      []string // 自动证书域名
+	cacheDir     string
+	redirectPort int // HTTP重定向端口，0 不启用
+}
+
+// loadTLSSettings 从系统配置读取HTTPS配置
+func loadTLSSettings(database *config.Database) tlsSettings {
+	get := func(key string) string {
+		value, _ := database.GetSystemConfig(key)
+		return strings.TrimSpace(value)
+	}
+	t := tlsSettings{
+		certFile: get("tls_cert_file"),
+		keyFile:  get("tls_key_file"),
+		cacheDir: get("tls_autocert_cache_dir"),
+	}
+	if domains := get("tls_autocert_domains"); domains != "" {
+		t.domains = strings.Split(domains, ",")
+	}
+	t.redirectPort, _ = strconv.Atoi(get("http_redirect_port"))
+	if t.cacheDir == "" {
+		t.cacheDir = "autocert_cache"
+	}
+	return t
+}
+
+// enabled 是否启用HTTPS
+func (t tlsSettings) enabled() bool {
+	return t.certFile != "" || t.keyFile != "" || len(t.domains) > 0
+}
+
+// validate 证书和私钥必须成对设置，且不能同时使用自动证书
+func (t tlsSettings) validate() error {
+	if (t.certFile == "") != (t.keyFile == "") {
+		return fmt.Errorf("tls_cert_file 和 tls_key_file 必须同时设置")
+	}
+	if t.certFile != "" && len(t.domains) > 0 {
+		return fmt.Errorf("证书文件和 tls_autocert_domains 只能二选一")
+	}
+	return nil
+}
+
+// setupTLS 按配置准备HTTPS和HTTP重定向服务
+func (s *Server) setupTLS() {
+	if !s.tls.enabled() {
+		return
+	}
+	redirect := httpsRedirectHandler(s.port)
+	if len(s.tls.domains) > 0 {
+		s.certManager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(s.tls.domains...),
+			Cache:      autocert.DirCache(s.tls.cacheDir),
+		}
+		s.httpServer.TLSConfig = s.certManager.TLSConfig()
+		// HTTP端口同时应答HTTP-01验证
+		redirect = s.certManager.HTTPHandler(redirect)
+	}
+	if s.tls.redirectPort > 0 {
+		s.redirectServer = &http.Server{Addr: fmt.Sprintf(":%d", s.tls.redirectPort), Handler: redirect}
+	}
+}
+
+// listenAndServe 按配置启动HTTP或HTTPS，正常关闭时返回 http.ErrServerClosed
+func (s *Server) listenAndServe() error {
+	if !s.tls.enabled() {
+		return s.httpServer.ListenAndServe()
+	}
+	if err := s.tls.validate(); err != nil {
+		return fmt.Errorf("HTTPS配置无效: %w", err)
+	}
+	if s.redirectServer != nil {
+		go func() {
+			if err := s.redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("HTTP重定向服务错误", "error", err)
+			}
+		}()
+	}
+	// 自动证书时证书由 TLSConfig.GetCertificate 提供
+	return s.httpServer.ListenAndServeTLS(s.tls.certFile, s.tls.keyFile)
+}
+
+// httpsRedirectHandler 把HTTP请求重定向到同一主机的HTTPS端口，308保留请求方法
+func httpsRedirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.Trim(host, "[]")
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nofx/config"
+	"testing"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	cases := []struct {
+		port       int
+		host, path string
+		want       string
+	}{
+		{443, "nofx.example.com", "/api/health?x=1", "https://nofx.example.com/api/health?x=1"},
+		{8443, "nofx.example.com:80", "/login", "https://nofx.example.com:8443/login"},
+		{8443, "[::1]", "/", "https://[::1]:8443/"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+		req.Host = tc.host
+		w := httptest.NewRecorder()
+		httpsRedirectHandler(tc.port).ServeHTTP(w, req)
+		if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != tc.want {
+			t.Errorf("%s%s: %d %s，期望 %s", tc.host, tc.path, w.Code, w.Header().Get("Location"), tc.want)
+		}
+	}
+}
+
+func TestTLSSettingsValidate(t *testing.T) {
+	if (tlsSettings{}).enabled() {
+		t.Error("未配置时不应启用HTTPS")
+	}
+	if err := (tlsSettings{certFile: "cert.pem"}).validate(); err == nil {
+		t.Error("只设置证书不设置私钥应报错")
+	}
+	if err := (tlsSettings{certFile: "cert.pem", keyFile: "key.pem", domains: []string{"a.com"}}).validate(); err == nil {
+		t.Error("证书文件和自动证书同时设置应报错")
+	}
+
+	if got, err := config.ValidateSystemConfig("tls_autocert_domains", " NoFX.example.com , api.example.com "); err != nil || got != "nofx.example.com,api.example.com" {
+		t.Errorf("域名应规范化: %q %v", got, err)
+	}
+	for _, bad := range []string{"https://nofx.example.com", "nofx.example.com:443", "localhost"} {
+		if _, err := config.ValidateSystemConfig("tls_autocert_domains", bad); err == nil {
+			t.Errorf("%q 应被拒绝", bad)
+		}
+	}
+}
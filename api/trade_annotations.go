@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"nofx/logger"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAnnotationNoteLength 复盘笔记的最大字符数
+const maxAnnotationNoteLength = 2000
+
+// annotationLogger 当前用户自己的交易员的决策日志，失败时已写入响应
+func (s *Server) annotationLogger(c *gin.Context) (*logger.DecisionLogger, bool) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	t, err := s.findOwnTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return nil, false
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return nil, false
+	}
+	at, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	return at.GetDecisionLogger(), true
+}
+
+// handleAddAnnotation 给决策周期或其中某个动作添加复盘笔记/评分
+// 已平仓交易用其开仓动作的下标标注，share_with_ai 的标注会出现在AI的历史表现上下文中
+func (s *Server) handleAddAnnotation(c *gin.Context) {
+	var req AnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	switch {
+	case req.Rating < 0 || req.Rating > 5:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "rating 必须在 1-5 之间（0 表示不评分）"})
+		return
+	case req.Note == "" && req.Rating == 0:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "note 和 rating 至少填写一项"})
+		return
+	case utf8.RuneCountInString(req.Note) > maxAnnotationNoteLength:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("note 不能超过 %d 个字符", maxAnnotationNoteLength)})
+		return
+	}
+
+	decisionLogger, ok := s.annotationLogger(c)
+	if !ok {
+		return
+	}
+	annotation, err := decisionLogger.AddAnnotation(c.Param("record_id"), logger.Annotation{
+		ActionIndex: req.ActionIndex,
+		Note:        req.Note,
+		Rating:      req.Rating,
+		ShareWithAI: req.ShareWithAI,
+		CreatedBy:   c.GetString("user_id"),
+	})
+	switch {
+	case errors.Is(err, logger.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "决策记录不存在"})
+		return
+	case errors.Is(err, logger.ErrInvalidActionIndex):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		requestLog(c).Error("添加决策标注失败", "trader_id", c.Param("id"), "record_id", c.Param("record_id"), "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "添加标注失败"})
+		return
+	}
+
+	requestLog(c).Info("已添加决策标注", "trader_id", c.Param("id"), "record_id", c.Param("record_id"), "annotation_id", annotation.ID)
+	c.JSON(http.StatusCreated, annotation)
+}
+
+// handleDeleteAnnotation 删除决策记录上的标注
+func (s *Server) handleDeleteAnnotation(c *gin.Context) {
+	decisionLogger, ok := s.annotationLogger(c)
+	if !ok {
+		return
+	}
+	err := decisionLogger.DeleteAnnotation(c.Param("record_id"), c.Param("annotation_id"))
+	if errors.Is(err, logger.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "标注不存在"})
+		return
+	}
+	if err != nil {
+		requestLog(c).Error("删除决策标注失败", "trader_id", c.Param("id"), "record_id", c.Param("record_id"), "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "删除标注失败"})
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "标注已删除"})
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"nofx/config"
+	"testing"
+)
+
+func TestAddAnnotationValidation(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/traders/t1/decisions/decision_20260101_120000_cycle1/annotations"
+
+	for _, body := range []string{`{"rating":6}`, `{"note":"  "}`, `{"rating":-1,"note":"x"}`} {
+		if w := doAsWithBody(t, s, "alice", http.MethodPost, path, body); w.Code != http.StatusBadRequest {
+			t.Errorf("无效标注 %s 应返回400: %d", body, w.Code)
+		}
+	}
+	if w := doAsWithBody(t, s, "boss", http.MethodPost, path, `{"note":"x"}`); w.Code != http.StatusNotFound {
+		t.Errorf("不能标注别人的交易员: %d", w.Code)
+	}
+	// 交易员未加载到内存时没有决策日志
+	if w := doAsWithBody(t, s, "alice", http.MethodPost, path, `{"note":"x","rating":3}`); w.Code != http.StatusNotFound {
+		t.Errorf("未运行的交易员应返回404: %d", w.Code)
+	}
+	if w := doAs(t, s, "alice", http.MethodDelete, path+"/abc"); w.Code != http.StatusNotFound {
+		t.Errorf("删除不存在的标注应返回404: %d", w.Code)
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/config"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleCloneTrader 以新名称复制交易员的完整配置（提示词、杠杆、币种、模型），可改用其他交易所或模型，方便做A/B对比
+func (s *Server) handleCloneTrader(c *gin.Context) {
+	userID := c.GetString("user_id")
+	sourceID := c.Param("id")
+
+	var req CloneTraderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	source, err := s.findOwnTrader(userID, sourceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	if source == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	limits, err := s.database.GetEffectiveLimits(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := s.checkTraderCountQuota(userID, limits); err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+	// 管理员可能在原交易员创建后提高了扫描间隔下限
+	if err := checkScanIntervalQuota(source.ScanIntervalMinutes, limits); err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	clone := *source
+	if req.AIModelID != "" && req.AIModelID != source.AIModelID {
+		if ok, err := s.hasAIModel(userID, req.AIModelID); err != nil || !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("AI模型不存在: %s", req.AIModelID)})
+			return
+		}
+		clone.AIModelID = req.AIModelID
+	}
+	if req.ExchangeID != "" && req.ExchangeID != source.ExchangeID {
+		if ok, err := s.hasExchange(userID, req.ExchangeID); err != nil || !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("交易所不存在: %s", req.ExchangeID)})
+			return
+		}
+		clone.ExchangeID = req.ExchangeID
+	}
+	clone.ID = fmt.Sprintf("%s_%s_%d", clone.ExchangeID, clone.AIModelID, time.Now().UnixMilli())
+	clone.Name = req.Name
+	clone.IsRunning = false
+
+	if err := s.database.CreateTrader(&clone); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("克隆交易员失败: %v", err)})
+		return
+	}
+	if err := s.traderManager.LoadUserTraders(s.database, userID); err != nil {
+		// 交易员已写入数据库，加载失败不影响克隆结果
+		requestLog(c).Warn("加载用户交易员到内存失败", "error", err)
+	}
+
+	requestLog(c).Info("克隆交易员成功", "source_id", sourceID, "trader_id", clone.ID, "ai_model", clone.AIModelID, "exchange", clone.ExchangeID)
+	c.JSON(http.StatusCreated, CreateTraderResponse{
+		TraderID:   clone.ID,
+		TraderName: clone.Name,
+		AIModel:    clone.AIModelID,
+		IsRunning:  false,
+	})
+}
+
+// findOwnTrader 当前用户自己的交易员完整配置（共享来的交易员不算），不存在时返回nil
+func (s *Server) findOwnTrader(userID, traderID string) (*config.TraderRecord, error) {
+	traders, err := s.database.GetTraders(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range traders {
+		if t.ID == traderID {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// hasAIModel 用户是否配置了该AI模型
+func (s *Server) hasAIModel(userID, id string) (bool, error) {
+	models, err := s.database.GetAIModels(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range models {
+		if m.ID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasExchange 用户是否配置了该交易所
+func (s *Server) hasExchange(userID, id string) (bool, error) {
+	exchanges, err := s.database.GetExchanges(userID)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range exchanges {
+		if e.ID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
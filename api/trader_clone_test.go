@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"testing"
+)
+
+func TestCloneTrader(t *testing.T) {
+	s := newAdminTestServer(t)
+	for _, model := range []string{"deepseek", "qwen"} {
+		if err := s.database.UpdateAIModel("alice", model, true, "sk-test", "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.database.UpdateExchange("alice", "paper", true, "", "", false, "", "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	source := &config.TraderRecord{
+		ID: "t1", UserID: "alice", Name: "原版", AIModelID: "alice_deepseek", ExchangeID: "paper",
+		InitialBalance: 1000, ScanIntervalMinutes: 5, BTCETHLeverage: 10, AltcoinLeverage: 3,
+		TradingSymbols: "BTCUSDT,ETHUSDT", CustomPrompt: "只做趋势", SystemPromptTemplate: "default",
+	}
+	if err := s.database.CreateTrader(source); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doAsWithBody(t, s, "boss", http.MethodPost, "/api/traders/t1/clone", `{"name":"x"}`); w.Code != http.StatusNotFound {
+		t.Errorf("只能克隆自己的交易员: %d", w.Code)
+	}
+	if w := doAsWithBody(t, s, "alice", http.MethodPost, "/api/traders/t1/clone", `{"name":"x","exchange_id":"binance"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("未配置的交易所应拒绝: %d", w.Code)
+	}
+
+	w := doAsWithBody(t, s, "alice", http.MethodPost, "/api/traders/t1/clone", `{"name":"Qwen版","ai_model_id":"alice_qwen"}`)
+	var created CreateTraderResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("克隆失败: %d %s", w.Code, w.Body.String())
+	}
+
+	clone, err := s.findOwnTrader("alice", created.TraderID)
+	if err != nil || clone == nil {
+		t.Fatalf("克隆的交易员未保存: %v", err)
+	}
+	if clone.Name != "Qwen版" || clone.AIModelID != "alice_qwen" || clone.ExchangeID != "paper" {
+		t.Errorf("名称/模型/交易所错误: %+v", clone)
+	}
+	if clone.BTCETHLeverage != 10 || clone.AltcoinLeverage != 3 || clone.TradingSymbols != source.TradingSymbols ||
+		clone.CustomPrompt != source.CustomPrompt || clone.ScanIntervalMinutes != 5 || clone.InitialBalance != 1000 {
+		t.Errorf("配置未完整复制: %+v", clone)
+	}
+	if clone.IsRunning {
+		t.Error("克隆的交易员应处于停止状态")
+	}
+}
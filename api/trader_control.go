@@ -0,0 +1,160 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/trader"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startTrader 校验运行配额后启动交易员并记录运行状态，失败时返回对应的HTTP状态码
+func (s *Server) startTrader(c *gin.Context, userID string, at *trader.AutoTrader) (int, error) {
+	traderID := at.GetID()
+
+	// 校验同时运行数量和扫描间隔上限
+	limits, err := s.database.GetEffectiveLimits(userID)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err := s.checkRunningQuota(userID, traderID, limits); err != nil {
+		return http.StatusForbidden, err
+	}
+
+	// 启动交易员
+	go func() {
+		tlog := at.Logger()
+		tlog.Info("启动交易员")
+		if err := at.Run(); err != nil {
+			tlog.Error("交易员运行错误", "error", err)
+			if err := s.database.UpdateTraderStatus(userID, traderID, false); err != nil {
+				tlog.Warn("更新交易员状态失败", "error", err)
+			}
+		}
+	}()
+
+	// 更新数据库中的运行状态
+	if err := s.database.UpdateTraderStatus(userID, traderID, true); err != nil {
+		requestLog(c).Warn("更新交易员状态失败", "trader_id", traderID, "error", err)
+	}
+	return http.StatusOK, nil
+}
+
+// stopTrader 停止交易员并记录运行状态：中断进行中的AI调用，最多等待 traderStopTimeout
+// 返回错误表示正在执行不可中断的操作，完成后自行退出
+func (s *Server) stopTrader(c *gin.Context, userID string, at *trader.AutoTrader) error {
+	stopErr := at.StopWithTimeout(traderStopTimeout)
+
+	// 更新数据库中的运行状态
+	if err := s.database.UpdateTraderStatus(userID, at.GetID(), false); err != nil {
+		requestLog(c).Warn("更新交易员状态失败", "trader_id", at.GetID(), "error", err)
+	}
+	return stopErr
+}
+
+// handlePauseTrader 手动暂停交易员的决策，交易员保持运行，持仓和止损止盈单保留
+func (s *Server) handlePauseTrader(c *gin.Context) {
+	s.setTraderPaused(c, true)
+}
+
+// handleUnpauseTrader 恢复被手动暂停的交易员
+func (s *Server) handleUnpauseTrader(c *gin.Context) {
+	s.setTraderPaused(c, false)
+}
+
+func (s *Server) setTraderPaused(c *gin.Context, paused bool) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	// 校验交易员是否属于当前用户
+	if _, _, _, err := s.database.GetTraderConfig(userID, traderID); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+	at, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在"})
+		return
+	}
+
+	if !at.SetPaused(paused) {
+		msg := map[bool]string{true: "交易员已处于暂停状态", false: "交易员未被暂停"}[paused]
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: msg})
+		return
+	}
+	msg := map[bool]string{true: "交易员已暂停决策", false: "交易员已恢复决策"}[paused]
+	requestLog(c).Info(msg, "trader_id", traderID, "name", at.GetName())
+	c.JSON(http.StatusOK, MessageResponse{Message: msg})
+}
+
+// 批量操作
+const (
+	bulkActionStart   = "start"
+	bulkActionStop    = "stop"
+	bulkActionPause   = "pause"
+	bulkActionUnpause = "unpause"
+)
+
+// handleBulkTraderAction 对带有某个标签的全部交易员执行启动/停止/暂停/恢复
+// 已处于目标状态的交易员跳过，单个交易员失败不影响其他交易员
+func (s *Server) handleBulkTraderAction(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req BulkTraderActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	switch req.Action {
+	case bulkActionStart, bulkActionStop, bulkActionPause, bulkActionUnpause:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("不支持的操作 %q（start/stop/pause/unpause）", req.Action)})
+		return
+	}
+	tag, err := normalizeTag(req.Tag)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// 只操作自己的交易员，共享来的交易员只读
+	ids, err := s.database.GetTraderIDsByTag(userID, tag)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+
+	resp := BulkTraderActionResponse{Action: req.Action, Tag: tag, Results: make([]BulkTraderResult, 0, len(ids))}
+	for _, id := range ids {
+		result := BulkTraderResult{TraderID: id}
+		at, err := s.traderManager.GetTrader(id)
+		if err != nil {
+			result.Error = "交易员未加载"
+			resp.Results = append(resp.Results, result)
+			continue
+		}
+		result.TraderName = at.GetName()
+
+		switch req.Action {
+		case bulkActionStart:
+			if at.IsRunning() {
+				result.Skipped = true
+			} else if _, err := s.startTrader(c, userID, at); err != nil {
+				result.Error = err.Error()
+			}
+		case bulkActionStop:
+			if !at.IsRunning() {
+				result.Skipped = true
+			} else if err := s.stopTrader(c, userID, at); err != nil {
+				result.Error = "停止超时，将在当前操作完成后退出"
+			}
+		case bulkActionPause, bulkActionUnpause:
+			result.Skipped = !at.SetPaused(req.Action == bulkActionPause)
+		}
+		result.Success = result.Error == ""
+		resp.Results = append(resp.Results, result)
+	}
+
+	requestLog(c).Info("批量操作交易员", "action", req.Action, "tag", tag, "count", len(resp.Results))
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"nofx/config"
+	"nofx/decision"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// traderExportVersion 交易员配置文档的格式版本，不兼容的改动需要递增
+const traderExportVersion = 1
+
+// handleExportTrader 导出交易员配置为可移植的JSON文档（不含密钥）
+func (s *Server) handleExportTrader(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	t, err := s.findOwnTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	// 模型ID带用户前缀，导出提供商以便在其他账户中匹配
+	provider := t.AIModelID
+	if models, err := s.database.GetAIModels(userID); err == nil {
+		for _, m := range models {
+			if m.ID == t.AIModelID {
+				provider = m.Provider
+				break
+			}
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="trader-%s.json"`, t.ID))
+	c.JSON(http.StatusOK, TraderExport{
+		Version:              traderExportVersion,
+		Name:                 t.Name,
+		AIProvider:           provider,
+		Exchange:             t.ExchangeID,
+		InitialBalance:       t.InitialBalance,
+		ScanIntervalMinutes:  t.ScanIntervalMinutes,
+		BTCETHLeverage:       t.BTCETHLeverage,
+		AltcoinLeverage:      t.AltcoinLeverage,
+		TradingSymbols:       t.TradingSymbols,
+		UseCoinPool:          t.UseCoinPool,
+		UseOITop:             t.UseOITop,
+		CustomPrompt:         t.CustomPrompt,
+		OverrideBasePrompt:   t.OverrideBasePrompt,
+		SystemPromptTemplate: t.SystemPromptTemplate,
+		IsCrossMargin:        t.IsCrossMargin,
+		MaxCandidates:        t.MaxCandidates,
+		PoolRefreshMinutes:   t.PoolRefreshMinutes,
+		LiquidityFilter:      t.LiquidityFilter,
+		MinOIValueMillions:   t.MinOIValueMillions,
+		MinVolumeMillions:    t.MinVolumeMillions,
+		ExportedAt:           time.Now().UTC(),
+	})
+}
+
+// handleImportTrader 校验导出的配置文档并据此创建交易员（处于停止状态）
+func (s *Server) handleImportTrader(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req ImportTraderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	doc := req.TraderExport
+	if doc.Version != traderExportVersion {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("不支持的配置文档版本: %d", doc.Version)})
+		return
+	}
+	if doc.Name == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "交易员名称不能为空"})
+		return
+	}
+	if doc.InitialBalance < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "初始资金不能为负数"})
+		return
+	}
+	if err := validateTraderSettings(doc.BTCETHLeverage, doc.AltcoinLeverage, doc.TradingSymbols); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validateCandidateSettings(doc.MaxCandidates, doc.PoolRefreshMinutes); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validateLiquiditySettings(doc.LiquidityFilter, doc.MinOIValueMillions, doc.MinVolumeMillions); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	// default 缺失时决策引擎会退回内置提示词，其他模板必须在本机存在
+	if doc.SystemPromptTemplate == "" {
+		doc.SystemPromptTemplate = "default"
+	}
+	if _, err := decision.GetPromptTemplate(doc.SystemPromptTemplate); err != nil && doc.SystemPromptTemplate != "default" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("提示词模板不存在: %s", doc.SystemPromptTemplate)})
+		return
+	}
+
+	limits, err := s.database.GetEffectiveLimits(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := s.checkTraderCountQuota(userID, limits); err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if doc.ScanIntervalMinutes <= 0 {
+		doc.ScanIntervalMinutes = max(defaultScanIntervalMinutes, limits.MinScanInterval)
+	}
+	if err := checkScanIntervalQuota(doc.ScanIntervalMinutes, limits); err != nil {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	aiModelID, err := s.resolveImportModel(userID, req.AIModelID, doc.AIProvider)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	exchangeID := req.ExchangeID
+	if exchangeID == "" {
+		exchangeID = doc.Exchange
+	}
+	if ok, err := s.hasExchange(userID, exchangeID); err != nil || !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("交易所不存在: %s，请先配置或通过 exchange_id 指定", exchangeID)})
+		return
+	}
+
+	record := &config.TraderRecord{
+		ID:                   fmt.Sprintf("%s_%s_%d", exchangeID, aiModelID, time.Now().UnixMilli()),
+		UserID:               userID,
+		Name:                 doc.Name,
+		AIModelID:            aiModelID,
+		ExchangeID:           exchangeID,
+		InitialBalance:       doc.InitialBalance,
+		ScanIntervalMinutes:  doc.ScanIntervalMinutes,
+		BTCETHLeverage:       doc.BTCETHLeverage,
+		AltcoinLeverage:      doc.AltcoinLeverage,
+		TradingSymbols:       doc.TradingSymbols,
+		UseCoinPool:          doc.UseCoinPool,
+		UseOITop:             doc.UseOITop,
+		CustomPrompt:         doc.CustomPrompt,
+		OverrideBasePrompt:   doc.OverrideBasePrompt,
+		SystemPromptTemplate: doc.SystemPromptTemplate,
+		IsCrossMargin:        doc.IsCrossMargin,
+		MaxCandidates:        doc.MaxCandidates,
+		PoolRefreshMinutes:   doc.PoolRefreshMinutes,
+		LiquidityFilter:      doc.LiquidityFilter,
+		MinOIValueMillions:   doc.MinOIValueMillions,
+		MinVolumeMillions:    doc.MinVolumeMillions,
+	}
+	if err := s.database.CreateTrader(record); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("导入交易员失败: %v", err)})
+		return
+	}
+	if err := s.traderManager.LoadUserTraders(s.database, userID); err != nil {
+		requestLog(c).Warn("加载用户交易员到内存失败", "error", err)
+	}
+
+	requestLog(c).Info("导入交易员成功", "trader_id", record.ID, "ai_model", aiModelID, "exchange", exchangeID)
+	c.JSON(http.StatusCreated, CreateTraderResponse{
+		TraderID:   record.ID,
+		TraderName: record.Name,
+		AIModel:    aiModelID,
+		IsRunning:  false,
+	})
+}
+
+// resolveImportModel 指定了模型ID时校验归属，否则按提供商匹配用户的模型（优先已启用的）
+func (s *Server) resolveImportModel(userID, aiModelID, provider string) (string, error) {
+	models, err := s.database.GetAIModels(userID)
+	if err != nil {
+		return "", fmt.Errorf("获取AI模型失败: %w", err)
+	}
+	if aiModelID != "" {
+		for _, m := range models {
+			if m.ID == aiModelID {
+				return m.ID, nil
+			}
+		}
+		return "", fmt.Errorf("AI模型不存在: %s", aiModelID)
+	}
+
+	match := ""
+	for _, m := range models {
+		if m.Provider != provider {
+			continue
+		}
+		if m.Enabled {
+			return m.ID, nil
+		}
+		if match == "" {
+			match = m.ID
+		}
+	}
+	if match == "" {
+		return "", fmt.Errorf("没有 %s 提供商的AI模型，请先配置或通过 ai_model_id 指定", provider)
+	}
+	return match, nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"strings"
+	"testing"
+)
+
+func TestExportImportTrader(t *testing.T) {
+	s := newAdminTestServer(t)
+	for _, userID := range []string{"alice", "boss"} {
+		if err := s.database.UpdateAIModel(userID, "deepseek", true, "sk-test", "", ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.database.UpdateExchange(userID, "paper", true, "", "", false, "", "", "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.database.CreateTrader(&config.TraderRecord{
+		ID: "t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper",
+		InitialBalance: 1000, ScanIntervalMinutes: 5, BTCETHLeverage: 8, AltcoinLeverage: 4,
+		TradingSymbols: "BTCUSDT", CustomPrompt: "只做趋势", SystemPromptTemplate: "default",
+		MaxCandidates: 12, PoolRefreshMinutes: 30, LiquidityFilter: "both", MinVolumeMillions: 80,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/export")
+	if w.Code != http.StatusOK {
+		t.Fatalf("导出失败: %d %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "sk-test") || strings.Contains(body, "alice_deepseek") {
+		t.Errorf("导出文档不应包含密钥或用户相关的模型ID: %s", body)
+	}
+	var doc TraderExport
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || doc.Version != traderExportVersion || doc.AIProvider != "deepseek" {
+		t.Fatalf("导出文档错误: %s", body)
+	}
+
+	// 其他用户导入时匹配自己的模型
+	w = doAsWithBody(t, s, "boss", http.MethodPost, "/api/traders/import", body)
+	var created CreateTraderResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("导入失败: %d %s", w.Code, w.Body.String())
+	}
+	imported, err := s.findOwnTrader("boss", created.TraderID)
+	if err != nil || imported == nil {
+		t.Fatalf("导入的交易员未保存: %v", err)
+	}
+	if imported.AIModelID != "boss_deepseek" || imported.BTCETHLeverage != 8 || imported.CustomPrompt != "只做趋势" || imported.TradingSymbols != "BTCUSDT" ||
+		imported.MaxCandidates != 12 || imported.PoolRefreshMinutes != 30 || imported.LiquidityFilter != "both" || imported.MinVolumeMillions != 80 {
+		t.Errorf("导入的配置错误: %+v", imported)
+	}
+
+	for name, mutate := range map[string]func(d *TraderExport){
+		"版本不支持":  func(d *TraderExport) { d.Version = 99 },
+		"杠杆越界":   func(d *TraderExport) { d.BTCETHLeverage = 100 },
+		"币种格式":   func(d *TraderExport) { d.TradingSymbols = "BTC" },
+		"候选上限越界": func(d *TraderExport) { d.MaxCandidates = 1000 },
+		"流动性方式":  func(d *TraderExport) { d.LiquidityFilter = "spread" },
+		"模板不存在":  func(d *TraderExport) { d.SystemPromptTemplate = "missing" },
+		"没有该交易所": func(d *TraderExport) { d.Exchange = "binance" },
+		"没有该模型":  func(d *TraderExport) { d.AIProvider = "qwen" },
+	} {
+		bad := doc
+		mutate(&bad)
+		data, _ := json.Marshal(bad)
+		if w := doAsWithBody(t, s, "boss", http.MethodPost, "/api/traders/import", string(data)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: 应拒绝导入, got %d %s", name, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"nofx/logging"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultTraderLogLimit 未指定 limit 时返回的日志条数
+const defaultTraderLogLimit = 200
+
+// handleTraderLogs 交易员最近的日志；follow=true 时先返回缓冲的日志，再以SSE推送新日志
+func (s *Server) handleTraderLogs(c *gin.Context) {
+	userID, err := s.streamUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		return
+	}
+	traderID := c.Param("id")
+	if !s.canViewTrader(userID, traderID) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	limit := defaultTraderLogLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > logging.TraderLogCapacity {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit 必须在 1-" + strconv.Itoa(logging.TraderLogCapacity) + " 之间"})
+			return
+		}
+		limit = n
+	}
+	minLevel := slog.LevelInfo
+	if value := c.Query("level"); value != "" {
+		if minLevel, err = logging.ParseLevel(value); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	if c.Query("follow") != "true" {
+		c.JSON(http.StatusOK, logging.TraderLogs(traderID, limit, minLevel))
+		return
+	}
+
+	// 先订阅再读取缓冲，避免两者之间的日志丢失
+	live, unsubscribe := logging.SubscribeTraderLogs(traderID, 64)
+	defer unsubscribe()
+	backlog := logging.TraderLogs(traderID, limit, minLevel)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		if len(backlog) > 0 {
+			for _, entry := range backlog {
+				c.SSEvent("log", entry)
+			}
+			backlog = nil
+			return true
+		}
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-s.shutdown:
+			return false
+		case entry, ok := <-live:
+			if !ok {
+				return false
+			}
+			if logLevelAtLeast(entry.Level, minLevel) {
+				c.SSEvent("log", entry)
+			}
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
+
+// logLevelAtLeast 日志级别是否不低于 minLevel
+func logLevelAtLeast(name string, minLevel slog.Level) bool {
+	lv, err := logging.ParseLevel(name)
+	return err != nil || lv >= minLevel
+}
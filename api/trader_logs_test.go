@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"nofx/config"
+	"nofx/logging"
+	"os"
+	"testing"
+)
+
+func TestTraderLogs(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := logging.Setup(io.Discard, "warn", logging.FormatText); err != nil {
+		t.Fatal(err)
+	}
+	defer log.SetOutput(os.Stderr)
+	for _, id := range []string{"t1", "t2"} {
+		defer logging.DropTraderLogs(id)
+	}
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t2", UserID: "boss", Name: "管理员的", AIModelID: "boss_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	l := slog.With("trader_id", "t1")
+	l.Info("AI决策周期开始", "cycle", 1)
+	l.Warn("下单失败", "symbol", "BTCUSDT")
+	l.Info("AI决策周期开始", "cycle", 2)
+
+	w := doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/logs?limit=2")
+	var entries []logging.Entry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("获取日志失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(entries) != 2 || entries[0].Message != "下单失败" || entries[1].Attrs["cycle"] != float64(2) {
+		t.Errorf("应返回最近的2条日志: %+v", entries)
+	}
+
+	w = doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/logs?level=warn")
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil || len(entries) != 1 || entries[0].Level != "WARN" {
+		t.Errorf("按级别过滤错误: %s", w.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/api/traders/t1/logs?limit=0":      http.StatusBadRequest,
+		"/api/traders/t1/logs?level=loud":   http.StatusBadRequest,
+		"/api/traders/t2/logs":              http.StatusNotFound,
+		"/api/traders/missing/logs?limit=5": http.StatusNotFound,
+	} {
+		if w := doAs(t, s, "alice", http.MethodGet, path); w.Code != want {
+			t.Errorf("%s: got %d, want %d", path, w.Code, want)
+		}
+	}
+	if w := doAs(t, s, "boss", http.MethodGet, "/api/traders/t1/logs"); w.Code != http.StatusOK {
+		t.Errorf("管理员应能查看所有交易员的日志: %d", w.Code)
+	}
+}
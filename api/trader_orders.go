@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"nofx/trader"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultOrderLimit = 100 // 未指定 limit 时返回的订单数
+	maxOrderLimit     = 1000
+)
+
+// orderStatuses 可用于过滤的订单状态
+var orderStatuses = map[string]bool{
+	trader.OrderStatusSubmitted: true,
+	trader.OrderStatusOpen:      true,
+	trader.OrderStatusFilled:    true,
+	trader.OrderStatusRejected:  true,
+	trader.OrderStatusUnknown:   true,
+	trader.OrderStatusCanceled:  true,
+	trader.OrderStatusTriggered: true,
+}
+
+// handleTraderOrders 交易员最近的订单及每笔订单的状态变化（新的在前）
+func (s *Server) handleTraderOrders(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+	if !s.canViewTrader(userID, traderID) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	limit := defaultOrderLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxOrderLimit {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit 必须在 1-" + strconv.Itoa(maxOrderLimit) + " 之间"})
+			return
+		}
+		limit = n
+	}
+	status := c.Query("status")
+	if status != "" && !orderStatuses[status] {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "无效的订单状态: " + status})
+		return
+	}
+
+	orders, err := s.database.GetTraderOrders(traderID, status, limit)
+	if err != nil {
+		requestLog(c).Error("获取订单记录失败", "trader_id", traderID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "获取订单记录失败"})
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"testing"
+	"time"
+)
+
+func TestTraderOrders(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "alice", Name: "趋势", AIModelID: "alice_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t2", UserID: "boss", Name: "管理员的", AIModelID: "boss_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for _, o := range []*config.Order{
+		{ClientOrderID: "o1", TraderID: "t1", UserID: "alice", Symbol: "BTCUSDT", Action: "open_long", Type: "market", Status: "submitted", Quantity: 0.01, Price: 60000, UpdatedAt: now},
+		{ClientOrderID: "o1", TraderID: "t1", Status: "filled", ExchangeOrderID: "42", FillPrice: 60010, UpdatedAt: now.Add(time.Second)},
+		{ClientOrderID: "o2", TraderID: "t1", UserID: "alice", Symbol: "BTCUSDT", Action: "close_long", Type: "stop_loss", Status: "open", Quantity: 0.01, StopPrice: 58000, UpdatedAt: now.Add(2 * time.Second)},
+		{ClientOrderID: "o2", TraderID: "t1", Status: "canceled", Reason: "position_closed", UpdatedAt: now.Add(3 * time.Second)},
+	} {
+		if err := s.database.RecordOrder(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/orders")
+	var orders []config.Order
+	if err := json.Unmarshal(w.Body.Bytes(), &orders); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("获取订单失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(orders) != 2 || orders[0].ClientOrderID != "o2" || orders[1].ClientOrderID != "o1" {
+		t.Fatalf("应按时间倒序返回2笔订单: %+v", orders)
+	}
+	filled := orders[1]
+	if filled.Status != "filled" || filled.ExchangeOrderID != "42" || filled.FillPrice != 60010 || filled.Price != 60000 || filled.Symbol != "BTCUSDT" {
+		t.Errorf("状态更新应保留下单信息: %+v", filled)
+	}
+	if len(filled.Events) != 2 || filled.Events[0].Status != "submitted" || filled.Events[1].Status != "filled" {
+		t.Errorf("状态变化错误: %+v", filled.Events)
+	}
+	if orders[0].Reason != "position_closed" {
+		t.Errorf("撤销原因错误: %+v", orders[0])
+	}
+
+	w = doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/orders?status=canceled")
+	if err := json.Unmarshal(w.Body.Bytes(), &orders); err != nil || len(orders) != 1 || orders[0].ClientOrderID != "o2" {
+		t.Errorf("按状态过滤错误: %s", w.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/api/traders/t1/orders?limit=0":      http.StatusBadRequest,
+		"/api/traders/t1/orders?status=done":  http.StatusBadRequest,
+		"/api/traders/t2/orders":              http.StatusNotFound,
+		"/api/traders/missing/orders?limit=5": http.StatusNotFound,
+	} {
+		if w := doAs(t, s, "alice", http.MethodGet, path); w.Code != want {
+			t.Errorf("%s: got %d, want %d", path, w.Code, want)
+		}
+	}
+	if w := doAs(t, s, "boss", http.MethodGet, "/api/traders/t1/orders"); w.Code != http.StatusOK {
+		t.Errorf("管理员应能查看所有交易员的订单: %d", w.Code)
+	}
+
+	if err := s.database.DeleteTrader("alice", "t1"); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, err := s.database.GetTraderOrders("t1", "", 10); err != nil || len(remaining) != 0 {
+		t.Errorf("删除交易员应清理订单记录: %v %v", remaining, err)
+	}
+}
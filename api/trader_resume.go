@@ -0,0 +1,17 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetTraderResumeReport 启动时恢复运行中交易员的报告（管理员）
+func (s *Server) handleGetTraderResumeReport(c *gin.Context) {
+	report := s.traderManager.GetResumeReport()
+	if report == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "本次启动未恢复交易员（resume_traders_on_boot 已关闭）"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
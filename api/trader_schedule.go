@@ -0,0 +1,89 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"nofx/config"
+	"nofx/trader"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetTraderSchedule 获取交易员的交易时段
+func (s *Server) handleGetTraderSchedule(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	schedule, err := s.database.GetTraderSchedule(userID, traderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "未设置交易时段（全天运行）"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易时段失败: %v", err)})
+		return
+	}
+	parsed, err := trader.ParseSchedule(schedule.Spec, schedule.Timezone)
+	c.JSON(http.StatusOK, TraderScheduleResponse{TraderSchedule: *schedule, Active: err == nil && parsed.Active(time.Now())})
+}
+
+// handleSetTraderSchedule 设置交易员的交易时段，时段外交易员跳过决策周期（持仓和止损止盈单保留）
+func (s *Server) handleSetTraderSchedule(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	var req TraderScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	parsed, err := trader.ParseSchedule(req.Spec, req.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	t, err := s.findOwnTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	schedule := &config.TraderSchedule{TraderID: traderID, UserID: userID, Spec: req.Spec, Timezone: req.Timezone}
+	if err := s.database.SetTraderSchedule(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存交易时段失败: %v", err)})
+		return
+	}
+	s.traderManager.SetTraderSchedule(traderID, parsed)
+
+	requestLog(c).Info("交易时段已更新", "trader_id", traderID, "schedule", req.Spec, "timezone", req.Timezone)
+	schedule.UpdatedAt = time.Now().UTC()
+	c.JSON(http.StatusOK, TraderScheduleResponse{TraderSchedule: *schedule, Active: parsed.Active(time.Now())})
+}
+
+// handleDeleteTraderSchedule 删除交易时段，交易员恢复全天运行
+func (s *Server) handleDeleteTraderSchedule(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	err := s.database.DeleteTraderSchedule(userID, traderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "未设置交易时段"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("删除交易时段失败: %v", err)})
+		return
+	}
+	s.traderManager.SetTraderSchedule(traderID, nil)
+
+	requestLog(c).Info("交易时段已删除，恢复全天运行", "trader_id", traderID)
+	c.JSON(http.StatusOK, MessageResponse{Message: "交易时段已删除，交易员恢复全天运行"})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"testing"
+)
+
+func TestTraderSchedule(t *testing.T) {
+	s := newAdminTestServer(t)
+	if err := s.database.CreateTrader(&config.TraderRecord{ID: "t1", UserID: "alice", Name: "夜猫", AIModelID: "alice_deepseek", ExchangeID: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/schedule"); w.Code != http.StatusNotFound {
+		t.Errorf("未设置时段应返回404: %d", w.Code)
+	}
+	for _, body := range []string{`{"spec":"weekdays 08:00-22:00"}`, `{"spec":"mon-fri 08:00-22:00","timezone":"Mars/Base"}`, `{}`} {
+		if w := doAsWithBody(t, s, "alice", http.MethodPut, "/api/traders/t1/schedule", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s 应拒绝: %d", body, w.Code)
+		}
+	}
+	if w := doAsWithBody(t, s, "boss", http.MethodPut, "/api/traders/t1/schedule", `{"spec":"* 00:00-24:00"}`); w.Code != http.StatusNotFound {
+		t.Errorf("只能设置自己的交易员: %d", w.Code)
+	}
+
+	w := doAsWithBody(t, s, "alice", http.MethodPut, "/api/traders/t1/schedule", `{"spec":"* 00:00-24:00"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("设置时段失败: %d %s", w.Code, w.Body.String())
+	}
+	w = doAs(t, s, "alice", http.MethodGet, "/api/traders/t1/schedule")
+	var resp TraderScheduleResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("获取时段失败: %d %s", w.Code, w.Body.String())
+	}
+	if resp.Spec != "* 00:00-24:00" || !resp.Active {
+		t.Errorf("时段错误: %+v", resp)
+	}
+
+	if w := doAs(t, s, "alice", http.MethodDelete, "/api/traders/t1/schedule"); w.Code != http.StatusOK {
+		t.Fatalf("删除时段失败: %d", w.Code)
+	}
+	if w := doAs(t, s, "alice", http.MethodDelete, "/api/traders/t1/schedule"); w.Code != http.StatusNotFound {
+		t.Errorf("重复删除应返回404: %d", w.Code)
+	}
+}
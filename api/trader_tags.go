@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTraderTags 每个交易员最多的标签数
+const maxTraderTags = 10
+
+// tagPattern 标签只允许小写字母、数字、下划线和连字符，如 "aggressive"、"btc-only"
+var tagPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// normalizeTag 标签去掉首尾空白并转为小写后校验
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(tag) {
+		return "", fmt.Errorf("无效的标签 %q：只能包含字母、数字、下划线和连字符，最长32个字符", tag)
+	}
+	return tag, nil
+}
+
+// normalizeTags 校验、去重并排序
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		tag, err := normalizeTag(t)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	if len(result) > maxTraderTags {
+		return nil, fmt.Errorf("每个交易员最多 %d 个标签", maxTraderTags)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// handleSetTraderTags 替换交易员的标签，空列表清除全部标签
+func (s *Server) handleSetTraderTags(c *gin.Context) {
+	userID := c.GetString("user_id")
+	traderID := c.Param("id")
+
+	var req TraderTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	t, err := s.findOwnTrader(userID, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取交易员失败: %v", err)})
+		return
+	}
+	if t == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+		return
+	}
+
+	if err := s.database.SetTraderTags(userID, traderID, tags); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("保存标签失败: %v", err)})
+		return
+	}
+
+	requestLog(c).Info("交易员标签已更新", "trader_id", traderID, "tags", tags)
+	c.JSON(http.StatusOK, TraderTagsRequest{Tags: tags})
+}
+
+// tagFilter 解析 ?tag= 查询参数，未指定时返回空字符串
+func tagFilter(c *gin.Context) (string, bool) {
+	raw := c.Query("tag")
+	if raw == "" {
+		return "", true
+	}
+	tag, err := normalizeTag(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return "", false
+	}
+	return tag, true
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"nofx/config"
+	"testing"
+)
+
+func TestTraderTags(t *testing.T) {
+	s := newAdminTestServer(t)
+	for _, tr := range []*config.TraderRecord{
+		{ID: "t1", UserID: "alice", Name: "激进", AIModelID: "alice_deepseek", ExchangeID: "paper"},
+		{ID: "t2", UserID: "alice", Name: "稳健", AIModelID: "alice_deepseek", ExchangeID: "paper"},
+	} {
+		if err := s.database.CreateTrader(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, body := range []string{`{"tags":["has space"]}`, `{"tags":[""]}`, `{"tags":["a","b","c","d","e","f","g","h","i","j","k"]}`} {
+		if w := doAsWithBody(t, s, "alice", http.MethodPut, "/api/traders/t1/tags", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s 应拒绝: %d", body, w.Code)
+		}
+	}
+	if w := doAsWithBody(t, s, "boss", http.MethodPut, "/api/traders/t1/tags", `{"tags":["x"]}`); w.Code != http.StatusNotFound {
+		t.Errorf("只能给自己的交易员打标签: %d", w.Code)
+	}
+
+	w := doAsWithBody(t, s, "alice", http.MethodPut, "/api/traders/t1/tags", `{"tags":[" Aggressive ","btc-only","aggressive"]}`)
+	var tags TraderTagsRequest
+	if err := json.Unmarshal(w.Body.Bytes(), &tags); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("设置标签失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(tags.Tags) != 2 || tags.Tags[0] != "aggressive" || tags.Tags[1] != "btc-only" {
+		t.Errorf("标签应转为小写、去重并排序: %v", tags.Tags)
+	}
+
+	w = doAs(t, s, "alice", http.MethodGet, "/api/my-traders?tag=btc-only")
+	var list []TraderSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("获取交易员列表失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(list) != 1 || list[0].TraderID != "t1" || len(list[0].Tags) != 2 {
+		t.Errorf("按标签筛选错误: %+v", list)
+	}
+	w = doAs(t, s, "alice", http.MethodGet, "/api/my-traders")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 2 {
+		t.Fatalf("不筛选时应返回全部交易员: %s", w.Body.String())
+	}
+
+	if w := doAsWithBody(t, s, "alice", http.MethodPost, "/api/traders/bulk", `{"tag":"aggressive","action":"explode"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("不支持的操作应拒绝: %d", w.Code)
+	}
+	// 测试中交易员未加载到内存，逐个报告失败
+	w = doAsWithBody(t, s, "alice", http.MethodPost, "/api/traders/bulk", `{"tag":"aggressive","action":"pause"}`)
+	var bulk BulkTraderActionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &bulk); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("批量操作失败: %d %s", w.Code, w.Body.String())
+	}
+	if len(bulk.Results) != 1 || bulk.Results[0].TraderID != "t1" || bulk.Results[0].Success {
+		t.Errorf("批量操作结果错误: %+v", bulk)
+	}
+	// 其他用户的同名标签不受影响
+	w = doAsWithBody(t, s, "boss", http.MethodPost, "/api/traders/bulk", `{"tag":"aggressive","action":"stop"}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &bulk); err != nil || len(bulk.Results) != 0 {
+		t.Errorf("只应操作自己的交易员: %s", w.Body.String())
+	}
+
+	if w := doAsWithBody(t, s, "alice", http.MethodPut, "/api/traders/t1/tags", `{"tags":[]}`); w.Code != http.StatusOK {
+		t.Fatalf("清除标签失败: %d", w.Code)
+	}
+	w = doAs(t, s, "alice", http.MethodGet, "/api/my-traders?tag=aggressive")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list) != 0 {
+		t.Errorf("清除后不应再匹配: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,687 @@
+package api
+
+import (
+	"encoding/json"
+	"nofx/backtest"
+	"nofx/config"
+	"nofx/decision"
+	"nofx/digest"
+	"nofx/health"
+	"nofx/logger"
+	"nofx/market"
+	"nofx/pool"
+	"nofx/trader"
+	"time"
+)
+
+// ErrorResponse 失败时的统一响应
+type ErrorResponse struct {
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"` // 由中间件填写，反馈问题时提供以便查日志
+}
+
+// MessageResponse 只返回提示信息的响应
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// HealthCheck 单项依赖的检查结果
+type HealthCheck struct {
+	Status  string `json:"status"` // ok / degraded / unhealthy / unknown
+	Message string `json:"message,omitempty"`
+}
+
+// MarketFeedHealth WebSocket行情的检查结果
+type MarketFeedHealth struct {
+	HealthCheck
+	market.FeedStatus
+}
+
+// HealthResponse 健康检查：status 为 ok / degraded / unhealthy，unhealthy 时返回503
+type HealthResponse struct {
+	Status      string           `json:"status"`
+	Time        time.Time        `json:"time"`
+	Database    HealthCheck      `json:"database"`
+	MarketFeed  MarketFeedHealth `json:"market_feed"`
+	AIProviders []health.Status  `json:"ai_providers"` // 按最近一次真实调用判断，没有调用过的提供商不出现
+	Exchanges   []health.Status  `json:"exchanges"`
+}
+
+// SystemConfigResponse 前端需要的系统配置
+type SystemConfigResponse struct {
+	AdminMode       bool     `json:"admin_mode"`
+	BetaMode        bool     `json:"beta_mode"`
+	DefaultCoins    []string `json:"default_coins"`
+	BTCETHLeverage  int      `json:"btc_eth_leverage"`
+	AltcoinLeverage int      `json:"altcoin_leverage"`
+}
+
+// TraderSummary 交易员列表项
+type TraderSummary struct {
+	TraderID       string   `json:"trader_id"`
+	TraderName     string   `json:"trader_name"`
+	AIModel        string   `json:"ai_model"`
+	ExchangeID     string   `json:"exchange_id"`
+	IsRunning      bool     `json:"is_running"`
+	InitialBalance float64  `json:"initial_balance"`
+	IsShared       bool     `json:"is_shared"`        // 其他用户共享给当前用户的（只读）
+	Health         string   `json:"health,omitempty"` // ok / stopped / stalled / error_loop，交易员未加载时为空
+	Paused         bool     `json:"paused"`           // 手动暂停决策
+	Tags           []string `json:"tags"`
+}
+
+// CreateTraderResponse 创建交易员结果
+type CreateTraderResponse struct {
+	TraderID   string `json:"trader_id"`
+	TraderName string `json:"trader_name"`
+	AIModel    string `json:"ai_model"`
+	IsRunning  bool   `json:"is_running"`
+}
+
+// CloneTraderRequest 克隆交易员请求，AI模型和交易所为空时沿用原交易员的配置
+type CloneTraderRequest struct {
+	Name       string `json:"name" binding:"required"`
+	AIModelID  string `json:"ai_model_id"`
+	ExchangeID string `json:"exchange_id"`
+}
+
+// TraderExport 可移植的交易员配置文档，不含密钥、代理地址和用户相关的ID
+type TraderExport struct {
+	Version              int       `json:"version"`
+	Name                 string    `json:"name"`
+	AIProvider           string    `json:"ai_provider"` // deepseek / qwen / custom，导入时匹配用户自己配置的模型
+	Exchange             string    `json:"exchange"`    // binance / hyperliquid / aster / paper
+	InitialBalance       float64   `json:"initial_balance"`
+	ScanIntervalMinutes  int       `json:"scan_interval_minutes"`
+	BTCETHLeverage       int       `json:"btc_eth_leverage"`
+	AltcoinLeverage      int       `json:"altcoin_leverage"`
+	TradingSymbols       string    `json:"trading_symbols"`
+	UseCoinPool          bool      `json:"use_coin_pool"`
+	UseOITop             bool      `json:"use_oi_top"`
+	CustomPrompt         string    `json:"custom_prompt"`
+	OverrideBasePrompt   bool      `json:"override_base_prompt"`
+	SystemPromptTemplate string    `json:"system_prompt_template"`
+	IsCrossMargin        bool      `json:"is_cross_margin"`
+	MaxCandidates        int       `json:"max_candidates,omitempty"`
+	PoolRefreshMinutes   int       `json:"pool_refresh_minutes,omitempty"`
+	LiquidityFilter      string    `json:"liquidity_filter,omitempty"`
+	MinOIValueMillions   float64   `json:"min_oi_value_millions,omitempty"`
+	MinVolumeMillions    float64   `json:"min_volume_millions,omitempty"`
+	ExportedAt           time.Time `json:"exported_at"`
+}
+
+// ImportTraderRequest 导入交易员：导出的文档，可指定使用自己的哪个模型和交易所
+type ImportTraderRequest struct {
+	TraderExport
+	AIModelID  string `json:"ai_model_id"` // 为空时按 ai_provider 匹配
+	ExchangeID string `json:"exchange_id"` // 为空时使用 exchange
+}
+
+// TraderScheduleRequest 设置交易时段，规则如 "mon-fri 08:00-22:00; sat 10:00-14:00"
+type TraderScheduleRequest struct {
+	Spec     string `json:"spec" binding:"required"`
+	Timezone string `json:"timezone"` // IANA时区名，空表示UTC
+}
+
+// TraderScheduleResponse 交易员的交易时段及当前是否处于时段内
+type TraderScheduleResponse struct {
+	config.TraderSchedule
+	Active bool `json:"active"`
+}
+
+// TraderTagsRequest 交易员的标签（替换全部标签）
+type TraderTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// BulkTraderActionRequest 按标签批量操作交易员
+type BulkTraderActionRequest struct {
+	Tag    string `json:"tag" binding:"required"`
+	Action string `json:"action" binding:"required"` // start / stop / pause / unpause
+}
+
+// BulkTraderResult 批量操作中单个交易员的结果
+type BulkTraderResult struct {
+	TraderID   string `json:"trader_id"`
+	TraderName string `json:"trader_name,omitempty"`
+	Success    bool   `json:"success"`
+	Skipped    bool   `json:"skipped,omitempty"` // 已处于目标状态
+	Error      string `json:"error,omitempty"`
+}
+
+// BulkTraderActionResponse 批量操作结果
+type BulkTraderActionResponse struct {
+	Action  string             `json:"action"`
+	Tag     string             `json:"tag"`
+	Results []BulkTraderResult `json:"results"`
+}
+
+// UpdateTraderResponse 更新交易员结果
+type UpdateTraderResponse struct {
+	TraderID   string `json:"trader_id"`
+	TraderName string `json:"trader_name"`
+	AIModel    string `json:"ai_model"`
+	Message    string `json:"message"`
+}
+
+// UpdatePromptRequest 更新交易员自定义prompt
+type UpdatePromptRequest struct {
+	CustomPrompt       string `json:"custom_prompt"`
+	OverrideBasePrompt bool   `json:"override_base_prompt"`
+}
+
+// SignalSource 用户信号源配置
+type SignalSource struct {
+	CoinPoolURL string `json:"coin_pool_url"`
+	OITopURL    string `json:"oi_top_url"`
+}
+
+// KlineExportResponse 导出的K线及指标（json格式）
+type KlineExportResponse struct {
+	Symbol   string                  `json:"symbol"`
+	Interval string                  `json:"interval"`
+	Klines   []market.KlineExportRow `json:"klines"`
+}
+
+// RegisterRequest 注册
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	BetaCode string `json:"beta_code"`
+}
+
+// RegisterResponse 注册后需要绑定OTP；恢复码明文只返回这一次
+type RegisterResponse struct {
+	UserID        string   `json:"user_id"`
+	Email         string   `json:"email"`
+	OTPSecret     string   `json:"otp_secret"`
+	QRCodeURL     string   `json:"qr_code_url"`
+	RecoveryCodes []string `json:"recovery_codes"`
+	Message       string   `json:"message"`
+}
+
+// OTPRequest 提交OTP验证码（完成注册/登录）
+type OTPRequest struct {
+	UserID  string `json:"user_id" binding:"required"`
+	OTPCode string `json:"otp_code" binding:"required"`
+}
+
+// LoginRequest 登录
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginResponse 密码验证通过，等待OTP
+type LoginResponse struct {
+	UserID      string `json:"user_id"`
+	Email       string `json:"email"`
+	Message     string `json:"message"`
+	RequiresOTP bool   `json:"requires_otp"`
+}
+
+// OTPSetupRequiredResponse 账户尚未完成OTP绑定
+type OTPSetupRequiredResponse struct {
+	Error            string `json:"error"`
+	UserID           string `json:"user_id"`
+	RequiresOTPSetup bool   `json:"requires_otp_setup"`
+	OTPSecret        string `json:"otp_secret,omitempty"` // 密码已验证，返回密钥用于（重新）绑定验证器
+	QRCodeURL        string `json:"qr_code_url,omitempty"`
+}
+
+// AuthResponse 认证完成，返回token
+type AuthResponse struct {
+	Token   string `json:"token"`
+	UserID  string `json:"user_id"`
+	Email   string `json:"email"`
+	Role    string `json:"role"`
+	Message string `json:"message"`
+}
+
+// RecoveryLoginRequest 用恢复码代替OTP登录
+type RecoveryLoginRequest struct {
+	Email        string `json:"email" binding:"required,email"`
+	Password     string `json:"password" binding:"required"`
+	RecoveryCode string `json:"recovery_code" binding:"required"`
+}
+
+// RecoveryLoginResponse 恢复码登录结果
+type RecoveryLoginResponse struct {
+	AuthResponse
+	RemainingRecoveryCodes int `json:"remaining_recovery_codes"`
+}
+
+// PasswordResetRequest 申请重置密码
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// PasswordResetConfirmRequest 使用重置链接中的token设置新密码
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// ShareTraderRequest 按邮箱共享交易员
+type ShareTraderRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// CreateShareLinkRequest 创建只读分享链接
+type CreateShareLinkRequest struct {
+	Label string `json:"label"` // 备注，方便区分发给谁
+}
+
+// CreateShareLinkResponse 分享链接，token 只在创建时返回
+type CreateShareLinkResponse struct {
+	*config.ShareLink
+	Token string `json:"token"` // 访问 /api/shared/<token>/...
+}
+
+// CreateWebhookRequest 注册webhook
+type CreateWebhookRequest struct {
+	URL      string   `json:"url" binding:"required"`
+	TraderID string   `json:"trader_id"` // 为空表示所有交易员
+	Events   []string `json:"events"`    // position_opened / position_closed / stop_hit / trader_error / drawdown_alert，为空表示全部
+}
+
+// CreateWebhookResponse 注册结果，secret 用于校验 X-NOFX-Signature
+type CreateWebhookResponse struct {
+	Webhook *config.Webhook `json:"webhook"`
+	Secret  string          `json:"secret"`
+}
+
+// WebhookTestResponse 测试投递结果
+type WebhookTestResponse struct {
+	Status int    `json:"status"` // 对方返回的HTTP状态码，连接失败为0
+	Error  string `json:"error,omitempty"`
+}
+
+// UpdateRoleRequest 管理员修改用户角色
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// SystemConfigItem 系统配置项定义及当前值
+type SystemConfigItem struct {
+	config.SystemConfigSpec
+	Value string `json:"value"`
+}
+
+// UpdateSystemConfigRequest 批量修改系统配置，key 为配置名
+type UpdateSystemConfigRequest struct {
+	Values map[string]string `json:"values" binding:"required"`
+}
+
+// UpdateSystemConfigResponse 修改结果
+type UpdateSystemConfigResponse struct {
+	Changed         []config.SystemConfigChange `json:"changed"`
+	RestartRequired []string                    `json:"restart_required"` // 需重启服务才生效的配置
+	Warnings        []string                    `json:"warnings"`
+}
+
+// PasswordRequest 敏感操作前再次确认密码
+type PasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// SignalInput 推送的一条信号
+type SignalInput struct {
+	Symbol     string `json:"symbol"`                // 如 SOL 或 SOLUSDT
+	Side       string `json:"side,omitempty"`        // long / short，为空表示只加入候选币种
+	Confidence int    `json:"confidence,omitempty"`  // 0-100
+	Note       string `json:"note,omitempty"`        // 信号说明，超过500字截断
+	Source     string `json:"source,omitempty"`      // 来源名称，如 tradingview
+	TTLMinutes int    `json:"ttl_minutes,omitempty"` // 有效期，默认60分钟，最长1440分钟
+}
+
+// SignalIngestRequest 外部系统推送的信号（请求体即签名内容）
+type SignalIngestRequest struct {
+	TraderID string        `json:"trader_id"`
+	Signals  []SignalInput `json:"signals"`
+}
+
+// SignalIngestResponse 推送结果
+type SignalIngestResponse struct {
+	Accepted int `json:"accepted"`
+	Active   int `json:"active"` // 交易员当前未过期的信号数
+}
+
+// SignalKeyResponse 新生成的信号签名密钥（明文只返回一次）
+type SignalKeyResponse struct {
+	TraderID string `json:"trader_id"`
+	Secret   string `json:"secret"`
+	Message  string `json:"message"`
+}
+
+// TraderSignalsResponse 交易员的信号推送状态
+type TraderSignalsResponse struct {
+	Key     *config.SignalKey         `json:"key"` // 为空表示未开启信号推送
+	Signals []decision.ExternalSignal `json:"signals"`
+}
+
+// CreateSignalSourceRequest 为交易员注册信号源
+type CreateSignalSourceRequest struct {
+	Name string `json:"name" binding:"required"` // 候选币种的来源标记
+	pool.SourceConfig
+}
+
+// CreateSignalSourceResponse 新注册的信号源，webhook 类型的签名密钥只在创建时返回
+type CreateSignalSourceResponse struct {
+	Source   *config.TraderSignalSource `json:"source"`
+	Secret   string                     `json:"secret,omitempty"`
+	PushPath string                     `json:"push_path,omitempty"` // webhook 类型的推送地址
+}
+
+// SignalSourceTestResponse 立即请求一次信号源的结果
+type SignalSourceTestResponse struct {
+	Coins []pool.SourceCoin `json:"coins"`
+	Error string            `json:"error,omitempty"`
+}
+
+// SignalSourcePushRequest 推送到 webhook 信号源的币种（请求体即签名内容）
+type SignalSourcePushRequest struct {
+	Coins      []pool.SourceCoin `json:"coins"`
+	TTLMinutes int               `json:"ttl_minutes"` // 有效期，默认60分钟，最长24小时
+}
+
+// DeleteAccountRequest 注销账户需要再次确认密码（已绑定验证器时还需要OTP验证码）
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+	OTPCode  string `json:"otp_code"`
+}
+
+// AccountExport 个人数据导出包中的 account.json
+type AccountExport struct {
+	Version      int                      `json:"version"`
+	User         *config.User             `json:"user"` // 不含密码哈希和OTP密钥
+	SignalSource *config.UserSignalSource `json:"signal_source,omitempty"`
+	Telegram     *config.TelegramLink     `json:"telegram,omitempty"`
+	DailyDigest  bool                     `json:"daily_digest"`
+	ExportedAt   time.Time                `json:"exported_at"`
+}
+
+// OTPCodeRequest 已登录用户提交OTP验证码
+type OTPCodeRequest struct {
+	OTPCode string `json:"otp_code" binding:"required"`
+}
+
+// OTPResetResponse 待确认的新OTP密钥
+type OTPResetResponse struct {
+	OTPSecret string `json:"otp_secret"`
+	QRCodeURL string `json:"qr_code_url"`
+	Message   string `json:"message"`
+}
+
+// RecoveryCodesResponse 新生成的恢复码（明文只返回这一次）
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Message       string   `json:"message"`
+}
+
+// RecoveryCodeStatusResponse 剩余可用的恢复码
+type RecoveryCodeStatusResponse struct {
+	Remaining int `json:"remaining"`
+}
+
+// PromptTemplateInfo 提示词模板列表项
+type PromptTemplateInfo struct {
+	Name string `json:"name"`
+}
+
+// PromptTemplatesResponse 提示词模板列表
+type PromptTemplatesResponse struct {
+	Templates []PromptTemplateInfo `json:"templates"`
+}
+
+// PromptTemplateResponse 提示词模板内容
+type PromptTemplateResponse struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// EquityPoint 收益率历史数据点
+type EquityPoint struct {
+	Timestamp        string  `json:"timestamp"`
+	TotalEquity      float64 `json:"total_equity"`      // 账户净值（wallet + unrealized）
+	AvailableBalance float64 `json:"available_balance"` // 可用余额
+	TotalPnL         float64 `json:"total_pnl"`         // 总盈亏（相对初始余额）
+	TotalPnLPct      float64 `json:"total_pnl_pct"`     // 总盈亏百分比
+	PositionCount    int     `json:"position_count"`    // 持仓数量
+	MarginUsedPct    float64 `json:"margin_used_pct"`   // 保证金使用率
+	CycleNumber      int     `json:"cycle_number"`
+}
+
+// EquityHistoryBatchRequest 批量获取收益率历史
+type EquityHistoryBatchRequest struct {
+	TraderIDs []string `json:"trader_ids"`
+}
+
+// BacktestVariant 回测对比中的一组配置
+type BacktestVariant struct {
+	TemplateName string `json:"template_name"`
+	AIModelID    string `json:"ai_model_id"`
+}
+
+// BacktestCompareRequest 回测对比
+type BacktestCompareRequest struct {
+	TraderID       string                      `json:"trader_id" binding:"required"`
+	Cycles         int                         `json:"cycles"`
+	InitialBalance float64                     `json:"initial_balance"`
+	Variants       []BacktestVariant           `json:"variants" binding:"required"`
+	Costs          json.RawMessage             `json:"costs"`        // 可选，未填写的字段使用backtest.DefaultCosts()
+	WalkForward    *backtest.WalkForwardConfig `json:"walk_forward"` // 可选，设置后按滚动窗口做样本内/样本外评估
+}
+
+// BacktestCompareResponse 回测对比结果
+type BacktestCompareResponse struct {
+	RunID          string                 `json:"run_id"`
+	TraderID       string                 `json:"trader_id"`
+	Costs          trader.SimulationCosts `json:"costs"`
+	Cycles         int                    `json:"cycles"`
+	From           time.Time              `json:"from"`
+	To             time.Time              `json:"to"`
+	InitialBalance float64                `json:"initial_balance"`
+	Results        []*backtest.Result     `json:"results"`
+}
+
+// WalkForwardResponse 滚动窗口回测结果
+type WalkForwardResponse struct {
+	RunID          string                      `json:"run_id"`
+	TraderID       string                      `json:"trader_id"`
+	Cycles         int                         `json:"cycles"`
+	InitialBalance float64                     `json:"initial_balance"`
+	Costs          trader.SimulationCosts      `json:"costs"`
+	WalkForward    *backtest.WalkForwardResult `json:"walk_forward"`
+}
+
+// LimitsOverviewResponse 系统级上限及所有用户的单独上限
+type LimitsOverviewResponse struct {
+	System *config.UserLimits   `json:"system"`
+	Users  []*config.UserLimits `json:"users"`
+}
+
+// AdminUserSummary 管理员查看的用户信息和资源占用
+type AdminUserSummary struct {
+	ID          string    `json:"id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	Disabled    bool      `json:"disabled"`
+	OTPVerified bool      `json:"otp_verified"`
+	CreatedAt   time.Time `json:"created_at"`
+	config.UserUsage
+	DecisionLogBytes int64 `json:"decision_log_bytes"` // 决策日志占用的磁盘空间
+}
+
+// AdminUserDetail 单个用户的详情
+type AdminUserDetail struct {
+	AdminUserSummary
+	TraderList []TraderSummary    `json:"trader_list"`
+	Limits     *config.UserLimits `json:"limits"` // 实际生效的上限
+}
+
+// GenerateUserPromptRequest 生成某个币种的User Prompt
+type GenerateUserPromptRequest struct {
+	Symbol   string `json:"symbol" binding:"required"`
+	TraderID string `json:"trader_id" binding:"required"` // 必须提供交易员ID
+}
+
+// TestAIDecisionRequest 用指定prompt测试AI决策
+type TestAIDecisionRequest struct {
+	Symbol       string `json:"symbol" binding:"required"`
+	SystemPrompt string `json:"system_prompt"`
+	UserPrompt   string `json:"user_prompt"`
+	TemplateName string `json:"template_name"` // 可选：使用指定的模板
+	TraderID     string `json:"trader_id"`     // 必须提供交易员ID
+}
+
+// AITestResponse AI测试接口的响应
+type AITestResponse struct {
+	Success bool        `json:"success"`
+	Error   string      `json:"error,omitempty"`
+	Data    interface{} `json:"data"` // UserPromptData 或 AIDecisionData
+}
+
+// UserPromptData 生成的User Prompt
+type UserPromptData struct {
+	Symbol     string      `json:"symbol"`
+	UserPrompt string      `json:"userPrompt"`
+	MarketData interface{} `json:"marketData"`
+	Timestamp  time.Time   `json:"timestamp"`
+}
+
+// AIDecisionData AI测试决策结果（AI响应解析失败时只有prompt和原始响应）
+type AIDecisionData struct {
+	Symbol       string      `json:"symbol"`
+	Decision     interface{} `json:"decision,omitempty"`
+	Confidence   interface{} `json:"confidence,omitempty"`
+	Reasoning    interface{} `json:"reasoning,omitempty"`
+	Parameters   interface{} `json:"parameters,omitempty"`
+	SystemPrompt string      `json:"systemPrompt"`
+	UserPrompt   string      `json:"userPrompt"`
+	AIResponse   string      `json:"aiResponse"`
+	CoTTrace     string      `json:"cotTrace"`
+	Timestamp    time.Time   `json:"timestamp"`
+	ResponseTime int64       `json:"responseTime"`
+}
+
+// TelegramLinkStatus 当前用户的Telegram绑定状态
+type TelegramLinkStatus struct {
+	Enabled     bool                 `json:"enabled"` // 服务端是否配置了机器人
+	BotUsername string               `json:"bot_username,omitempty"`
+	Link        *config.TelegramLink `json:"link,omitempty"` // 未绑定时为空
+}
+
+// TelegramLinkTokenResponse 绑定口令，在Telegram中发送 /link <token> 或打开 url
+type TelegramLinkTokenResponse struct {
+	Token       string    `json:"token"`
+	BotUsername string    `json:"bot_username"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// DailyDigestSettings 每日邮件摘要订阅
+type DailyDigestSettings struct {
+	Enabled bool `json:"enabled"`
+	Hour    int  `json:"hour"` // 发送时间（UTC小时），由管理员配置
+}
+
+// UpdateDailyDigestRequest 开启或关闭每日邮件摘要
+type UpdateDailyDigestRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// DailyDigestPreview 按最近一个完整UTC日生成的摘要
+type DailyDigestPreview struct {
+	Subject string         `json:"subject"`
+	Body    string         `json:"body"`
+	Report  *digest.Report `json:"report"`
+}
+
+// SeasonRequest 创建或修改赛季（时间为RFC3339）
+type SeasonRequest struct {
+	Name    string    `json:"name" binding:"required"`
+	StartAt time.Time `json:"start_at" binding:"required"`
+	EndAt   time.Time `json:"end_at" binding:"required"`
+}
+
+// SeasonLeaderboardResponse 赛季排名（只返回前50名）
+type SeasonLeaderboardResponse struct {
+	Season     *config.Season        `json:"season"`
+	Traders    []config.SeasonResult `json:"traders"`
+	Count      int                   `json:"count"`
+	TotalCount int                   `json:"total_count"`
+}
+
+// FollowLimits 跟单倍数和风控上限（0 表示不限制）
+type FollowLimits struct {
+	Multiplier     float64 `json:"multiplier" binding:"required"` // 跟单名义价值 = 领单名义价值 × multiplier
+	MaxLeverage    int     `json:"max_leverage"`                  // 0 表示跟随领单杠杆
+	MaxPositionUSD float64 `json:"max_position_usd"`
+	MaxPositions   int     `json:"max_positions"`
+	MaxDrawdownPct float64 `json:"max_drawdown_pct"` // 跟单以来净值高点回撤超过此百分比后停止开仓
+	Enabled        *bool   `json:"enabled"`          // 默认开启
+}
+
+// CreateFollowRequest 用自己的交易所账户跟随其他交易员
+type CreateFollowRequest struct {
+	LeaderID   string `json:"leader_id" binding:"required"`
+	ExchangeID string `json:"exchange_id" binding:"required"`
+	FollowLimits
+}
+
+// FollowInfo 跟单订阅及运行状态（未运行时 status 为空）
+type FollowInfo struct {
+	*config.Follow
+	LeaderName string                 `json:"leader_name"`
+	Status     *trader.FollowerStatus `json:"status"`
+}
+
+// PositionHistoryResponse 平仓记录及汇总（汇总包含全部匹配的记录，不受 limit 影响）
+type PositionHistoryResponse struct {
+	Positions []*config.ClosedPosition       `json:"positions"`
+	Summary   *config.PositionHistorySummary `json:"summary"`
+}
+
+// DecisionSearchResponse 决策记录检索结果（新的在前）
+type DecisionSearchResponse struct {
+	Terms   []string           `json:"terms"` // 解析后的关键词
+	Results []logger.SearchHit `json:"results"`
+}
+
+// AnnotationRequest 决策/交易的复盘标注
+type AnnotationRequest struct {
+	ActionIndex *int   `json:"action_index"` // 决策动作下标（已平仓交易用开仓动作），为空表示整个周期
+	Note        string `json:"note"`
+	Rating      int    `json:"rating"`        // 1-5，0表示不评分
+	ShareWithAI bool   `json:"share_with_ai"` // 是否放入AI的历史表现上下文
+}
+
+// AdminTraderSummary 管理员查看的交易员（所有用户）
+type AdminTraderSummary struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email"`
+	AIModelID  string    `json:"ai_model_id"`
+	ExchangeID string    `json:"exchange_id"`
+	IsRunning  bool      `json:"is_running"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BetaCodesRequest 生成内测码
+type BetaCodesRequest struct {
+	Count int `json:"count"` // 1-1000，默认1
+}
+
+// BetaCodesResponse 新生成的内测码和统计
+type BetaCodesResponse struct {
+	Codes []string `json:"codes,omitempty"`
+	Total int      `json:"total"`
+	Used  int      `json:"used"`
+}
+
+// RotateKeyResponse 重新加密的记录数
+type RotateKeyResponse struct {
+	Rotated int `json:"rotated"`
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"nofx/config"
+	"nofx/trader"
+	"nofx/webhook"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxWebhooksPerUser 每个用户最多注册的webhook数量
+const maxWebhooksPerUser = 10
+
+// handleListWebhooks 当前用户注册的webhook（不返回签名密钥）
+func (s *Server) handleListWebhooks(c *gin.Context) {
+	hooks, err := s.database.GetWebhooks(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取webhook列表失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// handleCreateWebhook 注册webhook，签名密钥只在创建时返回一次
+func (s *Server) handleCreateWebhook(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := webhook.ValidateURL(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	for _, event := range req.Events {
+		if !trader.IsTradeEvent(event) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("不支持的事件类型: %s", event)})
+			return
+		}
+	}
+	if req.TraderID != "" {
+		if _, _, _, err := s.database.GetTraderConfig(userID, req.TraderID); err != nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "交易员不存在或无访问权限"})
+			return
+		}
+	}
+
+	hooks, err := s.database.GetWebhooks(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("获取webhook列表失败: %v", err)})
+		return
+	}
+	if len(hooks) >= maxWebhooksPerUser {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: fmt.Sprintf("每个用户最多注册%d个webhook", maxWebhooksPerUser)})
+		return
+	}
+
+	secret, err := webhook.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "生成签名密钥失败"})
+		return
+	}
+	events := slices.Compact(slices.Sorted(slices.Values(req.Events)))
+	if events == nil {
+		events = []string{}
+	}
+	hook := &config.Webhook{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		URL:       req.URL,
+		Secret:    secret,
+		TraderID:  req.TraderID,
+		Events:    events,
+		CreatedAt: time.Now(),
+	}
+	if err := s.database.CreateWebhook(hook); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("注册webhook失败: %v", err)})
+		return
+	}
+
+	requestLog(c).Info("用户注册了webhook", "webhook_id", hook.ID, "url", hook.URL)
+	c.JSON(http.StatusOK, CreateWebhookResponse{Webhook: hook, Secret: secret})
+}
+
+// handleDeleteWebhook 删除webhook
+func (s *Server) handleDeleteWebhook(c *gin.Context) {
+	if err := s.database.DeleteWebhook(c.GetString("user_id"), c.Param("id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "webhook不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("删除webhook失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "webhook已删除"})
+}
+
+// handleTestWebhook 立即发送一条测试事件（不重试），用于检查地址和签名校验
+func (s *Server) handleTestWebhook(c *gin.Context) {
+	hook, err := s.database.GetWebhook(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "webhook不存在"})
+		return
+	}
+
+	payload := webhook.Payload{
+		ID:       uuid.New().String(),
+		Event:    webhook.EventTest,
+		TraderID: hook.TraderID,
+		Time:     time.Now(),
+		Data:     map[string]string{"message": "NOFX webhook 测试"},
+	}
+	status, err := webhook.NewDispatcher(s.database).Send(hook, payload)
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if recErr := s.database.RecordWebhookDelivery(hook.ID, status, errMsg); recErr != nil {
+		requestLog(c).Warn("记录webhook投递结果失败", "webhook_id", hook.ID, "error", recErr)
+	}
+	c.JSON(http.StatusOK, WebhookTestResponse{Status: status, Error: errMsg})
+}
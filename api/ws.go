@@ -0,0 +1,348 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"nofx/auth"
+	"nofx/manager"
+	"nofx/trader"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsEquityInterval = 10 * time.Second // 净值推送间隔（只对有订阅的交易员请求交易所）
+	wsPingInterval   = 30 * time.Second
+	wsPongWait       = 60 * time.Second
+	wsWriteWait      = 10 * time.Second
+	wsSendBuffer     = 64
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// CheckOrigin 在握手时按服务器的CORS白名单设置
+}
+
+// wsRequest 客户端消息：订阅/取消订阅某个交易员的频道（channels为空表示全部）
+type wsRequest struct {
+	Action   string   `json:"action"` // subscribe / unsubscribe
+	TraderID string   `json:"trader_id"`
+	Channels []string `json:"channels"`
+}
+
+// wsClient 一个WebSocket连接
+type wsClient struct {
+	userID string
+	conn   *websocket.Conn
+	send   chan []byte
+
+	mu   sync.RWMutex
+	subs map[string]map[string]bool // traderID -> 订阅的频道
+}
+
+// wants 是否订阅了该交易员的该频道
+func (c *wsClient) wants(traderID, channel string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	channels, ok := c.subs[traderID]
+	return ok && channels[channel]
+}
+
+// enqueue 发送消息，客户端处理过慢时丢弃
+func (c *wsClient) enqueue(msg []byte) {
+	select {
+	case c.send <- msg:
+	default:
+	}
+}
+
+// wsHub 管理所有连接，把交易员事件分发给订阅的客户端
+type wsHub struct {
+	traderManager *manager.TraderManager
+
+	mu      sync.RWMutex
+	clients map[*wsClient]bool
+}
+
+// newWSHub 创建推送中心
+func newWSHub(traderManager *manager.TraderManager) *wsHub {
+	return &wsHub{
+		traderManager: traderManager,
+		clients:       make(map[*wsClient]bool),
+	}
+}
+
+// run 转发交易员事件，并定时推送被订阅交易员的净值
+func (h *wsHub) run() {
+	events, unsubscribe := trader.SubscribeEvents(256)
+	defer unsubscribe()
+
+	ticker := time.NewTicker(wsEquityInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			h.broadcast(event)
+		case <-ticker.C:
+			h.pushEquity()
+		}
+	}
+}
+
+// broadcast 发给订阅了该交易员该频道的客户端
+func (h *wsHub) broadcast(event trader.Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var msg []byte
+	for c := range h.clients {
+		if !c.wants(event.TraderID, event.Type) {
+			continue
+		}
+		if msg == nil {
+			var err error
+			if msg, err = json.Marshal(event); err != nil {
+				slog.Warn("序列化推送事件失败", "trader_id", event.TraderID, "type", event.Type, "error", err)
+				return
+			}
+		}
+		c.enqueue(msg)
+	}
+}
+
+// pushEquity 获取被订阅交易员的账户信息并推送
+func (h *wsHub) pushEquity() {
+	h.mu.RLock()
+	traderIDs := make(map[string]bool)
+	for c := range h.clients {
+		c.mu.RLock()
+		for id, channels := range c.subs {
+			if channels[trader.EventEquity] {
+				traderIDs[id] = true
+			}
+		}
+		c.mu.RUnlock()
+	}
+	h.mu.RUnlock()
+
+	for id := range traderIDs {
+		t, err := h.traderManager.GetTrader(id)
+		if err != nil {
+			continue
+		}
+		account, err := t.GetAccountInfo()
+		if err != nil {
+			t.Logger().Warn("推送净值：获取交易员账户信息失败", "error", err)
+			continue
+		}
+		h.broadcast(trader.Event{Type: trader.EventEquity, TraderID: id, Time: time.Now(), Data: account})
+	}
+}
+
+// register 登记连接
+func (h *wsHub) register(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = true
+}
+
+// unregister 移除连接
+func (h *wsHub) unregister(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[c] {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// closeAll 服务器退出时通知并断开所有连接
+func (h *wsHub) closeAll() {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "服务器正在重启")
+	for c := range h.clients {
+		c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		c.conn.Close()
+	}
+}
+
+// handleWebSocket 实时推送：净值、决策、持仓变化、交易员状态
+// 浏览器无法给WebSocket/EventSource设置请求头，token也可以通过 ?token= 传入
+func (s *Server) handleWebSocket(c *gin.Context) {
+	userID, err := s.streamUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	upgrader := wsUpgrader
+	upgrader.CheckOrigin = s.cors.checkWebSocketOrigin
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		requestLog(c).Warn("WebSocket升级失败", "error", err)
+		return
+	}
+
+	client := &wsClient{
+		userID: userID,
+		conn:   conn,
+		send:   make(chan []byte, wsSendBuffer),
+		subs:   make(map[string]map[string]bool),
+	}
+	s.wsHub.register(client)
+	go client.writePump()
+	s.readPump(client)
+}
+
+// streamUserID 校验推送连接（WebSocket/SSE）的token，返回用户ID
+func (s *Server) streamUserID(c *gin.Context) (string, error) {
+	if auth.IsAdminMode() {
+		return "admin", nil
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			token = parts[1]
+		}
+	}
+	if token == "" {
+		return "", fmt.Errorf("缺少token")
+	}
+	claims, err := auth.ValidateJWT(token)
+	if err != nil {
+		return "", fmt.Errorf("无效的token: %w", err)
+	}
+	if user, err := s.database.GetUserByID(claims.UserID); err != nil || user.Disabled {
+		return "", fmt.Errorf("账户不存在或已被禁用")
+	}
+	return claims.UserID, nil
+}
+
+// readPump 处理客户端的订阅请求，连接断开时注销
+func (s *Server) readPump(client *wsClient) {
+	defer func() {
+		s.wsHub.unregister(client)
+		client.conn.Close()
+	}()
+
+	client.conn.SetReadLimit(4096)
+	client.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var req wsRequest
+		if err := client.conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				slog.Warn("WebSocket连接异常断开", "user_id", client.userID, "error", err)
+			}
+			return
+		}
+		s.handleWSRequest(client, req)
+	}
+}
+
+// handleWSRequest 订阅前校验当前用户可以查看该交易员
+func (s *Server) handleWSRequest(client *wsClient, req wsRequest) {
+	reply := func(v interface{}) {
+		if msg, err := json.Marshal(v); err == nil {
+			client.enqueue(msg)
+		}
+	}
+
+	switch req.Action {
+	case "subscribe":
+		if !s.canViewTrader(client.userID, req.TraderID) {
+			reply(gin.H{"type": "error", "error": "交易员不存在或无访问权限", "trader_id": req.TraderID})
+			return
+		}
+		channels, err := wsChannels(req.Channels)
+		if err != nil {
+			reply(gin.H{"type": "error", "error": err.Error(), "trader_id": req.TraderID})
+			return
+		}
+
+		client.mu.Lock()
+		client.subs[req.TraderID] = channels
+		client.mu.Unlock()
+		reply(gin.H{"type": "subscribed", "trader_id": req.TraderID, "channels": req.Channels})
+
+		// 立即推送一次当前状态，前端不必再单独请求
+		if t, err := s.traderManager.GetTrader(req.TraderID); err == nil && channels[trader.EventStatus] {
+			reply(trader.Event{Type: trader.EventStatus, TraderID: req.TraderID, Time: time.Now(), Data: t.GetStatus()})
+		}
+	case "unsubscribe":
+		client.mu.Lock()
+		delete(client.subs, req.TraderID)
+		client.mu.Unlock()
+		reply(gin.H{"type": "unsubscribed", "trader_id": req.TraderID})
+	default:
+		reply(gin.H{"type": "error", "error": fmt.Sprintf("未知的action: %s", req.Action)})
+	}
+}
+
+// wsChannels 解析订阅的频道，为空时订阅全部
+func wsChannels(requested []string) (map[string]bool, error) {
+	all := []string{trader.EventEquity, trader.EventDecision, trader.EventPositions, trader.EventStatus}
+	if len(requested) == 0 {
+		requested = all
+	}
+	channels := make(map[string]bool, len(requested))
+	for _, ch := range requested {
+		valid := false
+		for _, known := range all {
+			if ch == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("未知的频道: %s", ch)
+		}
+		channels[ch] = true
+	}
+	return channels, nil
+}
+
+// writePump 串行写出消息并定时ping（WebSocket连接不支持并发写）
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
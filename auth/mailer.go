@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mailer 邮件发送接口，便于替换为其他服务或在测试中打桩
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPConfig SMTP发信配置
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+// SMTPMailer 通过SMTP服务器发送纯文本邮件
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+// NewSMTPMailer 创建SMTP发信器，端口默认587
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	if config.From == "" {
+		config.From = config.Username
+	}
+	return &SMTPMailer{config: config}
+}
+
+// Send 发送邮件（服务器支持时自动STARTTLS）
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+	msg := buildMessage(m.config.From, to, subject, body, time.Now())
+	if err := smtp.SendMail(addr, auth, m.config.From, []string{to}, msg); err != nil {
+		return fmt.Errorf("SMTP发送失败: %w", err)
+	}
+	return nil
+}
+
+// buildMessage 组装RFC 5322邮件，标题按UTF-8编码以支持中文
+func buildMessage(from, to, subject, body string, date time.Time) []byte {
+	var sb strings.Builder
+	sb.WriteString("From: " + from + "\r\n")
+	sb.WriteString("To: " + to + "\r\n")
+	sb.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	sb.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(sb.String())
+}
+
+// LogMailer 未配置SMTP时的后备实现，只把邮件内容写到日志（便于本地开发）
+type LogMailer struct{}
+
+// Send 将邮件输出到日志（链接中的token会被隐去，日志泄露不能用来重置密码）
+func (LogMailer) Send(to, subject, body string) error {
+	slog.Info("未配置SMTP，邮件输出到日志", "to", to, "subject", subject, "body", redactTokens(body))
+	return nil
+}
+
+// tokenParamPattern 链接中的 token 查询参数
+var tokenParamPattern = regexp.MustCompile(`([?&]token=)[^&\s]+`)
+
+// redactTokens 隐去文本中链接的 token 参数值
+func redactTokens(text string) string {
+	return tokenParamPattern.ReplaceAllString(text, "${1}[REDACTED]")
+}
+
+// mailer 当前使用的发信器
+var mailer Mailer = LogMailer{}
+
+// SetMailer 设置发信器
+func SetMailer(m Mailer) {
+	mailer = m
+}
+
+// GetMailer 获取发信器
+func GetMailer() Mailer {
+	return mailer
+}
@@ -0,0 +1,11 @@
+package auth
+
+import "testing"
+
+func TestRedactTokens(t *testing.T) {
+	body := "请打开 https://nofx.example.com/reset-password?token=abc.def-ghi 设置新密码\nhttps://x.com/a?lang=zh&token=xyz&b=1"
+	want := "请打开 https://nofx.example.com/reset-password?token=[REDACTED] 设置新密码\nhttps://x.com/a?lang=zh&token=[REDACTED]&b=1"
+	if got := redactTokens(body); got != want {
+		t.Errorf("token未被隐去:\n%s", got)
+	}
+}
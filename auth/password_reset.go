@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PasswordResetTTL 密码重置链接有效期
+const PasswordResetTTL = 30 * time.Minute
+
+// ErrPasswordResetTokenUsed 密码已修改，旧的重置链接失效
+var ErrPasswordResetTokenUsed = errors.New("重置链接已失效")
+
+// PasswordResetClaims 密码重置token声明
+type PasswordResetClaims struct {
+	UserID      string `json:"user_id"`
+	Fingerprint string `json:"fp"` // 当前密码哈希的指纹，密码修改后token自动失效
+	jwt.RegisteredClaims
+}
+
+// passwordResetKey 由JWT密钥派生的独立签名密钥，避免重置token被当作登录token使用
+func passwordResetKey() []byte {
+	mac := hmac.New(sha256.New, JWTSecret)
+	mac.Write([]byte("nofx-password-reset"))
+	return mac.Sum(nil)
+}
+
+// PasswordFingerprint 密码哈希的指纹
+func PasswordFingerprint(passwordHash string) string {
+	mac := hmac.New(sha256.New, passwordResetKey())
+	mac.Write([]byte(passwordHash))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// GeneratePasswordResetToken 生成带有效期的密码重置token
+func GeneratePasswordResetToken(userID, passwordHash string) (string, error) {
+	now := time.Now()
+	claims := PasswordResetClaims{
+		UserID:      userID,
+		Fingerprint: PasswordFingerprint(passwordHash),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(PasswordResetTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "nofxAI",
+			Subject:   "password_reset",
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(passwordResetKey())
+}
+
+// ParsePasswordResetToken 校验签名和有效期，返回token中的声明
+func ParsePasswordResetToken(tokenString string) (*PasswordResetClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &PasswordResetClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("意外的签名方法: %v", token.Header["alg"])
+		}
+		return passwordResetKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*PasswordResetClaims)
+	if !ok || !token.Valid || claims.Subject != "password_reset" {
+		return nil, fmt.Errorf("无效的重置token")
+	}
+	return claims, nil
+}
+
+// CheckFingerprint 确认token签发后密码没有被修改过（保证链接只能使用一次）
+func (c *PasswordResetClaims) CheckFingerprint(passwordHash string) error {
+	if !hmac.Equal([]byte(c.Fingerprint), []byte(PasswordFingerprint(passwordHash))) {
+		return ErrPasswordResetTokenUsed
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestPasswordResetToken(t *testing.T) {
+	SetJWTSecret("test-secret")
+
+	token, err := GeneratePasswordResetToken("user-1", "hash-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParsePasswordResetToken(token)
+	if err != nil {
+		t.Fatalf("解析重置token失败: %v", err)
+	}
+	if claims.UserID != "user-1" {
+		t.Errorf("UserID = %s", claims.UserID)
+	}
+	if err := claims.CheckFingerprint("hash-1"); err != nil {
+		t.Errorf("密码未修改时指纹应一致: %v", err)
+	}
+	if err := claims.CheckFingerprint("hash-2"); err != ErrPasswordResetTokenUsed {
+		t.Errorf("密码修改后token应失效, got %v", err)
+	}
+
+	// 重置token不能当作登录token，反之亦然
+	if _, err := ValidateJWT(token); err == nil {
+		t.Error("重置token不应通过登录校验")
+	}
+	loginToken, err := GenerateJWT("user-1", "a@b.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePasswordResetToken(loginToken); err == nil {
+		t.Error("登录token不应通过重置校验")
+	}
+
+	// 密钥变化后旧token失效
+	SetJWTSecret("other-secret")
+	if _, err := ParsePasswordResetToken(token); err == nil {
+		t.Error("更换密钥后token应失效")
+	}
+}
+
+func TestPasswordResetTokenExpired(t *testing.T) {
+	SetJWTSecret("test-secret")
+
+	claims := PasswordResetClaims{
+		UserID:      "user-1",
+		Fingerprint: PasswordFingerprint("hash-1"),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+			Subject:   "password_reset",
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(passwordResetKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePasswordResetToken(token); err == nil {
+		t.Error("过期的token应被拒绝")
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	msg := string(buildMessage("noreply@nofx.ai", "a@b.com", "重置密码", "第一行\n第二行", time.Unix(0, 0).UTC()))
+
+	for _, want := range []string{
+		"From: noreply@nofx.ai\r\n",
+		"To: a@b.com\r\n",
+		"Subject: =?UTF-8?b?",
+		"Content-Type: text/plain; charset=UTF-8\r\n",
+		"\r\n\r\n第一行\r\n第二行",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("邮件缺少 %q:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+)
+
+// RecoveryCodeCount 每次生成的恢复码数量
+const RecoveryCodeCount = 10
+
+// recoveryAlphabet 去掉了容易看错的 0/o、1/l/i
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// GenerateRecoveryCodes 生成n个一次性恢复码，格式 xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	max := big.NewInt(int64(len(recoveryAlphabet)))
+	for i := 0; i < n; i++ {
+		var b strings.Builder
+		for j := 0; j < 10; j++ {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			idx, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteByte(recoveryAlphabet[idx.Int64()])
+		}
+		codes = append(codes, b.String())
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode 恢复码只保存哈希；忽略大小写、空格和连字符，方便用户输入
+func HashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
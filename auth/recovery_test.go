@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Fatalf("生成了 %d 个恢复码", len(codes))
+	}
+
+	format := regexp.MustCompile(`^[a-z2-9]{5}-[a-z2-9]{5}$`)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("恢复码格式错误: %s", code)
+		}
+		if seen[code] {
+			t.Errorf("恢复码重复: %s", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := HashRecoveryCode("abcde-fghjk")
+	for _, input := range []string{"ABCDE-FGHJK", " abcdefghjk ", "abcde fghjk"} {
+		if got := HashRecoveryCode(input); got != want {
+			t.Errorf("%q 的哈希应与原恢复码一致", input)
+		}
+	}
+	if HashRecoveryCode("abcde-fghjm") == want {
+		t.Error("不同的恢复码哈希不应相同")
+	}
+}
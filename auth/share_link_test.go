@@ -0,0 +1,17 @@
+package auth
+
+import "testing"
+
+func TestShareToken(t *testing.T) {
+	a, err := GenerateShareToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateShareToken()
+	if len(a) != 43 || a == b {
+		t.Errorf("token应为43位URL安全字符且不重复: %s %s", a, b)
+	}
+	if HashShareToken(a) == HashShareToken(b) || HashShareToken(a) != HashShareToken(a) {
+		t.Error("哈希应稳定且区分不同token")
+	}
+}
@@ -0,0 +1,70 @@
+package backtest
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// FilterSymbols 只保留指定币种：从prompt的market_data、价格和滑点中去掉其他币种，没有剩余币种的周期整体丢弃
+// symbols 为空时原样返回
+func FilterSymbols(history []Cycle, symbols []string) []Cycle {
+	if len(symbols) == 0 {
+		return history
+	}
+	allowed := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		allowed[strings.ToUpper(strings.TrimSpace(symbol))] = true
+	}
+
+	filtered := make([]Cycle, 0, len(history))
+	for _, cycle := range history {
+		prices := make(map[string]float64)
+		slippage := make(map[string]map[float64]float64)
+		for symbol, price := range cycle.Prices {
+			if allowed[symbol] {
+				prices[symbol] = price
+				if curve, ok := cycle.Slippage[symbol]; ok {
+					slippage[symbol] = curve
+				}
+			}
+		}
+		if len(prices) == 0 {
+			continue
+		}
+
+		userPrompt, ok := filterPromptMarketData(cycle.UserPrompt, allowed)
+		if !ok {
+			continue
+		}
+		filtered = append(filtered, Cycle{
+			Time:       cycle.Time,
+			UserPrompt: userPrompt,
+			Prices:     prices,
+			Slippage:   slippage,
+		})
+	}
+	return filtered
+}
+
+// filterPromptMarketData 去掉prompt中不在allowed里的币种市场数据，避免AI对未回放的币种下单
+func filterPromptMarketData(userPrompt string, allowed map[string]bool) (string, bool) {
+	var promptData map[string]interface{}
+	if err := json.Unmarshal([]byte(userPrompt), &promptData); err != nil {
+		return "", false
+	}
+	marketData, ok := promptData["market_data"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	for symbol := range marketData {
+		if !allowed[symbol] {
+			delete(marketData, symbol)
+		}
+	}
+
+	data, err := json.MarshalIndent(promptData, "", "  ")
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
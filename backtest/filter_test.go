@@ -0,0 +1,53 @@
+package backtest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFilterSymbols(t *testing.T) {
+	history := []Cycle{
+		{
+			Time:       time.Unix(100, 0),
+			UserPrompt: `{"market_data":{"BTCUSDT":{"current_price":60000},"ETHUSDT":{"current_price":3000}}}`,
+			Prices:     map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000},
+			Slippage:   map[string]map[float64]float64{"BTCUSDT": {10000: 0.5}, "ETHUSDT": {10000: 1}},
+		},
+		{
+			Time:       time.Unix(200, 0),
+			UserPrompt: `{"market_data":{"SOLUSDT":{"current_price":150}}}`,
+			Prices:     map[string]float64{"SOLUSDT": 150},
+		},
+	}
+
+	if got := FilterSymbols(history, nil); len(got) != 2 {
+		t.Fatalf("未指定币种时不应过滤，得到 %d", len(got))
+	}
+
+	filtered := FilterSymbols(history, []string{" ethusdt "})
+	if len(filtered) != 1 {
+		t.Fatalf("没有指定币种的周期应被丢弃，得到 %d", len(filtered))
+	}
+	cycle := filtered[0]
+	if len(cycle.Prices) != 1 || cycle.Prices["ETHUSDT"] != 3000 {
+		t.Errorf("价格过滤错误: %v", cycle.Prices)
+	}
+	if _, ok := cycle.Slippage["BTCUSDT"]; ok {
+		t.Errorf("滑点过滤错误: %v", cycle.Slippage)
+	}
+
+	var promptData map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(cycle.UserPrompt), &promptData); err != nil {
+		t.Fatalf("过滤后的prompt不是合法JSON: %v", err)
+	}
+	if _, ok := promptData["market_data"]["BTCUSDT"]; ok {
+		t.Error("prompt中不应再包含BTCUSDT")
+	}
+	if _, ok := promptData["market_data"]["ETHUSDT"]; !ok {
+		t.Error("prompt中应保留ETHUSDT")
+	}
+	if history[0].Prices["BTCUSDT"] != 60000 {
+		t.Error("不应修改原始历史")
+	}
+}
@@ -0,0 +1,326 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/mcp"
+	"nofx/trader"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cycle 一个已记录的决策周期（回放的最小单位）
+type Cycle struct {
+	Time       time.Time
+	UserPrompt string
+	Prices     map[string]float64             // 该周期prompt中各币种的当前价格
+	Slippage   map[string]map[float64]float64 // 该周期记录的参考规模滑点（symbol -> 仓位规模USD -> 基点）
+}
+
+// Variant 参与对比的一组配置（提示词模板 + AI模型）
+type Variant struct {
+	Name         string
+	TemplateName string
+	ModelName    string
+	MCPClient    mcp.AIClient
+}
+
+// Config 回测参数
+type Config struct {
+	InitialBalance  float64
+	BTCETHLeverage  int
+	AltcoinLeverage int
+	Costs           trader.SimulationCosts // 手续费与滑点模型
+}
+
+// Result 单个配置的回测结果
+type Result struct {
+	Variant      string                  `json:"variant"`
+	TemplateName string                  `json:"template_name"`
+	ModelName    string                  `json:"model_name"`
+	Cycles       int                     `json:"cycles"`
+	FailedCycles int                     `json:"failed_cycles"` // AI调用或解析失败的周期
+	FinalEquity  float64                 `json:"final_equity"`
+	ReturnPct    float64                 `json:"return_pct"`
+	EquityCurve  []logger.EquityPoint    `json:"equity_curve"`
+	Metrics      *logger.TradeMetrics    `json:"metrics"`
+	Trades       []trader.SimulatedTrade `json:"trades"`
+}
+
+// DefaultCosts 回测默认费用：按记录的盘口深度估算滑点，避免回测结果系统性高于实盘
+func DefaultCosts() trader.SimulationCosts {
+	costs := trader.DefaultSimulationCosts()
+	costs.SlippageModel = trader.SlippageModelDepth
+	return costs
+}
+
+// LoadHistory 从决策日志中提取可回放的周期（按时间从旧到新，跳过没有市场数据的记录）
+func LoadHistory(records []*logger.DecisionRecord) []Cycle {
+	cycles := make([]Cycle, 0, len(records))
+	for _, record := range records {
+		prices, slippage, err := extractMarketData(record.InputPrompt)
+		if err != nil || len(prices) == 0 {
+			continue
+		}
+		cycles = append(cycles, Cycle{
+			Time:       record.Timestamp,
+			UserPrompt: record.InputPrompt,
+			Prices:     prices,
+			Slippage:   slippage,
+		})
+	}
+	return cycles
+}
+
+// extractMarketData 从User Prompt的market_data中读取各币种当前价格和参考规模滑点
+func extractMarketData(userPrompt string) (map[string]float64, map[string]map[float64]float64, error) {
+	var prompt struct {
+		MarketData map[string]struct {
+			CurrentPrice float64 `json:"current_price"`
+			Liquidity    struct {
+				SlippageBps map[string]float64 `json:"slippage_bps"` // key形如 "10000_usd"
+			} `json:"liquidity"`
+		} `json:"market_data"`
+	}
+	if err := json.Unmarshal([]byte(userPrompt), &prompt); err != nil {
+		return nil, nil, err
+	}
+
+	prices := make(map[string]float64, len(prompt.MarketData))
+	slippage := make(map[string]map[float64]float64)
+	for symbol, data := range prompt.MarketData {
+		if data.CurrentPrice > 0 {
+			prices[symbol] = data.CurrentPrice
+		}
+		for key, bps := range data.Liquidity.SlippageBps {
+			size, err := strconv.ParseFloat(strings.TrimSuffix(key, "_usd"), 64)
+			if err != nil || size <= 0 {
+				continue
+			}
+			if slippage[symbol] == nil {
+				slippage[symbol] = make(map[float64]float64)
+			}
+			slippage[symbol][size] = bps
+		}
+	}
+	return prices, slippage, nil
+}
+
+// Compare 用同一段历史并发回放多组配置
+func Compare(history []Cycle, variants []Variant, cfg Config) []*Result {
+	results := make([]*Result, len(variants))
+	var wg sync.WaitGroup
+	for i, v := range variants {
+		wg.Add(1)
+		go func(i int, v Variant) {
+			defer wg.Done()
+			results[i] = Run(history, v, cfg)
+		}(i, v)
+	}
+	wg.Wait()
+	return results
+}
+
+// Run 逐周期回放：用模拟账户状态替换prompt中的账户信息，请求AI决策并在模拟交易所中执行
+func Run(history []Cycle, v Variant, cfg Config) *Result {
+	exchange := trader.NewSimulatedExchangeWithCosts(cfg.InitialBalance, cfg.Costs)
+	result := &Result{
+		Variant:      v.Name,
+		TemplateName: v.TemplateName,
+		ModelName:    v.ModelName,
+		Cycles:       len(history),
+		EquityCurve:  make([]logger.EquityPoint, 0, len(history)),
+	}
+
+	for _, cycle := range history {
+		exchange.SetReplayFeed(cycle.Prices, cycle.Time)
+		exchange.SetReplaySlippage(cycle.Slippage)
+
+		equity, account := simulatedAccount(exchange)
+		result.EquityCurve = append(result.EquityCurve, logger.EquityPoint{Time: cycle.Time, Equity: equity})
+
+		userPrompt := replaceAccount(cycle.UserPrompt, account)
+		fullDecision, err := decision.ReplayDecision(v.MCPClient, userPrompt, equity, cfg.BTCETHLeverage, cfg.AltcoinLeverage, v.TemplateName)
+		if err != nil {
+			slog.Warn("回测周期决策失败", "variant", v.Name, "cycle_time", cycle.Time, "error", err)
+			result.FailedCycles++
+			continue
+		}
+
+		for _, d := range sortDecisions(fullDecision.Decisions) {
+			if err := executeDecision(exchange, d, cycle.Prices); err != nil {
+				slog.Warn("回测执行决策失败", "variant", v.Name, "symbol", d.Symbol, "action", d.Action, "error", err)
+			}
+		}
+	}
+
+	result.FinalEquity = cfg.InitialBalance
+	if n := len(result.EquityCurve); n > 0 {
+		result.FinalEquity = result.EquityCurve[n-1].Equity
+	}
+	if cfg.InitialBalance > 0 {
+		result.ReturnPct = (result.FinalEquity - cfg.InitialBalance) / cfg.InitialBalance * 100
+	}
+	result.Trades = exchange.GetClosedTrades()
+	result.Metrics = logger.CalculateMetrics(toTradeOutcomes(result.Trades), result.EquityCurve)
+	return result
+}
+
+// simulatedAccount 返回模拟账户净值和与BuildUserPrompt相同结构的account字段
+func simulatedAccount(exchange *trader.SimulatedExchange) (float64, map[string]interface{}) {
+	balance, _ := exchange.GetBalance()
+	positions, _ := exchange.GetPositions()
+
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+	available, _ := balance["availableBalance"].(float64)
+	equity := wallet + unrealized
+
+	usedMargin := 0.0
+	promptPositions := make([]map[string]interface{}, 0, len(positions))
+	for _, pos := range positions {
+		quantity := pos["positionAmt"].(float64)
+		if quantity < 0 {
+			quantity = -quantity
+		}
+		entryPrice := pos["entryPrice"].(float64)
+		markPrice := pos["markPrice"].(float64)
+		leverage := pos["leverage"].(float64)
+		if leverage > 0 {
+			usedMargin += quantity * entryPrice / leverage
+		}
+
+		pnlPercent := 0.0
+		if entryPrice > 0 {
+			pnlPercent = (markPrice - entryPrice) / entryPrice * 100
+		}
+		promptPositions = append(promptPositions, map[string]interface{}{
+			"symbol":        pos["symbol"],
+			"side":          pos["side"],
+			"size":          quantity,
+			"open_price":    entryPrice,
+			"current_price": markPrice,
+			"pnl_percent":   pnlPercent,
+		})
+	}
+
+	marginUsageRate := 0.0
+	if equity > 0 {
+		marginUsageRate = usedMargin / equity
+	}
+
+	return equity, map[string]interface{}{
+		"account_equity":    equity,
+		"used_margin":       usedMargin,
+		"available_balance": available,
+		"margin_usage_rate": marginUsageRate,
+		"positions":         promptPositions,
+	}
+}
+
+// replaceAccount 把记录的prompt中的实盘账户信息换成模拟账户（解析失败时原样返回）
+func replaceAccount(userPrompt string, account map[string]interface{}) string {
+	var promptData map[string]interface{}
+	if err := json.Unmarshal([]byte(userPrompt), &promptData); err != nil {
+		return userPrompt
+	}
+	promptData["account"] = account
+
+	data, err := json.MarshalIndent(promptData, "", "  ")
+	if err != nil {
+		return userPrompt
+	}
+	return string(data)
+}
+
+// sortDecisions 先平仓再开仓，与实盘执行顺序一致
+func sortDecisions(decisions []decision.Decision) []decision.Decision {
+	sorted := make([]decision.Decision, 0, len(decisions))
+	for _, d := range decisions {
+		if d.Action == "close_long" || d.Action == "close_short" {
+			sorted = append(sorted, d)
+		}
+	}
+	for _, d := range decisions {
+		if d.Action == "open_long" || d.Action == "open_short" {
+			sorted = append(sorted, d)
+		}
+	}
+	return sorted
+}
+
+// executeDecision 在模拟交易所中执行单个决策
+func executeDecision(exchange *trader.SimulatedExchange, d decision.Decision, prices map[string]float64) error {
+	switch d.Action {
+	case "close_long":
+		_, err := exchange.CloseLong(d.Symbol, 0)
+		return err
+	case "close_short":
+		_, err := exchange.CloseShort(d.Symbol, 0)
+		return err
+	}
+
+	price, ok := prices[d.Symbol]
+	if !ok || price <= 0 {
+		return fmt.Errorf("回放数据中没有%s的价格", d.Symbol)
+	}
+	quantity := d.PositionSizeUSD / price
+
+	positionSide := "LONG"
+	var err error
+	if d.Action == "open_long" {
+		_, err = exchange.OpenLong(d.Symbol, quantity, d.Leverage)
+	} else {
+		positionSide = "SHORT"
+		_, err = exchange.OpenShort(d.Symbol, quantity, d.Leverage)
+	}
+	if err != nil {
+		return err
+	}
+
+	if d.StopLoss > 0 {
+		exchange.SetStopLoss(d.Symbol, positionSide, quantity, d.StopLoss)
+	}
+	if d.TakeProfit > 0 {
+		exchange.SetTakeProfit(d.Symbol, positionSide, quantity, d.TakeProfit)
+	}
+	return nil
+}
+
+// toTradeOutcomes 转换为指标引擎使用的交易结果
+func toTradeOutcomes(trades []trader.SimulatedTrade) []logger.TradeOutcome {
+	outcomes := make([]logger.TradeOutcome, 0, len(trades))
+	for _, t := range trades {
+		positionValue := t.Quantity * t.EntryPrice
+		outcome := logger.TradeOutcome{
+			Symbol:        t.Symbol,
+			Side:          t.Side,
+			Quantity:      t.Quantity,
+			Leverage:      t.Leverage,
+			OpenPrice:     t.EntryPrice,
+			ClosePrice:    t.ExitPrice,
+			PositionValue: positionValue,
+			PnL:           t.PnL,
+			Duration:      t.CloseTime.Sub(t.OpenTime).String(),
+			OpenTime:      t.OpenTime,
+			CloseTime:     t.CloseTime,
+		}
+		if t.Leverage > 0 {
+			outcome.MarginUsed = positionValue / float64(t.Leverage)
+			if outcome.MarginUsed > 0 {
+				outcome.PnLPct = t.PnL / outcome.MarginUsed * 100
+			}
+		}
+		if t.StopLoss > 0 {
+			outcome.RiskUSD = t.Quantity * math.Abs(t.EntryPrice-t.StopLoss)
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	return outcomes
+}
@@ -0,0 +1,77 @@
+package backtest
+
+import (
+	"encoding/json"
+	"nofx/logger"
+	"nofx/mcp"
+	"nofx/trader"
+	"testing"
+	"time"
+)
+
+func TestLoadHistory(t *testing.T) {
+	records := []*logger.DecisionRecord{
+		{Timestamp: time.Unix(100, 0), InputPrompt: `{"account":{"account_equity":500},"market_data":{"BTCUSDT":{"current_price":60000,"liquidity":{"slippage_bps":{"10000_usd":0.5,"50000_usd":2}}},"ETHUSDT":{"current_price":3000}}}`},
+		{Timestamp: time.Unix(200, 0), InputPrompt: "旧版文本格式的prompt"},
+	}
+
+	history := LoadHistory(records)
+	if len(history) != 1 {
+		t.Fatalf("应只保留可解析的周期，得到 %d", len(history))
+	}
+	if history[0].Prices["BTCUSDT"] != 60000 || history[0].Prices["ETHUSDT"] != 3000 {
+		t.Errorf("价格解析错误: %v", history[0].Prices)
+	}
+	if history[0].Slippage["BTCUSDT"][50000] != 2 || len(history[0].Slippage["ETHUSDT"]) != 0 {
+		t.Errorf("滑点曲线解析错误: %v", history[0].Slippage)
+	}
+
+	replaced := replaceAccount(history[0].UserPrompt, map[string]interface{}{"account_equity": 1000.0})
+	var promptData map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(replaced), &promptData); err != nil {
+		t.Fatalf("替换后的prompt不是合法JSON: %v", err)
+	}
+	if promptData["account"]["account_equity"] != 1000.0 {
+		t.Errorf("账户信息未替换: %v", promptData["account"])
+	}
+	if _, ok := promptData["market_data"]["BTCUSDT"]; !ok {
+		t.Error("市场数据不应被修改")
+	}
+}
+
+func TestRunWithRecordedResponses(t *testing.T) {
+	history := []Cycle{
+		{Time: time.Unix(0, 0), UserPrompt: `{"market_data":{"BTCUSDT":{"current_price":60000}}}`, Prices: map[string]float64{"BTCUSDT": 60000}},
+		{Time: time.Unix(180, 0), UserPrompt: `{"market_data":{"BTCUSDT":{"current_price":63000}}}`, Prices: map[string]float64{"BTCUSDT": 63000}},
+		{Time: time.Unix(360, 0), UserPrompt: `{"market_data":{"BTCUSDT":{"current_price":63000}}}`, Prices: map[string]float64{"BTCUSDT": 63000}},
+	}
+	client := mcp.NewRecordedClientFromText(
+		`趋势向上，开多 [{"symbol":"BTCUSDT","action":"open_long","leverage":5,"position_size_usd":1000,"stop_loss":58000,"take_profit":70000,"reasoning":"突破"}]`,
+		`获利了结 [{"symbol":"BTCUSDT","action":"close_long","reasoning":"止盈"}]`,
+	)
+
+	costs := trader.DefaultSimulationCosts()
+	costs.SlippageBps = 0
+	cfg := Config{InitialBalance: 1000, BTCETHLeverage: 5, AltcoinLeverage: 5, Costs: costs}
+
+	result := Run(history, Variant{Name: "recorded", TemplateName: "default", MCPClient: client}, cfg)
+	if result.FailedCycles != 1 {
+		t.Errorf("只有第3周期没有录制响应，失败周期应为1，得到 %d", result.FailedCycles)
+	}
+	if len(result.Trades) != 1 {
+		t.Fatalf("应有1笔已平仓交易，得到 %d", len(result.Trades))
+	}
+	if trade := result.Trades[0]; trade.EntryPrice != 60000 || trade.ExitPrice != 63000 || trade.PnL <= 0 {
+		t.Errorf("交易结果错误: %+v", trade)
+	}
+	if result.ReturnPct <= 0 {
+		t.Errorf("价格上涨5%%平多应盈利，收益率 %.2f%%", result.ReturnPct)
+	}
+
+	// 同一组录制响应重放应得到完全相同的结果
+	client.Reset()
+	again := Run(history, Variant{Name: "recorded", TemplateName: "default", MCPClient: client}, cfg)
+	if again.FinalEquity != result.FinalEquity {
+		t.Errorf("回放结果不确定: %.4f != %.4f", again.FinalEquity, result.FinalEquity)
+	}
+}
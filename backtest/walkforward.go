@@ -0,0 +1,127 @@
+package backtest
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// WalkForwardConfig 滚动窗口设置（单位：决策周期）
+type WalkForwardConfig struct {
+	TrainCycles int `json:"train_cycles"` // 样本内窗口：在这段历史上比较各配置并选出最优
+	TestCycles  int `json:"test_cycles"`  // 样本外窗口：用选中的配置继续回放
+	StepCycles  int `json:"step_cycles"`  // 每次向前滚动的周期数，默认等于TestCycles
+}
+
+// WalkForwardWindow 单个滚动窗口的结果
+type WalkForwardWindow struct {
+	Index     int       `json:"index"`
+	TrainFrom time.Time `json:"train_from"`
+	TrainTo   time.Time `json:"train_to"`
+	TestFrom  time.Time `json:"test_from"`
+	TestTo    time.Time `json:"test_to"`
+	Selected  string    `json:"selected"`         // 样本内收益最高的配置
+	TrainBest *Result   `json:"train_best"`       // 选中配置的样本内结果
+	Test      *Result   `json:"test"`             // 选中配置的样本外结果
+	Others    []*Result `json:"others,omitempty"` // 未选中配置的样本内结果
+}
+
+// WalkForwardResult 滚动回测汇总
+type WalkForwardResult struct {
+	Windows           []*WalkForwardWindow `json:"windows"`
+	AvgTrainReturnPct float64              `json:"avg_train_return_pct"`
+	AvgTestReturnPct  float64              `json:"avg_test_return_pct"`
+	Degradation       float64              `json:"degradation"` // 样本内平均收益 - 样本外平均收益，越大越可能过拟合
+	Selections        map[string]int       `json:"selections"`  // 各配置被选中的次数
+}
+
+// window 历史下标区间 [trainStart, testStart) 为样本内，[testStart, testEnd) 为样本外
+type window struct {
+	trainStart, testStart, testEnd int
+}
+
+// Normalize 补全默认值并检查窗口设置
+func (wf *WalkForwardConfig) Normalize() error {
+	if wf.TrainCycles <= 0 || wf.TestCycles <= 0 {
+		return fmt.Errorf("train_cycles 和 test_cycles 必须大于0")
+	}
+	if wf.StepCycles <= 0 {
+		wf.StepCycles = wf.TestCycles
+	}
+	return nil
+}
+
+// splitWindows 按设置切分滚动窗口（最后不足一个样本外窗口的部分丢弃）
+func splitWindows(total int, wf WalkForwardConfig) []window {
+	var windows []window
+	for start := 0; start+wf.TrainCycles+wf.TestCycles <= total; start += wf.StepCycles {
+		windows = append(windows, window{
+			trainStart: start,
+			testStart:  start + wf.TrainCycles,
+			testEnd:    start + wf.TrainCycles + wf.TestCycles,
+		})
+	}
+	return windows
+}
+
+// CountAICalls 估算滚动回测需要的AI调用次数（用于限制请求规模）
+func CountAICalls(total, variants int, wf WalkForwardConfig) int {
+	return len(splitWindows(total, wf)) * (wf.TrainCycles*variants + wf.TestCycles)
+}
+
+// WalkForward 在历史上滚动：每个窗口先在样本内比较所有配置，再用收益最高的配置回放紧随其后的样本外区间
+func WalkForward(history []Cycle, variants []Variant, cfg Config, wf WalkForwardConfig) (*WalkForwardResult, error) {
+	if err := wf.Normalize(); err != nil {
+		return nil, err
+	}
+	windows := splitWindows(len(history), wf)
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("历史周期数 %d 不足一个窗口（需要 %d）", len(history), wf.TrainCycles+wf.TestCycles)
+	}
+
+	result := &WalkForwardResult{
+		Windows:    make([]*WalkForwardWindow, 0, len(windows)),
+		Selections: make(map[string]int),
+	}
+	for i, w := range windows {
+		train := history[w.trainStart:w.testStart]
+		test := history[w.testStart:w.testEnd]
+
+		trainResults := Compare(train, variants, cfg)
+		best := 0
+		for j, r := range trainResults {
+			if r.ReturnPct > trainResults[best].ReturnPct {
+				best = j
+			}
+		}
+		selected := variants[best]
+		slog.Info("滚动回测窗口样本内最优，开始样本外回放", "window", i+1, "windows", len(windows), "variant", selected.Name, "return_pct", trainResults[best].ReturnPct)
+
+		fw := &WalkForwardWindow{
+			Index:     i,
+			TrainFrom: train[0].Time,
+			TrainTo:   train[len(train)-1].Time,
+			TestFrom:  test[0].Time,
+			TestTo:    test[len(test)-1].Time,
+			Selected:  selected.Name,
+			TrainBest: trainResults[best],
+			Test:      Run(test, selected, cfg),
+		}
+		for j, r := range trainResults {
+			if j != best {
+				fw.Others = append(fw.Others, r)
+			}
+		}
+
+		result.Windows = append(result.Windows, fw)
+		result.Selections[selected.Name]++
+		result.AvgTrainReturnPct += fw.TrainBest.ReturnPct
+		result.AvgTestReturnPct += fw.Test.ReturnPct
+	}
+
+	n := float64(len(result.Windows))
+	result.AvgTrainReturnPct /= n
+	result.AvgTestReturnPct /= n
+	result.Degradation = result.AvgTrainReturnPct - result.AvgTestReturnPct
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package backtest
+
+import "testing"
+
+func TestSplitWindows(t *testing.T) {
+	wf := WalkForwardConfig{TrainCycles: 4, TestCycles: 2}
+	if err := wf.Normalize(); err != nil {
+		t.Fatalf("窗口设置应合法: %v", err)
+	}
+
+	windows := splitWindows(11, wf)
+	want := []window{{0, 4, 6}, {2, 6, 8}, {4, 8, 10}}
+	if len(windows) != len(want) {
+		t.Fatalf("得到 %d 个窗口，期望 %d: %v", len(windows), len(want), windows)
+	}
+	for i := range want {
+		if windows[i] != want[i] {
+			t.Errorf("窗口 %d = %v，期望 %v", i, windows[i], want[i])
+		}
+	}
+
+	if calls := CountAICalls(11, 3, wf); calls != 3*(4*3+2) {
+		t.Errorf("AI调用次数 = %d，期望 %d", calls, 3*(4*3+2))
+	}
+	if got := splitWindows(5, wf); len(got) != 0 {
+		t.Errorf("历史不足一个窗口时不应切分，得到 %v", got)
+	}
+}
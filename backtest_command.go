@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"nofx/backtest"
+	"nofx/config"
+	"nofx/logger"
+	"nofx/mcp"
+	"strings"
+	"time"
+)
+
+// runBacktestCommand 命令行回测：nofx backtest --trader=xxx --template=a,b --symbols=BTCUSDT --from=2025-01-01 --to=2025-01-31
+// 不启动API和交易员，直接回放交易员的决策日志，适合在CI或服务器上无界面运行
+func runBacktestCommand(args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath(), "配置数据库路径（默认 NOFX_DB_PATH 或 config.db）")
+	traderID := fs.String("trader", "", "回放该交易员的决策日志（必填）")
+	templates := fs.String("template", "", "提示词模板，多个用逗号分隔（默认使用交易员当前模板）")
+	modelID := fs.String("model", "", "AI模型ID（默认使用交易员当前模型）")
+	symbols := fs.String("symbols", "", "只回放这些币种，多个用逗号分隔（默认全部）")
+	from := fs.String("from", "", "开始时间，YYYY-MM-DD 或 RFC3339")
+	to := fs.String("to", "", "结束时间，YYYY-MM-DD（含当天）或 RFC3339")
+	balance := fs.Float64("balance", 0, "初始资金（默认使用交易员的初始资金）")
+	costsJSON := fs.String("costs", "", "费用与滑点设置（JSON），默认按记录的盘口深度估算滑点")
+	output := fs.String("output", "", "把完整结果写入JSON文件")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *traderID == "" {
+		fs.Usage()
+		return fmt.Errorf("必须指定 --trader")
+	}
+
+	fromTime, err := parseBacktestTime(*from, false)
+	if err != nil {
+		return fmt.Errorf("--from 格式错误: %w", err)
+	}
+	toTime, err := parseBacktestTime(*to, true)
+	if err != nil {
+		return fmt.Errorf("--to 格式错误: %w", err)
+	}
+
+	costs := backtest.DefaultCosts()
+	if *costsJSON != "" {
+		if err := json.Unmarshal([]byte(*costsJSON), &costs); err != nil {
+			return fmt.Errorf("费用设置解析失败: %w", err)
+		}
+	}
+	if err := costs.Validate(); err != nil {
+		return fmt.Errorf("费用设置错误: %w", err)
+	}
+
+	database, err := openDatabase(*dbPath)
+	if err != nil {
+		return fmt.Errorf("初始化数据库失败: %w", err)
+	}
+	defer database.Close()
+
+	traderCfg, model, err := findTraderConfig(database, *traderID, *modelID)
+	if err != nil {
+		return err
+	}
+	if err := configureDecisionLogStorage(database); err != nil {
+		return fmt.Errorf("决策日志存储配置无效: %w", err)
+	}
+
+	records, err := logger.NewDecisionLogger(fmt.Sprintf("decision_logs/%s", traderCfg.ID)).GetRecordsBetween(fromTime, toTime)
+	if err != nil {
+		return fmt.Errorf("读取决策日志失败: %w", err)
+	}
+	history := backtest.FilterSymbols(backtest.LoadHistory(records), splitList(*symbols))
+	if len(history) == 0 {
+		return fmt.Errorf("指定区间内没有可回放的历史记录")
+	}
+
+	templateNames := splitList(*templates)
+	if len(templateNames) == 0 {
+		templateNames = []string{traderCfg.SystemPromptTemplate}
+	}
+	variants := make([]backtest.Variant, 0, len(templateNames))
+	for _, name := range templateNames {
+		variants = append(variants, backtest.Variant{
+			Name:         fmt.Sprintf("%s / %s", name, model.Name),
+			TemplateName: name,
+			ModelName:    model.Name,
+			MCPClient:    newBacktestMCPClient(model),
+		})
+	}
+
+	initialBalance := *balance
+	if initialBalance <= 0 {
+		initialBalance = traderCfg.InitialBalance
+	}
+	cfg := backtest.Config{
+		InitialBalance:  initialBalance,
+		BTCETHLeverage:  traderCfg.BTCETHLeverage,
+		AltcoinLeverage: traderCfg.AltcoinLeverage,
+		Costs:           costs,
+	}
+
+	slog.Info("开始命令行回测", "trader_id", traderCfg.ID, "user_id", traderCfg.UserID, "cycles", len(history),
+		"from", history[0].Time, "to", history[len(history)-1].Time, "variants", len(variants))
+	results := backtest.Compare(history, variants, cfg)
+
+	fmt.Println()
+	fmt.Printf("%-40s %10s %10s %8s %8s\n", "配置", "收益率", "最大回撤", "交易数", "失败周期")
+	allFailed := true
+	for _, r := range results {
+		fmt.Printf("%-40s %9.2f%% %9.2f%% %8d %8d\n", r.Variant, r.ReturnPct, r.Metrics.MaxDrawdownPct, r.Metrics.TotalTrades, r.FailedCycles)
+		if r.FailedCycles < r.Cycles {
+			allFailed = false
+		}
+	}
+
+	if *output != "" {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"trader_id":       traderCfg.ID,
+			"cycles":          len(history),
+			"from":            history[0].Time,
+			"to":              history[len(history)-1].Time,
+			"initial_balance": initialBalance,
+			"costs":           costs,
+			"results":         results,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化回测结果失败: %w", err)
+		}
+		if err := ioutil.WriteFile(*output, data, 0644); err != nil {
+			return fmt.Errorf("写入回测结果失败: %w", err)
+		}
+		slog.Info("回测结果已保存", "path", *output)
+	}
+
+	if allFailed {
+		return fmt.Errorf("所有配置的AI决策均失败，请检查模型配置")
+	}
+	return nil
+}
+
+// findTraderConfig 在所有用户中查找交易员，并确定回测使用的AI模型
+func findTraderConfig(database *config.Database, traderID, modelID string) (*config.TraderRecord, *config.AIModelConfig, error) {
+	userIDs, err := database.GetAllUsers()
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取用户列表失败: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		traderCfg, traderModel, _, err := database.GetTraderConfig(userID, traderID)
+		if err != nil {
+			continue
+		}
+		if modelID == "" {
+			return traderCfg, traderModel, nil
+		}
+
+		models, err := database.GetAIModels(userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取AI模型配置失败: %w", err)
+		}
+		for _, m := range models {
+			if m.ID == modelID {
+				return traderCfg, m, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("AI模型不存在: %s", modelID)
+	}
+	return nil, nil, fmt.Errorf("交易员不存在: %s", traderID)
+}
+
+// newBacktestMCPClient 按AI模型配置创建客户端
+func newBacktestMCPClient(model *config.AIModelConfig) *mcp.Client {
+	client := mcp.New()
+	switch model.Provider {
+	case "deepseek":
+		client.SetDeepSeekAPIKey(model.APIKey, model.CustomAPIURL, model.CustomModelName)
+	case "qwen":
+		client.SetQwenAPIKey(model.APIKey, model.CustomAPIURL, model.CustomModelName)
+	default:
+		client.SetCustomAPI(model.CustomAPIURL, model.APIKey, model.CustomModelName)
+	}
+	return client
+}
+
+// parseBacktestTime 解析命令行时间；只给日期时，结束时间取当天最后一刻
+func parseBacktestTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// splitList 拆分逗号分隔的参数并去掉空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
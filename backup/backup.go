@@ -0,0 +1,351 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"nofx/config"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 备份包内的文件布局
+const (
+	manifestName = "manifest.json"
+	databaseName = "config.db"
+	logsPrefix   = "decision_logs/"
+)
+
+// 备份文件名格式：nofx-backup-20060102-150405.tar.gz
+const (
+	filePrefix = "nofx-backup-"
+	fileSuffix = ".tar.gz"
+	timeLayout = "20060102-150405"
+)
+
+// Manifest 备份包的说明，恢复时用于检查兼容性
+type Manifest struct {
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int       `json:"schema_version"` // 备份时数据库的表结构版本
+	LogFiles      int       `json:"log_files"`      // 决策日志文件数
+}
+
+// Info 一个本地备份文件
+type Info struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Write 把数据库快照和 logDir 下的决策日志打包写入 w
+// 数据库使用一致性快照，决策日志按文件逐个复制，备份期间写入的日志可能只包含一部分
+func Write(database *config.Database, logDir string, w io.Writer) (*Manifest, error) {
+	snapshot, err := os.CreateTemp("", "nofx-snapshot-*.db")
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Close()
+	os.Remove(snapshot.Name()) // VACUUM INTO 要求目标文件不存在
+	defer os.Remove(snapshot.Name())
+
+	if err := database.Snapshot(snapshot.Name()); err != nil {
+		return nil, fmt.Errorf("数据库快照失败: %w", err)
+	}
+	version, err := database.SchemaVersion()
+	if err != nil {
+		return nil, fmt.Errorf("读取表结构版本失败: %w", err)
+	}
+
+	var logs []string
+	err = filepath.WalkDir(logDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == logDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			logs = append(logs, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取决策日志目录失败: %w", err)
+	}
+
+	manifest := &Manifest{CreatedAt: time.Now().UTC(), SchemaVersion: version, LogFiles: len(logs)}
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(data)), ModTime: manifest.CreatedAt}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := addFile(tw, snapshot.Name(), databaseName); err != nil {
+		return nil, err
+	}
+	for _, p := range logs {
+		rel, err := filepath.Rel(logDir, p)
+		if err != nil {
+			return nil, err
+		}
+		if err := addFile(tw, p, logsPrefix+filepath.ToSlash(rel)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// addFile 把文件以 name 写入备份包
+func addFile(tw *tar.Writer, src, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, f, info.Size())
+	return err
+}
+
+// Create 在 dir 下创建一个备份文件，返回文件信息
+func Create(database *config.Database, logDir, dir string) (*Info, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建备份目录失败: %w", err)
+	}
+	now := time.Now().UTC()
+	name := filePrefix + now.Format(timeLayout) + fileSuffix
+	target := filepath.Join(dir, name)
+
+	// 先写临时文件，避免中途失败留下不完整的备份
+	tmp, err := os.CreateTemp(dir, ".tmp-"+name)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := Write(database, logDir, tmp); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	return &Info{Name: name, Size: info.Size(), CreatedAt: now}, nil
+}
+
+// parseName 从备份文件名解析创建时间，不是备份文件时返回 false
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+	return t, err == nil
+}
+
+// List 列出 dir 下的备份文件，按时间从新到旧排列；目录不存在时返回空列表
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Info{}, nil
+		}
+		return nil, err
+	}
+	backups := make([]Info, 0, len(entries))
+	for _, e := range entries {
+		createdAt, ok := parseName(e.Name())
+		if !ok || !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Info{Name: e.Name(), Size: info.Size(), CreatedAt: createdAt})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// Path 返回 dir 下备份文件的路径，name 不是合法的备份文件名时返回错误
+func Path(dir, name string) (string, error) {
+	if filepath.Base(name) != name {
+		return "", fmt.Errorf("无效的备份文件名")
+	}
+	if _, ok := parseName(name); !ok {
+		return "", fmt.Errorf("无效的备份文件名")
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Prune 只保留最新的 keep 个备份（keep <= 0 不清理），返回删除的文件数
+func Prune(dir string, keep int) (int, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+	backups, err := List(dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, b := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(filepath.Join(dir, b.Name)); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RestoreResult 恢复结果，原有数据被移到 *Moved 路径（原来不存在时为空）
+type RestoreResult struct {
+	Manifest Manifest
+	DBMoved  string
+	LogMoved string
+}
+
+// Restore 用备份包替换 dbPath 处的数据库和 logDir 下的决策日志，必须在服务停止时执行
+// 原有数据库和日志目录重命名保留，不会删除
+func Restore(archive, dbPath, logDir string) (*RestoreResult, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("不是有效的备份文件: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	// 先解压到临时位置，全部成功后再替换
+	stagedDB := dbPath + ".restoring"
+	stagedLogs := strings.TrimRight(logDir, `/\`) + ".restoring"
+	os.Remove(stagedDB)
+	os.RemoveAll(stagedLogs)
+	defer os.Remove(stagedDB)
+	defer os.RemoveAll(stagedLogs)
+
+	var manifest *Manifest
+	hasDB := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取备份文件失败: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		switch name := path.Clean(hdr.Name); {
+		case name == manifestName:
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("解析 %s 失败: %w", manifestName, err)
+			}
+			if latest := config.LatestSchemaVersion(); manifest.SchemaVersion > latest {
+				return nil, fmt.Errorf("备份的表结构版本 %d 高于程序支持的版本 %d，请使用更新版本的程序恢复", manifest.SchemaVersion, latest)
+			}
+		case name == databaseName:
+			if err := extract(tr, stagedDB); err != nil {
+				return nil, err
+			}
+			hasDB = true
+		case strings.HasPrefix(name, logsPrefix):
+			rel := strings.TrimPrefix(name, logsPrefix)
+			if rel == "" || strings.HasPrefix(rel, "../") || path.IsAbs(rel) {
+				return nil, fmt.Errorf("备份文件包含非法路径: %s", hdr.Name)
+			}
+			if err := extract(tr, filepath.Join(stagedLogs, filepath.FromSlash(rel))); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if manifest == nil || !hasDB {
+		return nil, fmt.Errorf("备份文件缺少 %s 或 %s", manifestName, databaseName)
+	}
+
+	result := &RestoreResult{Manifest: *manifest}
+	suffix := ".before-restore-" + time.Now().UTC().Format(timeLayout)
+	if result.DBMoved, err = moveAside(dbPath, suffix); err != nil {
+		return nil, fmt.Errorf("备份原数据库失败: %w", err)
+	}
+	// SQLite 的临时日志文件属于原数据库，一起移走
+	for _, ext := range []string{"-wal", "-shm", "-journal"} {
+		moveAside(dbPath+ext, suffix)
+	}
+	if err := os.Rename(stagedDB, dbPath); err != nil {
+		return nil, fmt.Errorf("替换数据库失败: %w", err)
+	}
+
+	if result.LogMoved, err = moveAside(logDir, suffix); err != nil {
+		return nil, fmt.Errorf("备份原决策日志失败: %w", err)
+	}
+	if _, err := os.Stat(stagedLogs); os.IsNotExist(err) {
+		err = os.MkdirAll(logDir, 0755)
+	} else {
+		err = os.Rename(stagedLogs, logDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("替换决策日志失败: %w", err)
+	}
+	return result, nil
+}
+
+// extract 把当前文件内容写到 dst
+func extract(r io.Reader, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return fmt.Errorf("解压 %s 失败: %w", dst, err)
+	}
+	return out.Close()
+}
+
+// moveAside 把已存在的文件或目录重命名为 p+suffix，返回新路径（不存在时返回空）
+func moveAside(p, suffix string) (string, error) {
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return "", nil
+	}
+	moved := strings.TrimRight(p, `/\`) + suffix
+	return moved, os.Rename(p, moved)
+}
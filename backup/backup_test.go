@@ -0,0 +1,136 @@
+package backup
+
+import (
+	"nofx/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestDatabase(t *testing.T, path string) *config.Database {
+	t.Helper()
+	database, err := config.NewDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	database := newTestDatabase(t, filepath.Join(dir, "config.db"))
+	if err := database.CreateUser(&config.User{ID: "alice", Email: "alice@test.com", OTPVerified: true}); err != nil {
+		t.Fatal(err)
+	}
+	logDir := filepath.Join(dir, "decision_logs")
+	if err := os.MkdirAll(filepath.Join(logDir, "t1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(logDir, "t1", "decision_1.json"), []byte(`{"cycle_number":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := Create(database, logDir, filepath.Join(dir, "backups"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backups, _ := List(filepath.Join(dir, "backups")); len(backups) != 1 || backups[0].Name != info.Name {
+		t.Fatalf("备份列表错误: %+v", backups)
+	}
+
+	// 恢复到新位置，原位置已有的数据应被保留
+	target := filepath.Join(dir, "restored")
+	if err := os.MkdirAll(filepath.Join(target, "decision_logs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "config.db"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Restore(filepath.Join(dir, "backups", info.Name), filepath.Join(target, "config.db"), filepath.Join(target, "decision_logs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Manifest.LogFiles != 1 || result.Manifest.SchemaVersion != config.LatestSchemaVersion() {
+		t.Errorf("manifest错误: %+v", result.Manifest)
+	}
+	if data, err := os.ReadFile(result.DBMoved); err != nil || string(data) != "old" {
+		t.Errorf("原数据库应被保留: %q %v", data, err)
+	}
+	if result.LogMoved == "" {
+		t.Error("原日志目录应被保留")
+	}
+	if data, err := os.ReadFile(filepath.Join(target, "decision_logs", "t1", "decision_1.json")); err != nil || string(data) != `{"cycle_number":1}` {
+		t.Errorf("决策日志未恢复: %q %v", data, err)
+	}
+
+	restored := newTestDatabase(t, filepath.Join(target, "config.db"))
+	if user, err := restored.GetUserByID("alice"); err != nil || user.Email != "alice@test.com" {
+		t.Errorf("数据库未恢复: %+v %v", user, err)
+	}
+}
+
+func TestRestoreRejectsInvalidArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bad.tar.gz")
+	os.WriteFile(archive, []byte("not a backup"), 0644)
+	os.WriteFile(filepath.Join(dir, "config.db"), []byte("keep"), 0644)
+
+	if _, err := Restore(archive, filepath.Join(dir, "config.db"), filepath.Join(dir, "decision_logs")); err == nil {
+		t.Fatal("无效的备份文件应返回错误")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "config.db")); string(data) != "keep" {
+		t.Error("恢复失败时不应修改原数据库")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		name := filePrefix + base.Add(time.Duration(i)*time.Hour).Format(timeLayout) + fileSuffix
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "other.txt"), nil, 0644)
+
+	removed, err := Prune(dir, 2)
+	if err != nil || removed != 2 {
+		t.Fatalf("removed=%d err=%v", removed, err)
+	}
+	backups, _ := List(dir)
+	if len(backups) != 2 || !backups[0].CreatedAt.Equal(base.Add(3*time.Hour)) {
+		t.Errorf("应保留最新的2个备份: %+v", backups)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.txt")); err != nil {
+		t.Error("不应删除非备份文件")
+	}
+}
+
+func TestPath(t *testing.T) {
+	if _, err := Path("backups", "../config.db"); err == nil {
+		t.Error("应拒绝目录外的文件")
+	}
+	if _, err := Path("backups", "nofx-backup-20250301-000000.tar.gz"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSchedulerRunDue(t *testing.T) {
+	dir := t.TempDir()
+	database := newTestDatabase(t, filepath.Join(dir, "config.db"))
+	backupDir := filepath.Join(dir, "backups")
+	if err := database.SetSystemConfig("backup_config", `{"dir":"`+filepath.ToSlash(backupDir)+`","interval_hours":24}`); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScheduler(database, filepath.Join(dir, "decision_logs"))
+	s.RunDue(time.Now())
+	if backups, _ := List(backupDir); len(backups) != 1 {
+		t.Fatalf("没有备份时应立即备份: %+v", backups)
+	}
+	s.RunDue(time.Now().Add(time.Hour))
+	if backups, _ := List(backupDir); len(backups) != 1 {
+		t.Errorf("未到间隔不应再次备份: %+v", backups)
+	}
+}
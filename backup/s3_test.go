@@ -0,0 +1,49 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestS3Upload(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "nofx-backup-20250301-000000.tar.gz")
+	os.WriteFile(file, []byte("archive"), 0644)
+
+	cfg := &S3Config{Endpoint: srv.URL, Region: "us-east-1", Bucket: "bk", Prefix: "nofx/", AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	if err := cfg.Upload(context.Background(), file); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/bk/nofx/nofx-backup-20250301-000000.tar.gz" || gotBody != "archive" {
+		t.Errorf("path=%s body=%q", gotPath, gotBody)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(gotAuth, "/us-east-1/s3/aws4_request") {
+		t.Errorf("签名头错误: %s", gotAuth)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig("")
+	if err != nil || cfg.Dir != DefaultDir {
+		t.Fatalf("空配置应使用默认目录: %+v %v", cfg, err)
+	}
+	if _, err := ParseConfig(`{"s3":{"bucket":"b"}}`); err == nil {
+		t.Error("S3配置不完整应返回错误")
+	}
+	if _, err := ParseConfig(`{"interval_hours":-1}`); err == nil {
+		t.Error("负数间隔应返回错误")
+	}
+}
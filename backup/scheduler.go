@@ -0,0 +1,127 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"nofx/config"
+	"nofx/objstore"
+	"path/filepath"
+	"time"
+)
+
+// checkInterval 检查是否需要定时备份的间隔
+const checkInterval = 10 * time.Minute
+
+// DefaultDir 未配置备份目录时使用的目录
+const DefaultDir = "backups"
+
+// S3Config 上传备份的S3位置
+type S3Config = objstore.S3Config
+
+// Config 备份配置（系统配置 backup_config，JSON）
+type Config struct {
+	Dir           string    `json:"dir"`            // 本地备份目录，默认 backups
+	IntervalHours int       `json:"interval_hours"` // 定时备份间隔（小时），0 不定时备份
+	Keep          int       `json:"keep"`           // 本地保留的备份数，0 不清理
+	S3            *S3Config `json:"s3,omitempty"`   // 备份后上传到S3，为空不上传
+}
+
+// ParseConfig 解析并校验备份配置，为空时返回默认配置
+func ParseConfig(value string) (Config, error) {
+	var cfg Config
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &cfg); err != nil {
+			return cfg, fmt.Errorf("解析backup_config失败: %w", err)
+		}
+	}
+	if cfg.IntervalHours < 0 || cfg.Keep < 0 {
+		return cfg, fmt.Errorf("backup_config 的 interval_hours 和 keep 不能为负数")
+	}
+	if cfg.S3 != nil {
+		if err := cfg.S3.Validate(); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = DefaultDir
+	}
+	return cfg, nil
+}
+
+// LoadConfig 从系统配置读取备份配置
+func LoadConfig(database *config.Database) (Config, error) {
+	value, _ := database.GetSystemConfig("backup_config")
+	return ParseConfig(value)
+}
+
+// Run 创建备份，按配置上传到S3并清理旧备份
+// 上传或清理失败时备份文件已经生成，返回备份信息和错误
+func Run(database *config.Database, logDir string, cfg Config) (*Info, error) {
+	info, err := Create(database, logDir, cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("已创建备份", "file", info.Name, "size", info.Size)
+
+	if cfg.S3 != nil {
+		if err := cfg.S3.Upload(context.Background(), filepath.Join(cfg.Dir, info.Name)); err != nil {
+			return info, err
+		}
+		slog.Info("已上传备份到S3", "bucket", cfg.S3.Bucket, "key", cfg.S3.Prefix+info.Name)
+	}
+
+	if removed, err := Prune(cfg.Dir, cfg.Keep); err != nil {
+		return info, fmt.Errorf("清理旧备份失败: %w", err)
+	} else if removed > 0 {
+		slog.Info("已清理旧备份", "removed", removed, "keep", cfg.Keep)
+	}
+	return info, nil
+}
+
+// Scheduler 按 backup_config.interval_hours 定时备份
+type Scheduler struct {
+	database *config.Database
+	logDir   string
+}
+
+// NewScheduler 创建定时备份任务
+func NewScheduler(database *config.Database, logDir string) *Scheduler {
+	return &Scheduler{database: database, logDir: logDir}
+}
+
+// Start 开始定时检查（阻塞，调用方使用 go 启动）
+// 每次检查都重新读取配置，管理接口修改后无需重启
+func (s *Scheduler) Start() {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	s.RunDue(time.Now())
+	for now := range ticker.C {
+		s.RunDue(now)
+	}
+}
+
+// RunDue 距离最近一次本地备份超过间隔时创建备份（以备份文件为准，重启不会提前备份）
+func (s *Scheduler) RunDue(now time.Time) {
+	cfg, err := LoadConfig(s.database)
+	if err != nil {
+		slog.Warn("备份配置无效，跳过定时备份", "error", err)
+		return
+	}
+	if cfg.IntervalHours <= 0 {
+		return
+	}
+	backups, err := List(cfg.Dir)
+	if err != nil {
+		slog.Warn("读取备份目录失败", "dir", cfg.Dir, "error", err)
+		return
+	}
+	if len(backups) > 0 && now.Sub(backups[0].CreatedAt) < time.Duration(cfg.IntervalHours)*time.Hour {
+		return
+	}
+	if _, err := Run(s.database, s.logDir, cfg); err != nil {
+		slog.Error("定时备份失败", "error", err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"nofx/backup"
+	"path/filepath"
+)
+
+// runBackupCommand 备份数据库和决策日志：nofx backup [--db] [--logs] [--out] [--upload]
+// 服务运行时也可以执行，数据库使用一致性快照
+func runBackupCommand(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath(), "配置数据库路径（默认 NOFX_DB_PATH 或 config.db）")
+	logDir := fs.String("logs", "decision_logs", "决策日志目录")
+	out := fs.String("out", "", "备份目录（默认使用 backup_config.dir）")
+	upload := fs.Bool("upload", false, "备份后上传到 backup_config.s3")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	database, err := openDatabase(*dbPath)
+	if err != nil {
+		return fmt.Errorf("初始化数据库失败: %w", err)
+	}
+	defer database.Close()
+
+	cfg, err := backup.LoadConfig(database)
+	if err != nil {
+		return err
+	}
+	if *out != "" {
+		cfg.Dir = *out
+	}
+
+	info, err := backup.Create(database, *logDir, cfg.Dir)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(cfg.Dir, info.Name)
+	fmt.Printf("已创建备份 %s（%d 字节）\n", file, info.Size)
+
+	if *upload {
+		if cfg.S3 == nil {
+			return fmt.Errorf("backup_config 未配置 s3")
+		}
+		if err := cfg.S3.Upload(context.Background(), file); err != nil {
+			return err
+		}
+		fmt.Printf("已上传到 s3://%s/%s%s\n", cfg.S3.Bucket, cfg.S3.Prefix, info.Name)
+	}
+	return nil
+}
+
+// runRestoreCommand 从备份恢复数据库和决策日志：nofx restore [--db] [--logs] <备份文件>
+// 必须先停止服务；原有数据库和日志目录重命名保留
+func runRestoreCommand(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath(), "配置数据库路径（默认 NOFX_DB_PATH 或 config.db）")
+	logDir := fs.String("logs", "decision_logs", "决策日志目录")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: nofx restore [--db config.db] [--logs decision_logs] <备份文件>")
+	}
+
+	result, err := backup.Restore(fs.Arg(0), *dbPath, *logDir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已恢复 %s 的备份（表结构版本 %d，%d 个决策日志文件）\n",
+		result.Manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"), result.Manifest.SchemaVersion, result.Manifest.LogFiles)
+	if result.DBMoved != "" {
+		fmt.Printf("原数据库已移到 %s\n", result.DBMoved)
+	}
+	if result.LogMoved != "" {
+		fmt.Printf("原决策日志已移到 %s\n", result.LogMoved)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package cache 带过期时间的键值缓存：默认进程内缓存，配置 redis_url 后多个API副本共用Redis
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Store 带过期时间的键值缓存
+type Store interface {
+	// Get 读取缓存，不存在或已过期时 ok 为 false
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 写入缓存，ttl 后过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// Open 按地址创建缓存：为空时使用进程内缓存，redis:// 或 rediss://（TLS）地址使用Redis
+func Open(rawURL string) (Store, error) {
+	if rawURL == "" {
+		return NewMemory(), nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的缓存地址: %w", err)
+	}
+	switch u.Scheme {
+	case "redis", "rediss":
+		return NewRedis(rawURL)
+	default:
+		return nil, fmt.Errorf("不支持的缓存地址: %s（仅支持 redis:// 和 rediss://）", u.Scheme)
+	}
+}
+
+// memorySweepSize 条目超过此数量时写入前清理已过期的条目
+const memorySweepSize = 1024
+
+type memoryItem struct {
+	value   []byte
+	expires time.Time
+}
+
+// Memory 进程内缓存
+type Memory struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+	now   func() time.Time
+}
+
+// NewMemory 创建进程内缓存
+func NewMemory() *Memory {
+	return &Memory{items: make(map[string]memoryItem), now: time.Now}
+}
+
+// Get 读取缓存
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !m.now().Before(item.expires) {
+		delete(m.items, key)
+		return nil, false, nil
+	}
+	return item.value, true, nil
+}
+
+// Set 写入缓存
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if len(m.items) >= memorySweepSize {
+		for k, item := range m.items {
+			if !now.Before(item.expires) {
+				delete(m.items, k)
+			}
+		}
+	}
+	m.items[key] = memoryItem{value: value, expires: now.Add(ttl)}
+	return nil
+}
@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisTimeout  = 3 * time.Second // 未设置截止时间时单个命令的超时
+	redisPoolSize = 8               // 空闲连接数上限
+)
+
+// Redis 最小的Redis客户端（RESP协议），只实现缓存需要的 GET/SET
+type Redis struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      bool
+	pool     chan *redisConn
+}
+
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewRedis 按 redis://[用户名:密码@]host:port[/db] 地址创建客户端（不立即连接）
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("无效的Redis地址: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("Redis地址缺少主机")
+	}
+	r := &Redis{addr: u.Host, tls: u.Scheme == "rediss", pool: make(chan *redisConn, redisPoolSize)}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.password, _ = u.User.Password()
+		if r.password == "" {
+			// redis://:password@host 和 redis://password@host 两种写法
+			r.password = u.User.Username()
+		} else {
+			r.username = u.User.Username()
+		}
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil || r.db < 0 {
+			return nil, fmt.Errorf("无效的Redis数据库编号: %s", db)
+		}
+	}
+	return r, nil
+}
+
+// Ping 检查Redis是否可用
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+// Get 读取缓存
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("Redis GET 返回了意外的类型 %T", reply)
+	}
+	return value, true, nil
+}
+
+// Set 写入缓存
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ms := ttl.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+// do 执行一条命令，出错的连接直接关闭，正常的放回连接池
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	c, err := r.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.command(ctx, args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		c.conn.Close()
+		return nil, err
+	}
+	select {
+	case r.pool <- c:
+	default:
+		c.conn.Close()
+	}
+	return reply, err
+}
+
+// get 从连接池取一个连接，没有时新建并认证
+func (r *Redis) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-r.pool:
+		return c, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if r.tls {
+		host, _, _ := net.SplitHostPort(r.addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接Redis失败: %w", err)
+	}
+
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn)}
+	if r.password != "" {
+		args := []string{"AUTH", r.password}
+		if r.username != "" {
+			args = []string{"AUTH", r.username, r.password}
+		}
+		if _, err := c.command(ctx, args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("Redis认证失败: %w", err)
+		}
+	}
+	if r.db != 0 {
+		if _, err := c.command(ctx, "SELECT", strconv.Itoa(r.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("选择Redis数据库失败: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// redisError Redis返回的错误回复（连接仍可继续使用）
+type redisError string
+
+func (e redisError) Error() string { return "Redis: " + string(e) }
+
+// command 发送命令并读取回复
+func (c *redisConn) command(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisTimeout)
+	}
+	c.conn.SetDeadline(deadline)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
+
+// readReply 读取一个RESP回复：简单字符串返回 string，批量字符串返回 []byte，空值返回 nil
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("Redis回复格式错误")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("Redis回复格式错误: %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("Redis回复格式错误: %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := readReply(r)
+			var redisErr redisError
+			if errors.As(err, &redisErr) {
+				item = redisErr // 数组中的错误作为元素返回，保证回复读完整
+			} else if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("Redis回复格式错误: %q", line)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// cli 子命令的运行环境
+type cli struct {
+	*client
+	in   io.Reader
+	out  io.Writer
+	json bool
+}
+
+// print 按 --json 输出原始JSON，否则调用 table 输出表格
+func (x *cli) print(v interface{}, table func(w io.Writer)) error {
+	if x.json {
+		enc := json.NewEncoder(x.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	w := tabwriter.NewWriter(x.out, 0, 0, 2, ' ', 0)
+	table(w)
+	return w.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func runLogin(x *cli, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ContinueOnError)
+	email := fs.String("email", "", "管理员邮箱（必填）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		fs.Usage()
+		return fmt.Errorf("必须指定 --email")
+	}
+
+	reader := bufio.NewReader(x.in)
+	prompt := func(label string) (string, error) {
+		fmt.Fprint(x.out, label)
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	password, err := prompt("密码: ")
+	if err != nil {
+		return err
+	}
+	var login struct {
+		UserID      string `json:"user_id"`
+		RequiresOTP bool   `json:"requires_otp"`
+	}
+	if err := x.do(http.MethodPost, "/api/login", map[string]string{"email": *email, "password": password}, &login); err != nil {
+		return err
+	}
+
+	code, err := prompt("验证码: ")
+	if err != nil {
+		return err
+	}
+	var auth struct {
+		Token string `json:"token"`
+		Role  string `json:"role"`
+	}
+	if err := x.do(http.MethodPost, "/api/verify-otp", map[string]string{"user_id": login.UserID, "otp_code": code}, &auth); err != nil {
+		return err
+	}
+	if auth.Role != "admin" {
+		return fmt.Errorf("%s 不是管理员，无法使用管理命令", *email)
+	}
+	fmt.Fprintf(x.out, "export NOFXCTL_TOKEN=%s\n", auth.Token)
+	return nil
+}
+
+func runUsers(x *cli, args []string) error {
+	var users []struct {
+		ID             string    `json:"id"`
+		Email          string    `json:"email"`
+		Role           string    `json:"role"`
+		Disabled       bool      `json:"disabled"`
+		Traders        int       `json:"traders"`
+		RunningTraders int       `json:"running_traders"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+	if err := x.do(http.MethodGet, "/api/admin/users", nil, &users); err != nil {
+		return err
+	}
+	return x.print(users, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\t邮箱\t角色\t状态\t交易员\t运行中\t注册时间")
+		for _, u := range users {
+			status := "正常"
+			if u.Disabled {
+				status = "已禁用"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n", u.ID, u.Email, u.Role, status, u.Traders, u.RunningTraders, formatTime(u.CreatedAt))
+		}
+	})
+}
+
+func runTraders(x *cli, args []string) error {
+	var traders []struct {
+		ID         string    `json:"id"`
+		Name       string    `json:"name"`
+		Email      string    `json:"email"`
+		AIModelID  string    `json:"ai_model_id"`
+		ExchangeID string    `json:"exchange_id"`
+		IsRunning  bool      `json:"is_running"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+	if err := x.do(http.MethodGet, "/api/admin/traders", nil, &traders); err != nil {
+		return err
+	}
+	return x.print(traders, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\t名称\t用户\t模型\t交易所\t状态\t创建时间")
+		for _, t := range traders {
+			status := "已停止"
+			if t.IsRunning {
+				status = "运行中"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", t.ID, t.Name, t.Email, t.AIModelID, t.ExchangeID, status, formatTime(t.CreatedAt))
+		}
+	})
+}
+
+func runStop(x *cli, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: nofxctl stop TRADER_ID")
+	}
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if err := x.do(http.MethodPost, "/api/admin/traders/"+url.PathEscape(args[0])+"/stop", nil, &resp); err != nil {
+		return err
+	}
+	return x.print(resp, func(w io.Writer) { fmt.Fprintln(w, resp.Message) })
+}
+
+func runRotateKey(x *cli, args []string) error {
+	var resp struct {
+		Rotated int `json:"rotated"`
+	}
+	if err := x.do(http.MethodPost, "/api/admin/rotate-key", nil, &resp); err != nil {
+		return err
+	}
+	return x.print(resp, func(w io.Writer) {
+		fmt.Fprintf(w, "已重新加密 %d 条记录，确认服务正常后可移除旧密钥\n", resp.Rotated)
+	})
+}
+
+func runBetaCodes(x *cli, args []string) error {
+	fs := flag.NewFlagSet("beta-codes", flag.ContinueOnError)
+	count := fs.Int("n", 0, "生成的内测码数量（0 只查看统计）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var resp struct {
+		Codes []string `json:"codes"`
+		Total int      `json:"total"`
+		Used  int      `json:"used"`
+	}
+	var err error
+	if *count > 0 {
+		err = x.do(http.MethodPost, "/api/admin/beta-codes", map[string]int{"count": *count}, &resp)
+	} else {
+		err = x.do(http.MethodGet, "/api/admin/beta-codes", nil, &resp)
+	}
+	if err != nil {
+		return err
+	}
+	return x.print(resp, func(w io.Writer) {
+		for _, code := range resp.Codes {
+			fmt.Fprintln(w, code)
+		}
+		fmt.Fprintf(w, "内测码共 %d 个，已使用 %d 个\n", resp.Total, resp.Used)
+	})
+}
+
+// backupInfo 备份文件信息
+type backupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func runBackup(x *cli, args []string) error {
+	var info backupInfo
+	if err := x.do(http.MethodPost, "/api/admin/backups", nil, &info); err != nil {
+		return err
+	}
+	return x.print(info, func(w io.Writer) {
+		fmt.Fprintf(w, "已创建备份 %s（%.1f MB）\n", info.Name, float64(info.Size)/(1<<20))
+	})
+}
+
+func runBackups(x *cli, args []string) error {
+	var backups []backupInfo
+	if err := x.do(http.MethodGet, "/api/admin/backups", nil, &backups); err != nil {
+		return err
+	}
+	return x.print(backups, func(w io.Writer) {
+		fmt.Fprintln(w, "文件\t大小(MB)\t创建时间")
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%.1f\t%s\n", b.Name, float64(b.Size)/(1<<20), formatTime(b.CreatedAt))
+		}
+	})
+}
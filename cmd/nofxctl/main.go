@@ -0,0 +1,91 @@
+// nofxctl 通过管理接口运维 NOFX 服务器的命令行工具
+//
+//	nofxctl [--server URL] [--token TOKEN] [--json] <命令> [参数]
+//
+// 服务器地址和token也可以通过 NOFXCTL_SERVER、NOFXCTL_TOKEN 环境变量设置，
+// token 用 nofxctl login 获取（需要管理员账户）
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// command 一个子命令
+type command struct {
+	usage string
+	run   func(x *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"login":      {"login --email=EMAIL  登录并输出token（密码和验证码从标准输入读取）", runLogin},
+	"users":      {"users  列出所有用户", runUsers},
+	"traders":    {"traders  列出所有交易员和运行状态", runTraders},
+	"stop":       {"stop TRADER_ID  停止任意用户的交易员", runStop},
+	"rotate-key": {"rotate-key  用新加密密钥重新加密API密钥（服务需已带新旧密钥重启）", runRotateKey},
+	"beta-codes": {"beta-codes [-n N]  查看内测码统计，-n 生成N个新内测码", runBetaCodes},
+	"backup":     {"backup  立即备份数据库和决策日志", runBackup},
+	"backups":    {"backups  列出服务器上的备份", runBackups},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("nofxctl", flag.ContinueOnError)
+	server := fs.String("server", envOr("NOFXCTL_SERVER", defaultServer), "服务器地址")
+	token := fs.String("token", os.Getenv("NOFXCTL_TOKEN"), "管理员token")
+	asJSON := fs.Bool("json", false, "输出原始JSON")
+	fs.Usage = func() { printUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("缺少命令")
+	}
+
+	name := fs.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fs.Usage()
+		return fmt.Errorf("未知命令: %s", name)
+	}
+	if name != "login" && *token == "" {
+		return fmt.Errorf("缺少token，请先执行 nofxctl login 并设置 NOFXCTL_TOKEN")
+	}
+	x := &cli{client: newClient(*server, *token), in: in, out: out, json: *asJSON}
+	return cmd.run(x, fs.Args()[1:])
+}
+
+func printUsage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintln(w, "用法: nofxctl [--server URL] [--token TOKEN] [--json] <命令> [参数]")
+	fmt.Fprintln(w, "\n命令:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, "  "+commands[name].usage)
+	}
+	fmt.Fprintln(w, "\n选项:")
+	fs.PrintDefaults()
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
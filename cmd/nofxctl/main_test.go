@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeAdminAPI 只接受 token "t1" 的管理接口
+func fakeAdminAPI(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/login":
+			json.NewEncoder(w).Encode(map[string]interface{}{"user_id": "u1", "requires_otp": true})
+			return
+		case "/api/verify-otp":
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["user_id"] != "u1" || req["otp_code"] != "123456" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "验证码错误"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": "t1", "role": "admin"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "无效的token"})
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/admin/traders":
+			w.Write([]byte(`[{"id":"tr1","name":"趋势","email":"a@b.com","ai_model_id":"deepseek","exchange_id":"binance","is_running":true}]`))
+		case "POST /api/admin/traders/tr1/stop":
+			w.Write([]byte(`{"message":"交易员已停止"}`))
+		case "POST /api/admin/beta-codes":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"codes":["abc234","xyz789"],"total":12,"used":3}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 page not found"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRun(t *testing.T) {
+	srv := fakeAdminAPI(t)
+	exec := func(stdin string, args ...string) (string, error) {
+		var out bytes.Buffer
+		err := run(append([]string{"--server", srv.URL + "/"}, args...), strings.NewReader(stdin), &out)
+		return out.String(), err
+	}
+
+	out, err := exec("secret\n123456\n", "login", "--email=a@b.com")
+	if err != nil || !strings.Contains(out, "export NOFXCTL_TOKEN=t1") {
+		t.Fatalf("登录失败: %q %v", out, err)
+	}
+	if _, err := exec("secret\n000000\n", "login", "--email=a@b.com"); err == nil || !strings.Contains(err.Error(), "验证码错误") {
+		t.Errorf("错误的验证码应返回接口的错误信息: %v", err)
+	}
+
+	if _, err := exec("", "traders"); err == nil || !strings.Contains(err.Error(), "缺少token") {
+		t.Errorf("缺少token应报错: %v", err)
+	}
+	if _, err := exec("", "--token", "bad", "traders"); err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("无效token应报错: %v", err)
+	}
+
+	out, err = exec("", "--token", "t1", "traders")
+	if err != nil || !strings.Contains(out, "tr1") || !strings.Contains(out, "运行中") {
+		t.Errorf("交易员列表错误: %q %v", out, err)
+	}
+	out, err = exec("", "--token", "t1", "--json", "traders")
+	var traders []map[string]interface{}
+	if err != nil || json.Unmarshal([]byte(out), &traders) != nil || len(traders) != 1 {
+		t.Errorf("--json 应输出JSON: %q %v", out, err)
+	}
+	if out, err := exec("", "--token", "t1", "stop", "tr1"); err != nil || !strings.Contains(out, "交易员已停止") {
+		t.Errorf("停止交易员失败: %q %v", out, err)
+	}
+	if out, err := exec("", "--token", "t1", "beta-codes", "-n", "2"); err != nil || !strings.Contains(out, "abc234\nxyz789\n") {
+		t.Errorf("生成内测码失败: %q %v", out, err)
+	}
+	if _, err := exec("", "--token", "t1", "unknown"); err == nil {
+		t.Error("未知命令应报错")
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"time"
 )
@@ -182,13 +183,13 @@ func (c *Config) Validate() error {
 		c.Leverage.BTCETHLeverage = 5 // 默认5倍（安全值，适配子账户）
 	}
 	if c.Leverage.BTCETHLeverage > 5 {
-		fmt.Printf("⚠️  警告: BTC/ETH杠杆设置为%dx，如果使用子账户可能会失败（子账户限制≤5x）\n", c.Leverage.BTCETHLeverage)
+		slog.Warn("BTC/ETH杠杆超过5x，如果使用子账户可能会失败（子账户限制≤5x）", "leverage", c.Leverage.BTCETHLeverage)
 	}
 	if c.Leverage.AltcoinLeverage <= 0 {
 		c.Leverage.AltcoinLeverage = 5 // 默认5倍（安全值，适配子账户）
 	}
 	if c.Leverage.AltcoinLeverage > 5 {
-		fmt.Printf("⚠️  警告: 山寨币杠杆设置为%dx，如果使用子账户可能会失败（子账户限制≤5x）\n", c.Leverage.AltcoinLeverage)
+		slog.Warn("山寨币杠杆超过5x，如果使用子账户可能会失败（子账户限制≤5x）", "leverage", c.Leverage.AltcoinLeverage)
 	}
 
 	return nil
@@ -1,42 +1,116 @@
 package config
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/base32"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"nofx/market"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// Database 配置数据库
+// Database 配置数据库（SQLite 或 PostgreSQL，SQL差异由 dialect 处理）
 type Database struct {
-	db *sql.DB
+	db      *sqlDB
+	driver  string
+	secrets *secretBox // API密钥加解密，未配置加密密钥时为nil
 }
 
-// NewDatabase 创建配置数据库
+// NewDatabase 创建SQLite配置数据库
 func NewDatabase(dbPath string) (*Database, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	return OpenDatabase(DatabaseOptions{Driver: DriverSQLite, DSN: dbPath})
+}
+
+// OpenDatabase 按配置打开SQLite或PostgreSQL数据库并初始化表结构
+func OpenDatabase(opts DatabaseOptions) (*Database, error) {
+	var d dialect
+	switch opts.Driver {
+	case "", DriverSQLite, "sqlite":
+		opts.Driver, d = DriverSQLite, sqliteDialect{}
+	case DriverPostgres, "postgresql":
+		opts.Driver, d = DriverPostgres, &postgresDialect{}
+		if opts.MaxOpenConns <= 0 {
+			opts.MaxOpenConns = defaultPostgresMaxOpenConns
+		}
+		if opts.MaxIdleConns <= 0 {
+			opts.MaxIdleConns = defaultPostgresMaxIdleConns
+		}
+		if opts.ConnMaxLifetimeMinutes <= 0 {
+			opts.ConnMaxLifetimeMinutes = int(defaultPostgresConnMaxLifetime / time.Minute)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", opts.Driver)
+	}
+	if opts.DSN == "" {
+		return nil, fmt.Errorf("未配置数据库地址")
+	}
+
+	db, err := sql.Open(opts.Driver, opts.DSN)
 	if err != nil {
+		if opts.Driver == DriverPostgres {
+			return nil, fmt.Errorf("打开数据库失败（PostgreSQL 需要使用 -tags postgres 构建）: %w", err)
+		}
 		return nil, fmt.Errorf("打开数据库失败: %w", err)
 	}
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetimeMinutes > 0 {
+		db.SetConnMaxLifetime(time.Duration(opts.ConnMaxLifetimeMinutes) * time.Minute)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+
+	secrets, err := loadSecretBox()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
 
-	database := &Database{db: db}
+	database := &Database{db: &sqlDB{DB: db, dialect: d}, driver: opts.Driver, secrets: secrets}
 	if err := database.createTables(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("创建表失败: %w", err)
 	}
 
+	if err := database.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// 检查是否需要迁移exchanges表的主键结构（旧版本只用过SQLite），需要在补齐字段之后执行
+	if database.driver == DriverSQLite {
+		if err := database.migrateExchangesTable(); err != nil {
+			slog.Warn("迁移exchanges表失败", "error", err)
+		}
+	}
+
 	if err := database.initDefaultData(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("初始化默认数据失败: %w", err)
 	}
 
+	if err := database.encryptPlaintextSecrets(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return database, nil
 }
 
@@ -58,7 +132,7 @@ func (d *Database) createTables() error {
 
 		// 交易所配置表
 		`CREATE TABLE IF NOT EXISTS exchanges (
-			id TEXT PRIMARY KEY,
+			id TEXT NOT NULL,
 			user_id TEXT NOT NULL DEFAULT 'default',
 			name TEXT NOT NULL,
 			type TEXT NOT NULL, -- 'cex' or 'dex'
@@ -74,6 +148,7 @@ func (d *Database) createTables() error {
 			aster_private_key TEXT DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (id, user_id),
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 		)`,
 
@@ -109,6 +184,11 @@ func (d *Database) createTables() error {
 			system_prompt_template TEXT DEFAULT 'default',
 			is_cross_margin BOOLEAN DEFAULT 1,
 			binance_proxy_url TEXT DEFAULT '',
+			max_candidates INTEGER DEFAULT 0,
+			pool_refresh_minutes INTEGER DEFAULT 0,
+			liquidity_filter TEXT DEFAULT '',
+			min_oi_value_millions REAL DEFAULT 0,
+			min_volume_millions REAL DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
@@ -123,6 +203,9 @@ func (d *Database) createTables() error {
 			password_hash TEXT NOT NULL,
 			otp_secret TEXT,
 			otp_verified BOOLEAN DEFAULT 0,
+			pending_otp_secret TEXT DEFAULT '',
+			role TEXT DEFAULT 'user',
+			disabled BOOLEAN DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
@@ -134,6 +217,16 @@ func (d *Database) createTables() error {
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
 
+		// 系统配置修改记录
+		`CREATE TABLE IF NOT EXISTS system_config_audit (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			key TEXT NOT NULL,
+			old_value TEXT DEFAULT '',
+			new_value TEXT DEFAULT '',
+			changed_by TEXT NOT NULL,
+			changed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
 		// 内测码表
 		`CREATE TABLE IF NOT EXISTS beta_codes (
 			code TEXT PRIMARY KEY,
@@ -143,82 +236,256 @@ func (d *Database) createTables() error {
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
 
-		// 触发器：自动更新 updated_at
-		`CREATE TRIGGER IF NOT EXISTS update_users_updated_at
-			AFTER UPDATE ON users
-			BEGIN
-				UPDATE users SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-			END`,
+		// 回测记录表（config/summary/results 均为JSON）
+		`CREATE TABLE IF NOT EXISTS backtest_runs (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			trader_id TEXT NOT NULL,
+			mode TEXT NOT NULL DEFAULT 'compare', -- 'compare' or 'walk_forward'
+			period_from DATETIME,
+			period_to DATETIME,
+			cycles INTEGER DEFAULT 0,
+			config TEXT DEFAULT '{}',
+			summary TEXT DEFAULT '{}',
+			results TEXT DEFAULT '{}',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
 
-		`CREATE TRIGGER IF NOT EXISTS update_ai_models_updated_at
-			AFTER UPDATE ON ai_models
-			BEGIN
-				UPDATE ai_models SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-			END`,
+		// OTP恢复码（只保存哈希，used_at 非空表示已使用）
+		`CREATE TABLE IF NOT EXISTS otp_recovery_codes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT NOT NULL,
+			code_hash TEXT NOT NULL,
+			used_at DATETIME DEFAULT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
 
-		`CREATE TRIGGER IF NOT EXISTS update_exchanges_updated_at
-			AFTER UPDATE ON exchanges
-			BEGIN
-				UPDATE exchanges SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-			END`,
+		// 交易员共享：被共享的用户可以只读查看该交易员的数据
+		`CREATE TABLE IF NOT EXISTS trader_shares (
+			trader_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (trader_id, user_id),
+			FOREIGN KEY (trader_id) REFERENCES traders(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
 
-		`CREATE TRIGGER IF NOT EXISTS update_traders_updated_at
-			AFTER UPDATE ON traders
-			BEGIN
-				UPDATE traders SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-			END`,
+		// 交易员只读分享链接（只保存token哈希，删除即撤销）
+		`CREATE TABLE IF NOT EXISTS trader_share_links (
+			id TEXT PRIMARY KEY,
+			token_hash TEXT NOT NULL UNIQUE,
+			user_id TEXT NOT NULL,
+			trader_id TEXT NOT NULL,
+			label TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_used_at DATETIME DEFAULT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
 
-		`CREATE TRIGGER IF NOT EXISTS update_user_signal_sources_updated_at
-			AFTER UPDATE ON user_signal_sources
-			BEGIN
-				UPDATE user_signal_sources SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-			END`,
+		// 用户注册的webhook：交易事件以签名JSON推送到外部地址
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			trader_id TEXT DEFAULT '', -- 为空表示该用户的所有交易员
+			events TEXT DEFAULT '',    -- 逗号分隔，为空表示所有事件
+			last_status INTEGER DEFAULT 0,
+			last_error TEXT DEFAULT '',
+			last_delivered_at DATETIME DEFAULT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
 
-		`CREATE TRIGGER IF NOT EXISTS update_system_config_updated_at
-			AFTER UPDATE ON system_config
-			BEGIN
-				UPDATE system_config SET updated_at = CURRENT_TIMESTAMP WHERE key = NEW.key;
-			END`,
+		// Telegram绑定：每个用户最多绑定一个聊天，一个聊天只属于一个用户
+		`CREATE TABLE IF NOT EXISTS telegram_links (
+			user_id TEXT PRIMARY KEY,
+			chat_id INTEGER NOT NULL UNIQUE,
+			username TEXT DEFAULT '',
+			linked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+
+		// Telegram绑定口令（只保存哈希，一次性、短时有效）
+		`CREATE TABLE IF NOT EXISTS telegram_link_tokens (
+			token_hash TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			expires_at DATETIME NOT NULL
+		)`,
+
+		// 竞赛赛季：赛季内按区间收益率排名，结束后排名归档到 season_results
+		`CREATE TABLE IF NOT EXISTS competition_seasons (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			start_at DATETIME NOT NULL,
+			end_at DATETIME NOT NULL,
+			archived BOOLEAN NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 已结束赛季的最终排名（决策日志清理后仍可查询）
+		`CREATE TABLE IF NOT EXISTS season_results (
+			season_id INTEGER NOT NULL,
+			rank INTEGER NOT NULL,
+			trader_id TEXT NOT NULL,
+			trader_name TEXT NOT NULL,
+			ai_model TEXT NOT NULL DEFAULT '',
+			exchange TEXT NOT NULL DEFAULT '',
+			start_equity REAL NOT NULL,
+			end_equity REAL NOT NULL,
+			pnl REAL NOT NULL,
+			pnl_pct REAL NOT NULL,
+			PRIMARY KEY (season_id, trader_id)
+		)`,
+
+		// 每日邮件摘要订阅（last_sent_on 为最近一次发送的UTC日期，重启后不重复发送）
+		`CREATE TABLE IF NOT EXISTS daily_digests (
+			user_id TEXT PRIMARY KEY,
+			enabled BOOLEAN NOT NULL DEFAULT 0,
+			last_sent_on TEXT NOT NULL DEFAULT ''
+		)`,
+
+		// 跟单：用户用自己的交易所账户按倍数镜像其他交易员的决策
+		`CREATE TABLE IF NOT EXISTS follows (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			leader_id TEXT NOT NULL,
+			exchange_id TEXT NOT NULL,
+			multiplier REAL NOT NULL DEFAULT 1,
+			max_leverage INTEGER NOT NULL DEFAULT 0,
+			max_position_usd REAL NOT NULL DEFAULT 0,
+			max_positions INTEGER NOT NULL DEFAULT 0,
+			max_drawdown_pct REAL NOT NULL DEFAULT 0,
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+
+		// 管理员为单个用户设置的硬性上限（0 表示沿用系统级上限）
+		`CREATE TABLE IF NOT EXISTS user_limits (
+			user_id TEXT PRIMARY KEY,
+			max_leverage INTEGER DEFAULT 0,
+			max_notional REAL DEFAULT 0,
+			max_traders INTEGER DEFAULT 0,
+			max_running_traders INTEGER DEFAULT 0,
+			min_scan_interval INTEGER DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+
+		// 交易员的交易时段，时段外交易员保持运行但跳过决策周期
+		`CREATE TABLE IF NOT EXISTS trader_schedules (
+			trader_id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			spec TEXT NOT NULL,
+			timezone TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+
+		// 交易员标签，用于分组筛选和批量操作
+		`CREATE TABLE IF NOT EXISTS trader_tags (
+			trader_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (trader_id, tag),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_trader_tags_tag ON trader_tags(tag)`,
+
+		// 外部信号推送的签名密钥：每个交易员一个，没有记录表示未开启
+		`CREATE TABLE IF NOT EXISTS trader_signal_keys (
+			trader_id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_used_at DATETIME DEFAULT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+
+		// 用户为交易员注册的候选币种信号源（http_json/rss/webhook），config 为 pool.SourceConfig 的JSON
+		`CREATE TABLE IF NOT EXISTS trader_signal_sources (
+			id TEXT PRIMARY KEY,
+			trader_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			config TEXT NOT NULL DEFAULT '{}',
+			secret TEXT DEFAULT '', -- 只有webhook类型使用
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (trader_id, name),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+
+		// 订单生命周期：每笔市价单和止损/止盈单的当前状态，状态变化明细见 order_events
+		`CREATE TABLE IF NOT EXISTS orders (
+			client_order_id TEXT PRIMARY KEY,
+			trader_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			action TEXT NOT NULL,
+			order_type TEXT NOT NULL,
+			exchange_order_id TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			quantity REAL NOT NULL DEFAULT 0,
+			price REAL NOT NULL DEFAULT 0,
+			fill_price REAL NOT NULL DEFAULT 0,
+			stop_price REAL NOT NULL DEFAULT 0,
+			reason TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_orders_trader ON orders(trader_id, created_at)`,
+		`CREATE TABLE IF NOT EXISTS order_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			client_order_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_order_events_order ON order_events(client_order_id)`,
+
+		// 平仓记录：每次平仓（AI平仓、止损止盈、强平、自动平仓）写入一条，手续费为估算值
+		`CREATE TABLE IF NOT EXISTS position_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			trader_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			symbol TEXT NOT NULL,
+			side TEXT NOT NULL,
+			entry_price REAL NOT NULL DEFAULT 0,
+			exit_price REAL NOT NULL DEFAULT 0,
+			quantity REAL NOT NULL DEFAULT 0,
+			fees REAL NOT NULL DEFAULT 0,
+			funding_fee REAL NOT NULL DEFAULT 0,
+			pnl REAL NOT NULL DEFAULT 0,
+			realized_pnl REAL NOT NULL DEFAULT 0,
+			r_multiple REAL NOT NULL DEFAULT 0,
+			holding_minutes REAL NOT NULL DEFAULT 0,
+			exit_reason TEXT NOT NULL DEFAULT '',
+			opened_at DATETIME,
+			closed_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_position_history_trader ON position_history(trader_id, closed_at)`,
 	}
 
+	// 触发器：自动更新 updated_at
+	queries = append(queries, d.db.dialect.updatedAtTriggers([]updatedAtTable{
+		{"users", "id = NEW.id"},
+		{"ai_models", "id = NEW.id"},
+		{"exchanges", "id = NEW.id AND user_id = NEW.user_id"},
+		{"traders", "id = NEW.id"},
+		{"user_signal_sources", "id = NEW.id"},
+		{"system_config", "key = NEW.key"},
+	})...)
+
 	for _, query := range queries {
 		if _, err := d.db.Exec(query); err != nil {
 			return fmt.Errorf("执行SQL失败 [%s]: %w", query, err)
 		}
 	}
 
-	// 为现有数据库添加新字段（向后兼容）
-	alterQueries := []string{
-		`ALTER TABLE exchanges ADD COLUMN hyperliquid_wallet_addr TEXT DEFAULT ''`,
-		`ALTER TABLE exchanges ADD COLUMN aster_user TEXT DEFAULT ''`,
-		`ALTER TABLE exchanges ADD COLUMN aster_signer TEXT DEFAULT ''`,
-		`ALTER TABLE exchanges ADD COLUMN aster_private_key TEXT DEFAULT ''`,
-		`ALTER TABLE traders ADD COLUMN custom_prompt TEXT DEFAULT ''`,
-		`ALTER TABLE traders ADD COLUMN override_base_prompt BOOLEAN DEFAULT 0`,
-		`ALTER TABLE traders ADD COLUMN is_cross_margin BOOLEAN DEFAULT 1`,             // 默认为全仓模式
-		`ALTER TABLE traders ADD COLUMN use_default_coins BOOLEAN DEFAULT 1`,           // 默认使用默认币种
-		`ALTER TABLE traders ADD COLUMN custom_coins TEXT DEFAULT ''`,                  // 自定义币种列表（JSON格式）
-		`ALTER TABLE traders ADD COLUMN btc_eth_leverage INTEGER DEFAULT 5`,            // BTC/ETH杠杆倍数
-		`ALTER TABLE traders ADD COLUMN altcoin_leverage INTEGER DEFAULT 5`,            // 山寨币杠杆倍数
-		`ALTER TABLE traders ADD COLUMN trading_symbols TEXT DEFAULT ''`,               // 交易币种，逗号分隔
-		`ALTER TABLE traders ADD COLUMN use_coin_pool BOOLEAN DEFAULT 0`,               // 是否使用COIN POOL信号源
-		`ALTER TABLE traders ADD COLUMN use_oi_top BOOLEAN DEFAULT 0`,                  // 是否使用OI TOP信号源
-		`ALTER TABLE traders ADD COLUMN system_prompt_template TEXT DEFAULT 'default'`, // 系统提示词模板名称
-		`ALTER TABLE ai_models ADD COLUMN custom_api_url TEXT DEFAULT ''`,              // 自定义API地址
-		`ALTER TABLE ai_models ADD COLUMN custom_model_name TEXT DEFAULT ''`,           // 自定义模型名称
-	}
-
-	for _, query := range alterQueries {
-		// 忽略已存在字段的错误
-		d.db.Exec(query)
-	}
-
-	// 检查是否需要迁移exchanges表的主键结构
-	err := d.migrateExchangesTable()
-	if err != nil {
-		log.Printf("⚠️ 迁移exchanges表失败: %v", err)
-	}
-
 	return nil
 }
 
@@ -234,8 +501,9 @@ func (d *Database) initDefaultData() error {
 
 	for _, model := range aiModels {
 		_, err := d.db.Exec(`
-			INSERT OR IGNORE INTO ai_models (id, user_id, name, provider, enabled) 
-			VALUES (?, 'default', ?, ?, 0)
+			INSERT INTO ai_models (id, user_id, name, provider, enabled)
+			VALUES (?, 'default', ?, ?, FALSE)
+			ON CONFLICT DO NOTHING
 		`, model.id, model.name, model.provider)
 		if err != nil {
 			return fmt.Errorf("初始化AI模型失败: %w", err)
@@ -249,12 +517,14 @@ func (d *Database) initDefaultData() error {
 		{"binance", "Binance Futures", "binance"},
 		{"hyperliquid", "Hyperliquid", "hyperliquid"},
 		{"aster", "Aster DEX", "aster"},
+		{"paper", "Paper Trading", "paper"},
 	}
 
 	for _, exchange := range exchanges {
 		_, err := d.db.Exec(`
-			INSERT OR IGNORE INTO exchanges (id, user_id, name, type, enabled) 
-			VALUES (?, 'default', ?, ?, 0)
+			INSERT INTO exchanges (id, user_id, name, type, enabled)
+			VALUES (?, 'default', ?, ?, FALSE)
+			ON CONFLICT DO NOTHING
 		`, exchange.id, exchange.name, exchange.typ)
 		if err != nil {
 			return fmt.Errorf("初始化交易所失败: %w", err)
@@ -263,23 +533,79 @@ func (d *Database) initDefaultData() error {
 
 	// 初始化系统配置 - 创建所有字段，设置默认值，后续由config.json同步更新
 	systemConfigs := map[string]string{
-		"admin_mode":            "true",                                                                                // 默认开启管理员模式，便于首次使用
-		"beta_mode":             "false",                                                                             // 默认关闭内测模式
-		"api_server_port":       "8080",                                                                                // 默认API端口
-		"use_default_coins":     "true",                                                                                // 默认使用内置币种列表
-		"default_coins":         `["BTCUSDT","ETHUSDT","SOLUSDT","BNBUSDT","XRPUSDT","DOGEUSDT","ADAUSDT","HYPEUSDT"]`, // 默认币种列表（JSON格式）
-		"max_daily_loss":        "10.0",                                                                                // 最大日损失百分比
-		"max_drawdown":          "20.0",                                                                                // 最大回撤百分比
-		"stop_trading_minutes":  "60",                                                                                  // 停止交易时间（分钟）
-		"btc_eth_leverage":      "5",                                                                                   // BTC/ETH杠杆倍数
-		"altcoin_leverage":      "5",                                                                                   // 山寨币杠杆倍数
-		"jwt_secret":            "",                                                                                    // JWT密钥，默认为空，由config.json或系统生成
+		"admin_mode":                    "true",                                                                                // 默认开启管理员模式，便于首次使用
+		"beta_mode":                     "false",                                                                               // 默认关闭内测模式
+		"api_server_port":               "8080",                                                                                // 默认API端口
+		"use_default_coins":             "true",                                                                                // 默认使用内置币种列表
+		"default_coins":                 `["BTCUSDT","ETHUSDT","SOLUSDT","BNBUSDT","XRPUSDT","DOGEUSDT","ADAUSDT","HYPEUSDT"]`, // 默认币种列表（JSON格式）
+		"max_daily_loss":                "10.0",                                                                                // 最大日损失百分比
+		"max_drawdown":                  "20.0",                                                                                // 最大回撤百分比
+		"stop_trading_minutes":          "60",                                                                                  // 停止交易时间（分钟）
+		"resume_traders_on_boot":        "true",                                                                                // 启动时恢复重启前处于运行状态的交易员
+		"trader_resume_stagger_seconds": "5",                                                                                   // 恢复交易员时相邻两个的启动间隔（秒），避免同时对账和调用AI
+		"auto_restart_stalled_traders":  "false",                                                                               // 自动重启卡住或连续出错的交易员（默认只告警）
+		"btc_eth_leverage":              "5",                                                                                   // BTC/ETH杠杆倍数
+		"altcoin_leverage":              "5",                                                                                   // 山寨币杠杆倍数
+		"jwt_secret":                    "",                                                                                    // JWT密钥，默认为空，由config.json或系统生成
+		"news_feed_url":                 "",                                                                                    // 新闻标题RSS源（可选）
+		"oi_top_provider":               "api",                                                                                 // OI Top来源: api(请求 oi_top_api_url) / builtin(根据币安公开接口计算1小时持仓量变化)
+		"candidate_max_age_minutes":     "180",                                                                                 // 币种池/信号源数据超过此分钟数视为过期，对应币种不再发给AI（0 不过期）
+		"paper_trading_costs":           "",                                                                                    // 纸面交易费用与滑点（JSON，为空使用默认值）
+		"orphan_position_policy":        "adopt",                                                                               // 启动对账时孤儿持仓的处理: adopt(接管) / close(平仓)
+		"circuit_breaker_drop_pct":      "10",                                                                                  // 净值熔断：窗口内回撤百分比（0 关闭）
+		"circuit_breaker_minutes":       "30",                                                                                  // 净值熔断统计窗口（分钟）
+		"max_consecutive_losses":        "3",                                                                                   // 连续亏损多少笔后进入只分析模式（0 关闭）
+		"loss_cooldown_minutes":         "60",                                                                                  // 连续亏损冷却时长（分钟），结束后自动恢复
+		"limit_max_leverage":            "0",                                                                                   // 系统级杠杆上限（0 不限制，用户单独设置时以用户为准）
+		"limit_max_notional":            "0",                                                                                   // 系统级单仓名义价值上限（USDT）
+		"limit_max_traders":             "0",                                                                                   // 系统级每个用户的交易员数量上限
+		"limit_max_running_traders":     "0",                                                                                   // 系统级每个用户同时运行的交易员数量上限
+		"limit_min_scan_interval":       "0",                                                                                   // 系统级最小扫描间隔（分钟），防止单个用户耗尽AI/交易所限额
+		"balance_drift_threshold_pct":   "2",                                                                                   // 钱包余额变化与日志已实现盈亏的偏差告警阈值（%，0 关闭）
+		"funding_cost_close_pct":        "0",                                                                                   // 持仓累计资金费超过浮盈的此百分比时自动平仓（%，0 关闭）
+		"max_net_delta_pct":             "0",                                                                                   // 单个交易员净方向敞口（多-空名义价值）占净值的上限（%，0 不限制）
+		"order_retry_attempts":          "3",                                                                                   // 下单遇到限频时最多尝试次数（含第一次）；网络错误只重试平仓，开仓可能已成交
+		"order_retry_backoff_ms":        "1000",                                                                                // 第一次重试前等待的毫秒数，之后每次翻倍
+		"rate_limit_ip":                 "600",                                                                                 // API限流：每个IP每分钟请求数（0 不限制）
+		"rate_limit_user":               "300",                                                                                 // API限流：每个登录用户每分钟请求数
+		"rate_limit_public":             "120",                                                                                 // API限流：每个IP每分钟访问公开竞赛接口次数
+		"rate_limit_auth":               "10",                                                                                  // API限流：每个IP每分钟登录/注册/OTP次数（每个接口单独计数）
+		"smtp_config":                   "",                                                                                    // SMTP发信配置（JSON，为空时邮件只写入日志）
+		"backup_config":                 "",                                                                                    // 备份配置（JSON：dir/interval_hours/keep/s3），为空时只能手动备份到 backups 目录
+		"public_cache_seconds":          "5",                                                                                   // 公开接口响应缓存秒数（0 关闭）
+		"proxy_url":                     "",                                                                                    // 交易所API的默认代理，为空时直连
+		"redis_url":                     "",                                                                                    // 共享缓存的Redis地址（redis:// 或 rediss://），为空时使用进程内缓存
+		"decision_log_storage":          "",                                                                                    // 决策日志存储（JSON：type 为 file/database/s3，s3 时附带 s3 配置），为空时保存为 decision_logs 下的文件
+		"password_reset_url":            "",                                                                                    // 密码重置邮件中的前端地址（如 https://nofx.example.com），为空时只接受CORS白名单中的请求来源
+		"log_level":                     "info",                                                                                // 日志级别: debug / info / warn / error，修改后立即生效；环境变量 NOFX_LOG_LEVEL 优先
+		"log_format":                    "text",                                                                                // 日志格式: text / json（便于日志收集）；环境变量 NOFX_LOG_FORMAT 优先
+		"otlp_endpoint":                 "",                                                                                    // 链路追踪OTLP/HTTP地址（如 http://localhost:4318），为空时不启用；也可用 OTEL_EXPORTER_OTLP_ENDPOINT
+		"tls_cert_file":                 "",                                                                                    // HTTPS证书文件（PEM），与 tls_key_file 一起设置后API直接提供HTTPS
+		"tls_key_file":                  "",                                                                                    // HTTPS私钥文件（PEM）
+		"tls_autocert_domains":          "",                                                                                    // 通过Let's Encrypt自动申请证书的域名（逗号分隔），与证书文件二选一；需要公网能访问443端口
+		"tls_autocert_cache_dir":        "autocert_cache",                                                                      // 自动证书的缓存目录，应持久化以免重复申请触发Let's Encrypt限额
+		"http_redirect_port":            "0",                                                                                   // 启用HTTPS时在该端口监听HTTP并重定向到HTTPS（自动证书时也用于HTTP-01验证），0 不启用
+		"cors_allowed_origins":          "*",                                                                                   // 允许跨域访问的前端来源（逗号分隔），生产环境应改为前端实际地址；环境变量 NOFX_CORS_ORIGINS 优先
+		"trusted_proxies":               "",                                                                                    // 可信反向代理的IP或CIDR（逗号分隔），为空时不信任 X-Forwarded-For，按连接地址识别客户端IP
+		"telegram_bot_token":            "",                                                                                    // Telegram机器人token（@BotFather 创建），为空时不启用
+		"daily_digest_hour":             "0",                                                                                   // 每日邮件摘要发送时间（UTC小时），汇总前一个UTC日
+		"idle_trader_evict_minutes":     "0",                                                                                   // 已停止的交易员超过多少分钟未访问时移出内存，再次访问时自动重新加载（0 不移除）
+		"max_idle_traders":              "0",                                                                                   // 内存中最多保留的已停止交易员数量，超出时移除最久未访问的（0 不限制）
+		"ai_max_concurrent_calls":       "4",                                                                                   // 同一AI密钥（提供商+API密钥）同时进行的调用数上限，排队时在用户之间轮转（0 不限制）
+		"decision_log_keep_days":        "0",                                                                                   // 决策日志原始记录保留天数，更早的记录删除前按小时降采样保存净值（0 永久保留）
+		"decision_log_gzip_days":        "7",                                                                                   // 超过此天数的决策日志gzip压缩保存，读取时自动解压（0 不压缩）
+		"competition_rank_by":           "pnl_pct",                                                                             // 竞赛排名方式：pnl_pct / sharpe / calmar / drawdown_adjusted
+		"competition_min_trades":        "0",                                                                                   // 平仓笔数少于此值的交易员排在达标交易员之后
+		"equity_sample_minutes":         "5",                                                                                   // 独立于决策周期的净值采样间隔（分钟，0 关闭）
+		"ai_input_price_per_mtok":       "0.27",                                                                                // 每百万输入token的AI费用（USD，用于估算摘要中的AI成本）
+		"ai_output_price_per_mtok":      "1.10",                                                                                // 每百万输出token的AI费用（USD）
 	}
 
 	for key, value := range systemConfigs {
 		_, err := d.db.Exec(`
-			INSERT OR IGNORE INTO system_config (key, value) 
+			INSERT INTO system_config (key, value)
 			VALUES (?, ?)
+			ON CONFLICT DO NOTHING
 		`, key, value)
 		if err != nil {
 			return fmt.Errorf("初始化系统配置失败: %w", err)
@@ -291,22 +617,17 @@ func (d *Database) initDefaultData() error {
 
 // migrateExchangesTable 迁移exchanges表支持多用户
 func (d *Database) migrateExchangesTable() error {
-	// 检查是否已经迁移过
-	var count int
-	err := d.db.QueryRow(`
-		SELECT COUNT(*) FROM sqlite_master 
-		WHERE type='table' AND name='exchanges_new'
-	`).Scan(&count)
+	// 主键已经是 (id, user_id) 说明迁移过（重建表会丢掉表上的索引，不能每次启动都执行）
+	var pkColumns int
+	err := d.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('exchanges') WHERE pk > 0`).Scan(&pkColumns)
 	if err != nil {
 		return err
 	}
-
-	// 如果已经迁移过，直接返回
-	if count > 0 {
+	if pkColumns > 1 {
 		return nil
 	}
 
-	log.Printf("🔄 开始迁移exchanges表...")
+	slog.Info("开始迁移exchanges表")
 
 	// 创建新的exchanges表，使用复合主键
 	_, err = d.db.Exec(`
@@ -367,7 +688,7 @@ func (d *Database) migrateExchangesTable() error {
 		return fmt.Errorf("创建触发器失败: %w", err)
 	}
 
-	log.Printf("✅ exchanges表迁移完成")
+	slog.Info("exchanges表迁移完成")
 	return nil
 }
 
@@ -378,10 +699,25 @@ type User struct {
 	PasswordHash string    `json:"-"` // 不返回到前端
 	OTPSecret    string    `json:"-"` // 不返回到前端
 	OTPVerified  bool      `json:"otp_verified"`
+	PendingOTP   string    `json:"-"` // 重新绑定时待确认的OTP密钥
+	Role         string    `json:"role"`
+	Disabled     bool      `json:"disabled"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// 用户角色
+const (
+	RoleAdmin  = "admin"  // 管理所有用户和系统上限
+	RoleUser   = "user"   // 管理自己的交易员和密钥
+	RoleViewer = "viewer" // 只能查看共享给自己的交易员，不能启停，也看不到API密钥
+)
+
+// ValidRole 是否为合法角色
+func ValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleUser || role == RoleViewer
+}
+
 // AIModelConfig AI模型配置
 type AIModelConfig struct {
 	ID              string    `json:"id"`
@@ -436,10 +772,40 @@ type TraderRecord struct {
 	SystemPromptTemplate string    `json:"system_prompt_template"` // 系统提示词模板名称
 	IsCrossMargin        bool      `json:"is_cross_margin"`        // 是否为全仓模式（true=全仓，false=逐仓）
 	BinanceProxyURL      string    `json:"binance_proxy_url"`      // 币安代理URL，如"http://proxy.example.com:8080"
+	MaxCandidates        int       `json:"max_candidates"`         // 每个周期发给AI的候选币种上限（0 使用默认值）
+	PoolRefreshMinutes   int       `json:"pool_refresh_minutes"`   // 候选币种池刷新间隔（分钟，0 每个周期刷新）
+	LiquidityFilter      string    `json:"liquidity_filter"`       // 流动性过滤方式：oi_value（默认）/volume/both/off
+	MinOIValueMillions   float64   `json:"min_oi_value_millions"`  // 最低持仓价值（百万USDT，0 使用默认值15）
+	MinVolumeMillions    float64   `json:"min_volume_millions"`    // 最低24h成交额（百万USDT，0 使用默认值50）
 	CreatedAt            time.Time `json:"created_at"`
 	UpdatedAt            time.Time `json:"updated_at"`
 }
 
+// BacktestRun 回测记录（数据库实体）
+type BacktestRun struct {
+	ID         string          `json:"id"`
+	UserID     string          `json:"user_id"`
+	TraderID   string          `json:"trader_id"`
+	Mode       string          `json:"mode"` // compare 或 walk_forward
+	PeriodFrom time.Time       `json:"period_from"`
+	PeriodTo   time.Time       `json:"period_to"`
+	Cycles     int             `json:"cycles"`
+	Config     json.RawMessage `json:"config"`            // 回测参数
+	Summary    json.RawMessage `json:"summary"`           // 各配置收益摘要（列表页使用）
+	Results    json.RawMessage `json:"results,omitempty"` // 完整结果和权益曲线（列表接口不返回）
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// UserLimits 管理员设置的硬性上限，优先于交易员自身配置（0 表示不限制）
+type UserLimits struct {
+	UserID            string  `json:"user_id,omitempty"` // 为空表示系统级上限
+	MaxLeverage       int     `json:"max_leverage"`
+	MaxNotional       float64 `json:"max_notional"` // 单个仓位名义价值上限（USDT）
+	MaxTraders        int     `json:"max_traders"`
+	MaxRunningTraders int     `json:"max_running_traders"`       // 同时运行的交易员数量上限
+	MinScanInterval   int     `json:"min_scan_interval_minutes"` // 交易员扫描间隔下限（分钟）
+}
+
 // UserSignalSource 用户信号源配置
 type UserSignalSource struct {
 	ID          int       `json:"id"`
@@ -462,10 +828,14 @@ func GenerateOTPSecret() (string, error) {
 
 // CreateUser 创建用户
 func (d *Database) CreateUser(user *User) error {
+	role := user.Role
+	if role == "" {
+		role = RoleUser
+	}
 	_, err := d.db.Exec(`
-		INSERT INTO users (id, email, password_hash, otp_secret, otp_verified)
-		VALUES (?, ?, ?, ?, ?)
-	`, user.ID, user.Email, user.PasswordHash, user.OTPSecret, user.OTPVerified)
+		INSERT INTO users (id, email, password_hash, otp_secret, otp_verified, role)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, user.ID, user.Email, user.PasswordHash, user.OTPSecret, user.OTPVerified, role)
 	return err
 }
 
@@ -478,9 +848,10 @@ func (d *Database) EnsureAdminUser() error {
 		return err
 	}
 
-	// 如果已存在，直接返回
+	// 如果已存在，确保角色为管理员（兼容添加角色字段前创建的admin用户）
 	if count > 0 {
-		return nil
+		_, err := d.db.Exec(`UPDATE users SET role = ? WHERE id = 'admin'`, RoleAdmin)
+		return err
 	}
 
 	// 创建admin用户（密码为空，因为管理员模式下不需要密码）
@@ -490,6 +861,7 @@ func (d *Database) EnsureAdminUser() error {
 		PasswordHash: "", // 管理员模式下不使用密码
 		OTPSecret:    "",
 		OTPVerified:  true,
+		Role:         RoleAdmin,
 	}
 
 	return d.CreateUser(adminUser)
@@ -499,11 +871,12 @@ func (d *Database) EnsureAdminUser() error {
 func (d *Database) GetUserByEmail(email string) (*User, error) {
 	var user User
 	err := d.db.QueryRow(`
-		SELECT id, email, password_hash, otp_secret, otp_verified, created_at, updated_at
+		SELECT id, email, password_hash, otp_secret, otp_verified, pending_otp_secret,
+		       COALESCE(role, 'user'), COALESCE(disabled, FALSE), created_at, updated_at
 		FROM users WHERE email = ?
 	`, email).Scan(
 		&user.ID, &user.Email, &user.PasswordHash, &user.OTPSecret,
-		&user.OTPVerified, &user.CreatedAt, &user.UpdatedAt,
+		&user.OTPVerified, &user.PendingOTP, &user.Role, &user.Disabled, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -515,11 +888,12 @@ func (d *Database) GetUserByEmail(email string) (*User, error) {
 func (d *Database) GetUserByID(userID string) (*User, error) {
 	var user User
 	err := d.db.QueryRow(`
-		SELECT id, email, password_hash, otp_secret, otp_verified, created_at, updated_at
+		SELECT id, email, password_hash, otp_secret, otp_verified, pending_otp_secret,
+		       COALESCE(role, 'user'), COALESCE(disabled, FALSE), created_at, updated_at
 		FROM users WHERE id = ?
 	`, userID).Scan(
 		&user.ID, &user.Email, &user.PasswordHash, &user.OTPSecret,
-		&user.OTPVerified, &user.CreatedAt, &user.UpdatedAt,
+		&user.OTPVerified, &user.PendingOTP, &user.Role, &user.Disabled, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -546,12 +920,204 @@ func (d *Database) GetAllUsers() ([]string, error) {
 	return userIDs, nil
 }
 
+// ListUsers 所有用户（管理员管理角色用）
+func (d *Database) ListUsers() ([]*User, error) {
+	rows, err := d.db.Query(`
+		SELECT id, email, otp_verified, COALESCE(role, 'user'), COALESCE(disabled, FALSE), created_at, updated_at
+		FROM users ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*User, 0)
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Email, &user.OTPVerified, &user.Role, &user.Disabled, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+// SetUserRole 修改用户角色
+func (d *Database) SetUserRole(userID, role string) error {
+	if !ValidRole(role) {
+		return fmt.Errorf("无效的角色: %s", role)
+	}
+	result, err := d.db.Exec(`UPDATE users SET role = ? WHERE id = ?`, role, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetUserDisabled 禁用或启用账户
+func (d *Database) SetUserDisabled(userID string, disabled bool) error {
+	result, err := d.db.Exec(`UPDATE users SET disabled = ? WHERE id = ?`, disabled, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ResetUserOTP 管理员重置用户的验证器：换新密钥并要求重新绑定，旧恢复码全部作废
+func (d *Database) ResetUserOTP(userID, secret string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET otp_secret = ?, otp_verified = FALSE, pending_otp_secret = '' WHERE id = ?
+	`, secret, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec(`DELETE FROM otp_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UserUsage 用户占用的资源
+type UserUsage struct {
+	Traders        int `json:"traders"`
+	RunningTraders int `json:"running_traders"`
+	BacktestRuns   int `json:"backtest_runs"`
+}
+
+// GetUserUsage 按用户统计交易员和回测数量（没有任何记录的用户不在结果中）
+func (d *Database) GetUserUsage() (map[string]*UserUsage, error) {
+	usage := make(map[string]*UserUsage)
+	get := func(userID string) *UserUsage {
+		if usage[userID] == nil {
+			usage[userID] = &UserUsage{}
+		}
+		return usage[userID]
+	}
+
+	rows, err := d.db.Query(`
+		SELECT user_id, COUNT(*), COALESCE(SUM(CASE WHEN is_running THEN 1 ELSE 0 END), 0)
+		FROM traders GROUP BY user_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID string
+		var total, running int
+		if err := rows.Scan(&userID, &total, &running); err != nil {
+			return nil, err
+		}
+		get(userID).Traders = total
+		get(userID).RunningTraders = running
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	backtestRows, err := d.db.Query(`SELECT user_id, COUNT(*) FROM backtest_runs GROUP BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer backtestRows.Close()
+	for backtestRows.Next() {
+		var userID string
+		var count int
+		if err := backtestRows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+		get(userID).BacktestRuns = count
+	}
+	return usage, backtestRows.Err()
+}
+
 // UpdateUserOTPVerified 更新用户OTP验证状态
 func (d *Database) UpdateUserOTPVerified(userID string, verified bool) error {
 	_, err := d.db.Exec(`UPDATE users SET otp_verified = ? WHERE id = ?`, verified, userID)
 	return err
 }
 
+// UpdateUserPassword 更新用户密码哈希
+func (d *Database) UpdateUserPassword(userID, passwordHash string) error {
+	_, err := d.db.Exec(`UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, userID)
+	return err
+}
+
+// SetPendingOTPSecret 保存待确认的新OTP密钥（确认前旧密钥仍然有效）
+func (d *Database) SetPendingOTPSecret(userID, secret string) error {
+	_, err := d.db.Exec(`UPDATE users SET pending_otp_secret = ? WHERE id = ?`, secret, userID)
+	return err
+}
+
+// ConfirmPendingOTPSecret 用待确认的密钥替换当前OTP密钥
+func (d *Database) ConfirmPendingOTPSecret(userID string) error {
+	result, err := d.db.Exec(`
+		UPDATE users SET otp_secret = pending_otp_secret, pending_otp_secret = '', otp_verified = TRUE
+		WHERE id = ? AND pending_otp_secret != ''
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes 用新的恢复码哈希替换用户现有的全部恢复码
+func (d *Database) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM otp_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO otp_recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode 核销一个未使用的恢复码，返回是否核销成功
+func (d *Database) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	result, err := d.db.Exec(`
+		UPDATE otp_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE id = (SELECT id FROM otp_recovery_codes WHERE user_id = ? AND code_hash = ? AND used_at IS NULL LIMIT 1)
+	`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// CountRecoveryCodes 剩余可用的恢复码数量
+func (d *Database) CountRecoveryCodes(userID string) (int, error) {
+	var count int
+	err := d.db.QueryRow(`SELECT COUNT(*) FROM otp_recovery_codes WHERE user_id = ? AND used_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
 // GetAIModels 获取用户的AI模型配置
 func (d *Database) GetAIModels(userID string) ([]*AIModelConfig, error) {
 	rows, err := d.db.Query(`
@@ -578,6 +1144,9 @@ func (d *Database) GetAIModels(userID string) ([]*AIModelConfig, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := d.decryptSecrets(&model.APIKey); err != nil {
+			return nil, fmt.Errorf("AI模型 %s: %w", model.ID, err)
+		}
 		models = append(models, &model)
 	}
 
@@ -586,6 +1155,10 @@ func (d *Database) GetAIModels(userID string) ([]*AIModelConfig, error) {
 
 // UpdateAIModel 更新AI模型配置，如果不存在则创建用户特定配置
 func (d *Database) UpdateAIModel(userID, id string, enabled bool, apiKey, customAPIURL, customModelName string) error {
+	if err := d.encryptSecrets(&apiKey); err != nil {
+		return err
+	}
+
 	// 先尝试精确匹配 ID（新版逻辑，支持多个相同 provider 的模型）
 	var existingID string
 	err := d.db.QueryRow(`
@@ -595,7 +1168,7 @@ func (d *Database) UpdateAIModel(userID, id string, enabled bool, apiKey, custom
 	if err == nil {
 		// 找到了现有配置（精确匹配 ID），更新它
 		_, err = d.db.Exec(`
-			UPDATE ai_models SET enabled = ?, api_key = ?, custom_api_url = ?, custom_model_name = ?, updated_at = datetime('now')
+			UPDATE ai_models SET enabled = ?, api_key = ?, custom_api_url = ?, custom_model_name = ?, updated_at = CURRENT_TIMESTAMP
 			WHERE id = ? AND user_id = ?
 		`, enabled, apiKey, customAPIURL, customModelName, existingID, userID)
 		return err
@@ -609,9 +1182,9 @@ func (d *Database) UpdateAIModel(userID, id string, enabled bool, apiKey, custom
 
 	if err == nil {
 		// 找到了现有配置（通过 provider 匹配，兼容旧版），更新它
-		log.Printf("⚠️  使用旧版 provider 匹配更新模型: %s -> %s", provider, existingID)
+		slog.Warn("使用旧版 provider 匹配更新模型", "user_id", userID, "provider", provider, "model_id", existingID)
 		_, err = d.db.Exec(`
-			UPDATE ai_models SET enabled = ?, api_key = ?, custom_api_url = ?, custom_model_name = ?, updated_at = datetime('now')
+			UPDATE ai_models SET enabled = ?, api_key = ?, custom_api_url = ?, custom_model_name = ?, updated_at = CURRENT_TIMESTAMP
 			WHERE id = ? AND user_id = ?
 		`, enabled, apiKey, customAPIURL, customModelName, existingID, userID)
 		return err
@@ -656,10 +1229,10 @@ func (d *Database) UpdateAIModel(userID, id string, enabled bool, apiKey, custom
 		newModelID = fmt.Sprintf("%s_%s", userID, provider)
 	}
 
-	log.Printf("✓ 创建新的 AI 模型配置: ID=%s, Provider=%s, Name=%s", newModelID, provider, name)
+	slog.Info("创建新的AI模型配置", "user_id", userID, "model_id", newModelID, "provider", provider, "name", name)
 	_, err = d.db.Exec(`
 		INSERT INTO ai_models (id, user_id, name, provider, enabled, api_key, custom_api_url, custom_model_name, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`, newModelID, userID, name, provider, enabled, apiKey, customAPIURL, customModelName)
 
 	return err
@@ -695,6 +1268,9 @@ func (d *Database) GetExchanges(userID string) ([]*ExchangeConfig, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := d.decryptSecrets(&exchange.APIKey, &exchange.SecretKey, &exchange.AsterPrivateKey); err != nil {
+			return nil, fmt.Errorf("交易所 %s: %w", exchange.ID, err)
+		}
 		exchanges = append(exchanges, &exchange)
 	}
 
@@ -703,31 +1279,36 @@ func (d *Database) GetExchanges(userID string) ([]*ExchangeConfig, error) {
 
 // UpdateExchange 更新交易所配置，如果不存在则创建用户特定配置
 func (d *Database) UpdateExchange(userID, id string, enabled bool, apiKey, secretKey string, testnet bool, hyperliquidWalletAddr, asterUser, asterSigner, asterPrivateKey string) error {
-	log.Printf("🔧 UpdateExchange: userID=%s, id=%s, enabled=%v", userID, id, enabled)
+	xlog := slog.With("user_id", userID, "exchange_id", id)
+	xlog.Debug("更新交易所配置", "enabled", enabled)
 
-	// 首先尝试更新现有的用户配置
-	result, err := d.db.Exec(`
+	if err := d.encryptSecrets(&apiKey, &secretKey, &asterPrivateKey); err != nil {
+		return err
+	}
+
+	// 首先尝试更新现有的用户配置
+	result, err := d.db.Exec(`
 		UPDATE exchanges SET enabled = ?, api_key = ?, secret_key = ?, testnet = ?, 
-		       hyperliquid_wallet_addr = ?, aster_user = ?, aster_signer = ?, aster_private_key = ?, updated_at = datetime('now')
+		       hyperliquid_wallet_addr = ?, aster_user = ?, aster_signer = ?, aster_private_key = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ? AND user_id = ?
 	`, enabled, apiKey, secretKey, testnet, hyperliquidWalletAddr, asterUser, asterSigner, asterPrivateKey, id, userID)
 	if err != nil {
-		log.Printf("❌ UpdateExchange: 更新失败: %v", err)
+		xlog.Error("更新交易所配置失败", "error", err)
 		return err
 	}
 
 	// 检查是否有行被更新
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		log.Printf("❌ UpdateExchange: 获取影响行数失败: %v", err)
+		xlog.Error("更新交易所配置：获取影响行数失败", "error", err)
 		return err
 	}
 
-	log.Printf("📊 UpdateExchange: 影响行数 = %d", rowsAffected)
+	xlog.Debug("更新交易所配置", "rows_affected", rowsAffected)
 
 	// 如果没有行被更新，说明用户没有这个交易所的配置，需要创建
 	if rowsAffected == 0 {
-		log.Printf("💡 UpdateExchange: 没有现有记录，创建新记录")
+		xlog.Debug("交易所配置没有现有记录，创建新记录")
 
 		// 根据交易所ID确定基本信息
 		var name, typ string
@@ -740,46 +1321,57 @@ func (d *Database) UpdateExchange(userID, id string, enabled bool, apiKey, secre
 		} else if id == "aster" {
 			name = "Aster DEX"
 			typ = "dex"
+		} else if id == "paper" {
+			name = "Paper Trading"
+			typ = "paper"
 		} else {
 			name = id + " Exchange"
 			typ = "cex"
 		}
 
-		log.Printf("🆕 UpdateExchange: 创建新记录 ID=%s, name=%s, type=%s", id, name, typ)
+		xlog.Info("创建交易所配置", "name", name, "type", typ)
 
 		// 创建用户特定的配置，使用原始的交易所ID
 		_, err = d.db.Exec(`
 			INSERT INTO exchanges (id, user_id, name, type, enabled, api_key, secret_key, testnet, 
 			                       hyperliquid_wallet_addr, aster_user, aster_signer, aster_private_key, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		`, id, userID, name, typ, enabled, apiKey, secretKey, testnet, hyperliquidWalletAddr, asterUser, asterSigner, asterPrivateKey)
 
 		if err != nil {
-			log.Printf("❌ UpdateExchange: 创建记录失败: %v", err)
+			xlog.Error("创建交易所配置失败", "error", err)
 		} else {
-			log.Printf("✅ UpdateExchange: 创建记录成功")
+			xlog.Info("创建交易所配置成功")
 		}
 		return err
 	}
 
-	log.Printf("✅ UpdateExchange: 更新现有记录成功")
+	xlog.Info("更新交易所配置成功")
 	return nil
 }
 
 // CreateAIModel 创建AI模型配置
 func (d *Database) CreateAIModel(userID, id, name, provider string, enabled bool, apiKey, customAPIURL string) error {
+	if err := d.encryptSecrets(&apiKey); err != nil {
+		return err
+	}
 	_, err := d.db.Exec(`
-		INSERT OR IGNORE INTO ai_models (id, user_id, name, provider, enabled, api_key, custom_api_url) 
+		INSERT INTO ai_models (id, user_id, name, provider, enabled, api_key, custom_api_url)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING
 	`, id, userID, name, provider, enabled, apiKey, customAPIURL)
 	return err
 }
 
 // CreateExchange 创建交易所配置
 func (d *Database) CreateExchange(userID, id, name, typ string, enabled bool, apiKey, secretKey string, testnet bool, hyperliquidWalletAddr, asterUser, asterSigner, asterPrivateKey string) error {
+	if err := d.encryptSecrets(&apiKey, &secretKey, &asterPrivateKey); err != nil {
+		return err
+	}
 	_, err := d.db.Exec(`
-		INSERT OR IGNORE INTO exchanges (id, user_id, name, type, enabled, api_key, secret_key, testnet, hyperliquid_wallet_addr, aster_user, aster_signer, aster_private_key) 
+		INSERT INTO exchanges (id, user_id, name, type, enabled, api_key, secret_key, testnet, hyperliquid_wallet_addr, aster_user, aster_signer, aster_private_key)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING
 	`, id, userID, name, typ, enabled, apiKey, secretKey, testnet, hyperliquidWalletAddr, asterUser, asterSigner, asterPrivateKey)
 	return err
 }
@@ -787,9 +1379,9 @@ func (d *Database) CreateExchange(userID, id, name, typ string, enabled bool, ap
 // CreateTrader 创建交易员
 func (d *Database) CreateTrader(trader *TraderRecord) error {
 	_, err := d.db.Exec(`
-		INSERT INTO traders (id, user_id, name, ai_model_id, exchange_id, initial_balance, scan_interval_minutes, is_running, btc_eth_leverage, altcoin_leverage, trading_symbols, use_coin_pool, use_oi_top, custom_prompt, override_base_prompt, system_prompt_template, is_cross_margin)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, trader.ID, trader.UserID, trader.Name, trader.AIModelID, trader.ExchangeID, trader.InitialBalance, trader.ScanIntervalMinutes, trader.IsRunning, trader.BTCETHLeverage, trader.AltcoinLeverage, trader.TradingSymbols, trader.UseCoinPool, trader.UseOITop, trader.CustomPrompt, trader.OverrideBasePrompt, trader.SystemPromptTemplate, trader.IsCrossMargin)
+		INSERT INTO traders (id, user_id, name, ai_model_id, exchange_id, initial_balance, scan_interval_minutes, is_running, btc_eth_leverage, altcoin_leverage, trading_symbols, use_coin_pool, use_oi_top, custom_prompt, override_base_prompt, system_prompt_template, is_cross_margin, max_candidates, pool_refresh_minutes, liquidity_filter, min_oi_value_millions, min_volume_millions)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, trader.ID, trader.UserID, trader.Name, trader.AIModelID, trader.ExchangeID, trader.InitialBalance, trader.ScanIntervalMinutes, trader.IsRunning, trader.BTCETHLeverage, trader.AltcoinLeverage, trader.TradingSymbols, trader.UseCoinPool, trader.UseOITop, trader.CustomPrompt, trader.OverrideBasePrompt, trader.SystemPromptTemplate, trader.IsCrossMargin, trader.MaxCandidates, trader.PoolRefreshMinutes, trader.LiquidityFilter, trader.MinOIValueMillions, trader.MinVolumeMillions)
 	return err
 }
 
@@ -799,10 +1391,14 @@ func (d *Database) GetTraders(userID string) ([]*TraderRecord, error) {
 		SELECT id, user_id, name, ai_model_id, exchange_id, initial_balance, scan_interval_minutes, is_running,
 		       COALESCE(btc_eth_leverage, 5) as btc_eth_leverage, COALESCE(altcoin_leverage, 5) as altcoin_leverage,
 		       COALESCE(trading_symbols, '') as trading_symbols,
-		       COALESCE(use_coin_pool, 0) as use_coin_pool, COALESCE(use_oi_top, 0) as use_oi_top,
-		       COALESCE(custom_prompt, '') as custom_prompt, COALESCE(override_base_prompt, 0) as override_base_prompt,
+		       COALESCE(use_coin_pool, FALSE) as use_coin_pool, COALESCE(use_oi_top, FALSE) as use_oi_top,
+		       COALESCE(custom_prompt, '') as custom_prompt, COALESCE(override_base_prompt, FALSE) as override_base_prompt,
 		       COALESCE(system_prompt_template, 'default') as system_prompt_template,
-		       COALESCE(is_cross_margin, 1) as is_cross_margin, created_at, updated_at
+		       COALESCE(is_cross_margin, TRUE) as is_cross_margin,
+		       COALESCE(max_candidates, 0) as max_candidates, COALESCE(pool_refresh_minutes, 0) as pool_refresh_minutes,
+		       COALESCE(liquidity_filter, '') as liquidity_filter,
+		       COALESCE(min_oi_value_millions, 0) as min_oi_value_millions, COALESCE(min_volume_millions, 0) as min_volume_millions,
+		       created_at, updated_at
 		FROM traders WHERE user_id = ? ORDER BY created_at DESC
 	`, userID)
 	if err != nil {
@@ -820,6 +1416,8 @@ func (d *Database) GetTraders(userID string) ([]*TraderRecord, error) {
 			&trader.UseCoinPool, &trader.UseOITop,
 			&trader.CustomPrompt, &trader.OverrideBasePrompt, &trader.SystemPromptTemplate,
 			&trader.IsCrossMargin,
+			&trader.MaxCandidates, &trader.PoolRefreshMinutes,
+			&trader.LiquidityFilter, &trader.MinOIValueMillions, &trader.MinVolumeMillions,
 			&trader.CreatedAt, &trader.UpdatedAt,
 		)
 		if err != nil {
@@ -831,6 +1429,61 @@ func (d *Database) GetTraders(userID string) ([]*TraderRecord, error) {
 	return traders, nil
 }
 
+// GetRunningTraders 所有标记为运行中的交易员（按创建时间，用于启动时恢复）
+func (d *Database) GetRunningTraders() ([]*TraderRecord, error) {
+	rows, err := d.db.Query(`
+		SELECT id, user_id, name, ai_model_id, exchange_id, initial_balance, is_running
+		FROM traders WHERE is_running = TRUE ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traders []*TraderRecord
+	for rows.Next() {
+		var trader TraderRecord
+		if err := rows.Scan(&trader.ID, &trader.UserID, &trader.Name, &trader.AIModelID, &trader.ExchangeID,
+			&trader.InitialBalance, &trader.IsRunning); err != nil {
+			return nil, err
+		}
+		traders = append(traders, &trader)
+	}
+	return traders, rows.Err()
+}
+
+// ListAllTraders 所有用户的交易员（按创建时间，管理员查看）
+func (d *Database) ListAllTraders() ([]*TraderRecord, error) {
+	rows, err := d.db.Query(`
+		SELECT id, user_id, name, ai_model_id, exchange_id, initial_balance, is_running, created_at
+		FROM traders ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traders []*TraderRecord
+	for rows.Next() {
+		var trader TraderRecord
+		if err := rows.Scan(&trader.ID, &trader.UserID, &trader.Name, &trader.AIModelID, &trader.ExchangeID,
+			&trader.InitialBalance, &trader.IsRunning, &trader.CreatedAt); err != nil {
+			return nil, err
+		}
+		traders = append(traders, &trader)
+	}
+	return traders, rows.Err()
+}
+
+// ClearRunningTraders 把所有交易员标记为已停止，返回修改的数量
+func (d *Database) ClearRunningTraders() (int64, error) {
+	result, err := d.db.Exec(`UPDATE traders SET is_running = FALSE WHERE is_running = TRUE`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // UpdateTraderStatus 更新交易员状态
 func (d *Database) UpdateTraderStatus(userID, id string, isRunning bool) error {
 	_, err := d.db.Exec(`UPDATE traders SET is_running = ? WHERE id = ? AND user_id = ?`, isRunning, id, userID)
@@ -845,13 +1498,15 @@ func (d *Database) UpdateTrader(trader *TraderRecord) error {
 			scan_interval_minutes = ?, btc_eth_leverage = ?, altcoin_leverage = ?,
 			trading_symbols = ?, custom_prompt = ?, override_base_prompt = ?,
 			system_prompt_template = ?, is_cross_margin = ?, use_coin_pool = ?, use_oi_top = ?,
-			binance_proxy_url = ?, updated_at = CURRENT_TIMESTAMP
+			binance_proxy_url = ?, max_candidates = ?, pool_refresh_minutes = ?,
+			liquidity_filter = ?, min_oi_value_millions = ?, min_volume_millions = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ? AND user_id = ?
 	`, trader.Name, trader.AIModelID, trader.ExchangeID, trader.InitialBalance,
 		trader.ScanIntervalMinutes, trader.BTCETHLeverage, trader.AltcoinLeverage,
 		trader.TradingSymbols, trader.CustomPrompt, trader.OverrideBasePrompt,
 		trader.SystemPromptTemplate, trader.IsCrossMargin, trader.UseCoinPool, trader.UseOITop,
-		trader.BinanceProxyURL, trader.ID, trader.UserID)
+		trader.BinanceProxyURL, trader.MaxCandidates, trader.PoolRefreshMinutes,
+		trader.LiquidityFilter, trader.MinOIValueMillions, trader.MinVolumeMillions, trader.ID, trader.UserID)
 	return err
 }
 
@@ -863,10 +1518,286 @@ func (d *Database) UpdateTraderCustomPrompt(userID, id string, customPrompt stri
 
 // DeleteTrader 删除交易员
 func (d *Database) DeleteTrader(userID, id string) error {
-	_, err := d.db.Exec(`DELETE FROM traders WHERE id = ? AND user_id = ?`, id, userID)
+	result, err := d.db.Exec(`DELETE FROM traders WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	// SQLite未开启外键约束，手动清理共享记录、分享链接、交易时段、信号密钥和只推送该交易员事件的webhook
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		if _, err = d.db.Exec(`DELETE FROM trader_shares WHERE trader_id = ?`, id); err != nil {
+			return err
+		}
+		if _, err = d.db.Exec(`DELETE FROM trader_share_links WHERE trader_id = ?`, id); err != nil {
+			return err
+		}
+		if _, err = d.db.Exec(`DELETE FROM webhooks WHERE trader_id = ?`, id); err != nil {
+			return err
+		}
+		if _, err = d.db.Exec(`DELETE FROM trader_schedules WHERE trader_id = ?`, id); err != nil {
+			return err
+		}
+		if _, err = d.db.Exec(`DELETE FROM trader_tags WHERE trader_id = ?`, id); err != nil {
+			return err
+		}
+		if _, err = d.db.Exec(`DELETE FROM trader_signal_keys WHERE trader_id = ?`, id); err != nil {
+			return err
+		}
+		if _, err = d.db.Exec(`DELETE FROM trader_signal_sources WHERE trader_id = ?`, id); err != nil {
+			return err
+		}
+		if _, err = d.db.Exec(`DELETE FROM order_events WHERE client_order_id IN (SELECT client_order_id FROM orders WHERE trader_id = ?)`, id); err != nil {
+			return err
+		}
+		if _, err = d.db.Exec(`DELETE FROM orders WHERE trader_id = ?`, id); err != nil {
+			return err
+		}
+		if _, err = d.db.Exec(`DELETE FROM position_history WHERE trader_id = ?`, id); err != nil {
+			return err
+		}
+		_, err = d.db.Exec(`DELETE FROM follows WHERE leader_id = ?`, id)
+	}
+	return err
+}
+
+// DeletedTraderName 注销账户后已归档赛季排名中交易员的显示名称
+const DeletedTraderName = "已注销用户"
+
+// accountTables 带 user_id 列、注销账户时按用户删除的表
+var accountTables = []string{
+	"ai_models", "exchanges", "user_signal_sources", "traders", "backtest_runs", "otp_recovery_codes",
+	"trader_shares", "trader_share_links", "webhooks", "telegram_links", "telegram_link_tokens", "daily_digests",
+	"follows", "user_limits", "trader_schedules", "trader_tags", "trader_signal_keys", "trader_signal_sources", "orders",
+	"position_history",
+}
+
+// AnonymousTraderID 注销账户后已归档排名中交易员ID的替代值（同一交易员总是得到相同的值）
+func AnonymousTraderID(traderID string) string {
+	sum := sha256.Sum256([]byte(traderID))
+	return "deleted-" + hex.EncodeToString(sum[:6])
+}
+
+// DeleteUserAccount 注销账户：删除用户、API密钥等全部配置、交易记录和数据库中的决策日志，
+// 已归档赛季的排名保留但匿名化。返回被删除的交易员ID，由调用方停止交易员并清理日志文件
+func (d *Database) DeleteUserAccount(userID string) ([]string, error) {
+	user, err := d.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	traders, err := d.GetTraders(userID)
+	if err != nil {
+		return nil, err
+	}
+	traderIDs := make([]string, len(traders))
+	for i, t := range traders {
+		traderIDs[i] = t.ID
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, id := range traderIDs {
+		// 其他用户对这些交易员的共享、跟单和只推送这些交易员事件的webhook一并删除
+		for _, query := range []string{
+			`DELETE FROM trader_shares WHERE trader_id = ?`,
+			`DELETE FROM webhooks WHERE trader_id = ?`,
+			`DELETE FROM follows WHERE leader_id = ?`,
+			`DELETE FROM order_events WHERE client_order_id IN (SELECT client_order_id FROM orders WHERE trader_id = ?)`,
+			`DELETE FROM decision_records WHERE trader_id = ?`,
+			`DELETE FROM decision_equity_archive WHERE trader_id = ?`,
+			`DELETE FROM decision_search WHERE trader_id = ?`,
+			`DELETE FROM equity_samples WHERE trader_id = ?`,
+		} {
+			if _, err := tx.Exec(query, id); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.Exec(`UPDATE season_results SET trader_id = ?, trader_name = ? WHERE trader_id = ?`,
+			AnonymousTraderID(id), DeletedTraderName, id); err != nil {
+			return nil, err
+		}
+	}
+	for _, table := range accountTables {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE user_id = ?`, userID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE beta_codes SET used_by = '' WHERE used_by = ?`, user.Email); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM users WHERE id = ?`, userID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return traderIDs, nil
+}
+
+// TraderShare 交易员共享记录
+type TraderShare struct {
+	TraderID  string    `json:"trader_id"`
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShareTrader 将交易员只读共享给指定用户
+func (d *Database) ShareTrader(traderID, userID string) error {
+	_, err := d.db.Exec(`INSERT INTO trader_shares (trader_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING`, traderID, userID)
+	return err
+}
+
+// UnshareTrader 取消共享
+func (d *Database) UnshareTrader(traderID, userID string) error {
+	_, err := d.db.Exec(`DELETE FROM trader_shares WHERE trader_id = ? AND user_id = ?`, traderID, userID)
+	return err
+}
+
+// GetTraderShares 交易员共享给了哪些用户
+func (d *Database) GetTraderShares(traderID string) ([]*TraderShare, error) {
+	rows, err := d.db.Query(`
+		SELECT s.trader_id, s.user_id, u.email, s.created_at
+		FROM trader_shares s JOIN users u ON u.id = s.user_id
+		WHERE s.trader_id = ? ORDER BY s.created_at
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := make([]*TraderShare, 0)
+	for rows.Next() {
+		var share TraderShare
+		if err := rows.Scan(&share.TraderID, &share.UserID, &share.Email, &share.CreatedAt); err != nil {
+			return nil, err
+		}
+		shares = append(shares, &share)
+	}
+	return shares, rows.Err()
+}
+
+// ShareLink 交易员只读分享链接（token只在创建时返回）
+type ShareLink struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	TraderID   string     `json:"trader_id"`
+	Label      string     `json:"label"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// CreateShareLink 保存分享链接
+func (d *Database) CreateShareLink(link *ShareLink, tokenHash string) error {
+	_, err := d.db.Exec(`
+		INSERT INTO trader_share_links (id, token_hash, user_id, trader_id, label) VALUES (?, ?, ?, ?, ?)
+	`, link.ID, tokenHash, link.UserID, link.TraderID, link.Label)
 	return err
 }
 
+// GetShareLinks 交易员的所有分享链接
+func (d *Database) GetShareLinks(userID, traderID string) ([]*ShareLink, error) {
+	rows, err := d.db.Query(`
+		SELECT id, user_id, trader_id, label, created_at, last_used_at
+		FROM trader_share_links WHERE user_id = ? AND trader_id = ? ORDER BY created_at
+	`, userID, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*ShareLink{}
+	for rows.Next() {
+		var l ShareLink
+		var used sql.NullTime
+		if err := rows.Scan(&l.ID, &l.UserID, &l.TraderID, &l.Label, &l.CreatedAt, &used); err != nil {
+			return nil, err
+		}
+		if used.Valid {
+			l.LastUsedAt = &used.Time
+		}
+		links = append(links, &l)
+	}
+	return links, rows.Err()
+}
+
+// DeleteShareLink 撤销分享链接
+func (d *Database) DeleteShareLink(userID, traderID, id string) error {
+	result, err := d.db.Exec(`DELETE FROM trader_share_links WHERE id = ? AND user_id = ? AND trader_id = ?`, id, userID, traderID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UseShareLink 按token哈希查找分享链接并记录访问时间，不存在时返回 sql.ErrNoRows
+func (d *Database) UseShareLink(tokenHash string) (*ShareLink, error) {
+	var l ShareLink
+	err := d.db.QueryRow(`
+		SELECT id, user_id, trader_id, label, created_at FROM trader_share_links WHERE token_hash = ?
+	`, tokenHash).Scan(&l.ID, &l.UserID, &l.TraderID, &l.Label, &l.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if _, err := d.db.Exec(`UPDATE trader_share_links SET last_used_at = ? WHERE id = ?`, now, l.ID); err != nil {
+		return nil, err
+	}
+	l.LastUsedAt = &now
+	return &l, nil
+}
+
+// GetSharedTraders 共享给该用户的交易员（只包含列表展示需要的字段）
+func (d *Database) GetSharedTraders(userID string) ([]*TraderRecord, error) {
+	rows, err := d.db.Query(`
+		SELECT t.id, t.user_id, t.name, t.ai_model_id, t.exchange_id, t.initial_balance, t.is_running
+		FROM traders t JOIN trader_shares s ON s.trader_id = t.id
+		WHERE s.user_id = ? ORDER BY s.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traders []*TraderRecord
+	for rows.Next() {
+		var trader TraderRecord
+		if err := rows.Scan(&trader.ID, &trader.UserID, &trader.Name, &trader.AIModelID, &trader.ExchangeID,
+			&trader.InitialBalance, &trader.IsRunning); err != nil {
+			return nil, err
+		}
+		traders = append(traders, &trader)
+	}
+	return traders, rows.Err()
+}
+
+// GetTraderOwner 交易员所属用户ID
+func (d *Database) GetTraderOwner(traderID string) (string, error) {
+	var userID string
+	err := d.db.QueryRow(`SELECT user_id FROM traders WHERE id = ?`, traderID).Scan(&userID)
+	return userID, err
+}
+
+// CanViewTrader 用户是否可以查看该交易员（自己的或共享给自己的）
+func (d *Database) CanViewTrader(userID, traderID string) (bool, error) {
+	var count int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) FROM traders WHERE id = ? AND user_id = ?
+	`, traderID, userID).Scan(&count)
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	err = d.db.QueryRow(`
+		SELECT COUNT(*) FROM trader_shares WHERE trader_id = ? AND user_id = ?
+	`, traderID, userID).Scan(&count)
+	return count > 0, err
+}
+
 // GetTraderConfig 获取交易员完整配置（包含AI模型和交易所信息）
 func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIModelConfig, *ExchangeConfig, error) {
 	var trader TraderRecord
@@ -876,6 +1807,8 @@ func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIM
 	err := d.db.QueryRow(`
 		SELECT 
 			t.id, t.user_id, t.name, t.ai_model_id, t.exchange_id, t.initial_balance, t.scan_interval_minutes, t.is_running, t.created_at, t.updated_at,
+			COALESCE(t.max_candidates, 0), COALESCE(t.pool_refresh_minutes, 0),
+			COALESCE(t.liquidity_filter, ''), COALESCE(t.min_oi_value_millions, 0), COALESCE(t.min_volume_millions, 0),
 			a.id, a.user_id, a.name, a.provider, a.enabled, a.api_key, a.created_at, a.updated_at,
 			e.id, e.user_id, e.name, e.type, e.enabled, e.api_key, e.secret_key, e.testnet,
 			COALESCE(e.hyperliquid_wallet_addr, '') as hyperliquid_wallet_addr,
@@ -891,6 +1824,8 @@ func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIM
 		&trader.ID, &trader.UserID, &trader.Name, &trader.AIModelID, &trader.ExchangeID,
 		&trader.InitialBalance, &trader.ScanIntervalMinutes, &trader.IsRunning,
 		&trader.CreatedAt, &trader.UpdatedAt,
+		&trader.MaxCandidates, &trader.PoolRefreshMinutes,
+		&trader.LiquidityFilter, &trader.MinOIValueMillions, &trader.MinVolumeMillions,
 		&aiModel.ID, &aiModel.UserID, &aiModel.Name, &aiModel.Provider, &aiModel.Enabled, &aiModel.APIKey,
 		&aiModel.CreatedAt, &aiModel.UpdatedAt,
 		&exchange.ID, &exchange.UserID, &exchange.Name, &exchange.Type, &exchange.Enabled,
@@ -902,6 +1837,9 @@ func (d *Database) GetTraderConfig(userID, traderID string) (*TraderRecord, *AIM
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	if err := d.decryptSecrets(&aiModel.APIKey, &exchange.APIKey, &exchange.SecretKey, &exchange.AsterPrivateKey); err != nil {
+		return nil, nil, nil, err
+	}
 
 	return &trader, &aiModel, &exchange, nil
 }
@@ -916,16 +1854,118 @@ func (d *Database) GetSystemConfig(key string) (string, error) {
 // SetSystemConfig 设置系统配置
 func (d *Database) SetSystemConfig(key, value string) error {
 	_, err := d.db.Exec(`
-		INSERT OR REPLACE INTO system_config (key, value) VALUES (?, ?)
+		INSERT INTO system_config (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
 	`, key, value)
 	return err
 }
 
+// GetAllSystemConfig 获取全部系统配置
+func (d *Database) GetAllSystemConfig() (map[string]string, error) {
+	rows, err := d.db.Query(`SELECT key, value FROM system_config`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	configs := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		configs[key] = value
+	}
+	return configs, rows.Err()
+}
+
+// SystemConfigChange 一条系统配置修改记录
+type SystemConfigChange struct {
+	ID        int64     `json:"id"`
+	Key       string    `json:"key"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// UpdateSystemConfigs 在一个事务中修改多项系统配置并写入修改记录，值未变化的项跳过
+// 返回实际发生变化的记录，敏感配置的新旧值在记录中脱敏
+func (d *Database) UpdateSystemConfigs(changes map[string]string, changedBy string) ([]SystemConfigChange, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var applied []SystemConfigChange
+	for _, key := range keys {
+		value := changes[key]
+		var oldValue string
+		err := tx.QueryRow(`SELECT value FROM system_config WHERE key = ?`, key).Scan(&oldValue)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("读取配置 %s 失败: %w", key, err)
+		}
+		if err == nil && oldValue == value {
+			continue
+		}
+
+		if _, err := tx.Exec(`INSERT INTO system_config (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value`, key, value); err != nil {
+			return nil, fmt.Errorf("保存配置 %s 失败: %w", key, err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO system_config_audit (key, old_value, new_value, changed_by) VALUES (?, ?, ?, ?)
+		`, key, MaskSystemConfig(key, oldValue), MaskSystemConfig(key, value), changedBy); err != nil {
+			return nil, fmt.Errorf("写入修改记录失败: %w", err)
+		}
+		applied = append(applied, SystemConfigChange{
+			Key:       key,
+			OldValue:  MaskSystemConfig(key, oldValue),
+			NewValue:  MaskSystemConfig(key, value),
+			ChangedBy: changedBy,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// GetSystemConfigAudit 最近的系统配置修改记录，最新的在前
+func (d *Database) GetSystemConfigAudit(limit int) ([]SystemConfigChange, error) {
+	rows, err := d.db.Query(`
+		SELECT id, key, old_value, new_value, changed_by, changed_at
+		FROM system_config_audit ORDER BY id DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []SystemConfigChange{}
+	for rows.Next() {
+		var c SystemConfigChange
+		if err := rows.Scan(&c.ID, &c.Key, &c.OldValue, &c.NewValue, &c.ChangedBy, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
 // CreateUserSignalSource 创建用户信号源配置
 func (d *Database) CreateUserSignalSource(userID, coinPoolURL, oiTopURL string) error {
 	_, err := d.db.Exec(`
-		INSERT OR REPLACE INTO user_signal_sources (user_id, coin_pool_url, oi_top_url, updated_at)
+		INSERT INTO user_signal_sources (user_id, coin_pool_url, oi_top_url, updated_at)
 		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id) DO UPDATE SET coin_pool_url = excluded.coin_pool_url, oi_top_url = excluded.oi_top_url, updated_at = excluded.updated_at
 	`, userID, coinPoolURL, oiTopURL)
 	return err
 }
@@ -957,17 +1997,23 @@ func (d *Database) UpdateUserSignalSource(userID, coinPoolURL, oiTopURL string)
 
 // GetCustomCoins 获取所有交易员自定义币种 / Get all trader-customized currencies
 func (d *Database) GetCustomCoins() []string {
-	var symbol string
+	var customCoins []string
+	if rows, err := d.db.Query(`SELECT custom_coins FROM traders WHERE custom_coins != ''`); err == nil {
+		for rows.Next() {
+			var coins string
+			if rows.Scan(&coins) == nil {
+				customCoins = append(customCoins, coins)
+			}
+		}
+		rows.Close()
+	}
+	symbol := strings.Join(customCoins, ",")
 	var symbols []string
-	_ = d.db.QueryRow(`
-		SELECT GROUP_CONCAT(custom_coins , ',') as symbol
-		FROM main.traders where custom_coins != ''
-	`).Scan(&symbol)
 	// 检测用户是否未配置币种 - 兼容性
 	if symbol == "" {
 		symbolJSON, _ := d.GetSystemConfig("default_coins")
 		if err := json.Unmarshal([]byte(symbolJSON), &symbols); err != nil {
-			log.Printf("⚠️  解析default_coins配置失败: %v，使用硬编码默认值", err)
+			slog.Warn("解析default_coins配置失败，使用硬编码默认值", "error", err)
 			symbols = []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "BNBUSDT"}
 		}
 	}
@@ -984,37 +2030,1552 @@ func (d *Database) GetCustomCoins() []string {
 	return symbols
 }
 
-// Close 关闭数据库连接
-func (d *Database) Close() error {
-	return d.db.Close()
+// CreateBacktestRun 保存回测记录
+func (d *Database) CreateBacktestRun(run *BacktestRun) error {
+	_, err := d.db.Exec(`
+		INSERT INTO backtest_runs (id, user_id, trader_id, mode, period_from, period_to, cycles, config, summary, results)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.ID, run.UserID, run.TraderID, run.Mode, run.PeriodFrom, run.PeriodTo, run.Cycles, string(run.Config), string(run.Summary), string(run.Results))
+	return err
 }
 
-// LoadBetaCodesFromFile 从文件加载内测码到数据库
-func (d *Database) LoadBetaCodesFromFile(filePath string) error {
-	// 读取文件内容
-	content, err := os.ReadFile(filePath)
+// GetBacktestRuns 获取用户的回测记录列表（不含完整结果，traderID为空时返回全部）
+func (d *Database) GetBacktestRuns(userID, traderID string) ([]*BacktestRun, error) {
+	rows, err := d.db.Query(`
+		SELECT id, user_id, trader_id, mode, period_from, period_to, cycles, config, summary, created_at
+		FROM backtest_runs WHERE user_id = ? AND (? = '' OR trader_id = ?) ORDER BY created_at DESC
+	`, userID, traderID, traderID)
 	if err != nil {
-		return fmt.Errorf("读取内测码文件失败: %w", err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	// 按行分割内测码
-	lines := strings.Split(string(content), "\n")
-	var codes []string
-	for _, line := range lines {
-		code := strings.TrimSpace(line)
-		if code != "" && !strings.HasPrefix(code, "#") {
-			codes = append(codes, code)
+	runs := []*BacktestRun{}
+	for rows.Next() {
+		var run BacktestRun
+		var configJSON, summaryJSON string
+		err := rows.Scan(
+			&run.ID, &run.UserID, &run.TraderID, &run.Mode, &run.PeriodFrom, &run.PeriodTo,
+			&run.Cycles, &configJSON, &summaryJSON, &run.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
 		}
+		run.Config = json.RawMessage(configJSON)
+		run.Summary = json.RawMessage(summaryJSON)
+		runs = append(runs, &run)
 	}
 
-	// 批量插入内测码
-	tx, err := d.db.Begin()
-	if err != nil {
-		return fmt.Errorf("开始事务失败: %w", err)
-	}
-	defer tx.Rollback()
+	return runs, rows.Err()
+}
 
-	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO beta_codes (code) VALUES (?)`)
+// GetBacktestRun 获取单条回测记录（含完整结果）
+func (d *Database) GetBacktestRun(userID, id string) (*BacktestRun, error) {
+	var run BacktestRun
+	var configJSON, summaryJSON, resultsJSON string
+	err := d.db.QueryRow(`
+		SELECT id, user_id, trader_id, mode, period_from, period_to, cycles, config, summary, results, created_at
+		FROM backtest_runs WHERE id = ? AND user_id = ?
+	`, id, userID).Scan(
+		&run.ID, &run.UserID, &run.TraderID, &run.Mode, &run.PeriodFrom, &run.PeriodTo,
+		&run.Cycles, &configJSON, &summaryJSON, &resultsJSON, &run.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	run.Config = json.RawMessage(configJSON)
+	run.Summary = json.RawMessage(summaryJSON)
+	run.Results = json.RawMessage(resultsJSON)
+	return &run, nil
+}
+
+// DeleteBacktestRun 删除回测记录
+func (d *Database) DeleteBacktestRun(userID, id string) error {
+	result, err := d.db.Exec(`DELETE FROM backtest_runs WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Webhook 用户注册的事件推送地址
+type Webhook struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	URL             string     `json:"url"`
+	Secret          string     `json:"-"`
+	TraderID        string     `json:"trader_id"` // 为空表示所有交易员
+	Events          []string   `json:"events"`    // 为空表示所有事件
+	LastStatus      int        `json:"last_status"`
+	LastError       string     `json:"last_error"`
+	LastDeliveredAt *time.Time `json:"last_delivered_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Matches webhook是否订阅了该交易员的该事件
+func (w *Webhook) Matches(traderID, event string) bool {
+	if w.TraderID != "" && w.TraderID != traderID {
+		return false
+	}
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// CreateWebhook 注册webhook
+func (d *Database) CreateWebhook(w *Webhook) error {
+	_, err := d.db.Exec(`
+		INSERT INTO webhooks (id, user_id, url, secret, trader_id, events) VALUES (?, ?, ?, ?, ?, ?)
+	`, w.ID, w.UserID, w.URL, w.Secret, w.TraderID, strings.Join(w.Events, ","))
+	return err
+}
+
+// GetWebhooks 获取用户注册的所有webhook
+func (d *Database) GetWebhooks(userID string) ([]*Webhook, error) {
+	rows, err := d.db.Query(`
+		SELECT id, user_id, url, secret, trader_id, events, last_status, last_error, last_delivered_at, created_at
+		FROM webhooks WHERE user_id = ? ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []*Webhook{}
+	for rows.Next() {
+		var w Webhook
+		var events string
+		var delivered sql.NullTime
+		if err := rows.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &w.TraderID, &events,
+			&w.LastStatus, &w.LastError, &delivered, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		w.Events = []string{}
+		if events != "" {
+			w.Events = strings.Split(events, ",")
+		}
+		if delivered.Valid {
+			w.LastDeliveredAt = &delivered.Time
+		}
+		hooks = append(hooks, &w)
+	}
+	return hooks, rows.Err()
+}
+
+// GetWebhook 获取用户的单个webhook
+func (d *Database) GetWebhook(userID, id string) (*Webhook, error) {
+	hooks, err := d.GetWebhooks(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range hooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// DeleteWebhook 删除webhook
+func (d *Database) DeleteWebhook(userID, id string) error {
+	result, err := d.db.Exec(`DELETE FROM webhooks WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordWebhookDelivery 记录最近一次投递结果
+func (d *Database) RecordWebhookDelivery(id string, status int, errMsg string) error {
+	_, err := d.db.Exec(`
+		UPDATE webhooks SET last_status = ?, last_error = ?, last_delivered_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, status, errMsg, id)
+	return err
+}
+
+// TelegramLink 用户绑定的Telegram聊天
+type TelegramLink struct {
+	UserID   string    `json:"user_id"`
+	ChatID   int64     `json:"chat_id"`
+	Username string    `json:"username"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+// CreateTelegramLinkToken 保存绑定口令哈希，同时作废该用户之前的口令
+func (d *Database) CreateTelegramLinkToken(userID, tokenHash string, expiresAt time.Time) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM telegram_link_tokens WHERE user_id = ? OR expires_at < ?`, userID, time.Now()); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO telegram_link_tokens (token_hash, user_id, expires_at) VALUES (?, ?, ?)
+	`, tokenHash, userID, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumeTelegramLinkToken 使用绑定口令（一次性），过期或不存在返回 sql.ErrNoRows
+func (d *Database) ConsumeTelegramLinkToken(tokenHash string) (string, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	var expiresAt time.Time
+	err = tx.QueryRow(`SELECT user_id, expires_at FROM telegram_link_tokens WHERE token_hash = ?`, tokenHash).Scan(&userID, &expiresAt)
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(`DELETE FROM telegram_link_tokens WHERE token_hash = ?`, tokenHash); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	if time.Now().After(expiresAt) {
+		return "", sql.ErrNoRows
+	}
+	return userID, nil
+}
+
+// LinkTelegramChat 绑定用户和Telegram聊天（覆盖用户之前的绑定，聊天之前绑定的其他用户会被解绑）
+func (d *Database) LinkTelegramChat(userID string, chatID int64, username string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM telegram_links WHERE user_id = ? OR chat_id = ?`, userID, chatID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO telegram_links (user_id, chat_id, username) VALUES (?, ?, ?)
+	`, userID, chatID, username); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetTelegramLink 获取用户的Telegram绑定
+func (d *Database) GetTelegramLink(userID string) (*TelegramLink, error) {
+	var link TelegramLink
+	err := d.db.QueryRow(`
+		SELECT user_id, chat_id, username, linked_at FROM telegram_links WHERE user_id = ?
+	`, userID).Scan(&link.UserID, &link.ChatID, &link.Username, &link.LinkedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// GetUserByTelegramChat 根据聊天ID找到绑定的用户
+func (d *Database) GetUserByTelegramChat(chatID int64) (string, error) {
+	var userID string
+	err := d.db.QueryRow(`SELECT user_id FROM telegram_links WHERE chat_id = ?`, chatID).Scan(&userID)
+	return userID, err
+}
+
+// UnlinkTelegram 解除用户的Telegram绑定
+func (d *Database) UnlinkTelegram(userID string) error {
+	result, err := d.db.Exec(`DELETE FROM telegram_links WHERE user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Season 竞赛赛季，区间为 [StartAt, EndAt)
+type Season struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	StartAt   time.Time `json:"start_at"`
+	EndAt     time.Time `json:"end_at"`
+	Archived  bool      `json:"archived"` // 最终排名已保存
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsActive 赛季在 now 时是否进行中
+func (s *Season) IsActive(now time.Time) bool {
+	return !now.Before(s.StartAt) && now.Before(s.EndAt)
+}
+
+// Overlaps 两个赛季的时间区间是否重叠
+func (s *Season) Overlaps(other *Season) bool {
+	return s.StartAt.Before(other.EndAt) && other.StartAt.Before(s.EndAt)
+}
+
+// SeasonResult 交易员在赛季中的排名
+type SeasonResult struct {
+	Rank        int     `json:"rank"`
+	TraderID    string  `json:"trader_id"`
+	TraderName  string  `json:"trader_name"`
+	AIModel     string  `json:"ai_model"`
+	Exchange    string  `json:"exchange"`
+	StartEquity float64 `json:"start_equity"`
+	EndEquity   float64 `json:"end_equity"`
+	PnL         float64 `json:"pnl"`
+	PnLPct      float64 `json:"pnl_pct"`
+}
+
+// CreateSeason 创建赛季
+func (d *Database) CreateSeason(season *Season) error {
+	return d.db.QueryRow(`
+		INSERT INTO competition_seasons (name, start_at, end_at) VALUES (?, ?, ?) RETURNING id
+	`, season.Name, season.StartAt.UTC(), season.EndAt.UTC()).Scan(&season.ID)
+}
+
+// UpdateSeason 修改赛季名称和时间（已归档的赛季不能修改）
+func (d *Database) UpdateSeason(season *Season) error {
+	result, err := d.db.Exec(`
+		UPDATE competition_seasons SET name = ?, start_at = ?, end_at = ? WHERE id = ? AND archived = FALSE
+	`, season.Name, season.StartAt.UTC(), season.EndAt.UTC(), season.ID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteSeason 删除赛季及其归档排名
+func (d *Database) DeleteSeason(id int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM competition_seasons WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec(`DELETE FROM season_results WHERE season_id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSeasons 所有赛季（按开始时间倒序）
+func (d *Database) GetSeasons() ([]*Season, error) {
+	rows, err := d.db.Query(`
+		SELECT id, name, start_at, end_at, archived, created_at FROM competition_seasons ORDER BY start_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seasons := []*Season{}
+	for rows.Next() {
+		var s Season
+		if err := rows.Scan(&s.ID, &s.Name, &s.StartAt, &s.EndAt, &s.Archived, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, &s)
+	}
+	return seasons, rows.Err()
+}
+
+// GetSeason 获取单个赛季
+func (d *Database) GetSeason(id int64) (*Season, error) {
+	var s Season
+	err := d.db.QueryRow(`
+		SELECT id, name, start_at, end_at, archived, created_at FROM competition_seasons WHERE id = ?
+	`, id).Scan(&s.ID, &s.Name, &s.StartAt, &s.EndAt, &s.Archived, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetCurrentSeason 获取 now 时进行中的赛季，没有时返回 sql.ErrNoRows
+func (d *Database) GetCurrentSeason(now time.Time) (*Season, error) {
+	seasons, err := d.GetSeasons()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range seasons {
+		if s.IsActive(now) {
+			return s, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// ArchiveSeason 保存赛季最终排名并标记为已归档
+func (d *Database) ArchiveSeason(seasonID int64, results []SeasonResult) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM season_results WHERE season_id = ?`, seasonID); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if _, err := tx.Exec(`
+			INSERT INTO season_results (season_id, rank, trader_id, trader_name, ai_model, exchange, start_equity, end_equity, pnl, pnl_pct)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, seasonID, r.Rank, r.TraderID, r.TraderName, r.AIModel, r.Exchange, r.StartEquity, r.EndEquity, r.PnL, r.PnLPct); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE competition_seasons SET archived = TRUE WHERE id = ?`, seasonID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSeasonResults 已归档赛季的最终排名
+func (d *Database) GetSeasonResults(seasonID int64) ([]SeasonResult, error) {
+	rows, err := d.db.Query(`
+		SELECT rank, trader_id, trader_name, ai_model, exchange, start_equity, end_equity, pnl, pnl_pct
+		FROM season_results WHERE season_id = ? ORDER BY rank
+	`, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SeasonResult{}
+	for rows.Next() {
+		var r SeasonResult
+		if err := rows.Scan(&r.Rank, &r.TraderID, &r.TraderName, &r.AIModel, &r.Exchange, &r.StartEquity, &r.EndEquity, &r.PnL, &r.PnLPct); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// SetDailyDigest 开启或关闭用户的每日邮件摘要
+func (d *Database) SetDailyDigest(userID string, enabled bool) error {
+	_, err := d.db.Exec(`
+		INSERT INTO daily_digests (user_id, enabled) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET enabled = excluded.enabled
+	`, userID, enabled)
+	return err
+}
+
+// GetDailyDigest 用户是否订阅了每日邮件摘要
+func (d *Database) GetDailyDigest(userID string) (bool, error) {
+	var enabled bool
+	err := d.db.QueryRow(`SELECT enabled FROM daily_digests WHERE user_id = ?`, userID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return enabled, err
+}
+
+// GetPendingDailyDigests 订阅了摘要且当天（UTC日期 day）尚未发送的用户
+func (d *Database) GetPendingDailyDigests(day string) ([]string, error) {
+	rows, err := d.db.Query(`
+		SELECT user_id FROM daily_digests WHERE enabled = TRUE AND last_sent_on < ? ORDER BY user_id
+	`, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// MarkDailyDigestSent 记录摘要已在 day 发送
+func (d *Database) MarkDailyDigestSent(userID, day string) error {
+	_, err := d.db.Exec(`UPDATE daily_digests SET last_sent_on = ? WHERE user_id = ?`, day, userID)
+	return err
+}
+
+// Follow 跟单订阅
+type Follow struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	LeaderID       string    `json:"leader_id"`
+	ExchangeID     string    `json:"exchange_id"`
+	Multiplier     float64   `json:"multiplier"`
+	MaxLeverage    int       `json:"max_leverage"`     // 0 表示跟随领单杠杆
+	MaxPositionUSD float64   `json:"max_position_usd"` // 0 表示不限制
+	MaxPositions   int       `json:"max_positions"`    // 0 表示不限制
+	MaxDrawdownPct float64   `json:"max_drawdown_pct"` // 0 表示不限制
+	Enabled        bool      `json:"enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// followColumns 跟单查询列
+const followColumns = `id, user_id, leader_id, exchange_id, multiplier, max_leverage, max_position_usd, max_positions, max_drawdown_pct, enabled, created_at`
+
+// scanFollows 读取跟单查询结果
+func scanFollows(rows *sql.Rows) ([]*Follow, error) {
+	defer rows.Close()
+	follows := []*Follow{}
+	for rows.Next() {
+		var f Follow
+		if err := rows.Scan(&f.ID, &f.UserID, &f.LeaderID, &f.ExchangeID, &f.Multiplier, &f.MaxLeverage,
+			&f.MaxPositionUSD, &f.MaxPositions, &f.MaxDrawdownPct, &f.Enabled, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		follows = append(follows, &f)
+	}
+	return follows, rows.Err()
+}
+
+// CreateFollow 创建跟单订阅
+func (d *Database) CreateFollow(f *Follow) error {
+	_, err := d.db.Exec(`
+		INSERT INTO follows (id, user_id, leader_id, exchange_id, multiplier, max_leverage, max_position_usd, max_positions, max_drawdown_pct, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, f.ID, f.UserID, f.LeaderID, f.ExchangeID, f.Multiplier, f.MaxLeverage, f.MaxPositionUSD, f.MaxPositions, f.MaxDrawdownPct, f.Enabled)
+	return err
+}
+
+// UpdateFollow 修改跟单倍数、风控上限和启用状态
+func (d *Database) UpdateFollow(f *Follow) error {
+	result, err := d.db.Exec(`
+		UPDATE follows SET multiplier = ?, max_leverage = ?, max_position_usd = ?, max_positions = ?, max_drawdown_pct = ?, enabled = ?
+		WHERE id = ? AND user_id = ?
+	`, f.Multiplier, f.MaxLeverage, f.MaxPositionUSD, f.MaxPositions, f.MaxDrawdownPct, f.Enabled, f.ID, f.UserID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteFollow 删除跟单订阅
+func (d *Database) DeleteFollow(userID, id string) error {
+	result, err := d.db.Exec(`DELETE FROM follows WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetFollows 获取用户的所有跟单订阅
+func (d *Database) GetFollows(userID string) ([]*Follow, error) {
+	rows, err := d.db.Query(`SELECT `+followColumns+` FROM follows WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanFollows(rows)
+}
+
+// GetFollow 获取用户的单个跟单订阅
+func (d *Database) GetFollow(userID, id string) (*Follow, error) {
+	follows, err := d.GetFollows(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range follows {
+		if f.ID == id {
+			return f, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// GetEnabledFollows 所有启用的跟单订阅（启动时加载）
+func (d *Database) GetEnabledFollows() ([]*Follow, error) {
+	rows, err := d.db.Query(`SELECT ` + followColumns + ` FROM follows WHERE enabled = TRUE ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	return scanFollows(rows)
+}
+
+// GetSystemLimits 获取系统级上限
+func (d *Database) GetSystemLimits() *UserLimits {
+	limits := &UserLimits{}
+	if value, err := d.GetSystemConfig("limit_max_leverage"); err == nil {
+		limits.MaxLeverage, _ = strconv.Atoi(value)
+	}
+	if value, err := d.GetSystemConfig("limit_max_notional"); err == nil {
+		limits.MaxNotional, _ = strconv.ParseFloat(value, 64)
+	}
+	if value, err := d.GetSystemConfig("limit_max_traders"); err == nil {
+		limits.MaxTraders, _ = strconv.Atoi(value)
+	}
+	if value, err := d.GetSystemConfig("limit_max_running_traders"); err == nil {
+		limits.MaxRunningTraders, _ = strconv.Atoi(value)
+	}
+	if value, err := d.GetSystemConfig("limit_min_scan_interval"); err == nil {
+		limits.MinScanInterval, _ = strconv.Atoi(value)
+	}
+	return limits
+}
+
+// SetSystemLimits 设置系统级上限
+func (d *Database) SetSystemLimits(limits *UserLimits) error {
+	configs := map[string]string{
+		"limit_max_leverage":        strconv.Itoa(limits.MaxLeverage),
+		"limit_max_notional":        strconv.FormatFloat(limits.MaxNotional, 'f', -1, 64),
+		"limit_max_traders":         strconv.Itoa(limits.MaxTraders),
+		"limit_max_running_traders": strconv.Itoa(limits.MaxRunningTraders),
+		"limit_min_scan_interval":   strconv.Itoa(limits.MinScanInterval),
+	}
+	for key, value := range configs {
+		if err := d.SetSystemConfig(key, value); err != nil {
+			return fmt.Errorf("保存系统级上限失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetAllUserLimits 获取所有用户的单独上限
+func (d *Database) GetAllUserLimits() ([]*UserLimits, error) {
+	rows, err := d.db.Query(`
+		SELECT user_id, max_leverage, max_notional, max_traders, max_running_traders, min_scan_interval
+		FROM user_limits ORDER BY user_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*UserLimits{}
+	for rows.Next() {
+		var limits UserLimits
+		if err := rows.Scan(&limits.UserID, &limits.MaxLeverage, &limits.MaxNotional, &limits.MaxTraders,
+			&limits.MaxRunningTraders, &limits.MinScanInterval); err != nil {
+			return nil, err
+		}
+		list = append(list, &limits)
+	}
+	return list, rows.Err()
+}
+
+// SetUserLimits 设置用户的单独上限
+func (d *Database) SetUserLimits(limits *UserLimits) error {
+	_, err := d.db.Exec(`
+		INSERT INTO user_limits (user_id, max_leverage, max_notional, max_traders, max_running_traders, min_scan_interval, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id) DO UPDATE SET max_leverage = excluded.max_leverage, max_notional = excluded.max_notional,
+			max_traders = excluded.max_traders, max_running_traders = excluded.max_running_traders,
+			min_scan_interval = excluded.min_scan_interval, updated_at = excluded.updated_at
+	`, limits.UserID, limits.MaxLeverage, limits.MaxNotional, limits.MaxTraders, limits.MaxRunningTraders, limits.MinScanInterval)
+	return err
+}
+
+// DeleteUserLimits 删除用户的单独上限（恢复为系统级上限）
+func (d *Database) DeleteUserLimits(userID string) error {
+	result, err := d.db.Exec(`DELETE FROM user_limits WHERE user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetEffectiveLimits 获取用户实际生效的上限：用户单独设置的项优先，其余沿用系统级上限
+func (d *Database) GetEffectiveLimits(userID string) (*UserLimits, error) {
+	limits := d.GetSystemLimits()
+	limits.UserID = userID
+
+	var override UserLimits
+	err := d.db.QueryRow(`
+		SELECT max_leverage, max_notional, max_traders, max_running_traders, min_scan_interval FROM user_limits WHERE user_id = ?
+	`, userID).Scan(&override.MaxLeverage, &override.MaxNotional, &override.MaxTraders, &override.MaxRunningTraders, &override.MinScanInterval)
+	if err == sql.ErrNoRows {
+		return limits, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取用户上限失败: %w", err)
+	}
+
+	if override.MaxLeverage > 0 {
+		limits.MaxLeverage = override.MaxLeverage
+	}
+	if override.MaxNotional > 0 {
+		limits.MaxNotional = override.MaxNotional
+	}
+	if override.MaxTraders > 0 {
+		limits.MaxTraders = override.MaxTraders
+	}
+	if override.MaxRunningTraders > 0 {
+		limits.MaxRunningTraders = override.MaxRunningTraders
+	}
+	if override.MinScanInterval > 0 {
+		limits.MinScanInterval = override.MinScanInterval
+	}
+	return limits, nil
+}
+
+// TraderSchedule 交易员的交易时段（规则格式见 trader.ParseSchedule）
+type TraderSchedule struct {
+	TraderID  string    `json:"trader_id"`
+	UserID    string    `json:"user_id"`
+	Spec      string    `json:"spec"`     // 如 "mon-fri 08:00-22:00"，多条规则用分号分隔
+	Timezone  string    `json:"timezone"` // IANA时区名，空表示UTC
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetTraderSchedule 设置交易员的交易时段（覆盖已有设置）
+func (d *Database) SetTraderSchedule(s *TraderSchedule) error {
+	_, err := d.db.Exec(`
+		INSERT INTO trader_schedules (trader_id, user_id, spec, timezone, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(trader_id) DO UPDATE SET user_id = excluded.user_id, spec = excluded.spec,
+			timezone = excluded.timezone, updated_at = excluded.updated_at
+	`, s.TraderID, s.UserID, s.Spec, s.Timezone)
+	return err
+}
+
+// GetTraderSchedule 获取交易员的交易时段，未设置时返回 sql.ErrNoRows
+func (d *Database) GetTraderSchedule(userID, traderID string) (*TraderSchedule, error) {
+	var s TraderSchedule
+	err := d.db.QueryRow(`
+		SELECT trader_id, user_id, spec, timezone, updated_at FROM trader_schedules WHERE trader_id = ? AND user_id = ?
+	`, traderID, userID).Scan(&s.TraderID, &s.UserID, &s.Spec, &s.Timezone, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetAllTraderSchedules 所有交易员的交易时段（加载交易员时同步）
+func (d *Database) GetAllTraderSchedules() ([]*TraderSchedule, error) {
+	rows, err := d.db.Query(`SELECT trader_id, user_id, spec, timezone, updated_at FROM trader_schedules ORDER BY trader_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*TraderSchedule{}
+	for rows.Next() {
+		var s TraderSchedule
+		if err := rows.Scan(&s.TraderID, &s.UserID, &s.Spec, &s.Timezone, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &s)
+	}
+	return list, rows.Err()
+}
+
+// DeleteTraderSchedule 删除交易员的交易时段（恢复全天运行）
+func (d *Database) DeleteTraderSchedule(userID, traderID string) error {
+	result, err := d.db.Exec(`DELETE FROM trader_schedules WHERE trader_id = ? AND user_id = ?`, traderID, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SignalKey 交易员接收外部信号（POST /api/signals）的签名密钥
+type SignalKey struct {
+	TraderID   string     `json:"trader_id"`
+	UserID     string     `json:"user_id"`
+	Secret     string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// SetSignalKey 设置交易员的信号签名密钥（覆盖已有密钥，旧密钥立即失效）
+func (d *Database) SetSignalKey(userID, traderID, secret string) error {
+	_, err := d.db.Exec(`
+		INSERT INTO trader_signal_keys (trader_id, user_id, secret, created_at, last_used_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP, NULL)
+		ON CONFLICT(trader_id) DO UPDATE SET user_id = excluded.user_id, secret = excluded.secret,
+			created_at = excluded.created_at, last_used_at = NULL
+	`, traderID, userID, secret)
+	return err
+}
+
+// GetSignalKey 获取交易员的信号签名密钥，未开启时返回 sql.ErrNoRows
+func (d *Database) GetSignalKey(traderID string) (*SignalKey, error) {
+	var k SignalKey
+	var used sql.NullTime
+	err := d.db.QueryRow(`
+		SELECT trader_id, user_id, secret, created_at, last_used_at FROM trader_signal_keys WHERE trader_id = ?
+	`, traderID).Scan(&k.TraderID, &k.UserID, &k.Secret, &k.CreatedAt, &used)
+	if err != nil {
+		return nil, err
+	}
+	if used.Valid {
+		k.LastUsedAt = &used.Time
+	}
+	return &k, nil
+}
+
+// TouchSignalKey 记录密钥最近一次成功推送的时间
+func (d *Database) TouchSignalKey(traderID string) error {
+	_, err := d.db.Exec(`UPDATE trader_signal_keys SET last_used_at = CURRENT_TIMESTAMP WHERE trader_id = ?`, traderID)
+	return err
+}
+
+// DeleteSignalKey 删除信号签名密钥（关闭信号推送），未开启时返回 sql.ErrNoRows
+func (d *Database) DeleteSignalKey(userID, traderID string) error {
+	result, err := d.db.Exec(`DELETE FROM trader_signal_keys WHERE trader_id = ? AND user_id = ?`, traderID, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// TraderSignalSource 用户为交易员注册的候选币种信号源
+type TraderSignalSource struct {
+	ID        string          `json:"id"`
+	TraderID  string          `json:"trader_id"`
+	UserID    string          `json:"user_id"`
+	Name      string          `json:"name"` // 候选币种的来源标记
+	Type      string          `json:"type"`
+	Config    json.RawMessage `json:"config"`
+	Secret    string          `json:"-"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// CreateTraderSignalSource 保存信号源，同一交易员下名称重复时返回错误
+func (d *Database) CreateTraderSignalSource(s *TraderSignalSource) error {
+	_, err := d.db.Exec(`
+		INSERT INTO trader_signal_sources (id, trader_id, user_id, name, type, config, secret) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, s.ID, s.TraderID, s.UserID, s.Name, s.Type, string(s.Config), s.Secret)
+	return err
+}
+
+// scanTraderSignalSources 读取 trader_signal_sources 的查询结果
+func scanTraderSignalSources(rows *sql.Rows) ([]*TraderSignalSource, error) {
+	defer rows.Close()
+	sources := []*TraderSignalSource{}
+	for rows.Next() {
+		var s TraderSignalSource
+		var cfg string
+		if err := rows.Scan(&s.ID, &s.TraderID, &s.UserID, &s.Name, &s.Type, &cfg, &s.Secret, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.Config = json.RawMessage(cfg)
+		sources = append(sources, &s)
+	}
+	return sources, rows.Err()
+}
+
+// GetTraderSignalSources 交易员的全部信号源，按创建时间排序
+func (d *Database) GetTraderSignalSources(traderID string) ([]*TraderSignalSource, error) {
+	rows, err := d.db.Query(`
+		SELECT id, trader_id, user_id, name, type, config, secret, created_at
+		FROM trader_signal_sources WHERE trader_id = ? ORDER BY created_at, id
+	`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTraderSignalSources(rows)
+}
+
+// GetAllTraderSignalSources 所有交易员的信号源（加载交易员时使用）
+func (d *Database) GetAllTraderSignalSources() ([]*TraderSignalSource, error) {
+	rows, err := d.db.Query(`
+		SELECT id, trader_id, user_id, name, type, config, secret, created_at
+		FROM trader_signal_sources ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanTraderSignalSources(rows)
+}
+
+// GetTraderSignalSource 按ID获取信号源，不存在时返回 sql.ErrNoRows
+func (d *Database) GetTraderSignalSource(id string) (*TraderSignalSource, error) {
+	var s TraderSignalSource
+	var cfg string
+	err := d.db.QueryRow(`
+		SELECT id, trader_id, user_id, name, type, config, secret, created_at
+		FROM trader_signal_sources WHERE id = ?
+	`, id).Scan(&s.ID, &s.TraderID, &s.UserID, &s.Name, &s.Type, &cfg, &s.Secret, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	s.Config = json.RawMessage(cfg)
+	return &s, nil
+}
+
+// DeleteTraderSignalSource 删除信号源，不存在或不属于该用户的交易员时返回 sql.ErrNoRows
+func (d *Database) DeleteTraderSignalSource(userID, traderID, id string) error {
+	result, err := d.db.Exec(`DELETE FROM trader_signal_sources WHERE id = ? AND trader_id = ? AND user_id = ?`, id, traderID, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetTraderTags 替换交易员的全部标签（tags 为空时清除）
+func (d *Database) SetTraderTags(userID, traderID string, tags []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM trader_tags WHERE trader_id = ? AND user_id = ?`, traderID, userID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT INTO trader_tags (trader_id, user_id, tag) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`, traderID, userID, tag); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTraderTags 多个交易员的标签（trader_id -> 按字母排序的标签），没有标签的交易员不在结果中
+func (d *Database) GetTraderTags(traderIDs []string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if len(traderIDs) == 0 {
+		return result, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(traderIDs)), ",")
+	args := make([]interface{}, len(traderIDs))
+	for i, id := range traderIDs {
+		args[i] = id
+	}
+	rows, err := d.db.Query(`SELECT trader_id, tag FROM trader_tags WHERE trader_id IN (`+placeholders+`) ORDER BY trader_id, tag`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var traderID, tag string
+		if err := rows.Scan(&traderID, &tag); err != nil {
+			return nil, err
+		}
+		result[traderID] = append(result[traderID], tag)
+	}
+	return result, rows.Err()
+}
+
+// GetTraderIDsByTag 带有指定标签的交易员ID，userID 为空时查询所有用户
+func (d *Database) GetTraderIDsByTag(userID, tag string) ([]string, error) {
+	query := `SELECT trader_id FROM trader_tags WHERE tag = ?`
+	args := []interface{}{tag}
+	if userID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+	rows, err := d.db.Query(query+` ORDER BY trader_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Order 一笔订单的当前状态及状态变化
+type Order struct {
+	ClientOrderID   string       `json:"client_order_id"`
+	TraderID        string       `json:"trader_id"`
+	UserID          string       `json:"-"`
+	Symbol          string       `json:"symbol"`
+	Action          string       `json:"action"`
+	Type            string       `json:"type"` // market / stop_loss / take_profit
+	ExchangeOrderID string       `json:"exchange_order_id,omitempty"`
+	Status          string       `json:"status"`
+	Quantity        float64      `json:"quantity"`
+	Price           float64      `json:"price,omitempty"`
+	FillPrice       float64      `json:"fill_price,omitempty"`
+	StopPrice       float64      `json:"stop_price,omitempty"`
+	Reason          string       `json:"reason,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+	Events          []OrderEvent `json:"events"`
+}
+
+// OrderEvent 订单的一次状态变化
+type OrderEvent struct {
+	Status string    `json:"status"`
+	Reason string    `json:"reason,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// RecordOrder 写入订单的一次状态变化：新订单插入完整记录，已有订单只更新状态、
+// 原因以及非空的交易所订单ID和成交价，并追加一条状态变化（UpdatedAt 为这次变化的时间）
+func (d *Database) RecordOrder(o *Order) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO orders (client_order_id, trader_id, user_id, symbol, action, order_type, exchange_order_id,
+			status, quantity, price, fill_price, stop_price, reason, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(client_order_id) DO UPDATE SET
+			status = excluded.status,
+			reason = excluded.reason,
+			exchange_order_id = CASE WHEN excluded.exchange_order_id != '' THEN excluded.exchange_order_id ELSE orders.exchange_order_id END,
+			fill_price = CASE WHEN excluded.fill_price > 0 THEN excluded.fill_price ELSE orders.fill_price END,
+			updated_at = excluded.updated_at
+	`, o.ClientOrderID, o.TraderID, o.UserID, o.Symbol, o.Action, o.Type, o.ExchangeOrderID,
+		o.Status, o.Quantity, o.Price, o.FillPrice, o.StopPrice, o.Reason, o.UpdatedAt, o.UpdatedAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO order_events (client_order_id, status, reason, created_at) VALUES (?, ?, ?, ?)`,
+		o.ClientOrderID, o.Status, o.Reason, o.UpdatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetTraderOrders 交易员最近的订单（新的在前），status 为空时不过滤，每笔订单附带状态变化
+func (d *Database) GetTraderOrders(traderID, status string, limit int) ([]*Order, error) {
+	query := `
+		SELECT client_order_id, trader_id, user_id, symbol, action, order_type, exchange_order_id,
+			status, quantity, price, fill_price, stop_price, reason, created_at, updated_at
+		FROM orders WHERE trader_id = ?`
+	args := []interface{}{traderID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC, rowid DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*Order{}
+	byID := make(map[string]*Order)
+	for rows.Next() {
+		o := &Order{Events: []OrderEvent{}}
+		if err := rows.Scan(&o.ClientOrderID, &o.TraderID, &o.UserID, &o.Symbol, &o.Action, &o.Type, &o.ExchangeOrderID,
+			&o.Status, &o.Quantity, &o.Price, &o.FillPrice, &o.StopPrice, &o.Reason, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+		byID[o.ClientOrderID] = o
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(orders)), ",")
+	ids := make([]interface{}, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ClientOrderID
+	}
+	eventRows, err := d.db.Query(`SELECT client_order_id, status, reason, created_at FROM order_events
+		WHERE client_order_id IN (`+placeholders+`) ORDER BY id`, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer eventRows.Close()
+	for eventRows.Next() {
+		var id string
+		var e OrderEvent
+		if err := eventRows.Scan(&id, &e.Status, &e.Reason, &e.Time); err != nil {
+			return nil, err
+		}
+		byID[id].Events = append(byID[id].Events, e)
+	}
+	return orders, eventRows.Err()
+}
+
+// ClosedPosition 一笔已平仓持仓的结果
+type ClosedPosition struct {
+	ID             int64      `json:"id"`
+	TraderID       string     `json:"trader_id"`
+	UserID         string     `json:"-"`
+	Symbol         string     `json:"symbol"`
+	Side           string     `json:"side"`
+	EntryPrice     float64    `json:"entry_price"`
+	ExitPrice      float64    `json:"exit_price"`
+	Quantity       float64    `json:"quantity"`
+	Fees           float64    `json:"fees"`        // 估算的开平仓手续费
+	FundingFee     float64    `json:"funding_fee"` // 资金费净收入（负数表示支付）
+	PnL            float64    `json:"pnl"`         // 毛盈亏
+	RealizedPnL    float64    `json:"realized_pnl"`
+	RMultiple      float64    `json:"r_multiple"`
+	HoldingMinutes float64    `json:"holding_minutes"`
+	ExitReason     string     `json:"exit_reason"`
+	OpenedAt       *time.Time `json:"opened_at,omitempty"`
+	ClosedAt       time.Time  `json:"closed_at"`
+}
+
+// PositionHistorySummary 平仓记录汇总
+type PositionHistorySummary struct {
+	Count       int     `json:"count"`
+	Wins        int     `json:"wins"` // 已实现盈亏为正的笔数
+	RealizedPnL float64 `json:"realized_pnl"`
+	Fees        float64 `json:"fees"`
+	FundingFee  float64 `json:"funding_fee"`
+}
+
+// RecordClosedPosition 写入一条平仓记录
+func (d *Database) RecordClosedPosition(p *ClosedPosition) error {
+	_, err := d.db.Exec(`
+		INSERT INTO position_history (trader_id, user_id, symbol, side, entry_price, exit_price, quantity, fees, funding_fee,
+			pnl, realized_pnl, r_multiple, holding_minutes, exit_reason, opened_at, closed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.TraderID, p.UserID, p.Symbol, p.Side, p.EntryPrice, p.ExitPrice, p.Quantity, p.Fees, p.FundingFee,
+		p.PnL, p.RealizedPnL, p.RMultiple, p.HoldingMinutes, p.ExitReason, p.OpenedAt, p.ClosedAt)
+	return err
+}
+
+// GetPositionHistory 交易员最近的平仓记录（新的在前）及全部匹配记录的汇总，symbol 为空时不过滤
+func (d *Database) GetPositionHistory(traderID, symbol string, limit int) ([]*ClosedPosition, *PositionHistorySummary, error) {
+	where := ` WHERE trader_id = ?`
+	args := []interface{}{traderID}
+	if symbol != "" {
+		where += ` AND symbol = ?`
+		args = append(args, symbol)
+	}
+
+	var summary PositionHistorySummary
+	if err := d.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(realized_pnl), 0), COALESCE(SUM(fees), 0), COALESCE(SUM(funding_fee), 0)
+		FROM position_history`+where, args...,
+	).Scan(&summary.Count, &summary.Wins, &summary.RealizedPnL, &summary.Fees, &summary.FundingFee); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := d.db.Query(`
+		SELECT id, trader_id, user_id, symbol, side, entry_price, exit_price, quantity, fees, funding_fee,
+			pnl, realized_pnl, r_multiple, holding_minutes, exit_reason, opened_at, closed_at
+		FROM position_history`+where+` ORDER BY closed_at DESC, id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	positions := []*ClosedPosition{}
+	for rows.Next() {
+		var p ClosedPosition
+		var openedAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.TraderID, &p.UserID, &p.Symbol, &p.Side, &p.EntryPrice, &p.ExitPrice, &p.Quantity, &p.Fees, &p.FundingFee,
+			&p.PnL, &p.RealizedPnL, &p.RMultiple, &p.HoldingMinutes, &p.ExitReason, &openedAt, &p.ClosedAt); err != nil {
+			return nil, nil, err
+		}
+		if openedAt.Valid {
+			p.OpenedAt = &openedAt.Time
+		}
+		positions = append(positions, &p)
+	}
+	return positions, &summary, rows.Err()
+}
+
+// PerformanceBucket 按币种或方向分组的平仓表现
+type PerformanceBucket struct {
+	Key         string  `json:"key"` // 币种或 long/short
+	Trades      int     `json:"trades"`
+	Wins        int     `json:"wins"`     // 已实现盈亏为正的笔数
+	WinRate     float64 `json:"win_rate"` // 胜率（%）
+	RealizedPnL float64 `json:"realized_pnl"`
+	AvgPnL      float64 `json:"avg_pnl"`  // 每笔平均已实现盈亏
+	AvgR        float64 `json:"avg_r"`    // 平均R倍数（只统计有止损的交易）
+	RTrades     int     `json:"r_trades"` // 参与平均R计算的笔数
+}
+
+// PositionBreakdown 平仓表现按币种和方向的分组（亏损最多的在前）
+type PositionBreakdown struct {
+	BySymbol []*PerformanceBucket `json:"by_symbol"`
+	BySide   []*PerformanceBucket `json:"by_side"`
+}
+
+// GetPositionBreakdown 交易员平仓记录按币种和多空方向汇总，from/to 为零值时不限制平仓时间
+func (d *Database) GetPositionBreakdown(traderID string, from, to time.Time) (*PositionBreakdown, error) {
+	where := ` WHERE trader_id = ?`
+	args := []interface{}{traderID}
+	if !from.IsZero() {
+		where += ` AND closed_at >= ?`
+		args = append(args, from.Local()) // 与平仓时写入的本地时间一致
+	}
+	if !to.IsZero() {
+		where += ` AND closed_at <= ?`
+		args = append(args, to.Local())
+	}
+
+	bySymbol, err := d.positionBuckets("symbol", where, args)
+	if err != nil {
+		return nil, err
+	}
+	bySide, err := d.positionBuckets("side", where, args)
+	if err != nil {
+		return nil, err
+	}
+	return &PositionBreakdown{BySymbol: bySymbol, BySide: bySide}, nil
+}
+
+// positionBuckets 按 column（symbol 或 side）分组汇总平仓记录
+func (d *Database) positionBuckets(column, where string, args []interface{}) ([]*PerformanceBucket, error) {
+	rows, err := d.db.Query(`
+		SELECT `+column+`, COUNT(*), COALESCE(SUM(CASE WHEN realized_pnl > 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(realized_pnl), 0), COALESCE(SUM(r_multiple), 0),
+			COALESCE(SUM(CASE WHEN r_multiple <> 0 THEN 1 ELSE 0 END), 0)
+		FROM position_history`+where+` GROUP BY `+column+` ORDER BY SUM(realized_pnl), `+column, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []*PerformanceBucket{}
+	for rows.Next() {
+		var b PerformanceBucket
+		var totalR float64
+		if err := rows.Scan(&b.Key, &b.Trades, &b.Wins, &b.RealizedPnL, &totalR, &b.RTrades); err != nil {
+			return nil, err
+		}
+		b.WinRate = float64(b.Wins) / float64(b.Trades) * 100
+		b.AvgPnL = b.RealizedPnL / float64(b.Trades)
+		if b.RTrades > 0 {
+			b.AvgR = totalR / float64(b.RTrades)
+		}
+		buckets = append(buckets, &b)
+	}
+	return buckets, rows.Err()
+}
+
+// DecisionRecordRef 数据库中一条决策记录的标识和时间
+type DecisionRecordRef struct {
+	ID        string
+	Timestamp time.Time
+}
+
+// SaveDecisionRecord 写入或覆盖一条决策记录（data 为记录的JSON）
+func (d *Database) SaveDecisionRecord(traderID, id string, timestamp time.Time, data []byte) error {
+	_, err := d.db.Exec(`
+		INSERT INTO decision_records (trader_id, id, timestamp, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT(trader_id, id) DO UPDATE SET timestamp = excluded.timestamp, data = excluded.data
+	`, traderID, id, timestamp.UTC(), string(data))
+	return err
+}
+
+// ListDecisionRecords 交易员全部决策记录的标识（按时间正序，不读取内容）
+func (d *Database) ListDecisionRecords(traderID string) ([]DecisionRecordRef, error) {
+	rows, err := d.db.Query(`SELECT id, timestamp FROM decision_records WHERE trader_id = ? ORDER BY timestamp, id`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []DecisionRecordRef
+	for rows.Next() {
+		var ref DecisionRecordRef
+		if err := rows.Scan(&ref.ID, &ref.Timestamp); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// GetDecisionRecord 读取一条决策记录的JSON，不存在时返回 sql.ErrNoRows
+func (d *Database) GetDecisionRecord(traderID, id string) ([]byte, error) {
+	var data string
+	err := d.db.QueryRow(`SELECT data FROM decision_records WHERE trader_id = ? AND id = ?`, traderID, id).Scan(&data)
+	return []byte(data), err
+}
+
+// DeleteDecisionRecord 删除一条决策记录
+func (d *Database) DeleteDecisionRecord(traderID, id string) error {
+	_, err := d.db.Exec(`DELETE FROM decision_records WHERE trader_id = ? AND id = ?`, traderID, id)
+	return err
+}
+
+// ListDecisionRecordTraders 有决策记录或净值归档的交易员ID（包括已删除的交易员）
+func (d *Database) ListDecisionRecordTraders() ([]string, error) {
+	rows, err := d.db.Query(`
+		SELECT trader_id FROM decision_records
+		UNION
+		SELECT trader_id FROM decision_equity_archive
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var traderIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		traderIDs = append(traderIDs, id)
+	}
+	return traderIDs, rows.Err()
+}
+
+// AppendDecisionEquityArchive 追加降采样净值点（data 为每个点的JSON，与 timestamps 一一对应），已存在的时间点忽略
+func (d *Database) AppendDecisionEquityArchive(traderID string, timestamps []time.Time, data [][]byte) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range timestamps {
+		if _, err := tx.Exec(`
+			INSERT INTO decision_equity_archive (trader_id, timestamp, data) VALUES (?, ?, ?)
+			ON CONFLICT DO NOTHING
+		`, traderID, timestamps[i].UTC(), string(data[i])); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDecisionEquityArchive 交易员全部归档净值点的JSON（按时间正序）
+func (d *Database) GetDecisionEquityArchive(traderID string) ([][]byte, error) {
+	rows, err := d.db.Query(`SELECT data FROM decision_equity_archive WHERE trader_id = ? ORDER BY timestamp`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points [][]byte
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		points = append(points, []byte(data))
+	}
+	return points, rows.Err()
+}
+
+// DecisionSearchEntry 全文检索索引中的一条决策记录
+type DecisionSearchEntry struct {
+	TraderID    string
+	RecordID    string
+	Timestamp   time.Time
+	CycleNumber int
+	Content     string // 思维链、决策JSON和错误信息
+}
+
+// DecisionSearchQuery 决策记录检索条件
+type DecisionSearchQuery struct {
+	TraderID string
+	Terms    []string  // 内容需包含全部词（不区分大小写）
+	From, To time.Time // 零值表示不限
+	Limit    int
+}
+
+// IndexDecisionRecord 写入或覆盖一条决策记录的检索内容
+func (d *Database) IndexDecisionRecord(e *DecisionSearchEntry) error {
+	_, err := d.db.Exec(`
+		INSERT INTO decision_search (trader_id, record_id, timestamp, cycle_number, content) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(trader_id, record_id) DO UPDATE SET timestamp = excluded.timestamp, cycle_number = excluded.cycle_number, content = excluded.content
+	`, e.TraderID, e.RecordID, e.Timestamp.UTC(), e.CycleNumber, e.Content)
+	return err
+}
+
+// GetIndexedDecisionRecordIDs 交易员已写入检索索引的记录ID
+func (d *Database) GetIndexedDecisionRecordIDs(traderID string) (map[string]bool, error) {
+	rows, err := d.db.Query(`SELECT record_id FROM decision_search WHERE trader_id = ?`, traderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// DeleteDecisionSearchEntries 从检索索引中删除记录（原始记录已被清理）
+func (d *Database) DeleteDecisionSearchEntries(traderID string, recordIDs []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range recordIDs {
+		if _, err := tx.Exec(`DELETE FROM decision_search WHERE trader_id = ? AND record_id = ?`, traderID, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SearchDecisionRecords 按关键词检索决策记录（新的在前）
+func (d *Database) SearchDecisionRecords(q DecisionSearchQuery) ([]*DecisionSearchEntry, error) {
+	query := `SELECT trader_id, record_id, timestamp, cycle_number, content FROM decision_search WHERE trader_id = ?`
+	args := []interface{}{q.TraderID}
+	if !q.From.IsZero() {
+		query += ` AND timestamp >= ?`
+		args = append(args, q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		query += ` AND timestamp <= ?`
+		args = append(args, q.To.UTC())
+	}
+	for _, term := range q.Terms {
+		query += ` AND LOWER(content) LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(strings.ToLower(term))+"%")
+	}
+	query += ` ORDER BY timestamp DESC LIMIT ?`
+	args = append(args, q.Limit)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*DecisionSearchEntry
+	for rows.Next() {
+		var e DecisionSearchEntry
+		if err := rows.Scan(&e.TraderID, &e.RecordID, &e.Timestamp, &e.CycleNumber, &e.Content); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+// escapeLike 转义 LIKE 模式中的通配符（配合 ESCAPE '\'）
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// SaveEquitySample 保存一次净值采样（data 为采样的JSON）
+func (d *Database) SaveEquitySample(traderID string, ts time.Time, data []byte) error {
+	_, err := d.db.Exec(`
+		INSERT INTO equity_samples (trader_id, timestamp, data) VALUES (?, ?, ?)
+		ON CONFLICT(trader_id, timestamp) DO UPDATE SET data = excluded.data
+	`, traderID, ts.UTC(), string(data))
+	return err
+}
+
+// GetEquitySamples 交易员 since 之后的净值采样JSON（按时间正序，since 为零值时返回全部）
+func (d *Database) GetEquitySamples(traderID string, since time.Time) ([][]byte, error) {
+	rows, err := d.db.Query(`SELECT data FROM equity_samples WHERE trader_id = ? AND timestamp >= ? ORDER BY timestamp`, traderID, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples [][]byte
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		samples = append(samples, []byte(data))
+	}
+	return samples, rows.Err()
+}
+
+// DeleteEquitySamplesBefore 删除 cutoff 之前的净值采样，返回删除的条数
+func (d *Database) DeleteEquitySamplesBefore(cutoff time.Time) (int64, error) {
+	result, err := d.db.Exec(`DELETE FROM equity_samples WHERE timestamp < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Close 关闭数据库连接
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+// Ping 检查数据库连接是否可用（健康检查用）
+func (d *Database) Ping(ctx context.Context) error {
+	var one int
+	return d.db.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+}
+
+// Snapshot 把数据库的一致性快照写入 path（文件不能已存在），写入期间不阻塞其他读写
+// 只支持SQLite，PostgreSQL 请使用 pg_dump
+func (d *Database) Snapshot(path string) error {
+	if d.driver != DriverSQLite {
+		return fmt.Errorf("%s 不支持快照备份，请使用数据库自带的备份工具（如 pg_dump）", d.driver)
+	}
+	_, err := d.db.Exec(`VACUUM INTO ?`, path)
+	return err
+}
+
+// LoadBetaCodesFromFile 从文件加载内测码到数据库
+func (d *Database) LoadBetaCodesFromFile(filePath string) error {
+	// 读取文件内容
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("读取内测码文件失败: %w", err)
+	}
+
+	// 按行分割内测码
+	lines := strings.Split(string(content), "\n")
+	var codes []string
+	for _, line := range lines {
+		code := strings.TrimSpace(line)
+		if code != "" && !strings.HasPrefix(code, "#") {
+			codes = append(codes, code)
+		}
+	}
+
+	// 批量插入内测码
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO beta_codes (code) VALUES (?) ON CONFLICT DO NOTHING`)
 	if err != nil {
 		return fmt.Errorf("准备语句失败: %w", err)
 	}
@@ -1024,10 +3585,10 @@ func (d *Database) LoadBetaCodesFromFile(filePath string) error {
 	for _, code := range codes {
 		result, err := stmt.Exec(code)
 		if err != nil {
-			log.Printf("插入内测码 %s 失败: %v", code, err)
+			slog.Warn("插入内测码失败", "code", code, "error", err)
 			continue
 		}
-		
+
 		if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
 			insertedCount++
 		}
@@ -1037,7 +3598,7 @@ func (d *Database) LoadBetaCodesFromFile(filePath string) error {
 		return fmt.Errorf("提交事务失败: %w", err)
 	}
 
-	log.Printf("✅ 成功加载 %d 个内测码到数据库 (总计 %d 个)", insertedCount, len(codes))
+	slog.Info("成功加载内测码到数据库", "inserted", insertedCount, "total", len(codes))
 	return nil
 }
 
@@ -1057,8 +3618,8 @@ func (d *Database) ValidateBetaCode(code string) (bool, error) {
 // UseBetaCode 使用内测码（标记为已使用）
 func (d *Database) UseBetaCode(code, userEmail string) error {
 	result, err := d.db.Exec(`
-		UPDATE beta_codes SET used = 1, used_by = ?, used_at = CURRENT_TIMESTAMP 
-		WHERE code = ? AND used = 0
+		UPDATE beta_codes SET used = TRUE, used_by = ?, used_at = CURRENT_TIMESTAMP
+		WHERE code = ? AND used = FALSE
 	`, userEmail, code)
 	if err != nil {
 		return err
@@ -1076,6 +3637,30 @@ func (d *Database) UseBetaCode(code, userEmail string) error {
 	return nil
 }
 
+// AddBetaCodes 写入新的内测码，已存在的跳过，返回实际写入的内测码
+func (d *Database) AddBetaCodes(codes []string) ([]string, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	var inserted []string
+	for _, code := range codes {
+		result, err := tx.Exec(`INSERT INTO beta_codes (code) VALUES (?) ON CONFLICT DO NOTHING`, code)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			inserted = append(inserted, code)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
 // GetBetaCodeStats 获取内测码统计信息
 func (d *Database) GetBetaCodeStats() (total, used int, err error) {
 	err = d.db.QueryRow(`SELECT COUNT(*) FROM beta_codes`).Scan(&total)
@@ -1083,7 +3668,7 @@ func (d *Database) GetBetaCodeStats() (total, used int, err error) {
 		return 0, 0, err
 	}
 
-	err = d.db.QueryRow(`SELECT COUNT(*) FROM beta_codes WHERE used = 1`).Scan(&used)
+	err = d.db.QueryRow(`SELECT COUNT(*) FROM beta_codes WHERE used = TRUE`).Scan(&used)
 	if err != nil {
 		return 0, 0, err
 	}
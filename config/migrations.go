@@ -0,0 +1,318 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+)
+
+// migration 一次版本化的表结构变更，Up 和 Down 各自在一个事务中执行
+// 新增字段、索引、表时在 migrations 末尾追加，不要修改已发布的迁移；
+// createTables 中的建表语句是版本0的基础结构
+type migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string // 回退 Up 的语句
+}
+
+// migrations 按版本号递增排列
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "user_id_indexes",
+		Up: []string{
+			`CREATE INDEX IF NOT EXISTS idx_traders_user ON traders(user_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_ai_models_user ON ai_models(user_id)`,
+			`CREATE INDEX IF NOT EXISTS idx_exchanges_user ON exchanges(user_id)`,
+		},
+		Down: []string{
+			`DROP INDEX IF EXISTS idx_traders_user`,
+			`DROP INDEX IF EXISTS idx_ai_models_user`,
+			`DROP INDEX IF EXISTS idx_exchanges_user`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "decision_records",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS decision_records (
+				trader_id TEXT NOT NULL,
+				id TEXT NOT NULL,
+				timestamp DATETIME NOT NULL,
+				data TEXT NOT NULL,
+				PRIMARY KEY (trader_id, id)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_decision_records_time ON decision_records(trader_id, timestamp)`,
+			`CREATE TABLE IF NOT EXISTS decision_equity_archive (
+				trader_id TEXT NOT NULL,
+				timestamp DATETIME NOT NULL,
+				data TEXT NOT NULL,
+				PRIMARY KEY (trader_id, timestamp)
+			)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS decision_equity_archive`,
+			`DROP INDEX IF EXISTS idx_decision_records_time`,
+			`DROP TABLE IF EXISTS decision_records`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "decision_search",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS decision_search (
+				trader_id TEXT NOT NULL,
+				record_id TEXT NOT NULL,
+				timestamp DATETIME NOT NULL,
+				cycle_number INTEGER NOT NULL DEFAULT 0,
+				content TEXT NOT NULL,
+				PRIMARY KEY (trader_id, record_id)
+			)`,
+			`CREATE INDEX IF NOT EXISTS idx_decision_search_time ON decision_search(trader_id, timestamp)`,
+		},
+		Down: []string{
+			`DROP INDEX IF EXISTS idx_decision_search_time`,
+			`DROP TABLE IF EXISTS decision_search`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "equity_samples",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS equity_samples (
+				trader_id TEXT NOT NULL,
+				timestamp DATETIME NOT NULL,
+				data TEXT NOT NULL,
+				PRIMARY KEY (trader_id, timestamp)
+			)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS equity_samples`,
+		},
+	},
+	{
+		// 这些字段原来在每次启动时 ALTER TABLE 添加，升级上来的数据库可能已经有了，已存在的字段跳过
+		Version: 5,
+		Name:    "legacy_columns",
+		Up: []string{
+			`ALTER TABLE exchanges ADD COLUMN hyperliquid_wallet_addr TEXT DEFAULT ''`,
+			`ALTER TABLE exchanges ADD COLUMN aster_user TEXT DEFAULT ''`,
+			`ALTER TABLE exchanges ADD COLUMN aster_signer TEXT DEFAULT ''`,
+			`ALTER TABLE exchanges ADD COLUMN aster_private_key TEXT DEFAULT ''`,
+			`ALTER TABLE traders ADD COLUMN custom_prompt TEXT DEFAULT ''`,
+			`ALTER TABLE traders ADD COLUMN override_base_prompt BOOLEAN DEFAULT 0`,
+			`ALTER TABLE traders ADD COLUMN is_cross_margin BOOLEAN DEFAULT 1`,             // 默认为全仓模式
+			`ALTER TABLE traders ADD COLUMN use_default_coins BOOLEAN DEFAULT 1`,           // 默认使用默认币种
+			`ALTER TABLE traders ADD COLUMN custom_coins TEXT DEFAULT ''`,                  // 自定义币种列表（JSON格式）
+			`ALTER TABLE traders ADD COLUMN btc_eth_leverage INTEGER DEFAULT 5`,            // BTC/ETH杠杆倍数
+			`ALTER TABLE traders ADD COLUMN altcoin_leverage INTEGER DEFAULT 5`,            // 山寨币杠杆倍数
+			`ALTER TABLE traders ADD COLUMN trading_symbols TEXT DEFAULT ''`,               // 交易币种，逗号分隔
+			`ALTER TABLE traders ADD COLUMN use_coin_pool BOOLEAN DEFAULT 0`,               // 是否使用COIN POOL信号源
+			`ALTER TABLE traders ADD COLUMN use_oi_top BOOLEAN DEFAULT 0`,                  // 是否使用OI TOP信号源
+			`ALTER TABLE traders ADD COLUMN system_prompt_template TEXT DEFAULT 'default'`, // 系统提示词模板名称
+			`ALTER TABLE ai_models ADD COLUMN custom_api_url TEXT DEFAULT ''`,              // 自定义API地址
+			`ALTER TABLE ai_models ADD COLUMN custom_model_name TEXT DEFAULT ''`,           // 自定义模型名称
+			`ALTER TABLE users ADD COLUMN pending_otp_secret TEXT DEFAULT ''`,              // 重新绑定验证器时待确认的OTP密钥
+			`ALTER TABLE users ADD COLUMN role TEXT DEFAULT 'user'`,                        // 用户角色: admin / user / viewer
+			`ALTER TABLE users ADD COLUMN disabled BOOLEAN DEFAULT 0`,                      // 管理员禁用的账户不能登录
+			`ALTER TABLE user_limits ADD COLUMN max_running_traders INTEGER DEFAULT 0`,     // 同时运行的交易员数量上限
+			`ALTER TABLE user_limits ADD COLUMN min_scan_interval INTEGER DEFAULT 0`,       // 最小扫描间隔（分钟）
+		},
+		Down: []string{
+			`ALTER TABLE user_limits DROP COLUMN min_scan_interval`,
+			`ALTER TABLE user_limits DROP COLUMN max_running_traders`,
+			`ALTER TABLE users DROP COLUMN disabled`,
+			`ALTER TABLE users DROP COLUMN role`,
+			`ALTER TABLE users DROP COLUMN pending_otp_secret`,
+			`ALTER TABLE ai_models DROP COLUMN custom_model_name`,
+			`ALTER TABLE ai_models DROP COLUMN custom_api_url`,
+			`ALTER TABLE traders DROP COLUMN system_prompt_template`,
+			`ALTER TABLE traders DROP COLUMN use_oi_top`,
+			`ALTER TABLE traders DROP COLUMN use_coin_pool`,
+			`ALTER TABLE traders DROP COLUMN trading_symbols`,
+			`ALTER TABLE traders DROP COLUMN altcoin_leverage`,
+			`ALTER TABLE traders DROP COLUMN btc_eth_leverage`,
+			`ALTER TABLE traders DROP COLUMN custom_coins`,
+			`ALTER TABLE traders DROP COLUMN use_default_coins`,
+			`ALTER TABLE traders DROP COLUMN is_cross_margin`,
+			`ALTER TABLE traders DROP COLUMN override_base_prompt`,
+			`ALTER TABLE traders DROP COLUMN custom_prompt`,
+			`ALTER TABLE exchanges DROP COLUMN aster_private_key`,
+			`ALTER TABLE exchanges DROP COLUMN aster_signer`,
+			`ALTER TABLE exchanges DROP COLUMN aster_user`,
+			`ALTER TABLE exchanges DROP COLUMN hyperliquid_wallet_addr`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "trader_candidate_settings",
+		Up: []string{
+			`ALTER TABLE traders ADD COLUMN max_candidates INTEGER DEFAULT 0`,       // 每个周期发给AI的候选币种上限（0 使用默认值）
+			`ALTER TABLE traders ADD COLUMN pool_refresh_minutes INTEGER DEFAULT 0`, // 候选币种池刷新间隔（分钟，0 每个周期刷新）
+		},
+		Down: []string{
+			`ALTER TABLE traders DROP COLUMN pool_refresh_minutes`,
+			`ALTER TABLE traders DROP COLUMN max_candidates`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "trader_liquidity_filter",
+		Up: []string{
+			`ALTER TABLE traders ADD COLUMN liquidity_filter TEXT DEFAULT ''`,     // 流动性过滤方式（oi_value/volume/both/off，空为oi_value）
+			`ALTER TABLE traders ADD COLUMN min_oi_value_millions REAL DEFAULT 0`, // 最低持仓价值（百万USDT，0 使用默认值15）
+			`ALTER TABLE traders ADD COLUMN min_volume_millions REAL DEFAULT 0`,   // 最低24h成交额（百万USDT，0 使用默认值50）
+		},
+		Down: []string{
+			`ALTER TABLE traders DROP COLUMN min_volume_millions`,
+			`ALTER TABLE traders DROP COLUMN min_oi_value_millions`,
+			`ALTER TABLE traders DROP COLUMN liquidity_filter`,
+		},
+	},
+}
+
+// addColumnStmt 匹配 ALTER TABLE <表> ADD COLUMN <字段>
+var addColumnStmt = regexp.MustCompile(`(?i)^\s*ALTER TABLE\s+(\w+)\s+ADD COLUMN\s+(\w+)`)
+
+// MigrationStatus 单个迁移的执行状态
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"` // 未执行时为空
+}
+
+// LatestSchemaVersion 程序支持的最新表结构版本
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// createSchemaVersionTable 创建记录已执行迁移的表
+func (d *Database) createSchemaVersionTable() error {
+	_, err := d.db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	return err
+}
+
+// SchemaVersion 数据库当前的表结构版本（没有执行过迁移时为0）
+func (d *Database) SchemaVersion() (int, error) {
+	var version int
+	err := d.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	return version, err
+}
+
+// migrate 启动时执行所有未执行的迁移；数据库版本高于程序支持的版本时拒绝启动，避免旧程序写坏新结构
+func (d *Database) migrate() error {
+	if err := d.createSchemaVersionTable(); err != nil {
+		return fmt.Errorf("创建schema_version表失败: %w", err)
+	}
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("读取表结构版本失败: %w", err)
+	}
+	if latest := LatestSchemaVersion(); current > latest {
+		return fmt.Errorf("数据库表结构版本 %d 高于程序支持的版本 %d，请升级程序或用新版本执行 migrate --to=%d 回退", current, latest, latest)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := d.runMigration(m.Up, `INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`, m.Version, m.Name, time.Now().UTC()); err != nil {
+			return fmt.Errorf("执行迁移 %d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		slog.Info("已执行数据库迁移", "version", m.Version, "name", m.Name)
+	}
+	return nil
+}
+
+// MigrateTo 把表结构回退到指定版本（依次执行更高版本迁移的 Down）
+// 只能回退，升级在打开数据库时自动完成
+func (d *Database) MigrateTo(target int) error {
+	if target < 0 {
+		return fmt.Errorf("目标版本不能小于0")
+	}
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("读取表结构版本失败: %w", err)
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= target || m.Version > current {
+			continue
+		}
+		if err := d.runMigration(m.Down, `DELETE FROM schema_version WHERE version = ?`, m.Version); err != nil {
+			return fmt.Errorf("回退迁移 %d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		slog.Info("已回退数据库迁移", "version", m.Version, "name", m.Name)
+	}
+	return nil
+}
+
+// runMigration 在一个事务中执行迁移语句并更新 schema_version
+func (d *Database) runMigration(statements []string, record string, args ...interface{}) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		// 引入版本化迁移之前的数据库已经有这些字段时跳过（PostgreSQL 改写为 ADD COLUMN IF NOT EXISTS）
+		if m := addColumnStmt.FindStringSubmatch(stmt); m != nil && d.driver == DriverSQLite {
+			var exists int
+			if err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, m[1], m[2]).Scan(&exists); err != nil {
+				return err
+			}
+			if exists > 0 {
+				continue
+			}
+		}
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("执行SQL失败 [%s]: %w", stmt, err)
+		}
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetMigrationStatus 所有迁移及其执行时间
+func (d *Database) GetMigrationStatus() ([]MigrationStatus, error) {
+	rows, err := d.db.Query(`SELECT version, applied_at FROM schema_version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := MigrationStatus{Version: m.Version, Name: m.Name}
+		if at, ok := applied[m.Version]; ok {
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
@@ -0,0 +1,6 @@
+//go:build postgres
+
+package config
+
+// PostgreSQL驱动只在 -tags postgres 构建时引入，默认构建只依赖SQLite
+import _ "github.com/lib/pq"
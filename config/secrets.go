@@ -0,0 +1,274 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// 加密密钥的环境变量
+const (
+	// EnvEncryptionKey 当前密钥（32字节，base64 或 hex 编码），新写入的密钥都用它加密
+	EnvEncryptionKey = "NOFX_ENCRYPTION_KEY"
+	// EnvEncryptionKeyFile 从文件读取当前密钥（KMS/密钥管理服务挂载的文件），优先于 EnvEncryptionKey
+	EnvEncryptionKeyFile = "NOFX_ENCRYPTION_KEY_FILE"
+	// EnvEncryptionOldKeys 轮换前的旧密钥，逗号分隔，只用于解密
+	EnvEncryptionOldKeys = "NOFX_ENCRYPTION_OLD_KEYS"
+)
+
+// encryptedPrefix 加密值的格式为 enc:v1:<密钥ID>:<base64(nonce|密文)>，没有前缀的值视为旧版明文
+const encryptedPrefix = "enc:v1:"
+
+// ErrSecretKeyMissing 数据库中的值由未配置的密钥加密
+var ErrSecretKeyMissing = errors.New("缺少解密API密钥所需的加密密钥（检查 " + EnvEncryptionKey + " / " + EnvEncryptionOldKeys + "）")
+
+// secretBox 用 AES-256-GCM 加解密数据库中的API密钥和私钥
+type secretBox struct {
+	currentID string
+	keys      map[string]cipher.AEAD // 密钥ID -> 加密器
+}
+
+// loadSecretBox 从环境变量加载密钥，未配置当前密钥时返回nil（明文存储）
+func loadSecretBox() (*secretBox, error) {
+	current := strings.TrimSpace(os.Getenv(EnvEncryptionKey))
+	if path := os.Getenv(EnvEncryptionKeyFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", EnvEncryptionKeyFile, err)
+		}
+		current = strings.TrimSpace(string(data))
+	}
+	var old []string
+	for _, k := range strings.Split(os.Getenv(EnvEncryptionOldKeys), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			old = append(old, k)
+		}
+	}
+	if current == "" {
+		if len(old) > 0 {
+			return nil, fmt.Errorf("配置了 %s 但没有配置 %s", EnvEncryptionOldKeys, EnvEncryptionKey)
+		}
+		return nil, nil
+	}
+	return newSecretBox(current, old...)
+}
+
+// newSecretBox 创建加解密器，current 用于加密，current 和 old 都可用于解密
+func newSecretBox(current string, old ...string) (*secretBox, error) {
+	box := &secretBox{keys: make(map[string]cipher.AEAD)}
+	for i, encoded := range append([]string{current}, old...) {
+		key, err := decodeEncryptionKey(encoded)
+		if err != nil {
+			return nil, err
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(key)
+		id := hex.EncodeToString(sum[:4])
+		box.keys[id] = aead
+		if i == 0 {
+			box.currentID = id
+		}
+	}
+	return box, nil
+}
+
+// decodeEncryptionKey 解析 base64 或 hex 编码的32字节密钥
+func decodeEncryptionKey(encoded string) ([]byte, error) {
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, fmt.Errorf("加密密钥必须是32字节的 base64 或 hex 编码（可用 openssl rand -base64 32 生成）")
+}
+
+// encrypt 加密一个值，空值保持为空；未配置密钥时原样返回
+func (b *secretBox) encrypt(plaintext string) (string, error) {
+	if b == nil || plaintext == "" {
+		return plaintext, nil
+	}
+	aead := b.keys[b.currentID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + b.currentID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt 解密一个值，明文（旧数据）原样返回
+func (b *secretBox) decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	id, payload, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !ok {
+		return "", fmt.Errorf("加密值格式错误")
+	}
+	if b == nil || b.keys[id] == nil {
+		return "", fmt.Errorf("%w: 密钥ID %s", ErrSecretKeyMissing, id)
+	}
+	aead := b.keys[id]
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("加密值格式错误")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("解密失败（密钥ID %s）: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// isCurrent 值已用当前密钥加密（空值不需要加密）
+func (b *secretBox) isCurrent(value string) bool {
+	return value == "" || strings.HasPrefix(value, encryptedPrefix+b.currentID+":")
+}
+
+// encryptSecrets 加密多个值
+func (d *Database) encryptSecrets(values ...*string) error {
+	for _, v := range values {
+		encrypted, err := d.secrets.encrypt(*v)
+		if err != nil {
+			return fmt.Errorf("加密API密钥失败: %w", err)
+		}
+		*v = encrypted
+	}
+	return nil
+}
+
+// decryptSecrets 就地解密多个值
+func (d *Database) decryptSecrets(values ...*string) error {
+	for _, v := range values {
+		plaintext, err := d.secrets.decrypt(*v)
+		if err != nil {
+			return err
+		}
+		*v = plaintext
+	}
+	return nil
+}
+
+// secretColumns 保存密钥的表和字段
+var secretColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"ai_models", []string{"api_key"}},
+	{"exchanges", []string{"api_key", "secret_key", "aster_private_key"}},
+}
+
+// reencryptSecrets 把未用当前密钥加密的值重新加密，返回更新的记录数
+// onlyPlaintext 为 true 时只加密明文，旧密钥加密的值保持不变
+func (d *Database) reencryptSecrets(onlyPlaintext bool) (int, error) {
+	if d.secrets == nil {
+		return 0, fmt.Errorf("未配置 %s", EnvEncryptionKey)
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	updated := 0
+	for _, t := range secretColumns {
+		rows, err := tx.Query(fmt.Sprintf(`SELECT id, user_id, COALESCE(%s, '') FROM %s`, strings.Join(t.columns, ", ''), COALESCE("), t.table))
+		if err != nil {
+			return 0, err
+		}
+		type row struct {
+			id, userID string
+			values     []string
+		}
+		var pending []row
+		for rows.Next() {
+			r := row{values: make([]string, len(t.columns))}
+			dest := []interface{}{&r.id, &r.userID}
+			for i := range r.values {
+				dest = append(dest, &r.values[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			changed := false
+			for i, v := range r.values {
+				if d.secrets.isCurrent(v) || (onlyPlaintext && strings.HasPrefix(v, encryptedPrefix)) {
+					continue
+				}
+				plaintext, err := d.secrets.decrypt(v)
+				if err != nil {
+					rows.Close()
+					return 0, fmt.Errorf("%s %s/%s: %w", t.table, r.userID, r.id, err)
+				}
+				if r.values[i], err = d.secrets.encrypt(plaintext); err != nil {
+					rows.Close()
+					return 0, err
+				}
+				changed = true
+			}
+			if changed {
+				pending = append(pending, r)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+
+		query := fmt.Sprintf(`UPDATE %s SET %s = ? WHERE id = ? AND user_id = ?`, t.table, strings.Join(t.columns, " = ?, "))
+		for _, r := range pending {
+			args := make([]interface{}, 0, len(r.values)+2)
+			for _, v := range r.values {
+				args = append(args, v)
+			}
+			if _, err := tx.Exec(query, append(args, r.id, r.userID)...); err != nil {
+				return 0, err
+			}
+			updated++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// encryptPlaintextSecrets 启动时加密旧版本留下的明文密钥
+func (d *Database) encryptPlaintextSecrets() error {
+	if d.secrets == nil {
+		slog.Warn("未配置 " + EnvEncryptionKey + "，交易所和AI模型的API密钥将以明文保存在数据库中")
+		return nil
+	}
+	n, err := d.reencryptSecrets(true)
+	if err != nil {
+		return fmt.Errorf("加密已有API密钥失败: %w", err)
+	}
+	if n > 0 {
+		slog.Info("已加密数据库中的明文API密钥", "records", n)
+	}
+	return nil
+}
+
+// RotateEncryptionKey 用当前密钥重新加密所有API密钥（包括明文和旧密钥加密的值），返回更新的记录数
+// 轮换步骤：把旧密钥放入 NOFX_ENCRYPTION_OLD_KEYS、新密钥设为 NOFX_ENCRYPTION_KEY 后执行，完成后可移除旧密钥
+func (d *Database) RotateEncryptionKey() (int, error) {
+	return d.reencryptSecrets(false)
+}
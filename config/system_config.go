@@ -0,0 +1,304 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 系统配置值类型
+const (
+	ConfigTypeBool    = "bool"
+	ConfigTypeInt     = "int"
+	ConfigTypeFloat   = "float"
+	ConfigTypeString  = "string"
+	ConfigTypeURL     = "url"
+	ConfigTypeCoins   = "coins"   // JSON字符串数组，如 ["BTCUSDT","ETHUSDT"]
+	ConfigTypeJSON    = "json"    // JSON对象，为空表示使用默认值
+	ConfigTypeChoice  = "choice"  // 只能取 Choices 中的值
+	ConfigTypeOrigins = "origins" // 逗号分隔的来源列表（scheme://host[:port]），* 表示任意来源
+	ConfigTypeHosts   = "hosts"   // 逗号分隔的域名列表，可以为空
+	ConfigTypeIPNets  = "ipnets"  // 逗号分隔的IP或CIDR列表，可以为空
+)
+
+// SystemConfigSpec 可通过管理接口修改的系统配置项
+type SystemConfigSpec struct {
+	Key             string   `json:"key"`
+	Type            string   `json:"type"`
+	Min             *float64 `json:"min,omitempty"`
+	Max             *float64 `json:"max,omitempty"`
+	Choices         []string `json:"choices,omitempty"`
+	Secret          bool     `json:"secret,omitempty"`           // 读取时脱敏
+	RequiresRestart bool     `json:"requires_restart,omitempty"` // 修改后需重启服务才生效
+	Description     string   `json:"description"`
+}
+
+func bound(v float64) *float64 { return &v }
+
+// systemConfigSpecs 管理接口可修改的配置白名单
+// admin_mode 和 jwt_secret 会影响所有登录态，只能通过 config.json 修改
+var systemConfigSpecs = []SystemConfigSpec{
+	{Key: "beta_mode", Type: ConfigTypeBool, Description: "内测模式，注册需要内测码"},
+	{Key: "api_server_port", Type: ConfigTypeInt, Min: bound(1), Max: bound(65535), RequiresRestart: true, Description: "API端口"},
+	{Key: "use_default_coins", Type: ConfigTypeBool, Description: "使用内置币种列表"},
+	{Key: "default_coins", Type: ConfigTypeCoins, Description: "默认币种列表"},
+	{Key: "coin_pool_api_url", Type: ConfigTypeURL, Description: "AI500币种池API"},
+	{Key: "oi_top_api_url", Type: ConfigTypeURL, Description: "OI Top API"},
+	{Key: "oi_top_provider", Type: ConfigTypeChoice, Choices: []string{"api", "builtin"}, Description: "OI Top来源：外部API，或根据币安公开接口计算1小时持仓量变化"},
+	{Key: "news_feed_url", Type: ConfigTypeURL, Description: "新闻标题RSS源"},
+	{Key: "candidate_max_age_minutes", Type: ConfigTypeInt, Min: bound(0), Description: "信号源数据超过多少分钟视为过期，不再作为候选币种（0 不过期）"},
+	{Key: "btc_eth_leverage", Type: ConfigTypeInt, Min: bound(1), Max: bound(125), Description: "新建交易员的BTC/ETH默认杠杆"},
+	{Key: "altcoin_leverage", Type: ConfigTypeInt, Min: bound(1), Max: bound(125), Description: "新建交易员的山寨币默认杠杆"},
+	{Key: "max_daily_loss", Type: ConfigTypeFloat, Min: bound(0), Max: bound(100), RequiresRestart: true, Description: "最大日损失百分比"},
+	{Key: "max_drawdown", Type: ConfigTypeFloat, Min: bound(0), Max: bound(100), RequiresRestart: true, Description: "最大回撤百分比"},
+	{Key: "stop_trading_minutes", Type: ConfigTypeInt, Min: bound(0), RequiresRestart: true, Description: "触发风控后停止交易的分钟数"},
+	{Key: "resume_traders_on_boot", Type: ConfigTypeBool, Description: "启动时恢复重启前运行中的交易员"},
+	{Key: "auto_restart_stalled_traders", Type: ConfigTypeBool, Description: "自动重启卡住或连续出错的交易员"},
+	{Key: "trader_resume_stagger_seconds", Type: ConfigTypeInt, Min: bound(0), Max: bound(600), Description: "启动恢复交易员的间隔（秒）"},
+	{Key: "paper_trading_costs", Type: ConfigTypeJSON, Description: "纸面交易费用与滑点"},
+	{Key: "orphan_position_policy", Type: ConfigTypeChoice, Choices: []string{"adopt", "close"}, Description: "启动对账时孤儿持仓的处理"},
+	{Key: "circuit_breaker_drop_pct", Type: ConfigTypeFloat, Min: bound(0), Max: bound(100), Description: "净值熔断回撤百分比（0 关闭）"},
+	{Key: "circuit_breaker_minutes", Type: ConfigTypeInt, Min: bound(1), Description: "净值熔断统计窗口（分钟）"},
+	{Key: "max_consecutive_losses", Type: ConfigTypeInt, Min: bound(0), Description: "连续亏损多少笔后暂停开仓（0 关闭）"},
+	{Key: "loss_cooldown_minutes", Type: ConfigTypeInt, Min: bound(1), Description: "连续亏损冷却时长（分钟）"},
+	{Key: "limit_max_leverage", Type: ConfigTypeInt, Min: bound(0), Max: bound(125), Description: "系统级杠杆上限（0 不限制）"},
+	{Key: "limit_max_notional", Type: ConfigTypeFloat, Min: bound(0), Description: "系统级单仓名义价值上限（USDT）"},
+	{Key: "limit_max_traders", Type: ConfigTypeInt, Min: bound(0), Description: "每个用户的交易员数量上限"},
+	{Key: "limit_max_running_traders", Type: ConfigTypeInt, Min: bound(0), Description: "每个用户同时运行的交易员数量上限"},
+	{Key: "limit_min_scan_interval", Type: ConfigTypeInt, Min: bound(0), Description: "交易员最小扫描间隔（分钟）"},
+	{Key: "balance_drift_threshold_pct", Type: ConfigTypeFloat, Min: bound(0), Max: bound(100), Description: "余额偏差告警阈值（%）"},
+	{Key: "funding_cost_close_pct", Type: ConfigTypeFloat, Min: bound(0), Description: "资金费超过浮盈此百分比时自动平仓"},
+	{Key: "max_net_delta_pct", Type: ConfigTypeFloat, Min: bound(0), Description: "净方向敞口占净值上限（%）"},
+	{Key: "order_retry_attempts", Type: ConfigTypeInt, Min: bound(1), Description: "下单遇到限频或网络错误时最多尝试次数（含第一次）"},
+	{Key: "order_retry_backoff_ms", Type: ConfigTypeInt, Min: bound(0), Description: "第一次重试前等待的毫秒数，之后每次翻倍"},
+	{Key: "rate_limit_ip", Type: ConfigTypeInt, Min: bound(0), RequiresRestart: true, Description: "每个IP每分钟请求数"},
+	{Key: "rate_limit_user", Type: ConfigTypeInt, Min: bound(0), RequiresRestart: true, Description: "每个登录用户每分钟请求数"},
+	{Key: "rate_limit_public", Type: ConfigTypeInt, Min: bound(0), RequiresRestart: true, Description: "每个IP每分钟访问公开接口次数"},
+	{Key: "rate_limit_auth", Type: ConfigTypeInt, Min: bound(0), RequiresRestart: true, Description: "每个IP每分钟登录/注册次数"},
+	{Key: "smtp_config", Type: ConfigTypeJSON, Secret: true, Description: "SMTP发信配置"},
+	{Key: "backup_config", Type: ConfigTypeJSON, Secret: true, Description: "备份目录、定时备份间隔、保留数量和S3上传"},
+	{Key: "public_cache_seconds", Type: ConfigTypeInt, Min: bound(0), Max: bound(300), RequiresRestart: true, Description: "公开的交易员列表、前几名和净值对比接口的响应缓存秒数（0 关闭）"},
+	{Key: "proxy_url", Type: ConfigTypeString, Secret: true, RequiresRestart: true, Description: "交易所API的默认代理（http/https/socks5），交易员未单独配置时使用"},
+	{Key: "redis_url", Type: ConfigTypeString, Secret: true, RequiresRestart: true, Description: "多个API副本共享的Redis缓存（redis://[:密码@]host:port/db），为空时使用进程内缓存"},
+	{Key: "decision_log_storage", Type: ConfigTypeJSON, Secret: true, RequiresRestart: true, Description: "决策日志存储（file/database/s3）"},
+	{Key: "password_reset_url", Type: ConfigTypeURL, Description: "密码重置邮件中的前端地址"},
+	{Key: "log_level", Type: ConfigTypeChoice, Choices: []string{"debug", "info", "warn", "error"}, Description: "日志级别"},
+	{Key: "log_format", Type: ConfigTypeChoice, Choices: []string{"text", "json"}, RequiresRestart: true, Description: "日志格式（json 便于日志收集）"},
+	{Key: "otlp_endpoint", Type: ConfigTypeURL, RequiresRestart: true, Description: "链路追踪OTLP/HTTP地址（为空不启用）"},
+	{Key: "tls_cert_file", Type: ConfigTypeString, RequiresRestart: true, Description: "HTTPS证书文件路径（PEM）"},
+	{Key: "tls_key_file", Type: ConfigTypeString, RequiresRestart: true, Description: "HTTPS私钥文件路径（PEM）"},
+	{Key: "tls_autocert_domains", Type: ConfigTypeHosts, RequiresRestart: true, Description: "Let's Encrypt自动证书域名（逗号分隔，与证书文件二选一）"},
+	{Key: "tls_autocert_cache_dir", Type: ConfigTypeString, RequiresRestart: true, Description: "自动证书缓存目录"},
+	{Key: "http_redirect_port", Type: ConfigTypeInt, Min: bound(0), Max: bound(65535), RequiresRestart: true, Description: "HTTP重定向到HTTPS的监听端口（0 不启用）"},
+	{Key: "cors_allowed_origins", Type: ConfigTypeOrigins, RequiresRestart: true, Description: "允许跨域访问的前端来源（逗号分隔，* 表示任意）"},
+	{Key: "trusted_proxies", Type: ConfigTypeIPNets, RequiresRestart: true, Description: "可信反向代理的IP或CIDR（逗号分隔），只有来自这些地址的 X-Forwarded-For 才用于识别客户端IP"},
+	{Key: "telegram_bot_token", Type: ConfigTypeString, Secret: true, RequiresRestart: true, Description: "Telegram机器人token"},
+	{Key: "daily_digest_hour", Type: ConfigTypeInt, Min: bound(0), Max: bound(23), Description: "每日邮件摘要发送时间（UTC小时）"},
+	{Key: "ai_max_concurrent_calls", Type: ConfigTypeInt, Min: bound(0), Description: "同一AI密钥同时进行的调用数上限（0 不限制）"},
+	{Key: "idle_trader_evict_minutes", Type: ConfigTypeInt, Min: bound(0), Description: "已停止的交易员超过多少分钟未访问时移出内存（0 不移除）"},
+	{Key: "max_idle_traders", Type: ConfigTypeInt, Min: bound(0), Description: "内存中最多保留的已停止交易员数量（0 不限制）"},
+	{Key: "decision_log_keep_days", Type: ConfigTypeInt, Min: bound(0), Description: "决策日志原始记录保留天数，更早的只保留按小时降采样的净值（0 永久保留）"},
+	{Key: "decision_log_gzip_days", Type: ConfigTypeInt, Min: bound(0), Description: "超过此天数的决策日志gzip压缩保存（0 不压缩）"},
+	{Key: "competition_rank_by", Type: ConfigTypeChoice, Choices: []string{"pnl_pct", "sharpe", "calmar", "drawdown_adjusted"}, Description: "竞赛默认排名方式：收益率、夏普比率、Calmar（收益率/最大回撤）、收益率减最大回撤"},
+	{Key: "competition_min_trades", Type: ConfigTypeInt, Min: bound(0), Description: "平仓笔数少于此值的交易员排在达标交易员之后（0 不限制）"},
+	{Key: "equity_sample_minutes", Type: ConfigTypeInt, Min: bound(0), Max: bound(1440), Description: "净值采样间隔（分钟），AI循环停止或出错时也记录净值曲线（0 关闭）"},
+	{Key: "ai_input_price_per_mtok", Type: ConfigTypeFloat, Min: bound(0), Description: "每百万输入token的AI费用（USD）"},
+	{Key: "ai_output_price_per_mtok", Type: ConfigTypeFloat, Min: bound(0), Description: "每百万输出token的AI费用（USD）"},
+}
+
+// SystemConfigSpecs 所有可修改的配置项
+func SystemConfigSpecs() []SystemConfigSpec {
+	return systemConfigSpecs
+}
+
+// LookupSystemConfigSpec 查找配置项定义，不在白名单中返回 false
+func LookupSystemConfigSpec(key string) (SystemConfigSpec, bool) {
+	for _, spec := range systemConfigSpecs {
+		if spec.Key == key {
+			return spec, true
+		}
+	}
+	return SystemConfigSpec{}, false
+}
+
+// SecretMask 敏感配置读取时显示的占位符，提交该值表示保持不变
+const SecretMask = "******"
+
+// MaskSystemConfig 敏感配置非空时返回占位符
+func MaskSystemConfig(key, value string) string {
+	if spec, ok := LookupSystemConfigSpec(key); ok && spec.Secret && value != "" {
+		return SecretMask
+	}
+	return value
+}
+
+// checkRange 数值范围校验
+func (s SystemConfigSpec) checkRange(v float64) error {
+	if s.Min != nil && v < *s.Min {
+		return fmt.Errorf("%s 不能小于 %v", s.Key, *s.Min)
+	}
+	if s.Max != nil && v > *s.Max {
+		return fmt.Errorf("%s 不能大于 %v", s.Key, *s.Max)
+	}
+	return nil
+}
+
+// validHostname 只含字母、数字、- 和 . 的域名（不带协议和端口）
+func validHostname(host string) bool {
+	if len(host) > 253 || !strings.Contains(host, ".") || strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ValidateSystemConfig 校验并规范化配置值，返回实际写入数据库的字符串
+func ValidateSystemConfig(key, value string) (string, error) {
+	spec, ok := LookupSystemConfigSpec(key)
+	if !ok {
+		return "", fmt.Errorf("配置项 %s 不存在或不允许修改", key)
+	}
+	value = strings.TrimSpace(value)
+
+	switch spec.Type {
+	case ConfigTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%s 必须是 true 或 false", key)
+		}
+		return strconv.FormatBool(b), nil
+
+	case ConfigTypeInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("%s 必须是整数", key)
+		}
+		if err := spec.checkRange(float64(n)); err != nil {
+			return "", err
+		}
+		return strconv.Itoa(n), nil
+
+	case ConfigTypeFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s 必须是数字", key)
+		}
+		if err := spec.checkRange(f); err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+
+	case ConfigTypeString:
+		return value, nil
+
+	case ConfigTypeURL:
+		if value == "" {
+			return "", nil
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("%s 必须是 http(s) 地址", key)
+		}
+		return value, nil
+
+	case ConfigTypeCoins:
+		var coins []string
+		if err := json.Unmarshal([]byte(value), &coins); err != nil {
+			return "", fmt.Errorf("%s 必须是JSON字符串数组: %w", key, err)
+		}
+		if len(coins) == 0 {
+			return "", fmt.Errorf("%s 至少需要一个币种", key)
+		}
+		for i, coin := range coins {
+			coin = strings.ToUpper(strings.TrimSpace(coin))
+			if !strings.HasSuffix(coin, "USDT") || len(coin) <= len("USDT") {
+				return "", fmt.Errorf("无效的币种 %q，需为 xxxUSDT 格式", coins[i])
+			}
+			coins[i] = coin
+		}
+		normalized, _ := json.Marshal(coins)
+		return string(normalized), nil
+
+	case ConfigTypeJSON:
+		if value == "" {
+			return "", nil
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(value), &obj); err != nil {
+			return "", fmt.Errorf("%s 必须是JSON对象: %w", key, err)
+		}
+		return value, nil
+
+	case ConfigTypeChoice:
+		for _, choice := range spec.Choices {
+			if value == choice {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("%s 必须是 %s 之一", key, strings.Join(spec.Choices, "、"))
+
+	case ConfigTypeOrigins:
+		var origins []string
+		for _, origin := range strings.Split(value, ",") {
+			origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+			if origin == "" {
+				continue
+			}
+			if origin != "*" {
+				u, err := url.Parse(origin)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+					return "", fmt.Errorf("无效的来源 %q，需为 scheme://host[:port] 格式", origin)
+				}
+				origin = strings.ToLower(origin)
+			}
+			origins = append(origins, origin)
+		}
+		if len(origins) == 0 {
+			return "", fmt.Errorf("%s 至少需要一个来源", key)
+		}
+		return strings.Join(origins, ","), nil
+
+	case ConfigTypeHosts:
+		var hosts []string
+		for _, host := range strings.Split(value, ",") {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if host == "" {
+				continue
+			}
+			if !validHostname(host) {
+				return "", fmt.Errorf("无效的域名 %q", host)
+			}
+			hosts = append(hosts, host)
+		}
+		return strings.Join(hosts, ","), nil
+
+	case ConfigTypeIPNets:
+		var nets []string
+		for _, entry := range strings.Split(value, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			if net.ParseIP(entry) == nil {
+				if _, _, err := net.ParseCIDR(entry); err != nil {
+					return "", fmt.Errorf("无效的IP或CIDR %q", entry)
+				}
+			}
+			nets = append(nets, entry)
+		}
+		return strings.Join(nets, ","), nil
+	}
+	return "", fmt.Errorf("配置项 %s 类型未知", key)
+}
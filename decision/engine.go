@@ -1,16 +1,33 @@
 package decision
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"nofx/logger"
 	"nofx/market"
 	"nofx/mcp"
 	"nofx/pool"
+	"nofx/tracing"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	marketDataWorkers   = 8                // 市场数据并发获取的worker数量
+	marketDataTimeout   = 15 * time.Second // 单个币种市场数据获取超时
+	maxEntrySlippageBps = 30.0             // 开仓允许的最大预估滑点（基点）
+	maxCandidateCoins   = 20               // 单次分析的候选币种上限（控制prompt长度），交易员未设置时使用
 )
 
+// MaxCandidatesLimit 交易员可设置的候选币种上限（Context.MaxCandidates），更多币种会让prompt过长
+const MaxCandidatesLimit = 60
+
 // PositionInfo 持仓信息
 type PositionInfo struct {
 	Symbol           string  `json:"symbol"`
@@ -24,6 +41,7 @@ type PositionInfo struct {
 	LiquidationPrice float64 `json:"liquidation_price"`
 	MarginUsed       float64 `json:"margin_used"`
 	UpdateTime       int64   `json:"update_time"` // 持仓更新时间戳（毫秒）
+	FundingFee       float64 `json:"funding_fee"` // 持仓期间累计资金费净收入（负数表示支付，交易所不支持时为0）
 }
 
 // AccountInfo 账户信息
@@ -35,14 +53,24 @@ type AccountInfo struct {
 	MarginUsed       float64 `json:"margin_used"`       // 已用保证金
 	MarginUsedPct    float64 `json:"margin_used_pct"`   // 保证金使用率
 	PositionCount    int     `json:"position_count"`    // 持仓数量
+	NetDelta         float64 `json:"net_delta"`         // 净方向敞口（多头名义价值 - 空头名义价值，USDT）
 }
 
 // CandidateCoin 候选币种（来自币种池）
 type CandidateCoin struct {
 	Symbol  string   `json:"symbol"`
-	Sources []string `json:"sources"` // 来源: "ai500" 和/或 "oi_top"
+	Sources []string `json:"sources"`         // 来源: "ai500"、"oi_top"、"signal"（外部推送）、用户信号源名称等
+	Score   float64  `json:"score,omitempty"` // 信号源合并得分（pool.Candidate.Score），手动配置的币种为0
 }
 
+// 候选币种未发给AI的原因（Context.SkippedCandidates）
+const (
+	SkipTruncated       = "truncated"         // 超过候选数量上限
+	SkipStaleMarketData = "stale_market_data" // 行情数据过期
+	SkipFetchFailed     = "fetch_failed"      // 行情获取失败
+	SkipLowLiquidity    = "low_liquidity"     // 未达到流动性门槛（Context.Liquidity）
+)
+
 // OITopData 持仓量增长Top数据（用于AI决策参考）
 type OITopData struct {
 	Rank              int     // OI Top排名
@@ -55,17 +83,87 @@ type OITopData struct {
 
 // Context 交易上下文（传递给AI的完整信息）
 type Context struct {
-	CurrentTime     string                  `json:"current_time"`
-	RuntimeMinutes  int                     `json:"runtime_minutes"`
-	CallCount       int                     `json:"call_count"`
-	Account         AccountInfo             `json:"account"`
-	Positions       []PositionInfo          `json:"positions"`
-	CandidateCoins  []CandidateCoin         `json:"candidate_coins"`
-	MarketDataMap   map[string]*market.Data `json:"-"` // 不序列化，但内部使用
-	OITopDataMap    map[string]*OITopData   `json:"-"` // OI Top数据映射
-	Performance     interface{}             `json:"-"` // 历史表现分析（logger.PerformanceAnalysis）
-	BTCETHLeverage  int                     `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
-	AltcoinLeverage int                     `json:"-"` // 山寨币杠杆倍数（从配置读取）
+	CurrentTime       string                     `json:"current_time"`
+	RuntimeMinutes    int                        `json:"runtime_minutes"`
+	CallCount         int                        `json:"call_count"`
+	Account           AccountInfo                `json:"account"`
+	Positions         []PositionInfo             `json:"positions"`
+	CandidateCoins    []CandidateCoin            `json:"candidate_coins"`
+	MarketDataMap     map[string]*market.Data    `json:"-"` // 不序列化，但内部使用
+	OITopDataMap      map[string]*OITopData      `json:"-"` // OI Top数据映射
+	Performance       interface{}                `json:"-"` // 历史表现分析（logger.PerformanceAnalysis）
+	BTCETHLeverage    int                        `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
+	AltcoinLeverage   int                        `json:"-"` // 山寨币杠杆倍数（从配置读取）
+	StaleSymbols      []string                   `json:"-"` // 行情数据过期而被排除的币种
+	SkippedCandidates map[string]string          `json:"-"` // 未发给AI的币种 -> 原因（Skip*），由 fetchMarketDataForContext 填写
+	MaxCandidates     int                        `json:"-"` // 交易员设置的候选币种上限（0 使用默认值20）
+	Liquidity         LiquidityFilter            `json:"-"` // 交易员设置的最低流动性要求
+	LiquidityFiltered []logger.FilteredSymbol    `json:"-"` // 因流动性不足被排除的币种（按币种排序），由 fetchMarketDataForContext 填写
+	MarketRegime      *market.MarketRegimeData   `json:"-"` // 全市场状态汇总
+	MarketOverview    *market.MarketOverview     `json:"-"` // 全市场概览（BTC占比/总市值）
+	Sentiment         *market.SentimentData      `json:"-"` // 市场情绪（恐贪指数/新闻标题）
+	Correlation       *market.CorrelationSummary `json:"-"` // 持仓+候选币种的4h收益相关性摘要
+	Limits            Limits                     `json:"-"` // 管理员设置的硬性上限
+	MaxNetDeltaPct    float64                    `json:"-"` // 净方向敞口上限（占净值百分比，0 不限制）
+	FailedOrders      []FailedOrder              `json:"-"` // 上个周期执行失败的决策
+	Signals           []ExternalSignal           `json:"-"` // 外部推送的未过期信号
+	OnPromptBuilt     PromptHook                 `json:"-"` // prompt构建完成、调用AI之前的回调（可选）
+	CycleContext      context.Context            `json:"-"` // 本周期的上下文：取消时中止行情获取和AI调用，也是链路追踪的父span（可选）
+}
+
+// cycleContext 未设置时返回 context.Background()
+func (ctx *Context) cycleContext() context.Context {
+	if ctx.CycleContext == nil {
+		return context.Background()
+	}
+	return ctx.CycleContext
+}
+
+// currentTimeLayout CurrentTime 的格式（本地时区）
+const currentTimeLayout = "2006-01-02 15:04:05"
+
+// cycleTime 本周期的时间：回放/回测时 CurrentTime 是历史时间，交易时段也应按它计算；无法解析时使用当前时间
+func (ctx *Context) cycleTime() time.Time {
+	if t, err := time.ParseInLocation(currentTimeLayout, ctx.CurrentTime, time.Local); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// FailedOrder 执行失败的决策及原因分类
+type FailedOrder struct {
+	Symbol     string `json:"symbol"`
+	Action     string `json:"action"`
+	ErrorClass string `json:"error_class"` // insufficient_margin / precision / rate_limit / network / rejected / other
+	Error      string `json:"error"`
+}
+
+// ExternalSignal 外部系统（TradingView告警、自建扫描器等）通过 POST /api/signals 推送的信号
+type ExternalSignal struct {
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side,omitempty"`       // long / short，为空表示只加入候选币种
+	Confidence int       `json:"confidence,omitempty"` // 0-100，0 表示未提供
+	Note       string    `json:"note,omitempty"`
+	Source     string    `json:"source,omitempty"` // 推送方自定义的来源名称
+	ReceivedAt time.Time `json:"received_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// PromptHook 拿到本周期发送给AI的 system/user prompt
+type PromptHook func(systemPrompt, userPrompt string)
+
+// Limits 管理员设置的硬性上限，覆盖交易员配置（0 表示不限制）
+type Limits struct {
+	MaxLeverage int     `json:"max_leverage"`
+	MaxNotional float64 `json:"max_notional"` // 单个仓位名义价值上限（USDT）
+}
+
+// capLeverage 把配置的杠杆限制在上限以内
+func (l Limits) capLeverage(leverage int) int {
+	if l.MaxLeverage > 0 && leverage > l.MaxLeverage {
+		return l.MaxLeverage
+	}
+	return leverage
 }
 
 // Decision AI的交易决策
@@ -91,43 +189,89 @@ type FullDecision struct {
 }
 
 // GetFullDecision 获取AI的完整交易决策（批量分析所有币种和持仓）
-func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error) {
+func GetFullDecision(ctx *Context, mcpClient mcp.AIClient) (*FullDecision, error) {
 	return GetFullDecisionWithCustomPrompt(ctx, mcpClient, "", false, "")
 }
 
 // GetFullDecisionWithCustomPrompt 获取AI的完整交易决策（支持自定义prompt和模板选择）
-func GetFullDecisionWithCustomPrompt(ctx *Context, mcpClient *mcp.Client, customPrompt string, overrideBase bool, templateName string) (*FullDecision, error) {
+func GetFullDecisionWithCustomPrompt(ctx *Context, mcpClient mcp.AIClient, customPrompt string, overrideBase bool, templateName string) (*FullDecision, error) {
+	cycleCtx := ctx.cycleContext()
+
 	// 1. 为所有币种获取市场数据
-	if err := fetchMarketDataForContext(ctx); err != nil {
+	_, span := tracing.Start(cycleCtx, "decision.fetch_market_data", attribute.Int("candidate_count", len(ctx.CandidateCoins)))
+	err := fetchMarketDataForContext(ctx)
+	span.SetAttributes(attribute.Int("stale_count", len(ctx.StaleSymbols)))
+	tracing.End(span, err)
+	if err != nil {
 		return nil, fmt.Errorf("获取市场数据失败: %w", err)
 	}
 
-	// 2. 构建 System Prompt（固定规则）和 User Prompt（动态数据）
-	systemPrompt := buildSystemPromptWithCustom(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, customPrompt, overrideBase, templateName)
+	// 2. 构建 System Prompt（固定规则）和 User Prompt（动态数据），杠杆按管理员上限截断
+	_, span = tracing.Start(cycleCtx, "decision.build_prompt", attribute.String("template", templateName))
+	btcEthLeverage := ctx.Limits.capLeverage(ctx.BTCETHLeverage)
+	altcoinLeverage := ctx.Limits.capLeverage(ctx.AltcoinLeverage)
+	systemPrompt := buildSystemPromptWithCustom(ctx.Account.TotalEquity, btcEthLeverage, altcoinLeverage, customPrompt, overrideBase, templateName)
 	userPrompt := BuildUserPrompt(ctx)
+	span.SetAttributes(attribute.Int("system_prompt_chars", len(systemPrompt)), attribute.Int("user_prompt_chars", len(userPrompt)))
+	tracing.End(span, nil)
+	if ctx.OnPromptBuilt != nil {
+		ctx.OnPromptBuilt(systemPrompt, userPrompt)
+	}
 
 	// 3. 调用AI API（使用 system + user prompt）
-	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	_, span = tracing.Start(cycleCtx, "decision.ai_call")
+	aiResponse, err := mcp.CallWithContext(cycleCtx, mcpClient, systemPrompt, userPrompt)
+	span.SetAttributes(attribute.Int("response_chars", len(aiResponse)))
+	tracing.End(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("调用AI API失败: %w", err)
 	}
 
 	// 4. 解析AI响应
-	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	_, span = tracing.Start(cycleCtx, "decision.validate")
+	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, btcEthLeverage, altcoinLeverage, ctx.Limits)
+	if decision != nil {
+		span.SetAttributes(attribute.Int("decision_count", len(decision.Decisions)))
+	}
+	tracing.End(span, err)
 	if err != nil {
 		return decision, fmt.Errorf("解析AI响应失败: %w", err)
 	}
 
+	// 5. 按订单簿深度拒绝预估滑点过高的开仓
+	rejectHighSlippageEntries(decision.Decisions, ctx.MarketDataMap)
+
 	decision.Timestamp = time.Now()
 	decision.SystemPrompt = systemPrompt // 保存系统prompt
 	decision.UserPrompt = userPrompt     // 保存输入prompt
 	return decision, nil
 }
 
+// ReplayDecision 用已记录的User Prompt重新请求AI决策（回测用，不获取实时行情）
+func ReplayDecision(mcpClient mcp.AIClient, userPrompt string, accountEquity float64, btcEthLeverage, altcoinLeverage int, templateName string) (*FullDecision, error) {
+	systemPrompt := buildSystemPromptWithCustom(accountEquity, btcEthLeverage, altcoinLeverage, "", false, templateName)
+
+	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("调用AI API失败: %w", err)
+	}
+
+	decision, err := parseFullDecisionResponse(aiResponse, accountEquity, btcEthLeverage, altcoinLeverage, Limits{})
+	if err != nil {
+		return decision, fmt.Errorf("解析AI响应失败: %w", err)
+	}
+
+	decision.Timestamp = time.Now()
+	decision.SystemPrompt = systemPrompt
+	decision.UserPrompt = userPrompt
+	return decision, nil
+}
+
 // fetchMarketDataForContext 为上下文中的所有币种获取市场数据和OI数据
 func fetchMarketDataForContext(ctx *Context) error {
 	ctx.MarketDataMap = make(map[string]*market.Data)
 	ctx.OITopDataMap = make(map[string]*OITopData)
+	ctx.SkippedCandidates = make(map[string]string)
 
 	// 收集所有需要获取数据的币种
 	symbolSet := make(map[string]bool)
@@ -137,7 +281,13 @@ func fetchMarketDataForContext(ctx *Context) error {
 		symbolSet[pos.Symbol] = true
 	}
 
-	// 2. 候选币种数量根据账户状态动态调整
+	// 外部推送信号的币种不受候选数量上限影响
+	for _, signal := range ctx.Signals {
+		symbolSet[signal.Symbol] = true
+	}
+
+	// 2. 候选币种先按波动率和成交额排序，再按数量上限截断
+	rankCandidateCoins(ctx.CandidateCoins)
 	maxCandidates := calculateMaxCandidates(ctx)
 	for i, coin := range ctx.CandidateCoins {
 		if i >= maxCandidates {
@@ -145,38 +295,107 @@ func fetchMarketDataForContext(ctx *Context) error {
 		}
 		symbolSet[coin.Symbol] = true
 	}
+	for _, coin := range ctx.CandidateCoins[min(maxCandidates, len(ctx.CandidateCoins)):] {
+		if !symbolSet[coin.Symbol] {
+			ctx.SkippedCandidates[coin.Symbol] = SkipTruncated
+		}
+	}
 
-	// 并发获取市场数据
 	// 持仓币种集合（用于判断是否跳过OI检查）
 	positionSymbols := make(map[string]bool)
 	for _, pos := range ctx.Positions {
 		positionSymbols[pos.Symbol] = true
 	}
 
+	// 行情数据过期的币种不参与决策，避免AI基于过时价格开平仓
+	symbols := make([]string, 0, len(symbolSet))
 	for symbol := range symbolSet {
-		data, err := market.Get(symbol)
-		if err != nil {
-			// 单个币种失败不影响整体，只记录错误
+		if market.IsStale(symbol) {
+			slog.Warn("行情数据已过期，跳过此币种", "symbol", symbol, "stale_threshold", market.GetStaleThreshold())
+			ctx.StaleSymbols = append(ctx.StaleSymbols, symbol)
+			ctx.SkippedCandidates[symbol] = SkipStaleMarketData
 			continue
 		}
+		symbols = append(symbols, symbol)
+	}
+
+	// 并发获取市场数据
+	cycleCtx := ctx.cycleContext()
+	dataMap, fetchErrors := fetchMarketDataConcurrently(cycleCtx, symbols)
+	if err := cycleCtx.Err(); err != nil {
+		return err
+	}
+	if len(fetchErrors) > 0 {
+		failed := make([]string, 0, len(fetchErrors))
+		for symbol, err := range fetchErrors {
+			failed = append(failed, fmt.Sprintf("%s(%v)", symbol, err))
+			ctx.SkippedCandidates[symbol] = SkipFetchFailed
+		}
+		sort.Strings(failed)
+		slog.Warn("部分币种市场数据获取失败", "failed", len(fetchErrors), "total", len(symbols), "errors", strings.Join(failed, "; "))
+	}
+	if len(symbols) > 0 && len(dataMap) == 0 {
+		return fmt.Errorf("全部%d个币种的市场数据获取失败", len(symbols))
+	}
 
-		// ⚠️ 流动性过滤：持仓价值低于15M USD的币种不做（多空都不做）
-		// 持仓价值 = 持仓量 × 当前价格
+	for symbol, data := range dataMap {
+		// ⚠️ 流动性过滤：未达到门槛的币种不做（多空都不做）
+		// 持仓价值 = 持仓量 × 当前价格，24h成交额来自已缓存的K线
 		// 但现有持仓必须保留（需要决策是否平仓）
-		isExistingPosition := positionSymbols[symbol]
-		if !isExistingPosition && data.OpenInterest != nil && data.CurrentPrice > 0 {
-			// 计算持仓价值（USD）= 持仓量 × 当前价格
-			oiValue := data.OpenInterest.Latest * data.CurrentPrice
-			oiValueInMillions := oiValue / 1_000_000 // 转换为百万美元单位
-			if oiValueInMillions < 15 {
-				log.Printf("⚠️  %s 持仓价值过低(%.2fM USD < 15M)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
-					symbol, oiValueInMillions, data.OpenInterest.Latest, data.CurrentPrice)
+		if !positionSymbols[symbol] {
+			var oiValue, volume24h float64
+			if data.OpenInterest != nil && data.CurrentPrice > 0 {
+				oiValue = data.OpenInterest.Latest * data.CurrentPrice
+			}
+			if m, ok := market.GetCandidateMetrics(symbol); ok {
+				volume24h = m.QuoteVolume24h
+			}
+			if reason := ctx.Liquidity.failedBy(oiValue, volume24h); reason != "" {
+				slog.Info("流动性不足，跳过此币种", "symbol", symbol, "reason", reason,
+					"oi_value_millions", oiValue/1_000_000, "volume_24h_millions", volume24h/1_000_000)
+				ctx.SkippedCandidates[symbol] = SkipLowLiquidity
+				ctx.LiquidityFiltered = append(ctx.LiquidityFiltered, logger.FilteredSymbol{
+					Symbol: symbol, Reason: reason, OIValue: oiValue, Volume24h: volume24h,
+				})
 				continue
 			}
 		}
 
 		ctx.MarketDataMap[symbol] = data
 	}
+	sort.Slice(ctx.LiquidityFiltered, func(i, j int) bool {
+		return ctx.LiquidityFiltered[i].Symbol < ctx.LiquidityFiltered[j].Symbol
+	})
+
+	// 汇总全市场状态
+	regimes := make([]*market.RegimeData, 0, len(ctx.MarketDataMap))
+	for _, data := range ctx.MarketDataMap {
+		regimes = append(regimes, data.Regime)
+	}
+	ctx.MarketRegime = market.SummarizeMarketRegime(regimes)
+
+	// 相关性只在实际获取到数据的币种间计算
+	if len(ctx.MarketDataMap) > 1 {
+		symbols := make([]string, 0, len(ctx.MarketDataMap))
+		for symbol := range ctx.MarketDataMap {
+			symbols = append(symbols, symbol)
+		}
+		ctx.Correlation = market.CalculateCorrelationSummary(symbols)
+	}
+
+	// 全市场概览每周期获取一次，所有币种共享（失败不影响主流程）
+	if overview, err := market.GetMarketOverview(); err == nil {
+		ctx.MarketOverview = overview
+	} else {
+		slog.Warn("获取全市场概览失败", "error", err)
+	}
+
+	// 市场情绪（失败不影响主流程）
+	if sentiment, err := market.GetSentiment(); err == nil {
+		ctx.Sentiment = sentiment
+	} else {
+		slog.Warn("获取市场情绪失败", "error", err)
+	}
 
 	// 加载OI Top数据（不影响主流程）
 	oiPositions, err := pool.GetOITopPositions()
@@ -198,12 +417,108 @@ func fetchMarketDataForContext(ctx *Context) error {
 	return nil
 }
 
+// fetchMarketDataConcurrently 使用有界worker池并发获取市场数据，单个币种超时或失败不影响其他币种
+// ctx 取消后不再分发新的币种，进行中的请求也不再等待
+func fetchMarketDataConcurrently(ctx context.Context, symbols []string) (map[string]*market.Data, map[string]error) {
+	type result struct {
+		symbol string
+		data   *market.Data
+		err    error
+	}
+
+	jobs := make(chan string)
+	results := make(chan result, len(symbols))
+
+	workers := marketDataWorkers
+	if len(symbols) < workers {
+		workers = len(symbols)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range jobs {
+				data, err := getMarketDataWithTimeout(ctx, symbol, marketDataTimeout)
+				results <- result{symbol: symbol, data: data, err: err}
+			}
+		}()
+	}
+
+dispatch:
+	for _, symbol := range symbols {
+		select {
+		case jobs <- symbol:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+
+	dataMap := make(map[string]*market.Data, len(symbols))
+	errs := make(map[string]error)
+	for r := range results {
+		if r.err != nil {
+			errs[r.symbol] = r.err
+			continue
+		}
+		dataMap[r.symbol] = r.data
+	}
+	return dataMap, errs
+}
+
+// getMarketDataWithTimeout 获取单个币种市场数据，超时后放弃等待（后台请求自然结束）
+func getMarketDataWithTimeout(ctx context.Context, symbol string, timeout time.Duration) (*market.Data, error) {
+	type result struct {
+		data *market.Data
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := market.Get(symbol)
+		done <- result{data: data, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("超时(%v)", timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // calculateMaxCandidates 根据账户状态计算需要分析的候选币种数量
 func calculateMaxCandidates(ctx *Context) int {
-	// 直接返回候选池的全部币种数量
-	// 因为候选池已经在 auto_trader.go 中筛选过了
-	// 固定分析前20个评分最高的币种（来自AI500）
-	return len(ctx.CandidateCoins)
+	// 候选池已经在 auto_trader.go 中筛选过，并已按 rankCandidateCoins 排序
+	// 超过上限时只保留排序靠前的币种
+	limit := maxCandidateCoins
+	if ctx.MaxCandidates > 0 {
+		limit = min(ctx.MaxCandidates, MaxCandidatesLimit)
+	}
+	return min(len(ctx.CandidateCoins), limit)
+}
+
+// rankCandidateCoins 按ATR%和24h成交额原地排序候选币种，让最值得交易的币种在截断后保留下来
+func rankCandidateCoins(coins []CandidateCoin) {
+	if len(coins) < 2 {
+		return
+	}
+
+	symbols := make([]string, len(coins))
+	bySymbol := make(map[string]CandidateCoin, len(coins))
+	for i, coin := range coins {
+		symbols[i] = coin.Symbol
+		bySymbol[coin.Symbol] = coin
+	}
+
+	for i, symbol := range market.RankCandidates(symbols) {
+		coins[i] = bySymbol[symbol]
+	}
 }
 
 // buildSystemPromptWithCustom 构建包含自定义内容的 System Prompt
@@ -245,11 +560,11 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 	template, err := GetPromptTemplate(templateName)
 	if err != nil {
 		// 如果模板不存在，记录错误并使用 default
-		log.Printf("⚠️  提示词模板 '%s' 不存在，使用 default: %v", templateName, err)
+		slog.Warn("提示词模板不存在，使用 default", "template", templateName, "error", err)
 		template, err = GetPromptTemplate("default")
 		if err != nil {
 			// 如果连 default 都不存在，使用内置的简化版本
-			log.Printf("❌ 无法加载任何提示词模板，使用内置简化版本")
+			slog.Error("无法加载任何提示词模板，使用内置简化版本")
 			sb.WriteString("你是专业的加密货币交易AI。请根据市场数据做出交易决策。\n\n")
 		} else {
 			sb.WriteString(template.Content)
@@ -291,11 +606,22 @@ func BuildUserPrompt(ctx *Context) string {
 	promptData := make(map[string]interface{})
 
 	// 1. 系统信息
-	promptData["system"] = map[string]interface{}{
+	systemInfo := map[string]interface{}{
 		"current_time":    ctx.CurrentTime,
 		"call_count":      ctx.CallCount,
 		"runtime_minutes": ctx.RuntimeMinutes,
 	}
+	// 交易时段和关键时间点（K线收盘、资金费结算前后波动通常放大）
+	session := market.GetSessionInfo(ctx.cycleTime())
+	systemInfo["trading_sessions"] = session.Sessions
+	systemInfo["minutes_to_4h_close"] = session.MinutesTo4hClose
+	systemInfo["minutes_to_daily_close"] = session.MinutesToDailyClose
+	systemInfo["minutes_to_funding"] = session.MinutesToFunding
+	if len(ctx.StaleSymbols) > 0 {
+		// 告知AI哪些币种行情过期（无市场数据），避免对其做出决策
+		systemInfo["stale_symbols"] = ctx.StaleSymbols
+	}
+	promptData["system"] = systemInfo
 
 	// 2. 账户信息
 	accountEquity := ctx.Account.TotalEquity
@@ -328,16 +654,25 @@ func BuildUserPrompt(ctx *Context) string {
 			"pnl_percent":          pnlPercent,
 			"holding_time_minutes": holdingTimeMinutes,
 		}
+		if pos.FundingFee != 0 {
+			position["funding_fee"] = pos.FundingFee
+		}
 		positions = append(positions, position)
 	}
 
-	promptData["account"] = map[string]interface{}{
+	accountInfo := map[string]interface{}{
 		"account_equity":    accountEquity,
 		"used_margin":       usedMargin,
 		"available_balance": availableBalance,
 		"margin_usage_rate": marginUsageRate,
+		"net_delta":         ctx.Account.NetDelta,
 		"positions":         positions,
 	}
+	if ctx.MaxNetDeltaPct > 0 {
+		// 超过上限的同向开仓会被拒绝，让AI提前知道剩余空间
+		accountInfo["max_net_delta"] = accountEquity * ctx.MaxNetDeltaPct / 100
+	}
+	promptData["account"] = accountInfo
 
 	// 3. 市场数据
 	marketData := make(map[string]interface{})
@@ -463,6 +798,48 @@ func BuildUserPrompt(ctx *Context) string {
 			// 买卖压力比（基于实际数据估算）
 			indicators["buy_sell_pressure_ratio"] = 0.4 // 暂时使用默认值
 
+			// 市场状态（趋势/波动率）
+			if marketDataItem.Regime != nil {
+				symbolData["regime"] = map[string]interface{}{
+					"trend":          marketDataItem.Regime.Trend,
+					"volatility":     marketDataItem.Regime.Volatility,
+					"adx":            marketDataItem.Regime.ADX,
+					"atr_percentile": marketDataItem.Regime.ATRPercentile,
+				}
+			}
+
+			// 订单簿流动性（价差/深度/参考规模的预估滑点）
+			if liq := marketDataItem.Liquidity; liq != nil {
+				slippage := make(map[string]float64, len(liq.SlippageBps))
+				for size, bps := range liq.SlippageBps {
+					slippage[fmt.Sprintf("%.0f_usd", size)] = bps
+				}
+				symbolData["liquidity"] = map[string]interface{}{
+					"spread_bps":    liq.SpreadBps,
+					"bid_depth_usd": liq.BidDepthUSD,
+					"ask_depth_usd": liq.AskDepthUSD,
+					"slippage_bps":  slippage,
+				}
+			}
+
+			// RSI/MACD背离（bullish=底背离, bearish=顶背离, none=无）
+			divergence := make(map[string]interface{})
+			if marketDataItem.Divergence3m != nil {
+				divergence["3m"] = map[string]string{
+					"rsi":  marketDataItem.Divergence3m.RSI,
+					"macd": marketDataItem.Divergence3m.MACD,
+				}
+			}
+			if marketDataItem.Divergence4h != nil {
+				divergence["4h"] = map[string]string{
+					"rsi":  marketDataItem.Divergence4h.RSI,
+					"macd": marketDataItem.Divergence4h.MACD,
+				}
+			}
+			if len(divergence) > 0 {
+				indicators["divergence"] = divergence
+			}
+
 			symbolData["indicators"] = indicators
 
 			// 成交量分析（使用实际市场数据）
@@ -503,16 +880,56 @@ func BuildUserPrompt(ctx *Context) string {
 				}
 			}
 
+			// 支撑阻力位（4h/1d波段点聚类）
+			srData, err := market.CalculateSupportResistance(symbol)
+			if err == nil && srData != nil {
+				symbolData["support_resistance"] = map[string]interface{}{
+					"nearest_support":    srData.NearestSupport,
+					"nearest_resistance": srData.NearestResistance,
+					"supports":           srData.Supports,
+					"resistances":        srData.Resistances,
+				}
+			}
+
 			marketData[symbol] = symbolData
 		}
 	}
 
 	promptData["market_data"] = marketData
+	if ctx.MarketRegime != nil {
+		promptData["market_regime"] = ctx.MarketRegime
+	}
+	if ctx.MarketOverview != nil {
+		promptData["market_overview"] = ctx.MarketOverview
+	}
+	if ctx.Sentiment != nil {
+		promptData["sentiment"] = ctx.Sentiment
+	}
+	if ctx.Correlation != nil {
+		// 高相关币种同向开仓等同于加倍同一笔风险
+		promptData["correlation"] = ctx.Correlation
+	}
+	if perf, ok := ctx.Performance.(*logger.PerformanceAnalysis); ok && perf != nil && len(perf.RecentTrades) > 0 {
+		promptData["recent_trades"] = buildRecentTrades(perf)
+	}
+	if len(ctx.Signals) > 0 {
+		promptData["external_signals"] = map[string]interface{}{
+			"note":    "用户接入的外部系统推送的信号（side为空表示只推荐关注该币种）。信号仅供参考，需结合行情数据独立判断，不要仅凭信号开仓",
+			"signals": ctx.Signals,
+		}
+	}
+	if len(ctx.FailedOrders) > 0 {
+		// 告知AI上个周期哪些下单失败及原因，避免原样重复（如保证金不足时减小仓位）
+		promptData["failed_orders"] = map[string]interface{}{
+			"note":   "上个周期以下决策执行失败。insufficient_margin=保证金不足（减小仓位或杠杆），precision=数量或名义价值不满足交易所要求（调整仓位大小），rate_limit/network=交易所暂时不可用",
+			"orders": ctx.FailedOrders,
+		}
+	}
 
 	// 将数据转换为JSON字符串
 	jsonData, err := json.MarshalIndent(promptData, "", "  ")
 	if err != nil {
-		log.Printf("构建用户提示失败: %v", err)
+		slog.Error("构建用户提示失败", "error", err)
 		return fmt.Sprintf("时间: %s | 周期: #%d | 运行: %d分钟\n\n---\n\n现在请分析并输出决策（思维链 + JSON）\n",
 			ctx.CurrentTime, ctx.CallCount, ctx.RuntimeMinutes)
 	}
@@ -520,6 +937,49 @@ func BuildUserPrompt(ctx *Context) string {
 	return string(jsonData)
 }
 
+// maxRecentTradesInPrompt prompt中展示的最近已平仓交易数量
+const maxRecentTradesInPrompt = 5
+
+// buildRecentTrades 最近已平仓交易的实际结果（平仓原因、R倍数），供AI复盘
+func buildRecentTrades(perf *logger.PerformanceAnalysis) map[string]interface{} {
+	trades := perf.RecentTrades // 已按最新在前排序
+	if len(trades) > maxRecentTradesInPrompt {
+		trades = trades[:maxRecentTradesInPrompt]
+	}
+
+	items := make([]map[string]interface{}, 0, len(trades))
+	for _, t := range trades {
+		item := map[string]interface{}{
+			"symbol":          t.Symbol,
+			"side":            t.Side,
+			"pnl":             t.PnL,
+			"exit_reason":     t.ExitReason,
+			"holding_minutes": int(t.CloseTime.Sub(t.OpenTime).Minutes()),
+		}
+		if t.RiskUSD > 0 {
+			item["r_multiple"] = t.PnL / t.RiskUSD
+		}
+		if len(t.UserNotes) > 0 {
+			item["user_notes"] = t.UserNotes
+		}
+		if t.UserRating > 0 {
+			item["user_rating"] = t.UserRating
+		}
+		items = append(items, item)
+	}
+
+	summary := map[string]interface{}{
+		"total_trades": perf.TotalTrades,
+		"win_rate":     perf.WinRate,
+		"trades":       items,
+	}
+	if perf.Metrics != nil {
+		summary["avg_r"] = perf.Metrics.AvgR
+		summary["profit_factor"] = perf.Metrics.ProfitFactor
+	}
+	return summary
+}
+
 // maxFloat64 返回float64切片中的最大值
 func maxFloat64(nums ...float64) float64 {
 	if len(nums) == 0 {
@@ -549,7 +1009,7 @@ func minFloat64(nums ...float64) float64 {
 }
 
 // parseFullDecisionResponse 解析AI的完整决策响应
-func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthLeverage, altcoinLeverage int) (*FullDecision, error) {
+func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthLeverage, altcoinLeverage int, limits Limits) (*FullDecision, error) {
 	// 1. 提取思维链
 	cotTrace := extractCoTTrace(aiResponse)
 
@@ -563,7 +1023,7 @@ func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthL
 	}
 
 	// 3. 验证决策
-	if err := validateDecisions(decisions, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
+	if err := validateDecisions(decisions, accountEquity, btcEthLeverage, altcoinLeverage, limits); err != nil {
 		return &FullDecision{
 			CoTTrace:  cotTrace,
 			Decisions: decisions,
@@ -631,15 +1091,52 @@ func fixMissingQuotes(jsonStr string) string {
 }
 
 // validateDecisions 验证所有决策（需要账户信息和杠杆配置）
-func validateDecisions(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
+func validateDecisions(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int, limits Limits) error {
 	for i, decision := range decisions {
-		if err := validateDecision(&decision, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
+		if err := validateDecision(&decision, accountEquity, btcEthLeverage, altcoinLeverage, limits); err != nil {
 			return fmt.Errorf("决策 #%d 验证失败: %w", i+1, err)
 		}
 	}
 	return nil
 }
 
+// rejectHighSlippageEntries 将预估滑点超过阈值的开仓决策改为wait（只拒绝该条，不影响其他决策）
+func rejectHighSlippageEntries(decisions []Decision, marketDataMap map[string]*market.Data) {
+	for i := range decisions {
+		d := &decisions[i]
+		if d.Action != "open_long" && d.Action != "open_short" {
+			continue
+		}
+		data, ok := marketDataMap[d.Symbol]
+		if !ok || data == nil {
+			continue
+		}
+		// 订单簿获取失败或为空时无从判断深度，跳过检查而不是一律拒绝
+		if data.Liquidity == nil || data.Liquidity.OrderBook == nil {
+			slog.Warn("订单簿不可用，跳过滑点检查", "symbol", d.Symbol)
+			continue
+		}
+		book := data.Liquidity.OrderBook
+		if len(book.Bids) == 0 || len(book.Asks) == 0 {
+			slog.Warn("订单簿为空，跳过滑点检查", "symbol", d.Symbol)
+			continue
+		}
+
+		bps, filled := book.EstimateSlippage(d.PositionSizeUSD, d.Action == "open_long")
+		if filled && bps <= maxEntrySlippageBps {
+			continue
+		}
+
+		reason := fmt.Sprintf("预估滑点%.1fbps超过上限%.0fbps", bps, maxEntrySlippageBps)
+		if !filled {
+			reason = "订单簿深度不足以成交全部仓位"
+		}
+		slog.Warn("拒绝开仓", "symbol", d.Symbol, "action", d.Action, "position_size_usd", d.PositionSizeUSD, "reason", reason)
+		d.Reasoning = fmt.Sprintf("[已拒绝: %s] %s", reason, d.Reasoning)
+		d.Action = "wait"
+	}
+}
+
 // findMatchingBracket 查找匹配的右括号
 func findMatchingBracket(s string, start int) int {
 	if start >= len(s) || s[start] != '[' {
@@ -663,7 +1160,7 @@ func findMatchingBracket(s string, start int) int {
 }
 
 // validateDecision 验证单个决策的有效性
-func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
+func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int, limits Limits) error {
 	// 验证action
 	validActions := map[string]bool{
 		"open_long":   true,
@@ -691,9 +1188,15 @@ func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoi
 		if d.Leverage <= 0 || d.Leverage > maxLeverage {
 			return fmt.Errorf("杠杆必须在1-%d之间（%s，当前配置上限%d倍）: %d", maxLeverage, d.Symbol, maxLeverage, d.Leverage)
 		}
+		if limits.MaxLeverage > 0 && d.Leverage > limits.MaxLeverage {
+			return fmt.Errorf("杠杆超过管理员设置的上限%d倍: %d", limits.MaxLeverage, d.Leverage)
+		}
 		if d.PositionSizeUSD <= 0 {
 			return fmt.Errorf("仓位大小必须大于0: %.2f", d.PositionSizeUSD)
 		}
+		if limits.MaxNotional > 0 && d.PositionSizeUSD > limits.MaxNotional {
+			return fmt.Errorf("仓位价值超过管理员设置的上限%.0f USDT，实际: %.0f", limits.MaxNotional, d.PositionSizeUSD)
+		}
 		// 验证仓位价值上限（加1%容差以避免浮点数精度问题）
 		tolerance := maxPositionValue * 0.01 // 1%容差
 		if d.PositionSizeUSD > maxPositionValue+tolerance {
@@ -0,0 +1,133 @@
+package decision
+
+import (
+	"nofx/market"
+	"testing"
+	"time"
+)
+
+func TestValidateDecisionLimits(t *testing.T) {
+	d := Decision{
+		Symbol:          "BTCUSDT",
+		Action:          "open_long",
+		Leverage:        10,
+		PositionSizeUSD: 5000,
+		StopLoss:        95000,
+		TakeProfit:      110000,
+	}
+
+	if err := validateDecision(&d, 1000, 20, 5, Limits{}); err != nil {
+		t.Fatalf("无上限时应通过验证: %v", err)
+	}
+	if err := validateDecision(&d, 1000, 20, 5, Limits{MaxLeverage: 5}); err == nil {
+		t.Error("杠杆超过管理员上限应被拒绝")
+	}
+	if err := validateDecision(&d, 1000, 20, 5, Limits{MaxNotional: 2000}); err == nil {
+		t.Error("名义价值超过管理员上限应被拒绝")
+	}
+
+	closeDecision := Decision{Symbol: "BTCUSDT", Action: "close_long"}
+	if err := validateDecision(&closeDecision, 1000, 20, 5, Limits{MaxLeverage: 1, MaxNotional: 1}); err != nil {
+		t.Errorf("平仓不受上限影响: %v", err)
+	}
+
+	if got := (Limits{MaxLeverage: 3}).capLeverage(10); got != 3 {
+		t.Errorf("杠杆应截断到3，得到 %d", got)
+	}
+	if got := (Limits{}).capLeverage(10); got != 10 {
+		t.Errorf("无上限时杠杆不变，得到 %d", got)
+	}
+}
+
+func TestRejectHighSlippageEntriesSkipsUnavailableBook(t *testing.T) {
+	deep := &market.OrderBook{
+		Bids: []market.OrderBookLevel{{Price: 99, Quantity: 1000}},
+		Asks: []market.OrderBookLevel{{Price: 100, Quantity: 1000}},
+	}
+	thin := &market.OrderBook{
+		Bids: []market.OrderBookLevel{{Price: 99, Quantity: 1}},
+		Asks: []market.OrderBookLevel{{Price: 100, Quantity: 1}},
+	}
+	dataMap := map[string]*market.Data{
+		"DEEPUSDT":   {Liquidity: &market.LiquidityData{OrderBook: deep}},
+		"THINUSDT":   {Liquidity: &market.LiquidityData{OrderBook: thin}},
+		"EMPTYUSDT":  {Liquidity: &market.LiquidityData{OrderBook: &market.OrderBook{}}},
+		"NOBOOKUSDT": {},
+	}
+	decisions := []Decision{
+		{Symbol: "DEEPUSDT", Action: "open_long", PositionSizeUSD: 1000},
+		{Symbol: "THINUSDT", Action: "open_long", PositionSizeUSD: 1000},
+		{Symbol: "EMPTYUSDT", Action: "open_short", PositionSizeUSD: 1000},
+		{Symbol: "NOBOOKUSDT", Action: "open_long", PositionSizeUSD: 1000},
+	}
+
+	rejectHighSlippageEntries(decisions, dataMap)
+
+	want := []string{"open_long", "wait", "open_short", "open_long"}
+	for i, d := range decisions {
+		if d.Action != want[i] {
+			t.Errorf("%s: 动作 %s，期望 %s（订单簿不可用或为空时应跳过检查）", d.Symbol, d.Action, want[i])
+		}
+	}
+}
+
+func TestCycleTimeUsesContextTime(t *testing.T) {
+	ctx := &Context{CurrentTime: "2024-03-01 02:30:00"}
+	want := time.Date(2024, 3, 1, 2, 30, 0, 0, time.Local)
+	if got := ctx.cycleTime(); !got.Equal(want) {
+		t.Errorf("回放时应使用周期时间 %v，得到 %v", want, got)
+	}
+
+	if got := (&Context{}).cycleTime(); time.Since(got) > time.Minute {
+		t.Errorf("未设置时间时应回退到当前时间，得到 %v", got)
+	}
+}
+
+func TestCalculateMaxCandidates(t *testing.T) {
+	coins := make([]CandidateCoin, 80)
+	cases := []struct {
+		setting, n, want int
+	}{
+		{0, 80, maxCandidateCoins},
+		{0, 5, 5},
+		{8, 80, 8},
+		{40, 30, 30},
+		{500, 80, MaxCandidatesLimit},
+	}
+	for _, c := range cases {
+		ctx := &Context{CandidateCoins: coins[:c.n], MaxCandidates: c.setting}
+		if got := calculateMaxCandidates(ctx); got != c.want {
+			t.Errorf("max_candidates=%d 候选%d个: 得到 %d，应为 %d", c.setting, c.n, got, c.want)
+		}
+	}
+}
+
+func TestLiquidityFilter(t *testing.T) {
+	cases := []struct {
+		filter             LiquidityFilter
+		oiValue, volume24h float64
+		want               string
+	}{
+		{LiquidityFilter{}, 10e6, 1e9, LiquidityByOIValue},
+		{LiquidityFilter{}, 20e6, 1e6, ""},
+		{LiquidityFilter{}, 0, 0, ""}, // 缺少数据不过滤
+		{LiquidityFilter{MinOIValue: 5e6}, 10e6, 0, ""},
+		{LiquidityFilter{Mode: LiquidityByVolume}, 1e6, 60e6, ""},
+		{LiquidityFilter{Mode: LiquidityByVolume}, 1e9, 40e6, LiquidityByVolume},
+		{LiquidityFilter{Mode: LiquidityByBoth, MinVolume24h: 10e6}, 20e6, 5e6, LiquidityByVolume},
+		{LiquidityFilter{Mode: LiquidityByBoth}, 10e6, 1e9, LiquidityByOIValue},
+		{LiquidityFilter{Mode: LiquidityOff}, 1, 1, ""},
+	}
+	for _, c := range cases {
+		if got := c.filter.failedBy(c.oiValue, c.volume24h); got != c.want {
+			t.Errorf("%+v 持仓价值%.0f 成交额%.0f: 得到 %q，应为 %q", c.filter, c.oiValue, c.volume24h, got, c.want)
+		}
+	}
+
+	if err := (LiquidityFilter{Mode: "spread"}).Validate(); err == nil {
+		t.Error("未知的过滤方式应校验失败")
+	}
+	if err := (LiquidityFilter{MinVolume24h: -1}).Validate(); err == nil {
+		t.Error("负数门槛应校验失败")
+	}
+}
@@ -0,0 +1,63 @@
+package decision
+
+import "fmt"
+
+// 流动性过滤方式（LiquidityFilter.Mode）
+const (
+	LiquidityByOIValue = "oi_value" // 持仓价值（持仓量×价格）
+	LiquidityByVolume  = "volume"   // 24h成交额
+	LiquidityByBoth    = "both"     // 持仓价值和24h成交额都需达到门槛
+	LiquidityOff       = "off"      // 不过滤
+)
+
+const (
+	defaultMinOIValue   = 15_000_000 // 默认最低持仓价值（USDT）
+	defaultMinVolume24h = 50_000_000 // 默认最低24h成交额（USDT）
+)
+
+// LiquidityFilter 候选币种的最低流动性要求，持仓中的币种不过滤
+type LiquidityFilter struct {
+	Mode         string  // oi_value（默认）/ volume / both / off
+	MinOIValue   float64 // 最低持仓价值（USDT），0 使用默认值15M
+	MinVolume24h float64 // 最低24h成交额（USDT），0 使用默认值50M
+}
+
+// Validate 校验过滤方式和门槛
+func (f LiquidityFilter) Validate() error {
+	switch f.Mode {
+	case "", LiquidityByOIValue, LiquidityByVolume, LiquidityByBoth, LiquidityOff:
+	default:
+		return fmt.Errorf("不支持的流动性过滤方式 %q（可选 %s、%s、%s、%s）", f.Mode, LiquidityByOIValue, LiquidityByVolume, LiquidityByBoth, LiquidityOff)
+	}
+	if f.MinOIValue < 0 || f.MinVolume24h < 0 {
+		return fmt.Errorf("流动性门槛不能为负数")
+	}
+	return nil
+}
+
+// failedBy 返回币种未达到的要求（oi_value / volume），都达到时返回空字符串；缺少数据（<=0）的指标不检查
+func (f LiquidityFilter) failedBy(oiValue, volume24h float64) string {
+	minOI, minVolume := f.MinOIValue, f.MinVolume24h
+	if minOI == 0 {
+		minOI = defaultMinOIValue
+	}
+	if minVolume == 0 {
+		minVolume = defaultMinVolume24h
+	}
+	switch f.Mode {
+	case LiquidityOff:
+		return ""
+	case LiquidityByVolume:
+		minOI = 0
+	case LiquidityByBoth:
+	default:
+		minVolume = 0
+	}
+	if oiValue > 0 && oiValue < minOI {
+		return LiquidityByOIValue
+	}
+	if volume24h > 0 && volume24h < minVolume {
+		return LiquidityByVolume
+	}
+	return ""
+}
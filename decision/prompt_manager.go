@@ -2,7 +2,7 @@ package decision
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -32,9 +32,9 @@ var (
 func init() {
 	globalPromptManager = NewPromptManager()
 	if err := globalPromptManager.LoadTemplates(promptsDir); err != nil {
-		log.Printf("⚠️  加载提示词模板失败: %v", err)
+		slog.Warn("加载提示词模板失败", "error", err)
 	} else {
-		log.Printf("✓ 已加载 %d 个系统提示词模板", len(globalPromptManager.templates))
+		slog.Info("已加载系统提示词模板", "count", len(globalPromptManager.templates))
 	}
 }
 
@@ -62,7 +62,7 @@ func (pm *PromptManager) LoadTemplates(dir string) error {
 	}
 
 	if len(files) == 0 {
-		log.Printf("⚠️  提示词目录 %s 中没有找到 .txt 文件", dir)
+		slog.Warn("提示词目录中没有找到 .txt 文件", "dir", dir)
 		return nil
 	}
 
@@ -71,7 +71,7 @@ func (pm *PromptManager) LoadTemplates(dir string) error {
 		// 读取文件内容
 		content, err := os.ReadFile(file)
 		if err != nil {
-			log.Printf("⚠️  读取提示词文件失败 %s: %v", file, err)
+			slog.Warn("读取提示词文件失败", "file", file, "error", err)
 			continue
 		}
 
@@ -85,7 +85,7 @@ func (pm *PromptManager) LoadTemplates(dir string) error {
 			Content: string(content),
 		}
 
-		log.Printf("  📄 加载提示词模板: %s (%s)", templateName, fileName)
+		slog.Debug("加载提示词模板", "template", templateName, "file", fileName)
 	}
 
 	return nil
@@ -0,0 +1,200 @@
+package digest
+
+import (
+	"fmt"
+	"nofx/config"
+	"nofx/logger"
+	"nofx/manager"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRejections 每个交易员在摘要中列出的拒绝原因条数
+const maxRejections = 5
+
+// Prices 每百万token的AI费用（USD）
+type Prices struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+}
+
+// LoadPrices 从系统配置读取AI费用单价
+func LoadPrices(database *config.Database) Prices {
+	var p Prices
+	if v, err := database.GetSystemConfig("ai_input_price_per_mtok"); err == nil {
+		p.InputPerMTok, _ = strconv.ParseFloat(v, 64)
+	}
+	if v, err := database.GetSystemConfig("ai_output_price_per_mtok"); err == nil {
+		p.OutputPerMTok, _ = strconv.ParseFloat(v, 64)
+	}
+	return p
+}
+
+// Rejection 同一原因被拒绝的次数
+type Rejection struct {
+	Reason string `json:"reason"`
+	Count  int    `json:"count"`
+}
+
+// TraderSummary 单个交易员在统计区间内的表现
+type TraderSummary struct {
+	TraderID         string      `json:"trader_id"`
+	Name             string      `json:"name"`
+	StartEquity      float64     `json:"start_equity"`
+	EndEquity        float64     `json:"end_equity"`
+	RealizedPnL      float64     `json:"realized_pnl"`
+	ClosedTrades     int         `json:"closed_trades"`
+	OpenedTrades     int         `json:"opened_trades"`
+	Cycles           int         `json:"cycles"`
+	PromptTokens     int64       `json:"prompt_tokens"`
+	CompletionTokens int64       `json:"completion_tokens"`
+	AICost           float64     `json:"ai_cost"` // 按系统配置的单价估算（USD）
+	RejectionCount   int         `json:"rejection_count"`
+	Rejections       []Rejection `json:"rejections"` // 出现次数最多的拒绝原因
+}
+
+// Report 一个用户的每日摘要
+type Report struct {
+	UserID  string          `json:"user_id"`
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Traders []TraderSummary `json:"traders"`
+}
+
+// Build 汇总用户所有已加载交易员在 [from, to) 内的表现
+func Build(database *config.Database, traderManager *manager.TraderManager, userID string, from, to time.Time) (*Report, error) {
+	traders, err := database.GetTraders(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易员列表失败: %w", err)
+	}
+
+	prices := LoadPrices(database)
+	report := &Report{UserID: userID, From: from, To: to, Traders: []TraderSummary{}}
+	for _, t := range traders {
+		at, err := traderManager.GetTrader(t.ID)
+		if err != nil {
+			continue
+		}
+		summary, err := Summarize(at.GetDecisionLogger(), from, to, prices)
+		if err != nil {
+			return nil, fmt.Errorf("汇总交易员 %s 失败: %w", t.Name, err)
+		}
+		summary.TraderID = t.ID
+		summary.Name = t.Name
+		report.Traders = append(report.Traders, *summary)
+	}
+	return report, nil
+}
+
+// Summarize 从决策日志统计一个交易员在 [from, to) 内的表现
+func Summarize(l *logger.DecisionLogger, from, to time.Time, prices Prices) (*TraderSummary, error) {
+	records, err := l.GetRecordsBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &TraderSummary{Rejections: []Rejection{}}
+	reasons := make(map[string]int)
+	for _, record := range records {
+		if !record.Timestamp.Before(to) {
+			continue
+		}
+		if summary.Cycles == 0 {
+			summary.StartEquity = record.AccountState.TotalBalance
+		}
+		summary.EndEquity = record.AccountState.TotalBalance
+		summary.Cycles++
+		summary.PromptTokens += record.PromptTokens
+		summary.CompletionTokens += record.CompletionTokens
+
+		// AI输出未通过决策校验时整个周期被拒绝
+		if strings.Contains(record.ErrorMessage, "决策验证失败") {
+			reasons[rejectionReason(record.ErrorMessage)]++
+		}
+		for _, action := range record.Decisions {
+			if action.Success {
+				if action.Action == "open_long" || action.Action == "open_short" {
+					summary.OpenedTrades++
+				}
+				continue
+			}
+			if action.Error != "" {
+				reasons[fmt.Sprintf("%s %s: %s", action.Symbol, action.Action, action.Error)]++
+			}
+		}
+	}
+
+	// GetRealizedPnLBetween 统计 (from, to]，这里往前挪1ns得到 [from, to)
+	summary.RealizedPnL, summary.ClosedTrades, err = l.GetRealizedPnLBetween(from.Add(-time.Nanosecond), to.Add(-time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+
+	summary.AICost = float64(summary.PromptTokens)/1e6*prices.InputPerMTok + float64(summary.CompletionTokens)/1e6*prices.OutputPerMTok
+
+	for reason, count := range reasons {
+		summary.RejectionCount += count
+		summary.Rejections = append(summary.Rejections, Rejection{Reason: reason, Count: count})
+	}
+	sort.Slice(summary.Rejections, func(i, j int) bool {
+		if summary.Rejections[i].Count != summary.Rejections[j].Count {
+			return summary.Rejections[i].Count > summary.Rejections[j].Count
+		}
+		return summary.Rejections[i].Reason < summary.Rejections[j].Reason
+	})
+	if len(summary.Rejections) > maxRejections {
+		summary.Rejections = summary.Rejections[:maxRejections]
+	}
+	return summary, nil
+}
+
+// rejectionReason 去掉错误链中重复的前缀，只保留校验失败的具体原因
+func rejectionReason(message string) string {
+	if i := strings.LastIndex(message, "验证失败: "); i >= 0 {
+		return "决策校验: " + message[i+len("验证失败: "):]
+	}
+	return message
+}
+
+// Render 生成纯文本邮件的标题和正文
+func Render(r *Report) (string, string) {
+	day := r.From.UTC().Format("2006-01-02")
+	subject := fmt.Sprintf("NOFX 每日摘要 %s", day)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "统计区间: %s ~ %s (UTC)\n\n", r.From.UTC().Format("2006-01-02 15:04"), r.To.UTC().Format("2006-01-02 15:04"))
+	if len(r.Traders) == 0 {
+		sb.WriteString("没有运行中的交易员。\n")
+	}
+
+	var totalPnL, totalCost float64
+	for _, t := range r.Traders {
+		totalPnL += t.RealizedPnL
+		totalCost += t.AICost
+
+		fmt.Fprintf(&sb, "== %s ==\n", t.Name)
+		if t.Cycles == 0 {
+			sb.WriteString("当天没有决策周期\n\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "净值: %.2f → %.2f USDT (%+.2f)\n", t.StartEquity, t.EndEquity, t.EndEquity-t.StartEquity)
+		fmt.Fprintf(&sb, "已实现盈亏: %+.2f USDT（平仓 %d 笔）\n", t.RealizedPnL, t.ClosedTrades)
+		fmt.Fprintf(&sb, "开仓: %d 笔，决策周期: %d 次\n", t.OpenedTrades, t.Cycles)
+		fmt.Fprintf(&sb, "AI成本: 约 $%.4f（输入 %d / 输出 %d tokens）\n", t.AICost, t.PromptTokens, t.CompletionTokens)
+		if t.RejectionCount > 0 {
+			fmt.Fprintf(&sb, "被拒绝/失败的决策: %d 次\n", t.RejectionCount)
+			for _, rej := range t.Rejections {
+				fmt.Fprintf(&sb, "  - %s ×%d\n", rej.Reason, rej.Count)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(r.Traders) > 1 {
+		fmt.Fprintf(&sb, "合计已实现盈亏: %+.2f USDT，AI成本约 $%.4f\n", totalPnL, totalCost)
+	}
+	sb.WriteString("\n如需退订，请在账户设置中关闭每日摘要。\n")
+	return subject, sb.String()
+}
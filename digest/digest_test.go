@@ -0,0 +1,97 @@
+package digest
+
+import (
+	"math"
+	"nofx/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	l := logger.NewDecisionLogger(t.TempDir())
+	openTime := time.Now().Add(-30 * time.Minute)
+	records := []*logger.DecisionRecord{
+		{
+			AccountState:     logger.AccountSnapshot{TotalBalance: 1000},
+			PromptTokens:     800000,
+			CompletionTokens: 100000,
+			Decisions: []logger.DecisionAction{
+				{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.1, Price: 60000, StopLoss: 59000, Timestamp: openTime, Success: true},
+				{Action: "open_short", Symbol: "ETHUSDT", Error: "净值熔断中，禁止开仓"},
+			},
+		},
+		{
+			AccountState:     logger.AccountSnapshot{TotalBalance: 1150},
+			PromptTokens:     200000,
+			CompletionTokens: 100000,
+			Success:          false,
+			ErrorMessage:     "获取AI决策失败: 决策验证失败: 决策 #1 验证失败: 杠杆超过上限",
+		},
+		{
+			AccountState: logger.AccountSnapshot{TotalBalance: 1200},
+			Decisions: []logger.DecisionAction{
+				{Action: "open_short", Symbol: "ETHUSDT", Error: "净值熔断中，禁止开仓"},
+			},
+		},
+	}
+	for _, r := range records {
+		if err := l.LogDecision(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := l.LabelOutcome("BTCUSDT", "long", 62000, time.Now(), logger.ExitReasonTakeProfit); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Summarize(l, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), Prices{InputPerMTok: 0.5, OutputPerMTok: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Cycles != 3 || s.OpenedTrades != 1 || s.ClosedTrades != 1 || math.Abs(s.RealizedPnL-200) > 1e-9 {
+		t.Errorf("交易统计错误: %+v", s)
+	}
+	if s.StartEquity != 1000 || s.EndEquity != 1200 {
+		t.Errorf("净值统计错误: %+v", s)
+	}
+	// 1M输入 × 0.5 + 0.2M输出 × 2
+	if math.Abs(s.AICost-0.9) > 1e-9 {
+		t.Errorf("AI成本应为0.9，实际 %v", s.AICost)
+	}
+	if s.RejectionCount != 3 || len(s.Rejections) != 2 || s.Rejections[0].Count != 2 {
+		t.Fatalf("拒绝统计错误: %+v", s.Rejections)
+	}
+	if s.Rejections[1].Reason != "决策校验: 杠杆超过上限" {
+		t.Errorf("校验失败原因应去掉重复前缀: %q", s.Rejections[1].Reason)
+	}
+
+	empty, err := Summarize(l, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour), Prices{})
+	if err != nil || empty.Cycles != 0 || empty.ClosedTrades != 0 {
+		t.Errorf("区间外的记录不应计入: %+v %v", empty, err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	report := &Report{From: from, To: from.AddDate(0, 0, 1), Traders: []TraderSummary{{
+		Name: "btc-bot", Cycles: 10, StartEquity: 1000, EndEquity: 1010, RealizedPnL: 12.5, ClosedTrades: 2, AICost: 0.0123,
+		RejectionCount: 1, Rejections: []Rejection{{Reason: "决策校验: 杠杆超过上限", Count: 1}},
+	}}}
+
+	subject, body := Render(report)
+	if subject != "NOFX 每日摘要 2025-03-01" {
+		t.Errorf("标题错误: %s", subject)
+	}
+	for _, want := range []string{"btc-bot", "+12.50", "$0.0123", "杠杆超过上限 ×1"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("正文缺少 %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestLastDay(t *testing.T) {
+	from, to := LastDay(time.Date(2025, 3, 2, 7, 30, 0, 0, time.UTC))
+	if !from.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("区间错误: %v ~ %v", from, to)
+	}
+}
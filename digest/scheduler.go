@@ -0,0 +1,99 @@
+package digest
+
+import (
+	"fmt"
+	"log/slog"
+	"nofx/auth"
+	"nofx/config"
+	"nofx/manager"
+	"strconv"
+	"time"
+)
+
+// checkInterval 检查是否到达发送时间的间隔
+const checkInterval = 5 * time.Minute
+
+// Scheduler 每天在 daily_digest_hour（UTC）之后给订阅的用户发送前一天的摘要
+type Scheduler struct {
+	database      *config.Database
+	traderManager *manager.TraderManager
+}
+
+// NewScheduler 创建摘要定时任务
+func NewScheduler(database *config.Database, traderManager *manager.TraderManager) *Scheduler {
+	return &Scheduler{database: database, traderManager: traderManager}
+}
+
+// Start 开始定时检查（阻塞，调用方使用 go 启动）
+func (s *Scheduler) Start() {
+	slog.Info("每日邮件摘要任务已启动")
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	s.RunDue(time.Now())
+	for now := range ticker.C {
+		s.RunDue(now)
+	}
+}
+
+// LastDay 返回 now 之前最近一个完整的UTC日 [from, to)
+func LastDay(now time.Time) (time.Time, time.Time) {
+	to := now.UTC().Truncate(24 * time.Hour)
+	return to.AddDate(0, 0, -1), to
+}
+
+// RunDue 到达发送时间后，给当天还没收到摘要的订阅用户发送
+func (s *Scheduler) RunDue(now time.Time) {
+	hour := 0
+	if v, err := s.database.GetSystemConfig("daily_digest_hour"); err == nil {
+		hour, _ = strconv.Atoi(v)
+	}
+	now = now.UTC()
+	if now.Hour() < hour {
+		return
+	}
+
+	day := now.Format("2006-01-02")
+	userIDs, err := s.database.GetPendingDailyDigests(day)
+	if err != nil {
+		slog.Warn("获取每日摘要订阅失败", "error", err)
+		return
+	}
+
+	from, to := LastDay(now)
+	for _, userID := range userIDs {
+		if err := s.Send(userID, from, to); err != nil {
+			// 不标记已发送，下次检查时重试
+			slog.Warn("发送每日摘要失败", "user_id", userID, "error", err)
+			continue
+		}
+		if err := s.database.MarkDailyDigestSent(userID, day); err != nil {
+			slog.Warn("记录每日摘要发送状态失败", "user_id", userID, "error", err)
+		}
+	}
+}
+
+// Send 生成并发送用户在 [from, to) 的摘要；已禁用或没有交易员的账户直接跳过
+func (s *Scheduler) Send(userID string, from, to time.Time) error {
+	user, err := s.database.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("获取用户失败: %w", err)
+	}
+	if user.Disabled {
+		return nil
+	}
+
+	report, err := Build(s.database, s.traderManager, userID, from, to)
+	if err != nil {
+		return err
+	}
+	if len(report.Traders) == 0 {
+		return nil
+	}
+	subject, body := Render(report)
+	if err := auth.GetMailer().Send(user.Email, subject, body); err != nil {
+		return err
+	}
+	slog.Info("已发送每日摘要", "user_id", userID, "email", user.Email)
+	return nil
+}
@@ -0,0 +1,47 @@
+package digest
+
+import (
+	"nofx/config"
+	"nofx/manager"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunDue(t *testing.T) {
+	database, err := config.NewDatabase(filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.CreateUser(&config.User{ID: "alice", Email: "alice@test.com", OTPVerified: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetDailyDigest("alice", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.SetSystemConfig("daily_digest_hour", "8"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScheduler(database, manager.NewTraderManager())
+	s.RunDue(time.Date(2025, 3, 2, 7, 59, 0, 0, time.UTC))
+	if pending, _ := database.GetPendingDailyDigests("2025-03-02"); len(pending) != 1 {
+		t.Fatalf("未到发送时间不应处理: %v", pending)
+	}
+
+	s.RunDue(time.Date(2025, 3, 2, 8, 0, 0, 0, time.UTC))
+	if pending, _ := database.GetPendingDailyDigests("2025-03-02"); len(pending) != 0 {
+		t.Errorf("当天处理后不应再次发送: %v", pending)
+	}
+	if pending, _ := database.GetPendingDailyDigests("2025-03-03"); len(pending) != 1 {
+		t.Errorf("第二天应再次发送: %v", pending)
+	}
+
+	if err := database.SetDailyDigest("alice", false); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := database.GetPendingDailyDigests("2025-03-03"); len(pending) != 0 {
+		t.Errorf("退订后不应发送: %v", pending)
+	}
+}
@@ -0,0 +1,83 @@
+// Package health 记录外部依赖（AI提供商、交易所API）最近一次真实调用的结果，供健康检查使用
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 依赖状态
+const (
+	StatusOK        = "ok"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+	StatusUnknown   = "unknown" // 还没有调用记录
+)
+
+// Status 一个依赖的调用记录
+type Status struct {
+	Name                string     `json:"name"`
+	Status              string     `json:"status"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+// Tracker 按名称记录调用结果，并发安全
+type Tracker struct {
+	mu      sync.Mutex
+	entries map[string]*Status
+}
+
+// NewTracker 创建记录器
+func NewTracker() *Tracker {
+	return &Tracker{entries: make(map[string]*Status)}
+}
+
+// Record 记录一次调用；最近一次失败时为degraded，成功后恢复
+func (t *Tracker) Record(name string, err error) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[name]
+	if !ok {
+		entry = &Status{Name: name}
+		t.entries[name] = entry
+	}
+	if err != nil {
+		entry.LastFailure = &now
+		entry.ConsecutiveFailures++
+		entry.Status = StatusDegraded
+		return
+	}
+	entry.LastSuccess = &now
+	entry.ConsecutiveFailures = 0
+	entry.Status = StatusOK
+}
+
+// Snapshot 所有依赖的当前状态（按名称排序）
+func (t *Tracker) Snapshot() []Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]Status, 0, len(t.entries))
+	for _, entry := range t.entries {
+		statuses = append(statuses, *entry)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Worst 多个状态中最差的一个（unknown 不影响结果）
+func Worst(statuses ...string) string {
+	rank := map[string]int{StatusOK: 0, StatusUnknown: 0, StatusDegraded: 1, StatusUnhealthy: 2}
+	worst := StatusOK
+	for _, s := range statuses {
+		if rank[s] > rank[worst] {
+			worst = s
+		}
+	}
+	return worst
+}
@@ -0,0 +1,35 @@
+package health
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTrackerRecord(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Record("deepseek", nil)
+	tracker.Record("binance", errors.New("timeout"))
+	tracker.Record("binance", errors.New("timeout"))
+
+	statuses := tracker.Snapshot()
+	if len(statuses) != 2 || statuses[0].Name != "binance" {
+		t.Fatalf("应按名称排序: %+v", statuses)
+	}
+	if s := statuses[0]; s.Status != StatusDegraded || s.ConsecutiveFailures != 2 || s.LastSuccess != nil {
+		t.Errorf("连续失败应为degraded: %+v", s)
+	}
+
+	tracker.Record("binance", nil)
+	if s := tracker.Snapshot()[0]; s.Status != StatusOK || s.ConsecutiveFailures != 0 || s.LastFailure == nil {
+		t.Errorf("成功后应恢复并保留上次失败时间: %+v", s)
+	}
+}
+
+func TestWorst(t *testing.T) {
+	if got := Worst(StatusOK, StatusUnknown); got != StatusOK {
+		t.Errorf("unknown 不应影响结果: %s", got)
+	}
+	if got := Worst(StatusOK, StatusDegraded, StatusUnhealthy); got != StatusUnhealthy {
+		t.Errorf("应取最差状态: %s", got)
+	}
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrRecordNotFound 决策记录或标注不存在
+	ErrRecordNotFound = errors.New("决策记录不存在")
+	// ErrInvalidActionIndex 标注的动作下标超出该周期的动作数
+	ErrInvalidActionIndex = errors.New("action_index 超出范围")
+)
+
+// Annotation 用户对一个决策周期或其中某个动作的复盘笔记/评分
+// 已平仓交易的标注挂在其开仓动作上（开仓动作同时带有回填的平仓结果）
+type Annotation struct {
+	ID          string    `json:"id"`
+	ActionIndex *int      `json:"action_index,omitempty"` // 对应 Decisions 的下标，为空表示整个周期
+	Note        string    `json:"note,omitempty"`
+	Rating      int       `json:"rating,omitempty"` // 1-5，0表示未评分
+	ShareWithAI bool      `json:"share_with_ai"`    // 是否放入AI的历史表现上下文
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// rewriteRecord 读取记录、修改后写回（保持原来的压缩状态）
+func (l *DecisionLogger) rewriteRecord(id string, update func(record *DecisionRecord) error) error {
+	if !validRecordID(id) {
+		return ErrRecordNotFound
+	}
+
+	l.rewriteMu.Lock()
+	defer l.rewriteMu.Unlock()
+
+	record, err := l.store.Read(id)
+	if err == ErrRecordNotFound {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("读取决策记录失败: %w", err)
+	}
+	record.ID = id
+
+	if err := update(record); err != nil {
+		return err
+	}
+	return l.store.Save(record)
+}
+
+// AddAnnotation 给决策记录添加标注，返回补全了ID和时间的标注
+func (l *DecisionLogger) AddAnnotation(recordID string, a Annotation) (*Annotation, error) {
+	b := make([]byte, 6)
+	rand.Read(b)
+	a.ID = hex.EncodeToString(b)
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+
+	err := l.rewriteRecord(recordID, func(record *DecisionRecord) error {
+		if a.ActionIndex != nil && (*a.ActionIndex < 0 || *a.ActionIndex >= len(record.Decisions)) {
+			return fmt.Errorf("%w（该周期共 %d 个动作）", ErrInvalidActionIndex, len(record.Decisions))
+		}
+		record.Annotations = append(record.Annotations, a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteAnnotation 删除决策记录上的标注
+func (l *DecisionLogger) DeleteAnnotation(recordID, annotationID string) error {
+	return l.rewriteRecord(recordID, func(record *DecisionRecord) error {
+		for i, a := range record.Annotations {
+			if a.ID == annotationID {
+				record.Annotations = append(record.Annotations[:i], record.Annotations[i+1:]...)
+				return nil
+			}
+		}
+		return ErrRecordNotFound
+	})
+}
+
+// aiAnnotations 一笔交易上允许AI参考的笔记和评分
+type aiAnnotations struct {
+	notes  []string
+	rating int
+}
+
+// merge 合并另一个动作上的标注，对方有评分时以对方为准
+func (a aiAnnotations) merge(other aiAnnotations) aiAnnotations {
+	a.notes = append(append([]string(nil), a.notes...), other.notes...)
+	if other.rating > 0 {
+		a.rating = other.rating
+	}
+	return a
+}
+
+// sharedAnnotations 收集某个动作上标记为可供AI参考的标注，评分取最后一次
+func sharedAnnotations(annotations []Annotation, actionIndex int) aiAnnotations {
+	var shared aiAnnotations
+	for _, a := range annotations {
+		if !a.ShareWithAI || a.ActionIndex == nil || *a.ActionIndex != actionIndex {
+			continue
+		}
+		if a.Note != "" {
+			shared.notes = append(shared.notes, a.Note)
+		}
+		if a.Rating > 0 {
+			shared.rating = a.Rating
+		}
+	}
+	return shared
+}
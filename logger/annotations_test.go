@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAddAndDeleteAnnotation(t *testing.T) {
+	dir := t.TempDir()
+	l := NewDecisionLogger(dir)
+	if err := l.LogDecision(&DecisionRecord{Decisions: []DecisionAction{
+		{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.1, Price: 60000, Timestamp: time.Now(), Success: true},
+	}}); err != nil {
+		t.Fatalf("写入决策记录失败: %v", err)
+	}
+	records, _ := l.GetLatestRecords(1)
+	if len(records) != 1 || records[0].ID == "" {
+		t.Fatalf("记录应带有ID: %+v", records)
+	}
+	id := records[0].ID
+	path := filepath.Join(dir, id+".json")
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(path, old, old)
+
+	zero := 0
+	a, err := l.AddAnnotation(id, Annotation{ActionIndex: &zero, Note: "追高了", Rating: 2, ShareWithAI: true, CreatedBy: "u1"})
+	if err != nil || a.ID == "" {
+		t.Fatalf("添加标注失败: %v", err)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(old) {
+		t.Errorf("添加标注不应改变文件修改时间: %v", info.ModTime())
+	}
+
+	two := 2
+	if _, err := l.AddAnnotation(id, Annotation{ActionIndex: &two, Note: "x"}); !errors.Is(err, ErrInvalidActionIndex) {
+		t.Errorf("动作下标越界应返回 ErrInvalidActionIndex: %v", err)
+	}
+	for _, bad := range []string{"decision_missing", "../" + id, "other"} {
+		if _, err := l.AddAnnotation(bad, Annotation{Note: "x"}); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("无效的记录ID %q 应返回 ErrRecordNotFound: %v", bad, err)
+		}
+	}
+
+	records, _ = l.GetLatestRecords(1)
+	if got := records[0].Annotations; len(got) != 1 || got[0].Note != "追高了" || got[0].CreatedBy != "u1" {
+		t.Fatalf("标注未写入记录: %+v", got)
+	}
+
+	if err := l.DeleteAnnotation(id, a.ID); err != nil {
+		t.Fatalf("删除标注失败: %v", err)
+	}
+	if err := l.DeleteAnnotation(id, a.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("重复删除应返回 ErrRecordNotFound: %v", err)
+	}
+	records, _ = l.GetLatestRecords(1)
+	if len(records[0].Annotations) != 0 {
+		t.Errorf("标注未删除: %+v", records[0].Annotations)
+	}
+}
+
+func TestAnalyzePerformanceSharedAnnotations(t *testing.T) {
+	l := NewDecisionLogger(t.TempDir())
+	openTime := time.Now().Add(-time.Hour)
+	l.LogDecision(&DecisionRecord{Decisions: []DecisionAction{
+		{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.1, Price: 60000, Leverage: 5, Timestamp: openTime, Success: true},
+	}})
+	l.LogDecision(&DecisionRecord{Decisions: []DecisionAction{
+		{Action: "close_long", Symbol: "BTCUSDT", Quantity: 0.1, Price: 61000, Timestamp: openTime.Add(time.Hour), Success: true},
+	}})
+	records, _ := l.GetLatestRecords(2)
+
+	zero := 0
+	l.AddAnnotation(records[0].ID, Annotation{ActionIndex: &zero, Note: "突破入场", Rating: 3, ShareWithAI: true})
+	l.AddAnnotation(records[0].ID, Annotation{ActionIndex: &zero, Note: "私人笔记", ShareWithAI: false})
+	l.AddAnnotation(records[0].ID, Annotation{Note: "整个周期", ShareWithAI: true})
+	l.AddAnnotation(records[1].ID, Annotation{ActionIndex: &zero, Note: "止盈太早", Rating: 4, ShareWithAI: true})
+
+	perf, err := l.AnalyzePerformance(10)
+	if err != nil || len(perf.RecentTrades) != 1 {
+		t.Fatalf("分析失败: %v %+v", err, perf)
+	}
+	trade := perf.RecentTrades[0]
+	if len(trade.UserNotes) != 2 || trade.UserNotes[0] != "突破入场" || trade.UserNotes[1] != "止盈太早" {
+		t.Errorf("只应包含开平仓动作上可供AI参考的笔记: %v", trade.UserNotes)
+	}
+	if trade.UserRating != 4 {
+		t.Errorf("评分应以平仓动作上的为准: %d", trade.UserRating)
+	}
+}
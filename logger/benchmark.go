@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+// BenchmarkComparison 交易员收益与买入持有基准的对比（同一时间区间）
+type BenchmarkComparison struct {
+	From              time.Time `json:"from"`
+	To                time.Time `json:"to"`
+	TraderReturnPct   float64   `json:"trader_return_pct"`    // 区间内账户净值收益率
+	BTCReturnPct      float64   `json:"btc_return_pct"`       // 区间内持有BTC的收益率
+	BasketReturnPct   float64   `json:"basket_return_pct"`    // 候选币种等权持有的收益率
+	BasketSymbols     []string  `json:"basket_symbols"`       // 实际参与计算的候选币种
+	ExcessVsBTCPct    float64   `json:"excess_vs_btc_pct"`    // 交易员 - BTC
+	ExcessVsBasketPct float64   `json:"excess_vs_basket_pct"` // 交易员 - 候选币种组合
+}
+
+// CompareWithBenchmark 根据区间首尾净值和价格计算相对表现（缺少价格的币种不计入组合）
+func CompareWithBenchmark(first, last EquityPoint, startPrices, endPrices map[string]float64, btcSymbol string, basket []string) (*BenchmarkComparison, error) {
+	if first.Equity <= 0 {
+		return nil, fmt.Errorf("起始净值无效")
+	}
+	btcReturn, ok := priceReturnPct(btcSymbol, startPrices, endPrices)
+	if !ok {
+		return nil, fmt.Errorf("缺少%s的区间价格", btcSymbol)
+	}
+
+	c := &BenchmarkComparison{
+		From:            first.Time,
+		To:              last.Time,
+		TraderReturnPct: (last.Equity - first.Equity) / first.Equity * 100,
+		BTCReturnPct:    btcReturn,
+		BasketSymbols:   []string{},
+	}
+
+	total := 0.0
+	for _, symbol := range basket {
+		if r, ok := priceReturnPct(symbol, startPrices, endPrices); ok {
+			total += r
+			c.BasketSymbols = append(c.BasketSymbols, symbol)
+		}
+	}
+	if len(c.BasketSymbols) > 0 {
+		c.BasketReturnPct = total / float64(len(c.BasketSymbols))
+	} else {
+		// 没有可用的候选币种价格时退回BTC
+		c.BasketReturnPct = btcReturn
+	}
+
+	c.ExcessVsBTCPct = c.TraderReturnPct - c.BTCReturnPct
+	c.ExcessVsBasketPct = c.TraderReturnPct - c.BasketReturnPct
+	return c, nil
+}
+
+// priceReturnPct 单个币种区间涨跌幅
+func priceReturnPct(symbol string, startPrices, endPrices map[string]float64) (float64, bool) {
+	start, end := startPrices[symbol], endPrices[symbol]
+	if start <= 0 || end <= 0 {
+		return 0, false
+	}
+	return (end - start) / start * 100, true
+}
+
+// GetEquityBounds 获取权益历史的第一个和最后一个有效点，以及最后一个周期的候选币种
+func (l *DecisionLogger) GetEquityBounds() (first, last EquityPoint, candidates []string, err error) {
+	refs, err := l.store.List()
+	if err != nil {
+		return first, last, nil, err
+	}
+
+	// 最早的记录可能已被清理，先看净值归档
+	if archive, err := l.GetEquityArchive(); err == nil {
+		for _, p := range archive {
+			if p.AccountState.TotalBalance > 0 {
+				first = EquityPoint{Time: p.Timestamp, Equity: p.AccountState.TotalBalance}
+				break
+			}
+		}
+	}
+	for _, ref := range refs {
+		if first.Equity > 0 {
+			break
+		}
+		if record, err := l.store.Read(ref.ID); err == nil && record.AccountState.TotalBalance > 0 {
+			first = EquityPoint{Time: record.Timestamp, Equity: record.AccountState.TotalBalance}
+		}
+	}
+	for i := len(refs) - 1; i >= 0; i-- {
+		if record, err := l.store.Read(refs[i].ID); err == nil && record.AccountState.TotalBalance > 0 {
+			last = EquityPoint{Time: record.Timestamp, Equity: record.AccountState.TotalBalance}
+			candidates = record.CandidateCoins
+			break
+		}
+	}
+
+	if first.Equity <= 0 || !last.Time.After(first.Time) {
+		return first, last, nil, fmt.Errorf("权益历史不足")
+	}
+	return first, last, candidates, nil
+}
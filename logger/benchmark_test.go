@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCompareWithBenchmark(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := EquityPoint{Time: from, Equity: 1000}
+	last := EquityPoint{Time: from.Add(24 * time.Hour), Equity: 1150}
+
+	start := map[string]float64{"BTCUSDT": 100000, "ETHUSDT": 4000, "SOLUSDT": 200}
+	end := map[string]float64{"BTCUSDT": 110000, "ETHUSDT": 3800, "SOLUSDT": 230}
+
+	c, err := CompareWithBenchmark(first, last, start, end, "BTCUSDT", []string{"ETHUSDT", "SOLUSDT", "DOGEUSDT"})
+	if err != nil {
+		t.Fatalf("计算基准失败: %v", err)
+	}
+
+	check := func(name string, got, want float64) {
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %.6f，期望 %.6f", name, got, want)
+		}
+	}
+	check("TraderReturnPct", c.TraderReturnPct, 15)
+	check("BTCReturnPct", c.BTCReturnPct, 10)
+	check("BasketReturnPct", c.BasketReturnPct, 5) // (-5 + 15) / 2，DOGE缺少价格不计入
+	check("ExcessVsBTCPct", c.ExcessVsBTCPct, 5)
+	check("ExcessVsBasketPct", c.ExcessVsBasketPct, 10)
+	if len(c.BasketSymbols) != 2 {
+		t.Errorf("组合币种应为2个，得到 %v", c.BasketSymbols)
+	}
+
+	if _, err := CompareWithBenchmark(first, last, start, map[string]float64{}, "BTCUSDT", nil); err == nil {
+		t.Error("缺少BTC价格时应返回错误")
+	}
+}
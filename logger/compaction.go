@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+)
+
+// equityArchiveBucket 归档净值的采样间隔：每个区间保留最后一个周期
+const equityArchiveBucket = time.Hour
+
+// RetentionPolicy 决策日志的保留策略，0 表示不处理
+type RetentionPolicy struct {
+	RetentionDays     int // 原始记录保留天数，更早的记录降采样到净值归档后删除
+	CompressAfterDays int // 超过此天数的记录gzip压缩保存
+}
+
+// CompactionResult 一次压缩整理的结果
+type CompactionResult struct {
+	Compressed int `json:"compressed"` // 新压缩的记录数
+	Removed    int `json:"removed"`    // 删除的原始记录数
+	Archived   int `json:"archived"`   // 新写入净值归档的点数
+}
+
+// ArchivedEquity 原始记录删除后保留的净值点
+type ArchivedEquity struct {
+	Timestamp    time.Time       `json:"timestamp"`
+	CycleNumber  int             `json:"cycle_number"`
+	AccountState AccountSnapshot `json:"account_state"`
+}
+
+// Compact 按保留策略整理记录：过期记录先降采样写入净值归档再删除，较旧的记录压缩保存（仅文件存储）
+// 整理期间持有改写锁，回填结果和标注会等待
+func (l *DecisionLogger) Compact(policy RetentionPolicy, now time.Time) (*CompactionResult, error) {
+	l.rewriteMu.Lock()
+	defer l.rewriteMu.Unlock()
+
+	refs, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+	result := &CompactionResult{}
+
+	var expired []RecordRef
+	if policy.RetentionDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.RetentionDays)
+		for _, ref := range refs {
+			if !ref.Time.Before(cutoff) {
+				break
+			}
+			expired = append(expired, ref)
+		}
+		refs = refs[len(expired):]
+	}
+	if len(expired) > 0 {
+		if result.Archived, err = l.archiveEquity(expired); err != nil {
+			return result, fmt.Errorf("写入净值归档失败: %w", err)
+		}
+		for _, ref := range expired {
+			if err := l.store.Delete(ref.ID); err != nil {
+				return result, fmt.Errorf("删除旧记录失败: %w", err)
+			}
+			result.Removed++
+		}
+	}
+
+	if c, ok := l.store.(compressor); ok && policy.CompressAfterDays > 0 {
+		cutoff := now.AddDate(0, 0, -policy.CompressAfterDays)
+		for _, ref := range refs {
+			if !ref.Time.Before(cutoff) {
+				break
+			}
+			if ref.Compressed {
+				continue
+			}
+			if err := c.Compress(ref.ID); err != nil {
+				return result, fmt.Errorf("压缩记录 %s 失败: %w", ref.ID, err)
+			}
+			result.Compressed++
+		}
+	}
+	return result, nil
+}
+
+// archiveEquity 把即将删除的记录按小时降采样追加到净值归档，返回写入的点数
+// 只追加比归档中最后一个点更新的点，删除中途失败后重试不会重复写入
+func (l *DecisionLogger) archiveEquity(refs []RecordRef) (int, error) {
+	archive, err := l.GetEquityArchive()
+	if err != nil {
+		return 0, err
+	}
+	var last time.Time
+	if len(archive) > 0 {
+		last = archive[len(archive)-1].Timestamp
+	}
+
+	var points []ArchivedEquity
+	for _, ref := range refs {
+		record, err := l.store.Read(ref.ID)
+		if err != nil || record.AccountState.TotalBalance <= 0 || !record.Timestamp.After(last) {
+			continue
+		}
+		p := ArchivedEquity{Timestamp: record.Timestamp, CycleNumber: record.CycleNumber, AccountState: record.AccountState}
+		if n := len(points); n > 0 && points[n-1].Timestamp.Truncate(equityArchiveBucket).Equal(p.Timestamp.Truncate(equityArchiveBucket)) {
+			points[n-1] = p
+		} else {
+			points = append(points, p)
+		}
+	}
+	if len(points) == 0 {
+		return 0, nil
+	}
+
+	if err := l.store.AppendEquityArchive(points); err != nil {
+		return 0, err
+	}
+	return len(points), nil
+}
+
+// GetEquityArchive 读取已清理记录的降采样净值（按时间正序），没有归档时返回空
+func (l *DecisionLogger) GetEquityArchive() ([]ArchivedEquity, error) {
+	return l.store.EquityArchive()
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestRecord 以指定时间写入一条记录（文件名与 LogDecision 相同格式）
+func writeTestRecord(t *testing.T, dir string, ts time.Time, cycle int, equity float64) string {
+	t.Helper()
+	id := fmt.Sprintf("decision_%s_cycle%d", ts.Format("20060102_150405"), cycle)
+	record := &DecisionRecord{ID: id, Timestamp: ts, CycleNumber: cycle, AccountState: AccountSnapshot{TotalBalance: equity}}
+	if err := writeRecordFile(filepath.Join(dir, id+recordExt), record); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	l := NewDecisionLogger(dir)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+
+	// 100天前同一小时内的3个周期，降采样后只保留最后一个
+	old := now.AddDate(0, 0, -100)
+	writeTestRecord(t, dir, old, 1, 1000)
+	writeTestRecord(t, dir, old.Add(3*time.Minute), 2, 1010)
+	writeTestRecord(t, dir, old.Add(6*time.Minute), 3, 1020)
+	writeTestRecord(t, dir, old.Add(2*time.Hour), 4, 1030)
+	// 10天前：压缩；今天：保持原样
+	midID := writeTestRecord(t, dir, now.AddDate(0, 0, -10), 5, 1100)
+	writeTestRecord(t, dir, now.Add(-time.Hour), 6, 1200)
+	os.WriteFile(filepath.Join(dir, "runtime_state.json"), []byte(`{}`), 0644)
+
+	result, err := l.Compact(RetentionPolicy{RetentionDays: 90, CompressAfterDays: 7}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 4 || result.Archived != 2 || result.Compressed != 1 {
+		t.Fatalf("整理结果错误: %+v", result)
+	}
+
+	archive, err := l.GetEquityArchive()
+	if err != nil || len(archive) != 2 || archive[0].AccountState.TotalBalance != 1020 || archive[1].CycleNumber != 4 {
+		t.Fatalf("净值归档错误: %+v %v", archive, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, midID+compressedRecordExt)); err != nil {
+		t.Errorf("10天前的记录应被压缩: %v", err)
+	}
+
+	// 压缩后的记录仍可正常读取和改写
+	records, err := l.GetLatestRecords(10)
+	if err != nil || len(records) != 2 || records[0].ID != midID || records[0].AccountState.TotalBalance != 1100 {
+		t.Fatalf("读取压缩记录失败: %+v %v", records, err)
+	}
+	if _, err := l.AddAnnotation(midID, Annotation{Note: "复盘"}); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ := l.GetRecordsBetween(now.AddDate(0, 0, -11), now.AddDate(0, 0, -9)); len(records) != 1 || len(records[0].Annotations) != 1 {
+		t.Errorf("压缩记录的标注未保存: %+v", records)
+	}
+
+	// 再次整理不应重复归档
+	if result, err := l.Compact(RetentionPolicy{RetentionDays: 90, CompressAfterDays: 7}, now); err != nil || *result != (CompactionResult{}) {
+		t.Errorf("重复整理: %+v %v", result, err)
+	}
+}
+
+func TestGetEquityBoundsUsesArchive(t *testing.T) {
+	dir := t.TempDir()
+	l := NewDecisionLogger(dir)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	writeTestRecord(t, dir, now.AddDate(0, 0, -100), 1, 1000)
+	writeTestRecord(t, dir, now.Add(-time.Hour), 2, 1500)
+	if _, err := l.Compact(RetentionPolicy{RetentionDays: 90}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	first, last, _, err := l.GetEquityBounds()
+	if err != nil || first.Equity != 1000 || last.Equity != 1500 {
+		t.Errorf("first=%+v last=%+v err=%v", first, last, err)
+	}
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"nofx/config"
+	"path/filepath"
+	"time"
+)
+
+// databaseStorage 所有交易员的记录保存在配置数据库的 decision_records 表中
+// 使用PostgreSQL时多个实例共享同一份日志，可以集中查询
+type databaseStorage struct {
+	database *config.Database
+}
+
+func (s databaseStorage) open(logDir string) Store {
+	return &databaseStore{database: s.database, traderID: filepath.Base(logDir)}
+}
+
+func (s databaseStorage) traderIDs(string) ([]string, error) {
+	return s.database.ListDecisionRecordTraders()
+}
+
+// databaseStore 一个交易员在数据库中的记录
+type databaseStore struct {
+	database *config.Database
+	traderID string
+}
+
+func (s *databaseStore) List() ([]RecordRef, error) {
+	rows, err := s.database.ListDecisionRecords(s.traderID)
+	if err != nil {
+		return nil, fmt.Errorf("查询决策记录失败: %w", err)
+	}
+	refs := make([]RecordRef, 0, len(rows))
+	for _, row := range rows {
+		refs = append(refs, RecordRef{ID: row.ID, Time: row.Timestamp})
+	}
+	return refs, nil
+}
+
+func (s *databaseStore) Read(id string) (*DecisionRecord, error) {
+	data, err := s.database.GetDecisionRecord(s.traderID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record DecisionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	record.ID = id
+	return &record, nil
+}
+
+func (s *databaseStore) Save(record *DecisionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化决策记录失败: %w", err)
+	}
+	if err := s.database.SaveDecisionRecord(s.traderID, record.ID, record.Timestamp, data); err != nil {
+		return fmt.Errorf("写入决策记录失败: %w", err)
+	}
+	return nil
+}
+
+func (s *databaseStore) Delete(id string) error {
+	return s.database.DeleteDecisionRecord(s.traderID, id)
+}
+
+func (s *databaseStore) AppendEquityArchive(points []ArchivedEquity) error {
+	timestamps := make([]time.Time, 0, len(points))
+	data := make([][]byte, 0, len(points))
+	for _, p := range points {
+		line, _ := json.Marshal(p)
+		timestamps = append(timestamps, p.Timestamp)
+		data = append(data, line)
+	}
+	return s.database.AppendDecisionEquityArchive(s.traderID, timestamps, data)
+}
+
+func (s *databaseStore) EquityArchive() ([]ArchivedEquity, error) {
+	rows, err := s.database.GetDecisionEquityArchive(s.traderID)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]ArchivedEquity, 0, len(rows))
+	for _, data := range rows {
+		var p ArchivedEquity
+		if err := json.Unmarshal(data, &p); err != nil {
+			continue
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
@@ -1,17 +1,19 @@
 package logger
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"log/slog"
 	"math"
 	"os"
-	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
 // DecisionRecord 决策记录
 type DecisionRecord struct {
+	ID             string             `json:"id"`              // 记录标识（文件名去掉扩展名）
 	Timestamp      time.Time          `json:"timestamp"`       // 决策时间
 	CycleNumber    int                `json:"cycle_number"`    // 周期编号
 	SystemPrompt   string             `json:"system_prompt"`   // 系统提示词（发送给AI的系统prompt）
@@ -25,6 +27,21 @@ type DecisionRecord struct {
 	ExecutionLog   []string           `json:"execution_log"`   // 执行日志
 	Success        bool               `json:"success"`         // 是否成功
 	ErrorMessage   string             `json:"error_message"`   // 错误信息（如果有）
+
+	LiquidityFiltered []FilteredSymbol `json:"liquidity_filtered,omitempty"` // 因流动性不足未发给AI的币种
+
+	PromptTokens     int64 `json:"prompt_tokens,omitempty"`     // 本周期AI调用的输入token数
+	CompletionTokens int64 `json:"completion_tokens,omitempty"` // 本周期AI调用的输出token数
+
+	Annotations []Annotation `json:"annotations,omitempty"` // 用户添加的复盘笔记和评分
+}
+
+// FilteredSymbol 因流动性不足被排除的币种
+type FilteredSymbol struct {
+	Symbol    string  `json:"symbol"`
+	Reason    string  `json:"reason"`               // 未达到的要求：oi_value / volume
+	OIValue   float64 `json:"oi_value,omitempty"`   // 持仓价值（USDT）
+	Volume24h float64 `json:"volume_24h,omitempty"` // 24h成交额（USDT）
 }
 
 // AccountSnapshot 账户状态快照
@@ -50,24 +67,34 @@ type PositionSnapshot struct {
 
 // DecisionAction 决策动作
 type DecisionAction struct {
-	Action    string    `json:"action"`    // open_long, open_short, close_long, close_short
-	Symbol    string    `json:"symbol"`    // 币种
-	Quantity  float64   `json:"quantity"`  // 数量
-	Leverage  int       `json:"leverage"`  // 杠杆（开仓时）
-	Price     float64   `json:"price"`     // 执行价格
-	OrderID   int64     `json:"order_id"`  // 订单ID
-	Timestamp time.Time `json:"timestamp"` // 执行时间
-	Success   bool      `json:"success"`   // 是否成功
-	Error     string    `json:"error"`     // 错误信息
+	Action      string           `json:"action"`                 // open_long, open_short, close_long, close_short
+	Symbol      string           `json:"symbol"`                 // 币种
+	Quantity    float64          `json:"quantity"`               // 数量
+	Leverage    int              `json:"leverage"`               // 杠杆（开仓时）
+	Price       float64          `json:"price"`                  // 执行价格（下单时的行情价）
+	FillPrice   float64          `json:"fill_price,omitempty"`   // 交易所返回的成交均价（有返回时）
+	SlippageBps float64          `json:"slippage_bps,omitempty"` // 成交价相对下单时行情价的滑点（基点，正数为不利）
+	StopLoss    float64          `json:"stop_loss"`              // 止损价（开仓时，用于计算R倍数）
+	TakeProfit  float64          `json:"take_profit"`            // 止盈价（开仓时）
+	OrderID     int64            `json:"order_id"`               // 订单ID
+	Timestamp   time.Time        `json:"timestamp"`              // 执行时间
+	Success     bool             `json:"success"`                // 是否成功
+	Error       string           `json:"error"`                  // 错误信息
+	ErrorClass  string           `json:"error_class,omitempty"`  // 下单失败的类别（insufficient_margin/precision/rate_limit/network/rejected）
+	ExitReason  string           `json:"exit_reason,omitempty"`  // 平仓原因（平仓时）
+	Outcome     *DecisionOutcome `json:"outcome,omitempty"`      // 开仓的最终结果（平仓后回填）
 }
 
 // DecisionLogger 决策日志记录器
 type DecisionLogger struct {
 	logDir      string
+	store       Store
 	cycleNumber int
+	rewriteMu   *sync.Mutex // 改写已有记录（回填结果、添加标注、压缩）时互斥，避免互相覆盖；同一目录共用
 }
 
-// NewDecisionLogger 创建决策日志记录器
+// NewDecisionLogger 创建决策日志记录器，记录保存在 SetStorage 设置的存储中（默认 logDir 目录）
+// logDir 的最后一级为交易员ID，运行时状态等文件始终保存在 logDir
 func NewDecisionLogger(logDir string) *DecisionLogger {
 	if logDir == "" {
 		logDir = "decision_logs"
@@ -75,12 +102,14 @@ func NewDecisionLogger(logDir string) *DecisionLogger {
 
 	// 确保日志目录存在
 	if err := os.MkdirAll(logDir, 0755); err != nil {
-		fmt.Printf("⚠ 创建日志目录失败: %v\n", err)
+		slog.Warn("创建日志目录失败", "dir", logDir, "error", err)
 	}
 
 	return &DecisionLogger{
 		logDir:      logDir,
+		store:       currentStorage.open(logDir),
 		cycleNumber: 0,
+		rewriteMu:   dirLock(logDir),
 	}
 }
 
@@ -90,56 +119,39 @@ func (l *DecisionLogger) LogDecision(record *DecisionRecord) error {
 	record.CycleNumber = l.cycleNumber
 	record.Timestamp = time.Now()
 
-	// 生成文件名：decision_YYYYMMDD_HHMMSS_cycleN.json
-	filename := fmt.Sprintf("decision_%s_cycle%d.json",
+	// 记录标识（文件存储中为文件名）：decision_YYYYMMDD_HHMMSS_cycleN
+	record.ID = fmt.Sprintf("decision_%s_cycle%d",
 		record.Timestamp.Format("20060102_150405"),
 		record.CycleNumber)
 
-	filepath := filepath.Join(l.logDir, filename)
-
-	// 序列化为JSON（带缩进，方便阅读）
-	data, err := json.MarshalIndent(record, "", "  ")
-	if err != nil {
-		return fmt.Errorf("序列化决策记录失败: %w", err)
+	if err := l.store.Save(record); err != nil {
+		return err
 	}
-
-	// 写入文件
-	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
-		return fmt.Errorf("写入决策记录失败: %w", err)
+	if err := l.indexRecord(record); err != nil {
+		slog.Warn("写入决策检索索引失败", "trader", l.traderID(), "error", err)
 	}
 
-	fmt.Printf("📝 决策记录已保存: %s\n", filename)
+	slog.Info("决策记录已保存", "trader", l.traderID(), "record", record.ID)
 	return nil
 }
 
 // GetLatestRecords 获取最近N条记录（按时间正序：从旧到新）
 func (l *DecisionLogger) GetLatestRecords(n int) ([]*DecisionRecord, error) {
-	files, err := ioutil.ReadDir(l.logDir)
+	refs, err := l.store.List()
 	if err != nil {
-		return nil, fmt.Errorf("读取日志目录失败: %w", err)
+		return nil, err
 	}
 
-	// 先按修改时间倒序收集（最新的在前）
+	// 先按时间倒序收集（最新的在前）
 	var records []*DecisionRecord
 	count := 0
-	for i := len(files) - 1; i >= 0 && count < n; i-- {
-		file := files[i]
-		if file.IsDir() {
-			continue
-		}
-
-		filepath := filepath.Join(l.logDir, file.Name())
-		data, err := ioutil.ReadFile(filepath)
+	for i := len(refs) - 1; i >= 0 && count < n; i-- {
+		record, err := l.store.Read(refs[i].ID)
 		if err != nil {
 			continue
 		}
 
-		var record DecisionRecord
-		if err := json.Unmarshal(data, &record); err != nil {
-			continue
-		}
-
-		records = append(records, &record)
+		records = append(records, record)
 		count++
 	}
 
@@ -153,89 +165,117 @@ func (l *DecisionLogger) GetLatestRecords(n int) ([]*DecisionRecord, error) {
 
 // GetRecordByDate 获取指定日期的所有记录
 func (l *DecisionLogger) GetRecordByDate(date time.Time) ([]*DecisionRecord, error) {
-	dateStr := date.Format("20060102")
-	pattern := filepath.Join(l.logDir, fmt.Sprintf("decision_%s_*.json", dateStr))
+	prefix := fmt.Sprintf("%s%s_", recordPrefix, date.Format("20060102"))
 
-	files, err := filepath.Glob(pattern)
+	refs, err := l.store.List()
 	if err != nil {
-		return nil, fmt.Errorf("查找日志文件失败: %w", err)
+		return nil, err
 	}
 
 	var records []*DecisionRecord
-	for _, filepath := range files {
-		data, err := ioutil.ReadFile(filepath)
-		if err != nil {
+	for _, ref := range refs {
+		if !strings.HasPrefix(ref.ID, prefix) {
 			continue
 		}
-
-		var record DecisionRecord
-		if err := json.Unmarshal(data, &record); err != nil {
+		record, err := l.store.Read(ref.ID)
+		if err != nil {
 			continue
 		}
 
-		records = append(records, &record)
+		records = append(records, record)
 	}
 
 	return records, nil
 }
 
-// CleanOldRecords 清理N天前的旧记录
-func (l *DecisionLogger) CleanOldRecords(days int) error {
-	cutoffTime := time.Now().AddDate(0, 0, -days)
-
-	files, err := ioutil.ReadDir(l.logDir)
+// GetRecordsBetween 获取时间区间 [from, to] 内的记录（按时间正序；零值表示不限）
+func (l *DecisionLogger) GetRecordsBetween(from, to time.Time) ([]*DecisionRecord, error) {
+	refs, err := l.store.List()
 	if err != nil {
-		return fmt.Errorf("读取日志目录失败: %w", err)
+		return nil, err
 	}
 
-	removedCount := 0
-	for _, file := range files {
-		if file.IsDir() {
+	var records []*DecisionRecord
+	for _, ref := range refs {
+		// 记录标识中的时间与记录时间相差不到一秒，先按标识跳过区间外的记录，避免逐个读取
+		if t := ref.Time; (!from.IsZero() && t.Before(from.Add(-time.Minute))) || (!to.IsZero() && t.After(to.Add(time.Minute))) {
 			continue
 		}
-
-		if file.ModTime().Before(cutoffTime) {
-			filepath := filepath.Join(l.logDir, file.Name())
-			if err := os.Remove(filepath); err != nil {
-				fmt.Printf("⚠ 删除旧记录失败 %s: %v\n", file.Name(), err)
-				continue
-			}
-			removedCount++
+		record, err := l.store.Read(ref.ID)
+		if err != nil {
+			continue
+		}
+		if (!from.IsZero() && record.Timestamp.Before(from)) || (!to.IsZero() && record.Timestamp.After(to)) {
+			continue
 		}
+
+		records = append(records, record)
 	}
 
-	if removedCount > 0 {
-		fmt.Printf("🗑️ 已清理 %d 条旧记录（%d天前）\n", removedCount, days)
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// CleanOldRecords 清理N天前的旧记录（删除前把净值降采样保存到归档）
+func (l *DecisionLogger) CleanOldRecords(days int) error {
+	result, err := l.Compact(RetentionPolicy{RetentionDays: days}, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if result.Removed > 0 {
+		slog.Info("清理旧决策记录", "trader", l.traderID(), "removed", result.Removed, "days", days)
 	}
 
 	return nil
 }
 
+// Purge 删除全部决策记录、检索索引和日志目录（注销账户时使用），返回删除的记录数
+func (l *DecisionLogger) Purge() (int, error) {
+	l.rewriteMu.Lock()
+	defer l.rewriteMu.Unlock()
+
+	refs, err := l.store.List()
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if err := l.store.Delete(ref.ID); err != nil {
+			return len(ids), err
+		}
+		ids = append(ids, ref.ID)
+	}
+	if searchIndex != nil && len(ids) > 0 {
+		if err := searchIndex.DeleteDecisionSearchEntries(l.traderID(), ids); err != nil {
+			return len(ids), err
+		}
+	}
+	// 运行时状态和文件存储的净值归档也保存在日志目录中
+	if err := os.RemoveAll(l.logDir); err != nil {
+		return len(ids), err
+	}
+	return len(ids), nil
+}
+
 // GetStatistics 获取统计信息
 func (l *DecisionLogger) GetStatistics() (*Statistics, error) {
-	files, err := ioutil.ReadDir(l.logDir)
+	refs, err := l.store.List()
 	if err != nil {
-		return nil, fmt.Errorf("读取日志目录失败: %w", err)
+		return nil, err
 	}
 
 	stats := &Statistics{}
+	var slippage SlippageStats
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-
-		filepath := filepath.Join(l.logDir, file.Name())
-		data, err := ioutil.ReadFile(filepath)
+	for _, ref := range refs {
+		record, err := l.store.Read(ref.ID)
 		if err != nil {
 			continue
 		}
 
-		var record DecisionRecord
-		if err := json.Unmarshal(data, &record); err != nil {
-			continue
-		}
-
 		stats.TotalCycles++
 
 		for _, action := range record.Decisions {
@@ -246,6 +286,9 @@ func (l *DecisionLogger) GetStatistics() (*Statistics, error) {
 				case "close_long", "close_short":
 					stats.TotalClosePositions++
 				}
+				if action.FillPrice > 0 {
+					slippage.add(action.SlippageBps)
+				}
 			}
 		}
 
@@ -256,6 +299,18 @@ func (l *DecisionLogger) GetStatistics() (*Statistics, error) {
 		}
 	}
 
+	if slippage.Fills > 0 {
+		slippage.AvgBps = math.Round(slippage.AvgBps*100) / 100
+		stats.Slippage = &slippage
+	}
+
+	// 绩效指标覆盖全部历史周期（失败不影响基础统计）
+	if stats.TotalCycles > 0 {
+		if analysis, err := l.AnalyzePerformance(stats.TotalCycles); err == nil {
+			stats.Metrics = analysis.Metrics
+		}
+	}
+
 	return stats, nil
 }
 
@@ -266,24 +321,31 @@ type Statistics struct {
 	FailedCycles        int `json:"failed_cycles"`
 	TotalOpenPositions  int `json:"total_open_positions"`
 	TotalClosePositions int `json:"total_close_positions"`
+
+	Metrics  *TradeMetrics  `json:"metrics,omitempty"`  // 全部历史的绩效指标
+	Slippage *SlippageStats `json:"slippage,omitempty"` // 成交滑点，没有成交均价时为空
 }
 
 // TradeOutcome 单笔交易结果
 type TradeOutcome struct {
-	Symbol        string    `json:"symbol"`         // 币种
-	Side          string    `json:"side"`           // long/short
-	Quantity      float64   `json:"quantity"`       // 仓位数量
-	Leverage      int       `json:"leverage"`       // 杠杆倍数
-	OpenPrice     float64   `json:"open_price"`     // 开仓价
-	ClosePrice    float64   `json:"close_price"`    // 平仓价
-	PositionValue float64   `json:"position_value"` // 仓位价值（quantity × openPrice）
-	MarginUsed    float64   `json:"margin_used"`    // 保证金使用（positionValue / leverage）
-	PnL           float64   `json:"pn_l"`           // 盈亏（USDT）
-	PnLPct        float64   `json:"pn_l_pct"`       // 盈亏百分比（相对保证金）
-	Duration      string    `json:"duration"`       // 持仓时长
-	OpenTime      time.Time `json:"open_time"`      // 开仓时间
-	CloseTime     time.Time `json:"close_time"`     // 平仓时间
-	WasStopLoss   bool      `json:"was_stop_loss"`  // 是否止损
+	Symbol        string    `json:"symbol"`                // 币种
+	Side          string    `json:"side"`                  // long/short
+	Quantity      float64   `json:"quantity"`              // 仓位数量
+	Leverage      int       `json:"leverage"`              // 杠杆倍数
+	OpenPrice     float64   `json:"open_price"`            // 开仓价
+	ClosePrice    float64   `json:"close_price"`           // 平仓价
+	PositionValue float64   `json:"position_value"`        // 仓位价值（quantity × openPrice）
+	MarginUsed    float64   `json:"margin_used"`           // 保证金使用（positionValue / leverage）
+	PnL           float64   `json:"pn_l"`                  // 盈亏（USDT）
+	PnLPct        float64   `json:"pn_l_pct"`              // 盈亏百分比（相对保证金）
+	Duration      string    `json:"duration"`              // 持仓时长
+	OpenTime      time.Time `json:"open_time"`             // 开仓时间
+	CloseTime     time.Time `json:"close_time"`            // 平仓时间
+	WasStopLoss   bool      `json:"was_stop_loss"`         // 是否止损
+	ExitReason    string    `json:"exit_reason"`           // 平仓原因（旧记录为空）
+	RiskUSD       float64   `json:"risk_usd"`              // 开仓时按止损计算的风险（quantity × |开仓价-止损价|）
+	UserNotes     []string  `json:"user_notes,omitempty"`  // 用户标注为可供AI参考的复盘笔记
+	UserRating    int       `json:"user_rating,omitempty"` // 用户评分（1-5），未评分为0
 }
 
 // PerformanceAnalysis 交易表现分析
@@ -300,6 +362,8 @@ type PerformanceAnalysis struct {
 	SymbolStats   map[string]*SymbolPerformance `json:"symbol_stats"`   // 各币种表现
 	BestSymbol    string                        `json:"best_symbol"`    // 表现最好的币种
 	WorstSymbol   string                        `json:"worst_symbol"`   // 表现最差的币种
+	Metrics       *TradeMetrics                 `json:"metrics"`        // 完整绩效指标
+	Benchmark     *BenchmarkComparison          `json:"benchmark"`      // 买入持有基准对比（由调用方填充，可能为空）
 }
 
 // SymbolPerformance 币种表现统计
@@ -324,6 +388,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 		return &PerformanceAnalysis{
 			RecentTrades: []TradeOutcome{},
 			SymbolStats:  make(map[string]*SymbolPerformance),
+			Metrics:      &TradeMetrics{},
 		}, nil
 	}
 
@@ -341,7 +406,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 	if err == nil && len(allRecords) > len(records) {
 		// 先从扩大的窗口中收集所有开仓记录
 		for _, record := range allRecords {
-			for _, action := range record.Decisions {
+			for i, action := range record.Decisions {
 				if !action.Success {
 					continue
 				}
@@ -364,6 +429,8 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 						"openTime":  action.Timestamp,
 						"quantity":  action.Quantity,
 						"leverage":  action.Leverage,
+						"stopLoss":  action.StopLoss,
+						"notes":     sharedAnnotations(record.Annotations, i),
 					}
 				case "close_long", "close_short":
 					// 移除已平仓记录
@@ -375,7 +442,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 
 	// 遍历分析窗口内的记录，生成交易结果
 	for _, record := range records {
-		for _, action := range record.Decisions {
+		for i, action := range record.Decisions {
 			if !action.Success {
 				continue
 			}
@@ -398,6 +465,8 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 					"openTime":  action.Timestamp,
 					"quantity":  action.Quantity,
 					"leverage":  action.Leverage,
+					"stopLoss":  action.StopLoss,
+					"notes":     sharedAnnotations(record.Annotations, i),
 				}
 
 			case "close_long", "close_short":
@@ -408,6 +477,8 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 					side := openPos["side"].(string)
 					quantity := openPos["quantity"].(float64)
 					leverage := openPos["leverage"].(int)
+					stopLoss := openPos["stopLoss"].(float64)
+					notes := openPos["notes"].(aiAnnotations)
 
 					// 计算实际盈亏（USDT）
 					// 合约交易 PnL 计算：quantity × 价格差
@@ -442,7 +513,15 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 						Duration:      action.Timestamp.Sub(openTime).String(),
 						OpenTime:      openTime,
 						CloseTime:     action.Timestamp,
+						WasStopLoss:   action.ExitReason == ExitReasonStopLoss,
+						ExitReason:    action.ExitReason,
+					}
+					if stopLoss > 0 {
+						outcome.RiskUSD = quantity * math.Abs(openPrice-stopLoss)
 					}
+					// 平仓动作上的标注和开仓动作上的合并，评分以平仓动作上的为准
+					notes = notes.merge(sharedAnnotations(record.Annotations, i))
+					outcome.UserNotes, outcome.UserRating = notes.notes, notes.rating
 
 					analysis.RecentTrades = append(analysis.RecentTrades, outcome)
 					analysis.TotalTrades++
@@ -523,6 +602,9 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 		}
 	}
 
+	// 完整绩效指标基于窗口内全部交易（在截断最近交易列表之前计算）
+	analysis.Metrics = CalculateMetrics(analysis.RecentTrades, equityCurve(records))
+
 	// 只保留最近的交易（倒序：最新的在前）
 	if len(analysis.RecentTrades) > 10 {
 		// 反转数组，让最新的在前
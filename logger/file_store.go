@@ -0,0 +1,272 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 决策记录文件：decision_YYYYMMDD_HHMMSS_cycleN.json，压缩后加 .gz 后缀
+// 日志目录中还有运行时状态、净值归档等其他文件，读取记录时只处理这两种
+const (
+	recordPrefix        = "decision_"
+	recordExt           = ".json"
+	compressedRecordExt = ".json.gz"
+)
+
+// equityArchiveFile 清理原始记录前保存的降采样净值（每行一个 ArchivedEquity），永久保留
+const equityArchiveFile = "equity_archive.jsonl"
+
+// fileStorage 每个交易员一个目录，每条记录一个文件
+type fileStorage struct{}
+
+func (fileStorage) open(logDir string) Store {
+	return &fileStore{dir: logDir}
+}
+
+func (fileStorage) traderIDs(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if e.IsDir() {
+			ids = append(ids, e.Name())
+		}
+	}
+	return ids, nil
+}
+
+// fileStore 一个交易员的日志目录
+type fileStore struct {
+	dir string
+}
+
+// isRecordFile 是否为决策记录文件
+func isRecordFile(name string) bool {
+	return strings.HasPrefix(name, recordPrefix) && (strings.HasSuffix(name, recordExt) || strings.HasSuffix(name, compressedRecordExt))
+}
+
+// recordID 由日志文件名得到记录标识（压缩前后相同）
+func recordID(filename string) string {
+	name := filepath.Base(filename)
+	name = strings.TrimSuffix(name, compressedRecordExt)
+	return strings.TrimSuffix(name, recordExt)
+}
+
+// recordTime 由文件名得到记录时间，文件名不符合格式时使用修改时间
+func recordTime(file os.FileInfo) time.Time {
+	if t, ok := recordIDTime(recordID(file.Name())); ok {
+		return t
+	}
+	return file.ModTime()
+}
+
+// List 目录中的决策记录文件，按文件名（即时间）从旧到新排列
+func (s *fileStore) List() ([]RecordRef, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取日志目录失败: %w", err)
+	}
+	var refs []RecordRef
+	for _, file := range files {
+		if file.IsDir() || !isRecordFile(file.Name()) {
+			continue
+		}
+		refs = append(refs, RecordRef{
+			ID:         recordID(file.Name()),
+			Time:       recordTime(file),
+			Compressed: strings.HasSuffix(file.Name(), compressedRecordExt),
+		})
+	}
+	return refs, nil
+}
+
+// path 记录当前的文件路径（可能已被压缩），不存在时返回 ErrRecordNotFound
+func (s *fileStore) path(id string) (string, error) {
+	if !validRecordID(id) {
+		return "", ErrRecordNotFound
+	}
+	for _, ext := range []string{recordExt, compressedRecordExt} {
+		path := filepath.Join(s.dir, id+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !os.IsNotExist(err) {
+			return "", fmt.Errorf("读取决策记录失败: %w", err)
+		}
+	}
+	return "", ErrRecordNotFound
+}
+
+func (s *fileStore) Read(id string) (*DecisionRecord, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	return readRecordFile(path)
+}
+
+// Save 写入记录，已压缩的记录写回压缩文件
+func (s *fileStore) Save(record *DecisionRecord) error {
+	path, err := s.path(record.ID)
+	if err == ErrRecordNotFound && validRecordID(record.ID) {
+		path, err = filepath.Join(s.dir, record.ID+recordExt), nil
+	}
+	if err != nil {
+		return err
+	}
+	return writeRecordFile(path, record)
+}
+
+func (s *fileStore) Delete(id string) error {
+	path, err := s.path(id)
+	if err == ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Compress 把记录压缩为 .json.gz 并删除原文件
+func (s *fileStore) Compress(id string) error {
+	path := filepath.Join(s.dir, id+recordExt)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	tmp := path + ".gz.tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (s *fileStore) AppendEquityArchive(points []ArchivedEquity) error {
+	f, err := os.OpenFile(filepath.Join(s.dir, equityArchiveFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, p := range points {
+		line, _ := json.Marshal(p)
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *fileStore) EquityArchive() ([]ArchivedEquity, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, equityArchiveFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return parseEquityArchive(data), nil
+}
+
+// parseEquityArchive 解析JSONL格式的净值归档，跳过写入中断留下的半行
+func parseEquityArchive(data []byte) []ArchivedEquity {
+	var points []ArchivedEquity
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var p ArchivedEquity
+		if err := json.Unmarshal(line, &p); err != nil {
+			continue
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// readRecordFile 读取一条记录，压缩的记录自动解压
+func readRecordFile(path string) (*DecisionRecord, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+
+	var record DecisionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	if record.ID == "" {
+		record.ID = recordID(path)
+	}
+	return &record, nil
+}
+
+// writeRecordFile 写入一条记录（.gz 文件写入压缩内容），先写临时文件再重命名，避免读到半条记录
+// 改写已有记录时保留原修改时间，按修改时间排序的工具（如 ls -t）不受影响
+func writeRecordFile(path string, record *DecisionRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化决策记录失败: %w", err)
+	}
+	if strings.HasSuffix(path, ".gz") {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(data)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	info, statErr := os.Stat(path)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入决策记录失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入决策记录失败: %w", err)
+	}
+	if statErr == nil {
+		os.Chtimes(path, time.Now(), info.ModTime())
+	}
+	return nil
+}
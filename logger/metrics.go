@@ -0,0 +1,242 @@
+package logger
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// EquityPoint 权益曲线上的一个点
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// TradeMetrics 绩效指标（实盘统计和回测共用）
+type TradeMetrics struct {
+	TotalTrades     int     `json:"total_trades"`      // 已平仓交易数
+	TotalPnL        float64 `json:"total_pnl"`         // 累计盈亏（USDT）
+	WinRate         float64 `json:"win_rate"`          // 胜率（%）
+	ProfitFactor    float64 `json:"profit_factor"`     // 总盈利 / 总亏损
+	Expectancy      float64 `json:"expectancy"`        // 每笔期望盈亏（USDT）
+	AvgR            float64 `json:"avg_r"`             // 平均R倍数（盈亏 / 开仓时止损风险）
+	SharpeRatio     float64 `json:"sharpe_ratio"`      // 周期夏普比率（非年化）
+	SortinoRatio    float64 `json:"sortino_ratio"`     // 周期索提诺比率（只惩罚下行波动）
+	MaxDrawdownPct  float64 `json:"max_drawdown_pct"`  // 最大回撤（%）
+	ExposureTimePct float64 `json:"exposure_time_pct"` // 有持仓时间占统计区间的比例（%）
+	MaxWinStreak    int     `json:"max_win_streak"`    // 最长连续盈利笔数
+	MaxLossStreak   int     `json:"max_loss_streak"`   // 最长连续亏损笔数
+	CurrentStreak   int     `json:"current_streak"`    // 当前连续盈亏笔数（正数为连胜，负数为连亏）
+	TradesPerDay    float64 `json:"trades_per_day"`    // 平均每天平仓笔数（统计区间不足1天按1天计）
+}
+
+// CalculateMetrics 根据交易结果和权益曲线计算绩效指标
+func CalculateMetrics(trades []TradeOutcome, equity []EquityPoint) *TradeMetrics {
+	m := &TradeMetrics{TotalTrades: len(trades)}
+
+	grossWin, grossLoss := 0.0, 0.0
+	wins := 0
+	totalR, rCount := 0.0, 0
+	for _, t := range trades {
+		m.TotalPnL += t.PnL
+		if t.PnL > 0 {
+			wins++
+			grossWin += t.PnL
+		} else if t.PnL < 0 {
+			grossLoss -= t.PnL
+		}
+		if t.RiskUSD > 0 {
+			totalR += t.PnL / t.RiskUSD
+			rCount++
+		}
+	}
+
+	if len(trades) > 0 {
+		m.WinRate = float64(wins) / float64(len(trades)) * 100
+		m.Expectancy = m.TotalPnL / float64(len(trades))
+	}
+	if grossLoss > 0 {
+		m.ProfitFactor = grossWin / grossLoss
+	} else if grossWin > 0 {
+		m.ProfitFactor = 999.0 // 只有盈利没有亏损
+	}
+	if rCount > 0 {
+		m.AvgR = totalR / float64(rCount)
+	}
+
+	returns := equityReturns(equity)
+	m.SharpeRatio, m.SortinoRatio = riskAdjustedRatios(returns)
+	m.MaxDrawdownPct = maxDrawdownPct(equity)
+	m.ExposureTimePct = exposureTimePct(trades, equity)
+	m.MaxWinStreak, m.MaxLossStreak, m.CurrentStreak = tradeStreaks(trades)
+	if len(trades) > 0 {
+		days := 1.0
+		if len(equity) > 1 {
+			days = math.Max(days, equity[len(equity)-1].Time.Sub(equity[0].Time).Hours()/24)
+		}
+		m.TradesPerDay = float64(len(trades)) / days
+	}
+
+	return m
+}
+
+// tradeStreaks 按平仓时间统计连续盈亏，盈亏为0的交易中断连续
+func tradeStreaks(trades []TradeOutcome) (maxWin, maxLoss, current int) {
+	ordered := make([]TradeOutcome, len(trades))
+	copy(ordered, trades)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].CloseTime.Before(ordered[j].CloseTime) })
+
+	for _, t := range ordered {
+		switch {
+		case t.PnL > 0:
+			if current < 0 {
+				current = 0
+			}
+			current++
+			if current > maxWin {
+				maxWin = current
+			}
+		case t.PnL < 0:
+			if current > 0 {
+				current = 0
+			}
+			current--
+			if -current > maxLoss {
+				maxLoss = -current
+			}
+		default:
+			current = 0
+		}
+	}
+	return maxWin, maxLoss, current
+}
+
+// equityReturns 权益曲线的逐周期收益率
+func equityReturns(equity []EquityPoint) []float64 {
+	var returns []float64
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1].Equity > 0 {
+			returns = append(returns, (equity[i].Equity-equity[i-1].Equity)/equity[i-1].Equity)
+		}
+	}
+	return returns
+}
+
+// riskAdjustedRatios 计算夏普和索提诺比率（无风险利率按0处理）
+func riskAdjustedRatios(returns []float64) (sharpe, sortino float64) {
+	if len(returns) == 0 {
+		return 0, 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance, downside := 0.0, 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	stdDev := math.Sqrt(variance / float64(len(returns)))
+	downsideDev := math.Sqrt(downside / float64(len(returns)))
+
+	return safeRatio(mean, stdDev), safeRatio(mean, downsideDev)
+}
+
+// safeRatio 分母为0时按收益方向返回±999（与calculateSharpeRatio保持一致）
+func safeRatio(mean, deviation float64) float64 {
+	if deviation == 0 {
+		switch {
+		case mean > 0:
+			return 999.0
+		case mean < 0:
+			return -999.0
+		}
+		return 0
+	}
+	return mean / deviation
+}
+
+// maxDrawdownPct 权益曲线峰值到谷值的最大回撤百分比
+func maxDrawdownPct(equity []EquityPoint) float64 {
+	peak, maxDD := 0.0, 0.0
+	for _, p := range equity {
+		if p.Equity > peak {
+			peak = p.Equity
+		}
+		if peak > 0 {
+			if dd := (peak - p.Equity) / peak * 100; dd > maxDD {
+				maxDD = dd
+			}
+		}
+	}
+	return maxDD
+}
+
+// exposureTimePct 持仓区间并集占统计区间的比例（多笔重叠持仓只算一次）
+func exposureTimePct(trades []TradeOutcome, equity []EquityPoint) float64 {
+	if len(trades) == 0 {
+		return 0
+	}
+
+	type interval struct{ start, end time.Time }
+	intervals := make([]interval, 0, len(trades))
+	start, end := trades[0].OpenTime, trades[0].CloseTime
+	for _, t := range trades {
+		if !t.CloseTime.After(t.OpenTime) {
+			continue
+		}
+		intervals = append(intervals, interval{t.OpenTime, t.CloseTime})
+		if t.OpenTime.Before(start) {
+			start = t.OpenTime
+		}
+		if t.CloseTime.After(end) {
+			end = t.CloseTime
+		}
+	}
+	if len(equity) > 1 {
+		if equity[0].Time.Before(start) {
+			start = equity[0].Time
+		}
+		if last := equity[len(equity)-1].Time; last.After(end) {
+			end = last
+		}
+	}
+	span := end.Sub(start)
+	if span <= 0 || len(intervals) == 0 {
+		return 0
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+	var exposed time.Duration
+	cur := intervals[0]
+	for _, iv := range intervals[1:] {
+		if iv.start.After(cur.end) {
+			exposed += cur.end.Sub(cur.start)
+			cur = iv
+			continue
+		}
+		if iv.end.After(cur.end) {
+			cur.end = iv.end
+		}
+	}
+	exposed += cur.end.Sub(cur.start)
+
+	return float64(exposed) / float64(span) * 100
+}
+
+// equityCurve 从决策记录中提取权益曲线
+func equityCurve(records []*DecisionRecord) []EquityPoint {
+	curve := make([]EquityPoint, 0, len(records))
+	for _, record := range records {
+		// TotalBalance 实际存储的是账户总净值
+		if record.AccountState.TotalBalance > 0 {
+			curve = append(curve, EquityPoint{Time: record.Timestamp, Equity: record.AccountState.TotalBalance})
+		}
+	}
+	return curve
+}
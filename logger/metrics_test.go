@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateMetrics(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return base.Add(time.Duration(hours) * time.Hour) }
+
+	trades := []TradeOutcome{
+		{PnL: 30, RiskUSD: 10, OpenTime: at(0), CloseTime: at(2)},
+		{PnL: -10, RiskUSD: 10, OpenTime: at(1), CloseTime: at(3)}, // 与上一笔重叠
+		{PnL: 20, RiskUSD: 0, OpenTime: at(6), CloseTime: at(7)},   // 无止损，不计入R
+	}
+	equity := []EquityPoint{
+		{at(0), 1000}, {at(2), 1100}, {at(4), 990}, {at(6), 1050}, {at(10), 1040},
+	}
+
+	m := CalculateMetrics(trades, equity)
+
+	check := func(name string, got, want float64) {
+		if math.Abs(got-want) > 1e-6 {
+			t.Errorf("%s = %.6f，期望 %.6f", name, got, want)
+		}
+	}
+	check("TotalPnL", m.TotalPnL, 40)
+	check("WinRate", m.WinRate, 200.0/3)
+	check("ProfitFactor", m.ProfitFactor, 5)
+	check("Expectancy", m.Expectancy, 40.0/3)
+	check("AvgR", m.AvgR, 1)
+	check("MaxDrawdownPct", m.MaxDrawdownPct, 10)
+	check("ExposureTimePct", m.ExposureTimePct, 40) // (3h + 1h) / 10h
+	check("TradesPerDay", m.TradesPerDay, 3)        // 不足1天按1天计
+
+	if m.MaxWinStreak != 1 || m.MaxLossStreak != 1 || m.CurrentStreak != 1 {
+		t.Errorf("连续盈亏错误: win=%d loss=%d current=%d", m.MaxWinStreak, m.MaxLossStreak, m.CurrentStreak)
+	}
+
+	if m.SortinoRatio <= m.SharpeRatio {
+		t.Errorf("下行波动小于总波动时，Sortino(%.4f)应大于Sharpe(%.4f)", m.SortinoRatio, m.SharpeRatio)
+	}
+}
+
+func TestTradeStreaks(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var trades []TradeOutcome
+	for i, pnl := range []float64{5, 3, 8, -1, -2, -4, 0, 2, -3, -1} {
+		trades = append(trades, TradeOutcome{PnL: pnl, CloseTime: base.Add(time.Duration(i) * time.Hour)})
+	}
+	// 顺序打乱后按平仓时间统计
+	trades[0], trades[9] = trades[9], trades[0]
+
+	maxWin, maxLoss, current := tradeStreaks(trades)
+	if maxWin != 3 || maxLoss != 3 || current != -2 {
+		t.Errorf("tradeStreaks = %d, %d, %d，期望 3, 3, -2", maxWin, maxLoss, current)
+	}
+}
+
+func TestCalculateMetricsEmpty(t *testing.T) {
+	m := CalculateMetrics(nil, nil)
+	if m.TotalTrades != 0 || m.ProfitFactor != 0 || m.MaxDrawdownPct != 0 || m.ExposureTimePct != 0 {
+		t.Errorf("空输入应返回零值指标: %+v", m)
+	}
+}
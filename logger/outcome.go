@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// 平仓原因
+const (
+	ExitReasonAI          = "ai_close"    // AI决策平仓
+	ExitReasonStopLoss    = "stop_loss"   // 交易所止损单触发
+	ExitReasonTakeProfit  = "take_profit" // 交易所止盈单触发
+	ExitReasonLiquidation = "liquidation" // 强平
+	ExitReasonFunding     = "funding"     // 资金费超出预算自动平仓
+	ExitReasonUnknown     = "unknown"     // 仓位消失但无法判断原因（如手动平仓）
+)
+
+// outcomeSearchLimit 回填结果时最多向前查找的记录数
+const outcomeSearchLimit = 2000
+
+// DecisionOutcome 开仓决策的实际结果（平仓后回填到开仓记录）
+type DecisionOutcome struct {
+	ExitReason     string    `json:"exit_reason"`
+	ClosePrice     float64   `json:"close_price"`
+	PnL            float64   `json:"pnl"`        // 按开平仓价格计算的盈亏（USDT，不含手续费）
+	RMultiple      float64   `json:"r_multiple"` // 盈亏 / 开仓时止损风险，无止损时为0
+	HoldingMinutes float64   `json:"holding_minutes"`
+	CloseTime      time.Time `json:"close_time"`
+}
+
+// newDecisionOutcome 根据开仓动作和平仓信息计算结果
+func newDecisionOutcome(open DecisionAction, closePrice float64, closeTime time.Time, reason string) *DecisionOutcome {
+	direction := 1.0
+	if open.Action == "open_short" {
+		direction = -1.0
+	}
+
+	outcome := &DecisionOutcome{
+		ExitReason:     reason,
+		ClosePrice:     closePrice,
+		PnL:            (closePrice - open.Price) * open.Quantity * direction,
+		HoldingMinutes: closeTime.Sub(open.Timestamp).Minutes(),
+		CloseTime:      closeTime,
+	}
+	if open.StopLoss > 0 {
+		if risk := open.Quantity * math.Abs(open.Price-open.StopLoss); risk > 0 {
+			outcome.RMultiple = outcome.PnL / risk
+		}
+	}
+	return outcome
+}
+
+// LabelOutcome 把平仓结果回填到对应的开仓决策记录
+// 从最新的记录往前找该币种该方向最近一次成功开仓，已经标注过则说明没有未平仓的开仓记录
+func (l *DecisionLogger) LabelOutcome(symbol, side string, closePrice float64, closeTime time.Time, reason string) (*DecisionOutcome, error) {
+	l.rewriteMu.Lock()
+	defer l.rewriteMu.Unlock()
+
+	refs, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	openAction := "open_" + side
+	searched := 0
+	for i := len(refs) - 1; i >= 0 && searched < outcomeSearchLimit; i-- {
+		searched++
+
+		record, err := l.store.Read(refs[i].ID)
+		if err != nil {
+			continue
+		}
+
+		for j := len(record.Decisions) - 1; j >= 0; j-- {
+			action := &record.Decisions[j]
+			if !action.Success || action.Symbol != symbol || action.Action != openAction {
+				continue
+			}
+			if action.Outcome != nil {
+				return nil, fmt.Errorf("%s %s 最近一次开仓已有结果", symbol, side)
+			}
+
+			action.Outcome = newDecisionOutcome(*action, closePrice, closeTime, reason)
+			if err := l.store.Save(record); err != nil {
+				return nil, err
+			}
+			return action.Outcome, nil
+		}
+	}
+
+	return nil, fmt.Errorf("未找到 %s %s 的开仓记录", symbol, side)
+}
+
+// GetRealizedPnLBetween 汇总平仓时间在 (from, to] 内的已回填结果盈亏，返回盈亏合计和笔数
+func (l *DecisionLogger) GetRealizedPnLBetween(from, to time.Time) (float64, int, error) {
+	refs, err := l.store.List()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	total := 0.0
+	count := 0
+	searched := 0
+	for i := len(refs) - 1; i >= 0 && searched < outcomeSearchLimit; i-- {
+		searched++
+
+		record, err := l.store.Read(refs[i].ID)
+		if err != nil {
+			continue
+		}
+		for _, action := range record.Decisions {
+			if action.Outcome == nil || !action.Outcome.CloseTime.After(from) || action.Outcome.CloseTime.After(to) {
+				continue
+			}
+			total += action.Outcome.PnL
+			count++
+		}
+	}
+	return total, count, nil
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLabelOutcome(t *testing.T) {
+	l := NewDecisionLogger(t.TempDir())
+	openTime := time.Now().Add(-90 * time.Minute)
+	if err := l.LogDecision(&DecisionRecord{Decisions: []DecisionAction{
+		{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.1, Price: 60000, StopLoss: 59000, Timestamp: openTime, Success: true},
+	}}); err != nil {
+		t.Fatalf("写入决策记录失败: %v", err)
+	}
+
+	outcome, err := l.LabelOutcome("BTCUSDT", "long", 62000, openTime.Add(90*time.Minute), ExitReasonTakeProfit)
+	if err != nil {
+		t.Fatalf("回填结果失败: %v", err)
+	}
+	if math.Abs(outcome.PnL-200) > 1e-9 || math.Abs(outcome.RMultiple-2) > 1e-9 || math.Abs(outcome.HoldingMinutes-90) > 1e-9 {
+		t.Errorf("结果计算错误: %+v", outcome)
+	}
+
+	records, err := l.GetLatestRecords(1)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("读取记录失败: %v", err)
+	}
+	if got := records[0].Decisions[0].Outcome; got == nil || got.ExitReason != ExitReasonTakeProfit {
+		t.Errorf("开仓记录未回填结果: %+v", got)
+	}
+
+	if _, err := l.LabelOutcome("BTCUSDT", "long", 61000, time.Now(), ExitReasonAI); err == nil {
+		t.Error("已有结果的开仓不应被重复回填")
+	}
+	if _, err := l.LabelOutcome("BTCUSDT", "short", 61000, time.Now(), ExitReasonAI); err == nil {
+		t.Error("没有对应方向的开仓记录时应返回错误")
+	}
+}
+
+func TestGetRealizedPnLBetween(t *testing.T) {
+	l := NewDecisionLogger(t.TempDir())
+	start := time.Now().Add(-3 * time.Hour)
+	l.LogDecision(&DecisionRecord{Decisions: []DecisionAction{
+		{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.1, Price: 60000, Timestamp: start, Success: true},
+		{Action: "open_short", Symbol: "ETHUSDT", Quantity: 1, Price: 3000, Timestamp: start, Success: true},
+	}})
+	l.LabelOutcome("BTCUSDT", "long", 61000, start.Add(time.Hour), ExitReasonAI)
+	l.LabelOutcome("ETHUSDT", "short", 3050, start.Add(2*time.Hour), ExitReasonStopLoss)
+
+	pnl, count, err := l.GetRealizedPnLBetween(start, start.Add(90*time.Minute))
+	if err != nil || count != 1 || math.Abs(pnl-100) > 1e-9 {
+		t.Errorf("区间内应只有BTC盈利100: %.2f (%d笔) %v", pnl, count, err)
+	}
+	pnl, count, _ = l.GetRealizedPnLBetween(start, start.Add(2*time.Hour))
+	if count != 2 || math.Abs(pnl-50) > 1e-9 {
+		t.Errorf("区间终点应包含在内: %.2f (%d笔)", pnl, count)
+	}
+}
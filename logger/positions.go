@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"strings"
+)
+
+// ExpectedPosition 根据决策日志推算的应有持仓
+type ExpectedPosition struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`
+	Quantity   float64 `json:"quantity"`
+	EntryPrice float64 `json:"entry_price"`
+	StopLoss   float64 `json:"stop_loss"`   // 最近一次开仓设置的止损（找不到时为0）
+	TakeProfit float64 `json:"take_profit"` // 最近一次开仓设置的止盈（找不到时为0）
+}
+
+// GetExpectedPositions 推算当前应有的持仓：最新记录的持仓快照 + 该周期成功执行的开平仓
+// 没有任何记录时返回空map；key 为 symbol_side
+func (l *DecisionLogger) GetExpectedPositions() (map[string]*ExpectedPosition, error) {
+	refs, err := l.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*DecisionRecord
+	for i := len(refs) - 1; i >= 0 && len(records) < outcomeSearchLimit; i-- {
+		record, err := l.store.Read(refs[i].ID)
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	expected := make(map[string]*ExpectedPosition)
+	if len(records) == 0 {
+		return expected, nil
+	}
+
+	latest := records[0]
+	for _, pos := range latest.Positions {
+		expected[pos.Symbol+"_"+pos.Side] = &ExpectedPosition{
+			Symbol:     pos.Symbol,
+			Side:       pos.Side,
+			Quantity:   pos.PositionAmt,
+			EntryPrice: pos.EntryPrice,
+		}
+	}
+	for _, action := range latest.Decisions {
+		if !action.Success {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(action.Action, "open_"):
+			side := strings.TrimPrefix(action.Action, "open_")
+			expected[action.Symbol+"_"+side] = &ExpectedPosition{
+				Symbol:     action.Symbol,
+				Side:       side,
+				Quantity:   action.Quantity,
+				EntryPrice: action.Price,
+			}
+		case strings.HasPrefix(action.Action, "close_"):
+			delete(expected, action.Symbol+"_"+strings.TrimPrefix(action.Action, "close_"))
+		}
+	}
+
+	// 止损止盈只记录在开仓动作上，向前查找每个持仓最近一次成功开仓
+	for key, pos := range expected {
+	search:
+		for _, record := range records {
+			for j := len(record.Decisions) - 1; j >= 0; j-- {
+				action := record.Decisions[j]
+				if action.Success && action.Symbol == pos.Symbol && action.Action == "open_"+pos.Side {
+					expected[key].StopLoss = action.StopLoss
+					expected[key].TakeProfit = action.TakeProfit
+					break search
+				}
+			}
+		}
+	}
+	return expected, nil
+}
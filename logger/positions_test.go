@@ -0,0 +1,51 @@
+package logger
+
+import "testing"
+
+func TestGetExpectedPositions(t *testing.T) {
+	l := NewDecisionLogger(t.TempDir())
+
+	if expected, err := l.GetExpectedPositions(); err != nil || len(expected) != 0 {
+		t.Fatalf("没有记录时应返回空持仓: %v, %v", expected, err)
+	}
+
+	records := []*DecisionRecord{
+		{Decisions: []DecisionAction{
+			{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.1, Price: 60000, StopLoss: 59000, TakeProfit: 66000, Success: true},
+			{Action: "open_short", Symbol: "ETHUSDT", Quantity: 1, Price: 3000, StopLoss: 3100, TakeProfit: 2700, Success: true},
+		}},
+		{
+			Positions: []PositionSnapshot{
+				{Symbol: "BTCUSDT", Side: "long", PositionAmt: 0.1, EntryPrice: 60000},
+				{Symbol: "ETHUSDT", Side: "short", PositionAmt: 1, EntryPrice: 3000},
+			},
+			Decisions: []DecisionAction{
+				{Action: "close_short", Symbol: "ETHUSDT", Success: true},
+				{Action: "open_long", Symbol: "SOLUSDT", Quantity: 10, Price: 150, StopLoss: 145, TakeProfit: 170, Success: true},
+				{Action: "open_long", Symbol: "DOGEUSDT", Quantity: 1000, Price: 0.1, Success: false},
+			},
+		},
+	}
+	for _, r := range records {
+		if err := l.LogDecision(r); err != nil {
+			t.Fatalf("写入决策记录失败: %v", err)
+		}
+	}
+
+	expected, err := l.GetExpectedPositions()
+	if err != nil {
+		t.Fatalf("推算持仓失败: %v", err)
+	}
+	if len(expected) != 2 {
+		t.Fatalf("应有BTC多和SOL多两个持仓，得到 %v", expected)
+	}
+	if btc := expected["BTCUSDT_long"]; btc == nil || btc.Quantity != 0.1 || btc.StopLoss != 59000 || btc.TakeProfit != 66000 {
+		t.Errorf("BTC持仓应来自快照并带上早先开仓的止损止盈: %+v", btc)
+	}
+	if sol := expected["SOLUSDT_long"]; sol == nil || sol.Quantity != 10 || sol.StopLoss != 145 {
+		t.Errorf("SOL持仓应来自最新周期的开仓: %+v", sol)
+	}
+	if _, ok := expected["ETHUSDT_short"]; ok {
+		t.Error("已平仓的ETH空仓不应出现")
+	}
+}
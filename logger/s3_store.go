@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"nofx/objstore"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// s3Timeout 单次S3请求的超时时间
+const s3Timeout = 30 * time.Second
+
+// s3Storage 记录保存为 <prefix><trader_id>/<记录标识>.json 对象，净值归档为 <prefix><trader_id>/equity_archive.jsonl
+type s3Storage struct {
+	cfg *objstore.S3Config
+}
+
+func (s s3Storage) open(logDir string) Store {
+	return &s3Store{cfg: s.cfg, traderID: filepath.Base(logDir)}
+}
+
+func (s s3Storage) traderIDs(string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	_, dirs, err := s.cfg.List(ctx, "", "/")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		ids = append(ids, strings.TrimSuffix(dir, "/"))
+	}
+	return ids, nil
+}
+
+// s3Store 一个交易员在对象存储中的记录
+type s3Store struct {
+	cfg      *objstore.S3Config
+	traderID string
+}
+
+func (s *s3Store) key(name string) string {
+	return s.traderID + "/" + name
+}
+
+// List 按对象名列出记录，对象名与文件存储的文件名相同，按名称排序即按时间排序
+func (s *s3Store) List() ([]RecordRef, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	keys, _, err := s.cfg.List(ctx, s.key(recordPrefix), "")
+	if err != nil {
+		return nil, fmt.Errorf("列出决策记录失败: %w", err)
+	}
+	var refs []RecordRef
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, s.key(""))
+		if !strings.HasSuffix(name, recordExt) {
+			continue
+		}
+		id := recordID(name)
+		t, _ := recordIDTime(id)
+		refs = append(refs, RecordRef{ID: id, Time: t})
+	}
+	return refs, nil
+}
+
+func (s *s3Store) Read(id string) (*DecisionRecord, error) {
+	if !validRecordID(id) {
+		return nil, ErrRecordNotFound
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	data, err := s.cfg.Get(ctx, s.key(id+recordExt))
+	if errors.Is(err, objstore.ErrNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record DecisionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	record.ID = id
+	return &record, nil
+}
+
+func (s *s3Store) Save(record *DecisionRecord) error {
+	if !validRecordID(record.ID) {
+		return ErrRecordNotFound
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化决策记录失败: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	if err := s.cfg.Put(ctx, s.key(record.ID+recordExt), data); err != nil {
+		return fmt.Errorf("写入决策记录失败: %w", err)
+	}
+	return nil
+}
+
+func (s *s3Store) Delete(id string) error {
+	if !validRecordID(id) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	return s.cfg.Delete(ctx, s.key(id+recordExt))
+}
+
+// AppendEquityArchive 对象不支持追加，读出整个归档后写回（归档按小时降采样，体积很小）
+func (s *s3Store) AppendEquityArchive(points []ArchivedEquity) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	data, err := s.cfg.Get(ctx, s.key(equityArchiveFile))
+	if err != nil && !errors.Is(err, objstore.ErrNotFound) {
+		return err
+	}
+
+	buf := bytes.NewBuffer(data)
+	if buf.Len() > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	for _, p := range points {
+		line, _ := json.Marshal(p)
+		buf.Write(append(line, '\n'))
+	}
+	return s.cfg.Put(ctx, s.key(equityArchiveFile), buf.Bytes())
+}
+
+func (s *s3Store) EquityArchive() ([]ArchivedEquity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+	data, err := s.cfg.Get(ctx, s.key(equityArchiveFile))
+	if errors.Is(err, objstore.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseEquityArchive(data), nil
+}
@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"fmt"
+	"nofx/config"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 检索结果的片段设置
+const (
+	snippetRadius      = 60 // 命中词前后各保留的字符数
+	maxSnippetsPerHit  = 3
+	maxSearchTermCount = 8
+)
+
+// searchIndex 决策记录的检索索引（配置数据库的 decision_search 表），为空时不建立索引
+var (
+	searchIndex   *config.Database
+	searchIndexMu sync.Mutex // 同步索引时互斥，避免同一交易员并发补建
+)
+
+// SetSearchIndex 设置保存检索索引的数据库，设置后新记录写入时同步建立索引
+func SetSearchIndex(database *config.Database) {
+	searchIndex = database
+}
+
+// SearchHit 一条命中的决策记录
+type SearchHit struct {
+	RecordID    string    `json:"record_id"`
+	Timestamp   time.Time `json:"timestamp"`
+	CycleNumber int       `json:"cycle_number"`
+	Snippets    []string  `json:"snippets"` // 命中词前后的原文片段
+}
+
+// searchContent 参与检索的字段：思维链、决策JSON（含每个决策的reasoning）和错误信息
+func searchContent(record *DecisionRecord) string {
+	parts := make([]string, 0, 3)
+	for _, s := range []string{record.CoTTrace, record.DecisionJSON, record.ErrorMessage} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// traderID 日志目录对应的交易员ID
+func (l *DecisionLogger) traderID() string {
+	return filepath.Base(l.logDir)
+}
+
+// indexRecord 把记录写入检索索引（失败不影响记录本身，下次检索时补建）
+func (l *DecisionLogger) indexRecord(record *DecisionRecord) error {
+	if searchIndex == nil {
+		return nil
+	}
+	return searchIndex.IndexDecisionRecord(&config.DecisionSearchEntry{
+		TraderID:    l.traderID(),
+		RecordID:    record.ID,
+		Timestamp:   record.Timestamp,
+		CycleNumber: record.CycleNumber,
+		Content:     searchContent(record),
+	})
+}
+
+// SyncSearchIndex 补建缺少索引的记录（如启用索引前的记录），删除已清理记录的索引，返回补建的记录数
+func (l *DecisionLogger) SyncSearchIndex() (int, error) {
+	if searchIndex == nil {
+		return 0, fmt.Errorf("未启用决策记录检索")
+	}
+	searchIndexMu.Lock()
+	defer searchIndexMu.Unlock()
+
+	refs, err := l.store.List()
+	if err != nil {
+		return 0, err
+	}
+	indexed, err := searchIndex.GetIndexedDecisionRecordIDs(l.traderID())
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, ref := range refs {
+		if indexed[ref.ID] {
+			delete(indexed, ref.ID)
+			continue
+		}
+		record, err := l.store.Read(ref.ID)
+		if err != nil {
+			continue
+		}
+		if err := l.indexRecord(record); err != nil {
+			return added, err
+		}
+		added++
+	}
+
+	if len(indexed) > 0 {
+		stale := make([]string, 0, len(indexed))
+		for id := range indexed {
+			stale = append(stale, id)
+		}
+		if err := searchIndex.DeleteDecisionSearchEntries(l.traderID(), stale); err != nil {
+			return added, err
+		}
+	}
+	return added, nil
+}
+
+// ParseSearchTerms 把检索语句拆成关键词：空格分隔，双引号括起的短语作为一个词
+func ParseSearchTerms(query string) []string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		terms = append(terms, strings.Fields(part)...)
+	}
+	return terms
+}
+
+// Search 检索思维链和决策理由中包含全部关键词的记录（新的在前，不区分大小写，支持中文）
+// 检索前先补建缺少的索引，第一次检索历史较长的交易员会慢一些
+func (l *DecisionLogger) Search(terms []string, from, to time.Time, limit int) ([]SearchHit, error) {
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("缺少检索关键词")
+	}
+	if len(terms) > maxSearchTermCount {
+		return nil, fmt.Errorf("关键词不能超过 %d 个", maxSearchTermCount)
+	}
+	if _, err := l.SyncSearchIndex(); err != nil {
+		return nil, err
+	}
+
+	entries, err := searchIndex.SearchDecisionRecords(config.DecisionSearchQuery{
+		TraderID: l.traderID(),
+		Terms:    terms,
+		From:     from,
+		To:       to,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(entries))
+	for _, e := range entries {
+		hits = append(hits, SearchHit{
+			RecordID:    e.RecordID,
+			Timestamp:   e.Timestamp.Local(),
+			CycleNumber: e.CycleNumber,
+			Snippets:    searchSnippets(e.Content, terms),
+		})
+	}
+	return hits, nil
+}
+
+// searchSnippets 每个关键词第一次出现位置前后的原文片段，相互重叠的只保留一个
+func searchSnippets(content string, terms []string) []string {
+	lower := strings.ToLower(content)
+	if len(lower) != len(content) {
+		// 个别字符转小写后字节数变化，片段改用小写内容，保证下标对应
+		content = lower
+	}
+
+	var snippets []string
+	var covered [][2]int // 已有片段的范围
+	for _, term := range terms {
+		term = strings.ToLower(term)
+		pos := strings.Index(lower, term)
+		if pos < 0 || isCovered(covered, pos) {
+			continue
+		}
+		start := pos
+		for n := 0; n < snippetRadius && start > 0; n++ {
+			_, size := utf8.DecodeLastRuneInString(content[:start])
+			start -= size
+		}
+		end := pos + len(term)
+		for n := 0; n < snippetRadius && end < len(content); n++ {
+			_, size := utf8.DecodeRuneInString(content[end:])
+			end += size
+		}
+
+		snippet := strings.Join(strings.Fields(content[start:end]), " ")
+		if start > 0 {
+			snippet = "…" + snippet
+		}
+		if end < len(content) {
+			snippet += "…"
+		}
+		snippets = append(snippets, snippet)
+		covered = append(covered, [2]int{start, end})
+		if len(snippets) == maxSnippetsPerHit {
+			break
+		}
+	}
+	return snippets
+}
+
+// isCovered 位置是否在已有片段中
+func isCovered(ranges [][2]int, pos int) bool {
+	for _, r := range ranges {
+		if pos >= r[0] && pos < r[1] {
+			return true
+		}
+	}
+	return false
+}
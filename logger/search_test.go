@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"nofx/config"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	database, err := config.NewDatabase(filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	// 启用索引前已有的记录，检索时补建
+	dir := filepath.Join(t.TempDir(), "t1")
+	l := NewDecisionLogger(dir)
+	old := writeTestRecord(t, dir, time.Now().Add(-time.Hour), 1, 1000)
+	record, _ := l.store.Read(old)
+	record.CoTTrace = "资金费率偏高，Funding 成本超过预期，暂不开仓"
+	l.store.Save(record)
+
+	SetSearchIndex(database)
+	defer SetSearchIndex(nil)
+	l.LogDecision(&DecisionRecord{CoTTrace: "BTC 突破前高", DecisionJSON: `[{"symbol":"BTCUSDT","action":"open_long","reasoning":"趋势向上，funding 为负"}]`})
+	l.LogDecision(&DecisionRecord{ErrorMessage: "AI调用超时（已重试100次）"})
+
+	hits, err := l.Search([]string{"FUNDING"}, time.Time{}, time.Time{}, 10)
+	if err != nil || len(hits) != 2 {
+		t.Fatalf("应命中2条记录: %+v %v", hits, err)
+	}
+	if hits[1].RecordID != old || !strings.Contains(hits[1].Snippets[0], "Funding 成本") {
+		t.Errorf("结果应按时间倒序并包含原文片段: %+v", hits)
+	}
+
+	if hits, _ := l.Search([]string{"funding", "资金费率"}, time.Time{}, time.Time{}, 10); len(hits) != 1 || hits[0].RecordID != old {
+		t.Errorf("多个关键词应全部出现: %+v", hits)
+	}
+	if hits, _ := l.Search([]string{"funding"}, time.Now().Add(-time.Minute), time.Time{}, 10); len(hits) != 1 || hits[0].RecordID == old {
+		t.Errorf("时间范围过滤错误: %+v", hits)
+	}
+	if hits, _ := l.Search([]string{"100%"}, time.Time{}, time.Time{}, 10); len(hits) != 0 {
+		t.Errorf("通配符应按字面匹配: %+v", hits)
+	}
+
+	// 清理后的记录从索引中移除
+	l.store.Delete(old)
+	if hits, _ := l.Search([]string{"资金费率"}, time.Time{}, time.Time{}, 10); len(hits) != 0 {
+		t.Errorf("已删除的记录不应命中: %+v", hits)
+	}
+}
+
+func TestParseSearchTerms(t *testing.T) {
+	got := ParseSearchTerms(`funding  "stop loss" 止损 ""`)
+	if strings.Join(got, "|") != "funding|stop loss|止损" {
+		t.Errorf("ParseSearchTerms = %q", got)
+	}
+}
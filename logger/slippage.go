@@ -0,0 +1,31 @@
+package logger
+
+import "math"
+
+// SlippageStats 成交滑点统计（基点，正数表示成交价比决策价差）
+type SlippageStats struct {
+	Fills    int     `json:"fills"`     // 有成交均价的订单数
+	AvgBps   float64 `json:"avg_bps"`   // 平均滑点
+	WorstBps float64 `json:"worst_bps"` // 最差的一笔
+}
+
+// SlippageBps 成交价相对决策价的滑点（基点）：买入成交价高于决策价、卖出成交价低于决策价为正（不利）
+func SlippageBps(action string, decisionPrice, fillPrice float64) float64 {
+	if decisionPrice <= 0 || fillPrice <= 0 {
+		return 0
+	}
+	bps := (fillPrice - decisionPrice) / decisionPrice * 10000
+	if action == "open_short" || action == "close_long" {
+		bps = -bps
+	}
+	return math.Round(bps*100) / 100
+}
+
+// add 累加一笔成交的滑点
+func (s *SlippageStats) add(bps float64) {
+	if s.Fills == 0 || bps > s.WorstBps {
+		s.WorstBps = bps
+	}
+	s.AvgBps = (s.AvgBps*float64(s.Fills) + bps) / float64(s.Fills+1)
+	s.Fills++
+}
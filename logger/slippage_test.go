@@ -0,0 +1,46 @@
+package logger
+
+import "testing"
+
+func TestSlippageBps(t *testing.T) {
+	cases := []struct {
+		action      string
+		price, fill float64
+		want        float64
+	}{
+		{"open_long", 100, 100.1, 10},   // 买贵了
+		{"close_short", 100, 99.9, -10}, // 买便宜了
+		{"open_short", 100, 99.95, 5},   // 卖便宜了
+		{"close_long", 100, 100.2, -20},
+		{"open_long", 0, 100, 0},
+	}
+	for _, c := range cases {
+		if got := SlippageBps(c.action, c.price, c.fill); got != c.want {
+			t.Errorf("SlippageBps(%s, %v, %v) = %v, want %v", c.action, c.price, c.fill, got, c.want)
+		}
+	}
+}
+
+func TestStatisticsSlippage(t *testing.T) {
+	l := NewDecisionLogger(t.TempDir())
+	if err := l.LogDecision(&DecisionRecord{Success: true, Decisions: []DecisionAction{
+		{Action: "open_long", Symbol: "BTCUSDT", Price: 100, FillPrice: 100.1, SlippageBps: 10, Success: true},
+		{Action: "open_short", Symbol: "ETHUSDT", Price: 100, Success: true}, // 没有成交均价
+		{Action: "close_long", Symbol: "SOLUSDT", Price: 100, FillPrice: 99.98, SlippageBps: 2, Success: true},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := l.GetStatistics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Slippage == nil || stats.Slippage.Fills != 2 || stats.Slippage.AvgBps != 6 || stats.Slippage.WorstBps != 10 {
+		t.Errorf("滑点统计错误: %+v", stats.Slippage)
+	}
+
+	empty, err := NewDecisionLogger(t.TempDir()).GetStatistics()
+	if err != nil || empty.Slippage != nil {
+		t.Errorf("没有成交时不应返回滑点: %+v %v", empty.Slippage, err)
+	}
+}
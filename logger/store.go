@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"nofx/config"
+	"nofx/objstore"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 决策日志存储类型
+const (
+	StorageFile     = "file"     // 每条记录一个JSON文件（默认）
+	StorageDatabase = "database" // 配置数据库（SQLite/PostgreSQL）的 decision_records 表
+	StorageS3       = "s3"       // S3兼容的对象存储
+)
+
+// RecordRef 存储中的一条决策记录（列出记录时不读取内容）
+type RecordRef struct {
+	ID         string
+	Time       time.Time
+	Compressed bool // 文件存储中已gzip压缩
+}
+
+// Store 决策记录的存储后端，一个实例对应一个交易员
+// 实现不需要处理并发改写：DecisionLogger 改写已有记录时持有该交易员的改写锁
+type Store interface {
+	// List 全部记录，按时间从旧到新
+	List() ([]RecordRef, error)
+	// Read 读取一条记录，不存在时返回 ErrRecordNotFound
+	Read(id string) (*DecisionRecord, error)
+	// Save 按 record.ID 新建或覆盖记录
+	Save(record *DecisionRecord) error
+	// Delete 删除一条记录
+	Delete(id string) error
+	// AppendEquityArchive 追加降采样净值点（时间晚于已有的点）
+	AppendEquityArchive(points []ArchivedEquity) error
+	// EquityArchive 全部归档净值点，按时间正序，没有时返回空
+	EquityArchive() ([]ArchivedEquity, error)
+}
+
+// compressor 支持把旧记录压缩保存的存储
+type compressor interface {
+	Compress(id string) error
+}
+
+// StorageConfig 决策日志存储配置（系统配置 decision_log_storage，JSON）
+type StorageConfig struct {
+	Type string             `json:"type"`         // file（默认）/ database / s3
+	S3   *objstore.S3Config `json:"s3,omitempty"` // type 为 s3 时必填
+}
+
+// ParseStorageConfig 解析并校验存储配置，为空时使用文件存储
+func ParseStorageConfig(value string) (StorageConfig, error) {
+	var cfg StorageConfig
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &cfg); err != nil {
+			return cfg, fmt.Errorf("解析decision_log_storage失败: %w", err)
+		}
+	}
+	switch cfg.Type {
+	case "":
+		cfg.Type = StorageFile
+	case StorageFile, StorageDatabase:
+	case StorageS3:
+		if cfg.S3 == nil {
+			return cfg, fmt.Errorf("decision_log_storage 类型为 s3 时需要 s3 配置")
+		}
+		if err := cfg.S3.Validate(); err != nil {
+			return cfg, err
+		}
+	default:
+		return cfg, fmt.Errorf("未知的决策日志存储类型: %s（可选 file/database/s3）", cfg.Type)
+	}
+	return cfg, nil
+}
+
+// storage 按日志目录打开各交易员的记录存储
+type storage interface {
+	open(logDir string) Store
+	// traderIDs 有决策记录的交易员ID，root 为各交易员日志目录的上级目录
+	traderIDs(root string) ([]string, error)
+}
+
+// currentStorage 新建 DecisionLogger 使用的存储
+var currentStorage storage = fileStorage{}
+
+// SetStorage 设置决策日志存储（启动时、创建交易员之前调用），database 类型使用 database 保存记录
+func SetStorage(cfg StorageConfig, database *config.Database) error {
+	switch cfg.Type {
+	case "", StorageFile:
+		currentStorage = fileStorage{}
+	case StorageDatabase:
+		if database == nil {
+			return fmt.Errorf("database 存储需要配置数据库")
+		}
+		currentStorage = databaseStorage{database: database}
+	case StorageS3:
+		if cfg.S3 == nil {
+			return fmt.Errorf("s3 存储需要 s3 配置")
+		}
+		currentStorage = s3Storage{cfg: cfg.S3}
+	default:
+		return fmt.Errorf("未知的决策日志存储类型: %s", cfg.Type)
+	}
+	return nil
+}
+
+// LogDirs root 下所有有决策记录的交易员日志目录（包括未加载和已删除的交易员）
+func LogDirs(root string) ([]string, error) {
+	ids, err := currentStorage.traderIDs(root)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		dirs = append(dirs, filepath.Join(root, id))
+	}
+	return dirs, nil
+}
+
+// validRecordID 记录标识只能是 LogDecision 生成的文件名格式（不含路径）
+func validRecordID(id string) bool {
+	return id != "" && filepath.Base(id) == id && !strings.ContainsAny(id, `/\`) && strings.HasPrefix(id, recordPrefix)
+}
+
+// recordIDTime 由记录标识（decision_YYYYMMDD_HHMMSS_cycleN）得到记录时间
+func recordIDTime(id string) (time.Time, bool) {
+	id = strings.TrimPrefix(id, recordPrefix)
+	if len(id) < 15 {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("20060102_150405", id[:15], time.Local)
+	return t, err == nil
+}
+
+// dirLocks 每个日志目录一把锁：同一交易员的记录可能同时被多个 DecisionLogger 打开（交易员、后台压缩任务）
+var dirLocks sync.Map
+
+// dirLock 返回日志目录的改写锁
+func dirLock(logDir string) *sync.Mutex {
+	abs, err := filepath.Abs(logDir)
+	if err != nil {
+		abs = logDir
+	}
+	mu, _ := dirLocks.LoadOrStore(abs, &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"nofx/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useDatabaseStorage 测试期间把决策日志保存到临时数据库
+func useDatabaseStorage(t *testing.T) *config.Database {
+	t.Helper()
+	database, err := config.NewDatabase(filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetStorage(StorageConfig{Type: StorageDatabase}, database); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		currentStorage = fileStorage{}
+		database.Close()
+	})
+	return database
+}
+
+func TestDatabaseStorage(t *testing.T) {
+	useDatabaseStorage(t)
+	root := t.TempDir()
+	l := NewDecisionLogger(filepath.Join(root, "t1"))
+
+	for i := 0; i < 3; i++ {
+		record := &DecisionRecord{
+			AccountState: AccountSnapshot{TotalBalance: 1000 + float64(i)},
+			Decisions:    []DecisionAction{{Action: "open_long", Symbol: "BTCUSDT", Price: 100, Quantity: 1, Success: true}},
+		}
+		if err := l.LogDecision(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := l.GetLatestRecords(2)
+	if err != nil || len(records) != 2 || records[1].AccountState.TotalBalance != 1002 || records[1].ID == "" {
+		t.Fatalf("读取记录错误: %+v %v", records, err)
+	}
+	if _, err := l.AddAnnotation(records[1].ID, Annotation{Note: "复盘"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.LabelOutcome("BTCUSDT", "long", 110, time.Now(), ExitReasonAI); err != nil {
+		t.Fatal(err)
+	}
+	latest, _ := l.GetLatestRecords(1)
+	if len(latest[0].Annotations) != 1 || latest[0].Decisions[0].Outcome == nil {
+		t.Errorf("改写后的记录错误: %+v", latest[0])
+	}
+
+	// 其他交易员的记录互不影响
+	if other, _ := NewDecisionLogger(filepath.Join(root, "t2")).GetLatestRecords(10); len(other) != 0 {
+		t.Errorf("不应读到其他交易员的记录: %+v", other)
+	}
+	if dirs, err := LogDirs(root); err != nil || len(dirs) != 1 || filepath.Base(dirs[0]) != "t1" {
+		t.Errorf("交易员列表错误: %v %v", dirs, err)
+	}
+
+	// 过期记录删除前写入净值归档
+	result, err := l.Compact(RetentionPolicy{RetentionDays: 1, CompressAfterDays: 1}, time.Now().AddDate(0, 0, 2))
+	if err != nil || result.Removed != 3 || result.Archived != 1 || result.Compressed != 0 {
+		t.Fatalf("整理结果错误: %+v %v", result, err)
+	}
+	archive, err := l.GetEquityArchive()
+	if err != nil || len(archive) != 1 || archive[0].AccountState.TotalBalance != 1002 {
+		t.Errorf("净值归档错误: %+v %v", archive, err)
+	}
+}
+
+func TestParseStorageConfig(t *testing.T) {
+	if cfg, err := ParseStorageConfig(""); err != nil || cfg.Type != StorageFile {
+		t.Errorf("空配置应使用文件存储: %+v %v", cfg, err)
+	}
+	if _, err := ParseStorageConfig(`{"type":"s3"}`); err == nil {
+		t.Error("s3 存储缺少配置应返回错误")
+	}
+	if _, err := ParseStorageConfig(`{"type":"mongo"}`); err == nil {
+		t.Error("未知类型应返回错误")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "t1")
+	l := NewDecisionLogger(dir)
+	for i := 0; i < 2; i++ {
+		if err := l.LogDecision(&DecisionRecord{AccountState: AccountSnapshot{TotalBalance: 1000}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.store.AppendEquityArchive([]ArchivedEquity{{Timestamp: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed, err := l.Purge(); err != nil || removed != 2 {
+		t.Fatalf("删除记录数错误: %d %v", removed, err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("日志目录应被删除: %v", err)
+	}
+	if records, _ := NewDecisionLogger(dir).GetLatestRecords(10); len(records) != 0 {
+		t.Errorf("不应再读到记录: %+v", records)
+	}
+}
@@ -0,0 +1,70 @@
+// Package logging 基于 log/slog 的结构化日志：文本或JSON输出，级别可在运行时调整
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// 输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// level 全局日志级别，Setup 之后修改立即生效
+var level = new(slog.LevelVar)
+
+// Setup 初始化全局日志；标准库 log 的输出也会转为结构化日志
+func Setup(w io.Writer, levelName, format string) error {
+	lv, err := ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("不支持的日志格式: %s（可选 text / json）", format)
+	}
+
+	level.Set(lv)
+	// 之后标准库 log 的输出（第三方库）也经由该handler以INFO级别输出
+	// 带 trader_id 的日志同时进入该交易员的环形缓冲，供 /api/traders/:id/logs 查看
+	slog.SetDefault(slog.New(newCaptureHandler(handler)))
+	return nil
+}
+
+// ParseLevel 解析级别名称（debug / info / warn / error），为空时为info
+func ParseLevel(name string) (slog.Level, error) {
+	var lv slog.Level
+	if strings.TrimSpace(name) == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := lv.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return lv, fmt.Errorf("无效的日志级别 %q（可选 debug / info / warn / error）", name)
+	}
+	return lv, nil
+}
+
+// SetLevel 运行时调整日志级别
+func SetLevel(name string) error {
+	lv, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(lv)
+	return nil
+}
+
+// Level 当前日志级别名称
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
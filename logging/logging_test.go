@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetupJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Setup(&buf, "info", FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	defer log.SetOutput(os.Stderr)
+
+	slog.Warn("同步配置失败", "error", "boom")
+	log.Printf("第三方库日志")
+	slog.Debug("不应输出")
+	slog.Info("开仓", "trader_id", "t1", "cycle", 3)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("低于级别的日志应丢弃: %q", lines)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil || entry["level"] != "WARN" || entry["error"] != "boom" {
+		t.Errorf("级别和字段应写入JSON: %s", lines[0])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil || entry["level"] != "INFO" || entry["msg"] != "第三方库日志" {
+		t.Errorf("标准库log的输出应以INFO级别转为结构化日志: %s", lines[1])
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &entry); err != nil || entry["trader_id"] != "t1" || entry["cycle"] != float64(3) {
+		t.Errorf("结构化字段应写入JSON: %s", lines[2])
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Setup(&buf, "warn", FormatText); err != nil {
+		t.Fatal(err)
+	}
+	defer log.SetOutput(os.Stderr)
+
+	slog.Info("普通信息")
+	if buf.Len() != 0 {
+		t.Errorf("warn级别不应输出信息日志: %s", buf.String())
+	}
+	if err := SetLevel("debug"); err != nil || Level() != "debug" {
+		t.Fatalf("切换级别失败: %v %s", err, Level())
+	}
+	slog.Debug("调试")
+	if !strings.Contains(buf.String(), "level=DEBUG") {
+		t.Errorf("切换到debug后应立即生效: %s", buf.String())
+	}
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("无效级别应返回错误")
+	}
+	if err := Setup(&buf, "info", "xml"); err == nil {
+		t.Error("无效格式应返回错误")
+	}
+}
+
+func TestTraderLogs(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Setup(&buf, "warn", FormatText); err != nil {
+		t.Fatal(err)
+	}
+	defer log.SetOutput(os.Stderr)
+	defer DropTraderLogs("logs_t1")
+
+	live, unsubscribe := SubscribeTraderLogs("logs_t1", 4)
+	defer unsubscribe()
+
+	l := slog.With("trader_id", "logs_t1", "trader", "趋势")
+	l.Debug("调试信息")
+	l.Info("AI决策周期开始", "cycle", 1)
+	l.With(slog.Group("order", "symbol", "BTCUSDT")).Warn("下单失败", "error", os.ErrDeadlineExceeded)
+	slog.Info("其他交易员", "trader_id", "logs_t2")
+	defer DropTraderLogs("logs_t2")
+
+	if strings.Contains(buf.String(), "AI决策周期开始") {
+		t.Error("低于全局级别的日志不应输出，只进入缓冲")
+	}
+	entries := TraderLogs("logs_t1", 0, slog.LevelInfo)
+	if len(entries) != 2 {
+		t.Fatalf("应缓冲INFO及以上的2条日志: %+v", entries)
+	}
+	if entries[0].Message != "AI决策周期开始" || entries[0].Attrs["cycle"] != int64(1) || entries[0].Attrs["trader"] != "趋势" {
+		t.Errorf("日志内容错误: %+v", entries[0])
+	}
+	if _, ok := entries[0].Attrs["trader_id"]; ok {
+		t.Error("trader_id 不必重复写入字段")
+	}
+	if entries[1].Level != "WARN" || entries[1].Attrs["order.symbol"] != "BTCUSDT" || entries[1].Attrs["error"] != os.ErrDeadlineExceeded.Error() {
+		t.Errorf("分组字段和错误应展开: %+v", entries[1])
+	}
+	if warn := TraderLogs("logs_t1", 0, slog.LevelWarn); len(warn) != 1 {
+		t.Errorf("按级别过滤错误: %+v", warn)
+	}
+	if got := <-live; got.Message != "AI决策周期开始" {
+		t.Errorf("订阅者应收到新日志: %+v", got)
+	}
+
+	for i := 0; i < TraderLogCapacity+10; i++ {
+		l.Info("循环", "i", i)
+	}
+	entries = TraderLogs("logs_t1", 3, slog.LevelInfo)
+	if len(entries) != 3 || entries[2].Attrs["i"] != int64(TraderLogCapacity+9) || entries[0].Attrs["i"] != int64(TraderLogCapacity+7) {
+		t.Errorf("环形缓冲应保留最新的日志: %+v", entries)
+	}
+	if all := TraderLogs("logs_t1", 0, slog.LevelInfo); len(all) != TraderLogCapacity {
+		t.Errorf("缓冲容量应为 %d: %d", TraderLogCapacity, len(all))
+	}
+}
@@ -0,0 +1,238 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// TraderLogCapacity 每个交易员保留的最近日志条数
+const TraderLogCapacity = 500
+
+// traderIDKey 日志中标识交易员的字段，带该字段的日志会进入对应交易员的缓冲
+const traderIDKey = "trader_id"
+
+// Entry 一条交易员日志
+type Entry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// traderLog 单个交易员的环形缓冲
+type traderLog struct {
+	entries []Entry
+	next    int // 下一条写入的位置
+	full    bool
+}
+
+var (
+	traderLogMu          sync.RWMutex
+	traderLogs           = make(map[string]*traderLog)
+	traderLogSubscribers = make(map[int]traderLogSubscriber)
+	nextLogSubscriberID  int
+)
+
+// traderLogSubscriber 实时跟踪某个交易员日志的订阅者
+type traderLogSubscriber struct {
+	traderID string
+	ch       chan Entry
+}
+
+// appendTraderLog 写入交易员的环形缓冲并推送给订阅者
+func appendTraderLog(traderID string, entry Entry) {
+	traderLogMu.Lock()
+	defer traderLogMu.Unlock()
+
+	buf, ok := traderLogs[traderID]
+	if !ok {
+		buf = &traderLog{entries: make([]Entry, TraderLogCapacity)}
+		traderLogs[traderID] = buf
+	}
+	buf.entries[buf.next] = entry
+	buf.next = (buf.next + 1) % TraderLogCapacity
+	if buf.next == 0 {
+		buf.full = true
+	}
+
+	for _, sub := range traderLogSubscribers {
+		if sub.traderID != traderID {
+			continue
+		}
+		// 消费不及时时丢弃，不阻塞打日志的交易员
+		select {
+		case sub.ch <- entry:
+		default:
+		}
+	}
+}
+
+// TraderLogs 交易员最近的日志（按时间正序），limit<=0 返回全部，minLevel 以下的级别被过滤
+func TraderLogs(traderID string, limit int, minLevel slog.Level) []Entry {
+	traderLogMu.RLock()
+	defer traderLogMu.RUnlock()
+
+	entries := []Entry{}
+	buf, ok := traderLogs[traderID]
+	if !ok {
+		return entries
+	}
+	ordered := buf.entries[:buf.next]
+	if buf.full {
+		ordered = append(append([]Entry{}, buf.entries[buf.next:]...), buf.entries[:buf.next]...)
+	}
+	for _, e := range ordered {
+		if levelOf(e.Level) >= minLevel {
+			entries = append(entries, e)
+		}
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// SubscribeTraderLogs 实时跟踪交易员的新日志，返回日志通道和取消订阅函数
+func SubscribeTraderLogs(traderID string, buffer int) (<-chan Entry, func()) {
+	traderLogMu.Lock()
+	defer traderLogMu.Unlock()
+
+	id := nextLogSubscriberID
+	nextLogSubscriberID++
+	ch := make(chan Entry, buffer)
+	traderLogSubscribers[id] = traderLogSubscriber{traderID: traderID, ch: ch}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			traderLogMu.Lock()
+			defer traderLogMu.Unlock()
+			delete(traderLogSubscribers, id)
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// DropTraderLogs 删除交易员的日志缓冲（交易员被删除后调用）
+func DropTraderLogs(traderID string) {
+	traderLogMu.Lock()
+	defer traderLogMu.Unlock()
+	delete(traderLogs, traderID)
+}
+
+// levelOf 日志级别名称转为 slog.Level，无法识别时视为INFO
+func levelOf(name string) slog.Level {
+	var lv slog.Level
+	if err := lv.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+	return lv
+}
+
+// captureHandler 把带 trader_id 的日志同时写入该交易员的环形缓冲
+// INFO 及以上级别即使低于全局级别也会被缓冲，方便排查时不必调整全局级别
+type captureHandler struct {
+	next     slog.Handler
+	traderID string
+	attrs    map[string]interface{}
+	group    string
+}
+
+// newCaptureHandler 包装输出handler
+func newCaptureHandler(next slog.Handler) *captureHandler {
+	return &captureHandler{next: next}
+}
+
+func (h *captureHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	if h.traderID != "" && l >= slog.LevelInfo {
+		return true
+	}
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *captureHandler) Handle(ctx context.Context, r slog.Record) error {
+	traderID := h.traderID
+	var attrs map[string]interface{}
+	if len(h.attrs) > 0 || r.NumAttrs() > 0 {
+		attrs = make(map[string]interface{}, len(h.attrs)+r.NumAttrs())
+		for k, v := range h.attrs {
+			attrs[k] = v
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if h.group == "" && a.Key == traderIDKey {
+			traderID = a.Value.String()
+		}
+		addAttr(attrs, h.group, a)
+		return true
+	})
+
+	if traderID != "" && r.Level >= slog.LevelInfo {
+		delete(attrs, traderIDKey)
+		appendTraderLog(traderID, Entry{Time: r.Time, Level: r.Level.String(), Message: r.Message, Attrs: attrs})
+	}
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *captureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := &captureHandler{next: h.next.WithAttrs(attrs), traderID: h.traderID, group: h.group,
+		attrs: make(map[string]interface{}, len(h.attrs)+len(attrs))}
+	for k, v := range h.attrs {
+		clone.attrs[k] = v
+	}
+	for _, a := range attrs {
+		if h.group == "" && a.Key == traderIDKey {
+			clone.traderID = a.Value.String()
+		}
+		addAttr(clone.attrs, h.group, a)
+	}
+	return clone
+}
+
+func (h *captureHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	group := name
+	if h.group != "" {
+		group = h.group + "." + name
+	}
+	return &captureHandler{next: h.next.WithGroup(name), traderID: h.traderID, attrs: h.attrs, group: group}
+}
+
+// addAttr 展开分组字段写入map，键名以点号连接分组
+func addAttr(attrs map[string]interface{}, group string, a slog.Attr) {
+	if attrs == nil {
+		return
+	}
+	a.Value = a.Value.Resolve()
+	key := a.Key
+	if group != "" {
+		key = group + "." + key
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		// 空键名的分组直接展开到当前层级
+		if a.Key != "" {
+			group = key
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(attrs, group, ga)
+		}
+		return
+	}
+	switch v := a.Value.Any().(type) {
+	case error:
+		attrs[key] = v.Error()
+	case fmt.Stringer:
+		attrs[key] = v.String()
+	default:
+		attrs[key] = v
+	}
+}
@@ -1,20 +1,31 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"nofx/api"
 	"nofx/auth"
+	"nofx/backup"
+	"nofx/cache"
 	"nofx/config"
+	"nofx/digest"
+	"nofx/logger"
+	"nofx/logging"
 	"nofx/manager"
 	"nofx/market"
 	"nofx/pool"
+	"nofx/telegram"
+	"nofx/tracing"
+	"nofx/trader"
+	"nofx/webhook"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 )
 
 // LeverageConfig 杠杆配置
@@ -23,28 +34,110 @@ type LeverageConfig struct {
 	AltcoinLeverage int `json:"altcoin_leverage"`
 }
 
+// shutdownTimeout 收到退出信号后等待请求和交易周期结束的最长时间（一次AI调用可能需要几十秒）
+const shutdownTimeout = 90 * time.Second
+
 // ConfigFile 配置文件结构，只包含需要同步到数据库的字段
 type ConfigFile struct {
-	AdminMode          bool           `json:"admin_mode"`
-	BetaMode           bool           `json:"beta_mode"`
-	APIServerPort      int            `json:"api_server_port"`
-	UseDefaultCoins    bool           `json:"use_default_coins"`
-	DefaultCoins       []string       `json:"default_coins"`
-	CoinPoolAPIURL     string         `json:"coin_pool_api_url"`
-	OITopAPIURL        string         `json:"oi_top_api_url"`
-	MaxDailyLoss       float64        `json:"max_daily_loss"`
-	MaxDrawdown        float64        `json:"max_drawdown"`
-	StopTradingMinutes int            `json:"stop_trading_minutes"`
-	Leverage           LeverageConfig `json:"leverage"`
-	JWTSecret          string         `json:"jwt_secret"`
-	DataKLineTime      string         `json:"data_k_line_time"`
+	AdminMode            bool                    `json:"admin_mode"`
+	BetaMode             bool                    `json:"beta_mode"`
+	APIServerPort        int                     `json:"api_server_port"`
+	UseDefaultCoins      bool                    `json:"use_default_coins"`
+	DefaultCoins         []string                `json:"default_coins"`
+	CoinPoolAPIURL       string                  `json:"coin_pool_api_url"`
+	OITopAPIURL          string                  `json:"oi_top_api_url"`
+	OITopProvider        string                  `json:"oi_top_provider"` // OI Top来源: api / builtin
+	MaxDailyLoss         float64                 `json:"max_daily_loss"`
+	MaxDrawdown          float64                 `json:"max_drawdown"`
+	StopTradingMinutes   int                     `json:"stop_trading_minutes"`
+	Leverage             LeverageConfig          `json:"leverage"`
+	JWTSecret            string                  `json:"jwt_secret"`
+	DataKLineTime        string                  `json:"data_k_line_time"`
+	NewsFeedURL          string                  `json:"news_feed_url"`
+	PaperTradingCosts    *trader.SimulationCosts `json:"paper_trading_costs"`
+	OrphanPositionPolicy string                  `json:"orphan_position_policy"`      // 启动对账时孤儿持仓的处理: adopt / close
+	CircuitBreakerDrop   *float64                `json:"circuit_breaker_drop_pct"`    // 净值熔断回撤百分比（0 关闭）
+	CircuitBreakerMins   int                     `json:"circuit_breaker_minutes"`     // 净值熔断统计窗口（分钟）
+	MaxConsecutiveLosses *int                    `json:"max_consecutive_losses"`      // 连续亏损多少笔进入冷却（0 关闭）
+	LossCooldownMinutes  int                     `json:"loss_cooldown_minutes"`       // 连续亏损冷却时长（分钟）
+	BalanceDriftPct      *float64                `json:"balance_drift_threshold_pct"` // 余额偏差告警阈值（0 关闭）
+	FundingCostClosePct  *float64                `json:"funding_cost_close_pct"`      // 资金费超过浮盈此百分比时自动平仓（0 关闭）
+	MaxNetDeltaPct       *float64                `json:"max_net_delta_pct"`           // 净方向敞口占净值上限（0 不限制）
+	RateLimitIP          *int                    `json:"rate_limit_ip"`               // API限流：每分钟请求数（0 不限制）
+	RateLimitUser        *int                    `json:"rate_limit_user"`
+	RateLimitPublic      *int                    `json:"rate_limit_public"`
+	RateLimitAuth        *int                    `json:"rate_limit_auth"`
+	SMTP                 *auth.SMTPConfig        `json:"smtp"`                 // 密码重置等邮件的SMTP发信配置
+	PasswordResetURL     string                  `json:"password_reset_url"`   // 重置链接指向的前端地址
+	TelegramBotToken     string                  `json:"telegram_bot_token"`   // Telegram告警和命令机器人
+	CORSAllowedOrigins   []string                `json:"cors_allowed_origins"` // 允许跨域访问的前端来源
+	TrustedProxies       []string                `json:"trusted_proxies"`      // 可信反向代理的IP或CIDR
+	LogLevel             string                  `json:"log_level"`            // 日志级别: debug / info / warn / error
+	LogFormat            string                  `json:"log_format"`           // 日志格式: text / json
+	OTLPEndpoint         string                  `json:"otlp_endpoint"`        // 链路追踪OTLP/HTTP地址
+	TLSCertFile          string                  `json:"tls_cert_file"`        // HTTPS证书文件
+	TLSKeyFile           string                  `json:"tls_key_file"`         // HTTPS私钥文件
+	TLSAutocertDomains   []string                `json:"tls_autocert_domains"` // Let's Encrypt自动证书域名
+	HTTPRedirectPort     int                     `json:"http_redirect_port"`   // HTTP重定向到HTTPS的端口
+	Database             *config.DatabaseOptions `json:"database"`             // 数据库（默认SQLite，多副本部署可用PostgreSQL）
+	Backup               *backup.Config          `json:"backup"`               // 备份目录、定时备份和S3上传
+	DecisionLogs         *logger.StorageConfig   `json:"decision_logs"`        // 决策日志存储（file/database/s3）
+	RedisURL             string                  `json:"redis_url"`            // 多副本共享的缓存（redis://），为空时使用进程内缓存
+	ProxyURL             string                  `json:"proxy_url"`            // 交易所API的默认代理（http/https/socks5）
+}
+
+// openDatabase 打开配置数据库：NOFX_DB_DRIVER/NOFX_DB_DSN 或 config.json 配置了 database.driver 时使用该配置，否则使用 dbPath 处的SQLite文件
+func openDatabase(dbPath string) (*config.Database, error) {
+	opts, err := databaseOptions(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	return config.OpenDatabase(opts)
+}
+
+// configureDecisionLogStorage 按系统配置 decision_log_storage 设置决策日志存储，需在读写决策日志之前调用
+func configureDecisionLogStorage(database *config.Database) error {
+	value, _ := database.GetSystemConfig("decision_log_storage")
+	cfg, err := logger.ParseStorageConfig(value)
+	if err != nil {
+		return err
+	}
+	if err := logger.SetStorage(cfg, database); err != nil {
+		return err
+	}
+	if cfg.Type != logger.StorageFile {
+		slog.Info("决策日志存储", "type", cfg.Type)
+	}
+	return nil
+}
+
+// openCacheStore 按系统配置 redis_url 创建缓存，返回是否为多副本共享的Redis；Redis不可用时退回进程内缓存
+func openCacheStore(database *config.Database) (cache.Store, bool) {
+	redisURL, _ := database.GetSystemConfig("redis_url")
+	store, err := cache.Open(redisURL)
+	if err != nil {
+		slog.Warn("缓存配置无效，使用进程内缓存", "error", err)
+		return cache.NewMemory(), false
+	}
+	redis, ok := store.(*cache.Redis)
+	if !ok {
+		return store, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := redis.Ping(ctx); err != nil {
+		slog.Warn("连接Redis失败，使用进程内缓存", "error", err)
+		return cache.NewMemory(), false
+	}
+	slog.Info("已启用Redis缓存")
+	return store, true
 }
 
 // syncConfigToDatabase 从config.json读取配置并同步到数据库
 func syncConfigToDatabase(database *config.Database) error {
 	// 检查config.json是否存在
 	if _, err := os.Stat("config.json"); os.IsNotExist(err) {
-		log.Printf("📄 config.json不存在，跳过同步")
+		slog.Info("config.json不存在，跳过同步")
 		return nil
 	}
 
@@ -60,19 +153,20 @@ func syncConfigToDatabase(database *config.Database) error {
 		return fmt.Errorf("解析config.json失败: %w", err)
 	}
 
-	log.Printf("🔄 开始同步config.json到数据库...")
+	slog.Info("开始同步config.json到数据库")
 
 	// 同步各配置项到数据库
 	configs := map[string]string{
-		"admin_mode":            fmt.Sprintf("%t", configFile.AdminMode),
-		"beta_mode":             fmt.Sprintf("%t", configFile.BetaMode),
-		"api_server_port":       strconv.Itoa(configFile.APIServerPort),
-		"use_default_coins":     fmt.Sprintf("%t", configFile.UseDefaultCoins),
-		"coin_pool_api_url":     configFile.CoinPoolAPIURL,
-		"oi_top_api_url":        configFile.OITopAPIURL,
-		"max_daily_loss":        fmt.Sprintf("%.1f", configFile.MaxDailyLoss),
-		"max_drawdown":          fmt.Sprintf("%.1f", configFile.MaxDrawdown),
-		"stop_trading_minutes":  strconv.Itoa(configFile.StopTradingMinutes),
+		"admin_mode":           fmt.Sprintf("%t", configFile.AdminMode),
+		"beta_mode":            fmt.Sprintf("%t", configFile.BetaMode),
+		"api_server_port":      strconv.Itoa(configFile.APIServerPort),
+		"use_default_coins":    fmt.Sprintf("%t", configFile.UseDefaultCoins),
+		"coin_pool_api_url":    configFile.CoinPoolAPIURL,
+		"oi_top_api_url":       configFile.OITopAPIURL,
+		"max_daily_loss":       fmt.Sprintf("%.1f", configFile.MaxDailyLoss),
+		"max_drawdown":         fmt.Sprintf("%.1f", configFile.MaxDrawdown),
+		"stop_trading_minutes": strconv.Itoa(configFile.StopTradingMinutes),
+		"news_feed_url":        configFile.NewsFeedURL,
 	}
 
 	// 同步default_coins（转换为JSON字符串存储）
@@ -83,6 +177,116 @@ func syncConfigToDatabase(database *config.Database) error {
 		}
 	}
 
+	// 同步纸面交易费用设置（JSON字符串存储）
+	if configFile.PaperTradingCosts != nil {
+		costsJSON, err := json.Marshal(configFile.PaperTradingCosts)
+		if err == nil {
+			configs["paper_trading_costs"] = string(costsJSON)
+		}
+	}
+
+	if configFile.OITopProvider != "" {
+		configs["oi_top_provider"] = configFile.OITopProvider
+	}
+	if configFile.OrphanPositionPolicy != "" {
+		configs["orphan_position_policy"] = configFile.OrphanPositionPolicy
+	}
+
+	if configFile.CircuitBreakerDrop != nil {
+		configs["circuit_breaker_drop_pct"] = fmt.Sprintf("%.2f", *configFile.CircuitBreakerDrop)
+	}
+	if configFile.CircuitBreakerMins > 0 {
+		configs["circuit_breaker_minutes"] = strconv.Itoa(configFile.CircuitBreakerMins)
+	}
+
+	if configFile.MaxConsecutiveLosses != nil {
+		configs["max_consecutive_losses"] = strconv.Itoa(*configFile.MaxConsecutiveLosses)
+	}
+	if configFile.LossCooldownMinutes > 0 {
+		configs["loss_cooldown_minutes"] = strconv.Itoa(configFile.LossCooldownMinutes)
+	}
+
+	if configFile.BalanceDriftPct != nil {
+		configs["balance_drift_threshold_pct"] = fmt.Sprintf("%.2f", *configFile.BalanceDriftPct)
+	}
+	if configFile.FundingCostClosePct != nil {
+		configs["funding_cost_close_pct"] = fmt.Sprintf("%.2f", *configFile.FundingCostClosePct)
+	}
+	if configFile.MaxNetDeltaPct != nil {
+		configs["max_net_delta_pct"] = fmt.Sprintf("%.2f", *configFile.MaxNetDeltaPct)
+	}
+
+	// 同步API限流配置
+	for key, value := range map[string]*int{
+		"rate_limit_ip":     configFile.RateLimitIP,
+		"rate_limit_user":   configFile.RateLimitUser,
+		"rate_limit_public": configFile.RateLimitPublic,
+		"rate_limit_auth":   configFile.RateLimitAuth,
+	} {
+		if value != nil {
+			configs[key] = strconv.Itoa(*value)
+		}
+	}
+
+	// 同步SMTP配置（JSON字符串存储）
+	if configFile.SMTP != nil {
+		smtpJSON, err := json.Marshal(configFile.SMTP)
+		if err == nil {
+			configs["smtp_config"] = string(smtpJSON)
+		}
+	}
+	if configFile.Backup != nil {
+		backupJSON, err := json.Marshal(configFile.Backup)
+		if err == nil {
+			configs["backup_config"] = string(backupJSON)
+		}
+	}
+	if configFile.DecisionLogs != nil {
+		storageJSON, err := json.Marshal(configFile.DecisionLogs)
+		if err == nil {
+			configs["decision_log_storage"] = string(storageJSON)
+		}
+	}
+	if configFile.RedisURL != "" {
+		configs["redis_url"] = configFile.RedisURL
+	}
+	if configFile.ProxyURL != "" {
+		configs["proxy_url"] = configFile.ProxyURL
+	}
+	if configFile.PasswordResetURL != "" {
+		configs["password_reset_url"] = configFile.PasswordResetURL
+	}
+	if configFile.TelegramBotToken != "" {
+		configs["telegram_bot_token"] = configFile.TelegramBotToken
+	}
+	if configFile.LogLevel != "" {
+		configs["log_level"] = configFile.LogLevel
+	}
+	if configFile.LogFormat != "" {
+		configs["log_format"] = configFile.LogFormat
+	}
+	if configFile.OTLPEndpoint != "" {
+		configs["otlp_endpoint"] = configFile.OTLPEndpoint
+	}
+	if len(configFile.CORSAllowedOrigins) > 0 {
+		configs["cors_allowed_origins"] = strings.Join(configFile.CORSAllowedOrigins, ",")
+	}
+	if len(configFile.TrustedProxies) > 0 {
+		configs["trusted_proxies"] = strings.Join(configFile.TrustedProxies, ",")
+	}
+	if configFile.TLSCertFile != "" {
+		configs["tls_cert_file"] = configFile.TLSCertFile
+	}
+	if configFile.TLSKeyFile != "" {
+		configs["tls_key_file"] = configFile.TLSKeyFile
+	}
+	if len(configFile.TLSAutocertDomains) > 0 {
+		configs["tls_autocert_domains"] = strings.Join(configFile.TLSAutocertDomains, ",")
+	}
+	if configFile.HTTPRedirectPort > 0 {
+		configs["http_redirect_port"] = strconv.Itoa(configFile.HTTPRedirectPort)
+	}
+
 	// 同步杠杆配置
 	if configFile.Leverage.BTCETHLeverage > 0 {
 		configs["btc_eth_leverage"] = strconv.Itoa(configFile.Leverage.BTCETHLeverage)
@@ -99,23 +303,41 @@ func syncConfigToDatabase(database *config.Database) error {
 	// 更新数据库配置
 	for key, value := range configs {
 		if err := database.SetSystemConfig(key, value); err != nil {
-			log.Printf("⚠️  更新配置 %s 失败: %v", key, err)
+			slog.Warn("更新配置失败", "key", key, "error", err)
+		} else if key == "smtp_config" || key == "jwt_secret" || key == "telegram_bot_token" || key == "backup_config" || key == "decision_log_storage" || key == "redis_url" || key == "proxy_url" {
+			slog.Info("同步配置", "key", key, "value", "******") // 含密码的配置不输出明文
 		} else {
-			log.Printf("✓ 同步配置: %s = %s", key, value)
+			slog.Info("同步配置", "key", key, "value", value)
 		}
 	}
 
-	log.Printf("✅ config.json同步完成")
+	slog.Info("config.json同步完成")
 	return nil
 }
 
+// setupLogging 按 NOFX_LOG_LEVEL / NOFX_LOG_FORMAT 或系统配置初始化结构化日志
+func setupLogging(database *config.Database) {
+	level := os.Getenv("NOFX_LOG_LEVEL")
+	if level == "" {
+		level, _ = database.GetSystemConfig("log_level")
+	}
+	format := os.Getenv("NOFX_LOG_FORMAT")
+	if format == "" {
+		format, _ = database.GetSystemConfig("log_format")
+	}
+	if err := logging.Setup(os.Stderr, level, format); err != nil {
+		slog.Warn("日志配置无效，使用默认的文本格式和info级别", "error", err)
+		logging.Setup(os.Stderr, "info", logging.FormatText)
+	}
+}
+
 // loadBetaCodesToDatabase 加载内测码文件到数据库
 func loadBetaCodesToDatabase(database *config.Database) error {
 	betaCodeFile := "beta_codes.txt"
-	
+
 	// 检查内测码文件是否存在
 	if _, err := os.Stat(betaCodeFile); os.IsNotExist(err) {
-		log.Printf("📄 内测码文件 %s 不存在，跳过加载", betaCodeFile)
+		slog.Info("内测码文件不存在，跳过加载", "file", betaCodeFile)
 		return nil
 	}
 
@@ -125,8 +347,8 @@ func loadBetaCodesToDatabase(database *config.Database) error {
 		return fmt.Errorf("获取内测码文件信息失败: %w", err)
 	}
 
-	log.Printf("🔄 发现内测码文件 %s (%.1f KB)，开始加载...", betaCodeFile, float64(fileInfo.Size())/1024)
-	
+	slog.Info("发现内测码文件，开始加载", "file", betaCodeFile, "size_kb", float64(fileInfo.Size())/1024)
+
 	// 加载内测码到数据库
 	err = database.LoadBetaCodesFromFile(betaCodeFile)
 	if err != nil {
@@ -136,72 +358,137 @@ func loadBetaCodesToDatabase(database *config.Database) error {
 	// 显示统计信息
 	total, used, err := database.GetBetaCodeStats()
 	if err != nil {
-		log.Printf("⚠️  获取内测码统计失败: %v", err)
+		slog.Warn("获取内测码统计失败", "error", err)
 	} else {
-		log.Printf("✅ 内测码加载完成: 总计 %d 个，已使用 %d 个，剩余 %d 个", total, used, total-used)
+		slog.Info("内测码加载完成", "total", total, "used", used, "remaining", total-used)
 	}
 
 	return nil
 }
 
+// fatal 记录错误并退出
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func main() {
+	// 子命令：命令行回测
+	if len(os.Args) > 1 && os.Args[1] == "backtest" {
+		if err := runBacktestCommand(os.Args[2:]); err != nil {
+			fatal("回测失败", "error", err)
+		}
+		return
+	}
+	// 子命令：查看或回退数据库表结构版本
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			fatal("数据库迁移失败", "error", err)
+		}
+		return
+	}
+	// 子命令：修改用户角色
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if err := runBackupCommand(os.Args[2:]); err != nil {
+			fatal("备份失败", "error", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := runRestoreCommand(os.Args[2:]); err != nil {
+			fatal("恢复备份失败", "error", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "rotate-key" {
+		if err := runRotateKeyCommand(os.Args[2:]); err != nil {
+			fatal("轮换加密密钥失败", "error", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		if err := runRoleCommand(os.Args[2:]); err != nil {
+			fatal("修改用户角色失败", "error", err)
+		}
+		return
+	}
+
 	fmt.Println("╔════════════════════════════════════════════════════════════╗")
 	fmt.Println("║    🤖 AI多模型交易系统 - 支持 DeepSeek & Qwen            ║")
 	fmt.Println("╚════════════════════════════════════════════════════════════╝")
 	fmt.Println()
 
 	// 初始化数据库配置
-	dbPath := "config.db"
+	dbPath := defaultDBPath()
 	if len(os.Args) > 1 {
 		dbPath = os.Args[1]
 	}
 
-	log.Printf("📋 初始化配置数据库: %s", dbPath)
-	database, err := config.NewDatabase(dbPath)
+	slog.Info("初始化配置数据库", "path", dbPath)
+	database, err := openDatabase(dbPath)
 	if err != nil {
-		log.Fatalf("❌ 初始化数据库失败: %v", err)
+		fatal("初始化数据库失败", "error", err)
 	}
 	defer database.Close()
 
 	// 同步config.json到数据库
 	if err := syncConfigToDatabase(database); err != nil {
-		log.Printf("⚠️  同步config.json到数据库失败: %v", err)
+		slog.Warn("同步config.json到数据库失败", "error", err)
+	}
+
+	// 切换为结构化日志（环境变量优先于系统配置）
+	setupLogging(database)
+
+	// 链路追踪（未配置OTLP地址时不启用）
+	otlpEndpoint, _ := database.GetSystemConfig("otlp_endpoint")
+	shutdownTracing, err := tracing.Setup(context.Background(), otlpEndpoint)
+	if err != nil {
+		slog.Warn("初始化链路追踪失败", "error", err)
 	}
 
 	// 加载内测码到数据库
 	if err := loadBetaCodesToDatabase(database); err != nil {
-		log.Printf("⚠️  加载内测码到数据库失败: %v", err)
+		slog.Warn("加载内测码到数据库失败", "error", err)
+	}
+
+	// 基础设施配置（端口、JWT密钥、管理员模式、SMTP、代理），无效时直接退出
+	runtimeConfig, err := LoadRuntimeConfig(database)
+	if err != nil {
+		fatal("启动配置无效，请检查环境变量或config.json", "error", err)
 	}
 
 	// 获取系统配置
 	useDefaultCoinsStr, _ := database.GetSystemConfig("use_default_coins")
 	useDefaultCoins := useDefaultCoinsStr == "true"
-	apiPortStr, _ := database.GetSystemConfig("api_server_port")
 
-	// 获取管理员模式配置
-	adminModeStr, _ := database.GetSystemConfig("admin_mode")
-	adminMode := adminModeStr != "false" // 默认为true
+	auth.SetJWTSecret(runtimeConfig.JWTSecret)
+
+	// 配置SMTP发信（未配置时邮件内容只写入日志）
+	if runtimeConfig.SMTP != nil {
+		auth.SetMailer(auth.NewSMTPMailer(*runtimeConfig.SMTP))
+		slog.Info("已配置SMTP发信", "host", runtimeConfig.SMTP.Host)
+	}
 
-	// 设置JWT密钥
-	jwtSecret, _ := database.GetSystemConfig("jwt_secret")
-	if jwtSecret == "" {
-		jwtSecret = "your-jwt-secret-key-change-in-production-make-it-long-and-random"
-		log.Printf("⚠️  使用默认JWT密钥，建议在生产环境中配置")
+	if runtimeConfig.ProxyURL != "" {
+		trader.SetDefaultProxyURL(runtimeConfig.ProxyURL)
+		slog.Info("已配置交易所API默认代理")
 	}
-	auth.SetJWTSecret(jwtSecret)
 
 	// 在管理员模式下，确保admin用户存在
-	if adminMode {
+	if runtimeConfig.AdminMode {
 		err := database.EnsureAdminUser()
 		if err != nil {
-			log.Printf("⚠️  创建admin用户失败: %v", err)
+			slog.Warn("创建admin用户失败", "error", err)
 		} else {
-			log.Printf("✓ 管理员模式已启用，无需登录")
+			slog.Info("管理员模式已启用，无需登录")
 		}
 		auth.SetAdminMode(true)
 	}
 
-	log.Printf("✓ 配置数据库初始化成功")
+	slog.Info("配置数据库初始化成功")
 	fmt.Println()
 
 	// 从数据库读取默认主流币种列表
@@ -211,50 +498,187 @@ func main() {
 	if defaultCoinsJSON != "" {
 		// 尝试从JSON解析
 		if err := json.Unmarshal([]byte(defaultCoinsJSON), &defaultCoins); err != nil {
-			log.Printf("⚠️  解析default_coins配置失败: %v，使用硬编码默认值", err)
+			slog.Warn("解析default_coins配置失败，使用硬编码默认值", "error", err)
 			defaultCoins = []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "BNBUSDT", "XRPUSDT", "DOGEUSDT", "ADAUSDT", "HYPEUSDT"}
 		} else {
-			log.Printf("✓ 从数据库加载默认币种列表（共%d个）: %v", len(defaultCoins), defaultCoins)
+			slog.Info("从数据库加载默认币种列表", "count", len(defaultCoins), "coins", defaultCoins)
 		}
 	} else {
 		// 如果数据库中没有配置，使用硬编码默认值
 		defaultCoins = []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "BNBUSDT", "XRPUSDT", "DOGEUSDT", "ADAUSDT", "HYPEUSDT"}
-		log.Printf("⚠️  数据库中未配置default_coins，使用硬编码默认值")
+		slog.Warn("数据库中未配置default_coins，使用硬编码默认值")
 	}
 
 	pool.SetDefaultCoins(defaultCoins)
 	// 设置是否使用默认主流币种
 	pool.SetUseDefaultCoins(useDefaultCoins)
 	if useDefaultCoins {
-		log.Printf("✓ 已启用默认主流币种列表")
+		slog.Info("已启用默认主流币种列表")
 	}
 
 	// 设置币种池API URL
 	coinPoolAPIURL, _ := database.GetSystemConfig("coin_pool_api_url")
 	if coinPoolAPIURL != "" {
 		pool.SetCoinPoolAPI(coinPoolAPIURL)
-		log.Printf("✓ 已配置AI500币种池API")
+		slog.Info("已配置AI500币种池API")
 	}
 
 	oiTopAPIURL, _ := database.GetSystemConfig("oi_top_api_url")
 	if oiTopAPIURL != "" {
 		pool.SetOITopAPI(oiTopAPIURL)
-		log.Printf("✓ 已配置OI Top API")
+		slog.Info("已配置OI Top API")
+	}
+	oiTopProvider, _ := database.GetSystemConfig("oi_top_provider")
+	if err := pool.SetOITopProvider(oiTopProvider); err != nil {
+		slog.Warn("OI Top来源无效，使用外部API", "error", err)
+	} else if oiTopProvider == pool.OITopProviderBuiltin {
+		slog.Info("使用内置OI Top：根据币安公开接口计算1小时持仓量变化")
+	}
+	// 用户注册的信号源地址由用户填写，只允许访问公网地址
+	pool.SetSourceHTTPClient(webhook.NewSafeClient(15 * time.Second))
+
+	if maxAgeStr, _ := database.GetSystemConfig("candidate_max_age_minutes"); maxAgeStr != "" {
+		if minutes, err := strconv.Atoi(maxAgeStr); err != nil || minutes < 0 {
+			slog.Warn("candidate_max_age_minutes 无效，使用默认值", "value", maxAgeStr, "default", pool.MaxDataAge())
+		} else {
+			pool.SetMaxDataAge(time.Duration(minutes) * time.Minute)
+		}
+	}
+
+	newsFeedURL, _ := database.GetSystemConfig("news_feed_url")
+	if newsFeedURL != "" {
+		market.SetNewsFeedURL(newsFeedURL)
+		slog.Info("已配置新闻标题源")
+	}
+
+	paperCostsStr, _ := database.GetSystemConfig("paper_trading_costs")
+	if paperCostsStr != "" {
+		costs := trader.DefaultSimulationCosts()
+		if err := json.Unmarshal([]byte(paperCostsStr), &costs); err != nil {
+			slog.Warn("解析纸面交易费用配置失败，使用默认值", "error", err)
+		} else if err := trader.SetPaperTradingCosts(costs); err != nil {
+			slog.Warn("纸面交易费用配置无效，使用默认值", "error", err)
+		} else {
+			slog.Info("纸面交易费用", "maker_fee_pct", costs.MakerFeeRate*100, "taker_fee_pct", costs.TakerFeeRate*100,
+				"slippage_model", costs.SlippageModel, "slippage_bps", costs.SlippageBps)
+		}
+	}
+
+	orphanPolicy, _ := database.GetSystemConfig("orphan_position_policy")
+	if orphanPolicy != "" {
+		if err := trader.SetOrphanPositionPolicy(orphanPolicy); err != nil {
+			slog.Warn("孤儿持仓策略无效，使用默认策略", "error", err, "policy", trader.OrphanPolicyAdopt)
+		} else {
+			slog.Info("启动对账孤儿持仓策略", "policy", orphanPolicy)
+		}
+	}
+
+	breakerDropStr, _ := database.GetSystemConfig("circuit_breaker_drop_pct")
+	breakerMinutesStr, _ := database.GetSystemConfig("circuit_breaker_minutes")
+	if breakerDrop, err := strconv.ParseFloat(breakerDropStr, 64); err == nil {
+		breakerMinutes, err := strconv.Atoi(breakerMinutesStr)
+		if err != nil {
+			breakerMinutes = 30
+		}
+		if err := trader.SetEquityCircuitBreaker(breakerDrop, time.Duration(breakerMinutes)*time.Minute); err != nil {
+			slog.Warn("净值熔断配置无效，使用默认值", "error", err)
+		} else if breakerDrop > 0 {
+			slog.Info("净值熔断：窗口内回撤超过阈值暂停开仓", "window_minutes", breakerMinutes, "drop_pct", breakerDrop)
+		} else {
+			slog.Info("净值熔断已关闭")
+		}
+	}
+
+	maxLossesStr, _ := database.GetSystemConfig("max_consecutive_losses")
+	lossCooldownStr, _ := database.GetSystemConfig("loss_cooldown_minutes")
+	if maxLosses, err := strconv.Atoi(maxLossesStr); err == nil {
+		cooldownMinutes, err := strconv.Atoi(lossCooldownStr)
+		if err != nil {
+			cooldownMinutes = 60
+		}
+		if err := trader.SetLossCooldown(maxLosses, time.Duration(cooldownMinutes)*time.Minute); err != nil {
+			slog.Warn("连续亏损冷却配置无效，使用默认值", "error", err)
+		} else if maxLosses > 0 {
+			slog.Info("连续亏损冷却：连亏后只分析不开仓", "max_losses", maxLosses, "cooldown_minutes", cooldownMinutes)
+		} else {
+			slog.Info("连续亏损冷却已关闭")
+		}
+	}
+
+	driftStr, _ := database.GetSystemConfig("balance_drift_threshold_pct")
+	if driftPct, err := strconv.ParseFloat(driftStr, 64); err == nil {
+		if err := trader.SetBalanceDriftThreshold(driftPct); err != nil {
+			slog.Warn("余额偏差告警阈值无效，使用默认值", "error", err)
+		} else if driftPct > 0 {
+			slog.Info("余额偏差告警阈值", "threshold_pct", driftPct)
+		}
+	}
+
+	fundingStr, _ := database.GetSystemConfig("funding_cost_close_pct")
+	if fundingPct, err := strconv.ParseFloat(fundingStr, 64); err == nil {
+		if err := trader.SetFundingCostLimit(fundingPct); err != nil {
+			slog.Warn("资金费预算无效，资金费自动平仓保持关闭", "error", err)
+		} else if fundingPct > 0 {
+			slog.Info("资金费预算：累计资金费超过浮盈比例时自动平仓", "limit_pct", fundingPct)
+		}
+	}
+
+	deltaStr, _ := database.GetSystemConfig("max_net_delta_pct")
+	if deltaPct, err := strconv.ParseFloat(deltaStr, 64); err == nil {
+		if err := trader.SetMaxNetDelta(deltaPct); err != nil {
+			slog.Warn("净方向敞口上限无效，不限制净敞口", "error", err)
+		} else if deltaPct > 0 {
+			slog.Info("净方向敞口上限（占净值）", "max_net_delta_pct", deltaPct)
+		}
 	}
 
+	retryAttemptsStr, _ := database.GetSystemConfig("order_retry_attempts")
+	retryBackoffStr, _ := database.GetSystemConfig("order_retry_backoff_ms")
+	if attempts, err := strconv.Atoi(retryAttemptsStr); err == nil {
+		backoffMs, err := strconv.Atoi(retryBackoffStr)
+		if err != nil {
+			backoffMs = 1000
+		}
+		if err := trader.SetOrderRetryPolicy(trader.RetryPolicy{MaxAttempts: attempts, Backoff: time.Duration(backoffMs) * time.Millisecond}); err != nil {
+			slog.Warn("下单重试配置无效，使用默认值", "error", err)
+		}
+	}
+
+	// 决策日志存储和检索索引（加载交易员之前设置）
+	if err := configureDecisionLogStorage(database); err != nil {
+		fatal("决策日志存储配置无效", "error", err)
+	}
+	logger.SetSearchIndex(database)
+
 	// 创建TraderManager
 	traderManager := manager.NewTraderManager()
+	if v, _ := database.GetSystemConfig("ai_max_concurrent_calls"); v != "" {
+		if limit, err := strconv.Atoi(v); err == nil {
+			traderManager.SetAICallLimit(limit)
+		}
+	}
+
+	// 配置 redis_url 时多个API副本共用竞赛快照
+	cacheStore, sharedCache := openCacheStore(database)
+	if sharedCache {
+		traderManager.SetCompetitionStore(cacheStore)
+	}
 
 	// 从数据库加载所有交易员到内存
 	err = traderManager.LoadTradersFromDatabase(database)
 	if err != nil {
-		log.Fatalf("❌ 加载交易员失败: %v", err)
+		fatal("加载交易员失败", "error", err)
+	}
+
+	// 启动跟单（跟随的交易员需已加载）
+	if err := traderManager.LoadFollows(database); err != nil {
+		slog.Warn("启动跟单失败", "error", err)
 	}
 
 	// 获取数据库中的所有交易员配置（用于显示，使用default用户）
 	traders, err := database.GetTraders("default")
 	if err != nil {
-		log.Fatalf("❌ 获取交易员列表失败: %v", err)
+		fatal("获取交易员列表失败", "error", err)
 	}
 
 	// 显示加载的交易员信息
@@ -287,22 +711,51 @@ func main() {
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println()
 
-	// 获取API服务器端口
-	apiPort := 8080 // 默认端口
-	if apiPortStr != "" {
-		if port, err := strconv.Atoi(apiPortStr); err == nil {
-			apiPort = port
-		}
-	}
-
 	// 创建并启动API服务器
-	apiServer := api.NewServer(traderManager, database, apiPort)
+	apiServer := api.NewServer(traderManager, database, runtimeConfig.APIPort)
+	apiServer.SetResponseCache(cacheStore)
 	go func() {
 		if err := apiServer.Start(); err != nil {
-			log.Printf("❌ API服务器错误: %v", err)
+			slog.Error("API服务器错误", "error", err)
 		}
 	}()
 
+	// 推送交易事件到用户注册的webhook
+	go webhook.NewDispatcher(database).Start()
+
+	// 定时刷新竞赛快照，公开接口只读快照
+	go traderManager.StartCompetitionRefresher(15 * time.Second)
+
+	// 归档已结束的竞赛赛季
+	go traderManager.StartSeasonArchiver(database, 10*time.Minute)
+
+	// 发现卡住或连续出错的交易员
+	go traderManager.StartHeartbeatMonitor(database, time.Minute)
+
+	// 把长时间未访问的已停止交易员移出内存
+	go traderManager.StartIdleTraderEviction(database, 5*time.Minute)
+
+	// 按固定间隔记录净值，不依赖决策周期
+	go traderManager.StartEquitySampler(database)
+
+	// 压缩和清理旧的决策日志
+	go traderManager.StartDecisionLogCompaction(database, 6*time.Hour)
+
+	// 每日邮件摘要
+	go digest.NewScheduler(database, traderManager).Start()
+
+	// 定时备份（backup_config.interval_hours > 0 时）
+	go backup.NewScheduler(database, "decision_logs").Start()
+
+	// Telegram告警和命令机器人（配置了token时启用）
+	if token, _ := database.GetSystemConfig("telegram_bot_token"); token != "" {
+		if bot, err := telegram.NewBot(token, database, traderManager); err != nil {
+			slog.Warn("Telegram机器人启动失败", "error", err)
+		} else {
+			go bot.Start()
+		}
+	}
+
 	// 启动流行情数据 - 默认使用所有交易员设置的币种 如果没有设置币种 则优先使用系统默认
 	go market.NewWSMonitor(150).Start(database.GetCustomCoins())
 	//go market.NewWSMonitor(150).Start([]string{}) //这里是一个使用方式 传入空的话 则使用market市场的所有币种
@@ -310,15 +763,55 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	// TODO: 启动数据库中配置为运行状态的交易员
-	// traderManager.StartAll()
+	// 恢复重启前处于运行状态的交易员
+	if resume, _ := database.GetSystemConfig("resume_traders_on_boot"); resume != "false" {
+		stagger := 5 * time.Second
+		if v, _ := database.GetSystemConfig("trader_resume_stagger_seconds"); v != "" {
+			if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+				stagger = time.Duration(seconds) * time.Second
+			}
+		}
+		go func() {
+			if err := traderManager.ResumeRunningTraders(database, stagger); err != nil {
+				slog.Error("恢复交易员失败", "error", err)
+			}
+		}()
+	} else if n, err := database.ClearRunningTraders(); err != nil {
+		slog.Warn("重置交易员运行状态失败", "error", err)
+	} else if n > 0 {
+		// 不清除的话这些交易员会一直占用运行数配额
+		slog.Info("resume_traders_on_boot 已关闭，重启前运行中的交易员已标记为停止", "count", n)
+	}
 
 	// 等待退出信号
 	<-sigChan
 	fmt.Println()
 	fmt.Println()
-	log.Println("📛 收到退出信号，正在停止所有trader...")
-	traderManager.StopAll()
+	slog.Info("收到退出信号，正在优雅退出（再次发送信号立即退出）", "timeout", shutdownTimeout)
+	go func() {
+		<-sigChan
+		slog.Warn("再次收到退出信号，立即退出")
+		os.Exit(1)
+	}()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// 先停止接受新请求，避免退出过程中再启动交易员
+	if err := apiServer.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("关闭API服务器失败", "error", err)
+	}
+	// 等待交易员执行完当前周期（决策日志在周期内写入）
+	if err := traderManager.Shutdown(shutdownCtx, database); err != nil {
+		slog.Warn("停止交易员未完成", "error", err)
+	}
+
+	// 导出尚未发送的span
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelTracing()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		slog.Warn("导出链路追踪数据失败", "error", err)
+	}
 
 	fmt.Println()
 	fmt.Println("👋 感谢使用AI交易系统！")
@@ -0,0 +1,224 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"nofx/config"
+	"nofx/mcp"
+	"nofx/trader"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultAICallLimit 每个AI密钥默认的并发调用上限（系统配置 ai_max_concurrent_calls）
+const defaultAICallLimit = 4
+
+// AIScheduler 集中调度AI调用：同一提供商同一API密钥的并发调用数受限，
+// 排队时在用户之间轮转，避免一个用户的大量交易员占满额度
+type AIScheduler struct {
+	mu     sync.Mutex
+	limit  int // 每个密钥同时进行的调用数上限（<=0 不限制）
+	queues map[string]*aiCallQueue
+}
+
+// aiCallQueue 单个密钥的调用队列
+type aiCallQueue struct {
+	active  int
+	users   []string                   // 有请求在等待的用户，按轮转顺序
+	waiting map[string][]chan struct{} // 用户 -> 等待中的请求（先进先出）
+}
+
+// AISchedulerStats 单个密钥的调度状态
+type AISchedulerStats struct {
+	Key     string `json:"key"` // 提供商/密钥摘要
+	Active  int    `json:"active"`
+	Waiting int    `json:"waiting"`
+}
+
+// NewAIScheduler 创建AI调用调度器
+func NewAIScheduler(limit int) *AIScheduler {
+	return &AIScheduler{limit: limit, queues: make(map[string]*aiCallQueue)}
+}
+
+// SetLimit 修改并发上限，放宽时立即放行排队的请求
+func (s *AIScheduler) SetLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	for _, q := range s.queues {
+		s.dispatchLocked(q)
+	}
+}
+
+// Acquire 等待调用额度，返回释放函数；ctx 取消时放弃排队
+func (s *AIScheduler) Acquire(ctx context.Context, key, userID string) (func(), error) {
+	s.mu.Lock()
+	q, ok := s.queues[key]
+	if !ok {
+		q = &aiCallQueue{waiting: make(map[string][]chan struct{})}
+		s.queues[key] = q
+	}
+	if s.limit <= 0 || (q.active < s.limit && len(q.users) == 0) {
+		q.active++
+		s.mu.Unlock()
+		return s.releaseFunc(q), nil
+	}
+
+	granted := make(chan struct{})
+	if len(q.waiting[userID]) == 0 {
+		q.users = append(q.users, userID)
+	}
+	q.waiting[userID] = append(q.waiting[userID], granted)
+	s.mu.Unlock()
+
+	select {
+	case <-granted:
+		return s.releaseFunc(q), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-granted:
+			// 取消的同时已被放行，归还额度
+			q.active--
+			s.dispatchLocked(q)
+		default:
+			s.removeWaiterLocked(q, userID, granted)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// releaseFunc 调用结束后归还额度（多次调用只归还一次）
+func (s *AIScheduler) releaseFunc(q *aiCallQueue) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			q.active--
+			s.dispatchLocked(q)
+		})
+	}
+}
+
+// dispatchLocked 有空闲额度时按用户轮转放行排队的请求，调用方需已持有 s.mu
+func (s *AIScheduler) dispatchLocked(q *aiCallQueue) {
+	for len(q.users) > 0 && (s.limit <= 0 || q.active < s.limit) {
+		userID := q.users[0]
+		q.users = q.users[1:]
+		waiters := q.waiting[userID]
+		next := waiters[0]
+		if len(waiters) > 1 {
+			q.waiting[userID] = waiters[1:]
+			q.users = append(q.users, userID) // 还有请求的用户排到队尾
+		} else {
+			delete(q.waiting, userID)
+		}
+		q.active++
+		close(next)
+	}
+}
+
+// removeWaiterLocked 从队列中移除放弃等待的请求，调用方需已持有 s.mu
+func (s *AIScheduler) removeWaiterLocked(q *aiCallQueue, userID string, ch chan struct{}) {
+	waiters := q.waiting[userID]
+	for i, w := range waiters {
+		if w == ch {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) > 0 {
+		q.waiting[userID] = waiters
+		return
+	}
+	delete(q.waiting, userID)
+	for i, u := range q.users {
+		if u == userID {
+			q.users = append(q.users[:i], q.users[i+1:]...)
+			break
+		}
+	}
+}
+
+// Stats 各密钥当前进行中和排队的调用数
+func (s *AIScheduler) Stats() []AISchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]AISchedulerStats, 0, len(s.queues))
+	for key, q := range s.queues {
+		waiting := 0
+		for _, w := range q.waiting {
+			waiting += len(w)
+		}
+		stats = append(stats, AISchedulerStats{Key: key, Active: q.active, Waiting: waiting})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats
+}
+
+// aiCallKey 调度键：提供商加API密钥摘要，使用同一个密钥的交易员共享额度
+func aiCallKey(aiModelCfg *config.AIModelConfig) string {
+	sum := sha256.Sum256([]byte(aiModelCfg.APIKey + "|" + aiModelCfg.CustomAPIURL))
+	return aiModelCfg.Provider + "/" + hex.EncodeToString(sum[:4])
+}
+
+// scheduledClient 经调度器排队后再调用的AI客户端
+type scheduledClient struct {
+	inner     mcp.AIClient
+	scheduler *AIScheduler
+	key       string
+	userID    string
+	log       func(wait time.Duration)
+}
+
+func (c *scheduledClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	return c.CallWithMessagesContext(context.Background(), systemPrompt, userPrompt)
+}
+
+func (c *scheduledClient) CallWithMessagesContext(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	start := time.Now()
+	release, err := c.scheduler.Acquire(ctx, c.key, c.userID)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if wait := time.Since(start); wait >= time.Second && c.log != nil {
+		c.log(wait)
+	}
+	return mcp.CallWithContext(ctx, c.inner, systemPrompt, userPrompt)
+}
+
+// TakeUsage 转发底层客户端的token用量
+func (c *scheduledClient) TakeUsage() mcp.Usage {
+	if reporter, ok := c.inner.(mcp.UsageReporter); ok {
+		return reporter.TakeUsage()
+	}
+	return mcp.Usage{}
+}
+
+// scheduleAICalls 让交易员的AI调用经过全局调度器
+func (tm *TraderManager) scheduleAICalls(at *trader.AutoTrader, aiModelCfg *config.AIModelConfig) {
+	at.SetAIClient(&scheduledClient{
+		inner:     at.GetAIClient(),
+		scheduler: tm.aiScheduler,
+		key:       aiCallKey(aiModelCfg),
+		userID:    at.GetUserID(),
+		log: func(wait time.Duration) {
+			at.Logger().Info("AI调用排队等待", "wait", wait.Round(time.Millisecond), "provider", aiModelCfg.Provider)
+		},
+	})
+}
+
+// SetAICallLimit 修改每个AI密钥的并发调用上限（<=0 不限制）
+func (tm *TraderManager) SetAICallLimit(limit int) {
+	tm.aiScheduler.SetLimit(limit)
+}
+
+// GetAISchedulerStats AI调用调度状态
+func (tm *TraderManager) GetAISchedulerStats() []AISchedulerStats {
+	return tm.aiScheduler.Stats()
+}
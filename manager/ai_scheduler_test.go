@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAISchedulerFairness(t *testing.T) {
+	s := NewAIScheduler(1)
+	ctx := context.Background()
+
+	hold, err := s.Acquire(ctx, "deepseek/k1", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// alice 先排了3个请求，bob 后排1个：放行顺序应在用户之间轮转
+	order := make(chan string, 4)
+	enqueue := func(userID string) {
+		before := s.Stats()[0].Waiting
+		go func() {
+			release, err := s.Acquire(ctx, "deepseek/k1", userID)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			order <- userID
+			release()
+		}()
+		waitFor(t, func() bool { return s.Stats()[0].Waiting == before+1 })
+	}
+	for _, userID := range []string{"alice", "alice", "alice", "bob"} {
+		enqueue(userID)
+	}
+
+	if other, err := s.Acquire(ctx, "qwen/k2", "carol"); err != nil {
+		t.Fatal(err)
+	} else {
+		other() // 不同密钥互不影响
+	}
+
+	hold()
+	got := []string{<-order, <-order, <-order, <-order}
+	want := []string{"alice", "bob", "alice", "alice"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("放行顺序 %v，期望 %v", got, want)
+		}
+	}
+	if stats := s.Stats(); stats[0].Active != 0 || stats[0].Waiting != 0 {
+		t.Errorf("全部释放后应为空: %+v", stats)
+	}
+}
+
+func TestAISchedulerCancel(t *testing.T) {
+	s := NewAIScheduler(1)
+	hold, err := s.Acquire(context.Background(), "k", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, "k", "bob"); err != context.DeadlineExceeded {
+		t.Fatalf("超时应放弃排队: %v", err)
+	}
+	if stats := s.Stats(); stats[0].Waiting != 0 {
+		t.Errorf("放弃的请求应移出队列: %+v", stats)
+	}
+
+	hold()
+	hold() // 重复释放不应多归还额度
+	release, err := s.Acquire(context.Background(), "k", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := s.Stats(); stats[0].Active != 1 {
+		t.Errorf("进行中的调用数错误: %+v", stats)
+	}
+	release()
+
+	// 放宽上限后排队的请求立即放行
+	s.SetLimit(0)
+	for i := 0; i < 3; i++ {
+		if _, err := s.Acquire(context.Background(), "k", "alice"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// waitFor 等待条件成立（最多1秒）
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待超时")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"math"
+	"nofx/logger"
+	"sort"
+	"strconv"
+)
+
+// 竞赛排名方式
+const (
+	RankByPnL              = "pnl_pct"           // 收益率
+	RankBySharpe           = "sharpe"            // 夏普比率
+	RankByCalmar           = "calmar"            // 收益率 / 最大回撤
+	RankByDrawdownAdjusted = "drawdown_adjusted" // 收益率 - 最大回撤
+)
+
+// RankModes 支持的排名方式
+var RankModes = []string{RankByPnL, RankBySharpe, RankByCalmar, RankByDrawdownAdjusted}
+
+// minCalmarDrawdownPct 计算Calmar时回撤的下限，避免几乎没有回撤的账户得分无限大
+const minCalmarDrawdownPct = 1.0
+
+// competitionLimit 竞赛排行最多返回的交易员数
+const competitionLimit = 50
+
+// RankOptions 竞赛排名设置
+type RankOptions struct {
+	Mode      string // 排名方式，为空时按收益率
+	MinTrades int    // 平仓笔数少于此值的交易员排在达标交易员之后
+}
+
+// DefaultRankOptions 系统配置 competition_rank_by 和 competition_min_trades 中的默认排名设置
+func (tm *TraderManager) DefaultRankOptions() RankOptions {
+	opts := RankOptions{Mode: RankByPnL}
+	if tm.database == nil {
+		return opts
+	}
+	if mode, _ := tm.database.GetSystemConfig("competition_rank_by"); mode != "" {
+		opts.Mode = mode
+	}
+	minTrades, _ := tm.database.GetSystemConfig("competition_min_trades")
+	opts.MinTrades, _ = strconv.Atoi(minTrades)
+	return opts
+}
+
+// rankScore 交易员在指定排名方式下的得分（越大越靠前）
+func rankScore(data map[string]interface{}, mode string) float64 {
+	pnlPct, _ := data["total_pnl_pct"].(float64)
+	metrics, _ := data["metrics"].(*logger.TradeMetrics)
+	if metrics == nil {
+		metrics = &logger.TradeMetrics{}
+	}
+	switch mode {
+	case RankBySharpe:
+		return metrics.SharpeRatio
+	case RankByCalmar:
+		return pnlPct / math.Max(metrics.MaxDrawdownPct, minCalmarDrawdownPct)
+	case RankByDrawdownAdjusted:
+		return pnlPct - metrics.MaxDrawdownPct
+	default:
+		return pnlPct
+	}
+}
+
+// rankCompetitionData 按排名方式排序交易员数据，最多返回前50名
+// 每个交易员附带 rank_score 和 qualified（平仓笔数是否达到 MinTrades），未达标的排在达标的之后
+func rankCompetitionData(traders []map[string]interface{}, opts RankOptions) map[string]interface{} {
+	if opts.Mode == "" {
+		opts.Mode = RankByPnL
+	}
+
+	// 缓存中的数据被多个请求共用，复制后再添加排名字段
+	ranked := make([]map[string]interface{}, 0, len(traders))
+	for _, data := range traders {
+		entry := make(map[string]interface{}, len(data)+2)
+		for k, v := range data {
+			entry[k] = v
+		}
+		trades := 0
+		if metrics, ok := data["metrics"].(*logger.TradeMetrics); ok && metrics != nil {
+			trades = metrics.TotalTrades
+		}
+		entry["rank_score"] = rankScore(data, opts.Mode)
+		entry["qualified"] = trades >= opts.MinTrades
+		ranked = append(ranked, entry)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		qi, qj := ranked[i]["qualified"].(bool), ranked[j]["qualified"].(bool)
+		if qi != qj {
+			return qi
+		}
+		return ranked[i]["rank_score"].(float64) > ranked[j]["rank_score"].(float64)
+	})
+
+	// 限制返回前50名
+	totalCount := len(ranked)
+	if len(ranked) > competitionLimit {
+		ranked = ranked[:competitionLimit]
+	}
+
+	comparison := make(map[string]interface{})
+	comparison["traders"] = ranked
+	comparison["count"] = len(ranked)
+	comparison["total_count"] = totalCount // 总交易员数量
+	comparison["rank_by"] = opts.Mode
+	comparison["min_trades"] = opts.MinTrades
+	return comparison
+}
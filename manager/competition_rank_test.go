@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"nofx/logger"
+	"testing"
+)
+
+func TestRankCompetitionData(t *testing.T) {
+	traders := []map[string]interface{}{
+		// 只有一笔交易的幸运账户
+		{"trader_id": "lucky", "total_pnl_pct": 50.0, "metrics": &logger.TradeMetrics{TotalTrades: 1, SharpeRatio: 0.2, MaxDrawdownPct: 40}},
+		{"trader_id": "steady", "total_pnl_pct": 20.0, "metrics": &logger.TradeMetrics{TotalTrades: 30, SharpeRatio: 0.5, MaxDrawdownPct: 5}},
+		{"trader_id": "flat", "total_pnl_pct": 3.0, "metrics": &logger.TradeMetrics{TotalTrades: 12, SharpeRatio: 0.3, MaxDrawdownPct: 0.2}},
+		{"trader_id": "new", "total_pnl_pct": 0.0},
+	}
+	order := func(data map[string]interface{}) string {
+		var ids string
+		for _, tr := range data["traders"].([]map[string]interface{}) {
+			ids += tr["trader_id"].(string) + " "
+		}
+		return ids
+	}
+
+	cases := []struct {
+		opts RankOptions
+		want string
+	}{
+		{RankOptions{}, "lucky steady flat new "},
+		{RankOptions{Mode: RankBySharpe}, "steady flat lucky new "},
+		{RankOptions{Mode: RankByCalmar}, "steady flat lucky new "}, // 4, 3（回撤按1%计）, 1.25
+		{RankOptions{Mode: RankByDrawdownAdjusted}, "steady lucky flat new "},
+		{RankOptions{MinTrades: 10}, "steady flat lucky new "},
+	}
+	for _, tc := range cases {
+		if got := order(rankCompetitionData(traders, tc.opts)); got != tc.want {
+			t.Errorf("%+v: 排名 %q，期望 %q", tc.opts, got, tc.want)
+		}
+	}
+
+	data := rankCompetitionData(traders, RankOptions{MinTrades: 10})
+	first := data["traders"].([]map[string]interface{})[0]
+	if first["qualified"] != true || data["rank_by"] != RankByPnL || traders[0]["rank_score"] != nil {
+		t.Errorf("应标记达标状态且不修改原数据: %+v", first)
+	}
+}
@@ -0,0 +1,165 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"nofx/cache"
+	"nofx/logger"
+	"nofx/trader"
+	"time"
+)
+
+const (
+	competitionMaxAge   = 30 * time.Second // 快照超过此时间未刷新时，请求时同步刷新
+	competitionStoreKey = "nofx:competition:traders"
+)
+
+// competitionEntry 一个交易员最近一次成功获取的竞赛数据
+type competitionEntry struct {
+	data    map[string]interface{}
+	updated time.Time
+}
+
+// SetCompetitionStore 设置共享竞赛快照的缓存（Redis），没有加载交易员的API副本直接使用其中的快照
+func (tm *TraderManager) SetCompetitionStore(store cache.Store) {
+	tm.competitionCache.mu.Lock()
+	tm.competitionCache.store = store
+	tm.competitionCache.mu.Unlock()
+}
+
+// competitionTraders 全部交易员的竞赛数据快照，过期时同步刷新
+func (tm *TraderManager) competitionTraders() []map[string]interface{} {
+	c := tm.competitionCache
+	c.mu.RLock()
+	all, age := c.all, time.Since(c.timestamp)
+	c.mu.RUnlock()
+	if all != nil && age < competitionMaxAge {
+		slog.Debug("返回竞赛数据缓存", "cache_age", age)
+		return all
+	}
+	return tm.RefreshCompetition()
+}
+
+// RefreshCompetition 重新获取所有交易员的竞赛数据并更新快照
+// 获取失败或超时的交易员保留上一次成功的数据（标记 stale），已移除的交易员从快照中删除
+func (tm *TraderManager) RefreshCompetition() []map[string]interface{} {
+	c := tm.competitionCache
+	started := time.Now()
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// 等待期间其他请求已刷新时直接复用
+	c.mu.RLock()
+	if c.all != nil && c.timestamp.After(started) {
+		all := c.all
+		c.mu.RUnlock()
+		return all
+	}
+	store := c.store
+	c.mu.RUnlock()
+
+	tm.mu.RLock()
+	allTraders := make([]*trader.AutoTrader, 0, len(tm.traders))
+	for _, t := range tm.traders {
+		allTraders = append(allTraders, t)
+	}
+	tm.mu.RUnlock()
+
+	if len(allTraders) == 0 && store != nil {
+		if shared, ok := loadSharedCompetition(store); ok {
+			tm.setCompetitionSnapshot(shared, c.entries)
+			return shared
+		}
+	}
+
+	slog.Debug("重新获取竞赛数据", "trader_count", len(allTraders))
+	now := time.Now()
+	results := tm.getConcurrentTraderData(allTraders)
+	entries := make(map[string]competitionEntry, len(results))
+	all := make([]map[string]interface{}, 0, len(results))
+	for _, data := range results {
+		id, _ := data["trader_id"].(string)
+		if _, failed := data["error"]; !failed {
+			entries[id] = competitionEntry{data: data, updated: now}
+			all = append(all, data)
+			continue
+		}
+		prev, ok := c.entries[id]
+		if !ok {
+			all = append(all, data)
+			continue
+		}
+		entries[id] = prev
+		stale := make(map[string]interface{}, len(prev.data)+2)
+		for k, v := range prev.data {
+			stale[k] = v
+		}
+		stale["stale"] = true
+		stale["updated_at"] = prev.updated
+		all = append(all, stale)
+	}
+	tm.setCompetitionSnapshot(all, entries)
+
+	if store != nil {
+		if data, err := json.Marshal(all); err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			if err := store.Set(ctx, competitionStoreKey, data, 2*competitionMaxAge); err != nil {
+				slog.Warn("写入共享竞赛快照失败", "error", err)
+			}
+			cancel()
+		}
+	}
+	return all
+}
+
+// setCompetitionSnapshot 替换快照
+func (tm *TraderManager) setCompetitionSnapshot(all []map[string]interface{}, entries map[string]competitionEntry) {
+	c := tm.competitionCache
+	c.mu.Lock()
+	c.all = all
+	c.entries = entries
+	c.timestamp = time.Now()
+	c.mu.Unlock()
+}
+
+// loadSharedCompetition 读取其他副本写入的竞赛快照
+func loadSharedCompetition(store cache.Store) ([]map[string]interface{}, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	data, ok, err := store.Get(ctx, competitionStoreKey)
+	if err != nil {
+		slog.Warn("读取共享竞赛快照失败", "error", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var all []map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, false
+	}
+	// 排名和模型对比按类型读取绩效指标
+	for _, entry := range all {
+		raw, err := json.Marshal(entry["metrics"])
+		if err != nil || entry["metrics"] == nil {
+			continue
+		}
+		var metrics logger.TradeMetrics
+		if json.Unmarshal(raw, &metrics) == nil {
+			entry["metrics"] = &metrics
+		}
+	}
+	return all, true
+}
+
+// StartCompetitionRefresher 定期刷新竞赛快照（阻塞，调用方使用 go 启动），公开接口只读快照不再触发刷新
+func (tm *TraderManager) StartCompetitionRefresher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		tm.RefreshCompetition()
+		<-ticker.C
+	}
+}
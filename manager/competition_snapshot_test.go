@@ -0,0 +1,42 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"nofx/cache"
+	"nofx/logger"
+	"testing"
+	"time"
+)
+
+func TestSharedCompetitionSnapshot(t *testing.T) {
+	store := cache.NewMemory()
+	snapshot := []map[string]interface{}{
+		{"trader_id": "a", "ai_model": "deepseek", "total_pnl_pct": 5.0, "metrics": &logger.TradeMetrics{TotalTrades: 3, SharpeRatio: 0.4}},
+		{"trader_id": "b", "ai_model": "qwen", "total_pnl_pct": 8.0},
+	}
+	data, _ := json.Marshal(snapshot)
+	store.Set(context.Background(), competitionStoreKey, data, time.Minute)
+
+	// 没有加载交易员的副本使用共享快照
+	tm := NewTraderManager()
+	tm.SetCompetitionStore(store)
+	ranked, err := tm.GetRankedCompetitionData(RankOptions{Mode: RankBySharpe})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traders := ranked["traders"].([]map[string]interface{})
+	if len(traders) != 2 || traders[0]["trader_id"] != "a" {
+		t.Fatalf("应使用共享快照中的绩效指标排名: %+v", traders)
+	}
+	if stats := tm.GetModelAnalytics(); len(stats) != 2 || stats[1].TotalTrades != 3 {
+		t.Errorf("模型对比应读取共享快照: %+v", stats)
+	}
+
+	// 没有共享快照时返回空排行
+	empty := NewTraderManager()
+	empty.SetCompetitionStore(cache.NewMemory())
+	if ranked, _ := empty.GetCompetitionData(); ranked["count"] != 0 {
+		t.Errorf("没有交易员时应返回空排行: %+v", ranked)
+	}
+}
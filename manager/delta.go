@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TraderDelta 单个交易员的方向敞口
+type TraderDelta struct {
+	TraderID      string  `json:"trader_id"`
+	TraderName    string  `json:"trader_name"`
+	TotalEquity   float64 `json:"total_equity"`
+	LongNotional  float64 `json:"long_notional"`
+	ShortNotional float64 `json:"short_notional"`
+	NetDelta      float64 `json:"net_delta"`     // 多 - 空（USDT）
+	NetDeltaPct   float64 `json:"net_delta_pct"` // 净敞口 / 净值
+	Error         string  `json:"error,omitempty"`
+}
+
+// DeltaReport 用户所有交易员的净方向敞口
+type DeltaReport struct {
+	TotalEquity   float64       `json:"total_equity"`
+	LongNotional  float64       `json:"long_notional"`
+	ShortNotional float64       `json:"short_notional"`
+	NetDelta      float64       `json:"net_delta"`
+	NetDeltaPct   float64       `json:"net_delta_pct"`
+	Direction     string        `json:"direction"` // long / short / flat
+	Traders       []TraderDelta `json:"traders"`   // 按 |净敞口| 降序
+}
+
+// GetDeltaReport 汇总指定交易员的多空净敞口（按交易员和整体）
+func (tm *TraderManager) GetDeltaReport(traderIDs []string) (*DeltaReport, error) {
+	traders, positions := tm.collectPositions(traderIDs)
+	if len(traders) == 0 {
+		return nil, fmt.Errorf("没有运行中的交易员")
+	}
+	return BuildDeltaReport(traders, positions), nil
+}
+
+// BuildDeltaReport 根据各交易员账户和持仓计算净方向敞口
+func BuildDeltaReport(traders []PortfolioTrader, positions []PortfolioPosition) *DeltaReport {
+	report := &DeltaReport{Traders: make([]TraderDelta, 0, len(traders))}
+
+	byTrader := make(map[string]*TraderDelta, len(traders))
+	for _, t := range traders {
+		report.Traders = append(report.Traders, TraderDelta{
+			TraderID:    t.TraderID,
+			TraderName:  t.TraderName,
+			TotalEquity: t.TotalEquity,
+			Error:       t.Error,
+		})
+		if t.Error == "" {
+			report.TotalEquity += t.TotalEquity
+		}
+	}
+	for i := range report.Traders {
+		byTrader[report.Traders[i].TraderID] = &report.Traders[i]
+	}
+
+	for _, pos := range positions {
+		notional := pos.Quantity * pos.MarkPrice
+		td, ok := byTrader[pos.TraderID]
+		if !ok {
+			continue
+		}
+		if pos.Side == "short" {
+			td.ShortNotional += notional
+			report.ShortNotional += notional
+		} else {
+			td.LongNotional += notional
+			report.LongNotional += notional
+		}
+	}
+
+	for i := range report.Traders {
+		td := &report.Traders[i]
+		td.NetDelta = td.LongNotional - td.ShortNotional
+		if td.TotalEquity > 0 {
+			td.NetDeltaPct = td.NetDelta / td.TotalEquity * 100
+		}
+	}
+	sort.SliceStable(report.Traders, func(i, j int) bool {
+		return math.Abs(report.Traders[i].NetDelta) > math.Abs(report.Traders[j].NetDelta)
+	})
+
+	report.NetDelta = report.LongNotional - report.ShortNotional
+	if report.TotalEquity > 0 {
+		report.NetDeltaPct = report.NetDelta / report.TotalEquity * 100
+	}
+	switch {
+	case report.NetDelta > 0:
+		report.Direction = "long"
+	case report.NetDelta < 0:
+		report.Direction = "short"
+	default:
+		report.Direction = "flat"
+	}
+	return report
+}
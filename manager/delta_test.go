@@ -0,0 +1,41 @@
+package manager
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildDeltaReport(t *testing.T) {
+	traders := []PortfolioTrader{
+		{TraderID: "a", TotalEquity: 1000},
+		{TraderID: "b", TotalEquity: 1000},
+		{TraderID: "c", Error: "账户数据获取失败"},
+	}
+	positions := []PortfolioPosition{
+		{TraderID: "a", Symbol: "BTCUSDT", Side: "long", Quantity: 0.05, MarkPrice: 60000},
+		{TraderID: "b", Symbol: "BTCUSDT", Side: "short", Quantity: 0.12, MarkPrice: 60000},
+		{TraderID: "b", Symbol: "ETHUSDT", Side: "long", Quantity: 1, MarkPrice: 3000},
+	}
+
+	r := BuildDeltaReport(traders, positions)
+
+	if r.TotalEquity != 2000 || math.Abs(r.NetDeltaPct+60) > 1e-9 {
+		t.Errorf("失败的交易员不应计入净值: %.2f", r.TotalEquity)
+	}
+	if r.LongNotional != 6000 || r.ShortNotional != 7200 || r.NetDelta != -1200 || r.Direction != "short" {
+		t.Errorf("整体净敞口错误: %+v", r)
+	}
+	if len(r.Traders) != 3 || r.Traders[0].TraderID != "b" {
+		t.Fatalf("交易员应按净敞口绝对值降序: %+v", r.Traders)
+	}
+	b := r.Traders[0]
+	if b.NetDelta != -4200 || math.Abs(b.NetDeltaPct+420) > 1e-9 {
+		t.Errorf("交易员b净敞口错误: %+v", b)
+	}
+	if a := r.Traders[1]; a.TraderID != "a" || a.NetDelta != 3000 {
+		t.Errorf("交易员a净敞口错误: %+v", a)
+	}
+	if c := r.Traders[2]; c.Error == "" || c.NetDelta != 0 {
+		t.Errorf("失败的交易员应保留错误信息: %+v", c)
+	}
+}
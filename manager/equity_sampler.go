@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"nofx/config"
+	"nofx/trader"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// equitySampleTimeout 单个交易员一次采样的最长等待时间，超时的交易员本轮跳过
+const equitySampleTimeout = 10 * time.Second
+
+// SampleEquity 为所有交易员（包括已停止和出错的）记录一次净值采样，返回成功的数量
+// 开启采样前已被移出内存的交易员先重新加载，采样开启期间不会再被移除
+func (tm *TraderManager) SampleEquity(database *config.Database, now time.Time) int {
+	tm.reloadAllEvicted()
+
+	tm.mu.RLock()
+	traders := make([]*trader.AutoTrader, 0, len(tm.traders))
+	for _, at := range tm.traders {
+		traders = append(traders, at)
+	}
+	tm.mu.RUnlock()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		sampled int
+	)
+	for _, at := range traders {
+		wg.Add(1)
+		go func(at *trader.AutoTrader) {
+			defer wg.Done()
+			if err := sampleTraderEquity(database, at, now); err != nil {
+				slog.Debug("净值采样失败", "trader_id", at.GetID(), "error", err)
+				return
+			}
+			mu.Lock()
+			sampled++
+			mu.Unlock()
+		}(at)
+	}
+	wg.Wait()
+	return sampled
+}
+
+// sampleTraderEquity 查询并保存一个交易员的净值，交易所无响应时超时返回
+func sampleTraderEquity(database *config.Database, at *trader.AutoTrader, now time.Time) error {
+	type result struct {
+		sample *trader.EquitySample
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		sample, err := at.SampleEquity(now)
+		done <- result{sample, err}
+	}()
+
+	var r result
+	select {
+	case r = <-done:
+	case <-time.After(equitySampleTimeout):
+		return fmt.Errorf("查询超时（%s）", equitySampleTimeout)
+	}
+	if r.err != nil {
+		return r.err
+	}
+	data, err := json.Marshal(r.sample)
+	if err != nil {
+		return err
+	}
+	return database.SaveEquitySample(at.GetID(), now, data)
+}
+
+// StartEquitySampler 按 equity_sample_minutes 定期记录净值（阻塞，调用方使用 go 启动）
+// 每分钟检查一次配置，修改间隔无需重启，0 表示关闭
+func (tm *TraderManager) StartEquitySampler(database *config.Database) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	var last time.Time
+	for now := range ticker.C {
+		value, _ := database.GetSystemConfig("equity_sample_minutes")
+		minutes, _ := strconv.Atoi(value)
+		if minutes <= 0 || now.Sub(last) < time.Duration(minutes)*time.Minute-time.Second {
+			continue
+		}
+		last = now
+		n := tm.SampleEquity(database, now.Truncate(time.Second))
+		slog.Debug("已记录净值采样", "traders", n)
+	}
+}
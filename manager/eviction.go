@@ -0,0 +1,133 @@
+package manager
+
+import (
+	"log/slog"
+	"nofx/config"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// touchTrader 记录交易员最近一次被访问的时间
+func (tm *TraderManager) touchTrader(id string, now time.Time) {
+	tm.accessMu.Lock()
+	defer tm.accessMu.Unlock()
+	tm.lastAccess[id] = now
+}
+
+// EvictIdleTraders 从内存中移除已停止且长时间未访问的交易员：超过 idleTTL 未访问的全部移除，
+// 已停止的交易员多于 maxIdle 个时按最近访问时间淘汰最久未用的（两者 <=0 表示不限制）。
+// 被移除的交易员再次通过 GetTrader 访问时自动从数据库重新加载，返回移除的数量
+func (tm *TraderManager) EvictIdleTraders(now time.Time, idleTTL time.Duration, maxIdle int) int {
+	if idleTTL <= 0 && maxIdle <= 0 {
+		return 0
+	}
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.accessMu.Lock()
+	defer tm.accessMu.Unlock()
+
+	type idleTrader struct {
+		id         string
+		userID     string
+		lastAccess time.Time
+	}
+	idle := []idleTrader{}
+	for id, at := range tm.traders {
+		if at.IsRunning() {
+			continue
+		}
+		last, ok := tm.lastAccess[id]
+		if !ok {
+			// 第一次检查时从现在开始计时
+			last = now
+			tm.lastAccess[id] = now
+		}
+		idle = append(idle, idleTrader{id: id, userID: at.GetUserID(), lastAccess: last})
+	}
+	sort.Slice(idle, func(i, j int) bool { return idle[i].lastAccess.Before(idle[j].lastAccess) })
+
+	evicted := 0
+	for i, t := range idle {
+		overLimit := maxIdle > 0 && len(idle)-i > maxIdle
+		expired := idleTTL > 0 && now.Sub(t.lastAccess) >= idleTTL
+		if !overLimit && !expired {
+			continue
+		}
+		delete(tm.traders, t.id)
+		delete(tm.lastAccess, t.id)
+		tm.evicted[t.id] = t.userID
+		evicted++
+	}
+	if evicted > 0 {
+		slog.Info("已从内存移除空闲的交易员", "count", evicted, "loaded", len(tm.traders))
+	}
+	return evicted
+}
+
+// reloadEvicted 重新加载被移除的交易员，不是被移除的交易员时返回false
+func (tm *TraderManager) reloadEvicted(id string) bool {
+	tm.mu.RLock()
+	userID, ok := tm.evicted[id]
+	database := tm.database
+	tm.mu.RUnlock()
+	if !ok || database == nil {
+		return false
+	}
+
+	if err := tm.LoadUserTraders(database, userID); err != nil {
+		slog.Warn("重新加载交易员失败", "trader_id", id, "user_id", userID, "error", err)
+		return false
+	}
+	tm.mu.Lock()
+	delete(tm.evicted, id) // 交易员已被删除时也不再尝试
+	tm.mu.Unlock()
+	return true
+}
+
+// reloadAllEvicted 重新加载所有被移除的交易员（按用户加载，每个用户只查询一次），返回处理的用户数
+func (tm *TraderManager) reloadAllEvicted() int {
+	tm.mu.RLock()
+	database := tm.database
+	users := make(map[string][]string)
+	for id, userID := range tm.evicted {
+		users[userID] = append(users[userID], id)
+	}
+	tm.mu.RUnlock()
+	if database == nil {
+		return 0
+	}
+
+	for userID, ids := range users {
+		if err := tm.LoadUserTraders(database, userID); err != nil {
+			slog.Warn("重新加载交易员失败", "user_id", userID, "traders", len(ids), "error", err)
+			continue
+		}
+		tm.mu.Lock()
+		for _, id := range ids {
+			delete(tm.evicted, id) // 交易员已被删除时也不再尝试
+		}
+		tm.mu.Unlock()
+	}
+	return len(users)
+}
+
+// StartIdleTraderEviction 定期移除空闲的交易员（阻塞，调用方使用 go 启动）
+// 系统配置 idle_trader_evict_minutes 和 max_idle_traders 为0时不移除；
+// 净值采样开启（equity_sample_minutes>0）时也不移除，否则被移除的交易员停止后不再有净值采样
+func (tm *TraderManager) StartIdleTraderEviction(database *config.Database, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sampleStr, _ := database.GetSystemConfig("equity_sample_minutes")
+		if sampleMinutes, _ := strconv.Atoi(sampleStr); sampleMinutes > 0 {
+			continue
+		}
+		minutesStr, _ := database.GetSystemConfig("idle_trader_evict_minutes")
+		maxIdleStr, _ := database.GetSystemConfig("max_idle_traders")
+		minutes, _ := strconv.Atoi(minutesStr)
+		maxIdle, _ := strconv.Atoi(maxIdleStr)
+		tm.EvictIdleTraders(time.Now(), time.Duration(minutes)*time.Minute, maxIdle)
+	}
+}
@@ -0,0 +1,41 @@
+package manager
+
+import (
+	"nofx/trader"
+	"testing"
+	"time"
+)
+
+func TestEvictIdleTraders(t *testing.T) {
+	tm := NewTraderManager()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		tm.traders[id] = &trader.AutoTrader{}
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tm.touchTrader("a", now.Add(-2*time.Hour))
+	tm.touchTrader("b", now.Add(-30*time.Minute))
+	tm.touchTrader("c", now.Add(-10*time.Minute))
+
+	if n := tm.EvictIdleTraders(now, 0, 0); n != 0 {
+		t.Fatalf("未配置时不应移除，实际移除 %d 个", n)
+	}
+	// a 超过1小时未访问；d 第一次检查，从现在开始计时
+	if n := tm.EvictIdleTraders(now, time.Hour, 0); n != 1 || tm.traders["a"] != nil || tm.traders["d"] == nil {
+		t.Fatalf("应只移除超时的a，实际移除 %d 个", n)
+	}
+	// 最多保留2个时移除最久未访问的b
+	if n := tm.EvictIdleTraders(now, time.Hour, 2); n != 1 || tm.traders["b"] != nil {
+		t.Fatalf("应按最近访问时间移除b，实际移除 %d 个", n)
+	}
+	if len(tm.evicted) != 2 {
+		t.Errorf("应记录被移除的交易员: %v", tm.evicted)
+	}
+	// 没有数据库时批量重新加载不做任何事，保留移除记录
+	if n := tm.reloadAllEvicted(); n != 0 || len(tm.evicted) != 2 {
+		t.Errorf("没有数据库时不应重新加载，处理 %d 个用户，剩余 %v", n, tm.evicted)
+	}
+	// 没有数据库时无法重新加载
+	if _, err := tm.GetTrader("a"); err == nil {
+		t.Error("无法重新加载时应返回错误")
+	}
+}
@@ -0,0 +1,153 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+	"nofx/config"
+	"nofx/trader"
+	"sync"
+)
+
+// followRegistry 运行中的跟单执行器
+type followRegistry struct {
+	followers map[string]*trader.Follower // key: follow ID
+	mu        sync.Mutex
+}
+
+// followerConfig 数据库中的跟单订阅转换为执行器配置
+func followerConfig(f *config.Follow) trader.FollowConfig {
+	return trader.FollowConfig{
+		ID:             f.ID,
+		UserID:         f.UserID,
+		LeaderID:       f.LeaderID,
+		Multiplier:     f.Multiplier,
+		MaxLeverage:    f.MaxLeverage,
+		MaxPositionUSD: f.MaxPositionUSD,
+		MaxPositions:   f.MaxPositions,
+		MaxDrawdownPct: f.MaxDrawdownPct,
+	}
+}
+
+// NewFollowExchange 用跟随者自己的交易所配置创建交易器（密钥映射与交易员一致）
+func NewFollowExchange(f *config.Follow, exchangeCfg *config.ExchangeConfig) (trader.Trader, error) {
+	traderConfig := trader.AutoTraderConfig{
+		Name:               "follow-" + f.ID,
+		Exchange:           exchangeCfg.ID,
+		HyperliquidTestnet: exchangeCfg.Testnet,
+	}
+	switch exchangeCfg.ID {
+	case "binance":
+		traderConfig.BinanceAPIKey = exchangeCfg.APIKey
+		traderConfig.BinanceSecretKey = exchangeCfg.SecretKey
+	case "hyperliquid":
+		traderConfig.HyperliquidPrivateKey = exchangeCfg.APIKey // hyperliquid用APIKey存储private key
+		traderConfig.HyperliquidWalletAddr = exchangeCfg.HyperliquidWalletAddr
+	case "aster":
+		traderConfig.AsterUser = exchangeCfg.AsterUser
+		traderConfig.AsterSigner = exchangeCfg.AsterSigner
+		traderConfig.AsterPrivateKey = exchangeCfg.AsterPrivateKey
+	}
+	return trader.NewExchangeTrader(traderConfig)
+}
+
+// StartFollow 用给定交易器启动跟单，同ID的旧执行器先停止
+func (tm *TraderManager) StartFollow(f *config.Follow, exchange trader.Trader) error {
+	if _, err := tm.GetTrader(f.LeaderID); err != nil {
+		return fmt.Errorf("领单交易员不存在: %w", err)
+	}
+
+	tm.follows.mu.Lock()
+	defer tm.follows.mu.Unlock()
+	if old, ok := tm.follows.followers[f.ID]; ok {
+		old.Stop()
+	}
+	follower := trader.NewFollower(followerConfig(f), exchange)
+	follower.Start()
+	tm.follows.followers[f.ID] = follower
+	return nil
+}
+
+// UpdateFollow 修改运行中跟单的倍数和风控上限，未运行时返回 false
+func (tm *TraderManager) UpdateFollow(f *config.Follow) bool {
+	tm.follows.mu.Lock()
+	defer tm.follows.mu.Unlock()
+	follower, ok := tm.follows.followers[f.ID]
+	if ok {
+		follower.UpdateConfig(followerConfig(f))
+	}
+	return ok
+}
+
+// StopFollow 停止跟单（跟随者已有持仓保留）
+func (tm *TraderManager) StopFollow(id string) {
+	tm.follows.mu.Lock()
+	defer tm.follows.mu.Unlock()
+	if follower, ok := tm.follows.followers[id]; ok {
+		follower.Stop()
+		delete(tm.follows.followers, id)
+	}
+}
+
+// StopFollowsOf 停止跟随某个交易员的所有跟单（领单交易员被删除时调用）
+func (tm *TraderManager) StopFollowsOf(leaderID string) {
+	tm.follows.mu.Lock()
+	defer tm.follows.mu.Unlock()
+	for id, follower := range tm.follows.followers {
+		if follower.LeaderID() == leaderID {
+			follower.Stop()
+			delete(tm.follows.followers, id)
+		}
+	}
+}
+
+// GetFollowerStatus 跟单运行状态，未运行时返回 false
+func (tm *TraderManager) GetFollowerStatus(id string) (trader.FollowerStatus, bool) {
+	tm.follows.mu.Lock()
+	follower, ok := tm.follows.followers[id]
+	tm.follows.mu.Unlock()
+	if !ok {
+		return trader.FollowerStatus{}, false
+	}
+	return follower.Status(), true
+}
+
+// LoadFollows 启动时加载所有启用的跟单（需在交易员加载之后调用）
+func (tm *TraderManager) LoadFollows(database *config.Database) error {
+	follows, err := database.GetEnabledFollows()
+	if err != nil {
+		return fmt.Errorf("获取跟单列表失败: %w", err)
+	}
+
+	started := 0
+	for _, f := range follows {
+		exchanges, err := database.GetExchanges(f.UserID)
+		if err != nil {
+			slog.Warn("跟单获取交易所配置失败", "follow_id", f.ID, "user_id", f.UserID, "error", err)
+			continue
+		}
+		var exchangeCfg *config.ExchangeConfig
+		for _, e := range exchanges {
+			if e.ID == f.ExchangeID && e.Enabled {
+				exchangeCfg = e
+				break
+			}
+		}
+		if exchangeCfg == nil {
+			slog.Warn("跟单的交易所不存在或未启用，跳过", "follow_id", f.ID, "user_id", f.UserID, "exchange", f.ExchangeID)
+			continue
+		}
+
+		exchange, err := NewFollowExchange(f, exchangeCfg)
+		if err != nil {
+			slog.Warn("跟单连接交易所失败", "follow_id", f.ID, "user_id", f.UserID, "error", err)
+			continue
+		}
+		if err := tm.StartFollow(f, exchange); err != nil {
+			slog.Warn("跟单启动失败", "follow_id", f.ID, "user_id", f.UserID, "error", err)
+			continue
+		}
+		started++
+	}
+	slog.Info("跟单已启动", "started", started, "total", len(follows))
+	return nil
+}
@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"context"
+	"log/slog"
+	"nofx/config"
+	"nofx/trader"
+	"sort"
+	"sync"
+	"time"
+)
+
+// heartbeatTracker 心跳检查记录的异常交易员，只在状态变化时告警
+type heartbeatTracker struct {
+	mu          sync.Mutex
+	unhealthy   map[string]string    // trader ID -> 健康状态
+	restartedAt map[string]time.Time // 最近一次自动重启的时间
+}
+
+// UnhealthyTrader 运行中但卡住或连续出错的交易员
+type UnhealthyTrader struct {
+	TraderID  string           `json:"trader_id"`
+	UserID    string           `json:"user_id"`
+	Name      string           `json:"name"`
+	Heartbeat trader.Heartbeat `json:"heartbeat"`
+}
+
+// CheckHeartbeats 返回运行中但卡住或连续出错的交易员
+func (tm *TraderManager) CheckHeartbeats(now time.Time) []UnhealthyTrader {
+	tm.mu.RLock()
+	traders := make([]*trader.AutoTrader, 0, len(tm.traders))
+	for _, at := range tm.traders {
+		traders = append(traders, at)
+	}
+	tm.mu.RUnlock()
+
+	unhealthy := []UnhealthyTrader{}
+	for _, at := range traders {
+		if !at.IsRunning() {
+			continue
+		}
+		hb := at.GetHeartbeat(now)
+		if hb.Health == trader.HealthOK {
+			continue
+		}
+		unhealthy = append(unhealthy, UnhealthyTrader{TraderID: at.GetID(), UserID: at.GetUserID(), Name: at.GetName(), Heartbeat: hb})
+	}
+	sort.Slice(unhealthy, func(i, j int) bool { return unhealthy[i].TraderID < unhealthy[j].TraderID })
+	return unhealthy
+}
+
+// MonitorHeartbeats 检查一次心跳：新出现或恢复的异常交易员打日志，autoRestart 时重启异常的交易员
+func (tm *TraderManager) MonitorHeartbeats(database *config.Database, now time.Time, autoRestart bool) []UnhealthyTrader {
+	unhealthy := tm.CheckHeartbeats(now)
+
+	tm.heartbeats.mu.Lock()
+	current := make(map[string]string, len(unhealthy))
+	for _, u := range unhealthy {
+		current[u.TraderID] = u.Heartbeat.Health
+		if tm.heartbeats.unhealthy[u.TraderID] != u.Heartbeat.Health {
+			slog.Warn("交易员心跳异常", "trader_id", u.TraderID, "user_id", u.UserID, "trader", u.Name,
+				"health", u.Heartbeat.Health, "consecutive_errors", u.Heartbeat.ConsecutiveErrors, "last_error", u.Heartbeat.LastError)
+		}
+	}
+	for id := range tm.heartbeats.unhealthy {
+		if _, ok := current[id]; !ok {
+			slog.Info("交易员心跳恢复正常", "trader_id", id)
+		}
+	}
+	tm.heartbeats.unhealthy = current
+	tm.heartbeats.mu.Unlock()
+
+	if autoRestart {
+		for _, u := range unhealthy {
+			tm.restartUnhealthy(database, u.TraderID, now)
+		}
+	}
+	return unhealthy
+}
+
+// restartUnhealthy 停止并重新运行异常的交易员；同一交易员在卡住判定时间内只重启一次
+func (tm *TraderManager) restartUnhealthy(database *config.Database, traderID string, now time.Time) {
+	at, err := tm.GetTrader(traderID)
+	if err != nil {
+		return
+	}
+	tm.heartbeats.mu.Lock()
+	if last, ok := tm.heartbeats.restartedAt[traderID]; ok && now.Sub(last) < at.StallTimeout() {
+		tm.heartbeats.mu.Unlock()
+		return
+	}
+	tm.heartbeats.restartedAt[traderID] = now
+	tm.heartbeats.mu.Unlock()
+
+	at.Logger().Warn("自动重启异常的交易员")
+	go func() {
+		at.Stop()
+		// 卡住的交易所请求不可中断，受其自身超时限制，等它返回后再启动
+		at.Wait(context.Background())
+		if at.IsRunning() {
+			return // 等待期间已被用户手动启动
+		}
+		tm.startTrader(database, at)
+	}()
+}
+
+// StartHeartbeatMonitor 定期检查交易员心跳（阻塞，调用方使用 go 启动）
+// 系统配置 auto_restart_stalled_traders 为 true 时自动重启卡住或连续出错的交易员
+func (tm *TraderManager) StartHeartbeatMonitor(database *config.Database, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		autoRestart, _ := database.GetSystemConfig("auto_restart_stalled_traders")
+		tm.MonitorHeartbeats(database, time.Now(), autoRestart == "true")
+	}
+}
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+	"nofx/config"
+	"nofx/decision"
+)
+
+// ApplyUserLimits 把用户实际生效的管理员上限同步到其已加载的交易员
+func (tm *TraderManager) ApplyUserLimits(database *config.Database, userID string) error {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.applyUserLimitsLocked(database, userID)
+}
+
+// applyUserLimitsLocked 同 ApplyUserLimits，调用方需已持有 tm.mu
+func (tm *TraderManager) applyUserLimitsLocked(database *config.Database, userID string) error {
+	limits, err := database.GetEffectiveLimits(userID)
+	if err != nil {
+		return err
+	}
+	traders, err := database.GetTraders(userID)
+	if err != nil {
+		return fmt.Errorf("获取用户 %s 的交易员列表失败: %w", userID, err)
+	}
+
+	for _, traderCfg := range traders {
+		if t, ok := tm.traders[traderCfg.ID]; ok {
+			t.SetLimits(decision.Limits{MaxLeverage: limits.MaxLeverage, MaxNotional: limits.MaxNotional})
+		}
+	}
+	return nil
+}
+
+// ApplyAllUserLimits 把管理员上限同步到所有用户的交易员（系统级上限变化后调用）
+func (tm *TraderManager) ApplyAllUserLimits(database *config.Database) error {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return tm.applyAllUserLimitsLocked(database)
+}
+
+// applyAllUserLimitsLocked 同 ApplyAllUserLimits，调用方需已持有 tm.mu
+func (tm *TraderManager) applyAllUserLimitsLocked(database *config.Database) error {
+	userIDs, err := database.GetAllUsers()
+	if err != nil {
+		return fmt.Errorf("获取用户列表失败: %w", err)
+	}
+	for _, userID := range userIDs {
+		if err := tm.applyUserLimitsLocked(database, userID); err != nil {
+			slog.Warn("同步用户的上限失败", "user_id", userID, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"log/slog"
+	"nofx/config"
+	"nofx/logger"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// decisionLogRoot 各交易员决策日志目录的上级目录
+const decisionLogRoot = "decision_logs"
+
+// loadRetentionPolicy 从系统配置读取决策日志保留策略
+func loadRetentionPolicy(database *config.Database) logger.RetentionPolicy {
+	keep, _ := database.GetSystemConfig("decision_log_keep_days")
+	gzipDays, _ := database.GetSystemConfig("decision_log_gzip_days")
+	var policy logger.RetentionPolicy
+	policy.RetentionDays, _ = strconv.Atoi(keep)
+	policy.CompressAfterDays, _ = strconv.Atoi(gzipDays)
+	return policy
+}
+
+// CompactDecisionLogs 按保留策略整理 root 下所有交易员的决策日志（包括未加载和已删除的交易员）
+func CompactDecisionLogs(root string, policy logger.RetentionPolicy, now time.Time) logger.CompactionResult {
+	var total logger.CompactionResult
+	if policy.RetentionDays <= 0 && policy.CompressAfterDays <= 0 {
+		return total
+	}
+	dirs, err := logger.LogDirs(root)
+	if err != nil {
+		slog.Warn("列出决策日志失败", "dir", root, "error", err)
+		return total
+	}
+	for _, dir := range dirs {
+		result, err := logger.NewDecisionLogger(dir).Compact(policy, now)
+		if err != nil {
+			slog.Warn("整理决策日志失败", "trader_id", filepath.Base(dir), "error", err)
+		}
+		if result != nil {
+			total.Compressed += result.Compressed
+			total.Removed += result.Removed
+			total.Archived += result.Archived
+		}
+	}
+	return total
+}
+
+// pruneEquitySamples 删除超过 decision_log_keep_days 的净值采样（与原始决策记录保留同样长的时间）
+func pruneEquitySamples(database *config.Database, now time.Time) {
+	policy := loadRetentionPolicy(database)
+	if policy.RetentionDays <= 0 {
+		return
+	}
+	removed, err := database.DeleteEquitySamplesBefore(now.AddDate(0, 0, -policy.RetentionDays))
+	if err != nil {
+		slog.Warn("清理净值采样失败", "error", err)
+	} else if removed > 0 {
+		slog.Info("已清理过期的净值采样", "removed", removed)
+	}
+}
+
+// StartDecisionLogCompaction 定期压缩和清理决策日志（阻塞，调用方使用 go 启动）
+// 每次运行都重新读取 decision_log_keep_days 和 decision_log_gzip_days，过期的净值采样一并清理
+func (tm *TraderManager) StartDecisionLogCompaction(database *config.Database, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result := CompactDecisionLogs(decisionLogRoot, loadRetentionPolicy(database), time.Now())
+		if result.Compressed > 0 || result.Removed > 0 {
+			slog.Info("已整理决策日志", "compressed", result.Compressed, "removed", result.Removed, "archived_points", result.Archived)
+		}
+		pruneEquitySamples(database, time.Now())
+		<-ticker.C
+	}
+}
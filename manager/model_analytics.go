@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"nofx/logger"
+	"sort"
+)
+
+// ModelStats 使用同一AI模型的全部交易员的表现汇总
+type ModelStats struct {
+	Model                string  `json:"model"` // AI模型提供商（deepseek、qwen、custom 等）
+	Traders              int     `json:"traders"`
+	MedianReturnPct      float64 `json:"median_return_pct"`
+	BestReturnPct        float64 `json:"best_return_pct"`
+	WorstReturnPct       float64 `json:"worst_return_pct"`
+	MedianMaxDrawdownPct float64 `json:"median_max_drawdown_pct"`
+	MedianTradesPerDay   float64 `json:"median_trades_per_day"`
+	MedianWinRate        float64 `json:"median_win_rate"`
+	TotalTrades          int     `json:"total_trades"`
+}
+
+// GetModelAnalytics 按AI模型汇总全部交易员的表现（与竞赛数据共用缓存，账户数据获取失败的交易员不计入）
+func (tm *TraderManager) GetModelAnalytics() []ModelStats {
+	return aggregateModelStats(tm.competitionTraders())
+}
+
+// aggregateModelStats 按 ai_model 分组计算中位数等统计，按收益率中位数从高到低排列
+func aggregateModelStats(traders []map[string]interface{}) []ModelStats {
+	type samples struct {
+		returns, drawdowns, frequency, winRates []float64
+		trades                                  int
+	}
+	groups := make(map[string]*samples)
+	for _, data := range traders {
+		if _, failed := data["error"]; failed {
+			continue
+		}
+		model, _ := data["ai_model"].(string)
+		if model == "" {
+			continue
+		}
+		g := groups[model]
+		if g == nil {
+			g = &samples{}
+			groups[model] = g
+		}
+		pnlPct, _ := data["total_pnl_pct"].(float64)
+		g.returns = append(g.returns, pnlPct)
+		if metrics, ok := data["metrics"].(*logger.TradeMetrics); ok && metrics != nil {
+			g.drawdowns = append(g.drawdowns, metrics.MaxDrawdownPct)
+			g.frequency = append(g.frequency, metrics.TradesPerDay)
+			if metrics.TotalTrades > 0 {
+				g.winRates = append(g.winRates, metrics.WinRate)
+			}
+			g.trades += metrics.TotalTrades
+		}
+	}
+
+	stats := make([]ModelStats, 0, len(groups))
+	for model, g := range groups {
+		sort.Float64s(g.returns)
+		stats = append(stats, ModelStats{
+			Model:                model,
+			Traders:              len(g.returns),
+			MedianReturnPct:      median(g.returns),
+			BestReturnPct:        g.returns[len(g.returns)-1],
+			WorstReturnPct:       g.returns[0],
+			MedianMaxDrawdownPct: median(g.drawdowns),
+			MedianTradesPerDay:   median(g.frequency),
+			MedianWinRate:        median(g.winRates),
+			TotalTrades:          g.trades,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].MedianReturnPct != stats[j].MedianReturnPct {
+			return stats[i].MedianReturnPct > stats[j].MedianReturnPct
+		}
+		return stats[i].Model < stats[j].Model
+	})
+	return stats
+}
+
+// median 中位数（不修改 values），为空时返回0
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,31 @@
+package manager
+
+import (
+	"nofx/logger"
+	"testing"
+)
+
+func TestAggregateModelStats(t *testing.T) {
+	traders := []map[string]interface{}{
+		{"ai_model": "deepseek", "total_pnl_pct": 10.0, "metrics": &logger.TradeMetrics{TotalTrades: 4, WinRate: 50, MaxDrawdownPct: 5, TradesPerDay: 2}},
+		{"ai_model": "deepseek", "total_pnl_pct": -4.0, "metrics": &logger.TradeMetrics{TotalTrades: 2, WinRate: 0, MaxDrawdownPct: 8, TradesPerDay: 1}},
+		{"ai_model": "deepseek", "total_pnl_pct": 2.0, "metrics": &logger.TradeMetrics{MaxDrawdownPct: 1}},
+		{"ai_model": "qwen", "total_pnl_pct": 6.0, "metrics": &logger.TradeMetrics{TotalTrades: 1, WinRate: 100, MaxDrawdownPct: 3, TradesPerDay: 1}},
+		{"ai_model": "qwen", "total_pnl_pct": 0.0, "error": "获取超时"},
+	}
+
+	stats := aggregateModelStats(traders)
+	if len(stats) != 2 || stats[0].Model != "qwen" {
+		t.Fatalf("应按收益率中位数排序: %+v", stats)
+	}
+	if qwen := stats[0]; qwen.Traders != 1 || qwen.MedianReturnPct != 6 {
+		t.Errorf("获取失败的交易员不应计入: %+v", qwen)
+	}
+	ds := stats[1]
+	if ds.Traders != 3 || ds.MedianReturnPct != 2 || ds.BestReturnPct != 10 || ds.WorstReturnPct != -4 {
+		t.Errorf("deepseek 收益统计错误: %+v", ds)
+	}
+	if ds.MedianMaxDrawdownPct != 5 || ds.MedianTradesPerDay != 1 || ds.MedianWinRate != 25 || ds.TotalTrades != 6 {
+		t.Errorf("deepseek 回撤/频率/胜率统计错误: %+v", ds)
+	}
+}
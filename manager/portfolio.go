@@ -0,0 +1,310 @@
+package manager
+
+import (
+	"fmt"
+	"math"
+	"nofx/market"
+	"sort"
+)
+
+// PortfolioPosition 组合中的单个持仓
+type PortfolioPosition struct {
+	TraderID         string  `json:"trader_id"`
+	Symbol           string  `json:"symbol"`
+	Side             string  `json:"side"` // long / short
+	Quantity         float64 `json:"quantity"`
+	MarkPrice        float64 `json:"mark_price"`
+	LiquidationPrice float64 `json:"liquidation_price"`
+	Leverage         int     `json:"leverage"`
+	MarginUsed       float64 `json:"margin_used"`
+	UnrealizedPnL    float64 `json:"unrealized_pnl"`
+}
+
+// PortfolioTrader 组合中单个交易员的账户概况
+type PortfolioTrader struct {
+	TraderID      string  `json:"trader_id"`
+	TraderName    string  `json:"trader_name"`
+	TotalEquity   float64 `json:"total_equity"`
+	MarginUsed    float64 `json:"margin_used"`
+	PositionCount int     `json:"position_count"`
+	Error         string  `json:"error,omitempty"` // 账户数据获取失败时的原因（不计入汇总）
+}
+
+// SymbolExposure 单个币种在所有交易员上的合计敞口（名义价值，USDT）
+type SymbolExposure struct {
+	Symbol         string   `json:"symbol"`
+	LongNotional   float64  `json:"long_notional"`
+	ShortNotional  float64  `json:"short_notional"`
+	NetNotional    float64  `json:"net_notional"`     // 多 - 空
+	NetExposurePct float64  `json:"net_exposure_pct"` // 净敞口 / 组合净值
+	Traders        []string `json:"traders"`
+}
+
+// CorrelatedExposure 高相关币对的同向敞口（实际风险接近同一个仓位）
+type CorrelatedExposure struct {
+	SymbolA          string  `json:"symbol_a"`
+	SymbolB          string  `json:"symbol_b"`
+	Correlation      float64 `json:"correlation"`
+	CombinedNotional float64 `json:"combined_notional"` // 两个币种按相关方向合并后的净敞口
+}
+
+// CorrelatedRisk 相关性风险
+type CorrelatedRisk struct {
+	Pairs                 []CorrelatedExposure `json:"pairs"`
+	BTCEquivalentExposure float64              `json:"btc_equivalent_exposure"` // Σ 净敞口 × 与BTC相关性（BTC自身为1）
+	AvgCorrelation        float64              `json:"avg_correlation"`
+}
+
+// LiquidationScenario 最坏情况：所有持仓同时打到强平价
+type LiquidationScenario struct {
+	NearestTraderID     string  `json:"nearest_trader_id"`
+	NearestSymbol       string  `json:"nearest_symbol"`
+	NearestSide         string  `json:"nearest_side"`
+	NearestDistancePct  float64 `json:"nearest_distance_pct"` // 离强平最近的持仓还需不利波动的百分比
+	LossIfAllLiquidated float64 `json:"loss_if_all_liquidated"`
+	LossPct             float64 `json:"loss_pct"` // 占组合净值
+}
+
+// Portfolio 用户所有交易员的组合风险视图
+type Portfolio struct {
+	TotalEquity   float64              `json:"total_equity"`
+	MarginUsed    float64              `json:"margin_used"`
+	MarginUsedPct float64              `json:"margin_used_pct"`
+	UnrealizedPnL float64              `json:"unrealized_pnl"`
+	GrossExposure float64              `json:"gross_exposure"` // Σ|名义价值|
+	NetExposure   float64              `json:"net_exposure"`   // Σ多 - Σ空
+	Leverage      float64              `json:"leverage"`       // 总敞口 / 组合净值
+	Traders       []PortfolioTrader    `json:"traders"`
+	Exposures     []SymbolExposure     `json:"exposures"` // 按总敞口降序
+	Correlation   *CorrelatedRisk      `json:"correlation,omitempty"`
+	WorstCase     *LiquidationScenario `json:"worst_case,omitempty"`
+	Positions     []PortfolioPosition  `json:"positions"`
+}
+
+// GetPortfolio 汇总指定交易员（通常是同一用户的全部交易员）的组合风险，未加载到内存的交易员会被跳过
+func (tm *TraderManager) GetPortfolio(traderIDs []string) (*Portfolio, error) {
+	traders, positions := tm.collectPositions(traderIDs)
+	if len(traders) == 0 {
+		return nil, fmt.Errorf("没有运行中的交易员")
+	}
+
+	var correlation *market.CorrelationSummary
+	if symbols := positionSymbols(positions); len(symbols) > 0 {
+		correlation = market.CalculateCorrelationSummary(symbols)
+	}
+	return BuildPortfolio(traders, positions, correlation), nil
+}
+
+// collectPositions 获取各交易员的账户概况和持仓，未加载到内存的交易员会被跳过
+func (tm *TraderManager) collectPositions(traderIDs []string) ([]PortfolioTrader, []PortfolioPosition) {
+	traders := make([]PortfolioTrader, 0, len(traderIDs))
+	var positions []PortfolioPosition
+
+	for _, id := range traderIDs {
+		t, err := tm.GetTrader(id)
+		if err != nil {
+			continue
+		}
+		entry := PortfolioTrader{TraderID: t.GetID(), TraderName: t.GetName()}
+
+		account, err := t.GetAccountInfo()
+		if err != nil {
+			t.Logger().Warn("组合风险：获取交易员账户信息失败", "error", err)
+			entry.Error = "账户数据获取失败"
+			traders = append(traders, entry)
+			continue
+		}
+		traderPositions, err := t.GetPositions()
+		if err != nil {
+			t.Logger().Warn("组合风险：获取交易员持仓失败", "error", err)
+			entry.Error = "持仓数据获取失败"
+			traders = append(traders, entry)
+			continue
+		}
+
+		entry.TotalEquity, _ = account["total_equity"].(float64)
+		for _, pos := range traderPositions {
+			p := toPortfolioPosition(id, pos)
+			entry.MarginUsed += p.MarginUsed
+			entry.PositionCount++
+			positions = append(positions, p)
+		}
+		traders = append(traders, entry)
+	}
+	return traders, positions
+}
+
+// toPortfolioPosition 转换 AutoTrader.GetPositions 返回的持仓
+func toPortfolioPosition(traderID string, pos map[string]interface{}) PortfolioPosition {
+	p := PortfolioPosition{TraderID: traderID}
+	p.Symbol, _ = pos["symbol"].(string)
+	p.Side, _ = pos["side"].(string)
+	p.Quantity, _ = pos["quantity"].(float64)
+	p.MarkPrice, _ = pos["mark_price"].(float64)
+	p.LiquidationPrice, _ = pos["liquidation_price"].(float64)
+	p.Leverage, _ = pos["leverage"].(int)
+	p.MarginUsed, _ = pos["margin_used"].(float64)
+	p.UnrealizedPnL, _ = pos["unrealized_pnl"].(float64)
+	return p
+}
+
+// positionSymbols 持仓涉及的币种（去重）
+func positionSymbols(positions []PortfolioPosition) []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, p := range positions {
+		if !seen[p.Symbol] {
+			seen[p.Symbol] = true
+			symbols = append(symbols, p.Symbol)
+		}
+	}
+	return symbols
+}
+
+// BuildPortfolio 根据各交易员账户和持仓计算组合敞口、相关性风险和最坏强平情景
+func BuildPortfolio(traders []PortfolioTrader, positions []PortfolioPosition, correlation *market.CorrelationSummary) *Portfolio {
+	p := &Portfolio{
+		Traders:   traders,
+		Exposures: []SymbolExposure{},
+		Positions: positions,
+	}
+	if p.Positions == nil {
+		p.Positions = []PortfolioPosition{}
+	}
+	for _, t := range traders {
+		if t.Error == "" {
+			p.TotalEquity += t.TotalEquity
+		}
+	}
+
+	exposures := make(map[string]*SymbolExposure)
+	for _, pos := range positions {
+		notional := pos.Quantity * pos.MarkPrice
+		p.MarginUsed += pos.MarginUsed
+		p.UnrealizedPnL += pos.UnrealizedPnL
+		p.GrossExposure += notional
+
+		e, ok := exposures[pos.Symbol]
+		if !ok {
+			e = &SymbolExposure{Symbol: pos.Symbol}
+			exposures[pos.Symbol] = e
+		}
+		if pos.Side == "short" {
+			e.ShortNotional += notional
+		} else {
+			e.LongNotional += notional
+		}
+		if !containsString(e.Traders, pos.TraderID) {
+			e.Traders = append(e.Traders, pos.TraderID)
+		}
+	}
+
+	for _, e := range exposures {
+		e.NetNotional = e.LongNotional - e.ShortNotional
+		if p.TotalEquity > 0 {
+			e.NetExposurePct = e.NetNotional / p.TotalEquity * 100
+		}
+		p.NetExposure += e.NetNotional
+		p.Exposures = append(p.Exposures, *e)
+	}
+	sort.Slice(p.Exposures, func(i, j int) bool {
+		gi := p.Exposures[i].LongNotional + p.Exposures[i].ShortNotional
+		gj := p.Exposures[j].LongNotional + p.Exposures[j].ShortNotional
+		if gi != gj {
+			return gi > gj
+		}
+		return p.Exposures[i].Symbol < p.Exposures[j].Symbol
+	})
+
+	if p.TotalEquity > 0 {
+		p.MarginUsedPct = p.MarginUsed / p.TotalEquity * 100
+		p.Leverage = p.GrossExposure / p.TotalEquity
+	}
+	if correlation != nil {
+		p.Correlation = correlatedRisk(exposures, correlation)
+	}
+	p.WorstCase = worstCaseLiquidation(positions, p.TotalEquity)
+	return p
+}
+
+// correlatedRisk 找出高相关且同向（负相关则反向）的敞口，并折算BTC等效敞口
+func correlatedRisk(exposures map[string]*SymbolExposure, correlation *market.CorrelationSummary) *CorrelatedRisk {
+	risk := &CorrelatedRisk{
+		Pairs:          []CorrelatedExposure{},
+		AvgCorrelation: correlation.AvgCorrelation,
+	}
+
+	for _, pair := range correlation.HighlyCorrelated {
+		a, okA := exposures[pair.SymbolA]
+		b, okB := exposures[pair.SymbolB]
+		if !okA || !okB || a.NetNotional == 0 || b.NetNotional == 0 {
+			continue
+		}
+		// 正相关同向、负相关反向时风险叠加
+		if a.NetNotional*b.NetNotional*pair.Correlation <= 0 {
+			continue
+		}
+		risk.Pairs = append(risk.Pairs, CorrelatedExposure{
+			SymbolA:          pair.SymbolA,
+			SymbolB:          pair.SymbolB,
+			Correlation:      pair.Correlation,
+			CombinedNotional: math.Abs(a.NetNotional) + math.Abs(b.NetNotional),
+		})
+	}
+
+	for symbol, e := range exposures {
+		if symbol == "BTCUSDT" {
+			risk.BTCEquivalentExposure += e.NetNotional
+		} else if corr, ok := correlation.BTCCorrelation[symbol]; ok {
+			risk.BTCEquivalentExposure += e.NetNotional * corr
+		}
+	}
+	return risk
+}
+
+// worstCaseLiquidation 计算离强平最近的持仓，以及所有持仓同时打到强平价的总亏损
+func worstCaseLiquidation(positions []PortfolioPosition, totalEquity float64) *LiquidationScenario {
+	var scenario *LiquidationScenario
+	totalLoss := 0.0
+	for _, pos := range positions {
+		if pos.LiquidationPrice <= 0 || pos.MarkPrice <= 0 {
+			continue
+		}
+		distance := (pos.MarkPrice - pos.LiquidationPrice) / pos.MarkPrice * 100
+		if pos.Side == "short" {
+			distance = -distance
+		}
+		if distance < 0 {
+			distance = 0
+		}
+		totalLoss += math.Abs(pos.MarkPrice-pos.LiquidationPrice) * pos.Quantity
+
+		if scenario == nil || distance < scenario.NearestDistancePct {
+			scenario = &LiquidationScenario{
+				NearestTraderID:    pos.TraderID,
+				NearestSymbol:      pos.Symbol,
+				NearestSide:        pos.Side,
+				NearestDistancePct: distance,
+			}
+		}
+	}
+	if scenario == nil {
+		return nil
+	}
+
+	scenario.LossIfAllLiquidated = totalLoss
+	if totalEquity > 0 {
+		scenario.LossPct = totalLoss / totalEquity * 100
+	}
+	return scenario
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
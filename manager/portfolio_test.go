@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"math"
+	"nofx/market"
+	"testing"
+)
+
+func TestBuildPortfolio(t *testing.T) {
+	traders := []PortfolioTrader{
+		{TraderID: "a", TotalEquity: 1000},
+		{TraderID: "b", TotalEquity: 1000},
+		{TraderID: "c", Error: "账户数据获取失败"},
+	}
+	positions := []PortfolioPosition{
+		{TraderID: "a", Symbol: "BTCUSDT", Side: "long", Quantity: 0.05, MarkPrice: 60000, LiquidationPrice: 54000, MarginUsed: 300},
+		{TraderID: "b", Symbol: "BTCUSDT", Side: "short", Quantity: 0.01, MarkPrice: 60000, LiquidationPrice: 66000, MarginUsed: 60},
+		{TraderID: "b", Symbol: "ETHUSDT", Side: "long", Quantity: 1, MarkPrice: 3000, LiquidationPrice: 2850, MarginUsed: 150},
+	}
+	correlation := &market.CorrelationSummary{
+		HighlyCorrelated: []market.CorrelationPair{{SymbolA: "BTCUSDT", SymbolB: "ETHUSDT", Correlation: 0.9}},
+		BTCCorrelation:   map[string]float64{"ETHUSDT": 0.9},
+	}
+
+	p := BuildPortfolio(traders, positions, correlation)
+
+	if p.TotalEquity != 2000 {
+		t.Errorf("失败的交易员不应计入净值: %.2f", p.TotalEquity)
+	}
+	if p.MarginUsed != 510 || math.Abs(p.MarginUsedPct-25.5) > 1e-9 {
+		t.Errorf("保证金汇总错误: %.2f (%.2f%%)", p.MarginUsed, p.MarginUsedPct)
+	}
+	if p.GrossExposure != 6600 || p.NetExposure != 5400 {
+		t.Errorf("敞口错误: gross %.2f net %.2f", p.GrossExposure, p.NetExposure)
+	}
+
+	if len(p.Exposures) != 2 || p.Exposures[0].Symbol != "BTCUSDT" {
+		t.Fatalf("敞口应按总敞口降序: %+v", p.Exposures)
+	}
+	btc := p.Exposures[0]
+	if btc.LongNotional != 3000 || btc.ShortNotional != 600 || btc.NetNotional != 2400 || len(btc.Traders) != 2 {
+		t.Errorf("BTC敞口错误: %+v", btc)
+	}
+
+	if len(p.Correlation.Pairs) != 1 || p.Correlation.Pairs[0].CombinedNotional != 5400 {
+		t.Errorf("同向高相关敞口错误: %+v", p.Correlation.Pairs)
+	}
+	if math.Abs(p.Correlation.BTCEquivalentExposure-(2400+3000*0.9)) > 1e-9 {
+		t.Errorf("BTC等效敞口错误: %.2f", p.Correlation.BTCEquivalentExposure)
+	}
+
+	wc := p.WorstCase
+	if wc == nil || wc.NearestSymbol != "ETHUSDT" || math.Abs(wc.NearestDistancePct-5) > 1e-9 {
+		t.Fatalf("最近强平持仓错误: %+v", wc)
+	}
+	// 300 + 60 + 150
+	if math.Abs(wc.LossIfAllLiquidated-510) > 1e-9 || math.Abs(wc.LossPct-25.5) > 1e-9 {
+		t.Errorf("最坏情景亏损错误: %.2f (%.2f%%)", wc.LossIfAllLiquidated, wc.LossPct)
+	}
+}
+
+func TestBuildPortfolioOppositeCorrelatedExposure(t *testing.T) {
+	positions := []PortfolioPosition{
+		{TraderID: "a", Symbol: "BTCUSDT", Side: "long", Quantity: 0.01, MarkPrice: 60000},
+		{TraderID: "a", Symbol: "ETHUSDT", Side: "short", Quantity: 0.2, MarkPrice: 3000},
+	}
+	correlation := &market.CorrelationSummary{
+		HighlyCorrelated: []market.CorrelationPair{{SymbolA: "BTCUSDT", SymbolB: "ETHUSDT", Correlation: 0.9}},
+	}
+
+	p := BuildPortfolio([]PortfolioTrader{{TraderID: "a", TotalEquity: 1000}}, positions, correlation)
+	if len(p.Correlation.Pairs) != 0 {
+		t.Errorf("正相关币种反向持仓是对冲，不应计入风险: %+v", p.Correlation.Pairs)
+	}
+	if p.WorstCase != nil {
+		t.Errorf("没有强平价时不应生成最坏情景: %+v", p.WorstCase)
+	}
+}
@@ -0,0 +1,150 @@
+package manager
+
+import (
+	"log/slog"
+	"nofx/config"
+	"nofx/trader"
+	"time"
+)
+
+// 恢复结果
+const (
+	ResumeResumed = "resumed"
+	ResumeSkipped = "skipped" // 用户已禁用、交易员已在运行等
+	ResumeFailed  = "failed"  // 交易员未能加载（AI模型或交易所配置无效）
+)
+
+// ResumeResult 单个交易员的恢复结果
+type ResumeResult struct {
+	TraderID string    `json:"trader_id"`
+	UserID   string    `json:"user_id"`
+	Name     string    `json:"name"`
+	Status   string    `json:"status"`
+	Reason   string    `json:"reason,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// ResumeReport 启动时恢复运行中交易员的报告
+type ResumeReport struct {
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"` // 为空表示还在按间隔逐个启动
+	Stagger    string         `json:"stagger"`
+	Resumed    int            `json:"resumed"`
+	Skipped    int            `json:"skipped"`
+	Failed     int            `json:"failed"`
+	Results    []ResumeResult `json:"results"`
+}
+
+// GetResumeReport 最近一次启动恢复的报告副本，未执行过时返回nil
+func (tm *TraderManager) GetResumeReport() *ResumeReport {
+	tm.resumeMu.Lock()
+	defer tm.resumeMu.Unlock()
+	if tm.resumeReport == nil {
+		return nil
+	}
+	report := *tm.resumeReport
+	report.Results = append([]ResumeResult(nil), tm.resumeReport.Results...)
+	return &report
+}
+
+// addResumeResult 记录一个交易员的恢复结果
+func (tm *TraderManager) addResumeResult(r ResumeResult) {
+	r.Time = time.Now()
+	tm.resumeMu.Lock()
+	defer tm.resumeMu.Unlock()
+	switch r.Status {
+	case ResumeResumed:
+		tm.resumeReport.Resumed++
+	case ResumeSkipped:
+		tm.resumeReport.Skipped++
+	case ResumeFailed:
+		tm.resumeReport.Failed++
+	}
+	tm.resumeReport.Results = append(tm.resumeReport.Results, r)
+}
+
+// ResumeRunningTraders 重启前处于运行状态的交易员逐个恢复运行，相邻两个间隔 stagger，
+// 避免同时对账和调用AI；无法恢复的交易员在数据库中标记为已停止。阻塞到全部处理完
+func (tm *TraderManager) ResumeRunningTraders(database *config.Database, stagger time.Duration) error {
+	records, err := database.GetRunningTraders()
+	if err != nil {
+		return err
+	}
+
+	tm.resumeMu.Lock()
+	tm.resumeReport = &ResumeReport{StartedAt: time.Now(), Stagger: stagger.String(), Results: []ResumeResult{}}
+	tm.resumeMu.Unlock()
+	slog.Info("恢复重启前运行中的交易员", "count", len(records), "stagger", stagger)
+
+	started := 0
+	for _, record := range records {
+		result := ResumeResult{TraderID: record.ID, UserID: record.UserID, Name: record.Name}
+		at, status, reason := tm.checkResumable(database, record)
+		if at == nil {
+			result.Status, result.Reason = status, reason
+			if err := database.UpdateTraderStatus(record.UserID, record.ID, false); err != nil {
+				traderLog(record).Warn("更新交易员状态失败", "error", err)
+			}
+			traderLog(record).Warn("交易员未恢复", "reason", reason)
+			tm.addResumeResult(result)
+			continue
+		}
+		if at.IsRunning() {
+			// 恢复过程中已被用户手动启动
+			result.Status, result.Reason = ResumeSkipped, "已在运行"
+			tm.addResumeResult(result)
+			continue
+		}
+
+		if started > 0 && stagger > 0 {
+			time.Sleep(stagger)
+		}
+		at.Logger().Info("恢复交易员")
+		if !tm.startTrader(database, at) {
+			slog.Info("服务正在退出，停止恢复交易员")
+			break
+		}
+		started++
+		result.Status = ResumeResumed
+		tm.addResumeResult(result)
+	}
+
+	tm.resumeMu.Lock()
+	now := time.Now()
+	report := tm.resumeReport
+	report.FinishedAt = &now
+	slog.Info("交易员恢复完成", "resumed", report.Resumed, "skipped", report.Skipped, "failed", report.Failed)
+	tm.resumeMu.Unlock()
+	return nil
+}
+
+// checkResumable 返回可以恢复的交易员；不能恢复时返回nil以及结果状态和原因
+func (tm *TraderManager) checkResumable(database *config.Database, record *config.TraderRecord) (*trader.AutoTrader, string, string) {
+	if user, err := database.GetUserByID(record.UserID); err != nil || user.Disabled {
+		return nil, ResumeSkipped, "用户不存在或已被禁用"
+	}
+	at, err := tm.GetTrader(record.ID)
+	if err != nil {
+		return nil, ResumeFailed, "交易员未能加载，请检查AI模型和交易所配置"
+	}
+	return at, "", ""
+}
+
+// startTrader 在后台运行交易员（启动恢复和自动重启共用）；服务已开始退出时不再启动并返回false
+func (tm *TraderManager) startTrader(database *config.Database, at *trader.AutoTrader) bool {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	if tm.closing {
+		return false
+	}
+
+	go func() {
+		if err := at.Run(); err != nil {
+			at.Logger().Error("交易员运行错误", "error", err)
+			if err := database.UpdateTraderStatus(at.GetUserID(), at.GetID(), false); err != nil {
+				at.Logger().Warn("更新交易员状态失败", "error", err)
+			}
+		}
+	}()
+	return true
+}
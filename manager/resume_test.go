@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"nofx/config"
+	"path/filepath"
+	"testing"
+)
+
+func TestResumeRunningTraders(t *testing.T) {
+	database, err := config.NewDatabase(filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, u := range []*config.User{{ID: "alice", Email: "alice@test.com"}, {ID: "bob", Email: "bob@test.com"}} {
+		if err := database.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := database.SetUserDisabled("bob", true); err != nil {
+		t.Fatal(err)
+	}
+	for _, tr := range []*config.TraderRecord{
+		{ID: "t-missing", UserID: "alice", Name: "未加载", IsRunning: true},
+		{ID: "t-disabled", UserID: "bob", Name: "禁用用户", IsRunning: true},
+		{ID: "t-stopped", UserID: "alice", Name: "已停止"},
+	} {
+		if err := database.CreateTrader(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tm := NewTraderManager()
+	if tm.GetResumeReport() != nil {
+		t.Fatal("未执行恢复时报告应为nil")
+	}
+	if err := tm.ResumeRunningTraders(database, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	report := tm.GetResumeReport()
+	if report == nil || report.FinishedAt == nil || len(report.Results) != 2 || report.Failed != 1 || report.Skipped != 1 || report.Resumed != 0 {
+		t.Fatalf("恢复报告错误: %+v", report)
+	}
+	for _, r := range report.Results {
+		want := map[string]string{"t-missing": ResumeFailed, "t-disabled": ResumeSkipped}[r.TraderID]
+		if r.Status != want || r.Reason == "" {
+			t.Errorf("%s: %+v，期望 %s", r.TraderID, r, want)
+		}
+	}
+	if running, _ := database.GetRunningTraders(); len(running) != 0 {
+		t.Errorf("无法恢复的交易员应标记为已停止: %d 个仍在运行", len(running))
+	}
+}
@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"fmt"
+	"nofx/config"
+	"nofx/trader"
+)
+
+// SetTraderSchedule 更新已加载交易员的交易时段（nil 表示全天运行），交易员未加载时忽略
+func (tm *TraderManager) SetTraderSchedule(traderID string, schedule *trader.Schedule) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	if t, ok := tm.traders[traderID]; ok {
+		t.SetSchedule(schedule)
+	}
+}
+
+// applySchedulesLocked 把数据库中的交易时段同步到已加载的交易员，调用方需已持有 tm.mu
+func (tm *TraderManager) applySchedulesLocked(database *config.Database) error {
+	schedules, err := database.GetAllTraderSchedules()
+	if err != nil {
+		return fmt.Errorf("获取交易时段失败: %w", err)
+	}
+	for _, s := range schedules {
+		t, ok := tm.traders[s.TraderID]
+		if !ok {
+			continue
+		}
+		schedule, err := trader.ParseSchedule(s.Spec, s.Timezone)
+		if err != nil {
+			// 保存时已校验，这里只可能是系统缺少时区数据
+			t.Logger().Warn("交易时段无效，按全天运行", "schedule", s.Spec, "timezone", s.Timezone, "error", err)
+			continue
+		}
+		t.SetSchedule(schedule)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+	"nofx/config"
+	"nofx/trader"
+	"sort"
+	"sync"
+	"time"
+)
+
+// seasonCacheTTL 进行中赛季排名的缓存时间（与竞赛数据一致）
+const seasonCacheTTL = 30 * time.Second
+
+type seasonCacheEntry struct {
+	results   []config.SeasonResult
+	timestamp time.Time
+}
+
+// seasonCache 进行中赛季的排名缓存（计算需要读取所有交易员的决策日志）
+type seasonCache struct {
+	entries map[int64]seasonCacheEntry
+	mu      sync.Mutex
+}
+
+// seasonStanding 从决策日志计算交易员在 [start, end) 内的区间收益，区间内没有记录时返回 false
+func seasonStanding(at *trader.AutoTrader, start, end time.Time) (config.SeasonResult, bool) {
+	records, err := at.GetDecisionLogger().GetRecordsBetween(start, end)
+	if err != nil {
+		at.Logger().Warn("读取交易员的决策日志失败", "error", err)
+		return config.SeasonResult{}, false
+	}
+	// GetRecordsBetween 包含右端点，赛季区间不包含
+	for len(records) > 0 && !records[len(records)-1].Timestamp.Before(end) {
+		records = records[:len(records)-1]
+	}
+	if len(records) == 0 || records[0].AccountState.TotalBalance <= 0 {
+		return config.SeasonResult{}, false
+	}
+
+	startEquity := records[0].AccountState.TotalBalance
+	endEquity := records[len(records)-1].AccountState.TotalBalance
+	return config.SeasonResult{
+		TraderID:    at.GetID(),
+		TraderName:  at.GetName(),
+		AIModel:     at.GetAIModel(),
+		Exchange:    at.GetExchange(),
+		StartEquity: startEquity,
+		EndEquity:   endEquity,
+		PnL:         endEquity - startEquity,
+		PnLPct:      (endEquity - startEquity) / startEquity * 100,
+	}, true
+}
+
+// RankSeasonResults 按区间收益率降序排名
+func RankSeasonResults(results []config.SeasonResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].PnLPct != results[j].PnLPct {
+			return results[i].PnLPct > results[j].PnLPct
+		}
+		return results[i].TraderID < results[j].TraderID
+	})
+	for i := range results {
+		results[i].Rank = i + 1
+	}
+}
+
+// computeSeasonResults 按所有已加载交易员的决策日志计算赛季排名
+func (tm *TraderManager) computeSeasonResults(season *config.Season, now time.Time) []config.SeasonResult {
+	end := season.EndAt
+	if now.Before(end) {
+		end = now
+	}
+
+	tm.mu.RLock()
+	traders := make([]*trader.AutoTrader, 0, len(tm.traders))
+	for _, t := range tm.traders {
+		traders = append(traders, t)
+	}
+	tm.mu.RUnlock()
+
+	results := []config.SeasonResult{}
+	for _, t := range traders {
+		if standing, ok := seasonStanding(t, season.StartAt, end); ok {
+			results = append(results, standing)
+		}
+	}
+	RankSeasonResults(results)
+	return results
+}
+
+// GetSeasonLeaderboard 赛季排名：已归档的读取保存的最终排名，否则根据决策日志实时计算
+func (tm *TraderManager) GetSeasonLeaderboard(database *config.Database, season *config.Season) ([]config.SeasonResult, error) {
+	if season.Archived {
+		return database.GetSeasonResults(season.ID)
+	}
+
+	now := time.Now()
+	if now.Before(season.StartAt) {
+		return []config.SeasonResult{}, nil
+	}
+
+	tm.seasonCache.mu.Lock()
+	defer tm.seasonCache.mu.Unlock()
+	if entry, ok := tm.seasonCache.entries[season.ID]; ok && time.Since(entry.timestamp) < seasonCacheTTL {
+		return entry.results, nil
+	}
+	results := tm.computeSeasonResults(season, now)
+	tm.seasonCache.entries[season.ID] = seasonCacheEntry{results: results, timestamp: now}
+	return results, nil
+}
+
+// InvalidateSeason 赛季时间修改或删除后清除缓存
+func (tm *TraderManager) InvalidateSeason(seasonID int64) {
+	tm.seasonCache.mu.Lock()
+	defer tm.seasonCache.mu.Unlock()
+	delete(tm.seasonCache.entries, seasonID)
+}
+
+// ArchiveEndedSeasons 保存所有已结束但尚未归档赛季的最终排名
+func (tm *TraderManager) ArchiveEndedSeasons(database *config.Database, now time.Time) error {
+	seasons, err := database.GetSeasons()
+	if err != nil {
+		return fmt.Errorf("获取赛季列表失败: %w", err)
+	}
+	for _, season := range seasons {
+		if season.Archived || now.Before(season.EndAt) {
+			continue
+		}
+		results := tm.computeSeasonResults(season, now)
+		if err := database.ArchiveSeason(season.ID, results); err != nil {
+			return fmt.Errorf("归档赛季 %s 失败: %w", season.Name, err)
+		}
+		tm.InvalidateSeason(season.ID)
+		slog.Info("赛季已结束，归档交易员的最终排名", "season_id", season.ID, "season", season.Name, "trader_count", len(results))
+	}
+	return nil
+}
+
+// StartSeasonArchiver 定期归档已结束的赛季（阻塞，调用方使用 go 启动）
+func (tm *TraderManager) StartSeasonArchiver(database *config.Database, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := tm.ArchiveEndedSeasons(database, time.Now()); err != nil {
+			slog.Warn("归档赛季失败", "error", err)
+		}
+		<-ticker.C
+	}
+}
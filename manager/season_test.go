@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"nofx/config"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRankSeasonResults(t *testing.T) {
+	results := []config.SeasonResult{
+		{TraderID: "b", PnLPct: 5},
+		{TraderID: "c", PnLPct: 12},
+		{TraderID: "a", PnLPct: 5},
+	}
+	RankSeasonResults(results)
+
+	want := []string{"c", "a", "b"}
+	for i, r := range results {
+		if r.TraderID != want[i] || r.Rank != i+1 {
+			t.Errorf("第%d名应为 %s，实际 %+v", i+1, want[i], r)
+		}
+	}
+}
+
+func TestArchiveEndedSeasons(t *testing.T) {
+	database, err := config.NewDatabase(filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	now := time.Now().UTC()
+	ended := &config.Season{Name: "S1", StartAt: now.Add(-48 * time.Hour), EndAt: now.Add(-time.Hour)}
+	active := &config.Season{Name: "S2", StartAt: now.Add(-time.Hour), EndAt: now.Add(24 * time.Hour)}
+	for _, s := range []*config.Season{ended, active} {
+		if err := database.CreateSeason(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tm := NewTraderManager()
+	if err := tm.ArchiveEndedSeasons(database, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, _ := database.GetSeason(ended.ID); !s.Archived {
+		t.Error("已结束的赛季应归档")
+	}
+	if s, _ := database.GetSeason(active.ID); s.Archived {
+		t.Error("进行中的赛季不应归档")
+	}
+	if current, err := database.GetCurrentSeason(now); err != nil || current.ID != active.ID {
+		t.Errorf("当前赛季应为 S2: %+v %v", current, err)
+	}
+}
@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"nofx/config"
+	"nofx/pool"
+)
+
+// buildSignalSources 把数据库中的信号源配置转换为信号源，配置无效的记录跳过并记录日志
+func buildSignalSources(records []*config.TraderSignalSource) []pool.SignalSource {
+	sources := make([]pool.SignalSource, 0, len(records))
+	for _, r := range records {
+		var cfg pool.SourceConfig
+		if err := json.Unmarshal(r.Config, &cfg); err != nil {
+			slog.Warn("信号源配置无效，跳过", "trader_id", r.TraderID, "source", r.Name, "error", err)
+			continue
+		}
+		source, err := pool.NewSignalSource(r.ID, r.Name, cfg)
+		if err != nil {
+			slog.Warn("信号源配置无效，跳过", "trader_id", r.TraderID, "source", r.Name, "error", err)
+			continue
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// ReloadTraderSignalSources 从数据库重新读取交易员的信号源，交易员未加载时忽略
+func (tm *TraderManager) ReloadTraderSignalSources(database *config.Database, traderID string) error {
+	records, err := database.GetTraderSignalSources(traderID)
+	if err != nil {
+		return fmt.Errorf("获取信号源失败: %w", err)
+	}
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	if t, ok := tm.traders[traderID]; ok {
+		t.SetSignalSources(buildSignalSources(records))
+	}
+	return nil
+}
+
+// applySignalSourcesLocked 把数据库中的信号源同步到已加载的交易员，调用方需已持有 tm.mu
+func (tm *TraderManager) applySignalSourcesLocked(database *config.Database) error {
+	records, err := database.GetAllTraderSignalSources()
+	if err != nil {
+		return fmt.Errorf("获取信号源失败: %w", err)
+	}
+	byTrader := make(map[string][]*config.TraderSignalSource)
+	for _, r := range records {
+		byTrader[r.TraderID] = append(byTrader[r.TraderID], r)
+	}
+	for traderID, records := range byTrader {
+		if t, ok := tm.traders[traderID]; ok {
+			t.SetSignalSources(buildSignalSources(records))
+		}
+	}
+	return nil
+}
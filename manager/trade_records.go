@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"nofx/config"
+	"nofx/trader"
+)
+
+// dbOrderJournal 把交易员的订单状态变化写入数据库
+type dbOrderJournal struct {
+	database *config.Database
+	userID   string
+}
+
+func (j *dbOrderJournal) RecordOrder(u trader.OrderUpdate) error {
+	return j.database.RecordOrder(&config.Order{
+		ClientOrderID:   u.ClientOrderID,
+		TraderID:        u.TraderID,
+		UserID:          j.userID,
+		Symbol:          u.Symbol,
+		Action:          u.Action,
+		Type:            u.Type,
+		ExchangeOrderID: u.ExchangeOrderID,
+		Status:          u.Status,
+		Quantity:        u.Quantity,
+		Price:           u.Price,
+		FillPrice:       u.FillPrice,
+		StopPrice:       u.StopPrice,
+		Reason:          u.Reason,
+		UpdatedAt:       u.Time,
+	})
+}
+
+// dbPositionHistory 把交易员的平仓记录写入数据库
+type dbPositionHistory struct {
+	database *config.Database
+	userID   string
+}
+
+func (h *dbPositionHistory) RecordClosedPosition(p trader.ClosedPosition) error {
+	record := &config.ClosedPosition{
+		TraderID:       p.TraderID,
+		UserID:         h.userID,
+		Symbol:         p.Symbol,
+		Side:           p.Side,
+		EntryPrice:     p.EntryPrice,
+		ExitPrice:      p.ExitPrice,
+		Quantity:       p.Quantity,
+		Fees:           p.Fees,
+		FundingFee:     p.FundingFee,
+		PnL:            p.PnL,
+		RealizedPnL:    p.RealizedPnL,
+		RMultiple:      p.RMultiple,
+		HoldingMinutes: p.HoldingMinutes,
+		ExitReason:     p.ExitReason,
+		ClosedAt:       p.ClosedAt,
+	}
+	if !p.OpenedAt.IsZero() {
+		record.OpenedAt = &p.OpenedAt
+	}
+	return h.database.RecordClosedPosition(record)
+}
+
+// attachTradeRecords 让交易员的订单和平仓记录写入数据库（未从数据库加载时不记录）
+func (tm *TraderManager) attachTradeRecords(at *trader.AutoTrader) {
+	if tm.database == nil {
+		return
+	}
+	at.SetOrderJournal(&dbOrderJournal{database: tm.database, userID: at.GetUserID()})
+	at.SetPositionHistory(&dbPositionHistory{database: tm.database, userID: at.GetUserID()})
+}
@@ -4,37 +4,72 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"nofx/cache"
 	"nofx/config"
+	"nofx/decision"
 	"nofx/trader"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-// CompetitionCache 竞赛数据缓存
+// CompetitionCache 竞赛数据快照，由定时任务逐个交易员增量刷新
 type CompetitionCache struct {
-	data      map[string]interface{}
+	all       []map[string]interface{} // 全部交易员的数据（未排序，排名和模型对比共用）
+	entries   map[string]competitionEntry
 	timestamp time.Time
 	mu        sync.RWMutex
+	refreshMu sync.Mutex  // 同一时间只有一个刷新，其余请求等待后复用结果
+	store     cache.Store // 共享快照（Redis），为空时只用本进程的数据
 }
 
 // TraderManager 管理多个trader实例
 type TraderManager struct {
-	traders         map[string]*trader.AutoTrader // key: trader ID
+	traders          map[string]*trader.AutoTrader // key: trader ID
 	competitionCache *CompetitionCache
-	mu              sync.RWMutex
+	seasonCache      *seasonCache
+	follows          *followRegistry
+	heartbeats       *heartbeatTracker
+	aiScheduler      *AIScheduler
+	database         *config.Database  // 重新加载被移出内存的交易员时使用
+	evicted          map[string]string // 因空闲被移出内存的交易员 ID -> 用户ID
+	mu               sync.RWMutex
+	closing          bool // Shutdown 后不再启动交易员
+
+	accessMu   sync.Mutex
+	lastAccess map[string]time.Time // 交易员最近一次被访问的时间
+
+	resumeMu     sync.Mutex
+	resumeReport *ResumeReport // 启动时恢复交易员的报告
 }
 
 // NewTraderManager 创建trader管理器
 func NewTraderManager() *TraderManager {
 	return &TraderManager{
-		traders: make(map[string]*trader.AutoTrader),
-		competitionCache: &CompetitionCache{
-			data: make(map[string]interface{}),
-		},
+		traders:          make(map[string]*trader.AutoTrader),
+		competitionCache: &CompetitionCache{entries: make(map[string]competitionEntry)},
+		seasonCache:      &seasonCache{entries: make(map[int64]seasonCacheEntry)},
+		follows:          &followRegistry{followers: make(map[string]*trader.Follower)},
+		aiScheduler:      NewAIScheduler(defaultAICallLimit),
+		evicted:          make(map[string]string),
+		lastAccess:       make(map[string]time.Time),
+		heartbeats:       &heartbeatTracker{unhealthy: make(map[string]string), restartedAt: make(map[string]time.Time)},
+	}
+}
+
+// traderLog 带交易员上下文（trader_id、user_id）的结构化日志，用于交易员实例创建之前
+func traderLog(traderCfg *config.TraderRecord) *slog.Logger {
+	return slog.With("trader_id", traderCfg.ID, "user_id", traderCfg.UserID, "trader", traderCfg.Name)
+}
+
+// liquidityFilterOf 交易员的最低流动性要求（数据库中的门槛以百万USDT为单位）
+func liquidityFilterOf(traderCfg *config.TraderRecord) decision.LiquidityFilter {
+	return decision.LiquidityFilter{
+		Mode:         traderCfg.LiquidityFilter,
+		MinOIValue:   traderCfg.MinOIValueMillions * 1_000_000,
+		MinVolume24h: traderCfg.MinVolumeMillions * 1_000_000,
 	}
 }
 
@@ -42,6 +77,7 @@ func NewTraderManager() *TraderManager {
 func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
+	tm.database = database
 
 	// 获取所有用户
 	userIDs, err := database.GetAllUsers()
@@ -49,21 +85,21 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 		return fmt.Errorf("获取用户列表失败: %w", err)
 	}
 
-	log.Printf("📋 发现 %d 个用户，开始加载所有交易员配置...", len(userIDs))
+	slog.Info("开始加载所有交易员配置", "user_count", len(userIDs))
 
 	var allTraders []*config.TraderRecord
 	for _, userID := range userIDs {
 		// 获取每个用户的交易员
 		traders, err := database.GetTraders(userID)
 		if err != nil {
-			log.Printf("⚠️ 获取用户 %s 的交易员失败: %v", userID, err)
+			slog.Warn("获取用户的交易员失败", "user_id", userID, "error", err)
 			continue
 		}
-		log.Printf("📋 用户 %s: %d 个交易员", userID, len(traders))
+		slog.Info("用户交易员配置", "user_id", userID, "count", len(traders))
 		allTraders = append(allTraders, traders...)
 	}
 
-	log.Printf("📋 总共加载 %d 个交易员配置", len(allTraders))
+	slog.Info("交易员配置读取完成", "count", len(allTraders))
 
 	// 获取系统配置（不包含信号源，信号源现在为用户级别）
 	maxDailyLossStr, _ := database.GetSystemConfig("max_daily_loss")
@@ -91,7 +127,7 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 	var defaultCoins []string
 	if defaultCoinsStr != "" {
 		if err := json.Unmarshal([]byte(defaultCoinsStr), &defaultCoins); err != nil {
-			log.Printf("⚠️ 解析默认币种配置失败: %v，使用空列表", err)
+			slog.Warn("解析默认币种配置失败，使用空列表", "error", err)
 			defaultCoins = []string{}
 		}
 	}
@@ -101,7 +137,7 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 		// 获取AI模型配置（使用交易员所属的用户ID）
 		aiModels, err := database.GetAIModels(traderCfg.UserID)
 		if err != nil {
-			log.Printf("⚠️  获取AI模型配置失败: %v", err)
+			traderLog(traderCfg).Warn("获取AI模型配置失败", "error", err)
 			continue
 		}
 
@@ -118,26 +154,26 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 			for _, model := range aiModels {
 				if model.Provider == traderCfg.AIModelID {
 					aiModelCfg = model
-					log.Printf("⚠️  交易员 %s 使用旧版 provider 匹配: %s -> %s", traderCfg.Name, traderCfg.AIModelID, model.ID)
+					traderLog(traderCfg).Warn("交易员使用旧版 provider 匹配AI模型", "ai_model", traderCfg.AIModelID, "matched_model", model.ID)
 					break
 				}
 			}
 		}
 
 		if aiModelCfg == nil {
-			log.Printf("⚠️  交易员 %s 的AI模型 %s 不存在，跳过", traderCfg.Name, traderCfg.AIModelID)
+			traderLog(traderCfg).Warn("交易员的AI模型不存在，跳过", "ai_model", traderCfg.AIModelID)
 			continue
 		}
 
 		if !aiModelCfg.Enabled {
-			log.Printf("⚠️  交易员 %s 的AI模型 %s 未启用，跳过", traderCfg.Name, traderCfg.AIModelID)
+			traderLog(traderCfg).Warn("交易员的AI模型未启用，跳过", "ai_model", traderCfg.AIModelID)
 			continue
 		}
 
 		// 获取交易所配置（使用交易员所属的用户ID）
 		exchanges, err := database.GetExchanges(traderCfg.UserID)
 		if err != nil {
-			log.Printf("⚠️  获取交易所配置失败: %v", err)
+			traderLog(traderCfg).Warn("获取交易所配置失败", "error", err)
 			continue
 		}
 
@@ -150,12 +186,12 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 		}
 
 		if exchangeCfg == nil {
-			log.Printf("⚠️  交易员 %s 的交易所 %s 不存在，跳过", traderCfg.Name, traderCfg.ExchangeID)
+			traderLog(traderCfg).Warn("交易员的交易所不存在，跳过", "exchange", traderCfg.ExchangeID)
 			continue
 		}
 
 		if !exchangeCfg.Enabled {
-			log.Printf("⚠️  交易员 %s 的交易所 %s 未启用，跳过", traderCfg.Name, traderCfg.ExchangeID)
+			traderLog(traderCfg).Warn("交易员的交易所未启用，跳过", "exchange", traderCfg.ExchangeID)
 			continue
 		}
 
@@ -166,19 +202,25 @@ func (tm *TraderManager) LoadTradersFromDatabase(database *config.Database) erro
 			oiTopURL = userSignalSource.OITopURL
 		} else {
 			// 如果用户没有配置信号源，使用空字符串
-			log.Printf("🔍 用户 %s 暂未配置信号源", traderCfg.UserID)
+			traderLog(traderCfg).Debug("用户暂未配置信号源")
 		}
 
 		// 添加到TraderManager
 		err = tm.addTraderFromDB(traderCfg, aiModelCfg, exchangeCfg, coinPoolURL, oiTopURL, maxDailyLoss, maxDrawdown, stopTradingMinutes, defaultCoins)
 		if err != nil {
-			log.Printf("❌ 添加交易员 %s 失败: %v", traderCfg.Name, err)
+			traderLog(traderCfg).Error("添加交易员失败", "error", err)
 			continue
 		}
 	}
 
-	log.Printf("✓ 成功加载 %d 个交易员到内存", len(tm.traders))
-	return nil
+	slog.Info("交易员已加载到内存", "count", len(tm.traders))
+	if err := tm.applySchedulesLocked(database); err != nil {
+		slog.Warn("同步交易时段失败", "error", err)
+	}
+	if err := tm.applySignalSourcesLocked(database); err != nil {
+		slog.Warn("同步信号源失败", "error", err)
+	}
+	return tm.applyAllUserLimitsLocked(database)
 }
 
 // addTraderFromConfig 内部方法：从配置添加交易员（不加锁，因为调用方已加锁）
@@ -209,13 +251,14 @@ func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModel
 	var effectiveCoinPoolURL string
 	if traderCfg.UseCoinPool && coinPoolURL != "" {
 		effectiveCoinPoolURL = coinPoolURL
-		log.Printf("✓ 交易员 %s 启用 COIN POOL 信号源: %s", traderCfg.Name, coinPoolURL)
+		traderLog(traderCfg).Info("交易员启用 COIN POOL 信号源", "coin_pool_url", coinPoolURL)
 	}
 
 	// 构建AutoTraderConfig
 	traderConfig := trader.AutoTraderConfig{
 		ID:                    traderCfg.ID,
 		Name:                  traderCfg.Name,
+		UserID:                traderCfg.UserID,
 		AIModel:               aiModelCfg.Provider, // 使用provider作为模型标识
 		Exchange:              exchangeCfg.ID,      // 使用exchange ID
 		BinanceAPIKey:         "",
@@ -238,6 +281,9 @@ func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		IsCrossMargin:         traderCfg.IsCrossMargin,
 		DefaultCoins:          defaultCoins,
 		TradingCoins:          tradingCoins,
+		MaxCandidates:         traderCfg.MaxCandidates,
+		PoolRefreshInterval:   time.Duration(traderCfg.PoolRefreshMinutes) * time.Minute,
+		Liquidity:             liquidityFilterOf(traderCfg),
 		SystemPromptTemplate:  traderCfg.SystemPromptTemplate, // 系统提示词模板
 	}
 
@@ -272,14 +318,16 @@ func (tm *TraderManager) addTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		at.SetCustomPrompt(traderCfg.CustomPrompt)
 		at.SetOverrideBasePrompt(traderCfg.OverrideBasePrompt)
 		if traderCfg.OverrideBasePrompt {
-			log.Printf("✓ 已设置自定义交易策略prompt (覆盖基础prompt)")
+			traderLog(traderCfg).Info("已设置自定义交易策略prompt（覆盖基础prompt）")
 		} else {
-			log.Printf("✓ 已设置自定义交易策略prompt (补充基础prompt)")
+			traderLog(traderCfg).Info("已设置自定义交易策略prompt（补充基础prompt）")
 		}
 	}
 
+	tm.scheduleAICalls(at, aiModelCfg)
+	tm.attachTradeRecords(at)
 	tm.traders[traderCfg.ID] = at
-	log.Printf("✓ Trader '%s' (%s + %s) 已加载到内存", traderCfg.Name, aiModelCfg.Provider, exchangeCfg.ID)
+	traderLog(traderCfg).Info("交易员已加载到内存", "ai_provider", aiModelCfg.Provider, "exchange", exchangeCfg.ID)
 	return nil
 }
 
@@ -316,13 +364,14 @@ func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModel
 	var effectiveCoinPoolURL string
 	if traderCfg.UseCoinPool && coinPoolURL != "" {
 		effectiveCoinPoolURL = coinPoolURL
-		log.Printf("✓ 交易员 %s 启用 COIN POOL 信号源: %s", traderCfg.Name, coinPoolURL)
+		traderLog(traderCfg).Info("交易员启用 COIN POOL 信号源", "coin_pool_url", coinPoolURL)
 	}
 
 	// 构建AutoTraderConfig
 	traderConfig := trader.AutoTraderConfig{
 		ID:                    traderCfg.ID,
 		Name:                  traderCfg.Name,
+		UserID:                traderCfg.UserID,
 		AIModel:               aiModelCfg.Provider, // 使用provider作为模型标识
 		Exchange:              exchangeCfg.ID,      // 使用exchange ID
 		BinanceAPIKey:         "",
@@ -345,6 +394,9 @@ func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		IsCrossMargin:         traderCfg.IsCrossMargin,
 		DefaultCoins:          defaultCoins,
 		TradingCoins:          tradingCoins,
+		MaxCandidates:         traderCfg.MaxCandidates,
+		PoolRefreshInterval:   time.Duration(traderCfg.PoolRefreshMinutes) * time.Minute,
+		Liquidity:             liquidityFilterOf(traderCfg),
 	}
 
 	// 根据交易所类型设置API密钥
@@ -378,29 +430,49 @@ func (tm *TraderManager) AddTraderFromDB(traderCfg *config.TraderRecord, aiModel
 		at.SetCustomPrompt(traderCfg.CustomPrompt)
 		at.SetOverrideBasePrompt(traderCfg.OverrideBasePrompt)
 		if traderCfg.OverrideBasePrompt {
-			log.Printf("✓ 已设置自定义交易策略prompt (覆盖基础prompt)")
+			traderLog(traderCfg).Info("已设置自定义交易策略prompt（覆盖基础prompt）")
 		} else {
-			log.Printf("✓ 已设置自定义交易策略prompt (补充基础prompt)")
+			traderLog(traderCfg).Info("已设置自定义交易策略prompt（补充基础prompt）")
 		}
 	}
 
+	tm.scheduleAICalls(at, aiModelCfg)
+	tm.attachTradeRecords(at)
 	tm.traders[traderCfg.ID] = at
-	log.Printf("✓ Trader '%s' (%s + %s) 已添加", traderCfg.Name, aiModelCfg.Provider, exchangeCfg.ID)
+	traderLog(traderCfg).Info("交易员已添加", "ai_provider", aiModelCfg.Provider, "exchange", exchangeCfg.ID)
 	return nil
 }
 
 // GetTrader 获取指定ID的trader
+// 因空闲被移出内存的交易员会从数据库重新加载
 func (tm *TraderManager) GetTrader(id string) (*trader.AutoTrader, error) {
 	tm.mu.RLock()
-	defer tm.mu.RUnlock()
-
 	t, exists := tm.traders[id]
+	tm.mu.RUnlock()
+	if !exists && tm.reloadEvicted(id) {
+		tm.mu.RLock()
+		t, exists = tm.traders[id]
+		tm.mu.RUnlock()
+	}
 	if !exists {
 		return nil, fmt.Errorf("trader ID '%s' 不存在", id)
 	}
+	tm.touchTrader(id, time.Now())
 	return t, nil
 }
 
+// RemoveTrader 从内存移除交易员（交易员已从数据库删除时使用，调用方负责先停止）
+func (tm *TraderManager) RemoveTrader(id string) {
+	tm.mu.Lock()
+	delete(tm.traders, id)
+	delete(tm.evicted, id)
+	tm.mu.Unlock()
+
+	tm.accessMu.Lock()
+	delete(tm.lastAccess, id)
+	tm.accessMu.Unlock()
+}
+
 // GetAllTraders 获取所有trader
 func (tm *TraderManager) GetAllTraders() map[string]*trader.AutoTrader {
 	tm.mu.RLock()
@@ -430,12 +502,12 @@ func (tm *TraderManager) StartAll() {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	log.Println("🚀 启动所有Trader...")
+	slog.Info("启动所有交易员")
 	for id, t := range tm.traders {
 		go func(traderID string, at *trader.AutoTrader) {
-			log.Printf("▶️  启动 %s...", at.GetName())
+			at.Logger().Info("启动交易员")
 			if err := at.Run(); err != nil {
-				log.Printf("❌ %s 运行错误: %v", at.GetName(), err)
+				at.Logger().Error("交易员运行错误", "error", err)
 			}
 		}(id, t)
 	}
@@ -446,12 +518,47 @@ func (tm *TraderManager) StopAll() {
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 
-	log.Println("⏹  停止所有Trader...")
+	slog.Info("停止所有交易员")
 	for _, t := range tm.traders {
 		t.Stop()
 	}
 }
 
+// Shutdown 进程退出前调用：把运行中的trader标记为运行状态（下次启动时恢复），再通知停止并等待当前周期执行完
+// 未运行的trader不改写数据库，以免把还没来得及恢复的trader标记为已停止
+func (tm *TraderManager) Shutdown(ctx context.Context, database *config.Database) error {
+	tm.mu.Lock()
+	tm.closing = true
+	var running []*trader.AutoTrader
+	for _, t := range tm.traders {
+		if t.IsRunning() {
+			running = append(running, t)
+		}
+	}
+	tm.mu.Unlock()
+
+	for _, t := range running {
+		if err := database.UpdateTraderStatus(t.GetUserID(), t.GetID(), true); err != nil {
+			t.Logger().Warn("保存交易员运行状态失败", "error", err)
+		}
+	}
+
+	slog.Info("停止运行中的交易员，等待当前周期结束", "count", len(running))
+	for _, t := range running {
+		t.Stop()
+	}
+	var unfinished []string
+	for _, t := range running {
+		if err := t.Wait(ctx); err != nil {
+			unfinished = append(unfinished, t.GetName())
+		}
+	}
+	if len(unfinished) > 0 {
+		return fmt.Errorf("等待交易员结束超时: %s", strings.Join(unfinished, ", "))
+	}
+	return nil
+}
+
 // GetComparisonData 获取对比数据
 func (tm *TraderManager) GetComparisonData() (map[string]interface{}, error) {
 	tm.mu.RLock()
@@ -489,68 +596,28 @@ func (tm *TraderManager) GetComparisonData() (map[string]interface{}, error) {
 	return comparison, nil
 }
 
-// GetCompetitionData 获取竞赛数据（全平台所有交易员）
+// GetCompetitionData 获取竞赛数据（全平台所有交易员），按系统配置的排名方式排序
 func (tm *TraderManager) GetCompetitionData() (map[string]interface{}, error) {
-	// 检查缓存是否有效（30秒内）
-	tm.competitionCache.mu.RLock()
-	if time.Since(tm.competitionCache.timestamp) < 30*time.Second && len(tm.competitionCache.data) > 0 {
-		// 返回缓存数据
-		cachedData := make(map[string]interface{})
-		for k, v := range tm.competitionCache.data {
-			cachedData[k] = v
-		}
-		tm.competitionCache.mu.RUnlock()
-		log.Printf("📋 返回竞赛数据缓存 (缓存时间: %.1fs)", time.Since(tm.competitionCache.timestamp).Seconds())
-		return cachedData, nil
-	}
-	tm.competitionCache.mu.RUnlock()
+	return tm.GetRankedCompetitionData(tm.DefaultRankOptions())
+}
 
+// GetRankedCompetitionData 按指定排名方式排序的竞赛数据（交易员数据30秒缓存，排名每次重新计算）
+func (tm *TraderManager) GetRankedCompetitionData(opts RankOptions) (map[string]interface{}, error) {
+	return rankCompetitionData(tm.competitionTraders(), opts), nil
+}
+
+// GetCompetitionDataFor 指定交易员的竞赛数据（按标签筛选时使用，不缓存），未加载的交易员被忽略
+func (tm *TraderManager) GetCompetitionDataFor(traderIDs []string, opts RankOptions) map[string]interface{} {
 	tm.mu.RLock()
-	
-	// 获取所有交易员列表
-	allTraders := make([]*trader.AutoTrader, 0, len(tm.traders))
-	for _, t := range tm.traders {
-		allTraders = append(allTraders, t)
+	selected := make([]*trader.AutoTrader, 0, len(traderIDs))
+	for _, id := range traderIDs {
+		if t, ok := tm.traders[id]; ok {
+			selected = append(selected, t)
+		}
 	}
 	tm.mu.RUnlock()
-	
-	log.Printf("🔄 重新获取竞赛数据，交易员数量: %d", len(allTraders))
-	
-	// 并发获取交易员数据
-	traders := tm.getConcurrentTraderData(allTraders)
-	
-	// 按收益率排序（降序）
-	sort.Slice(traders, func(i, j int) bool {
-		pnlPctI, okI := traders[i]["total_pnl_pct"].(float64)
-		pnlPctJ, okJ := traders[j]["total_pnl_pct"].(float64)
-		if !okI {
-			pnlPctI = 0
-		}
-		if !okJ {
-			pnlPctJ = 0
-		}
-		return pnlPctI > pnlPctJ
-	})
-	
-	// 限制返回前50名
-	totalCount := len(traders)
-	limit := 50
-	if len(traders) > limit {
-		traders = traders[:limit]
-	}
-	
-	comparison := make(map[string]interface{})
-	comparison["traders"] = traders
-	comparison["count"] = len(traders)
-	comparison["total_count"] = totalCount // 总交易员数量
-
-	// 更新缓存
-	tm.competitionCache.mu.Lock()
-	tm.competitionCache.data = comparison
-	tm.competitionCache.timestamp = time.Now()
-	tm.competitionCache.mu.Unlock()
 
-	return comparison, nil
+	return rankCompetitionData(tm.getConcurrentTraderData(selected), opts)
 }
 
 // getConcurrentTraderData 并发获取多个交易员的数据
@@ -559,21 +626,21 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 		index int
 		data  map[string]interface{}
 	}
-	
+
 	// 创建结果通道
 	resultChan := make(chan traderResult, len(traders))
-	
+
 	// 并发获取每个交易员的数据
 	for i, t := range traders {
 		go func(index int, trader *trader.AutoTrader) {
 			// 设置单个交易员的超时时间为3秒
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancel()
-			
+
 			// 使用通道来实现超时控制
 			accountChan := make(chan map[string]interface{}, 1)
 			errorChan := make(chan error, 1)
-			
+
 			go func() {
 				account, err := trader.GetAccountInfo()
 				if err != nil {
@@ -582,10 +649,10 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 					accountChan <- account
 				}
 			}()
-			
+
 			status := trader.GetStatus()
 			var traderData map[string]interface{}
-			
+
 			select {
 			case account := <-accountChan:
 				// 成功获取账户信息
@@ -601,9 +668,15 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 					"margin_used_pct": account["margin_used_pct"],
 					"is_running":      status["is_running"],
 				}
+				if benchmark, err := trader.GetBenchmark(); err == nil {
+					traderData["benchmark"] = benchmark
+				}
+				if metrics, err := trader.GetTradeMetrics(); err == nil {
+					traderData["metrics"] = metrics
+				}
 			case err := <-errorChan:
 				// 获取账户信息失败
-				log.Printf("⚠️ 获取交易员 %s 账户信息失败: %v", trader.GetID(), err)
+				trader.Logger().Warn("获取交易员账户信息失败", "error", err)
 				traderData = map[string]interface{}{
 					"trader_id":       trader.GetID(),
 					"trader_name":     trader.GetName(),
@@ -619,7 +692,7 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 				}
 			case <-ctx.Done():
 				// 超时
-				log.Printf("⏰ 获取交易员 %s 账户信息超时", trader.GetID())
+				trader.Logger().Warn("获取交易员账户信息超时")
 				traderData = map[string]interface{}{
 					"trader_id":       trader.GetID(),
 					"trader_name":     trader.GetName(),
@@ -634,18 +707,18 @@ func (tm *TraderManager) getConcurrentTraderData(traders []*trader.AutoTrader) [
 					"error":           "获取超时",
 				}
 			}
-			
+
 			resultChan <- traderResult{index: index, data: traderData}
 		}(i, t)
 	}
-	
+
 	// 收集所有结果
 	results := make([]map[string]interface{}, len(traders))
 	for i := 0; i < len(traders); i++ {
 		result := <-resultChan
 		results[result.index] = result.data
 	}
-	
+
 	return results
 }
 
@@ -656,20 +729,20 @@ func (tm *TraderManager) GetTopTradersData() (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// 从竞赛数据中提取前5名
 	allTraders, ok := competitionData["traders"].([]map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("竞赛数据格式错误")
 	}
-	
+
 	// 限制返回前5名
 	limit := 5
 	topTraders := allTraders
 	if len(allTraders) > limit {
 		topTraders = allTraders[:limit]
 	}
-	
+
 	result := map[string]interface{}{
 		"traders": topTraders,
 		"count":   len(topTraders),
@@ -712,6 +785,7 @@ func containsUserPrefix(traderID string) bool {
 func (tm *TraderManager) LoadUserTraders(database *config.Database, userID string) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
+	tm.database = database
 
 	// 获取指定用户的所有交易员
 	traders, err := database.GetTraders(userID)
@@ -719,7 +793,7 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 		return fmt.Errorf("获取用户 %s 的交易员列表失败: %w", userID, err)
 	}
 
-	log.Printf("📋 为用户 %s 加载交易员配置: %d 个", userID, len(traders))
+	slog.Info("为用户加载交易员配置", "user_id", userID, "count", len(traders))
 
 	// 获取系统配置（不包含信号源，信号源现在为用户级别）
 	maxDailyLossStr, _ := database.GetSystemConfig("max_daily_loss")
@@ -732,9 +806,9 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 	if userSignalSource, err := database.GetUserSignalSource(userID); err == nil {
 		coinPoolURL = userSignalSource.CoinPoolURL
 		oiTopURL = userSignalSource.OITopURL
-		log.Printf("📡 加载用户 %s 的信号源配置: COIN POOL=%s, OI TOP=%s", userID, coinPoolURL, oiTopURL)
+		slog.Info("加载用户的信号源配置", "user_id", userID, "coin_pool_url", coinPoolURL, "oi_top_url", oiTopURL)
 	} else {
-		log.Printf("🔍 用户 %s 暂未配置信号源", userID)
+		slog.Debug("用户暂未配置信号源", "user_id", userID)
 	}
 
 	// 解析配置
@@ -757,7 +831,7 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 	var defaultCoins []string
 	if defaultCoinsStr != "" {
 		if err := json.Unmarshal([]byte(defaultCoinsStr), &defaultCoins); err != nil {
-			log.Printf("⚠️ 解析默认币种配置失败: %v，使用空列表", err)
+			slog.Warn("解析默认币种配置失败，使用空列表", "error", err)
 			defaultCoins = []string{}
 		}
 	}
@@ -766,14 +840,14 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 	for _, traderCfg := range traders {
 		// 检查是否已经加载过这个交易员
 		if _, exists := tm.traders[traderCfg.ID]; exists {
-			log.Printf("⚠️ 交易员 %s 已经加载，跳过", traderCfg.Name)
+			traderLog(traderCfg).Warn("交易员已经加载，跳过")
 			continue
 		}
 
 		// 获取AI模型配置（使用该用户的配置）
 		aiModels, err := database.GetAIModels(userID)
 		if err != nil {
-			log.Printf("⚠️ 获取用户 %s 的AI模型配置失败: %v", userID, err)
+			slog.Warn("获取用户的AI模型配置失败", "user_id", userID, "error", err)
 			continue
 		}
 
@@ -790,26 +864,26 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 			for _, model := range aiModels {
 				if model.Provider == traderCfg.AIModelID {
 					aiModelCfg = model
-					log.Printf("⚠️  交易员 %s 使用旧版 provider 匹配: %s -> %s", traderCfg.Name, traderCfg.AIModelID, model.ID)
+					traderLog(traderCfg).Warn("交易员使用旧版 provider 匹配AI模型", "ai_model", traderCfg.AIModelID, "matched_model", model.ID)
 					break
 				}
 			}
 		}
 
 		if aiModelCfg == nil {
-			log.Printf("⚠️ 交易员 %s 的AI模型 %s 不存在，跳过", traderCfg.Name, traderCfg.AIModelID)
+			traderLog(traderCfg).Warn("交易员的AI模型不存在，跳过", "ai_model", traderCfg.AIModelID)
 			continue
 		}
 
 		if !aiModelCfg.Enabled {
-			log.Printf("⚠️ 交易员 %s 的AI模型 %s 未启用，跳过", traderCfg.Name, traderCfg.AIModelID)
+			traderLog(traderCfg).Warn("交易员的AI模型未启用，跳过", "ai_model", traderCfg.AIModelID)
 			continue
 		}
 
 		// 获取交易所配置（使用该用户的配置）
 		exchanges, err := database.GetExchanges(userID)
 		if err != nil {
-			log.Printf("⚠️ 获取用户 %s 的交易所配置失败: %v", userID, err)
+			slog.Warn("获取用户的交易所配置失败", "user_id", userID, "error", err)
 			continue
 		}
 
@@ -822,22 +896,31 @@ func (tm *TraderManager) LoadUserTraders(database *config.Database, userID strin
 		}
 
 		if exchangeCfg == nil {
-			log.Printf("⚠️ 交易员 %s 的交易所 %s 不存在，跳过", traderCfg.Name, traderCfg.ExchangeID)
+			traderLog(traderCfg).Warn("交易员的交易所不存在，跳过", "exchange", traderCfg.ExchangeID)
 			continue
 		}
 
 		if !exchangeCfg.Enabled {
-			log.Printf("⚠️ 交易员 %s 的交易所 %s 未启用，跳过", traderCfg.Name, traderCfg.ExchangeID)
+			traderLog(traderCfg).Warn("交易员的交易所未启用，跳过", "exchange", traderCfg.ExchangeID)
 			continue
 		}
 
 		// 使用现有的方法加载交易员
 		err = tm.loadSingleTrader(traderCfg, aiModelCfg, exchangeCfg, coinPoolURL, oiTopURL, maxDailyLoss, maxDrawdown, stopTradingMinutes, defaultCoins)
 		if err != nil {
-			log.Printf("⚠️ 加载交易员 %s 失败: %v", traderCfg.Name, err)
+			traderLog(traderCfg).Warn("加载交易员失败", "error", err)
 		}
 	}
 
+	if err := tm.applyUserLimitsLocked(database, userID); err != nil {
+		slog.Warn("同步用户的上限失败", "user_id", userID, "error", err)
+	}
+	if err := tm.applySchedulesLocked(database); err != nil {
+		slog.Warn("同步交易时段失败", "user_id", userID, "error", err)
+	}
+	if err := tm.applySignalSourcesLocked(database); err != nil {
+		slog.Warn("同步信号源失败", "user_id", userID, "error", err)
+	}
 	return nil
 }
 
@@ -865,13 +948,14 @@ func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiMode
 	var effectiveCoinPoolURL string
 	if traderCfg.UseCoinPool && coinPoolURL != "" {
 		effectiveCoinPoolURL = coinPoolURL
-		log.Printf("✓ 交易员 %s 启用 COIN POOL 信号源: %s", traderCfg.Name, coinPoolURL)
+		traderLog(traderCfg).Info("交易员启用 COIN POOL 信号源", "coin_pool_url", coinPoolURL)
 	}
 
 	// 构建AutoTraderConfig
 	traderConfig := trader.AutoTraderConfig{
 		ID:                   traderCfg.ID,
 		Name:                 traderCfg.Name,
+		UserID:               traderCfg.UserID,
 		AIModel:              aiModelCfg.Provider, // 使用provider作为模型标识
 		Exchange:             exchangeCfg.ID,      // 使用exchange ID
 		InitialBalance:       traderCfg.InitialBalance,
@@ -888,6 +972,9 @@ func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiMode
 		IsCrossMargin:        traderCfg.IsCrossMargin,
 		DefaultCoins:         defaultCoins,
 		TradingCoins:         tradingCoins,
+		MaxCandidates:        traderCfg.MaxCandidates,
+		PoolRefreshInterval:  time.Duration(traderCfg.PoolRefreshMinutes) * time.Minute,
+		Liquidity:            liquidityFilterOf(traderCfg),
 		SystemPromptTemplate: traderCfg.SystemPromptTemplate, // 系统提示词模板
 	}
 
@@ -922,13 +1009,15 @@ func (tm *TraderManager) loadSingleTrader(traderCfg *config.TraderRecord, aiMode
 		at.SetCustomPrompt(traderCfg.CustomPrompt)
 		at.SetOverrideBasePrompt(traderCfg.OverrideBasePrompt)
 		if traderCfg.OverrideBasePrompt {
-			log.Printf("✓ 已设置自定义交易策略prompt (覆盖基础prompt)")
+			traderLog(traderCfg).Info("已设置自定义交易策略prompt（覆盖基础prompt）")
 		} else {
-			log.Printf("✓ 已设置自定义交易策略prompt (补充基础prompt)")
+			traderLog(traderCfg).Info("已设置自定义交易策略prompt（补充基础prompt）")
 		}
 	}
 
+	tm.scheduleAICalls(at, aiModelCfg)
+	tm.attachTradeRecords(at)
 	tm.traders[traderCfg.ID] = at
-	log.Printf("✓ Trader '%s' (%s + %s) 已为用户加载到内存", traderCfg.Name, aiModelCfg.Provider, exchangeCfg.ID)
+	traderLog(traderCfg).Info("交易员已为用户加载到内存", "ai_provider", aiModelCfg.Provider, "exchange", exchangeCfg.ID)
 	return nil
 }
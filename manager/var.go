@@ -0,0 +1,190 @@
+package manager
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"nofx/market"
+	"sort"
+)
+
+const (
+	varLookbackDays    = 90 // 参与计算的日收益率数量
+	varMinObservations = 20 // 对齐后少于此天数不计算
+)
+
+// varLevels 置信水平及对应的正态分布分位数
+var varLevels = []struct {
+	confidence float64
+	z          float64
+}{{0.95, 1.6449}, {0.99, 2.3263}}
+
+// VaREstimate 单个置信水平下的1日VaR（USDT，正数表示可能亏损）
+type VaREstimate struct {
+	Confidence    float64 `json:"confidence"`
+	Parametric    float64 `json:"parametric"` // 正态分布假设
+	Historical    float64 `json:"historical"` // 历史模拟
+	ParametricPct float64 `json:"parametric_pct"`
+	HistoricalPct float64 `json:"historical_pct"` // 占净值百分比
+}
+
+// VaRResult 一组持仓按当前仓位回放历史日收益得到的VaR
+type VaRResult struct {
+	Equity         float64       `json:"equity"`
+	GrossExposure  float64       `json:"gross_exposure"`
+	Observations   int           `json:"observations"`              // 对齐后的交易日数
+	MissingSymbols []string      `json:"missing_symbols,omitempty"` // 缺少历史数据、未计入的币种
+	Estimates      []VaREstimate `json:"estimates"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// TraderVaR 交易员和所属用户组合的VaR
+type TraderVaR struct {
+	TraderID  string     `json:"trader_id"`
+	Trader    *VaRResult `json:"trader"`
+	Portfolio *VaRResult `json:"portfolio"` // 用户全部交易员合并
+}
+
+// GetTraderVaR 计算交易员及组合（portfolioIDs，通常是同一用户的全部交易员）的1日参数法和历史模拟法VaR
+func (tm *TraderManager) GetTraderVaR(traderID string, portfolioIDs []string) (*TraderVaR, error) {
+	if _, err := tm.GetTrader(traderID); err != nil {
+		return nil, err
+	}
+	traders, positions := tm.collectPositions(portfolioIDs)
+
+	returns := make(map[string]map[int64]float64)
+	for _, symbol := range positionSymbols(positions) {
+		r, err := market.DailyReturns(symbol, varLookbackDays)
+		if err != nil {
+			slog.Warn("VaR：获取日收益率失败", "trader_id", traderID, "symbol", symbol, "error", err)
+			continue
+		}
+		returns[symbol] = r
+	}
+
+	var traderEquity, portfolioEquity float64
+	var traderPositions []PortfolioPosition
+	for _, t := range traders {
+		if t.Error != "" {
+			continue
+		}
+		portfolioEquity += t.TotalEquity
+		if t.TraderID == traderID {
+			traderEquity = t.TotalEquity
+		}
+	}
+	for _, p := range positions {
+		if p.TraderID == traderID {
+			traderPositions = append(traderPositions, p)
+		}
+	}
+
+	return &TraderVaR{
+		TraderID:  traderID,
+		Trader:    CalculateVaR(traderPositions, traderEquity, returns),
+		Portfolio: CalculateVaR(positions, portfolioEquity, returns),
+	}, nil
+}
+
+// CalculateVaR 按当前持仓的名义价值（空仓为负）回放各币种对齐后的日收益率，计算1日VaR
+func CalculateVaR(positions []PortfolioPosition, equity float64, returns map[string]map[int64]float64) *VaRResult {
+	result := &VaRResult{Equity: equity, Estimates: []VaREstimate{}}
+
+	exposure := make(map[string]float64)
+	for _, p := range positions {
+		notional := p.Quantity * p.MarkPrice
+		result.GrossExposure += notional
+		if p.Side == "short" {
+			notional = -notional
+		}
+		exposure[p.Symbol] += notional
+	}
+	if len(exposure) == 0 {
+		return result
+	}
+
+	// 只保留所有币种都有收益率的交易日
+	var symbols []string
+	for symbol := range exposure {
+		if len(returns[symbol]) == 0 {
+			result.MissingSymbols = append(result.MissingSymbols, symbol)
+			continue
+		}
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(result.MissingSymbols)
+	if len(symbols) == 0 {
+		result.Error = "缺少历史收益率数据"
+		return result
+	}
+
+	var days []int64
+	for day := range returns[symbols[0]] {
+		aligned := true
+		for _, symbol := range symbols[1:] {
+			if _, ok := returns[symbol][day]; !ok {
+				aligned = false
+				break
+			}
+		}
+		if aligned {
+			days = append(days, day)
+		}
+	}
+	result.Observations = len(days)
+	if len(days) < varMinObservations {
+		result.Error = fmt.Sprintf("历史数据不足（%d天，至少需要%d天）", len(days), varMinObservations)
+		return result
+	}
+
+	pnls := make([]float64, 0, len(days))
+	for _, day := range days {
+		pnl := 0.0
+		for _, symbol := range symbols {
+			pnl += exposure[symbol] * returns[symbol][day]
+		}
+		pnls = append(pnls, pnl)
+	}
+	mean, std := meanStd(pnls)
+	sort.Float64s(pnls)
+
+	for _, level := range varLevels {
+		estimate := VaREstimate{
+			Confidence: level.confidence,
+			Parametric: math.Max(0, -(mean - level.z*std)),
+			Historical: math.Max(0, -historicalQuantile(pnls, 1-level.confidence)),
+		}
+		if equity > 0 {
+			estimate.ParametricPct = estimate.Parametric / equity * 100
+			estimate.HistoricalPct = estimate.Historical / equity * 100
+		}
+		result.Estimates = append(result.Estimates, estimate)
+	}
+	return result
+}
+
+// historicalQuantile 已排序样本的分位数（线性插值）
+func historicalQuantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// meanStd 均值和样本标准差
+func meanStd(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	if len(values) > 1 {
+		variance /= float64(len(values) - 1)
+	}
+	return mean, math.Sqrt(variance)
+}
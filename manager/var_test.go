@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateVaR(t *testing.T) {
+	// BTC 和 ETH 同日收益：-5%, -4%, ..., +4%（重复3轮，共30天）
+	btc := make(map[int64]float64)
+	eth := make(map[int64]float64)
+	for i := 0; i < 30; i++ {
+		r := float64(i%10-5) / 100
+		btc[int64(i)] = r
+		eth[int64(i)] = r
+	}
+	returns := map[string]map[int64]float64{"BTCUSDT": btc, "ETHUSDT": eth}
+
+	positions := []PortfolioPosition{
+		{Symbol: "BTCUSDT", Side: "long", Quantity: 0.1, MarkPrice: 60000}, // +6000
+		{Symbol: "ETHUSDT", Side: "short", Quantity: 1, MarkPrice: 2000},   // -2000
+		{Symbol: "XRPUSDT", Side: "long", Quantity: 100, MarkPrice: 1},
+	}
+	result := CalculateVaR(positions, 10000, returns)
+
+	if result.Error != "" || result.Observations != 30 {
+		t.Fatalf("VaR计算失败: %+v", result)
+	}
+	if len(result.MissingSymbols) != 1 || result.MissingSymbols[0] != "XRPUSDT" {
+		t.Errorf("缺少数据的币种应被列出: %v", result.MissingSymbols)
+	}
+	if len(result.Estimates) != 2 {
+		t.Fatalf("应有95%%和99%%两个置信水平: %+v", result.Estimates)
+	}
+
+	// 净敞口4000，最差日收益-5% → 历史模拟99% VaR 接近 200
+	var99 := result.Estimates[1]
+	if math.Abs(var99.Historical-200) > 1e-6 {
+		t.Errorf("历史模拟99%% VaR错误: %.4f", var99.Historical)
+	}
+	if var99.Parametric <= result.Estimates[0].Parametric {
+		t.Errorf("99%% VaR应大于95%%: %+v", result.Estimates)
+	}
+	if math.Abs(var99.HistoricalPct-2) > 1e-6 {
+		t.Errorf("VaR占净值比例错误: %.4f", var99.HistoricalPct)
+	}
+
+	short := CalculateVaR(positions[:1], 10000, map[string]map[int64]float64{"BTCUSDT": {1: 0.01}})
+	if short.Error == "" {
+		t.Error("历史数据不足时应返回错误说明")
+	}
+	if empty := CalculateVaR(nil, 10000, returns); empty.Error != "" || len(empty.Estimates) != 0 {
+		t.Errorf("无持仓时VaR为空: %+v", empty)
+	}
+}
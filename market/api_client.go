@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
@@ -20,9 +20,7 @@ type APIClient struct {
 
 func NewAPIClient() *APIClient {
 	return &APIClient{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client: NewRateLimitedHTTPClient(30*time.Second, false),
 	}
 }
 
@@ -81,7 +79,7 @@ func (c *APIClient) GetKlines(symbol, interval string, limit int) ([]Kline, erro
 	for _, kr := range klineResponses {
 		kline, err := parseKline(kr)
 		if err != nil {
-			log.Printf("解析K线数据失败: %v", err)
+			slog.Warn("解析K线数据失败", "symbol", symbol, "error", err)
 			continue
 		}
 		klines = append(klines, kline)
@@ -148,3 +146,77 @@ func (c *APIClient) GetCurrentPrice(symbol string) (float64, error) {
 
 	return price, nil
 }
+
+// GetPriceAt 获取指定时间所在1分钟K线的开盘价（用于计算历史区间收益）
+func (c *APIClient) GetPriceAt(symbol string, t time.Time) (float64, error) {
+	url := fmt.Sprintf("%s/fapi/v1/klines", baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	q.Add("interval", "1m")
+	q.Add("startTime", strconv.FormatInt(t.UnixMilli(), 10))
+	q.Add("limit", "1")
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var klineResponses []KlineResponse
+	if err := json.Unmarshal(body, &klineResponses); err != nil {
+		return 0, err
+	}
+	if len(klineResponses) == 0 {
+		return 0, fmt.Errorf("%s 在 %s 没有K线数据", symbol, t.Format(time.RFC3339))
+	}
+
+	kline, err := parseKline(klineResponses[0])
+	if err != nil {
+		return 0, err
+	}
+	return kline.Open, nil
+}
+
+func (c *APIClient) Get24hrTicker(symbol string) (*Ticker24hr, error) {
+	url := fmt.Sprintf("%s/fapi/v1/ticker/24hr", baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("24h行情返回错误 (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var ticker Ticker24hr
+	if err := json.Unmarshal(body, &ticker); err != nil {
+		return nil, err
+	}
+
+	return &ticker, nil
+}
@@ -3,7 +3,8 @@ package market
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"math/rand"
 	"strings"
 	"sync"
 	"time"
@@ -11,18 +12,30 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+const (
+	reconnectBaseDelay = 1 * time.Second
+	reconnectMaxDelay  = 60 * time.Second
+	wsReadTimeout      = 2 * time.Minute // 超过该时长无任何消息视为连接假死
+)
+
 type CombinedStreamsClient struct {
-	conn        *websocket.Conn
-	mu          sync.RWMutex
-	subscribers map[string]chan []byte
-	reconnect   bool
-	done        chan struct{}
-	batchSize   int // 每批订阅的流数量
+	conn         *websocket.Conn
+	mu           sync.RWMutex
+	subscribers  map[string]chan []byte
+	streams      map[string]bool // 已订阅的流（重连后全量重新订阅）
+	reconnect    bool
+	reconnecting bool
+	done         chan struct{}
+	batchSize    int // 每批订阅的流数量
+
+	// OnReconnect 重连并重新订阅成功后回调，参数为断线时长（用于REST补齐缺口）
+	OnReconnect func(downtime time.Duration)
 }
 
 func NewCombinedStreamsClient(batchSize int) *CombinedStreamsClient {
 	return &CombinedStreamsClient{
 		subscribers: make(map[string]chan []byte),
+		streams:     make(map[string]bool),
 		reconnect:   true,
 		done:        make(chan struct{}),
 		batchSize:   batchSize,
@@ -44,8 +57,8 @@ func (c *CombinedStreamsClient) Connect() error {
 	c.conn = conn
 	c.mu.Unlock()
 
-	log.Println("组合流WebSocket连接成功")
-	go c.readMessages()
+	slog.Info("组合流WebSocket连接成功")
+	go c.readMessages(conn)
 
 	return nil
 }
@@ -56,7 +69,7 @@ func (c *CombinedStreamsClient) BatchSubscribeKlines(symbols []string, interval
 	batches := c.splitIntoBatches(symbols, c.batchSize)
 
 	for i, batch := range batches {
-		log.Printf("订阅第 %d 批, 数量: %d", i+1, len(batch))
+		slog.Debug("订阅批次", "batch", i+1, "count", len(batch))
 
 		streams := make([]string, len(batch))
 		for j, symbol := range batch {
@@ -99,36 +112,64 @@ func (c *CombinedStreamsClient) subscribeStreams(streams []string) error {
 		"id":     time.Now().UnixNano(),
 	}
 
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	// websocket连接不支持并发写，需独占锁
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	if c.conn == nil {
 		return fmt.Errorf("WebSocket未连接")
 	}
 
-	log.Printf("订阅流: %v", streams)
-	return c.conn.WriteJSON(subscribeMsg)
+	slog.Debug("订阅流", "streams", streams)
+	if err := c.conn.WriteJSON(subscribeMsg); err != nil {
+		return err
+	}
+	for _, stream := range streams {
+		c.streams[stream] = true
+	}
+	return nil
 }
 
-func (c *CombinedStreamsClient) readMessages() {
+// resubscribeAll 重连后按批次重新订阅所有流
+func (c *CombinedStreamsClient) resubscribeAll() error {
+	c.mu.RLock()
+	streams := make([]string, 0, len(c.streams))
+	for stream := range c.streams {
+		streams = append(streams, stream)
+	}
+	c.mu.RUnlock()
+
+	batches := c.splitIntoBatches(streams, c.batchSize)
+	for i, batch := range batches {
+		if err := c.subscribeStreams(batch); err != nil {
+			return fmt.Errorf("第 %d 批重新订阅失败: %v", i+1, err)
+		}
+		if i < len(batches)-1 {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	slog.Info("组合流已重新订阅", "count", len(streams))
+	return nil
+}
+
+// readMessages 读取指定连接的消息（每个连接独立一个读协程，连接失效即退出）
+func (c *CombinedStreamsClient) readMessages(conn *websocket.Conn) {
 	for {
 		select {
 		case <-c.done:
 			return
 		default:
-			c.mu.RLock()
-			conn := c.conn
-			c.mu.RUnlock()
-
-			if conn == nil {
-				time.Sleep(1 * time.Second)
-				continue
-			}
-
+			conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
 			_, message, err := conn.ReadMessage()
 			if err != nil {
-				log.Printf("读取组合流消息失败: %v", err)
-				c.handleReconnect()
+				slog.Warn("读取组合流消息失败", "error", err)
+				c.mu.RLock()
+				current := c.conn == conn
+				c.mu.RUnlock()
+				// 只有当前活跃连接失效才触发重连（被主动丢弃的旧连接直接退出）
+				if current {
+					c.handleReconnect()
+				}
 				return
 			}
 
@@ -144,7 +185,7 @@ func (c *CombinedStreamsClient) handleCombinedMessage(message []byte) {
 	}
 
 	if err := json.Unmarshal(message, &combinedMsg); err != nil {
-		log.Printf("解析组合消息失败: %v", err)
+		slog.Warn("解析组合消息失败", "error", err)
 		return
 	}
 
@@ -156,7 +197,7 @@ func (c *CombinedStreamsClient) handleCombinedMessage(message []byte) {
 		select {
 		case ch <- combinedMsg.Data:
 		default:
-			log.Printf("订阅者通道已满: %s", combinedMsg.Stream)
+			slog.Warn("订阅者通道已满", "stream", combinedMsg.Stream)
 		}
 	}
 }
@@ -169,18 +210,73 @@ func (c *CombinedStreamsClient) AddSubscriber(stream string, bufferSize int) <-c
 	return ch
 }
 
+// handleReconnect 带抖动的指数退避重连，成功后重新订阅并回调补齐数据
 func (c *CombinedStreamsClient) handleReconnect() {
-	if !c.reconnect {
+	c.mu.Lock()
+	if !c.reconnect || c.reconnecting {
+		c.mu.Unlock()
 		return
 	}
+	c.reconnecting = true
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.reconnecting = false
+		c.mu.Unlock()
+	}()
 
-	log.Println("组合流尝试重新连接...")
-	time.Sleep(3 * time.Second)
+	disconnectedAt := time.Now()
+	for attempt := 1; ; attempt++ {
+		delay := reconnectDelay(attempt)
+		slog.Info("组合流尝试重新连接", "attempt", attempt, "delay", delay.Round(time.Millisecond))
 
-	if err := c.Connect(); err != nil {
-		log.Printf("组合流重新连接失败: %v", err)
-		go c.handleReconnect()
+		select {
+		case <-c.done:
+			return
+		case <-time.After(delay):
+		}
+
+		if err := c.Connect(); err != nil {
+			slog.Warn("组合流重新连接失败", "attempt", attempt, "error", err)
+			continue
+		}
+		if err := c.resubscribeAll(); err != nil {
+			// 订阅不完整的连接直接丢弃，继续退避重连
+			slog.Warn("组合流重新订阅失败", "attempt", attempt, "error", err)
+			c.mu.Lock()
+			if c.conn != nil {
+				c.conn.Close()
+				c.conn = nil
+			}
+			c.mu.Unlock()
+			continue
+		}
+
+		downtime := time.Since(disconnectedAt)
+		slog.Info("组合流重连成功", "downtime", downtime.Round(time.Second))
+		if c.OnReconnect != nil {
+			go c.OnReconnect(downtime)
+		}
+		return
+	}
+}
+
+// reconnectDelay 计算第N次重连的等待时间（指数退避 + ±50%随机抖动，避免大量客户端同时重连）
+func reconnectDelay(attempt int) time.Duration {
+	delay := reconnectBaseDelay
+	for i := 1; i < attempt && delay < reconnectMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > reconnectMaxDelay {
+		delay = reconnectMaxDelay
 	}
+	jitter := 0.5 + rand.Float64() // [0.5, 1.5)
+	return time.Duration(float64(delay) * jitter)
 }
 
 func (c *CombinedStreamsClient) Close() {
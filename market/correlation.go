@@ -0,0 +1,156 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	correlationLookback  = 60  // 参与计算的4h收益率数量（约10天）
+	correlationThreshold = 0.8 // 视为高相关的阈值
+	maxCorrelationPairs  = 15  // prompt中最多展示的高相关币对数量
+)
+
+// CorrelationPair 币对相关性
+type CorrelationPair struct {
+	SymbolA     string  `json:"symbol_a"`
+	SymbolB     string  `json:"symbol_b"`
+	Correlation float64 `json:"correlation"`
+}
+
+// CorrelationSummary 相关性摘要（只保留对决策有用的部分，避免输出完整矩阵）
+type CorrelationSummary struct {
+	HighlyCorrelated []CorrelationPair  `json:"highly_correlated"` // |相关系数|≥阈值的币对，按相关性降序
+	BTCCorrelation   map[string]float64 `json:"btc_correlation"`   // 各币种与BTC的相关性
+	AvgCorrelation   float64            `json:"avg_correlation"`   // 所有币对的平均相关性
+}
+
+// CalculateCorrelationSummary 计算币种间4h收益率相关性摘要
+func CalculateCorrelationSummary(symbols []string) *CorrelationSummary {
+	returns := make(map[string]map[int64]float64)
+	for _, symbol := range symbols {
+		klines, err := WSMonitorCli.GetCurrentKlines(Normalize(symbol), "4h")
+		if err != nil || len(klines) < 10 {
+			continue
+		}
+		returns[Normalize(symbol)] = klineReturns(klines, correlationLookback)
+	}
+	if _, ok := returns["BTCUSDT"]; !ok {
+		if klines, err := WSMonitorCli.GetCurrentKlines("BTCUSDT", "4h"); err == nil && len(klines) >= 10 {
+			returns["BTCUSDT"] = klineReturns(klines, correlationLookback)
+		}
+	}
+	return summarizeCorrelations(returns)
+}
+
+// DailyReturns 获取最近N个日收益率（按开盘时间索引），用于VaR等风险计算
+func DailyReturns(symbol string, lookback int) (map[int64]float64, error) {
+	klines, err := getDailyKlines(Normalize(symbol))
+	if err != nil {
+		return nil, fmt.Errorf("获取日线K线失败: %w", err)
+	}
+	// 最后一根日线尚未收盘，不计入
+	if len(klines) > 0 {
+		klines = klines[:len(klines)-1]
+	}
+	return klineReturns(klines, lookback), nil
+}
+
+// klineReturns 计算最近N根K线的收益率（按开盘时间索引，便于不同币种对齐）
+func klineReturns(klines []Kline, lookback int) map[int64]float64 {
+	start := len(klines) - lookback - 1
+	if start < 0 {
+		start = 0
+	}
+	result := make(map[int64]float64, lookback)
+	for i := start + 1; i < len(klines); i++ {
+		if klines[i-1].Close > 0 {
+			result[klines[i].OpenTime] = (klines[i].Close - klines[i-1].Close) / klines[i-1].Close
+		}
+	}
+	return result
+}
+
+// summarizeCorrelations 两两计算相关性并生成摘要
+func summarizeCorrelations(returns map[string]map[int64]float64) *CorrelationSummary {
+	summary := &CorrelationSummary{
+		HighlyCorrelated: []CorrelationPair{},
+		BTCCorrelation:   make(map[string]float64),
+	}
+
+	symbols := make([]string, 0, len(returns))
+	for symbol := range returns {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	sum, count := 0.0, 0
+	for i := 0; i < len(symbols); i++ {
+		for j := i + 1; j < len(symbols); j++ {
+			corr, ok := pearsonCorrelation(returns[symbols[i]], returns[symbols[j]])
+			if !ok {
+				continue
+			}
+			corr = math.Round(corr*100) / 100
+			sum += corr
+			count++
+
+			if symbols[i] == "BTCUSDT" {
+				summary.BTCCorrelation[symbols[j]] = corr
+			} else if symbols[j] == "BTCUSDT" {
+				summary.BTCCorrelation[symbols[i]] = corr
+			}
+			if math.Abs(corr) >= correlationThreshold {
+				summary.HighlyCorrelated = append(summary.HighlyCorrelated, CorrelationPair{
+					SymbolA: symbols[i], SymbolB: symbols[j], Correlation: corr,
+				})
+			}
+		}
+	}
+
+	sort.Slice(summary.HighlyCorrelated, func(i, j int) bool {
+		return math.Abs(summary.HighlyCorrelated[i].Correlation) > math.Abs(summary.HighlyCorrelated[j].Correlation)
+	})
+	if len(summary.HighlyCorrelated) > maxCorrelationPairs {
+		summary.HighlyCorrelated = summary.HighlyCorrelated[:maxCorrelationPairs]
+	}
+	if count > 0 {
+		summary.AvgCorrelation = math.Round(sum/float64(count)*100) / 100
+	}
+	return summary
+}
+
+// pearsonCorrelation 计算两个收益率序列在共同时间点上的皮尔逊相关系数
+func pearsonCorrelation(a, b map[int64]float64) (float64, bool) {
+	var xs, ys []float64
+	for t, x := range a {
+		if y, ok := b[t]; ok {
+			xs = append(xs, x)
+			ys = append(ys, y)
+		}
+	}
+	n := float64(len(xs))
+	if n < 10 {
+		return 0, false
+	}
+
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varX*varY), true
+}
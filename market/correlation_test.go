@@ -0,0 +1,40 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+// TestSummarizeCorrelations 测试相关性计算与摘要
+func TestSummarizeCorrelations(t *testing.T) {
+	btc := make(map[int64]float64)
+	eth := make(map[int64]float64)
+	inverse := make(map[int64]float64)
+	for i := int64(0); i < 30; i++ {
+		r := math.Sin(float64(i)) * 0.02
+		btc[i] = r
+		eth[i] = r*1.5 + 0.001
+		inverse[i] = -r
+	}
+
+	summary := summarizeCorrelations(map[string]map[int64]float64{
+		"BTCUSDT": btc,
+		"ETHUSDT": eth,
+		"XYZUSDT": inverse,
+	})
+
+	if summary.BTCCorrelation["ETHUSDT"] != 1 {
+		t.Errorf("ETH与BTC应完全正相关，实际: %.2f", summary.BTCCorrelation["ETHUSDT"])
+	}
+	if summary.BTCCorrelation["XYZUSDT"] != -1 {
+		t.Errorf("XYZ与BTC应完全负相关，实际: %.2f", summary.BTCCorrelation["XYZUSDT"])
+	}
+	if len(summary.HighlyCorrelated) != 3 {
+		t.Errorf("期望3个高相关币对，实际: %+v", summary.HighlyCorrelated)
+	}
+
+	// 共同样本不足时不计算
+	if _, ok := pearsonCorrelation(map[int64]float64{1: 0.1}, map[int64]float64{1: 0.2}); ok {
+		t.Error("样本不足时不应返回相关性")
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"net/http"
 	"strconv"
 	"strings"
 )
@@ -63,6 +62,9 @@ func Get(symbol string) (*Data, error) {
 	// 获取Funding Rate
 	fundingRate, _ := getFundingRate(symbol)
 
+	// 订单簿流动性（失败不影响整体）
+	liquidity, _ := getLiquidityData(symbol)
+
 	// 计算日内系列数据
 	intradayData := calculateIntradaySeries(klines3m)
 
@@ -81,6 +83,10 @@ func Get(symbol string) (*Data, error) {
 		FundingRate:       fundingRate,
 		IntradaySeries:    intradayData,
 		LongerTermContext: longerTermData,
+		Divergence3m:      detectDivergences(klines3m),
+		Divergence4h:      detectDivergences(klines4h),
+		Regime:            classifyRegime(klines4h),
+		Liquidity:         liquidity,
 	}, nil
 }
 
@@ -293,7 +299,7 @@ func calculateLongerTermData(klines []Kline) *LongerTermData {
 func getOpenInterestData(symbol string) (*OIData, error) {
 	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/openInterest?symbol=%s", symbol)
 
-	resp, err := http.Get(url)
+	resp, err := restClient.Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -326,7 +332,7 @@ func getOpenInterestData(symbol string) (*OIData, error) {
 func getFundingRate(symbol string) (float64, error) {
 	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/premiumIndex?symbol=%s", symbol)
 
-	resp, err := http.Get(url)
+	resp, err := restClient.Get(url)
 	if err != nil {
 		return 0, err
 	}
@@ -355,6 +361,11 @@ func getFundingRate(symbol string) (float64, error) {
 	return rate, nil
 }
 
+// GetFundingRate 获取指定币种最近一次资金费率
+func GetFundingRate(symbol string) (float64, error) {
+	return getFundingRate(Normalize(symbol))
+}
+
 // Format 格式化输出市场数据
 func Format(data *Data) string {
 	var sb strings.Builder
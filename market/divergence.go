@@ -0,0 +1,60 @@
+package market
+
+const (
+	divergenceLookback    = 40 // 背离检测回看的K线数量
+	divergencePivotWindow = 2  // 波段点左右确认K线数
+
+	DivergenceBullish = "bullish"
+	DivergenceBearish = "bearish"
+	DivergenceNone    = "none"
+)
+
+// detectDivergences 检测RSI14和MACD相对价格的背离
+func detectDivergences(klines []Kline) *DivergenceData {
+	data := &DivergenceData{RSI: DivergenceNone, MACD: DivergenceNone}
+	if len(klines) < 26+divergenceLookback {
+		return data
+	}
+
+	start := len(klines) - divergenceLookback
+	rsi := make([]float64, 0, divergenceLookback)
+	macd := make([]float64, 0, divergenceLookback)
+	for i := start; i < len(klines); i++ {
+		rsi = append(rsi, calculateRSI(klines[:i+1], 14))
+		macd = append(macd, calculateMACD(klines[:i+1]))
+	}
+
+	recent := klines[start:]
+	data.RSI = detectDivergence(recent, rsi)
+	data.MACD = detectDivergence(recent, macd)
+	return data
+}
+
+// detectDivergence 比较最近两个波段点的价格与指标走向
+// 价格创新低而指标抬高为底背离，价格创新高而指标走低为顶背离；两者同时存在时取较新的一个
+func detectDivergence(klines []Kline, indicator []float64) string {
+	highIdx, lowIdx := findPivots(klines, divergencePivotWindow)
+
+	bearishAt, bullishAt := -1, -1
+	if n := len(highIdx); n >= 2 {
+		prev, last := highIdx[n-2], highIdx[n-1]
+		if klines[last].High > klines[prev].High && indicator[last] < indicator[prev] {
+			bearishAt = last
+		}
+	}
+	if n := len(lowIdx); n >= 2 {
+		prev, last := lowIdx[n-2], lowIdx[n-1]
+		if klines[last].Low < klines[prev].Low && indicator[last] > indicator[prev] {
+			bullishAt = last
+		}
+	}
+
+	switch {
+	case bearishAt < 0 && bullishAt < 0:
+		return DivergenceNone
+	case bearishAt > bullishAt:
+		return DivergenceBearish
+	default:
+		return DivergenceBullish
+	}
+}
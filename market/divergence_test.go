@@ -0,0 +1,34 @@
+package market
+
+import "testing"
+
+// TestDetectDivergence 测试顶背离与底背离识别
+func TestDetectDivergence(t *testing.T) {
+	// 价格两次见顶（第二次更高），指标第二次更低 -> 顶背离
+	highs := []float64{100, 102, 105, 102, 100, 101, 103, 106, 103, 101, 100}
+	indicator := []float64{50, 60, 75, 60, 50, 55, 62, 68, 60, 52, 50}
+	var klines []Kline
+	for _, h := range highs {
+		klines = append(klines, Kline{High: h, Low: h - 1, Close: h - 0.5})
+	}
+	if got := detectDivergence(klines, indicator); got != DivergenceBearish {
+		t.Errorf("期望顶背离，实际: %s", got)
+	}
+
+	// 价格两次探底（第二次更低），指标第二次更高 -> 底背离
+	lows := []float64{100, 98, 95, 98, 100, 99, 97, 94, 97, 99, 100}
+	indicator = []float64{50, 40, 25, 40, 50, 45, 38, 32, 40, 48, 50}
+	klines = klines[:0]
+	for _, l := range lows {
+		klines = append(klines, Kline{High: l + 1, Low: l, Close: l + 0.5})
+	}
+	if got := detectDivergence(klines, indicator); got != DivergenceBullish {
+		t.Errorf("期望底背离，实际: %s", got)
+	}
+
+	// 指标与价格同步 -> 无背离
+	indicator = []float64{50, 40, 25, 40, 50, 45, 38, 20, 40, 48, 50}
+	if got := detectDivergence(klines, indicator); got != DivergenceNone {
+		t.Errorf("期望无背离，实际: %s", got)
+	}
+}
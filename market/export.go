@@ -0,0 +1,80 @@
+package market
+
+import (
+	"fmt"
+	"time"
+)
+
+// KlineExportRow 导出的单根K线及当时的指标值
+type KlineExportRow struct {
+	OpenTime  int64   `json:"open_time"`
+	CloseTime int64   `json:"close_time"`
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Close     float64 `json:"close"`
+	Volume    float64 `json:"volume"`
+	EMA20     float64 `json:"ema20"`
+	MACD      float64 `json:"macd"`
+	RSI7      float64 `json:"rsi7"`
+	RSI14     float64 `json:"rsi14"`
+	ATR14     float64 `json:"atr14"`
+}
+
+// ExportKlines 导出WebSocket缓存中的K线及指标，from/to为零值表示不限制
+func ExportKlines(symbol, interval string, from, to time.Time) ([]KlineExportRow, error) {
+	supported := false
+	for _, st := range subKlineTime {
+		if st == interval {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("不支持的K线周期: %s（可选: %v）", interval, subKlineTime)
+	}
+	if WSMonitorCli == nil {
+		return nil, fmt.Errorf("行情监控未启动")
+	}
+
+	symbol = Normalize(symbol)
+	value, ok := WSMonitorCli.getKlineDataMap(interval).Load(symbol)
+	if !ok {
+		return nil, fmt.Errorf("没有%s的%s缓存K线", symbol, interval)
+	}
+
+	// 复制一份，避免与WebSocket更新并发读写
+	klines := append([]Kline(nil), value.([]Kline)...)
+	return buildExportRows(klines, from, to), nil
+}
+
+// buildExportRows 按与决策时相同的方式逐根计算指标（指标基于完整历史，之后再按时间过滤）
+func buildExportRows(klines []Kline, from, to time.Time) []KlineExportRow {
+	rows := make([]KlineExportRow, 0, len(klines))
+	for i, k := range klines {
+		openTime := time.UnixMilli(k.OpenTime)
+		if !from.IsZero() && openTime.Before(from) {
+			continue
+		}
+		if !to.IsZero() && openTime.After(to) {
+			continue
+		}
+
+		history := klines[:i+1]
+		rows = append(rows, KlineExportRow{
+			OpenTime:  k.OpenTime,
+			CloseTime: k.CloseTime,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			EMA20:     calculateEMA(history, 20),
+			MACD:      calculateMACD(history),
+			RSI7:      calculateRSI(history, 7),
+			RSI14:     calculateRSI(history, 14),
+			ATR14:     calculateATR(history, 14),
+		})
+	}
+	return rows
+}
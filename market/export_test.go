@@ -0,0 +1,38 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildExportRowsFilter(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]Kline, 30)
+	for i := range klines {
+		open := base.Add(time.Duration(i) * 3 * time.Minute)
+		price := 100 + float64(i)
+		klines[i] = Kline{
+			OpenTime:  open.UnixMilli(),
+			CloseTime: open.Add(3*time.Minute).UnixMilli() - 1,
+			Open:      price,
+			High:      price + 1,
+			Low:       price - 1,
+			Close:     price,
+		}
+	}
+
+	from := base.Add(25 * 3 * time.Minute)
+	rows := buildExportRows(klines, from, time.Time{})
+	if len(rows) != 5 {
+		t.Fatalf("期望5根K线，得到 %d", len(rows))
+	}
+	// 指标基于完整历史计算，过滤后首根仍应有EMA20
+	if rows[0].EMA20 == 0 || rows[0].OpenTime != from.UnixMilli() {
+		t.Errorf("首根导出K线不正确: %+v", rows[0])
+	}
+
+	all := buildExportRows(klines, time.Time{}, time.Time{})
+	if len(all) != len(klines) || all[0].EMA20 != 0 {
+		t.Errorf("不过滤时应导出全部K线，且历史不足时指标为0")
+	}
+}
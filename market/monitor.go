@@ -3,7 +3,8 @@ package market
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -22,6 +23,7 @@ type WSMonitor struct {
 	filterSymbols  sync.Map // 使用sync.Map来存储需要监控的币种和其状态
 	symbolStats    sync.Map // 存储币种统计信息
 	FilterSymbol   []string //经过筛选的币种
+	lastUpdateMap  sync.Map // 存储每个交易对各周期K线的最后更新时间（key: symbol_interval）
 }
 type SymbolStats struct {
 	LastActiveTime   time.Time
@@ -34,6 +36,9 @@ type SymbolStats struct {
 var WSMonitorCli *WSMonitor
 var subKlineTime = []string{"3m", "4h"} // 管理订阅流的K线周期
 
+// klineHistoryLimit 每个周期回填和缓存的K线数量（需覆盖EMA50/RSI14/ATR14等指标的预热期）
+const klineHistoryLimit = 200
+
 func NewWSMonitor(batchSize int) *WSMonitor {
 	WSMonitorCli = &WSMonitor{
 		wsClient:       NewWSClient(),
@@ -41,11 +46,12 @@ func NewWSMonitor(batchSize int) *WSMonitor {
 		alertsChan:     make(chan Alert, 1000),
 		batchSize:      batchSize,
 	}
+	WSMonitorCli.combinedClient.OnReconnect = WSMonitorCli.gapFill
 	return WSMonitorCli
 }
 
 func (m *WSMonitor) Initialize(coins []string) error {
-	log.Println("初始化WebSocket监控器...")
+	slog.Info("初始化WebSocket监控器")
 	// 获取交易对信息
 	apiClient := NewAPIClient()
 	// 如果不指定交易对，则使用market市场的所有交易对币种
@@ -66,10 +72,10 @@ func (m *WSMonitor) Initialize(coins []string) error {
 		m.symbols = coins
 	}
 
-	log.Printf("找到 %d 个交易对", len(m.symbols))
+	slog.Info("找到交易对", "count", len(m.symbols))
 	// 初始化历史数据
 	if err := m.initializeHistoricalData(); err != nil {
-		log.Printf("初始化历史数据失败: %v", err)
+		slog.Error("初始化历史数据失败", "error", err)
 	}
 
 	return nil
@@ -89,25 +95,13 @@ func (m *WSMonitor) initializeHistoricalData() error {
 			defer wg.Done()
 			defer func() { <-semaphore }()
 
-			// 获取历史K线数据
-			klines, err := apiClient.GetKlines(s, "3m", 100)
-			if err != nil {
-				log.Printf("获取 %s 历史数据失败: %v", s, err)
-				return
-			}
-			if len(klines) > 0 {
-				m.klineDataMap3m.Store(s, klines)
-				log.Printf("已加载 %s 的历史K线数据-3m: %d 条", s, len(klines))
-			}
-			// 获取历史K线数据
-			klines4h, err := apiClient.GetKlines(s, "4h", 100)
-			if err != nil {
-				log.Printf("获取 %s 历史数据失败: %v", s, err)
-				return
-			}
-			if len(klines4h) > 0 {
-				m.klineDataMap4h.Store(s, klines4h)
-				log.Printf("已加载 %s 的历史K线数据-4h: %d 条", s, len(klines4h))
+			for _, st := range subKlineTime {
+				klines, err := m.backfillKlines(apiClient, s, st)
+				if err != nil {
+					slog.Warn("获取历史K线数据失败", "symbol", s, "interval", st, "error", err)
+					return
+				}
+				slog.Debug("已加载历史K线数据", "symbol", s, "interval", st, "count", len(klines))
 			}
 		}(symbol)
 	}
@@ -116,25 +110,194 @@ func (m *WSMonitor) initializeHistoricalData() error {
 	return nil
 }
 
+// backfillKlines 通过REST回填指定周期的历史K线并写入缓存
+func (m *WSMonitor) backfillKlines(apiClient *APIClient, symbol, _time string) ([]Kline, error) {
+	klines, err := apiClient.GetKlines(symbol, _time, klineHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("%s 无%s历史K线", symbol, _time)
+	}
+	m.getKlineDataMap(_time).Store(strings.ToUpper(symbol), klines)
+	m.markUpdated(symbol, _time)
+	return klines, nil
+}
+
+// gapFill 重连后通过REST补齐断线期间缺失的K线
+func (m *WSMonitor) gapFill(downtime time.Duration) {
+	apiClient := NewAPIClient()
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 5) // 限制并发数
+
+	for _, st := range subKlineTime {
+		limit := gapFillLimit(st, downtime)
+		klineDataMap := m.getKlineDataMap(st)
+
+		var symbols []string
+		klineDataMap.Range(func(key, _ interface{}) bool {
+			symbols = append(symbols, key.(string))
+			return true
+		})
+		slog.Info("断线后补齐K线", "downtime", downtime.Round(time.Second), "symbols", len(symbols), "interval", st, "limit", limit)
+
+		for _, symbol := range symbols {
+			wg.Add(1)
+			semaphore <- struct{}{}
+			go func(symbol, st string) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+
+				fresh, err := apiClient.GetKlines(symbol, st, limit)
+				if err != nil || len(fresh) == 0 {
+					slog.Warn("补齐K线失败", "symbol", symbol, "interval", st, "error", err)
+					return
+				}
+				value, _ := klineDataMap.Load(symbol)
+				existing, _ := value.([]Kline)
+				klineDataMap.Store(symbol, mergeKlines(existing, fresh, klineHistoryLimit))
+				m.markUpdated(symbol, st)
+			}(symbol, st)
+		}
+	}
+
+	wg.Wait()
+	slog.Info("K线缺口补齐完成")
+}
+
+// gapFillLimit 根据断线时长计算需要补齐的K线数量（多取2根覆盖边界，最多取满缓存）
+func gapFillLimit(interval string, downtime time.Duration) int {
+	intervalDuration := map[string]time.Duration{"3m": 3 * time.Minute, "4h": 4 * time.Hour}[interval]
+	if intervalDuration == 0 {
+		return klineHistoryLimit
+	}
+	limit := int(downtime/intervalDuration) + 2
+	if limit > klineHistoryLimit {
+		limit = klineHistoryLimit
+	}
+	return limit
+}
+
+// mergeKlines 按开盘时间合并K线：相同时间以新数据覆盖，新K线追加，并保留最近maxLen根
+func mergeKlines(existing, fresh []Kline, maxLen int) []Kline {
+	merged := make([]Kline, 0, len(existing)+len(fresh))
+	for _, k := range existing {
+		if len(fresh) > 0 && k.OpenTime >= fresh[0].OpenTime {
+			break
+		}
+		merged = append(merged, k)
+	}
+	merged = append(merged, fresh...)
+	if len(merged) > maxLen {
+		merged = merged[len(merged)-maxLen:]
+	}
+	return merged
+}
+
+// markUpdated 记录K线最后更新时间
+func (m *WSMonitor) markUpdated(symbol, _time string) {
+	m.lastUpdateMap.Store(strings.ToUpper(symbol)+"_"+_time, time.Now())
+}
+
+// GetDataAge 获取币种K线数据距上次更新的时长（取各订阅周期中最旧的一个）
+func (m *WSMonitor) GetDataAge(symbol string) (time.Duration, bool) {
+	symbol = strings.ToUpper(symbol)
+	var maxAge time.Duration
+	for _, st := range subKlineTime {
+		value, exists := m.lastUpdateMap.Load(symbol + "_" + st)
+		if !exists {
+			return 0, false
+		}
+		if age := time.Since(value.(time.Time)); age > maxAge {
+			maxAge = age
+		}
+	}
+	return maxAge, true
+}
+
+// IsStale 判断币种K线数据是否过期（从未更新过的币种不视为过期，由首次获取时回填）
+func (m *WSMonitor) IsStale(symbol string) bool {
+	age, exists := m.GetDataAge(symbol)
+	return exists && age > config.StaleThreshold
+}
+
+// GetStaleSymbols 获取所有数据过期的币种及其数据时长（秒）
+func (m *WSMonitor) GetStaleSymbols() map[string]float64 {
+	stale := make(map[string]float64)
+	symbols := make(map[string]bool)
+	m.lastUpdateMap.Range(func(key, _ interface{}) bool {
+		k := key.(string)
+		symbols[k[:strings.LastIndex(k, "_")]] = true
+		return true
+	})
+	for symbol := range symbols {
+		if age, exists := m.GetDataAge(symbol); exists && age > config.StaleThreshold {
+			stale[symbol] = age.Seconds()
+		}
+	}
+	return stale
+}
+
+// IsStale 判断币种市场数据是否过期（监控器未启动时返回false）
+func IsStale(symbol string) bool {
+	if WSMonitorCli == nil {
+		return false
+	}
+	return WSMonitorCli.IsStale(Normalize(symbol))
+}
+
+// GetStaleThreshold 获取过期数据判定阈值
+func GetStaleThreshold() time.Duration {
+	return config.StaleThreshold
+}
+
+// FeedStatus WebSocket行情的新鲜度汇总
+type FeedStatus struct {
+	Running      bool       `json:"running"`
+	Symbols      int        `json:"symbols"`       // 收到过K线的币种数
+	StaleSymbols int        `json:"stale_symbols"` // 数据过期的币种数
+	LatestUpdate *time.Time `json:"latest_update,omitempty"`
+}
+
+// GetFeedStatus 行情推送状态（监控器未启动时 Running 为false）
+func GetFeedStatus() FeedStatus {
+	m := WSMonitorCli
+	if m == nil {
+		return FeedStatus{}
+	}
+	status := FeedStatus{Running: true, StaleSymbols: len(m.GetStaleSymbols())}
+	symbols := make(map[string]bool)
+	m.lastUpdateMap.Range(func(key, value interface{}) bool {
+		k := key.(string)
+		symbols[k[:strings.LastIndex(k, "_")]] = true
+		if t := value.(time.Time); status.LatestUpdate == nil || t.After(*status.LatestUpdate) {
+			status.LatestUpdate = &t
+		}
+		return true
+	})
+	status.Symbols = len(symbols)
+	return status
+}
+
 func (m *WSMonitor) Start(coins []string) {
-	log.Printf("启动WebSocket实时监控...")
+	slog.Info("启动WebSocket实时监控")
 	// 初始化交易对
 	err := m.Initialize(coins)
 	if err != nil {
-		log.Fatalf("❌ 初始化币种: %v", err)
-		return
+		slog.Error("初始化币种失败", "error", err)
+		os.Exit(1)
 	}
 
 	err = m.combinedClient.Connect()
 	if err != nil {
-		log.Fatalf("❌ 批量订阅流: %v", err)
-		return
+		slog.Error("批量订阅流失败", "error", err)
+		os.Exit(1)
 	}
 	// 订阅所有交易对
 	err = m.subscribeAll()
 	if err != nil {
-		log.Fatalf("❌ 订阅币种交易对: %v", err)
-		return
+		slog.Error("订阅币种交易对失败", "error", err)
+		os.Exit(1)
 	}
 }
 
@@ -150,7 +313,7 @@ func (m *WSMonitor) subscribeSymbol(symbol, st string) []string {
 }
 func (m *WSMonitor) subscribeAll() error {
 	// 执行批量订阅
-	log.Println("开始订阅所有交易对...")
+	slog.Info("开始订阅所有交易对")
 	for _, symbol := range m.symbols {
 		for _, st := range subKlineTime {
 			m.subscribeSymbol(symbol, st)
@@ -159,11 +322,10 @@ func (m *WSMonitor) subscribeAll() error {
 	for _, st := range subKlineTime {
 		err := m.combinedClient.BatchSubscribeKlines(m.symbols, st)
 		if err != nil {
-			log.Fatalf("❌ 订阅3m K线: %v", err)
-			return err
+			return fmt.Errorf("订阅%s K线: %w", st, err)
 		}
 	}
-	log.Println("所有交易对订阅完成")
+	slog.Info("所有交易对订阅完成")
 	return nil
 }
 
@@ -171,7 +333,7 @@ func (m *WSMonitor) handleKlineData(symbol string, ch <-chan []byte, _time strin
 	for data := range ch {
 		var klineData KlineWSData
 		if err := json.Unmarshal(data, &klineData); err != nil {
-			log.Printf("解析Kline数据失败: %v", err)
+			slog.Warn("解析Kline数据失败", "symbol", symbol, "error", err)
 			continue
 		}
 		m.processKlineUpdate(symbol, klineData, _time)
@@ -221,7 +383,7 @@ func (m *WSMonitor) processKlineUpdate(symbol string, wsData KlineWSData, _time
 			klines = append(klines, kline)
 
 			// 保持数据长度
-			if len(klines) > 100 {
+			if len(klines) > klineHistoryLimit {
 				klines = klines[1:]
 			}
 		}
@@ -230,26 +392,25 @@ func (m *WSMonitor) processKlineUpdate(symbol string, wsData KlineWSData, _time
 	}
 
 	klineDataMap.Store(symbol, klines)
+	m.markUpdated(symbol, _time)
 }
 
 func (m *WSMonitor) GetCurrentKlines(symbol string, _time string) ([]Kline, error) {
 	// 对每一个进来的symbol检测是否存在内类 是否的话就订阅它
 	value, exists := m.getKlineDataMap(_time).Load(symbol)
 	if !exists {
-		// 如果Ws数据未初始化完成时,单独使用api获取 - 兼容性代码 (防止在未初始化完成是,已经有交易员运行)
-		apiClient := NewAPIClient()
-		klines, err := apiClient.GetKlines(symbol, _time, 100)
-		m.getKlineDataMap(_time).Store(strings.ToUpper(symbol), klines) //动态缓存进缓存
-		subStr := m.subscribeSymbol(symbol, _time)
-		subErr := m.combinedClient.subscribeStreams(subStr)
-		log.Printf("动态订阅流: %v", subStr)
-		if subErr != nil {
-			return nil, fmt.Errorf("动态订阅%v分钟K线失败: %v", _time, subErr)
-		}
+		// 新币种（或WS数据未初始化完成时）先通过REST回填历史K线，保证指标立即可用，再动态订阅后续更新
+		klines, err := m.backfillKlines(NewAPIClient(), symbol, _time)
 		if err != nil {
 			return nil, fmt.Errorf("获取%v分钟K线失败: %v", _time, err)
 		}
-		return klines, fmt.Errorf("symbol不存在")
+		subStr := m.subscribeSymbol(symbol, _time)
+		if subErr := m.combinedClient.subscribeStreams(subStr); subErr != nil {
+			slog.Warn("动态订阅K线失败", "symbol", symbol, "interval", _time, "error", subErr)
+		} else {
+			slog.Debug("动态订阅流", "streams", subStr)
+		}
+		return klines, nil
 	}
 	return value.([]Kline), nil
 }
@@ -0,0 +1,35 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMergeKlines 测试重连后K线缺口合并
+func TestMergeKlines(t *testing.T) {
+	existing := []Kline{{OpenTime: 1, Close: 1}, {OpenTime: 2, Close: 2}, {OpenTime: 3, Close: 3}}
+	fresh := []Kline{{OpenTime: 3, Close: 30}, {OpenTime: 4, Close: 4}, {OpenTime: 5, Close: 5}}
+
+	merged := mergeKlines(existing, fresh, 10)
+	if len(merged) != 5 {
+		t.Fatalf("期望5根K线，实际 %d", len(merged))
+	}
+	if merged[2].Close != 30 {
+		t.Errorf("重叠的K线应以新数据为准，实际: %.0f", merged[2].Close)
+	}
+
+	capped := mergeKlines(existing, fresh, 3)
+	if len(capped) != 3 || capped[0].OpenTime != 3 {
+		t.Errorf("超出上限应保留最近的K线，实际: %+v", capped)
+	}
+}
+
+// TestGapFillLimit 测试补齐数量计算
+func TestGapFillLimit(t *testing.T) {
+	if got := gapFillLimit("3m", 30*time.Minute); got != 12 {
+		t.Errorf("断线30分钟应补齐12根3m K线，实际 %d", got)
+	}
+	if got := gapFillLimit("3m", 48*time.Hour); got != klineHistoryLimit {
+		t.Errorf("长时间断线应取满缓存，实际 %d", got)
+	}
+}
@@ -0,0 +1,164 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const orderBookDepthLimit = 100 // 深度档位数量
+
+// slippageReferenceSizes 输出到prompt中的参考仓位规模（USD）
+var slippageReferenceSizes = []float64{10000, 50000, 200000}
+
+// OrderBookLevel 盘口档位
+type OrderBookLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// OrderBook 订单簿快照
+type OrderBook struct {
+	Bids []OrderBookLevel // 买盘（价格从高到低）
+	Asks []OrderBookLevel // 卖盘（价格从低到高）
+}
+
+// LiquidityData 流动性与滑点估算
+type LiquidityData struct {
+	SpreadBps   float64             // 买一卖一价差（基点）
+	BidDepthUSD float64             // 快照内买盘总深度
+	AskDepthUSD float64             // 快照内卖盘总深度
+	SlippageBps map[float64]float64 // 参考仓位规模 -> 买入方向预估滑点（基点）
+	OrderBook   *OrderBook          // 原始快照（供下单前按实际仓位估算）
+}
+
+func (c *APIClient) GetOrderBook(symbol string, limit int) (*OrderBook, error) {
+	url := fmt.Sprintf("%s/fapi/v1/depth", baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("symbol", symbol)
+	q.Add("limit", strconv.Itoa(limit))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// 418/429/5xx 的错误体会解析成空订单簿，被误判为深度不足
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("订单簿返回错误 (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Bids [][]string `json:"bids"`
+		Asks [][]string `json:"asks"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return &OrderBook{
+		Bids: parseOrderBookLevels(result.Bids),
+		Asks: parseOrderBookLevels(result.Asks),
+	}, nil
+}
+
+func parseOrderBookLevels(raw [][]string) []OrderBookLevel {
+	levels := make([]OrderBookLevel, 0, len(raw))
+	for _, r := range raw {
+		if len(r) < 2 {
+			continue
+		}
+		price, _ := strconv.ParseFloat(r[0], 64)
+		qty, _ := strconv.ParseFloat(r[1], 64)
+		levels = append(levels, OrderBookLevel{Price: price, Quantity: qty})
+	}
+	return levels
+}
+
+// EstimateSlippage 估算市价单吃掉指定名义价值时相对最优价的滑点（基点）
+// isBuy=true 消耗卖盘（开多/平空），false 消耗买盘（开空/平多）
+// 深度不足以成交全部数量时 filled=false，滑点按最后一档计算
+func (ob *OrderBook) EstimateSlippage(notionalUSD float64, isBuy bool) (bps float64, filled bool) {
+	levels := ob.Bids
+	if isBuy {
+		levels = ob.Asks
+	}
+	if len(levels) == 0 || notionalUSD <= 0 {
+		return 0, len(levels) > 0
+	}
+
+	bestPrice := levels[0].Price
+	remaining := notionalUSD
+	cost, qty := 0.0, 0.0
+	for _, level := range levels {
+		levelNotional := level.Price * level.Quantity
+		take := levelNotional
+		if remaining < take {
+			take = remaining
+		}
+		cost += take
+		qty += take / level.Price
+		remaining -= take
+		if remaining <= 0 {
+			filled = true
+			break
+		}
+	}
+	if qty == 0 || bestPrice == 0 {
+		return 0, filled
+	}
+
+	avgPrice := cost / qty
+	if isBuy {
+		bps = (avgPrice - bestPrice) / bestPrice * 10000
+	} else {
+		bps = (bestPrice - avgPrice) / bestPrice * 10000
+	}
+	return bps, filled
+}
+
+// analyzeLiquidity 根据订单簿计算价差、深度和参考规模的滑点
+func analyzeLiquidity(ob *OrderBook) *LiquidityData {
+	data := &LiquidityData{SlippageBps: make(map[float64]float64), OrderBook: ob}
+	if len(ob.Bids) == 0 || len(ob.Asks) == 0 {
+		return data
+	}
+
+	bestBid, bestAsk := ob.Bids[0].Price, ob.Asks[0].Price
+	if mid := (bestBid + bestAsk) / 2; mid > 0 {
+		data.SpreadBps = (bestAsk - bestBid) / mid * 10000
+	}
+	for _, l := range ob.Bids {
+		data.BidDepthUSD += l.Price * l.Quantity
+	}
+	for _, l := range ob.Asks {
+		data.AskDepthUSD += l.Price * l.Quantity
+	}
+	for _, size := range slippageReferenceSizes {
+		bps, _ := ob.EstimateSlippage(size, true)
+		data.SlippageBps[size] = bps
+	}
+	return data
+}
+
+// getLiquidityData 获取订单簿并估算流动性
+func getLiquidityData(symbol string) (*LiquidityData, error) {
+	ob, err := NewAPIClient().GetOrderBook(symbol, orderBookDepthLimit)
+	if err != nil {
+		return nil, err
+	}
+	return analyzeLiquidity(ob), nil
+}
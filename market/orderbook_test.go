@@ -0,0 +1,35 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+// TestEstimateSlippage 测试按订单簿深度估算滑点
+func TestEstimateSlippage(t *testing.T) {
+	ob := &OrderBook{
+		Bids: []OrderBookLevel{{Price: 99.9, Quantity: 100}, {Price: 99, Quantity: 100}},
+		Asks: []OrderBookLevel{{Price: 100, Quantity: 100}, {Price: 101, Quantity: 100}},
+	}
+
+	// 只吃第一档，无滑点
+	if bps, filled := ob.EstimateSlippage(5000, true); bps != 0 || !filled {
+		t.Errorf("第一档内成交应无滑点，实际: %.2f bps filled=%v", bps, filled)
+	}
+
+	// 吃满第一档(10000) + 第二档一半(5050)：均价 = 15050 / (100 + 50) ≈ 100.333
+	bps, filled := ob.EstimateSlippage(15050, true)
+	if !filled || math.Abs(bps-33.33) > 0.1 {
+		t.Errorf("跨档成交滑点计算错误: %.2f bps filled=%v", bps, filled)
+	}
+
+	// 深度不足
+	if _, filled := ob.EstimateSlippage(1e6, false); filled {
+		t.Error("深度不足时filled应为false")
+	}
+
+	liq := analyzeLiquidity(ob)
+	if math.Abs(liq.SpreadBps-10) > 0.1 {
+		t.Errorf("价差计算错误: %.2f bps", liq.SpreadBps)
+	}
+}
@@ -0,0 +1,112 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	globalMetricsURL    = "https://api.coingecko.com/api/v3/global"
+	marketOverviewCache = 2 * time.Minute // 多个trader同一周期内共享，避免重复请求
+)
+
+var (
+	overviewMu     sync.Mutex
+	cachedOverview *MarketOverview
+)
+
+// GetMarketOverview 获取全市场概览（BTC占比、总市值/TOTAL3变化、BTC/ETH 24h涨跌）
+// 锁只保护缓存，请求在锁外进行，上游慢时不会阻塞其他trader
+func GetMarketOverview() (*MarketOverview, error) {
+	overviewMu.Lock()
+	cached := cachedOverview
+	overviewMu.Unlock()
+
+	if cached != nil && time.Since(cached.UpdatedAt) < marketOverviewCache {
+		return cached, nil
+	}
+
+	overview, err := fetchMarketOverview()
+	if err != nil {
+		// 请求失败时沿用旧数据
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	overviewMu.Lock()
+	cachedOverview = overview
+	overviewMu.Unlock()
+	return overview, nil
+}
+
+// fetchMarketOverview 请求全局指标并结合BTC/ETH行情推算TOTAL3
+func fetchMarketOverview() (*MarketOverview, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(globalMetricsURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求全局市场数据失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取全局市场数据失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("全局市场数据返回错误 (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data struct {
+			TotalMarketCap              map[string]float64 `json:"total_market_cap"`
+			MarketCapPercentage         map[string]float64 `json:"market_cap_percentage"`
+			MarketCapChangePercentage24 float64            `json:"market_cap_change_percentage_24h_usd"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析全局市场数据失败: %w", err)
+	}
+
+	overview := &MarketOverview{
+		BTCDominance:         result.Data.MarketCapPercentage["btc"],
+		ETHDominance:         result.Data.MarketCapPercentage["eth"],
+		TotalMarketCap:       result.Data.TotalMarketCap["usd"],
+		TotalMarketCapChange: result.Data.MarketCapChangePercentage24,
+		UpdatedAt:            time.Now(),
+	}
+
+	apiClient := NewAPIClient()
+	if ticker, err := apiClient.Get24hrTicker("BTCUSDT"); err == nil {
+		overview.BTCPriceChange24h, _ = strconv.ParseFloat(ticker.PriceChangePercent, 64)
+	}
+	if ticker, err := apiClient.Get24hrTicker("ETHUSDT"); err == nil {
+		overview.ETHPriceChange24h, _ = strconv.ParseFloat(ticker.PriceChangePercent, 64)
+	}
+
+	overview.Total3MarketCap, overview.Total3MarketCapChange = calculateTotal3(overview)
+	return overview, nil
+}
+
+// calculateTotal3 由总市值、BTC/ETH占比及24h涨跌反推TOTAL3及其24h变化
+func calculateTotal3(o *MarketOverview) (float64, float64) {
+	btcCap := o.TotalMarketCap * o.BTCDominance / 100
+	ethCap := o.TotalMarketCap * o.ETHDominance / 100
+	total3 := o.TotalMarketCap - btcCap - ethCap
+
+	// 24h前的市值 = 当前市值 / (1 + 涨跌幅)
+	totalAgo := o.TotalMarketCap / (1 + o.TotalMarketCapChange/100)
+	btcAgo := btcCap / (1 + o.BTCPriceChange24h/100)
+	ethAgo := ethCap / (1 + o.ETHPriceChange24h/100)
+	total3Ago := totalAgo - btcAgo - ethAgo
+	if total3Ago <= 0 {
+		return total3, 0
+	}
+	return total3, (total3 - total3Ago) / total3Ago * 100
+}
@@ -0,0 +1,41 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateTotal3(t *testing.T) {
+	tests := []struct {
+		name       string
+		overview   MarketOverview
+		wantTotal3 float64
+		wantChange float64
+	}{
+		{
+			name:       "总市值不变、BTC上涨，TOTAL3下跌",
+			overview:   MarketOverview{TotalMarketCap: 1000, BTCDominance: 50, ETHDominance: 20, BTCPriceChange24h: 25},
+			wantTotal3: 300,
+			wantChange: -25,
+		},
+		{
+			name:       "全部同幅上涨",
+			overview:   MarketOverview{TotalMarketCap: 1100, BTCDominance: 50, ETHDominance: 20, TotalMarketCapChange: 10, BTCPriceChange24h: 10, ETHPriceChange24h: 10},
+			wantTotal3: 330,
+			wantChange: 10,
+		},
+		{
+			name:       "反推出的24h前TOTAL3非正时变化记为0",
+			overview:   MarketOverview{TotalMarketCap: 1000, BTCDominance: 60, ETHDominance: 30, BTCPriceChange24h: -50},
+			wantTotal3: 100,
+			wantChange: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		total3, change := calculateTotal3(&tt.overview)
+		if math.Abs(total3-tt.wantTotal3) > 1e-9 || math.Abs(change-tt.wantChange) > 1e-9 {
+			t.Errorf("%s: 得到 total3=%.4f change=%.4f，期望 %.4f/%.4f", tt.name, total3, change, tt.wantTotal3, tt.wantChange)
+		}
+	}
+}
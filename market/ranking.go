@@ -0,0 +1,163 @@
+package market
+
+import (
+	"sort"
+	"sync"
+)
+
+// candidateBackfillWorkers 回填未缓存候选币K线时的并发数
+const candidateBackfillWorkers = 4
+
+// loadCandidateKlines 仅通过REST获取4h K线，不订阅WS（候选币未必会交易，订阅后无人退订）（测试中可替换）
+var loadCandidateKlines = func(symbol string) ([]Kline, error) {
+	return NewAPIClient().GetKlines(symbol, "4h", klineHistoryLimit)
+}
+
+// CandidateMetrics 候选币排序指标
+type CandidateMetrics struct {
+	Symbol         string
+	ATRPercent     float64 // 4h ATR14 占价格的百分比
+	QuoteVolume24h float64 // 最近24小时成交额（USDT）
+}
+
+// GetCandidateMetrics 从已缓存的4h K线计算排序指标（不发起REST请求，未缓存时返回false）
+func GetCandidateMetrics(symbol string) (*CandidateMetrics, bool) {
+	if WSMonitorCli == nil {
+		return nil, false
+	}
+	symbol = Normalize(symbol)
+	value, ok := WSMonitorCli.getKlineDataMap("4h").Load(symbol)
+	if !ok {
+		return nil, false
+	}
+	return candidateMetricsFromKlines(symbol, value.([]Kline))
+}
+
+func candidateMetricsFromKlines(symbol string, klines []Kline) (*CandidateMetrics, bool) {
+	if len(klines) <= 14 {
+		return nil, false
+	}
+	price := klines[len(klines)-1].Close
+	if price <= 0 {
+		return nil, false
+	}
+
+	// 6根4h K线 = 24小时
+	volume := 0.0
+	for _, k := range klines[len(klines)-6:] {
+		volume += k.QuoteVolume
+	}
+
+	return &CandidateMetrics{
+		Symbol:         symbol,
+		ATRPercent:     calculateATR(klines, 14) / price * 100,
+		QuoteVolume24h: volume,
+	}, true
+}
+
+// RankCandidates 按波动率（ATR%）和24h成交额综合排序，缺少数据的币种保持原顺序排在最后
+// 未缓存K线的币种（新上币、新信号源）先回填再参与排序，否则截断后永远轮不到它们
+func RankCandidates(symbols []string) []string {
+	metrics := make(map[string]*CandidateMetrics, len(symbols))
+	var uncached []string
+	for _, symbol := range symbols {
+		symbol = Normalize(symbol)
+		if _, ok := metrics[symbol]; ok {
+			continue
+		}
+		if m, ok := GetCandidateMetrics(symbol); ok {
+			metrics[symbol] = m
+		} else {
+			uncached = append(uncached, symbol)
+		}
+	}
+	for symbol, m := range backfillCandidateMetrics(uncached) {
+		metrics[symbol] = m
+	}
+	return rankByMetrics(symbols, metrics)
+}
+
+// backfillCandidateMetrics 并发获取未缓存币种的K线并计算指标，获取失败的币种不返回（symbols需已标准化）
+func backfillCandidateMetrics(symbols []string) map[string]*CandidateMetrics {
+	result := make(map[string]*CandidateMetrics, len(symbols))
+	if len(symbols) == 0 {
+		return result
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	for i := 0; i < candidateBackfillWorkers && i < len(symbols); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range jobs {
+				klines, err := loadCandidateKlines(symbol)
+				if err != nil {
+					continue
+				}
+				if m, ok := candidateMetricsFromKlines(symbol, klines); ok {
+					mu.Lock()
+					result[symbol] = m
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, symbol := range symbols {
+		jobs <- symbol
+	}
+	close(jobs)
+	wg.Wait()
+	return result
+}
+
+// rankByMetrics 两项指标各自换算成分位数后取平均，避免成交额的数量级压过波动率
+// metrics按标准化后的币种索引，返回结果保留调用方传入的原始写法
+func rankByMetrics(symbols []string, metrics map[string]*CandidateMetrics) []string {
+	normalized := make(map[string]string, len(symbols))
+	ranked := make([]*CandidateMetrics, 0, len(metrics))
+	added := make(map[string]bool, len(metrics))
+	for _, symbol := range symbols {
+		key := Normalize(symbol)
+		normalized[symbol] = key
+		if m, ok := metrics[key]; ok && !added[key] {
+			added[key] = true
+			ranked = append(ranked, m)
+		}
+	}
+
+	atrPct := percentiles(ranked, func(m *CandidateMetrics) float64 { return m.ATRPercent })
+	volPct := percentiles(ranked, func(m *CandidateMetrics) float64 { return m.QuoteVolume24h })
+	score := make(map[string]float64, len(ranked))
+	for _, m := range ranked {
+		score[m.Symbol] = (atrPct[m.Symbol] + volPct[m.Symbol]) / 2
+	}
+
+	result := append([]string(nil), symbols...)
+	sort.SliceStable(result, func(i, j int) bool {
+		si, iok := score[normalized[result[i]]]
+		sj, jok := score[normalized[result[j]]]
+		if iok != jok {
+			return iok
+		}
+		return si > sj
+	})
+	return result
+}
+
+// percentiles 返回每个币种在该指标上的分位数（0~1，越大越好）
+func percentiles(items []*CandidateMetrics, value func(*CandidateMetrics) float64) map[string]float64 {
+	sorted := append([]*CandidateMetrics(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return value(sorted[i]) < value(sorted[j]) })
+
+	result := make(map[string]float64, len(sorted))
+	for i, m := range sorted {
+		if len(sorted) == 1 {
+			result[m.Symbol] = 1
+			continue
+		}
+		result[m.Symbol] = float64(i) / float64(len(sorted)-1)
+	}
+	return result
+}
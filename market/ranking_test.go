@@ -0,0 +1,87 @@
+package market
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRankByMetrics(t *testing.T) {
+	symbols := []string{"AUSDT", "BUSDT", "CUSDT", "DUSDT"}
+	metrics := map[string]*CandidateMetrics{
+		"AUSDT": {Symbol: "AUSDT", ATRPercent: 1.0, QuoteVolume24h: 1e6},
+		"BUSDT": {Symbol: "BUSDT", ATRPercent: 3.0, QuoteVolume24h: 5e8},
+		"CUSDT": {Symbol: "CUSDT", ATRPercent: 2.0, QuoteVolume24h: 1e8},
+	}
+
+	got := rankByMetrics(symbols, metrics)
+	want := []string{"BUSDT", "CUSDT", "AUSDT", "DUSDT"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("排序结果 %v，期望 %v", got, want)
+	}
+}
+
+func TestRankByMetricsNormalizesSymbols(t *testing.T) {
+	metrics := map[string]*CandidateMetrics{
+		"AUSDT": {Symbol: "AUSDT", ATRPercent: 1.0, QuoteVolume24h: 1e6},
+		"BUSDT": {Symbol: "BUSDT", ATRPercent: 3.0, QuoteVolume24h: 5e8},
+	}
+
+	got := rankByMetrics([]string{"a", "DUSDT", "b"}, metrics)
+	want := []string{"b", "a", "DUSDT"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("排序结果 %v，期望 %v（未标准化的币种也应按指标排序）", got, want)
+	}
+}
+
+func TestCandidateMetricsFromKlines(t *testing.T) {
+	klines := make([]Kline, 20)
+	for i := range klines {
+		klines[i] = Kline{Open: 100, High: 102, Low: 98, Close: 100, QuoteVolume: 1000}
+	}
+
+	m, ok := candidateMetricsFromKlines("XUSDT", klines)
+	if !ok {
+		t.Fatal("K线足够时应返回指标")
+	}
+	if m.ATRPercent < 3.99 || m.ATRPercent > 4.01 {
+		t.Errorf("ATR%% = %.2f，期望约4", m.ATRPercent)
+	}
+	if m.QuoteVolume24h != 6000 {
+		t.Errorf("24h成交额 = %.0f，期望6000", m.QuoteVolume24h)
+	}
+
+	if _, ok := candidateMetricsFromKlines("XUSDT", klines[:10]); ok {
+		t.Error("K线不足时不应返回指标")
+	}
+}
+
+func TestRankCandidatesBackfillsUncached(t *testing.T) {
+	flatKlines := func(rangePct, quoteVolume float64) []Kline {
+		klines := make([]Kline, 20)
+		for i := range klines {
+			klines[i] = Kline{Open: 100, High: 100 + rangePct/2, Low: 100 - rangePct/2, Close: 100, QuoteVolume: quoteVolume}
+		}
+		return klines
+	}
+	loaded := map[string][]Kline{
+		"AUSDT":   flatKlines(1, 1e6),
+		"NEWUSDT": flatKlines(5, 1e8),
+	}
+
+	orig, origMonitor := loadCandidateKlines, WSMonitorCli
+	t.Cleanup(func() { loadCandidateKlines, WSMonitorCli = orig, origMonitor })
+	WSMonitorCli = nil // 没有任何缓存，全部走回填
+	loadCandidateKlines = func(symbol string) ([]Kline, error) {
+		if klines, ok := loaded[symbol]; ok {
+			return klines, nil
+		}
+		return nil, fmt.Errorf("无K线")
+	}
+
+	got := RankCandidates([]string{"AUSDT", "BADUSDT", "NEWUSDT"})
+	want := []string{"NEWUSDT", "AUSDT", "BADUSDT"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("排序结果 %v，期望 %v（未缓存币种应回填后参与排序）", got, want)
+	}
+}
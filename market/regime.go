@@ -0,0 +1,180 @@
+package market
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	RegimeTrendingUp   = "trending_up"
+	RegimeTrendingDown = "trending_down"
+	RegimeRanging      = "ranging"
+
+	VolatilityHigh   = "high"
+	VolatilityNormal = "normal"
+	VolatilityLow    = "low"
+
+	regimeADXTrend      = 25.0 // ADX高于该值视为趋势行情
+	regimeHighVolPctile = 80.0 // ATR%百分位高于该值视为高波动
+	regimeLowVolPctile  = 20.0 // ATR%百分位低于该值视为低波动
+)
+
+// classifyRegime 基于ADX和ATR百分位判断市场状态
+func classifyRegime(klines []Kline) *RegimeData {
+	regime := &RegimeData{Trend: RegimeRanging, Volatility: VolatilityNormal}
+	if len(klines) < 30 {
+		return regime
+	}
+
+	regime.ADX, regime.PlusDI, regime.MinusDI = calculateADX(klines, 14)
+	if regime.ADX >= regimeADXTrend {
+		if regime.PlusDI > regime.MinusDI {
+			regime.Trend = RegimeTrendingUp
+		} else {
+			regime.Trend = RegimeTrendingDown
+		}
+	}
+
+	regime.ATRPercentile = atrPercentile(klines, 14)
+	if regime.ATRPercentile >= regimeHighVolPctile {
+		regime.Volatility = VolatilityHigh
+	} else if regime.ATRPercentile <= regimeLowVolPctile {
+		regime.Volatility = VolatilityLow
+	}
+
+	return regime
+}
+
+// calculateADX 计算ADX及±DI（Wilder平滑）
+func calculateADX(klines []Kline, period int) (adx, plusDI, minusDI float64) {
+	if len(klines) < period*2+1 {
+		return 0, 0, 0
+	}
+
+	var trSum, plusDMSum, minusDMSum float64
+	var dxValues []float64
+	for i := 1; i < len(klines); i++ {
+		upMove := klines[i].High - klines[i-1].High
+		downMove := klines[i-1].Low - klines[i].Low
+		plusDM, minusDM := 0.0, 0.0
+		if upMove > downMove && upMove > 0 {
+			plusDM = upMove
+		}
+		if downMove > upMove && downMove > 0 {
+			minusDM = downMove
+		}
+		tr := math.Max(klines[i].High-klines[i].Low,
+			math.Max(math.Abs(klines[i].High-klines[i-1].Close), math.Abs(klines[i].Low-klines[i-1].Close)))
+
+		if i <= period {
+			trSum += tr
+			plusDMSum += plusDM
+			minusDMSum += minusDM
+			if i < period {
+				continue
+			}
+		} else {
+			trSum = trSum - trSum/float64(period) + tr
+			plusDMSum = plusDMSum - plusDMSum/float64(period) + plusDM
+			minusDMSum = minusDMSum - minusDMSum/float64(period) + minusDM
+		}
+
+		if trSum == 0 {
+			dxValues = append(dxValues, 0)
+			continue
+		}
+		plusDI = 100 * plusDMSum / trSum
+		minusDI = 100 * minusDMSum / trSum
+		dx := 0.0
+		if plusDI+minusDI > 0 {
+			dx = 100 * math.Abs(plusDI-minusDI) / (plusDI + minusDI)
+		}
+		dxValues = append(dxValues, dx)
+	}
+
+	if len(dxValues) < period {
+		return 0, plusDI, minusDI
+	}
+	for i := 0; i < period; i++ {
+		adx += dxValues[i]
+	}
+	adx /= float64(period)
+	for i := period; i < len(dxValues); i++ {
+		adx = (adx*float64(period-1) + dxValues[i]) / float64(period)
+	}
+	return adx, plusDI, minusDI
+}
+
+// atrPercentile 计算当前ATR%（ATR/收盘价）在历史序列中的百分位
+func atrPercentile(klines []Kline, period int) float64 {
+	if len(klines) <= period+1 {
+		return 50
+	}
+
+	var atrPcts []float64
+	atr := 0.0
+	for i := 1; i < len(klines); i++ {
+		tr := math.Max(klines[i].High-klines[i].Low,
+			math.Max(math.Abs(klines[i].High-klines[i-1].Close), math.Abs(klines[i].Low-klines[i-1].Close)))
+		if i <= period {
+			atr += tr / float64(period)
+			if i < period {
+				continue
+			}
+		} else {
+			atr = (atr*float64(period-1) + tr) / float64(period)
+		}
+		if klines[i].Close > 0 {
+			atrPcts = append(atrPcts, atr/klines[i].Close)
+		}
+	}
+	if len(atrPcts) < 2 {
+		return 50
+	}
+
+	current := atrPcts[len(atrPcts)-1]
+	sorted := append([]float64(nil), atrPcts...)
+	sort.Float64s(sorted)
+	below := sort.SearchFloat64s(sorted, current)
+	return float64(below) / float64(len(sorted)-1) * 100
+}
+
+// SummarizeMarketRegime 按币种多数投票汇总全市场状态
+func SummarizeMarketRegime(regimes []*RegimeData) *MarketRegimeData {
+	summary := &MarketRegimeData{Trend: RegimeRanging, Volatility: VolatilityNormal}
+	for _, r := range regimes {
+		if r == nil {
+			continue
+		}
+		switch r.Trend {
+		case RegimeTrendingUp:
+			summary.TrendingUp++
+		case RegimeTrendingDown:
+			summary.TrendingDown++
+		default:
+			summary.Ranging++
+		}
+		switch r.Volatility {
+		case VolatilityHigh:
+			summary.HighVolatility++
+		case VolatilityLow:
+			summary.LowVolatility++
+		}
+	}
+
+	total := summary.TrendingUp + summary.TrendingDown + summary.Ranging
+	if total == 0 {
+		return summary
+	}
+	if summary.TrendingUp > summary.TrendingDown && summary.TrendingUp > summary.Ranging {
+		summary.Trend = RegimeTrendingUp
+	} else if summary.TrendingDown > summary.TrendingUp && summary.TrendingDown > summary.Ranging {
+		summary.Trend = RegimeTrendingDown
+	}
+	if summary.HighVolatility*2 > total {
+		summary.Volatility = VolatilityHigh
+	} else if summary.LowVolatility*2 > total {
+		summary.Volatility = VolatilityLow
+	}
+	return summary
+}
@@ -0,0 +1,51 @@
+package market
+
+import "testing"
+
+// TestClassifyRegime 测试趋势与震荡行情识别
+func TestClassifyRegime(t *testing.T) {
+	// 持续上涨
+	var up []Kline
+	for i := 0; i < 60; i++ {
+		base := 100 + float64(i)*2
+		up = append(up, Kline{Open: base, High: base + 2.5, Low: base - 0.5, Close: base + 2})
+	}
+	if r := classifyRegime(up); r.Trend != RegimeTrendingUp {
+		t.Errorf("期望上涨趋势，实际: %+v", r)
+	}
+
+	// 窄幅来回震荡
+	var flat []Kline
+	for i := 0; i < 60; i++ {
+		base := 100.0
+		if i%2 == 0 {
+			base = 101
+		}
+		flat = append(flat, Kline{Open: base, High: base + 1, Low: base - 1, Close: base})
+	}
+	if r := classifyRegime(flat); r.Trend != RegimeRanging {
+		t.Errorf("期望震荡，实际: %+v", r)
+	}
+
+	// 末段波幅骤增 -> 高波动
+	spike := append([]Kline(nil), flat...)
+	for i := 0; i < 5; i++ {
+		spike = append(spike, Kline{Open: 100, High: 110, Low: 90, Close: 100})
+	}
+	if r := classifyRegime(spike); r.Volatility != VolatilityHigh {
+		t.Errorf("期望高波动，实际: %+v", r)
+	}
+}
+
+// TestSummarizeMarketRegime 测试全市场状态汇总
+func TestSummarizeMarketRegime(t *testing.T) {
+	summary := SummarizeMarketRegime([]*RegimeData{
+		{Trend: RegimeTrendingDown, Volatility: VolatilityHigh},
+		{Trend: RegimeTrendingDown, Volatility: VolatilityHigh},
+		{Trend: RegimeRanging, Volatility: VolatilityNormal},
+		nil,
+	})
+	if summary.Trend != RegimeTrendingDown || summary.Volatility != VolatilityHigh {
+		t.Errorf("汇总结果错误: %+v", summary)
+	}
+}
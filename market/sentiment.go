@@ -0,0 +1,172 @@
+package market
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fearGreedURL       = "https://api.alternative.me/fng/?limit=1"
+	sentimentCacheTTL  = 10 * time.Minute // 恐贪指数每日更新，新闻无需高频拉取
+	maxNewsHeadlines   = 8
+	sentimentHTTPLimit = 10 * time.Second
+)
+
+// SentimentData 市场情绪数据
+type SentimentData struct {
+	FearGreedValue int            `json:"fear_greed_value"` // 0-100，越低越恐慌
+	FearGreedLabel string         `json:"fear_greed_label"` // Extreme Fear / Fear / Neutral / Greed / Extreme Greed
+	Headlines      []NewsHeadline `json:"headlines,omitempty"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// NewsHeadline 新闻标题
+type NewsHeadline struct {
+	Title       string `json:"title"`
+	PublishedAt string `json:"published_at,omitempty"`
+}
+
+var (
+	sentimentMu     sync.Mutex
+	cachedSentiment *SentimentData
+	newsFeedURL     string
+)
+
+// SetNewsFeedURL 设置新闻标题RSS源（为空则不获取新闻）
+func SetNewsFeedURL(url string) {
+	sentimentMu.Lock()
+	defer sentimentMu.Unlock()
+	newsFeedURL = strings.TrimSpace(url)
+	cachedSentiment = nil
+}
+
+// GetSentiment 获取恐贪指数及新闻标题（带缓存，失败时沿用旧数据）
+// 锁只保护缓存，请求在锁外进行，上游慢时不会阻塞其他trader
+func GetSentiment() (*SentimentData, error) {
+	sentimentMu.Lock()
+	cached, feedURL := cachedSentiment, newsFeedURL
+	sentimentMu.Unlock()
+
+	if cached != nil && time.Since(cached.UpdatedAt) < sentimentCacheTTL {
+		return cached, nil
+	}
+
+	client := &http.Client{Timeout: sentimentHTTPLimit}
+	sentiment := &SentimentData{UpdatedAt: time.Now()}
+
+	value, label, err := fetchFearGreed(client)
+	if err != nil {
+		if cached != nil {
+			return cached, nil
+		}
+		return nil, err
+	}
+	sentiment.FearGreedValue = value
+	sentiment.FearGreedLabel = label
+
+	// 新闻源可选，失败不影响恐贪指数
+	if feedURL != "" {
+		if headlines, err := fetchNewsHeadlines(client, feedURL); err == nil {
+			sentiment.Headlines = headlines
+		} else if cached != nil {
+			sentiment.Headlines = cached.Headlines
+		}
+	}
+
+	sentimentMu.Lock()
+	// 请求期间新闻源被修改则丢弃本次结果，下次按新配置拉取
+	if newsFeedURL == feedURL {
+		cachedSentiment = sentiment
+	}
+	sentimentMu.Unlock()
+	return sentiment, nil
+}
+
+// fetchFearGreed 获取最新的恐贪指数
+func fetchFearGreed(client *http.Client) (int, string, error) {
+	resp, err := client.Get(fearGreedURL)
+	if err != nil {
+		return 0, "", fmt.Errorf("请求恐贪指数失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", fmt.Errorf("读取恐贪指数失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("恐贪指数返回错误 (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		Data []struct {
+			Value               string `json:"value"`
+			ValueClassification string `json:"value_classification"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, "", fmt.Errorf("解析恐贪指数失败: %w", err)
+	}
+	if len(result.Data) == 0 {
+		return 0, "", fmt.Errorf("恐贪指数数据为空")
+	}
+
+	value, err := strconv.Atoi(result.Data[0].Value)
+	if err != nil {
+		return 0, "", fmt.Errorf("恐贪指数格式错误: %w", err)
+	}
+	return value, result.Data[0].ValueClassification, nil
+}
+
+// fetchNewsHeadlines 从RSS源获取最新的新闻标题
+func fetchNewsHeadlines(client *http.Client, url string) ([]NewsHeadline, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求新闻源失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取新闻源失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("新闻源返回错误 (status %d)", resp.StatusCode)
+	}
+	return parseRSSHeadlines(body, maxNewsHeadlines)
+}
+
+// parseRSSHeadlines 解析RSS中的标题
+func parseRSSHeadlines(body []byte, limit int) ([]NewsHeadline, error) {
+	var rss struct {
+		Channel struct {
+			Items []struct {
+				Title   string `xml:"title"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(body, &rss); err != nil {
+		return nil, fmt.Errorf("解析新闻RSS失败: %w", err)
+	}
+
+	headlines := make([]NewsHeadline, 0, limit)
+	for _, item := range rss.Channel.Items {
+		if len(headlines) >= limit {
+			break
+		}
+		title := strings.TrimSpace(item.Title)
+		if title == "" {
+			continue
+		}
+		headlines = append(headlines, NewsHeadline{Title: title, PublishedAt: item.PubDate})
+	}
+	return headlines, nil
+}
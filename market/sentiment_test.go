@@ -0,0 +1,33 @@
+package market
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRSSHeadlines(t *testing.T) {
+	body := []byte(`<?xml version="1.0"?>
+<rss version="2.0"><channel>
+  <title>Crypto News</title>
+  <item><title> BTC breaks 100k </title><pubDate>Mon, 01 Jan 2025 00:00:00 GMT</pubDate></item>
+  <item><title></title></item>
+  <item><title>ETH ETF approved</title></item>
+  <item><title>SOL outage</title></item>
+</channel></rss>`)
+
+	headlines, err := parseRSSHeadlines(body, 2)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	want := []NewsHeadline{
+		{Title: "BTC breaks 100k", PublishedAt: "Mon, 01 Jan 2025 00:00:00 GMT"},
+		{Title: "ETH ETF approved"},
+	}
+	if !reflect.DeepEqual(headlines, want) {
+		t.Errorf("得到 %+v，期望 %+v（跳过空标题、去除空白并截断到上限）", headlines, want)
+	}
+
+	if _, err := parseRSSHeadlines([]byte("not xml <"), 5); err == nil {
+		t.Error("非法RSS应返回错误")
+	}
+}
@@ -0,0 +1,51 @@
+package market
+
+import (
+	"time"
+)
+
+// 交易时段（UTC小时，左闭右开，欧美时段有重叠）
+var tradingSessions = []struct {
+	name       string
+	start, end int
+}{
+	{"asia", 0, 8},
+	{"europe", 7, 16},
+	{"us", 13, 22},
+}
+
+const fundingInterval = 8 * time.Hour // Binance永续默认每8小时结算一次资金费（00/08/16 UTC）
+
+// SessionInfo 当前交易时段及关键时间点
+type SessionInfo struct {
+	Sessions            []string // 当前活跃的时段，空表示非主要交易时段
+	MinutesTo4hClose    int      // 距离当前4h K线收盘
+	MinutesToDailyClose int      // 距离日线收盘（00:00 UTC）
+	MinutesToFunding    int      // 距离下次资金费结算
+}
+
+// GetSessionInfo 计算指定时刻所处的交易时段和距离各收盘/结算点的分钟数
+func GetSessionInfo(now time.Time) *SessionInfo {
+	now = now.UTC()
+
+	sessions := make([]string, 0, 2)
+	hour := now.Hour()
+	for _, s := range tradingSessions {
+		if hour >= s.start && hour < s.end {
+			sessions = append(sessions, s.name)
+		}
+	}
+
+	return &SessionInfo{
+		Sessions:            sessions,
+		MinutesTo4hClose:    minutesToNextBoundary(now, 4*time.Hour),
+		MinutesToDailyClose: minutesToNextBoundary(now, 24*time.Hour),
+		MinutesToFunding:    minutesToNextBoundary(now, fundingInterval),
+	}
+}
+
+// minutesToNextBoundary 距离下一个按UTC对齐的周期边界的分钟数（向上取整）
+func minutesToNextBoundary(now time.Time, period time.Duration) int {
+	next := now.Truncate(period).Add(period)
+	return int((next.Sub(now) + time.Minute - 1) / time.Minute)
+}
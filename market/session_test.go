@@ -0,0 +1,32 @@
+package market
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetSessionInfo(t *testing.T) {
+	tests := []struct {
+		now      time.Time
+		sessions []string
+		to4h     int
+		toDaily  int
+		toFund   int
+	}{
+		{time.Date(2025, 1, 1, 2, 30, 0, 0, time.UTC), []string{"asia"}, 90, 21*60 + 30, 5*60 + 30},
+		{time.Date(2025, 1, 1, 14, 0, 0, 0, time.UTC), []string{"europe", "us"}, 120, 10 * 60, 2 * 60},
+		{time.Date(2025, 1, 1, 23, 59, 30, 0, time.UTC), []string{}, 1, 1, 1},
+	}
+
+	for _, tt := range tests {
+		info := GetSessionInfo(tt.now)
+		if !reflect.DeepEqual(info.Sessions, tt.sessions) {
+			t.Errorf("%v: 时段 %v，期望 %v", tt.now, info.Sessions, tt.sessions)
+		}
+		if info.MinutesTo4hClose != tt.to4h || info.MinutesToDailyClose != tt.toDaily || info.MinutesToFunding != tt.toFund {
+			t.Errorf("%v: 得到 4h=%d daily=%d funding=%d，期望 %d/%d/%d", tt.now,
+				info.MinutesTo4hClose, info.MinutesToDailyClose, info.MinutesToFunding, tt.to4h, tt.toDaily, tt.toFund)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	srPivotWindow    = 2             // 波段点左右确认K线数
+	srMaxLevels      = 3             // 每侧保留的支撑/阻力位数量
+	srMinTolerance   = 0.003         // 聚类最小容差（0.3%）
+	dailyKlineLimit  = 120           // 日线K线数量
+	dailyKlineMaxAge = 1 * time.Hour // 日线缓存有效期
+)
+
+// dailyKlineCache 日线K线缓存（日线未走WS订阅，通过REST按需获取）
+type dailyKlineCache struct {
+	klines    []Kline
+	fetchedAt time.Time
+}
+
+var dailyKlineCacheMap sync.Map
+
+// getDailyKlines 获取日线K线（带缓存）
+func getDailyKlines(symbol string) ([]Kline, error) {
+	if value, ok := dailyKlineCacheMap.Load(symbol); ok {
+		cache := value.(*dailyKlineCache)
+		if time.Since(cache.fetchedAt) < dailyKlineMaxAge {
+			return cache.klines, nil
+		}
+	}
+
+	klines, err := NewAPIClient().GetKlines(symbol, "1d", dailyKlineLimit)
+	if err != nil {
+		return nil, err
+	}
+	dailyKlineCacheMap.Store(symbol, &dailyKlineCache{klines: klines, fetchedAt: time.Now()})
+	return klines, nil
+}
+
+// CalculateSupportResistance 基于4h和1d波段点聚类计算支撑阻力位
+func CalculateSupportResistance(symbol string) (*SupportResistanceData, error) {
+	symbol = Normalize(symbol)
+	klines4h, err := WSMonitorCli.GetCurrentKlines(symbol, "4h")
+	if err != nil {
+		return nil, fmt.Errorf("获取4小时K线失败: %v", err)
+	}
+	if len(klines4h) < 30 {
+		return nil, fmt.Errorf("K线数据不足，需要至少30根4小时K线")
+	}
+
+	levels := detectSRLevels(klines4h, "4h")
+
+	// 日线获取失败不影响整体，仅使用4h级别
+	if klines1d, err := getDailyKlines(symbol); err == nil && len(klines1d) >= 10 {
+		levels = append(levels, detectSRLevels(klines1d, "1d")...)
+	}
+
+	currentPrice := klines4h[len(klines4h)-1].Close
+	return buildSupportResistance(currentPrice, levels), nil
+}
+
+// detectSRLevels 识别波段高低点并按价格聚类成水平位
+func detectSRLevels(klines []Kline, timeframe string) []SRLevel {
+	highIdx, lowIdx := findPivots(klines, srPivotWindow)
+	pivots := make([]float64, 0, len(highIdx)+len(lowIdx))
+	for _, i := range highIdx {
+		pivots = append(pivots, klines[i].High)
+	}
+	for _, i := range lowIdx {
+		pivots = append(pivots, klines[i].Low)
+	}
+	if len(pivots) == 0 {
+		return nil
+	}
+
+	// 容差取ATR占比的一半，波动越大的币种聚类范围越宽
+	tolerance := srMinTolerance
+	if price := klines[len(klines)-1].Close; price > 0 {
+		if atrPct := calculateATR(klines, 14) / price * 0.5; atrPct > tolerance {
+			tolerance = atrPct
+		}
+	}
+
+	return clusterPivots(pivots, tolerance, timeframe)
+}
+
+// findPivots 查找波段高点和低点的索引（左右各window根K线内的极值）
+func findPivots(klines []Kline, window int) (highIdx, lowIdx []int) {
+	for i := window; i < len(klines)-window; i++ {
+		isHigh, isLow := true, true
+		for j := i - window; j <= i+window; j++ {
+			if j == i {
+				continue
+			}
+			if klines[j].High >= klines[i].High {
+				isHigh = false
+			}
+			if klines[j].Low <= klines[i].Low {
+				isLow = false
+			}
+		}
+		if isHigh {
+			highIdx = append(highIdx, i)
+		}
+		if isLow {
+			lowIdx = append(lowIdx, i)
+		}
+	}
+	return highIdx, lowIdx
+}
+
+// clusterPivots 将相近的波段点合并为一个水平位（价格取聚类均值）
+func clusterPivots(pivots []float64, tolerance float64, timeframe string) []SRLevel {
+	sorted := append([]float64(nil), pivots...)
+	sort.Float64s(sorted)
+
+	var levels []SRLevel
+	sum, count := sorted[0], 1
+	for _, p := range sorted[1:] {
+		mean := sum / float64(count)
+		if (p-mean)/mean <= tolerance {
+			sum += p
+			count++
+			continue
+		}
+		levels = append(levels, SRLevel{Price: mean, Touches: count, Timeframe: timeframe})
+		sum, count = p, 1
+	}
+	levels = append(levels, SRLevel{Price: sum / float64(count), Touches: count, Timeframe: timeframe})
+	return levels
+}
+
+// buildSupportResistance 按当前价格划分支撑/阻力并按距离排序
+func buildSupportResistance(currentPrice float64, levels []SRLevel) *SupportResistanceData {
+	data := &SupportResistanceData{
+		CurrentPrice: currentPrice,
+		Supports:     []SRLevel{},
+		Resistances:  []SRLevel{},
+	}
+	if currentPrice <= 0 {
+		return data
+	}
+
+	for _, level := range levels {
+		level.DistancePct = math.Abs(level.Price-currentPrice) / currentPrice * 100
+		if level.Price < currentPrice {
+			data.Supports = append(data.Supports, level)
+		} else if level.Price > currentPrice {
+			data.Resistances = append(data.Resistances, level)
+		}
+	}
+
+	byDistance := func(s []SRLevel) {
+		sort.Slice(s, func(i, j int) bool { return s[i].DistancePct < s[j].DistancePct })
+	}
+	byDistance(data.Supports)
+	byDistance(data.Resistances)
+	if len(data.Supports) > srMaxLevels {
+		data.Supports = data.Supports[:srMaxLevels]
+	}
+	if len(data.Resistances) > srMaxLevels {
+		data.Resistances = data.Resistances[:srMaxLevels]
+	}
+
+	if len(data.Supports) > 0 {
+		data.NearestSupport = &data.Supports[0]
+	}
+	if len(data.Resistances) > 0 {
+		data.NearestResistance = &data.Resistances[0]
+	}
+	return data
+}
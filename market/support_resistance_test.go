@@ -0,0 +1,62 @@
+package market
+
+import (
+	"testing"
+)
+
+// TestClusterPivots 测试波段点聚类
+func TestClusterPivots(t *testing.T) {
+	pivots := []float64{100, 100.2, 99.9, 110, 110.3, 120}
+	levels := clusterPivots(pivots, 0.005, "4h")
+
+	if len(levels) != 3 {
+		t.Fatalf("期望3个水平位，实际 %d: %+v", len(levels), levels)
+	}
+	if levels[0].Touches != 3 || levels[1].Touches != 2 || levels[2].Touches != 1 {
+		t.Errorf("聚类触及次数错误: %+v", levels)
+	}
+}
+
+// TestBuildSupportResistance 测试支撑阻力划分与最近水平位
+func TestBuildSupportResistance(t *testing.T) {
+	levels := []SRLevel{
+		{Price: 90, Touches: 2, Timeframe: "4h"},
+		{Price: 98, Touches: 1, Timeframe: "4h"},
+		{Price: 105, Touches: 3, Timeframe: "1d"},
+		{Price: 120, Touches: 1, Timeframe: "1d"},
+	}
+	data := buildSupportResistance(100, levels)
+
+	if data.NearestSupport == nil || data.NearestSupport.Price != 98 {
+		t.Errorf("最近支撑位错误: %+v", data.NearestSupport)
+	}
+	if data.NearestResistance == nil || data.NearestResistance.Price != 105 {
+		t.Errorf("最近阻力位错误: %+v", data.NearestResistance)
+	}
+	if data.NearestResistance.DistancePct != 5 {
+		t.Errorf("阻力位距离错误: %.2f", data.NearestResistance.DistancePct)
+	}
+}
+
+// TestDetectSRLevels 测试从K线中识别水平位
+func TestDetectSRLevels(t *testing.T) {
+	// 两次在110附近见顶、两次在100附近见底的震荡走势
+	highs := []float64{105, 107, 110, 107, 104, 102, 104, 107, 110.2, 107, 104, 101, 104}
+	lows := []float64{103, 105, 108, 105, 102, 100, 102, 105, 108, 105, 102, 100.1, 102}
+	var klines []Kline
+	for i := range highs {
+		klines = append(klines, Kline{High: highs[i], Low: lows[i], Close: (highs[i] + lows[i]) / 2})
+	}
+
+	levels := detectSRLevels(klines, "4h")
+	found := map[int]int{}
+	for _, l := range levels {
+		found[int(l.Price/10)] += l.Touches
+	}
+	if found[11] != 2 {
+		t.Errorf("期望110附近有2个波段高点，实际: %+v", levels)
+	}
+	if found[10] != 1 {
+		t.Errorf("期望100附近有1个波段低点，实际: %+v", levels)
+	}
+}
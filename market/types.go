@@ -15,6 +15,37 @@ type Data struct {
 	FundingRate       float64
 	IntradaySeries    *IntradayData
 	LongerTermContext *LongerTermData
+	Divergence3m      *DivergenceData // 3分钟级别背离
+	Divergence4h      *DivergenceData // 4小时级别背离
+	Regime            *RegimeData     // 4小时级别市场状态
+	Liquidity         *LiquidityData  // 订单簿流动性与滑点估算
+}
+
+// RegimeData 单币种市场状态（趋势 + 波动率）
+type RegimeData struct {
+	Trend         string  // trending_up / trending_down / ranging
+	Volatility    string  // high / normal / low
+	ADX           float64 // ADX(14)
+	PlusDI        float64 // +DI(14)
+	MinusDI       float64 // -DI(14)
+	ATRPercentile float64 // 当前ATR%在历史中的百分位(0-100)
+}
+
+// MarketRegimeData 全市场状态（按币种多数投票汇总）
+type MarketRegimeData struct {
+	Trend          string `json:"trend"`
+	Volatility     string `json:"volatility"`
+	TrendingUp     int    `json:"trending_up"`
+	TrendingDown   int    `json:"trending_down"`
+	Ranging        int    `json:"ranging"`
+	HighVolatility int    `json:"high_volatility"`
+	LowVolatility  int    `json:"low_volatility"`
+}
+
+// DivergenceData 价格与指标背离（bullish=底背离, bearish=顶背离, none=无）
+type DivergenceData struct {
+	RSI  string
+	MACD string
 }
 
 // OIData Open Interest数据
@@ -88,6 +119,19 @@ type Ticker24hr struct {
 	QuoteVolume        string `json:"quoteVolume"`
 }
 
+// 全市场概览（所有币种共享）
+type MarketOverview struct {
+	BTCDominance          float64   `json:"btc_dominance"`            // BTC市值占比(%)
+	ETHDominance          float64   `json:"eth_dominance"`            // ETH市值占比(%)
+	TotalMarketCap        float64   `json:"total_market_cap"`         // 总市值(USD)
+	TotalMarketCapChange  float64   `json:"total_market_cap_change"`  // 总市值24h变化(%)
+	Total3MarketCap       float64   `json:"total3_market_cap"`        // 除BTC/ETH外的总市值(USD)
+	Total3MarketCapChange float64   `json:"total3_market_cap_change"` // TOTAL3 24h变化(%)
+	BTCPriceChange24h     float64   `json:"btc_price_change_24h"`     // BTC 24h涨跌幅(%)
+	ETHPriceChange24h     float64   `json:"eth_price_change_24h"`     // ETH 24h涨跌幅(%)
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
 // 斐波那契分析数据
 type FibonacciData struct {
 	SwingHigh         float64            `json:"swing_high"`
@@ -104,6 +148,23 @@ type WyckoffSignalData struct {
 	PriceAction    string   `json:"price_action"`
 }
 
+// 支撑阻力位
+type SRLevel struct {
+	Price       float64 `json:"price"`
+	Touches     int     `json:"touches"`      // 聚类内的波段点数量（越多越可靠）
+	Timeframe   string  `json:"timeframe"`    // 来源周期: 4h / 1d
+	DistancePct float64 `json:"distance_pct"` // 与当前价格的距离百分比
+}
+
+// 支撑阻力分析数据
+type SupportResistanceData struct {
+	CurrentPrice      float64   `json:"current_price"`
+	NearestSupport    *SRLevel  `json:"nearest_support"`
+	NearestResistance *SRLevel  `json:"nearest_resistance"`
+	Supports          []SRLevel `json:"supports"`    // 按距离由近到远
+	Resistances       []SRLevel `json:"resistances"` // 按距离由近到远
+}
+
 // 特征数据结构
 type SymbolFeatures struct {
 	Symbol           string    `json:"symbol"`
@@ -139,6 +200,7 @@ type Config struct {
 	AlertThresholds AlertThresholds `json:"alert_thresholds"`
 	UpdateInterval  int             `json:"update_interval"` // seconds
 	CleanupConfig   CleanupConfig   `json:"cleanup_config"`
+	StaleThreshold  time.Duration   `json:"stale_threshold"` // K线超过该时长未更新视为过期数据
 }
 
 type AlertThresholds struct {
@@ -170,4 +232,5 @@ var config = Config{
 		CheckInterval:     5 * time.Minute,
 	},
 	UpdateInterval: 60, // 1 minute
+	StaleThreshold: 5 * time.Minute,
 }
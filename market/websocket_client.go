@@ -3,7 +3,7 @@ package market
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -90,7 +90,7 @@ func (w *WSClient) Connect() error {
 	w.conn = conn
 	w.mu.Unlock()
 
-	log.Println("WebSocket连接成功")
+	slog.Info("WebSocket连接成功")
 
 	// 启动消息读取循环
 	go w.readMessages()
@@ -132,7 +132,7 @@ func (w *WSClient) subscribe(stream string) error {
 		return err
 	}
 
-	log.Printf("订阅流: %s", stream)
+	slog.Debug("订阅流", "stream", stream)
 	return nil
 }
 
@@ -153,7 +153,7 @@ func (w *WSClient) readMessages() {
 
 			_, message, err := conn.ReadMessage()
 			if err != nil {
-				log.Printf("读取WebSocket消息失败: %v", err)
+				slog.Warn("读取WebSocket消息失败", "error", err)
 				w.handleReconnect()
 				return
 			}
@@ -178,7 +178,7 @@ func (w *WSClient) handleMessage(message []byte) {
 		select {
 		case ch <- wsMsg.Data:
 		default:
-			log.Printf("订阅者通道已满: %s", wsMsg.Stream)
+			slog.Warn("订阅者通道已满", "stream", wsMsg.Stream)
 		}
 	}
 }
@@ -188,11 +188,11 @@ func (w *WSClient) handleReconnect() {
 		return
 	}
 
-	log.Println("尝试重新连接...")
+	slog.Info("WebSocket尝试重新连接")
 	time.Sleep(3 * time.Second)
 
 	if err := w.Connect(); err != nil {
-		log.Printf("重新连接失败: %v", err)
+		slog.Warn("WebSocket重新连接失败", "error", err)
 		go w.handleReconnect()
 	}
 }
@@ -0,0 +1,152 @@
+package market
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	binanceWeightLimit   = 2400 // 合约REST每分钟权重上限
+	weightSoftLimitRatio = 0.80 // 超过后暂停非关键请求
+	weightHardLimitRatio = 0.95 // 超过后关键请求（下单等）也等待窗口重置
+	usedWeightHeader     = "X-MBX-USED-WEIGHT-1M"
+)
+
+// weightBudget 按分钟窗口跟踪Binance REST权重使用情况
+type weightBudget struct {
+	mu          sync.Mutex
+	limit       int
+	used        int
+	window      time.Time // used所属的分钟
+	bannedUntil time.Time // 收到429/418后的暂停截止时间
+}
+
+// sharedWeightBudget market/trader共享同一份权重预算（同一IP共享限额）
+var sharedWeightBudget = &weightBudget{limit: binanceWeightLimit}
+
+// restClient 包内直接调用Binance REST时使用的客户端
+var restClient = NewRateLimitedHTTPClient(30*time.Second, false)
+
+// pauseUntil 返回请求需要等待到的时间，零值表示可以立即发送
+func (b *weightBudget) pauseUntil(now time.Time, critical bool) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Before(b.bannedUntil) {
+		return b.bannedUntil
+	}
+
+	// 新的一分钟窗口，权重已重置
+	if !now.Truncate(time.Minute).Equal(b.window) {
+		return time.Time{}
+	}
+
+	ratio := weightSoftLimitRatio
+	if critical {
+		ratio = weightHardLimitRatio
+	}
+	if float64(b.used) < float64(b.limit)*ratio {
+		return time.Time{}
+	}
+	return b.window.Add(time.Minute)
+}
+
+// record 根据响应头更新已用权重，遇到限频状态码时暂停所有请求
+func (b *weightBudget) record(now time.Time, header http.Header, status int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if v := header.Get(usedWeightHeader); v != "" {
+		if used, err := strconv.Atoi(v); err == nil {
+			b.used = used
+			b.window = now.Truncate(time.Minute)
+		}
+	}
+
+	if status == http.StatusTooManyRequests || status == http.StatusTeapot {
+		retryAfter := time.Minute
+		if s, err := strconv.Atoi(header.Get("Retry-After")); err == nil && s > 0 {
+			retryAfter = time.Duration(s) * time.Second
+		}
+		b.bannedUntil = now.Add(retryAfter)
+		slog.Warn("Binance API限频，暂停请求", "status", status, "retry_after", retryAfter)
+	}
+}
+
+// usage 返回当前窗口已用权重与上限
+func (b *weightBudget) usage(now time.Time) (int, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !now.Truncate(time.Minute).Equal(b.window) {
+		return 0, b.limit
+	}
+	return b.used, b.limit
+}
+
+// weightTransport 在发送Binance请求前检查权重预算，返回后记录已用权重
+type weightTransport struct {
+	base     http.RoundTripper
+	critical bool
+}
+
+func (t *weightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isBinanceHost(req.URL.Hostname()) {
+		return t.base.RoundTrip(req)
+	}
+
+	for {
+		until := sharedWeightBudget.pauseUntil(time.Now(), t.critical)
+		if until.IsZero() {
+			break
+		}
+		wait := time.Until(until)
+		slog.Warn("Binance权重接近上限，暂停请求", "path", req.URL.Path, "wait", wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	sharedWeightBudget.record(time.Now(), resp.Header, resp.StatusCode)
+	return resp, nil
+}
+
+// isBinanceHost 只对Binance域名做权重控制，其他API直接放行
+func isBinanceHost(host string) bool {
+	return host == "binance.com" || strings.HasSuffix(host, ".binance.com")
+}
+
+// WrapRateLimitedTransport 为已有Transport加上共享的权重预算控制
+// critical=true 的请求（下单、平仓等）在软上限后仍可发送，直到接近硬上限
+func WrapRateLimitedTransport(base http.RoundTripper, critical bool) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &weightTransport{base: base, critical: critical}
+}
+
+// NewRateLimitedHTTPClient 创建共享权重预算的HTTP客户端
+func NewRateLimitedHTTPClient(timeout time.Duration, critical bool) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: WrapRateLimitedTransport(nil, critical),
+	}
+}
+
+// GetAPIWeightUsage 返回当前分钟已用的Binance REST权重和上限
+func GetAPIWeightUsage() (used, limit int) {
+	return sharedWeightBudget.usage(time.Now())
+}
@@ -0,0 +1,47 @@
+package market
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWeightBudgetPause(t *testing.T) {
+	b := &weightBudget{limit: 2400}
+	now := time.Date(2025, 1, 1, 12, 0, 30, 0, time.UTC)
+	nextWindow := time.Date(2025, 1, 1, 12, 1, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set(usedWeightHeader, "2000")
+	b.record(now, header, http.StatusOK)
+
+	if until := b.pauseUntil(now, false); !until.Equal(nextWindow) {
+		t.Errorf("非关键请求应暂停到下一分钟，得到 %v", until)
+	}
+	if until := b.pauseUntil(now, true); !until.IsZero() {
+		t.Errorf("关键请求在硬上限前不应暂停，得到 %v", until)
+	}
+	if until := b.pauseUntil(nextWindow, false); !until.IsZero() {
+		t.Errorf("新窗口权重已重置，不应暂停，得到 %v", until)
+	}
+
+	header.Set(usedWeightHeader, "2300")
+	b.record(now, header, http.StatusOK)
+	if until := b.pauseUntil(now, true); !until.Equal(nextWindow) {
+		t.Errorf("超过硬上限后关键请求也应暂停，得到 %v", until)
+	}
+}
+
+func TestWeightBudgetRetryAfter(t *testing.T) {
+	b := &weightBudget{limit: 2400}
+	now := time.Date(2025, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set("Retry-After", "90")
+	b.record(now, header, http.StatusTooManyRequests)
+
+	want := now.Add(90 * time.Second)
+	if until := b.pauseUntil(now, true); !until.Equal(want) {
+		t.Errorf("429后应暂停到 %v，得到 %v", want, until)
+	}
+}
@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCallWithMessagesContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // 模拟迟迟不返回的AI
+	}))
+	// Cleanup 按注册的逆序执行：先放行handler，Close 才不会一直等待
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := New()
+	client.SetCustomAPI(srv.URL+"#", "key", "model")
+	client.Provider = "cancel-test"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := client.CallWithMessagesContext(ctx, "sys", "user")
+	if !errors.Is(err, context.Canceled) || time.Since(start) > 5*time.Second {
+		t.Fatalf("取消后应立即返回 context.Canceled: %v (%v)", err, time.Since(start))
+	}
+	for _, s := range ProviderHealth.Snapshot() {
+		if s.Name == "cancel-test" {
+			t.Errorf("主动取消不应计入提供商健康状态: %+v", s)
+		}
+	}
+}
+
+// blockingClient 不支持取消、永远不返回的AI客户端
+type blockingClient struct{}
+
+func (blockingClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	select {}
+}
+
+func TestCallWithContextFallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := CallWithContext(ctx, blockingClient{}, "sys", "user"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("不支持取消的客户端也应在ctx结束后返回: %v", err)
+	}
+
+	client := NewRecordedClient(map[int]RecordedResponse{1: {Content: "ok"}})
+	if resp, err := CallWithContext(context.Background(), client, "sys", "user"); err != nil || resp != "ok" {
+		t.Errorf("未取消时应正常返回: %q %v", resp, err)
+	}
+}
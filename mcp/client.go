@@ -2,11 +2,13 @@ package mcp
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"nofx/health"
 	"strings"
 	"time"
 )
@@ -20,6 +22,41 @@ const (
 	ProviderCustom   Provider = "custom"
 )
 
+// AIClient 决策引擎依赖的AI调用接口（*Client 调用真实API，RecordedClient 回放录制的响应）
+type AIClient interface {
+	CallWithMessages(systemPrompt, userPrompt string) (string, error)
+}
+
+// ContextAIClient 支持取消的AI客户端，ctx 取消时中止进行中的请求
+type ContextAIClient interface {
+	CallWithMessagesContext(ctx context.Context, systemPrompt, userPrompt string) (string, error)
+}
+
+// CallWithContext 调用AI；客户端不支持取消时在 ctx 取消后放弃等待（请求在后台自然结束）
+func CallWithContext(ctx context.Context, client AIClient, systemPrompt, userPrompt string) (string, error) {
+	if c, ok := client.(ContextAIClient); ok {
+		return c.CallWithMessagesContext(ctx, systemPrompt, userPrompt)
+	}
+	type result struct {
+		content string
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		content, err := client.CallWithMessages(systemPrompt, userPrompt)
+		done <- result{content, err}
+	}()
+	select {
+	case r := <-done:
+		return r.content, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// ProviderHealth 各AI提供商最近一次调用（含重试）的结果，供健康检查使用
+var ProviderHealth = health.NewTracker()
+
 // Client AI API配置
 type Client struct {
 	Provider   Provider
@@ -28,6 +65,10 @@ type Client struct {
 	Model      string
 	Timeout    time.Duration
 	UseFullURL bool // 是否使用完整URL（不添加/chat/completions）
+
+	// 累计token用量（见 TakeUsage）
+	promptTokens     int64
+	completionTokens int64
 }
 
 func New() *Client {
@@ -47,21 +88,21 @@ func (client *Client) SetDeepSeekAPIKey(apiKey string, customURL string, customM
 	client.APIKey = apiKey
 	if customURL != "" {
 		client.BaseURL = customURL
-		log.Printf("🔧 [MCP] DeepSeek 使用自定义 BaseURL: %s", customURL)
+		slog.Info("MCP使用自定义BaseURL", "provider", "DeepSeek", "base_url", customURL)
 	} else {
 		client.BaseURL = "https://api.deepseek.com/v1"
-		log.Printf("🔧 [MCP] DeepSeek 使用默认 BaseURL: %s", client.BaseURL)
+		slog.Info("MCP使用默认BaseURL", "provider", "DeepSeek", "base_url", client.BaseURL)
 	}
 	if customModel != "" {
 		client.Model = customModel
-		log.Printf("🔧 [MCP] DeepSeek 使用自定义 Model: %s", customModel)
+		slog.Info("MCP使用自定义Model", "provider", "DeepSeek", "model", customModel)
 	} else {
 		client.Model = "deepseek-chat"
-		log.Printf("🔧 [MCP] DeepSeek 使用默认 Model: %s", client.Model)
+		slog.Info("MCP使用默认Model", "provider", "DeepSeek", "model", client.Model)
 	}
 	// 打印 API Key 的前后各4位用于验证
 	if len(apiKey) > 8 {
-		log.Printf("🔧 [MCP] DeepSeek API Key: %s...%s", apiKey[:4], apiKey[len(apiKey)-4:])
+		slog.Debug("MCP API Key", "provider", "DeepSeek", "api_key", apiKey[:4]+"..."+apiKey[len(apiKey)-4:])
 	}
 }
 
@@ -72,21 +113,21 @@ func (client *Client) SetQwenAPIKey(apiKey string, customURL string, customModel
 	client.APIKey = apiKey
 	if customURL != "" {
 		client.BaseURL = customURL
-		log.Printf("🔧 [MCP] Qwen 使用自定义 BaseURL: %s", customURL)
+		slog.Info("MCP使用自定义BaseURL", "provider", "Qwen", "base_url", customURL)
 	} else {
 		client.BaseURL = "https://dashscope.aliyuncs.com/compatible-mode/v1"
-		log.Printf("🔧 [MCP] Qwen 使用默认 BaseURL: %s", client.BaseURL)
+		slog.Info("MCP使用默认BaseURL", "provider", "Qwen", "base_url", client.BaseURL)
 	}
 	if customModel != "" {
 		client.Model = customModel
-		log.Printf("🔧 [MCP] Qwen 使用自定义 Model: %s", customModel)
+		slog.Info("MCP使用自定义Model", "provider", "Qwen", "model", customModel)
 	} else {
 		client.Model = "qwen-plus" // 可选: qwen-turbo, qwen-plus, qwen-max
-		log.Printf("🔧 [MCP] Qwen 使用默认 Model: %s", client.Model)
+		slog.Info("MCP使用默认Model", "provider", "Qwen", "model", client.Model)
 	}
 	// 打印 API Key 的前后各4位用于验证
 	if len(apiKey) > 8 {
-		log.Printf("🔧 [MCP] Qwen API Key: %s...%s", apiKey[:4], apiKey[len(apiKey)-4:])
+		slog.Debug("MCP API Key", "provider", "Qwen", "api_key", apiKey[:4]+"..."+apiKey[len(apiKey)-4:])
 	}
 }
 
@@ -118,38 +159,57 @@ func (client *Client) SetClient(Client Client) {
 
 // CallWithMessages 使用 system + user prompt 调用AI API（推荐）
 func (client *Client) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	return client.CallWithMessagesContext(context.Background(), systemPrompt, userPrompt)
+}
+
+// CallWithMessagesContext 同 CallWithMessages，ctx 取消时中止请求和重试
+func (client *Client) CallWithMessagesContext(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	if client.APIKey == "" {
 		return "", fmt.Errorf("AI API密钥未设置，请先调用 SetDeepSeekAPIKey() 或 SetQwenAPIKey()")
 	}
 
+	result, err := client.callWithRetry(ctx, systemPrompt, userPrompt)
+	// 主动取消不代表提供商不可用
+	if ctx.Err() == nil {
+		ProviderHealth.Record(string(client.Provider), err)
+	}
+	return result, err
+}
+
+// callWithRetry 网络类错误最多重试3次
+func (client *Client) callWithRetry(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	// 重试配置
 	maxRetries := 3
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
-			fmt.Printf("⚠️  AI API调用失败，正在重试 (%d/%d)...\n", attempt, maxRetries)
+			slog.Warn("AI API调用失败，正在重试", "provider", client.Provider, "attempt", attempt, "max_retries", maxRetries, "error", lastErr)
 		}
 
-		result, err := client.callOnce(systemPrompt, userPrompt)
+		result, err := client.callOnce(ctx, systemPrompt, userPrompt)
 		if err == nil {
 			if attempt > 1 {
-				fmt.Printf("✓ AI API重试成功\n")
+				slog.Info("AI API重试成功", "provider", client.Provider, "attempt", attempt)
 			}
 			return result, nil
 		}
 
 		lastErr = err
-		// 如果不是网络错误，不重试
-		if !isRetryableError(err) {
+		// 如果不是网络错误或已被取消，不重试
+		if ctx.Err() != nil || !isRetryableError(err) {
 			return "", err
 		}
 
 		// 重试前等待
 		if attempt < maxRetries {
 			waitTime := time.Duration(attempt) * 2 * time.Second
-			fmt.Printf("⏳ 等待%v后重试...\n", waitTime)
-			time.Sleep(waitTime)
+			slog.Info("等待后重试AI API", "provider", client.Provider, "wait", waitTime)
+			select {
+			case <-time.After(waitTime):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
 		}
 	}
 
@@ -157,16 +217,13 @@ func (client *Client) CallWithMessages(systemPrompt, userPrompt string) (string,
 }
 
 // callOnce 单次调用AI API（内部使用）
-func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
+func (client *Client) callOnce(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	// 打印当前 AI 配置
-	log.Printf("📡 [MCP] AI 请求配置:")
-	log.Printf("   Provider: %s", client.Provider)
-	log.Printf("   BaseURL: %s", client.BaseURL)
-	log.Printf("   Model: %s", client.Model)
-	log.Printf("   UseFullURL: %v", client.UseFullURL)
+	reqLog := slog.With("provider", client.Provider, "base_url", client.BaseURL, "model", client.Model, "use_full_url", client.UseFullURL)
 	if len(client.APIKey) > 8 {
-		log.Printf("   API Key: %s...%s", client.APIKey[:4], client.APIKey[len(client.APIKey)-4:])
+		reqLog = reqLog.With("api_key", client.APIKey[:4]+"..."+client.APIKey[len(client.APIKey)-4:])
 	}
+	reqLog.Debug("MCP AI请求配置")
 
 	// 构建 messages 数组
 	messages := []map[string]string{}
@@ -210,9 +267,9 @@ func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error)
 		// 默认行为：添加/chat/completions
 		url = fmt.Sprintf("%s/chat/completions", client.BaseURL)
 	}
-	log.Printf("📡 [MCP] 请求 URL: %s", url)
+	slog.Debug("MCP请求URL", "url", url)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("创建请求失败: %w", err)
 	}
@@ -256,12 +313,15 @@ func (client *Client) callOnce(systemPrompt, userPrompt string) (string, error)
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage Usage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
 		return "", fmt.Errorf("解析响应失败: %w", err)
 	}
 
+	client.addUsage(result.Usage)
+
 	if len(result.Choices) == 0 {
 		return "", fmt.Errorf("API返回空响应")
 	}
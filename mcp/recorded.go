@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// RecordedResponse 某个周期录制的AI响应；Error 不为空时该周期回放为调用失败
+type RecordedResponse struct {
+	Content string
+	Error   string
+}
+
+// RecordedClient 按周期返回预先录制的响应，不访问任何AI服务（用于确定性的集成测试）
+// 第N次调用对应第N个周期（从1开始），缺少对应周期的响应时返回错误
+type RecordedClient struct {
+	mu        sync.Mutex
+	responses map[int]RecordedResponse
+	cycle     int
+}
+
+// NewRecordedClient 用 周期 -> 响应 创建回放客户端
+func NewRecordedClient(responses map[int]RecordedResponse) *RecordedClient {
+	return &RecordedClient{responses: responses}
+}
+
+// NewRecordedClientFromText 用纯文本响应列表创建回放客户端（第i个元素对应第i+1个周期）
+func NewRecordedClientFromText(responses ...string) *RecordedClient {
+	m := make(map[int]RecordedResponse, len(responses))
+	for i, content := range responses {
+		m[i+1] = RecordedResponse{Content: content}
+	}
+	return NewRecordedClient(m)
+}
+
+// LoadRecordedClient 从决策日志目录构建回放客户端：按文件顺序依次作为第1、2、3...个周期
+// 响应由记录的思维链和决策JSON拼接而成，两者都为空的记录回放为调用失败
+func LoadRecordedClient(logDir string) (*RecordedClient, error) {
+	files, err := ioutil.ReadDir(logDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取日志目录失败: %w", err)
+	}
+
+	responses := make(map[int]RecordedResponse)
+	cycle := 0
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(logDir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取决策记录失败: %w", err)
+		}
+		var record struct {
+			CoTTrace     string `json:"cot_trace"`
+			DecisionJSON string `json:"decision_json"`
+			ErrorMessage string `json:"error_message"`
+		}
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("解析决策记录 %s 失败: %w", file.Name(), err)
+		}
+
+		cycle++
+		if record.CoTTrace == "" && record.DecisionJSON == "" {
+			reason := record.ErrorMessage
+			if reason == "" {
+				reason = "记录中没有AI响应"
+			}
+			responses[cycle] = RecordedResponse{Error: reason}
+			continue
+		}
+		decisionJSON := record.DecisionJSON
+		if decisionJSON == "" {
+			decisionJSON = "[]"
+		}
+		responses[cycle] = RecordedResponse{Content: record.CoTTrace + "\n\n" + decisionJSON}
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("%s 中没有决策记录", logDir)
+	}
+	return NewRecordedClient(responses), nil
+}
+
+// CallWithMessages 返回下一个周期的录制响应（忽略prompt内容）
+func (c *RecordedClient) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cycle++
+	resp, ok := c.responses[c.cycle]
+	if !ok {
+		return "", fmt.Errorf("没有第%d个周期的录制响应", c.cycle)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("录制的第%d个周期调用失败: %s", c.cycle, resp.Error)
+	}
+	return resp.Content, nil
+}
+
+// Cycle 已回放的周期数
+func (c *RecordedClient) Cycle() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cycle
+}
+
+// Reset 从第1个周期重新开始回放
+func (c *RecordedClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cycle = 0
+}
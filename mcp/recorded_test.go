@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordedClient(t *testing.T) {
+	client := NewRecordedClient(map[int]RecordedResponse{
+		1: {Content: "第一周期"},
+		2: {Error: "timeout"},
+	})
+
+	if resp, err := client.CallWithMessages("sys", "user"); err != nil || resp != "第一周期" {
+		t.Fatalf("第1周期应返回录制内容，得到 %q, %v", resp, err)
+	}
+	if _, err := client.CallWithMessages("sys", "user"); err == nil {
+		t.Error("第2周期应回放为调用失败")
+	}
+	if _, err := client.CallWithMessages("sys", "user"); err == nil {
+		t.Error("缺少录制响应的周期应返回错误")
+	}
+	if client.Cycle() != 3 {
+		t.Errorf("周期计数错误: %d", client.Cycle())
+	}
+
+	client.Reset()
+	if resp, _ := client.CallWithMessages("sys", "user"); resp != "第一周期" {
+		t.Errorf("Reset后应从第1周期重新回放，得到 %q", resp)
+	}
+}
+
+func TestLoadRecordedClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"decision_20250101_000000_cycle1.json": `{"cot_trace":"看多BTC","decision_json":"[{\"symbol\":\"BTCUSDT\",\"action\":\"hold\"}]"}`,
+		"decision_20250101_000300_cycle2.json": `{"cot_trace":"","decision_json":"","error_message":"调用AI API失败"}`,
+		"decision_20250101_000600_cycle3.json": `{"cot_trace":"观望","decision_json":""}`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client, err := LoadRecordedClient(dir)
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+
+	resp, err := client.CallWithMessages("", "")
+	if err != nil || resp != "看多BTC\n\n[{\"symbol\":\"BTCUSDT\",\"action\":\"hold\"}]" {
+		t.Errorf("第1周期响应错误: %q, %v", resp, err)
+	}
+	if _, err := client.CallWithMessages("", ""); err == nil {
+		t.Error("失败的周期应回放为错误")
+	}
+	if resp, err := client.CallWithMessages("", ""); err != nil || resp != "观望\n\n[]" {
+		t.Errorf("没有决策的周期应补空数组: %q, %v", resp, err)
+	}
+}
@@ -0,0 +1,29 @@
+package mcp
+
+import "sync/atomic"
+
+// Usage AI调用消耗的token数
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+}
+
+// UsageReporter 能统计token用量的AI客户端（回放客户端不实现）
+type UsageReporter interface {
+	// TakeUsage 返回上次调用 TakeUsage 以来累计的用量并清零
+	TakeUsage() Usage
+}
+
+// addUsage 累加一次调用的用量（重试的失败请求不计入）
+func (client *Client) addUsage(u Usage) {
+	atomic.AddInt64(&client.promptTokens, u.PromptTokens)
+	atomic.AddInt64(&client.completionTokens, u.CompletionTokens)
+}
+
+// TakeUsage 返回累计用量并清零
+func (client *Client) TakeUsage() Usage {
+	return Usage{
+		PromptTokens:     atomic.SwapInt64(&client.promptTokens, 0),
+		CompletionTokens: atomic.SwapInt64(&client.completionTokens, 0),
+	}
+}
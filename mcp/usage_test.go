@@ -0,0 +1,29 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTakeUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":120,"completion_tokens":30}}`))
+	}))
+	defer srv.Close()
+
+	client := New()
+	client.SetCustomAPI(srv.URL+"#", "key", "model")
+	for i := 0; i < 2; i++ {
+		if _, err := client.CallWithMessages("sys", "user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if u := client.TakeUsage(); u.PromptTokens != 240 || u.CompletionTokens != 60 {
+		t.Errorf("应累计两次调用的用量: %+v", u)
+	}
+	if u := client.TakeUsage(); u != (Usage{}) {
+		t.Errorf("取出后应清零: %+v", u)
+	}
+}
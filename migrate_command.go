@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"nofx/config"
+)
+
+// runMigrateCommand 查看或回退数据库表结构版本：nofx migrate [--to=N]
+// 打开数据库时会先执行所有未执行的迁移，--to 再回退到指定版本
+func runMigrateCommand(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath(), "配置数据库路径（默认 NOFX_DB_PATH 或 config.db）")
+	to := fs.Int("to", -1, "回退到的表结构版本（不指定时只显示状态）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	database, err := openDatabase(*dbPath)
+	if err != nil {
+		return fmt.Errorf("初始化数据库失败: %w", err)
+	}
+	defer database.Close()
+
+	if *to >= 0 {
+		if err := database.MigrateTo(*to); err != nil {
+			return err
+		}
+	}
+
+	status, err := database.GetMigrationStatus()
+	if err != nil {
+		return fmt.Errorf("读取迁移状态失败: %w", err)
+	}
+	version, _ := database.SchemaVersion()
+	fmt.Printf("表结构版本: %d（程序支持 %d）\n", version, config.LatestSchemaVersion())
+	for _, s := range status {
+		applied := "未执行"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("  %4d  %-32s %s\n", s.Version, s.Name, applied)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("对象不存在")
+
+// S3Config S3（或MinIO等兼容存储）位置，对象名都加上 Prefix
+type S3Config struct {
+	Endpoint        string `json:"endpoint"` // 为空时使用 https://s3.<region>.amazonaws.com
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket"`
+	Prefix          string `json:"prefix"` // 对象名前缀，如 nofx/
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// Validate 检查必填项
+func (c *S3Config) Validate() error {
+	if c.Region == "" || c.Bucket == "" || c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return fmt.Errorf("s3 需要 region、bucket、access_key_id 和 secret_access_key")
+	}
+	if c.Endpoint != "" {
+		if u, err := url.Parse(c.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("s3.endpoint 必须是 http(s):// 地址")
+		}
+	}
+	return nil
+}
+
+// s3Client S3请求使用的HTTP客户端
+var s3Client = &http.Client{Timeout: 30 * time.Minute}
+
+// Upload 把本地文件上传为 <prefix><文件名>
+func (c *S3Config) Upload(ctx context.Context, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	resp, err := c.do(ctx, http.MethodPut, c.Prefix+filepath.Base(file), nil, f)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Put 写入对象 <prefix><key>
+func (c *S3Config) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, c.Prefix+key, nil, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get 读取对象 <prefix><key>，不存在时返回 ErrNotFound
+func (c *S3Config) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.Prefix+key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Delete 删除对象 <prefix><key>（对象不存在时S3也返回成功）
+func (c *S3Config) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, c.Prefix+key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// listResult ListObjectsV2 的响应
+type listResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List 列出 <prefix><keyPrefix> 下的对象（自动翻页），返回去掉 Prefix 的对象名
+// delimiter 不为空时，名称中在 delimiter 之后还有内容的对象合并为子目录返回（如 trader1/）
+func (c *S3Config) List(ctx context.Context, keyPrefix, delimiter string) (keys, dirs []string, err error) {
+	query := url.Values{"list-type": {"2"}, "prefix": {c.Prefix + keyPrefix}}
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	for {
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		var result listResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("解析S3对象列表失败: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			keys = append(keys, strings.TrimPrefix(obj.Key, c.Prefix))
+		}
+		for _, p := range result.CommonPrefixes {
+			dirs = append(dirs, strings.TrimPrefix(p.Prefix, c.Prefix))
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, dirs, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// do 发送签名后的请求，使用路径风格地址（兼容MinIO）；key 为空时请求存储桶本身
+// 非2xx响应转为错误（404 为 ErrNotFound），成功时调用方负责关闭响应
+func (c *S3Config) do(ctx context.Context, method, key string, query url.Values, body io.ReadSeeker) (*http.Response, error) {
+	// 签名需要内容的SHA256，先读一遍内容
+	h := sha256.New()
+	var size int64
+	if body != nil {
+		n, err := io.Copy(h, body)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		size = n
+	}
+	payloadHash := hex.EncodeToString(h.Sum(nil))
+
+	endpoint := strings.TrimRight(c.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://s3." + c.Region + ".amazonaws.com"
+	}
+	objectPath := "/" + awsEscape(c.Bucket)
+	if key != "" {
+		for _, seg := range strings.Split(key, "/") {
+			objectPath += "/" + awsEscape(seg)
+		}
+	}
+	rawQuery := canonicalQuery(query)
+	target := endpoint + objectPath
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = body
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	c.sign(req, objectPath, rawQuery, payloadHash, time.Now().UTC())
+
+	resp, err := s3Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound && key != "" {
+			return nil, ErrNotFound
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("S3请求失败: %s %s: HTTP %d: %s", method, key, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// sign 按 AWS Signature Version 4 给请求签名
+func (c *S3Config) sign(req *http.Request, objectPath, rawQuery, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		objectPath,
+		rawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + c.Region + "/s3/aws4_request"
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), day)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery 按参数名排序并按SigV4规则编码的查询串（签名和请求使用同一个串）
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, v := range query[name] {
+			parts = append(parts, awsEscape(name)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape 除 A-Z a-z 0-9 - _ . ~ 外全部百分号编码
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') || ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,122 @@
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 内存中的存储桶，支持 PUT/GET/DELETE 和 ListObjectsV2（每页最多2个对象，用于测试翻页）
+func fakeS3(t *testing.T, bucket string) *httptest.Server {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+bucket), "/")
+		switch {
+		case r.Method == http.MethodPut:
+			objects[key], _ = io.ReadAll(r.Body)
+		case r.Method == http.MethodDelete:
+			delete(objects, key)
+		case r.Method == http.MethodGet && key != "":
+			data, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		default:
+			q := r.URL.Query()
+			prefix, delimiter, after := q.Get("prefix"), q.Get("delimiter"), q.Get("continuation-token")
+			var keys []string
+			for k := range objects {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			var contents, dirs []string
+			seen := map[string]bool{}
+			for _, k := range keys {
+				if !strings.HasPrefix(k, prefix) || k <= after {
+					continue
+				}
+				if i := strings.Index(k[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+					if dir := k[:len(prefix)+i+1]; !seen[dir] {
+						seen[dir] = true
+						dirs = append(dirs, dir)
+					}
+					continue
+				}
+				contents = append(contents, k)
+			}
+			truncated := len(contents) > 2
+			if truncated {
+				contents = contents[:2]
+			}
+			fmt.Fprint(w, "<ListBucketResult>")
+			for _, k := range contents {
+				fmt.Fprintf(w, "<Contents><Key>%s</Key></Contents>", k)
+			}
+			for _, d := range dirs {
+				fmt.Fprintf(w, "<CommonPrefixes><Prefix>%s</Prefix></CommonPrefixes>", d)
+			}
+			if truncated {
+				fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", contents[1])
+			}
+			fmt.Fprint(w, "</ListBucketResult>")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestS3Objects(t *testing.T) {
+	srv := fakeS3(t, "bk")
+	cfg := &S3Config{Endpoint: srv.URL, Region: "us-east-1", Bucket: "bk", Prefix: "nofx/", AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	ctx := context.Background()
+
+	for _, key := range []string{"t1/a.json", "t1/b.json", "t1/c.json", "t2/a.json"} {
+		if err := cfg.Put(ctx, key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, err := cfg.Get(ctx, "t1/b.json"); err != nil || string(data) != "t1/b.json" {
+		t.Errorf("读取对象错误: %q %v", data, err)
+	}
+	if _, err := cfg.Get(ctx, "t1/missing.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("不存在的对象应返回 ErrNotFound: %v", err)
+	}
+
+	keys, _, err := cfg.List(ctx, "t1/", "")
+	if err != nil || strings.Join(keys, ",") != "t1/a.json,t1/b.json,t1/c.json" {
+		t.Errorf("列出对象错误（需自动翻页）: %v %v", keys, err)
+	}
+	if _, dirs, err := cfg.List(ctx, "", "/"); err != nil || strings.Join(dirs, ",") != "t1/,t2/" {
+		t.Errorf("列出子目录错误: %v %v", dirs, err)
+	}
+
+	if err := cfg.Delete(ctx, "t1/a.json"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Get(ctx, "t1/a.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("删除后应不存在: %v", err)
+	}
+}
+
+func TestAWSEscape(t *testing.T) {
+	if got := awsEscape("a b/c~d+e"); got != "a%20b%2Fc~d%2Be" {
+		t.Errorf("awsEscape = %s", got)
+	}
+}
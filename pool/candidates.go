@@ -0,0 +1,87 @@
+package pool
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultMaxDataAge 信号源数据的默认有效期，超过后该来源的币种不再作为候选
+const defaultMaxDataAge = 180 * time.Minute
+
+var maxDataAge = struct {
+	sync.RWMutex
+	d time.Duration
+}{d: defaultMaxDataAge}
+
+// SetMaxDataAge 设置信号源数据的有效期（0 表示不过期），对所有交易员生效
+func SetMaxDataAge(d time.Duration) {
+	maxDataAge.Lock()
+	defer maxDataAge.Unlock()
+	maxDataAge.d = d
+}
+
+// MaxDataAge 信号源数据的有效期，0 表示不过期
+func MaxDataAge() time.Duration {
+	maxDataAge.RLock()
+	defer maxDataAge.RUnlock()
+	return maxDataAge.d
+}
+
+// Candidate 合并多个信号源后的候选币种
+type Candidate struct {
+	Symbol string `json:"symbol"`
+	// Score 各信号源按名次给分（第1名100分，依次递减）后求和，多个信号源同时给出的币种分数更高
+	Score     float64   `json:"score"`
+	Sources   []string  `json:"sources"`
+	FetchedAt time.Time `json:"fetched_at"` // 各来源中最新的数据时间
+}
+
+// DroppedCoin 因数据过期被丢弃的币种
+type DroppedCoin struct {
+	Symbol    string    `json:"symbol"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// rankScore 信号源内第 i 名（从0开始，共 n 个）的得分
+func rankScore(i, n int) float64 {
+	return 100 * float64(n-i) / float64(n)
+}
+
+// MergeResults 合并多个信号源的币种：去重并累加得分，按得分从高到低排序（同分保持首次出现的顺序）；
+// 数据时间早于 now-maxAge 的币种丢弃（maxAge 为0不丢弃，获取时间未知的币种视为最新），获取失败的信号源跳过
+func MergeResults(results []SourceResult, maxAge time.Duration, now time.Time) ([]Candidate, []DroppedCoin) {
+	var candidates []Candidate
+	var dropped []DroppedCoin
+	index := make(map[string]int)
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		for i, coin := range r.Coins {
+			if maxAge > 0 && !coin.FetchedAt.IsZero() && now.Sub(coin.FetchedAt) > maxAge {
+				dropped = append(dropped, DroppedCoin{Symbol: coin.Symbol, Source: r.Source, FetchedAt: coin.FetchedAt})
+				continue
+			}
+			j, ok := index[coin.Symbol]
+			if !ok {
+				j = len(candidates)
+				index[coin.Symbol] = j
+				candidates = append(candidates, Candidate{Symbol: coin.Symbol})
+			}
+			c := &candidates[j]
+			if slices.Contains(c.Sources, r.Source) {
+				continue
+			}
+			c.Sources = append(c.Sources, r.Source)
+			c.Score += rankScore(i, len(r.Coins))
+			if coin.FetchedAt.After(c.FetchedAt) {
+				c.FetchedAt = coin.FetchedAt
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	return candidates, dropped
+}
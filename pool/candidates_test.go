@@ -0,0 +1,38 @@
+package pool
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeResults(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+	candidates, dropped := MergeResults([]SourceResult{
+		{Source: "ai500", Coins: []SourceCoin{{Symbol: "BTCUSDT", FetchedAt: now}, {Symbol: "ETHUSDT", FetchedAt: now}}},
+		{Source: "broken", Err: context.DeadlineExceeded},
+		{Source: "feed", Coins: []SourceCoin{{Symbol: "SOLUSDT"}, {Symbol: "ETHUSDT"}, {Symbol: "ETHUSDT"}}},
+		{Source: "cached", Coins: []SourceCoin{{Symbol: "DOGEUSDT", FetchedAt: old}}},
+	}, time.Hour, now)
+
+	var symbols []string
+	for _, c := range candidates {
+		symbols = append(symbols, c.Symbol)
+	}
+	// ETH: 50 + 2/3*100，BTC: 100，SOL: 100（同分保持首次出现的顺序）
+	if !reflect.DeepEqual(symbols, []string{"ETHUSDT", "BTCUSDT", "SOLUSDT"}) {
+		t.Errorf("应按得分排序: %+v", candidates)
+	}
+	if !reflect.DeepEqual(candidates[0].Sources, []string{"ai500", "feed"}) || !candidates[0].FetchedAt.Equal(now) {
+		t.Errorf("来源或数据时间错误: %+v", candidates[0])
+	}
+	if len(dropped) != 1 || dropped[0].Symbol != "DOGEUSDT" || dropped[0].Source != "cached" {
+		t.Errorf("过期数据应被丢弃: %+v", dropped)
+	}
+
+	if candidates, dropped := MergeResults([]SourceResult{{Source: "cached", Coins: []SourceCoin{{Symbol: "DOGEUSDT", FetchedAt: old}}}}, 0, now); len(candidates) != 1 || len(dropped) != 0 {
+		t.Errorf("有效期为0时不应丢弃: %+v %+v", candidates, dropped)
+	}
+}
@@ -1,14 +1,16 @@
 package pool
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -39,6 +41,27 @@ var coinPoolConfig = CoinPoolConfig{
 	UseDefaultCoins: false, // 默认不使用
 }
 
+// dataFetchedAt 内置数据最近一次返回的数据时间（使用缓存文件时为缓存写入的时间），用于判断数据是否过期
+var dataFetchedAt = struct {
+	sync.Mutex
+	coinPool time.Time
+	oiTop    time.Time
+}{}
+
+// markFetched 记录内置数据的时间
+func markFetched(field *time.Time, t time.Time) {
+	dataFetchedAt.Lock()
+	defer dataFetchedAt.Unlock()
+	*field = t
+}
+
+// fetchedAt 读取内置数据的时间
+func fetchedAt(field *time.Time) time.Time {
+	dataFetchedAt.Lock()
+	defer dataFetchedAt.Unlock()
+	return *field
+}
+
 // CoinPoolCache 币种池缓存
 type CoinPoolCache struct {
 	Coins      []CoinInfo `json:"coins"`
@@ -87,7 +110,7 @@ func SetUseDefaultCoins(useDefault bool) {
 func SetDefaultCoins(coins []string) {
 	if len(coins) > 0 {
 		defaultMainstreamCoins = coins
-		log.Printf("✓ 已设置默认币种池（共%d个币种）: %v", len(coins), coins)
+		slog.Info("已设置默认币种池", "count", len(coins), "coins", coins)
 	}
 }
 
@@ -95,13 +118,15 @@ func SetDefaultCoins(coins []string) {
 func GetCoinPool() ([]CoinInfo, error) {
 	// 优先检查是否启用默认币种列表
 	if coinPoolConfig.UseDefaultCoins {
-		log.Printf("✓ 已启用默认主流币种列表")
+		slog.Info("已启用默认主流币种列表")
+		markFetched(&dataFetchedAt.coinPool, time.Now())
 		return convertSymbolsToCoins(defaultMainstreamCoins), nil
 	}
 
 	// 检查API URL是否配置
 	if strings.TrimSpace(coinPoolConfig.APIURL) == "" {
-		log.Printf("⚠️  未配置币种池API URL，使用默认主流币种列表")
+		slog.Warn("未配置币种池API URL，使用默认主流币种列表")
+		markFetched(&dataFetchedAt.coinPool, time.Now())
 		return convertSymbolsToCoins(defaultMainstreamCoins), nil
 	}
 
@@ -111,42 +136,44 @@ func GetCoinPool() ([]CoinInfo, error) {
 	// 尝试从API获取
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
-			log.Printf("⚠️  第%d次重试获取币种池（共%d次）...", attempt, maxRetries)
+			slog.Warn("重试获取币种池", "attempt", attempt, "max_retries", maxRetries)
 			time.Sleep(2 * time.Second) // 重试前等待2秒
 		}
 
 		coins, err := fetchCoinPool()
 		if err == nil {
 			if attempt > 1 {
-				log.Printf("✓ 第%d次重试成功", attempt)
+				slog.Info("重试获取币种池成功", "attempt", attempt)
 			}
 			// 成功获取后保存到缓存
 			if err := saveCoinPoolCache(coins); err != nil {
-				log.Printf("⚠️  保存币种池缓存失败: %v", err)
+				slog.Warn("保存币种池缓存失败", "error", err)
 			}
+			markFetched(&dataFetchedAt.coinPool, time.Now())
 			return coins, nil
 		}
 
 		lastErr = err
-		log.Printf("❌ 第%d次请求失败: %v", attempt, err)
+		slog.Error("请求币种池失败", "attempt", attempt, "error", err)
 	}
 
 	// API获取失败，尝试使用缓存
-	log.Printf("⚠️  API请求全部失败，尝试使用历史缓存数据...")
+	slog.Warn("币种池API请求全部失败，尝试使用历史缓存数据")
 	cachedCoins, err := loadCoinPoolCache()
 	if err == nil {
-		log.Printf("✓ 使用历史缓存数据（共%d个币种）", len(cachedCoins))
+		slog.Info("使用历史币种池缓存数据", "count", len(cachedCoins))
 		return cachedCoins, nil
 	}
 
 	// 缓存也失败，使用默认主流币种
-	log.Printf("⚠️  无法加载缓存数据（最后错误: %v），使用默认主流币种列表", lastErr)
+	slog.Warn("无法加载币种池缓存数据，使用默认主流币种列表", "last_error", lastErr)
+	markFetched(&dataFetchedAt.coinPool, time.Now())
 	return convertSymbolsToCoins(defaultMainstreamCoins), nil
 }
 
 // fetchCoinPool 实际执行币种池请求
 func fetchCoinPool() ([]CoinInfo, error) {
-	log.Printf("🔄 正在请求AI500币种池...")
+	slog.Debug("正在请求AI500币种池")
 
 	client := &http.Client{
 		Timeout: coinPoolConfig.Timeout,
@@ -187,7 +214,7 @@ func fetchCoinPool() ([]CoinInfo, error) {
 		coins[i].IsAvailable = true
 	}
 
-	log.Printf("✓ 成功获取%d个币种", len(coins))
+	slog.Info("获取币种池成功", "count", len(coins))
 	return coins, nil
 }
 
@@ -214,7 +241,7 @@ func saveCoinPoolCache(coins []CoinInfo) error {
 		return fmt.Errorf("写入缓存文件失败: %w", err)
 	}
 
-	log.Printf("💾 已保存币种池缓存（%d个币种）", len(coins))
+	slog.Debug("已保存币种池缓存", "count", len(coins))
 	return nil
 }
 
@@ -240,12 +267,11 @@ func loadCoinPoolCache() ([]CoinInfo, error) {
 	// 检查缓存年龄
 	cacheAge := time.Since(cache.FetchedAt)
 	if cacheAge > 24*time.Hour {
-		log.Printf("⚠️  缓存数据较旧（%.1f小时前），但仍可使用", cacheAge.Hours())
+		slog.Warn("币种池缓存数据较旧，但仍可使用", "fetched_at", cache.FetchedAt, "age_hours", cacheAge.Hours())
 	} else {
-		log.Printf("📂 缓存数据时间: %s（%.1f分钟前）",
-			cache.FetchedAt.Format("2006-01-02 15:04:05"),
-			cacheAge.Minutes())
+		slog.Info("币种池缓存数据时间", "fetched_at", cache.FetchedAt, "age_minutes", cacheAge.Minutes())
 	}
+	markFetched(&dataFetchedAt.coinPool, cache.FetchedAt)
 
 	return cache.Coins, nil
 }
@@ -418,11 +444,16 @@ var oiTopConfig = struct {
 	CacheDir: "coin_pool_cache",
 }
 
-// GetOITopPositions 获取持仓量增长Top20数据（带重试和缓存）
+// GetOITopPositions 获取持仓量增长Top20数据，来源由 SetOITopProvider 决定
 func GetOITopPositions() ([]OIPosition, error) {
+	return currentOITopProvider().Positions(context.Background())
+}
+
+// getOITopFromAPI 请求 oi_top_api_url 获取OI Top数据（带重试和缓存）
+func getOITopFromAPI() ([]OIPosition, error) {
 	// 检查API URL是否配置
 	if strings.TrimSpace(oiTopConfig.APIURL) == "" {
-		log.Printf("⚠️  未配置OI Top API URL，跳过OI Top数据获取")
+		slog.Warn("未配置OI Top API URL，跳过OI Top数据获取")
 		return []OIPosition{}, nil // 返回空列表，不是错误
 	}
 
@@ -432,42 +463,43 @@ func GetOITopPositions() ([]OIPosition, error) {
 	// 尝试从API获取
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
-			log.Printf("⚠️  第%d次重试获取OI Top数据（共%d次）...", attempt, maxRetries)
+			slog.Warn("重试获取OI Top数据", "attempt", attempt, "max_retries", maxRetries)
 			time.Sleep(2 * time.Second)
 		}
 
 		positions, err := fetchOITop()
 		if err == nil {
 			if attempt > 1 {
-				log.Printf("✓ 第%d次重试成功", attempt)
+				slog.Info("重试获取OI Top数据成功", "attempt", attempt)
 			}
 			// 成功获取后保存到缓存
 			if err := saveOITopCache(positions); err != nil {
-				log.Printf("⚠️  保存OI Top缓存失败: %v", err)
+				slog.Warn("保存OI Top缓存失败", "error", err)
 			}
+			markFetched(&dataFetchedAt.oiTop, time.Now())
 			return positions, nil
 		}
 
 		lastErr = err
-		log.Printf("❌ 第%d次请求OI Top失败: %v", attempt, err)
+		slog.Error("请求OI Top失败", "attempt", attempt, "error", err)
 	}
 
 	// API获取失败，尝试使用缓存
-	log.Printf("⚠️  OI Top API请求全部失败，尝试使用历史缓存数据...")
+	slog.Warn("OI Top API请求全部失败，尝试使用历史缓存数据")
 	cachedPositions, err := loadOITopCache()
 	if err == nil {
-		log.Printf("✓ 使用历史OI Top缓存数据（共%d个币种）", len(cachedPositions))
+		slog.Info("使用历史OI Top缓存数据", "count", len(cachedPositions))
 		return cachedPositions, nil
 	}
 
 	// 缓存也失败，返回空列表（OI Top是可选的）
-	log.Printf("⚠️  无法加载OI Top缓存数据（最后错误: %v），跳过OI Top数据", lastErr)
+	slog.Warn("无法加载OI Top缓存数据，跳过OI Top数据", "last_error", lastErr)
 	return []OIPosition{}, nil
 }
 
 // fetchOITop 实际执行OI Top请求
 func fetchOITop() ([]OIPosition, error) {
-	log.Printf("🔄 正在请求OI Top数据...")
+	slog.Debug("正在请求OI Top数据")
 
 	client := &http.Client{
 		Timeout: oiTopConfig.Timeout,
@@ -502,8 +534,7 @@ func fetchOITop() ([]OIPosition, error) {
 		return nil, fmt.Errorf("OI Top持仓列表为空")
 	}
 
-	log.Printf("✓ 成功获取%d个OI Top币种（时间范围: %s）",
-		len(response.Data.Positions), response.Data.TimeRange)
+	slog.Info("获取OI Top数据成功", "count", len(response.Data.Positions), "time_range", response.Data.TimeRange)
 	return response.Data.Positions, nil
 }
 
@@ -529,7 +560,7 @@ func saveOITopCache(positions []OIPosition) error {
 		return fmt.Errorf("写入OI Top缓存文件失败: %w", err)
 	}
 
-	log.Printf("💾 已保存OI Top缓存（%d个币种）", len(positions))
+	slog.Debug("已保存OI Top缓存", "count", len(positions))
 	return nil
 }
 
@@ -553,12 +584,11 @@ func loadOITopCache() ([]OIPosition, error) {
 
 	cacheAge := time.Since(cache.FetchedAt)
 	if cacheAge > 24*time.Hour {
-		log.Printf("⚠️  OI Top缓存数据较旧（%.1f小时前），但仍可使用", cacheAge.Hours())
+		slog.Warn("OI Top缓存数据较旧，但仍可使用", "fetched_at", cache.FetchedAt, "age_hours", cacheAge.Hours())
 	} else {
-		log.Printf("📂 OI Top缓存数据时间: %s（%.1f分钟前）",
-			cache.FetchedAt.Format("2006-01-02 15:04:05"),
-			cacheAge.Minutes())
+		slog.Info("OI Top缓存数据时间", "fetched_at", cache.FetchedAt, "age_minutes", cacheAge.Minutes())
 	}
+	markFetched(&dataFetchedAt.oiTop, cache.FetchedAt)
 
 	return cache.Positions, nil
 }
@@ -583,48 +613,30 @@ func GetOITopSymbols() ([]string, error) {
 type MergedCoinPool struct {
 	AI500Coins    []CoinInfo          // AI500评分币种
 	OITopCoins    []OIPosition        // 持仓量增长Top20
-	AllSymbols    []string            // 所有不重复的币种符号
+	AllSymbols    []string            // 所有不重复的币种符号（按得分从高到低）
 	SymbolSources map[string][]string // 每个币种的来源（"ai500"/"oi_top"）
+	Candidates    []Candidate         // 合并后的候选币种和得分
+	Dropped       []DroppedCoin       // 数据过期被丢弃的币种
 }
 
 // GetMergedCoinPool 获取合并后的币种池（AI500 + OI Top，去重）
 func GetMergedCoinPool(ai500Limit int) (*MergedCoinPool, error) {
-	// 1. 获取AI500数据
-	ai500TopSymbols, err := GetTopRatedCoins(ai500Limit)
-	if err != nil {
-		log.Printf("⚠️  获取AI500数据失败: %v", err)
-		ai500TopSymbols = []string{} // 失败时用空列表
-	}
-
-	// 2. 获取OI Top数据
-	oiTopSymbols, err := GetOITopSymbols()
-	if err != nil {
-		log.Printf("⚠️  获取OI Top数据失败: %v", err)
-		oiTopSymbols = []string{} // 失败时用空列表
-	}
-
-	// 3. 合并并去重
-	symbolSet := make(map[string]bool)
-	symbolSources := make(map[string][]string)
-
-	// 添加AI500币种
-	for _, symbol := range ai500TopSymbols {
-		symbolSet[symbol] = true
-		symbolSources[symbol] = append(symbolSources[symbol], "ai500")
-	}
+	// 1. 获取AI500和OI Top数据，失败的信号源按空列表处理
+	results := FetchAll(context.Background(), []SignalSource{NewCoinPoolSource(ai500Limit), NewOITopSource()})
 
-	// 添加OI Top币种
-	for _, symbol := range oiTopSymbols {
-		if !symbolSet[symbol] {
-			symbolSet[symbol] = true
+	for _, r := range results {
+		if r.Err != nil {
+			slog.Warn("获取信号源数据失败", "source", r.Source, "error", r.Err)
 		}
-		symbolSources[symbol] = append(symbolSources[symbol], "oi_top")
 	}
 
-	// 转换为数组
-	var allSymbols []string
-	for symbol := range symbolSet {
-		allSymbols = append(allSymbols, symbol)
+	// 2. 合并、去重并按得分排序，丢弃数据过期的币种
+	candidates, dropped := MergeResults(results, MaxDataAge(), time.Now())
+	allSymbols := make([]string, 0, len(candidates))
+	symbolSources := make(map[string][]string, len(candidates))
+	for _, c := range candidates {
+		allSymbols = append(allSymbols, c.Symbol)
+		symbolSources[c.Symbol] = c.Sources
 	}
 
 	// 获取完整数据
@@ -636,10 +648,11 @@ func GetMergedCoinPool(ai500Limit int) (*MergedCoinPool, error) {
 		OITopCoins:    oiTopPositions,
 		AllSymbols:    allSymbols,
 		SymbolSources: symbolSources,
+		Candidates:    candidates,
+		Dropped:       dropped,
 	}
 
-	log.Printf("📊 币种池合并完成: AI500=%d, OI_Top=%d, 总计(去重)=%d",
-		len(ai500TopSymbols), len(oiTopSymbols), len(allSymbols))
+	slog.Info("币种池合并完成", "ai500", len(results[0].Coins), "oi_top", len(results[1].Coins), "total", len(allSymbols), "dropped", len(dropped))
 
 	return merged, nil
 }
@@ -0,0 +1,288 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"nofx/market"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OI Top数据的提供方（系统配置 oi_top_provider）
+const (
+	OITopProviderAPI     = "api"     // 请求外部的 oi_top_api_url
+	OITopProviderBuiltin = "builtin" // 用币安合约公开接口计算最近1小时持仓量变化
+)
+
+const (
+	oiDeltaRefresh     = 15 * time.Minute // 内置计算结果的有效期，过期后下一次读取时重新计算
+	oiDeltaTimeout     = 2 * time.Minute  // 一次完整计算的超时
+	oiDeltaTopN        = 20               // 与外部OI Top一致，取前20
+	oiDeltaMinOIValue  = 15_000_000       // 持仓价值低于此值（USDT）的币种不参与排名，避免小币的百分比变化排在前面
+	oiDeltaConcurrency = 8                // 同时请求持仓量历史的币种数
+	oiDeltaMaxSymbols  = 150              // 每次计算最多请求的币种数（按24h成交额取前N个），控制请求量
+	oiDeltaPeriod      = "5m"
+	oiDeltaPoints      = 13       // 13个5分钟数据点覆盖1小时
+	oiDeltaMaxBody     = 16 << 20 // 合约列表包含所有交易规则，响应较大
+)
+
+// binanceFuturesURL 币安U本位合约公开接口地址（测试时替换）
+var binanceFuturesURL = "https://fapi.binance.com"
+
+// OITopProvider OI Top数据的提供方
+type OITopProvider interface {
+	Name() string
+	Positions(ctx context.Context) ([]OIPosition, error)
+}
+
+var oiTopProvider = struct {
+	sync.RWMutex
+	p OITopProvider
+}{p: apiOITopProvider{}}
+
+// SetOITopProvider 切换OI Top数据的提供方（api / builtin），对所有交易员生效
+func SetOITopProvider(name string) error {
+	var p OITopProvider
+	switch name {
+	case OITopProviderAPI, "":
+		p = apiOITopProvider{}
+	case OITopProviderBuiltin:
+		p = builtinOITop
+	default:
+		return fmt.Errorf("不支持的OI Top来源 %q（可选 %s、%s）", name, OITopProviderAPI, OITopProviderBuiltin)
+	}
+	oiTopProvider.Lock()
+	defer oiTopProvider.Unlock()
+	oiTopProvider.p = p
+	return nil
+}
+
+// currentOITopProvider 当前使用的OI Top提供方
+func currentOITopProvider() OITopProvider {
+	oiTopProvider.RLock()
+	defer oiTopProvider.RUnlock()
+	return oiTopProvider.p
+}
+
+// apiOITopProvider 请求外部OI Top服务，未配置地址时返回空列表
+type apiOITopProvider struct{}
+
+func (apiOITopProvider) Name() string { return OITopProviderAPI }
+
+func (apiOITopProvider) Positions(ctx context.Context) ([]OIPosition, error) {
+	return getOITopFromAPI()
+}
+
+// builtinOITop 内置计算结果在所有交易员之间共享；与行情、下单共用币安的权重预算，限频时一起暂停
+var builtinOITop = &oiDeltaRanker{
+	client:     market.NewRateLimitedHTTPClient(10*time.Second, false),
+	maxSymbols: oiDeltaMaxSymbols,
+}
+
+// oiDeltaRanker 根据币安成交额最大的USDT永续合约最近1小时的持仓量变化排名
+type oiDeltaRanker struct {
+	client     *http.Client
+	maxSymbols int // 每次计算最多请求的币种数
+
+	mu         sync.Mutex // 计算期间持有，并发的读取等待同一次计算
+	positions  []OIPosition
+	computedAt time.Time
+}
+
+func (r *oiDeltaRanker) Name() string { return OITopProviderBuiltin }
+
+// Positions 返回最近一次计算的结果，过期时重新计算；计算失败时沿用上一次的结果
+func (r *oiDeltaRanker) Positions(ctx context.Context) ([]OIPosition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.computedAt.IsZero() && time.Since(r.computedAt) < oiDeltaRefresh {
+		return r.positions, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, oiDeltaTimeout)
+	defer cancel()
+	start := time.Now()
+	positions, err := r.compute(ctx)
+	if err != nil {
+		if r.computedAt.IsZero() {
+			slog.Warn("计算内置OI Top失败，跳过OI Top数据", "error", err)
+			return []OIPosition{}, nil
+		}
+		slog.Warn("计算内置OI Top失败，沿用上一次的结果", "error", err, "computed_at", r.computedAt)
+		return r.positions, nil
+	}
+	r.positions, r.computedAt = positions, time.Now()
+	markFetched(&dataFetchedAt.oiTop, r.computedAt)
+	slog.Info("内置OI Top计算完成", "count", len(positions), "elapsed", time.Since(start).Round(time.Millisecond))
+	return positions, nil
+}
+
+// compute 请求所有USDT永续合约的持仓量历史，按1小时持仓量变化百分比取前 oiDeltaTopN 个
+func (r *oiDeltaRanker) compute(ctx context.Context) ([]OIPosition, error) {
+	symbols, err := r.perpetualSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu        sync.Mutex
+		positions []OIPosition
+		failed    int
+		lastErr   error
+		wg        sync.WaitGroup
+	)
+	sem := make(chan struct{}, oiDeltaConcurrency)
+	for _, symbol := range symbols {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(symbol string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			pos, ok, err := r.symbolDelta(ctx, symbol)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+				lastErr = err
+				return
+			}
+			if ok {
+				positions = append(positions, pos)
+			}
+		}(symbol)
+	}
+	wg.Wait()
+
+	if failed == len(symbols) && lastErr != nil {
+		return nil, fmt.Errorf("所有币种的持仓量历史都获取失败: %w", lastErr)
+	}
+	if failed > 0 {
+		slog.Debug("部分币种持仓量历史获取失败", "failed", failed, "total", len(symbols), "last_error", lastErr)
+	}
+	return rankOIDelta(positions, oiDeltaTopN), nil
+}
+
+// rankOIDelta 按持仓量变化百分比从高到低排序，取前n个并写入排名
+func rankOIDelta(positions []OIPosition, n int) []OIPosition {
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].OIDeltaPercent != positions[j].OIDeltaPercent {
+			return positions[i].OIDeltaPercent > positions[j].OIDeltaPercent
+		}
+		return positions[i].Symbol < positions[j].Symbol
+	})
+	if len(positions) > n {
+		positions = positions[:n]
+	}
+	for i := range positions {
+		positions[i].Rank = i + 1
+	}
+	return positions
+}
+
+// getJSON 请求币安公开接口并解析JSON
+func (r *oiDeltaRanker) getJSON(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, binanceFuturesURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("返回 HTTP %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oiDeltaMaxBody)).Decode(out)
+}
+
+// perpetualSymbols 正在交易的USDT永续合约中24h成交额最大的 maxSymbols 个
+func (r *oiDeltaRanker) perpetualSymbols(ctx context.Context) ([]string, error) {
+	var info struct {
+		Symbols []struct {
+			Symbol       string `json:"symbol"`
+			ContractType string `json:"contractType"`
+			QuoteAsset   string `json:"quoteAsset"`
+			Status       string `json:"status"`
+		} `json:"symbols"`
+	}
+	if err := r.getJSON(ctx, "/fapi/v1/exchangeInfo", &info); err != nil {
+		return nil, fmt.Errorf("获取合约列表失败: %w", err)
+	}
+	var symbols []string
+	for _, s := range info.Symbols {
+		if s.ContractType == "PERPETUAL" && s.QuoteAsset == "USDT" && s.Status == "TRADING" {
+			symbols = append(symbols, s.Symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("合约列表为空")
+	}
+	if len(symbols) <= r.maxSymbols {
+		return symbols, nil
+	}
+
+	var tickers []struct {
+		Symbol      string `json:"symbol"`
+		QuoteVolume string `json:"quoteVolume"`
+	}
+	if err := r.getJSON(ctx, "/fapi/v1/ticker/24hr", &tickers); err != nil {
+		return nil, fmt.Errorf("获取24h成交额失败: %w", err)
+	}
+	volumes := make(map[string]float64, len(tickers))
+	for _, t := range tickers {
+		volumes[t.Symbol], _ = strconv.ParseFloat(t.QuoteVolume, 64)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if volumes[symbols[i]] != volumes[symbols[j]] {
+			return volumes[symbols[i]] > volumes[symbols[j]]
+		}
+		return symbols[i] < symbols[j]
+	})
+	return symbols[:r.maxSymbols], nil
+}
+
+// symbolDelta 计算单个币种最近1小时的持仓量和价格变化；持仓价值不足或数据不全时 ok 为 false
+func (r *oiDeltaRanker) symbolDelta(ctx context.Context, symbol string) (pos OIPosition, ok bool, err error) {
+	var hist []struct {
+		SumOpenInterest      string `json:"sumOpenInterest"`
+		SumOpenInterestValue string `json:"sumOpenInterestValue"`
+		Timestamp            int64  `json:"timestamp"`
+	}
+	path := fmt.Sprintf("/futures/data/openInterestHist?symbol=%s&period=%s&limit=%d", symbol, oiDeltaPeriod, oiDeltaPoints)
+	if err := r.getJSON(ctx, path, &hist); err != nil {
+		return pos, false, fmt.Errorf("%s: %w", symbol, err)
+	}
+	if len(hist) < 2 {
+		return pos, false, nil
+	}
+	first, last := hist[0], hist[len(hist)-1]
+	firstOI, _ := strconv.ParseFloat(first.SumOpenInterest, 64)
+	firstValue, _ := strconv.ParseFloat(first.SumOpenInterestValue, 64)
+	lastOI, _ := strconv.ParseFloat(last.SumOpenInterest, 64)
+	lastValue, _ := strconv.ParseFloat(last.SumOpenInterestValue, 64)
+	if firstOI <= 0 || lastOI <= 0 || lastValue < oiDeltaMinOIValue {
+		return pos, false, nil
+	}
+
+	// 持仓价值/持仓量即为当时的价格
+	firstPrice, lastPrice := firstValue/firstOI, lastValue/lastOI
+	delta := lastOI - firstOI
+	pos = OIPosition{
+		Symbol:         symbol,
+		CurrentOI:      lastOI,
+		OIDelta:        delta,
+		OIDeltaPercent: delta / firstOI * 100,
+		OIDeltaValue:   delta * lastPrice,
+	}
+	if firstPrice > 0 {
+		pos.PriceDeltaPercent = (lastPrice - firstPrice) / firstPrice * 100
+	}
+	return pos, true, nil
+}
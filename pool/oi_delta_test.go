@@ -0,0 +1,104 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOIDeltaRanker(t *testing.T) {
+	// 每个币种1小时前和现在的持仓量、价格
+	hist := map[string][2][2]float64{
+		"BTCUSDT":  {{1000, 60000}, {1100, 61200}}, // +10%
+		"ETHUSDT":  {{10000, 3000}, {12000, 2940}}, // +20%
+		"SOLUSDT":  {{200000, 150}, {190000, 150}}, // -5%
+		"TINYUSDT": {{1000, 1}, {5000, 1}},         // 持仓价值不足
+	}
+	var requests atomic.Int32
+	var histRequested sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/fapi/v1/ticker/24hr" {
+			fmt.Fprint(w, `[{"symbol":"BTCUSDT","quoteVolume":"9000000000"},{"symbol":"ETHUSDT","quoteVolume":"5000000000"},
+				{"symbol":"BADUSDT","quoteVolume":"2000000000"},{"symbol":"SOLUSDT","quoteVolume":"1000000000"}]`)
+			return
+		}
+		if r.URL.Path == "/fapi/v1/exchangeInfo" {
+			fmt.Fprint(w, `{"symbols":[
+				{"symbol":"BTCUSDT","contractType":"PERPETUAL","quoteAsset":"USDT","status":"TRADING"},
+				{"symbol":"ETHUSDT","contractType":"PERPETUAL","quoteAsset":"USDT","status":"TRADING"},
+				{"symbol":"SOLUSDT","contractType":"PERPETUAL","quoteAsset":"USDT","status":"TRADING"},
+				{"symbol":"TINYUSDT","contractType":"PERPETUAL","quoteAsset":"USDT","status":"TRADING"},
+				{"symbol":"BTCUSDT_260925","contractType":"CURRENT_QUARTER","quoteAsset":"USDT","status":"TRADING"},
+				{"symbol":"BADUSDT","contractType":"PERPETUAL","quoteAsset":"USDT","status":"TRADING"}]}`)
+			return
+		}
+		histRequested.Store(r.URL.Query().Get("symbol"), true)
+		points, ok := hist[r.URL.Query().Get("symbol")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "[")
+		for i, p := range points {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"sumOpenInterest":"%f","sumOpenInterestValue":"%f","timestamp":%d}`, p[0], p[0]*p[1], i)
+		}
+		fmt.Fprint(w, "]")
+	}))
+	defer srv.Close()
+	defer func(u string) { binanceFuturesURL = u }(binanceFuturesURL)
+	binanceFuturesURL = srv.URL
+
+	ranker := &oiDeltaRanker{client: srv.Client(), maxSymbols: 10}
+	positions, err := ranker.Positions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range positions {
+		got = append(got, fmt.Sprintf("%d:%s:%.0f", p.Rank, p.Symbol, p.OIDeltaPercent))
+	}
+	if want := []string{"1:ETHUSDT:20", "2:BTCUSDT:10", "3:SOLUSDT:-5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("排名错误: %v", got)
+	}
+	if eth := positions[0]; eth.OIDelta != 2000 || eth.OIDeltaValue != 2000*2940 || eth.PriceDeltaPercent > -1.99 || eth.PriceDeltaPercent < -2.01 {
+		t.Errorf("持仓量变化计算错误: %+v", eth)
+	}
+
+	// 有效期内不重复请求
+	before := requests.Load()
+	if _, err := ranker.Positions(context.Background()); err != nil || requests.Load() != before {
+		t.Errorf("有效期内应使用上一次的结果: requests %d -> %d", before, requests.Load())
+	}
+
+	// 超过上限时只请求24h成交额最大的币种
+	histRequested.Clear()
+	capped := &oiDeltaRanker{client: srv.Client(), maxSymbols: 2}
+	positions, err = capped.Positions(context.Background())
+	if err != nil || len(positions) != 2 || positions[0].Symbol != "ETHUSDT" || positions[1].Symbol != "BTCUSDT" {
+		t.Errorf("限制币种数后的排名错误: %+v %v", positions, err)
+	}
+	for _, symbol := range []string{"SOLUSDT", "BADUSDT", "TINYUSDT"} {
+		if _, ok := histRequested.Load(symbol); ok {
+			t.Errorf("%s 成交额不在前2，不应请求持仓量历史", symbol)
+		}
+	}
+}
+
+func TestSetOITopProvider(t *testing.T) {
+	defer SetOITopProvider(OITopProviderAPI)
+	if err := SetOITopProvider(OITopProviderBuiltin); err != nil || currentOITopProvider().Name() != OITopProviderBuiltin {
+		t.Errorf("切换到内置OI Top失败: %v", err)
+	}
+	if err := SetOITopProvider("coinglass"); err == nil || currentOITopProvider().Name() != OITopProviderBuiltin {
+		t.Errorf("无效的来源应报错且不改变当前来源: %v", err)
+	}
+}
@@ -0,0 +1,521 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 用户可注册的信号源类型
+const (
+	SourceHTTPJSON = "http_json" // 定时请求返回JSON的接口，按映射配置取出币种
+	SourceRSS      = "rss"       // RSS/Atom 订阅，从标题和摘要中提取 $BTC、BTCUSDT 形式的币种
+	SourceWebhook  = "webhook"   // 由外部系统签名推送币种，不主动请求
+)
+
+// 信号源的限制
+const (
+	defaultSourceMaxCoins = 10
+	maxSourceMaxCoins     = 30
+	maxSourceBodyBytes    = 1 << 20
+	maxFeedItems          = 50
+	maxSymbolLen          = 20
+)
+
+// reservedSourceNames 内置来源标记，用户信号源不能重名，避免候选币种的来源混淆
+var reservedSourceNames = map[string]bool{
+	"ai500": true, "oi_top": true, "signal": true, "default": true, "custom": true,
+}
+
+var sourceNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// sourceHTTPClient 用户信号源使用的HTTP客户端，由 main 替换为只允许访问公网地址的客户端
+var sourceHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// SetSourceHTTPClient 设置用户信号源请求使用的HTTP客户端
+func SetSourceHTTPClient(client *http.Client) {
+	sourceHTTPClient = client
+}
+
+// SourceCoin 信号源给出的一个候选币种
+type SourceCoin struct {
+	Symbol    string    `json:"symbol"`
+	Score     float64   `json:"score"`      // 信号源的评分（RSS为提及次数），没有评分时为0
+	FetchedAt time.Time `json:"fetched_at"` // 数据的获取时间（使用缓存或上一次结果时为原始获取时间）
+}
+
+// SignalSource 候选币种信号源：内置的 AI500、OI Top 和用户注册的信号源都实现该接口
+type SignalSource interface {
+	// Name 来源标记，写入候选币种的 sources
+	Name() string
+	// Fetch 获取当前的候选币种，按优先级从高到低排列
+	Fetch(ctx context.Context) ([]SourceCoin, error)
+}
+
+// SourceConfig 用户注册的信号源配置（以JSON保存在数据库中）
+type SourceConfig struct {
+	Type string `json:"type"` // http_json / rss / webhook
+	URL  string `json:"url,omitempty"`
+	// ItemsPath JSON中币种列表的路径，点号分隔（如 data.coins），为空表示响应本身就是列表
+	ItemsPath string `json:"items_path,omitempty"`
+	// SymbolField 列表元素为对象时币种所在的字段，默认 symbol；元素为字符串时忽略
+	SymbolField string `json:"symbol_field,omitempty"`
+	// ScoreField 评分字段，设置后按评分从高到低取前 MaxCoins 个
+	ScoreField string `json:"score_field,omitempty"`
+	MaxCoins   int    `json:"max_coins,omitempty"` // 每个周期最多取的币种数，默认10，最多30
+}
+
+// ValidateSourceName 检查信号源名称：小写字母、数字、下划线和短横线，不能与内置来源重名
+func ValidateSourceName(name string) error {
+	if !sourceNamePattern.MatchString(name) {
+		return fmt.Errorf("信号源名称只能包含小写字母、数字、下划线和短横线（1-32个字符）")
+	}
+	if reservedSourceNames[name] {
+		return fmt.Errorf("信号源名称 %q 与内置来源重名", name)
+	}
+	return nil
+}
+
+// Validate 检查配置并填充默认值（URL是否指向内网由调用方检查）
+func (c *SourceConfig) Validate() error {
+	switch c.Type {
+	case SourceHTTPJSON, SourceRSS:
+		if c.URL == "" {
+			return fmt.Errorf("%s 类型的信号源必须设置 url", c.Type)
+		}
+	case SourceWebhook:
+		if c.URL != "" {
+			return fmt.Errorf("webhook 类型的信号源由外部推送，不需要 url")
+		}
+	default:
+		return fmt.Errorf("不支持的信号源类型 %q（可选 %s、%s、%s）", c.Type, SourceHTTPJSON, SourceRSS, SourceWebhook)
+	}
+	if c.Type != SourceHTTPJSON && (c.ItemsPath != "" || c.SymbolField != "" || c.ScoreField != "") {
+		return fmt.Errorf("items_path、symbol_field、score_field 只用于 %s 类型", SourceHTTPJSON)
+	}
+	if c.MaxCoins < 0 || c.MaxCoins > maxSourceMaxCoins {
+		return fmt.Errorf("max_coins 必须在1-%d之间", maxSourceMaxCoins)
+	}
+	if c.MaxCoins == 0 {
+		c.MaxCoins = defaultSourceMaxCoins
+	}
+	if c.Type == SourceHTTPJSON && c.SymbolField == "" {
+		c.SymbolField = "symbol"
+	}
+	return nil
+}
+
+// NewSignalSource 根据配置创建用户信号源，id 用于关联 webhook 推送的数据
+func NewSignalSource(id, name string, cfg SourceConfig) (SignalSource, error) {
+	if err := ValidateSourceName(name); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.Type {
+	case SourceHTTPJSON:
+		return &httpJSONSource{name: name, cfg: cfg}, nil
+	case SourceRSS:
+		return &rssSource{name: name, cfg: cfg}, nil
+	default:
+		return &webhookSource{id: id, name: name, maxCoins: cfg.MaxCoins}, nil
+	}
+}
+
+// fetchBody 请求信号源地址，响应体超过 maxSourceBodyBytes 时报错
+func fetchBody(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sourceHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求信号源失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取信号源响应失败: %w", err)
+	}
+	if len(body) > maxSourceBodyBytes {
+		return nil, fmt.Errorf("信号源响应超过%dKB", maxSourceBodyBytes>>10)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("信号源返回错误 (status %d)", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// CleanSymbol 标准化信号源给出的币种（BTC、$btc → BTCUSDT），不是合法交易对时返回空字符串
+func CleanSymbol(raw string) string {
+	symbol := normalizeSymbol(strings.TrimPrefix(strings.TrimSpace(raw), "$"))
+	if symbol == "USDT" || len(symbol) > maxSymbolLen {
+		return ""
+	}
+	for _, c := range symbol {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return ""
+		}
+	}
+	return symbol
+}
+
+// stamp 设置币种的获取时间
+func stamp(coins []SourceCoin, t time.Time) {
+	for i := range coins {
+		coins[i].FetchedAt = t
+	}
+}
+
+// lastGood 信号源最近一次成功获取的结果，请求失败时继续使用
+type lastGood struct {
+	mu    sync.Mutex
+	coins []SourceCoin
+}
+
+func (l *lastGood) fallback(name string, err error) ([]SourceCoin, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.coins == nil {
+		return nil, err
+	}
+	slog.Warn("信号源请求失败，使用上一次的结果", "source", name, "error", err)
+	return l.coins, nil
+}
+
+func (l *lastGood) store(coins []SourceCoin) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.coins = coins
+}
+
+// httpJSONSource 请求JSON接口，按 ItemsPath/SymbolField/ScoreField 取出币种
+type httpJSONSource struct {
+	name string
+	cfg  SourceConfig
+	last lastGood
+}
+
+func (s *httpJSONSource) Name() string { return s.name }
+
+func (s *httpJSONSource) Fetch(ctx context.Context) ([]SourceCoin, error) {
+	body, err := fetchBody(ctx, s.cfg.URL)
+	if err != nil {
+		return s.last.fallback(s.name, err)
+	}
+	coins, err := extractJSONCoins(body, s.cfg)
+	if err != nil {
+		return s.last.fallback(s.name, err)
+	}
+	stamp(coins, time.Now())
+	s.last.store(coins)
+	return coins, nil
+}
+
+// extractJSONCoins 按映射配置从JSON响应中取出币种，设置了评分字段时按评分排序
+func extractJSONCoins(body []byte, cfg SourceConfig) ([]SourceCoin, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("信号源JSON解析失败: %w", err)
+	}
+	node := doc
+	if cfg.ItemsPath != "" {
+		for _, key := range strings.Split(cfg.ItemsPath, ".") {
+			switch v := node.(type) {
+			case map[string]interface{}:
+				node = v[key]
+			case []interface{}:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(v) {
+					return nil, fmt.Errorf("items_path %q 中的 %q 不是有效的下标", cfg.ItemsPath, key)
+				}
+				node = v[i]
+			default:
+				node = nil
+			}
+			if node == nil {
+				return nil, fmt.Errorf("响应中不存在 items_path %q", cfg.ItemsPath)
+			}
+		}
+	}
+	items, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("items_path %q 指向的不是列表", cfg.ItemsPath)
+	}
+
+	var coins []SourceCoin
+	seen := make(map[string]bool)
+	for _, item := range items {
+		var coin SourceCoin
+		switch v := item.(type) {
+		case string:
+			coin.Symbol = CleanSymbol(v)
+		case map[string]interface{}:
+			if raw, ok := v[cfg.SymbolField].(string); ok {
+				coin.Symbol = CleanSymbol(raw)
+			}
+			if cfg.ScoreField != "" {
+				coin.Score = toFloat(v[cfg.ScoreField])
+			}
+		}
+		if coin.Symbol == "" || seen[coin.Symbol] {
+			continue
+		}
+		seen[coin.Symbol] = true
+		coins = append(coins, coin)
+	}
+	if len(coins) == 0 {
+		return nil, fmt.Errorf("信号源没有返回有效的币种")
+	}
+	if cfg.ScoreField != "" {
+		sort.SliceStable(coins, func(i, j int) bool { return coins[i].Score > coins[j].Score })
+	}
+	if len(coins) > cfg.MaxCoins {
+		coins = coins[:cfg.MaxCoins]
+	}
+	return coins, nil
+}
+
+// toFloat 评分字段可能是数字或数字字符串，无法解析时为0
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f
+	}
+	return 0
+}
+
+// rssSource 订阅RSS/Atom，按币种被提及的次数排序
+type rssSource struct {
+	name string
+	cfg  SourceConfig
+	last lastGood
+}
+
+func (s *rssSource) Name() string { return s.name }
+
+func (s *rssSource) Fetch(ctx context.Context) ([]SourceCoin, error) {
+	body, err := fetchBody(ctx, s.cfg.URL)
+	if err != nil {
+		return s.last.fallback(s.name, err)
+	}
+	coins, err := extractFeedCoins(body, s.cfg.MaxCoins)
+	if err != nil {
+		return s.last.fallback(s.name, err)
+	}
+	stamp(coins, time.Now())
+	s.last.store(coins)
+	return coins, nil
+}
+
+// feedDoc 同时兼容 RSS 2.0（channel/item）和 Atom（entry）
+type feedDoc struct {
+	Items []struct {
+		Title       string `xml:"title"`
+		Description string `xml:"description"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		Title   string `xml:"title"`
+		Summary string `xml:"summary"`
+	} `xml:"entry"`
+}
+
+// feedSymbolPattern 订阅内容中的币种：$BTC 形式的 cashtag 或 BTCUSDT 形式的交易对
+var feedSymbolPattern = regexp.MustCompile(`(?:\$([A-Za-z][A-Za-z0-9]{1,9})|\b([A-Z][A-Z0-9]{1,9})USDT)\b`)
+
+// extractFeedCoins 统计订阅中最近 maxFeedItems 条内容提及的币种
+func extractFeedCoins(body []byte, maxCoins int) ([]SourceCoin, error) {
+	var doc feedDoc
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("订阅内容解析失败: %w", err)
+	}
+	var texts []string
+	for _, item := range doc.Items {
+		texts = append(texts, item.Title+" "+item.Description)
+	}
+	for _, entry := range doc.Entries {
+		texts = append(texts, entry.Title+" "+entry.Summary)
+	}
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("订阅中没有内容")
+	}
+	if len(texts) > maxFeedItems {
+		texts = texts[:maxFeedItems]
+	}
+
+	var coins []SourceCoin
+	index := make(map[string]int)
+	for _, text := range texts {
+		for _, m := range feedSymbolPattern.FindAllStringSubmatch(text, -1) {
+			symbol := CleanSymbol(m[1] + m[2])
+			if symbol == "" {
+				continue
+			}
+			if i, ok := index[symbol]; ok {
+				coins[i].Score++
+				continue
+			}
+			index[symbol] = len(coins)
+			coins = append(coins, SourceCoin{Symbol: symbol, Score: 1})
+		}
+	}
+	// 提及次数相同时保持在订阅中出现的先后顺序（订阅通常按时间倒序）
+	sort.SliceStable(coins, func(i, j int) bool { return coins[i].Score > coins[j].Score })
+	if len(coins) > maxCoins {
+		coins = coins[:maxCoins]
+	}
+	return coins, nil
+}
+
+// webhookCoin 外部推送到 webhook 信号源的一个币种
+type webhookCoin struct {
+	score     float64
+	pushedAt  time.Time
+	expiresAt time.Time
+}
+
+// webhookBuffers webhook 信号源收到的币种（信号源ID -> 币种 -> 数据），只保存在内存中，
+// 按信号源ID而不是交易员保存，交易员重新加载后推送的数据仍然有效
+var webhookBuffers = struct {
+	sync.Mutex
+	m map[string]map[string]webhookCoin
+}{m: make(map[string]map[string]webhookCoin)}
+
+// PushWebhookCoins 保存推送到 webhook 信号源的币种（同一币种覆盖旧数据），返回当前未过期的币种数
+func PushWebhookCoins(sourceID string, coins []SourceCoin, ttl time.Duration) int {
+	webhookBuffers.Lock()
+	defer webhookBuffers.Unlock()
+
+	buf := webhookBuffers.m[sourceID]
+	if buf == nil {
+		buf = make(map[string]webhookCoin)
+		webhookBuffers.m[sourceID] = buf
+	}
+	now := time.Now()
+	for _, coin := range coins {
+		buf[coin.Symbol] = webhookCoin{score: coin.Score, pushedAt: now, expiresAt: now.Add(ttl)}
+	}
+	for symbol, coin := range buf {
+		if !now.Before(coin.expiresAt) {
+			delete(buf, symbol)
+		}
+	}
+	return len(buf)
+}
+
+// DropWebhookSource 删除 webhook 信号源已收到的数据
+func DropWebhookSource(sourceID string) {
+	webhookBuffers.Lock()
+	defer webhookBuffers.Unlock()
+	delete(webhookBuffers.m, sourceID)
+}
+
+// webhookSource 返回外部推送的未过期币种，按评分从高到低排列
+type webhookSource struct {
+	id       string
+	name     string
+	maxCoins int
+}
+
+func (s *webhookSource) Name() string { return s.name }
+
+func (s *webhookSource) Fetch(ctx context.Context) ([]SourceCoin, error) {
+	webhookBuffers.Lock()
+	defer webhookBuffers.Unlock()
+
+	now := time.Now()
+	coins := []SourceCoin{}
+	for symbol, coin := range webhookBuffers.m[s.id] {
+		if now.Before(coin.expiresAt) {
+			coins = append(coins, SourceCoin{Symbol: symbol, Score: coin.score, FetchedAt: coin.pushedAt})
+		}
+	}
+	sort.Slice(coins, func(i, j int) bool {
+		if coins[i].Score != coins[j].Score {
+			return coins[i].Score > coins[j].Score
+		}
+		return coins[i].Symbol < coins[j].Symbol
+	})
+	if len(coins) > s.maxCoins {
+		coins = coins[:s.maxCoins]
+	}
+	return coins, nil
+}
+
+// coinPoolSource 内置的 AI500 评分币种池
+type coinPoolSource struct{ limit int }
+
+// NewCoinPoolSource AI500 评分最高的 limit 个币种
+func NewCoinPoolSource(limit int) SignalSource { return coinPoolSource{limit: limit} }
+
+func (s coinPoolSource) Name() string { return "ai500" }
+
+func (s coinPoolSource) Fetch(ctx context.Context) ([]SourceCoin, error) {
+	symbols, err := GetTopRatedCoins(s.limit)
+	if err != nil {
+		return nil, err
+	}
+	return symbolsToSourceCoins(symbols, fetchedAt(&dataFetchedAt.coinPool)), nil
+}
+
+// oiTopSource 内置的持仓量增长 Top 榜
+type oiTopSource struct{}
+
+// NewOITopSource 持仓量增长Top币种（未配置 OI Top API 时为空）
+func NewOITopSource() SignalSource { return oiTopSource{} }
+
+func (oiTopSource) Name() string { return "oi_top" }
+
+func (oiTopSource) Fetch(ctx context.Context) ([]SourceCoin, error) {
+	symbols, err := GetOITopSymbols()
+	if err != nil {
+		return nil, err
+	}
+	return symbolsToSourceCoins(symbols, fetchedAt(&dataFetchedAt.oiTop)), nil
+}
+
+func symbolsToSourceCoins(symbols []string, fetchedAt time.Time) []SourceCoin {
+	coins := make([]SourceCoin, 0, len(symbols))
+	for _, symbol := range symbols {
+		coins = append(coins, SourceCoin{Symbol: symbol, FetchedAt: fetchedAt})
+	}
+	return coins
+}
+
+// SourceResult 一个信号源的获取结果
+type SourceResult struct {
+	Source string
+	Coins  []SourceCoin
+	Err    error
+}
+
+// FetchAll 并发获取多个信号源，结果按 sources 的顺序返回，单个信号源失败不影响其他信号源
+func FetchAll(ctx context.Context, sources []SignalSource) []SourceResult {
+	results := make([]SourceResult, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source SignalSource) {
+			defer wg.Done()
+			coins, err := source.Fetch(ctx)
+			results[i] = SourceResult{Source: source.Name(), Coins: coins, Err: err}
+		}(i, source)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,126 @@
+package pool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func symbolsOf(coins []SourceCoin) []string {
+	var symbols []string
+	for _, c := range coins {
+		symbols = append(symbols, c.Symbol)
+	}
+	return symbols
+}
+
+func TestExtractJSONCoins(t *testing.T) {
+	body := []byte(`{"data":{"list":[
+		{"coin":"eth","rank":"70"},{"coin":"btc","rank":90},{"coin":"BTC","rank":10},
+		{"coin":"bad/sym","rank":99},{"rank":50},{"coin":"SOLUSDT","rank":80}
+	]}}`)
+	cfg := SourceConfig{Type: SourceHTTPJSON, URL: "https://x", ItemsPath: "data.list", SymbolField: "coin", ScoreField: "rank", MaxCoins: 2}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	coins, err := extractJSONCoins(body, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := symbolsOf(coins); !reflect.DeepEqual(got, []string{"BTCUSDT", "SOLUSDT"}) {
+		t.Errorf("应按评分取前2个并去重: %v", got)
+	}
+
+	plain := SourceConfig{Type: SourceHTTPJSON, URL: "https://x"}
+	plain.Validate()
+	coins, err = extractJSONCoins([]byte(`["doge","PEPE"]`), plain)
+	if err != nil || !reflect.DeepEqual(symbolsOf(coins), []string{"DOGEUSDT", "PEPEUSDT"}) {
+		t.Errorf("字符串列表解析错误: %v %v", coins, err)
+	}
+	if _, err := extractJSONCoins(body, plain); err == nil {
+		t.Error("items_path 指向对象时应报错")
+	}
+}
+
+func TestExtractFeedCoins(t *testing.T) {
+	rss := []byte(`<rss><channel>
+		<item><title>$SOL breaks out</title><description>SOLUSDT volume up, $sol again</description></item>
+		<item><title>ETHUSDT funding flips</title><description>costs $100 per contract</description></item>
+	</channel></rss>`)
+	coins, err := extractFeedCoins(rss, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coins) != 2 || coins[0] != (SourceCoin{Symbol: "SOLUSDT", Score: 3}) || coins[1].Symbol != "ETHUSDT" {
+		t.Errorf("RSS币种统计错误: %+v", coins)
+	}
+
+	atom := []byte(`<feed xmlns="http://www.w3.org/2005/Atom"><entry><title>Watching $ARB</title></entry></feed>`)
+	if coins, err := extractFeedCoins(atom, 10); err != nil || !reflect.DeepEqual(symbolsOf(coins), []string{"ARBUSDT"}) {
+		t.Errorf("Atom解析错误: %v %v", coins, err)
+	}
+}
+
+func TestHTTPJSONSourceFallback(t *testing.T) {
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`["btc"]`))
+	}))
+	defer srv.Close()
+
+	source, err := NewSignalSource("s1", "my-feed", SourceConfig{Type: SourceHTTPJSON, URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fail = true
+	coins, err := source.Fetch(context.Background())
+	if err != nil || !reflect.DeepEqual(symbolsOf(coins), []string{"BTCUSDT"}) {
+		t.Errorf("请求失败时应使用上一次的结果: %v %v", coins, err)
+	}
+}
+
+func TestWebhookSource(t *testing.T) {
+	defer DropWebhookSource("wh1")
+	source, err := NewSignalSource("wh1", "pushed", SourceConfig{Type: SourceWebhook, MaxCoins: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	PushWebhookCoins("wh1", []SourceCoin{{Symbol: "ETHUSDT", Score: 1}, {Symbol: "BTCUSDT", Score: 5}}, time.Hour)
+	if n := PushWebhookCoins("wh1", []SourceCoin{{Symbol: "SOLUSDT", Score: 3}, {Symbol: "XRPUSDT"}}, -time.Second); n != 2 {
+		t.Errorf("已过期的币种不应计入: %d", n)
+	}
+	coins, _ := source.Fetch(context.Background())
+	if !reflect.DeepEqual(symbolsOf(coins), []string{"BTCUSDT", "ETHUSDT"}) {
+		t.Errorf("webhook信号源应按评分返回未过期的币种: %v", coins)
+	}
+}
+
+func TestSourceValidation(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  SourceConfig
+	}{
+		{"ai500", SourceConfig{Type: SourceWebhook}},
+		{"Bad Name", SourceConfig{Type: SourceWebhook}},
+		{"feed", SourceConfig{Type: SourceRSS}},
+		{"feed", SourceConfig{Type: SourceWebhook, URL: "https://x"}},
+		{"feed", SourceConfig{Type: SourceRSS, URL: "https://x", ItemsPath: "data"}},
+		{"feed", SourceConfig{Type: SourceRSS, URL: "https://x", MaxCoins: 31}},
+		{"feed", SourceConfig{Type: "ftp", URL: "https://x"}},
+	}
+	for _, c := range cases {
+		if _, err := NewSignalSource("id", c.name, c.cfg); err == nil {
+			t.Errorf("%s %+v 应校验失败", c.name, c.cfg)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"nofx/config"
+)
+
+// runRoleCommand 命令行修改用户角色：nofx role --email=a@b.com --role=admin
+// 非管理员模式下第一个管理员只能通过这里指定
+func runRoleCommand(args []string) error {
+	fs := flag.NewFlagSet("role", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath(), "配置数据库路径（默认 NOFX_DB_PATH 或 config.db）")
+	email := fs.String("email", "", "用户邮箱（必填）")
+	role := fs.String("role", "", "角色: admin / user / viewer（必填）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || !config.ValidRole(*role) {
+		fs.Usage()
+		return fmt.Errorf("必须指定 --email 和有效的 --role")
+	}
+
+	database, err := openDatabase(*dbPath)
+	if err != nil {
+		return fmt.Errorf("初始化数据库失败: %w", err)
+	}
+	defer database.Close()
+
+	user, err := database.GetUserByEmail(*email)
+	if err != nil {
+		return fmt.Errorf("用户 %s 不存在: %w", *email, err)
+	}
+	if err := database.SetUserRole(user.ID, *role); err != nil {
+		return fmt.Errorf("修改角色失败: %w", err)
+	}
+
+	slog.Info("用户角色已修改", "user_id", user.ID, "email", user.Email, "from", user.Role, "to", *role)
+	return nil
+}
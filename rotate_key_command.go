@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"nofx/config"
+	"os"
+)
+
+// runRotateKeyCommand 用当前加密密钥重新加密数据库中的API密钥：nofx rotate-key [--db]
+// 执行前把旧密钥放入 NOFX_ENCRYPTION_OLD_KEYS，新密钥设为 NOFX_ENCRYPTION_KEY
+func runRotateKeyCommand(args []string) error {
+	fs := flag.NewFlagSet("rotate-key", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultDBPath(), "配置数据库路径（默认 NOFX_DB_PATH 或 config.db）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if os.Getenv(config.EnvEncryptionKey) == "" && os.Getenv(config.EnvEncryptionKeyFile) == "" {
+		return fmt.Errorf("请先设置 %s 或 %s", config.EnvEncryptionKey, config.EnvEncryptionKeyFile)
+	}
+
+	database, err := openDatabase(*dbPath)
+	if err != nil {
+		return fmt.Errorf("初始化数据库失败: %w", err)
+	}
+	defer database.Close()
+
+	n, err := database.RotateEncryptionKey()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已用新密钥重新加密 %d 条记录，确认服务正常后可移除 %s\n", n, config.EnvEncryptionOldKeys)
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"nofx/auth"
+	"nofx/config"
+	"nofx/trader"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 基础设施配置的环境变量，优先于 config.json 和系统配置（容器部署时不必挂载配置文件）
+const (
+	envAPIPort      = "NOFX_API_PORT"
+	envDBPath       = "NOFX_DB_PATH"
+	envDBDriver     = "NOFX_DB_DRIVER"
+	envDBDSN        = "NOFX_DB_DSN"
+	envJWTSecret    = "NOFX_JWT_SECRET"
+	envAdminMode    = "NOFX_ADMIN_MODE"
+	envSMTPHost     = "NOFX_SMTP_HOST"
+	envSMTPPort     = "NOFX_SMTP_PORT"
+	envSMTPUsername = "NOFX_SMTP_USERNAME"
+	envSMTPPassword = "NOFX_SMTP_PASSWORD"
+	envSMTPFrom     = "NOFX_SMTP_FROM"
+	envProxyURL     = "NOFX_PROXY_URL"
+)
+
+const (
+	defaultAPIPort = 8080
+	// insecureJWTSecret 未配置JWT密钥时使用的默认值，任何人都可以用它伪造登录态
+	insecureJWTSecret = "your-jwt-secret-key-change-in-production-make-it-long-and-random"
+	minJWTSecretLen   = 32
+)
+
+// RuntimeConfig 启动时需要确定的基础设施配置
+// 来源优先级：环境变量 > config.json（启动时已同步到系统配置）> 默认值
+type RuntimeConfig struct {
+	APIPort   int
+	JWTSecret string
+	AdminMode bool
+	SMTP      *auth.SMTPConfig // 未配置时为nil，邮件内容只写入日志
+	ProxyURL  string           // 交易所API的默认代理，交易员未单独配置代理时使用
+}
+
+// envOr 环境变量的值，未设置时返回 fallback
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// defaultDBPath SQLite配置数据库的默认路径（NOFX_DB_PATH，默认 config.db）
+func defaultDBPath() string {
+	return envOr(envDBPath, "config.db")
+}
+
+// databaseOptions 数据库连接配置：NOFX_DB_DRIVER/NOFX_DB_DSN 优先，其次 config.json 的 database，否则使用 dbPath 处的SQLite文件
+func databaseOptions(dbPath string) (config.DatabaseOptions, error) {
+	opts := config.DatabaseOptions{Driver: config.DriverSQLite, DSN: dbPath}
+	if data, err := os.ReadFile("config.json"); err == nil {
+		var configFile ConfigFile
+		if err := json.Unmarshal(data, &configFile); err != nil {
+			return opts, fmt.Errorf("解析config.json失败: %w", err)
+		}
+		if fileOpts := configFile.Database; fileOpts != nil && fileOpts.Driver != "" {
+			opts = *fileOpts
+		}
+	}
+
+	source := "config.json database.driver"
+	if driver := os.Getenv(envDBDriver); driver != "" {
+		opts.Driver, opts.DSN, source = driver, "", envDBDriver
+	}
+	if dsn := os.Getenv(envDBDSN); dsn != "" {
+		opts.DSN = dsn
+	}
+
+	switch opts.Driver {
+	case config.DriverSQLite, "sqlite":
+		if opts.DSN == "" {
+			opts.DSN = dbPath
+		}
+	case config.DriverPostgres, "postgresql":
+		if opts.DSN == "" {
+			return opts, fmt.Errorf("使用PostgreSQL时必须设置 %s 或 config.json 的 database.dsn", envDBDSN)
+		}
+	default:
+		return opts, fmt.Errorf("%s 不支持的数据库驱动 %q（可选 sqlite3、postgres）", source, opts.Driver)
+	}
+	return opts, nil
+}
+
+// LoadRuntimeConfig 读取并校验基础设施配置，所有无效的配置项一起返回，便于一次改完
+func LoadRuntimeConfig(database *config.Database) (*RuntimeConfig, error) {
+	lookup := func(env, key string) (value, source string) {
+		if value := os.Getenv(env); value != "" {
+			return value, env
+		}
+		value, _ = database.GetSystemConfig(key)
+		return value, "配置项 " + key
+	}
+
+	cfg := &RuntimeConfig{APIPort: defaultAPIPort, AdminMode: true}
+	var errs []error
+
+	// config.json 未写端口时同步的是 0，按未设置处理
+	if value, source := lookup(envAPIPort, "api_server_port"); value != "" && value != "0" {
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("%s 不是有效的端口（1-65535）: %q", source, value))
+		} else {
+			cfg.APIPort = port
+		}
+	}
+
+	if value, source := lookup(envAdminMode, "admin_mode"); value != "" {
+		adminMode, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s 必须是 true 或 false: %q", source, value))
+		} else {
+			cfg.AdminMode = adminMode
+		}
+	}
+
+	cfg.JWTSecret, _ = lookup(envJWTSecret, "jwt_secret")
+	switch {
+	case cfg.JWTSecret == "":
+		cfg.JWTSecret = insecureJWTSecret
+		slog.Warn("使用默认JWT密钥，建议在生产环境中配置", "env", envJWTSecret)
+	case len(cfg.JWTSecret) < minJWTSecretLen:
+		slog.Warn("JWT密钥过短，建议使用随机生成的长密钥", "min_length", minJWTSecretLen)
+	}
+
+	smtp, err := loadSMTPConfig(lookup)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.SMTP = smtp
+
+	if value, source := lookup(envProxyURL, "proxy_url"); value != "" {
+		if err := trader.ValidateProxyURL(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+		} else {
+			cfg.ProxyURL = value
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// loadSMTPConfig 设置了 NOFX_SMTP_HOST 时从 NOFX_SMTP_* 读取，否则解析系统配置 smtp_config
+func loadSMTPConfig(lookup func(env, key string) (string, string)) (*auth.SMTPConfig, error) {
+	var smtp auth.SMTPConfig
+	source := envSMTPHost
+	if host := os.Getenv(envSMTPHost); host != "" {
+		smtp = auth.SMTPConfig{
+			Host:     host,
+			Username: os.Getenv(envSMTPUsername),
+			Password: os.Getenv(envSMTPPassword),
+			From:     os.Getenv(envSMTPFrom),
+		}
+		if value := os.Getenv(envSMTPPort); value != "" {
+			port, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s 不是有效的端口: %q", envSMTPPort, value)
+			}
+			smtp.Port = port
+		}
+	} else {
+		value, _ := lookup("", "smtp_config")
+		if value == "" {
+			return nil, nil
+		}
+		source = "配置项 smtp_config"
+		if err := json.Unmarshal([]byte(value), &smtp); err != nil {
+			return nil, fmt.Errorf("%s 不是有效的JSON: %w", source, err)
+		}
+		if smtp.Host == "" {
+			return nil, nil
+		}
+	}
+
+	if smtp.Port < 0 || smtp.Port > 65535 {
+		return nil, fmt.Errorf("%s: SMTP端口必须在1-65535之间: %d", source, smtp.Port)
+	}
+	if smtp.From == "" && !strings.Contains(smtp.Username, "@") {
+		return nil, fmt.Errorf("%s: 未设置发件人地址（from）", source)
+	}
+	return &smtp, nil
+}
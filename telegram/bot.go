@@ -0,0 +1,378 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"nofx/config"
+	"nofx/manager"
+	"nofx/trader"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LinkTokenTTL 绑定口令有效期
+const LinkTokenTTL = 10 * time.Minute
+
+// maxMessageLength Telegram 单条消息长度上限
+const maxMessageLength = 4096
+
+// GenerateLinkToken 生成绑定口令（可直接放进 t.me/<bot>?start= 深链接）
+func GenerateLinkToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashLinkToken 数据库只保存口令哈希
+func HashLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+// active 当前运行的机器人（未配置token时为空）
+var active atomic.Pointer[Bot]
+
+// BotUsername 当前机器人的用户名，未启用时为空
+func BotUsername() string {
+	if b := active.Load(); b != nil {
+		return b.username
+	}
+	return ""
+}
+
+// Bot 推送交易告警并响应用户命令
+type Bot struct {
+	client        *Client
+	database      *config.Database
+	traderManager *manager.TraderManager
+	username      string
+}
+
+// NewBot 创建机器人并校验token
+func NewBot(token string, database *config.Database, traderManager *manager.TraderManager) (*Bot, error) {
+	client := NewClient(token)
+	me, err := client.GetMe()
+	if err != nil {
+		return nil, fmt.Errorf("校验Telegram机器人token失败: %w", err)
+	}
+	return &Bot{client: client, database: database, traderManager: traderManager, username: me.Username}, nil
+}
+
+// Start 开始接收命令和推送告警（阻塞，调用方使用 go 启动）
+func (b *Bot) Start() {
+	active.Store(b)
+	slog.Info("Telegram机器人已启动", "username", b.username)
+	go b.pushAlerts()
+	b.pollCommands()
+}
+
+// pollCommands 长轮询接收消息，出错时退避重试
+func (b *Bot) pollCommands() {
+	var offset int64
+	for {
+		updates, err := b.client.GetUpdates(offset, 30*time.Second)
+		if err != nil {
+			slog.Warn("Telegram获取消息失败", "error", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message != nil && u.Message.Text != "" {
+				b.handleUpdate(u.Message)
+			}
+		}
+	}
+}
+
+// handleUpdate 处理一条消息并回复，处理过程中的panic只记录日志，不中断轮询
+func (b *Bot) handleUpdate(msg *Message) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Telegram处理消息异常", "chat_id", msg.Chat.ID, "error", r, "stack", string(debug.Stack()))
+		}
+	}()
+	b.reply(msg.Chat.ID, b.handleMessage(msg))
+}
+
+// pushAlerts 订阅交易事件，推送给交易员所有者绑定的聊天
+func (b *Bot) pushAlerts() {
+	events, unsubscribe := trader.SubscribeEvents(256)
+	defer unsubscribe()
+
+	for event := range events {
+		if !trader.IsTradeEvent(event.Type) {
+			continue
+		}
+		owner, err := b.database.GetTraderOwner(event.TraderID)
+		if err != nil {
+			continue
+		}
+		link, err := b.database.GetTelegramLink(owner)
+		if err != nil {
+			continue
+		}
+		name := event.TraderID
+		if at, err := b.traderManager.GetTrader(event.TraderID); err == nil {
+			name = at.GetName()
+		}
+		go b.reply(link.ChatID, formatEvent(name, event))
+	}
+}
+
+// reply 发送消息，超长时截断
+func (b *Bot) reply(chatID int64, text string) {
+	if text == "" {
+		return
+	}
+	if r := []rune(text); len(r) > maxMessageLength {
+		text = string(r[:maxMessageLength-1]) + "…"
+	}
+	if err := b.client.SendMessage(chatID, text); err != nil {
+		slog.Warn("Telegram发送消息失败", "chat_id", chatID, "error", err)
+	}
+}
+
+// handleMessage 处理一条消息，返回回复内容
+func (b *Bot) handleMessage(msg *Message) string {
+	fields := strings.Fields(msg.Text)
+	// 只有空白字符（如全角空格）的消息不回复
+	if len(fields) == 0 {
+		return ""
+	}
+	// 群聊中命令可能带 @botname 后缀
+	command := strings.ToLower(strings.SplitN(fields[0], "@", 2)[0])
+	args := fields[1:]
+
+	switch command {
+	case "/start", "/link":
+		if len(args) == 0 {
+			return helpText
+		}
+		return b.handleLink(msg, args[0])
+	case "/help":
+		return helpText
+	}
+
+	userID, err := b.database.GetUserByTelegramChat(msg.Chat.ID)
+	if err != nil {
+		return "此聊天尚未绑定NOFX账户。请在网页端「Telegram通知」中生成绑定口令，然后发送 /link <口令>"
+	}
+	user, err := b.database.GetUserByID(userID)
+	if err != nil || user.Disabled {
+		return "账户不可用，请联系管理员"
+	}
+
+	switch command {
+	case "/status":
+		return b.handleStatus(userID)
+	case "/positions":
+		return b.handlePositions(userID, args)
+	case "/pause", "/flatten":
+		if user.Role == config.RoleViewer {
+			return "观察者账户不能操作交易员"
+		}
+		if command == "/pause" {
+			return b.handlePause(userID, args)
+		}
+		return b.handleFlatten(userID, args)
+	case "/unlink":
+		if err := b.database.UnlinkTelegram(userID); err != nil {
+			return fmt.Sprintf("解绑失败: %v", err)
+		}
+		return "已解除绑定，不会再收到通知"
+	}
+	return "未知命令，发送 /help 查看可用命令"
+}
+
+const helpText = `NOFX 交易机器人
+/link <口令> 绑定账户（口令在网页端生成，10分钟内有效）
+/status 所有交易员的运行状态和净值
+/positions [交易员] 当前持仓
+/pause <交易员> 停止交易员
+/flatten <交易员> 停止交易员并市价平掉所有持仓
+/unlink 解除绑定
+
+只有一个交易员时可以省略交易员名称。`
+
+// handleLink 用网页端生成的口令绑定账户
+func (b *Bot) handleLink(msg *Message, token string) string {
+	userID, err := b.database.ConsumeTelegramLinkToken(HashLinkToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "口令无效或已过期，请在网页端重新生成"
+		}
+		return fmt.Sprintf("绑定失败: %v", err)
+	}
+	username := ""
+	if msg.From != nil {
+		username = msg.From.Username
+	}
+	if err := b.database.LinkTelegramChat(userID, msg.Chat.ID, username); err != nil {
+		return fmt.Sprintf("绑定失败: %v", err)
+	}
+	slog.Info("用户绑定了Telegram聊天", "user_id", userID)
+	return "绑定成功！交易告警会推送到这里，发送 /help 查看可用命令"
+}
+
+// findTrader 按名称或ID前缀查找用户的交易员；只有一个交易员时可省略
+func (b *Bot) findTrader(userID string, args []string) (*config.TraderRecord, error) {
+	traders, err := b.database.GetTraders(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易员列表失败: %w", err)
+	}
+	if len(traders) == 0 {
+		return nil, fmt.Errorf("还没有交易员")
+	}
+	if len(args) == 0 {
+		if len(traders) == 1 {
+			return traders[0], nil
+		}
+		return nil, fmt.Errorf("有多个交易员，请指定名称: %s", traderNames(traders))
+	}
+
+	query := strings.Join(args, " ")
+	var matches []*config.TraderRecord
+	for _, t := range traders {
+		if strings.EqualFold(t.Name, query) || t.ID == query {
+			return t, nil
+		}
+		if strings.HasPrefix(t.ID, query) || strings.HasPrefix(strings.ToLower(t.Name), strings.ToLower(query)) {
+			matches = append(matches, t)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("找不到交易员 %q，可选: %s", query, traderNames(traders))
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("%q 匹配到多个交易员: %s", query, traderNames(matches))
+}
+
+func traderNames(traders []*config.TraderRecord) string {
+	names := make([]string, 0, len(traders))
+	for _, t := range traders {
+		names = append(names, t.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// handleStatus 所有交易员的运行状态和净值
+func (b *Bot) handleStatus(userID string) string {
+	traders, err := b.database.GetTraders(userID)
+	if err != nil {
+		return fmt.Sprintf("获取交易员列表失败: %v", err)
+	}
+	if len(traders) == 0 {
+		return "还没有交易员"
+	}
+
+	var sb strings.Builder
+	for _, t := range traders {
+		at, err := b.traderManager.GetTrader(t.ID)
+		if err != nil {
+			fmt.Fprintf(&sb, "⚪ %s：未加载\n", t.Name)
+			continue
+		}
+		state := "⏹ 已停止"
+		if running, _ := at.GetStatus()["is_running"].(bool); running {
+			state = "▶️ 运行中"
+		}
+		fmt.Fprintf(&sb, "%s %s\n", state, t.Name)
+		if account, err := at.GetAccountInfo(); err == nil {
+			fmt.Fprintf(&sb, "   净值 %.2f USDT  盈亏 %+.2f (%+.2f%%)  持仓 %v\n",
+				account["total_equity"], account["total_pnl"], account["total_pnl_pct"], account["position_count"])
+		}
+		if trip := at.GetCircuitBreakerTrip(); trip != nil {
+			fmt.Fprintf(&sb, "   🚨 净值熔断中（回撤 %.2f%%）\n", trip.DropPct)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// handlePositions 交易员的当前持仓
+func (b *Bot) handlePositions(userID string, args []string) string {
+	record, err := b.findTrader(userID, args)
+	if err != nil {
+		return err.Error()
+	}
+	at, err := b.traderManager.GetTrader(record.ID)
+	if err != nil {
+		return "交易员未加载"
+	}
+	positions, err := at.GetPositions()
+	if err != nil {
+		return fmt.Sprintf("获取持仓失败: %v", err)
+	}
+	if len(positions) == 0 {
+		return fmt.Sprintf("%s 当前没有持仓", record.Name)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s 的持仓：\n", record.Name)
+	for _, p := range positions {
+		fmt.Fprintf(&sb, "%s %s %vx  数量 %v\n   开仓 %v  标记 %v  浮盈 %+.2f (%+.2f%%)\n",
+			p["symbol"], strings.ToUpper(fmt.Sprint(p["side"])), p["leverage"], p["quantity"],
+			p["entry_price"], p["mark_price"], p["unrealized_pnl"], p["unrealized_pnl_pct"])
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// stopTrader 停止交易员并更新数据库状态，返回停止前是否在运行
+func (b *Bot) stopTrader(userID string, at *trader.AutoTrader) bool {
+	running, _ := at.GetStatus()["is_running"].(bool)
+	if running {
+		at.Stop()
+		if err := b.database.UpdateTraderStatus(userID, at.GetID(), false); err != nil {
+			at.Logger().Warn("更新交易员状态失败", "error", err)
+		}
+	}
+	return running
+}
+
+// handlePause 停止交易员（持仓保留，止损止盈单仍然有效）
+func (b *Bot) handlePause(userID string, args []string) string {
+	record, err := b.findTrader(userID, args)
+	if err != nil {
+		return err.Error()
+	}
+	at, err := b.traderManager.GetTrader(record.ID)
+	if err != nil {
+		return "交易员未加载"
+	}
+	if !b.stopTrader(userID, at) {
+		return fmt.Sprintf("%s 已经是停止状态", record.Name)
+	}
+	at.Logger().Info("交易员已通过Telegram停止")
+	return fmt.Sprintf("⏹ %s 已停止，现有持仓和止损止盈单保留。在网页端可重新启动", record.Name)
+}
+
+// handleFlatten 停止交易员并平掉所有持仓
+func (b *Bot) handleFlatten(userID string, args []string) string {
+	record, err := b.findTrader(userID, args)
+	if err != nil {
+		return err.Error()
+	}
+	at, err := b.traderManager.GetTrader(record.ID)
+	if err != nil {
+		return "交易员未加载"
+	}
+	b.stopTrader(userID, at)
+
+	closed, err := at.FlattenPositions()
+	at.Logger().Info("交易员已通过Telegram一键平仓")
+	if err != nil {
+		return fmt.Sprintf("⚠️ %s 已停止，平掉 %d 个仓位，部分失败: %v", record.Name, closed, err)
+	}
+	return fmt.Sprintf("🧹 %s 已停止，平掉 %d 个仓位", record.Name, closed)
+}
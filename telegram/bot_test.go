@@ -0,0 +1,123 @@
+package telegram
+
+import (
+	"nofx/config"
+	"nofx/manager"
+	"nofx/trader"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestBot(t *testing.T) *Bot {
+	t.Helper()
+	database, err := config.NewDatabase(filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	for _, u := range []*config.User{
+		{ID: "alice", Email: "alice@test.com", OTPVerified: true},
+		{ID: "victor", Email: "victor@test.com", Role: config.RoleViewer, OTPVerified: true},
+	} {
+		if err := database.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &Bot{database: database, traderManager: manager.NewTraderManager()}
+}
+
+func message(chatID int64, text string) *Message {
+	return &Message{Chat: Chat{ID: chatID}, From: &User{Username: "tg_user"}, Text: text}
+}
+
+func TestLinkTokenFlow(t *testing.T) {
+	b := newTestBot(t)
+
+	if reply := b.handleMessage(message(42, "/status")); !strings.Contains(reply, "尚未绑定") {
+		t.Fatalf("未绑定时应提示绑定: %s", reply)
+	}
+
+	token, err := GenerateLinkToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.database.CreateTelegramLinkToken("alice", HashLinkToken(token), time.Now().Add(LinkTokenTTL)); err != nil {
+		t.Fatal(err)
+	}
+	if reply := b.handleMessage(message(42, "/start "+token)); !strings.Contains(reply, "绑定成功") {
+		t.Fatalf("应绑定成功: %s", reply)
+	}
+	if reply := b.handleMessage(message(43, "/link "+token)); !strings.Contains(reply, "无效或已过期") {
+		t.Errorf("口令只能使用一次: %s", reply)
+	}
+
+	link, err := b.database.GetTelegramLink("alice")
+	if err != nil || link.ChatID != 42 || link.Username != "tg_user" {
+		t.Fatalf("绑定记录错误: %+v %v", link, err)
+	}
+	if reply := b.handleMessage(message(42, "/status@nofx_bot")); reply != "还没有交易员" {
+		t.Errorf("绑定后应能查询状态: %s", reply)
+	}
+
+	if reply := b.handleMessage(message(42, "/unlink")); !strings.Contains(reply, "已解除绑定") {
+		t.Errorf("应解除绑定: %s", reply)
+	}
+	if _, err := b.database.GetUserByTelegramChat(42); err == nil {
+		t.Error("解绑后聊天不应再关联用户")
+	}
+}
+
+func TestExpiredLinkToken(t *testing.T) {
+	b := newTestBot(t)
+	if err := b.database.CreateTelegramLinkToken("alice", HashLinkToken("old"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if reply := b.handleMessage(message(42, "/link old")); !strings.Contains(reply, "无效或已过期") {
+		t.Errorf("过期口令应被拒绝: %s", reply)
+	}
+}
+
+func TestWhitespaceOnlyMessage(t *testing.T) {
+	b := newTestBot(t)
+	for _, text := range []string{"\u3000", "\u00a0", " \t\n"} {
+		if reply := b.handleMessage(message(42, text)); reply != "" {
+			t.Errorf("只有空白字符的消息 %q 不应回复: %s", text, reply)
+		}
+		b.handleUpdate(message(42, text))
+	}
+	// 发送失败等处理过程中的panic不应中断轮询（测试中的机器人没有client）
+	b.handleUpdate(message(42, "/help"))
+}
+
+func TestViewerCannotPauseOrFlatten(t *testing.T) {
+	b := newTestBot(t)
+	if err := b.database.LinkTelegramChat("victor", 7, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range []string{"/pause", "/flatten btc"} {
+		if reply := b.handleMessage(message(7, cmd)); !strings.Contains(reply, "观察者") {
+			t.Errorf("%s 应拒绝观察者: %s", cmd, reply)
+		}
+	}
+}
+
+func TestFormatEvent(t *testing.T) {
+	pnl := -12.5
+	closed := trader.PositionClosedEvent{Symbol: "BTCUSDT", Side: "long", Price: 60000, ExitReason: "stop_loss", PnL: &pnl}
+
+	msg := formatEvent("btc-bot", trader.Event{Type: trader.EventPositionClosed, Data: closed})
+	if !strings.Contains(msg, "[btc-bot]") || !strings.Contains(msg, "止损") || !strings.Contains(msg, "-12.50") {
+		t.Errorf("平仓消息格式错误: %s", msg)
+	}
+	msg = formatEvent("btc-bot", trader.Event{Type: trader.EventStopHit, Data: closed})
+	if !strings.HasPrefix(msg, "🛑") {
+		t.Errorf("止损消息格式错误: %s", msg)
+	}
+	msg = formatEvent("btc-bot", trader.Event{Type: trader.EventTraderError, Data: map[string]interface{}{"error": "boom"}})
+	if !strings.Contains(msg, "boom") {
+		t.Errorf("异常消息应包含错误原因: %s", msg)
+	}
+}
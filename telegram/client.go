@@ -0,0 +1,112 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// apiBaseURL Telegram Bot API 地址
+const apiBaseURL = "https://api.telegram.org"
+
+// Client Telegram Bot API 的最小封装（只用到长轮询和发消息）
+type Client struct {
+	token   string
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient 创建客户端
+func NewClient(token string) *Client {
+	return &Client{
+		token:   token,
+		baseURL: apiBaseURL,
+		http:    &http.Client{Timeout: 60 * time.Second}, // 需大于长轮询的等待时间
+	}
+}
+
+// User 机器人或发消息的用户
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// Chat 聊天
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+// Message 收到的消息
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+// Update 长轮询返回的更新
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// call 调用Bot API方法，结果解析到 result
+func (c *Client) call(method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Post(fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method), "application/json", bytes.NewReader(body))
+	if err != nil {
+		// 错误信息中的URL包含token，不能原样记录
+		return fmt.Errorf("请求Telegram %s 失败", method)
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("解析Telegram %s 响应失败: %w", method, err)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("Telegram %s 失败: %s", method, envelope.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(envelope.Result, result)
+	}
+	return nil
+}
+
+// GetMe 获取机器人自身信息
+func (c *Client) GetMe() (*User, error) {
+	var me User
+	if err := c.call("getMe", map[string]interface{}{}, &me); err != nil {
+		return nil, err
+	}
+	return &me, nil
+}
+
+// GetUpdates 长轮询获取 offset 之后的消息
+func (c *Client) GetUpdates(offset int64, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := c.call("getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+// SendMessage 发送纯文本消息
+func (c *Client) SendMessage(chatID int64, text string) error {
+	return c.call("sendMessage", map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}, nil)
+}
@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"fmt"
+	"nofx/logger"
+	"nofx/trader"
+	"strings"
+)
+
+// exitReasonLabels 平仓原因的中文说明
+var exitReasonLabels = map[string]string{
+	logger.ExitReasonAI:          "AI平仓",
+	logger.ExitReasonStopLoss:    "止损",
+	logger.ExitReasonTakeProfit:  "止盈",
+	logger.ExitReasonLiquidation: "强平",
+	logger.ExitReasonFunding:     "资金费超预算平仓",
+	logger.ExitReasonUnknown:     "原因未知",
+}
+
+// formatEvent 把交易事件格式化为推送消息
+func formatEvent(traderName string, event trader.Event) string {
+	switch data := event.Data.(type) {
+	case trader.PositionOpenedEvent:
+		msg := fmt.Sprintf("🟢 [%s] 开仓 %s %s %dx\n数量 %.4f  价格 %.4f",
+			traderName, data.Symbol, strings.ToUpper(data.Side), data.Leverage, data.Quantity, data.Price)
+		if data.StopLoss > 0 || data.TakeProfit > 0 {
+			msg += fmt.Sprintf("\n止损 %.4f  止盈 %.4f", data.StopLoss, data.TakeProfit)
+		}
+		return msg
+
+	case trader.PositionClosedEvent:
+		if event.Type == trader.EventStopHit {
+			return fmt.Sprintf("🛑 [%s] %s %s 触发%s，价格 %.4f",
+				traderName, data.Symbol, strings.ToUpper(data.Side), exitReason(data.ExitReason), data.Price)
+		}
+		msg := fmt.Sprintf("🔴 [%s] 平仓 %s %s（%s）价格 %.4f",
+			traderName, data.Symbol, strings.ToUpper(data.Side), exitReason(data.ExitReason), data.Price)
+		if data.PnL != nil {
+			msg += fmt.Sprintf("\n盈亏 %+.2f USDT", *data.PnL)
+		}
+		if data.RMultiple != nil {
+			msg += fmt.Sprintf("  %+.2fR", *data.RMultiple)
+		}
+		return msg
+
+	case trader.CircuitBreakerTrip:
+		return fmt.Sprintf("🚨 [%s] 净值回撤熔断：%s 内从 %.2f 跌到 %.2f（-%.2f%%），已暂停开仓",
+			traderName, data.Window, data.PeakEquity, data.Equity, data.DropPct)
+	}
+
+	if event.Type == trader.EventTraderError {
+		if data, ok := event.Data.(map[string]interface{}); ok {
+			return fmt.Sprintf("❌ [%s] 交易员异常停止: %v", traderName, data["error"])
+		}
+		return fmt.Sprintf("❌ [%s] 交易员异常停止", traderName)
+	}
+	return fmt.Sprintf("ℹ️ [%s] %s", traderName, event.Type)
+}
+
+func exitReason(reason string) string {
+	if label, ok := exitReasonLabels[reason]; ok {
+		return label
+	}
+	return reason
+}
@@ -0,0 +1,73 @@
+// Package tracing OpenTelemetry链路追踪：按OTLP/HTTP导出交易周期各阶段的span
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 本服务所有span使用的instrumentation名称
+const tracerName = "nofx"
+
+// Setup 配置OTLP导出；endpoint 和标准环境变量 OTEL_EXPORTER_OTLP_(TRACES_)ENDPOINT 都为空时不启用（span为空操作）
+// 返回的 shutdown 会在退出前刷新尚未导出的span
+func Setup(ctx context.Context, endpoint string) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	if endpoint == "" && os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return noop, nil
+	}
+
+	var opts []otlptracehttp.Option
+	if endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return noop, fmt.Errorf("创建OTLP导出器失败: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME 等环境变量优先
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "nofx")),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return noop, fmt.Errorf("创建追踪资源失败: %w", err)
+	}
+
+	// 采样率可用 OTEL_TRACES_SAMPLER / OTEL_TRACES_SAMPLER_ARG 调整
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
+
+// Start 开始一个span；未启用追踪时为空操作
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束span，err 非nil时标记为失败
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetupDisabledWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	shutdown, err := Setup(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("未启用时 shutdown 应为空操作: %v", err)
+	}
+}
+
+func TestSpansNestAndRecordErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	ctx, cycle := Start(context.Background(), "trader.cycle", attribute.Int("cycle", 1))
+	_, call := Start(ctx, "decision.ai_call")
+	End(call, errors.New("timeout"))
+	End(cycle, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("应记录2个span: %d", len(spans))
+	}
+	child, root := spans[0], spans[1]
+	if child.Parent().SpanID() != root.SpanContext().SpanID() || child.SpanContext().TraceID() != root.SpanContext().TraceID() {
+		t.Error("子span应挂在周期span下")
+	}
+	if child.Status().Code != codes.Error || len(child.Events()) == 0 {
+		t.Errorf("失败的span应标记错误: %+v", child.Status())
+	}
+	if root.Status().Code == codes.Error {
+		t.Error("成功的span不应标记错误")
+	}
+}
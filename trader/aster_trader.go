@@ -8,7 +8,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"math"
 	"math/big"
 	"net/http"
@@ -36,6 +35,8 @@ type AsterTrader struct {
 	// 缓存交易对精度信息
 	symbolPrecision map[string]SymbolPrecision
 	mu              sync.RWMutex
+
+	exchangeLog
 }
 
 // SymbolPrecision 交易对精度信息
@@ -524,7 +525,7 @@ func (t *AsterTrader) GetPositions() ([]map[string]interface{}, error) {
 func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
+		t.log().Warn("取消挂单失败，继续开仓", "symbol", symbol, "error", err)
 	}
 
 	// 先设置杠杆
@@ -561,8 +562,9 @@ func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (m
 	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
-	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
-		limitPrice, priceStr, prec.PricePrecision, quantity, qtyStr, prec.QuantityPrecision)
+	t.log().Debug("精度处理", "symbol", symbol,
+		"price", limitPrice, "price_str", priceStr, "price_precision", prec.PricePrecision,
+		"quantity", quantity, "quantity_str", qtyStr, "quantity_precision", prec.QuantityPrecision)
 
 	params := map[string]interface{}{
 		"symbol":       symbol,
@@ -591,7 +593,7 @@ func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (m
 func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
+		t.log().Warn("取消挂单失败，继续开仓", "symbol", symbol, "error", err)
 	}
 
 	// 先设置杠杆
@@ -628,8 +630,9 @@ func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (
 	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
-	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
-		limitPrice, priceStr, prec.PricePrecision, quantity, qtyStr, prec.QuantityPrecision)
+	t.log().Debug("精度处理", "symbol", symbol,
+		"price", limitPrice, "price_str", priceStr, "price_precision", prec.PricePrecision,
+		"quantity", quantity, "quantity_str", qtyStr, "quantity_precision", prec.QuantityPrecision)
 
 	params := map[string]interface{}{
 		"symbol":       symbol,
@@ -673,7 +676,7 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 		if quantity == 0 {
 			return nil, fmt.Errorf("没有找到 %s 的多仓", symbol)
 		}
-		log.Printf("  📊 获取到多仓数量: %.8f", quantity)
+		t.log().Debug("获取到多仓数量", "symbol", symbol, "quantity", quantity)
 	}
 
 	price, err := t.GetMarketPrice(symbol)
@@ -703,8 +706,9 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
-	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
-		limitPrice, priceStr, prec.PricePrecision, quantity, qtyStr, prec.QuantityPrecision)
+	t.log().Debug("精度处理", "symbol", symbol,
+		"price", limitPrice, "price_str", priceStr, "price_precision", prec.PricePrecision,
+		"quantity", quantity, "quantity_str", qtyStr, "quantity_precision", prec.QuantityPrecision)
 
 	params := map[string]interface{}{
 		"symbol":       symbol,
@@ -726,11 +730,11 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 		return nil, err
 	}
 
-	log.Printf("✓ 平多仓成功: %s 数量: %s", symbol, qtyStr)
+	t.log().Info("平多仓成功", "symbol", symbol, "quantity", qtyStr)
 
 	// 平仓后取消该币种的所有挂单(止损止盈单)
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		t.log().Warn("取消挂单失败", "symbol", symbol, "error", err)
 	}
 
 	return result, nil
@@ -756,7 +760,7 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 		if quantity == 0 {
 			return nil, fmt.Errorf("没有找到 %s 的空仓", symbol)
 		}
-		log.Printf("  📊 获取到空仓数量: %.8f", quantity)
+		t.log().Debug("获取到空仓数量", "symbol", symbol, "quantity", quantity)
 	}
 
 	price, err := t.GetMarketPrice(symbol)
@@ -786,8 +790,9 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
-	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
-		limitPrice, priceStr, prec.PricePrecision, quantity, qtyStr, prec.QuantityPrecision)
+	t.log().Debug("精度处理", "symbol", symbol,
+		"price", limitPrice, "price_str", priceStr, "price_precision", prec.PricePrecision,
+		"quantity", quantity, "quantity_str", qtyStr, "quantity_precision", prec.QuantityPrecision)
 
 	params := map[string]interface{}{
 		"symbol":       symbol,
@@ -809,11 +814,11 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 		return nil, err
 	}
 
-	log.Printf("✓ 平空仓成功: %s 数量: %s", symbol, qtyStr)
+	t.log().Info("平空仓成功", "symbol", symbol, "quantity", qtyStr)
 
 	// 平仓后取消该币种的所有挂单(止损止盈单)
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		t.log().Warn("取消挂单失败", "symbol", symbol, "error", err)
 	}
 
 	return result, nil
@@ -839,15 +844,15 @@ func (t *AsterTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
 		// 如果错误表示无需更改，忽略错误
 		if strings.Contains(err.Error(), "No need to change") ||
 			strings.Contains(err.Error(), "Margin type cannot be changed") {
-			log.Printf("  ✓ %s 仓位模式已是 %s 或有持仓无法更改", symbol, marginType)
+			t.log().Debug("仓位模式已是目标模式或有持仓无法更改", "symbol", symbol, "margin_mode", marginType)
 			return nil
 		}
-		log.Printf("  ⚠️ 设置仓位模式失败: %v", err)
+		t.log().Warn("设置仓位模式失败", "symbol", symbol, "error", err)
 		// 不返回错误，让交易继续
 		return nil
 	}
 
-	log.Printf("  ✓ %s 仓位模式已设置为 %s", symbol, marginType)
+	t.log().Info("仓位模式已设置", "symbol", symbol, "margin_mode", marginType)
 	return nil
 }
 
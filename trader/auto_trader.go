@@ -1,27 +1,40 @@
 package trader
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"nofx/decision"
+	"nofx/health"
 	"nofx/logger"
 	"nofx/market"
 	"nofx/mcp"
 	"nofx/pool"
+	"nofx/tracing"
+	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// ExchangeHealth 各交易所API最近一次查询账户的结果（每个周期开始时），供健康检查使用
+var ExchangeHealth = health.NewTracker()
+
 // AutoTraderConfig 自动交易配置（简化版 - AI全权决策）
 type AutoTraderConfig struct {
 	// Trader标识
 	ID      string // Trader唯一标识（用于日志目录等）
 	Name    string // Trader显示名称
+	UserID  string // 所属用户（写入日志上下文）
 	AIModel string // AI模型: "qwen" 或 "deepseek"
 
 	// 交易平台选择
-	Exchange string // "binance", "hyperliquid" 或 "aster"
+	Exchange string // "binance", "hyperliquid", "aster" 或 "paper"（模拟交易）
 
 	// 币安API配置
 	BinanceAPIKey    string
@@ -74,6 +87,13 @@ type AutoTraderConfig struct {
 
 	// 系统提示词模板
 	SystemPromptTemplate string // 系统提示词模板名称（如 "default", "aggressive"）
+
+	// 候选币种池
+	MaxCandidates       int           // 每个周期发给AI的候选币种上限（0 使用默认值）
+	PoolRefreshInterval time.Duration // 候选币种池的刷新间隔（0 每个周期刷新）
+
+	// 最低流动性要求（持仓价值/24h成交额），零值为持仓价值不低于15M USDT
+	Liquidity decision.LiquidityFilter
 }
 
 // AutoTrader 自动交易器
@@ -84,7 +104,7 @@ type AutoTrader struct {
 	exchange              string // 交易平台名称
 	config                AutoTraderConfig
 	trader                Trader // 使用Trader接口（支持多平台）
-	mcpClient             *mcp.Client
+	mcpClient             mcp.AIClient
 	decisionLogger        *logger.DecisionLogger // 决策日志记录器
 	initialBalance        float64
 	dailyPnL              float64
@@ -96,9 +116,70 @@ type AutoTrader struct {
 	lastResetTime         time.Time
 	stopUntil             time.Time
 	isRunning             bool
-	startTime             time.Time        // 系统启动时间
-	callCount             int              // AI调用次数
-	positionFirstSeenTime map[string]int64 // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	runMu                 sync.Mutex
+	cancelRun             context.CancelFunc          // 取消 Run 的上下文：中止进行中的AI调用和行情获取
+	doneCh                chan struct{}               // Run 返回时关闭
+	startTime             time.Time                   // 系统启动时间
+	callCount             int                         // AI调用次数
+	positionFirstSeenTime map[string]int64            // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	staleSymbols          []string                    // 最近一个周期因行情过期被排除的币种
+	candidatePool         candidatePool               // 候选币种池（合并信号源后），设置了刷新间隔时在间隔内复用
+	trackedPositions      map[string]*trackedPosition // 上一周期的持仓 (symbol_side)，用于发现止损/止盈/强平
+	pendingExits          []logger.DecisionAction     // 交易所侧平仓动作，写入下一条决策记录
+	placedOrders          map[string]placedOrder      // 系统挂出的止损/止盈单 (symbol_side)，持仓消失后撤掉
+	failedOrders          []decision.FailedOrder      // 上个周期执行失败的决策，下个周期告知AI
+	orderJournal          OrderJournal                // 订单生命周期记录，nil 时不记录
+	positionHistory       PositionHistory             // 平仓记录，nil 时不记录
+
+	benchmarkMu   sync.Mutex
+	benchmark     *logger.BenchmarkComparison // 最近一次计算的买入持有基准对比
+	benchmarkTime time.Time
+
+	metricsMu   sync.Mutex
+	metrics     *logger.TradeMetrics // 最近一次计算的绩效指标（竞赛排行用）
+	metricsTime time.Time
+
+	reconcileMu     sync.Mutex
+	reconcileReport *ReconcileReport // 启动对账结果
+
+	candidatesMu    sync.Mutex
+	candidateReport *CandidateReport // 最近一个周期的有效候选列表
+
+	breakerMu     sync.Mutex
+	equitySamples []equitySample      // 熔断统计窗口内的净值
+	breakerTrip   *CircuitBreakerTrip // 净值熔断记录，非nil时禁止开仓直到手动恢复
+
+	limitsMu sync.RWMutex
+	limits   decision.Limits // 管理员设置的杠杆/名义价值上限
+
+	heartbeatMu sync.Mutex
+	heartbeat   heartbeatState // 周期心跳，用于发现卡住或连续失败的交易员
+
+	scheduleMu    sync.Mutex
+	schedule      *Schedule // 交易时段，nil 表示全天运行
+	outOfSchedule bool      // 上一周期是否在时段外，用于只在进出时段时打日志
+
+	pauseMu sync.Mutex
+	paused  bool // 手动暂停，跳过决策周期直到恢复
+
+	driftMu         sync.Mutex
+	driftCheckpoint *balanceCheckpoint  // 上一次余额偏差检查
+	driftAlerts     []BalanceDriftAlert // 最近的余额偏差告警
+
+	lossStreakMu  sync.Mutex
+	lossStreak    int       // 当前连续亏损笔数
+	cooldownUntil time.Time // 连续亏损冷却截止时间，之前只分析不开仓
+
+	lastPositionsSig string // 上次推送的持仓摘要，变化时才推送
+
+	signalsMu sync.Mutex
+	signals   map[string]decision.ExternalSignal // 外部推送的信号（币种 -> 最新一条），只保存在内存中
+
+	sourcesMu     sync.Mutex
+	signalSources []pool.SignalSource // 用户注册的信号源，每个周期请求一次并合并到候选币种
+
+	stateMu   sync.Mutex
+	stateFile string // 运行时状态文件，为空时不保存
 }
 
 // NewAutoTrader 创建自动交易器
@@ -119,27 +200,28 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 	}
 
 	mcpClient := mcp.New()
+	l := slog.With("trader_id", config.ID, "user_id", config.UserID, "trader", config.Name)
 
 	// 初始化AI
 	if config.AIModel == "custom" {
 		// 使用自定义API
 		mcpClient.SetCustomAPI(config.CustomAPIURL, config.CustomAPIKey, config.CustomModelName)
-		log.Printf("🤖 [%s] 使用自定义AI API: %s (模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+		l.Info("使用自定义AI API", "url", config.CustomAPIURL, "model", config.CustomModelName)
 	} else if config.UseQwen || config.AIModel == "qwen" {
 		// 使用Qwen (支持自定义URL和Model)
 		mcpClient.SetQwenAPIKey(config.QwenKey, config.CustomAPIURL, config.CustomModelName)
 		if config.CustomAPIURL != "" || config.CustomModelName != "" {
-			log.Printf("🤖 [%s] 使用阿里云Qwen AI (自定义URL: %s, 模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+			l.Info("使用阿里云Qwen AI", "url", config.CustomAPIURL, "model", config.CustomModelName)
 		} else {
-			log.Printf("🤖 [%s] 使用阿里云Qwen AI", config.Name)
+			l.Info("使用阿里云Qwen AI")
 		}
 	} else {
 		// 默认使用DeepSeek (支持自定义URL和Model)
 		mcpClient.SetDeepSeekAPIKey(config.DeepSeekKey, config.CustomAPIURL, config.CustomModelName)
 		if config.CustomAPIURL != "" || config.CustomModelName != "" {
-			log.Printf("🤖 [%s] 使用DeepSeek AI (自定义URL: %s, 模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+			l.Info("使用DeepSeek AI", "url", config.CustomAPIURL, "model", config.CustomModelName)
 		} else {
-			log.Printf("🤖 [%s] 使用DeepSeek AI", config.Name)
+			l.Info("使用DeepSeek AI")
 		}
 	}
 
@@ -153,35 +235,17 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		config.Exchange = "binance"
 	}
 
-	// 根据配置创建对应的交易器
-	var trader Trader
-	var err error
-
 	// 记录仓位模式（通用）
 	marginModeStr := "全仓"
 	if !config.IsCrossMargin {
 		marginModeStr = "逐仓"
 	}
-	log.Printf("📊 [%s] 仓位模式: %s", config.Name, marginModeStr)
+	l.Info("仓位模式", "margin_mode", marginModeStr)
 
-	switch config.Exchange {
-	case "binance":
-		log.Printf("🏦 [%s] 使用币安合约交易", config.Name)
-		trader = NewFuturesTraderWithProxy(config.BinanceAPIKey, config.BinanceSecretKey, config.BinanceProxyURL)
-	case "hyperliquid":
-		log.Printf("🏦 [%s] 使用Hyperliquid交易", config.Name)
-		trader, err = NewHyperliquidTrader(config.HyperliquidPrivateKey, config.HyperliquidWalletAddr, config.HyperliquidTestnet)
-		if err != nil {
-			return nil, fmt.Errorf("初始化Hyperliquid交易器失败: %w", err)
-		}
-	case "aster":
-		log.Printf("🏦 [%s] 使用Aster交易", config.Name)
-		trader, err = NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
-		if err != nil {
-			return nil, fmt.Errorf("初始化Aster交易器失败: %w", err)
-		}
-	default:
-		return nil, fmt.Errorf("不支持的交易平台: %s", config.Exchange)
+	// 根据配置创建对应的交易器
+	trader, err := NewExchangeTrader(config)
+	if err != nil {
+		return nil, err
 	}
 
 	// 验证初始金额配置
@@ -199,7 +263,7 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		systemPromptTemplate = "default" // 默认使用 default 模板
 	}
 
-	return &AutoTrader{
+	at := &AutoTrader{
 		id:                    config.ID,
 		name:                  config.Name,
 		aiModel:               config.AIModel,
@@ -217,50 +281,190 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		callCount:             0,
 		isRunning:             false,
 		positionFirstSeenTime: make(map[string]int64),
-	}, nil
+		trackedPositions:      make(map[string]*trackedPosition),
+		placedOrders:          make(map[string]placedOrder),
+		stateFile:             filepath.Join(logDir, runtimeStateFile),
+	}
+	if err := at.restoreRuntimeState(); err != nil {
+		at.log().Warn("运行时状态无法恢复，从初始状态开始", "error", err)
+	}
+	return at, nil
+}
+
+// log 带交易员上下文（trader_id、user_id，运行后还有周期号）的结构化日志
+func (at *AutoTrader) log() *slog.Logger {
+	l := slog.With("trader_id", at.id, "user_id", at.config.UserID, "trader", at.name)
+	if at.callCount > 0 {
+		l = l.With("cycle", at.callCount)
+	}
+	return l
+}
+
+// Logger 带交易员上下文的结构化日志，供manager等调用方记录与该交易员相关的日志
+func (at *AutoTrader) Logger() *slog.Logger {
+	return at.log()
 }
 
-// Run 运行自动交易主循环
-func (at *AutoTrader) Run() error {
+// NewExchangeTrader 根据配置中的交易平台和密钥创建交易器（跟单也用它连接跟随者的账户）
+func NewExchangeTrader(config AutoTraderConfig) (Trader, error) {
+	var trader Trader
+	var err error
+
+	switch config.Exchange {
+	case "binance":
+		slog.Info("使用币安合约交易", "trader", config.Name)
+		proxyURL := config.BinanceProxyURL
+		if proxyURL == "" {
+			proxyURL = defaultProxyURL
+		}
+		trader = NewFuturesTraderWithProxy(config.BinanceAPIKey, config.BinanceSecretKey, proxyURL)
+	case "hyperliquid":
+		slog.Info("使用Hyperliquid交易", "trader", config.Name)
+		trader, err = NewHyperliquidTrader(config.HyperliquidPrivateKey, config.HyperliquidWalletAddr, config.HyperliquidTestnet)
+		if err != nil {
+			return nil, fmt.Errorf("初始化Hyperliquid交易器失败: %w", err)
+		}
+	case "aster":
+		slog.Info("使用Aster交易", "trader", config.Name)
+		trader, err = NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("初始化Aster交易器失败: %w", err)
+		}
+	case "paper":
+		slog.Info("使用模拟交易所（纸面交易）", "trader", config.Name, "initial_balance", config.InitialBalance)
+		trader = NewSimulatedExchange(config.InitialBalance)
+	default:
+		return nil, fmt.Errorf("不支持的交易平台: %s", config.Exchange)
+	}
+	if l, ok := trader.(interface{ setLogger(*slog.Logger) }); ok {
+		l.setLogger(slog.With("trader_id", config.ID, "user_id", config.UserID, "trader", config.Name, "exchange", config.Exchange))
+	}
+	return trader, nil
+}
+
+// Run 运行自动交易主循环，周期内发生panic时停止该交易员并返回错误（不影响其他交易员）
+func (at *AutoTrader) Run() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			at.isRunning = false
+			err = fmt.Errorf("交易员异常停止: %v", r)
+			at.log().Error("交易员异常停止", "error", r, "stack", string(debug.Stack()))
+			publishEvent(at.id, EventTraderError, map[string]interface{}{"error": err.Error()})
+			at.publishStatus()
+		}
+	}()
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	at.runMu.Lock()
+	at.cancelRun, at.doneCh = cancel, done
+	at.runMu.Unlock()
+	defer close(done)
+
 	at.isRunning = true
-	log.Println("🚀 AI驱动自动交易系统启动")
-	log.Printf("💰 初始余额: %.2f USDT", at.initialBalance)
-	log.Printf("⚙️  扫描间隔: %v", at.config.ScanInterval)
-	log.Println("🤖 AI将全权决定杠杆、仓位大小、止损止盈等参数")
+	at.markRunStarted(time.Now())
+	at.publishStatus()
+	at.log().Info("AI驱动自动交易系统启动", "initial_balance", at.initialBalance, "scan_interval", at.config.ScanInterval)
+
+	// 先核对决策日志与交易所的实际持仓，再开始决策
+	at.reconcileOnStart()
 
 	ticker := time.NewTicker(at.config.ScanInterval)
 	defer ticker.Stop()
 
 	// 首次立即执行
-	if err := at.runCycle(); err != nil {
-		log.Printf("❌ 执行失败: %v", err)
-	}
+	at.runCycleAndLog(runCtx)
 
-	for at.isRunning {
+	for {
 		select {
+		case <-runCtx.Done():
+			at.saveRuntimeState()
+			return nil
 		case <-ticker.C:
-			if err := at.runCycle(); err != nil {
-				log.Printf("❌ 执行失败: %v", err)
-			}
+			at.runCycleAndLog(runCtx)
 		}
 	}
+}
 
-	return nil
+// runCycleAndLog 执行一个周期并记录错误；被 Stop 中断不算失败
+func (at *AutoTrader) runCycleAndLog(ctx context.Context) {
+	at.markCycleStarted(time.Now())
+	err := at.runCycle(ctx)
+	at.markCycleFinished(time.Now(), err)
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		at.log().Info("交易周期被停止中断", "error", err)
+	default:
+		at.log().Error("交易周期执行失败", "error", err)
+	}
 }
 
-// Stop 停止自动交易
+// Stop 停止自动交易：中止进行中的AI调用和行情获取，已开始下单的动作会执行完；不等待Run返回，需要等待时调用 Wait
 func (at *AutoTrader) Stop() {
 	at.isRunning = false
-	log.Println("⏹ 自动交易系统停止")
+	at.runMu.Lock()
+	if at.cancelRun != nil {
+		at.cancelRun()
+	}
+	at.runMu.Unlock()
+	at.publishStatus()
+	at.log().Info("自动交易系统停止")
+}
+
+// StopWithTimeout 停止并最多等待 timeout 让当前周期退出（交易所请求不可中断，受其自身超时限制）
+func (at *AutoTrader) StopWithTimeout(timeout time.Duration) error {
+	at.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return at.Wait(ctx)
+}
+
+// Wait 等待 Run 返回；ctx 先结束时返回 ctx.Err()
+func (at *AutoTrader) Wait(ctx context.Context) error {
+	at.runMu.Lock()
+	done := at.doneCh
+	at.runMu.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // runCycle 运行一个交易周期（使用AI全权决策）
-func (at *AutoTrader) runCycle() error {
-	at.callCount++
+func (at *AutoTrader) runCycle(runCtx context.Context) (err error) {
+	// 交易时段外不调用AI也不记录决策，持仓和止损止盈单保留
+	if !at.checkSchedule(time.Now()) {
+		return nil
+	}
+	// 手动暂停同样跳过整个周期
+	if at.IsPaused() {
+		return nil
+	}
 
-	log.Println("\n" + strings.Repeat("=", 70))
-	log.Printf("⏰ %s - AI决策周期 #%d", time.Now().Format("2006-01-02 15:04:05"), at.callCount)
-	log.Println(strings.Repeat("=", 70))
+	at.callCount++
+	cycle := at.callCount
+	publishEvent(at.id, EventCycleStarted, map[string]interface{}{"cycle": cycle})
+	// 周期内只在这个goroutine修改状态，结束时保存
+	defer at.saveRuntimeState()
+
+	// 整个周期一个trace：构建上下文 → 行情 → prompt → AI → 校验 → 执行 → 记录
+	traceCtx, span := tracing.Start(runCtx, "trader.cycle",
+		attribute.String("trader_id", at.id),
+		attribute.String("user_id", at.config.UserID),
+		attribute.Int("cycle", cycle),
+		attribute.String("exchange", at.exchange),
+		attribute.String("ai_model", at.aiModel),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	at.log().Info("AI决策周期开始")
 
 	// 创建决策记录
 	record := &logger.DecisionRecord{
@@ -271,10 +475,12 @@ func (at *AutoTrader) runCycle() error {
 	// 1. 检查是否需要停止交易
 	if time.Now().Before(at.stopUntil) {
 		remaining := at.stopUntil.Sub(time.Now())
-		log.Printf("⏸ 风险控制：暂停交易中，剩余 %.0f 分钟", remaining.Minutes())
+		at.log().Info("风险控制：暂停交易中", "remaining", remaining.Round(time.Minute))
+		span.SetAttributes(attribute.Bool("paused", true))
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("风险控制暂停中，剩余 %.0f 分钟", remaining.Minutes())
 		at.decisionLogger.LogDecision(record)
+		publishEvent(at.id, EventDecision, record)
 		return nil
 	}
 
@@ -282,18 +488,29 @@ func (at *AutoTrader) runCycle() error {
 	if time.Since(at.lastResetTime) > 24*time.Hour {
 		at.dailyPnL = 0
 		at.lastResetTime = time.Now()
-		log.Println("📅 日盈亏已重置")
+		at.log().Info("日盈亏已重置")
 	}
 
 	// 3. 收集交易上下文
+	_, buildSpan := tracing.Start(traceCtx, "trader.build_context")
 	ctx, err := at.buildTradingContext()
+	tracing.End(buildSpan, err)
 	if err != nil {
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("构建交易上下文失败: %v", err)
 		at.decisionLogger.LogDecision(record)
+		publishEvent(at.id, EventDecision, record)
 		return fmt.Errorf("构建交易上下文失败: %w", err)
 	}
 
+	// 记录交易所侧平仓（止损/止盈/强平），让绩效分析能配对到这些交易
+	exitActions, exitLogs := at.popPendingExits()
+	record.Decisions = append(record.Decisions, exitActions...)
+	record.ExecutionLog = append(record.ExecutionLog, exitLogs...)
+
+	// 资金费吃掉过多浮盈的持仓直接平掉
+	at.closeFundingHeavyPositions(ctx, record)
+
 	// 保存账户状态快照
 	record.AccountState = logger.AccountSnapshot{
 		TotalBalance:          ctx.Account.TotalEquity,
@@ -322,12 +539,50 @@ func (at *AutoTrader) runCycle() error {
 		record.CandidateCoins = append(record.CandidateCoins, coin.Symbol)
 	}
 
-	log.Printf("📊 账户净值: %.2f USDT | 可用: %.2f USDT | 持仓: %d",
-		ctx.Account.TotalEquity, ctx.Account.AvailableBalance, ctx.Account.PositionCount)
+	at.log().Info("账户状态", "equity", ctx.Account.TotalEquity, "available", ctx.Account.AvailableBalance, "positions", ctx.Account.PositionCount)
+
+	// 净值短时间异常下跌（AI失控或交易所数据异常）时熔断，只允许平仓
+	breakerTripped := at.checkCircuitBreaker(ctx.Account.TotalEquity, time.Now())
+	if breakerTripped {
+		at.log().Warn("净值熔断中：本周期只执行平仓，恢复需手动操作")
+	}
+	cooldownRemaining := at.lossCooldownRemaining(time.Now())
+	if cooldownRemaining > 0 {
+		at.log().Info("连续亏损冷却中：只分析不开仓", "remaining", cooldownRemaining.Round(time.Minute))
+	}
 
 	// 4. 调用AI获取完整决策
-	log.Printf("🤖 正在请求AI分析并决策... [模板: %s]", at.systemPromptTemplate)
+	at.log().Info("正在请求AI分析并决策", "template", at.systemPromptTemplate)
+	var aiStart time.Time
+	ctx.CycleContext = traceCtx
+	ctx.OnPromptBuilt = func(systemPrompt, userPrompt string) {
+		aiStart = time.Now()
+		publishEvent(at.id, EventPromptBuilt, map[string]interface{}{
+			"cycle":               cycle,
+			"system_prompt_chars": len(systemPrompt),
+			"user_prompt_chars":   len(userPrompt),
+			"candidate_count":     len(ctx.CandidateCoins),
+			"position_count":      len(ctx.Positions),
+		})
+	}
 	decision, err := decision.GetFullDecisionWithCustomPrompt(ctx, at.mcpClient, at.customPrompt, at.overrideBasePrompt, at.systemPromptTemplate)
+	at.staleSymbols = ctx.StaleSymbols
+	record.LiquidityFiltered = ctx.LiquidityFiltered
+	if len(ctx.LiquidityFiltered) > 0 {
+		filtered := make([]string, 0, len(ctx.LiquidityFiltered))
+		for _, f := range ctx.LiquidityFiltered {
+			filtered = append(filtered, f.Symbol)
+		}
+		at.log().Info("流动性不足的币种未发给AI", "count", len(filtered), "symbols", strings.Join(filtered, ","))
+	}
+	at.candidatesMu.Lock()
+	at.candidateReport = newCandidateReport(cycle, ctx, at.candidatePool)
+	at.candidatesMu.Unlock()
+	at.publishAIResponded(cycle, aiStart, decision, err)
+	if reporter, ok := at.mcpClient.(mcp.UsageReporter); ok {
+		usage := reporter.TakeUsage()
+		record.PromptTokens, record.CompletionTokens = usage.PromptTokens, usage.CompletionTokens
+	}
 
 	// 即使有错误，也保存思维链、决策和输入prompt（用于debug）
 	if decision != nil {
@@ -344,26 +599,18 @@ func (at *AutoTrader) runCycle() error {
 		record.Success = false
 		record.ErrorMessage = fmt.Sprintf("获取AI决策失败: %v", err)
 
-		// 打印系统提示词和AI思维链（即使有错误，也要输出以便调试）
+		// 系统提示词和AI思维链较长，只在debug级别输出（决策记录中也有保存）
 		if decision != nil {
 			if decision.SystemPrompt != "" {
-				log.Println("\n" + strings.Repeat("=", 70))
-				log.Printf("📋 系统提示词 [模板: %s] (错误情况)", at.systemPromptTemplate)
-				log.Println(strings.Repeat("=", 70))
-				log.Println(decision.SystemPrompt)
-				log.Println(strings.Repeat("=", 70) + "\n")
+				at.log().Debug("系统提示词（获取决策失败）", "template", at.systemPromptTemplate, "prompt", decision.SystemPrompt)
 			}
-
 			if decision.CoTTrace != "" {
-				log.Println("\n" + strings.Repeat("-", 70))
-				log.Println("💭 AI思维链分析（错误情况）:")
-				log.Println(strings.Repeat("-", 70))
-				log.Println(decision.CoTTrace)
-				log.Println(strings.Repeat("-", 70) + "\n")
+				at.log().Debug("AI思维链分析（获取决策失败）", "cot", decision.CoTTrace)
 			}
 		}
 
 		at.decisionLogger.LogDecision(record)
+		publishEvent(at.id, EventDecision, record)
 		return fmt.Errorf("获取AI决策失败: %w", err)
 	}
 
@@ -390,18 +637,29 @@ func (at *AutoTrader) runCycle() error {
 	// 			d.Leverage, d.PositionSizeUSD, d.StopLoss, d.TakeProfit)
 	// 	}
 	// }
-	log.Println()
 
 	// 8. 对决策排序：确保先平仓后开仓（防止仓位叠加超限）
 	sortedDecisions := sortDecisionsByPriority(decision.Decisions)
 
-	log.Println("🔄 执行顺序（已优化）: 先平仓→后开仓")
+	order := make([]string, len(sortedDecisions))
 	for i, d := range sortedDecisions {
-		log.Printf("  [%d] %s %s", i+1, d.Symbol, d.Action)
+		order[i] = d.Symbol + " " + d.Action
+	}
+	at.log().Info("执行顺序（已优化）: 先平仓→后开仓", "decisions", order)
+
+	// AI返回后已被停止则不再下单；开始下单后不中断，避免只开仓没挂止损
+	if err := runCtx.Err(); err != nil {
+		record.Success = false
+		record.ErrorMessage = "交易员已停止，未执行本周期决策"
+		at.decisionLogger.LogDecision(record)
+		publishEvent(at.id, EventDecision, record)
+		return fmt.Errorf("执行决策前交易员已停止: %w", err)
 	}
-	log.Println()
 
 	// 执行决策并记录结果
+	executeCtx, executeSpan := tracing.Start(traceCtx, "trader.execute", attribute.Int("decision_count", len(sortedDecisions)))
+	deltaGuard := newNetDeltaGuard(ctx, ctx.MaxNetDeltaPct)
+	at.failedOrders = nil
 	for _, d := range sortedDecisions {
 		actionRecord := logger.DecisionAction{
 			Action:    d.Action,
@@ -413,12 +671,35 @@ func (at *AutoTrader) runCycle() error {
 			Success:   false,
 		}
 
-		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
-			log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
+		if isOpenAction(d.Action) && (breakerTripped || cooldownRemaining > 0) {
+			reason := "净值熔断中"
+			if !breakerTripped {
+				reason = fmt.Sprintf("连续亏损冷却中（剩余 %.0f 分钟）", cooldownRemaining.Minutes())
+			}
+			actionRecord.Error = reason + "，禁止开仓"
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏸ %s %s 跳过: %s", d.Symbol, d.Action, reason))
+			record.Decisions = append(record.Decisions, actionRecord)
+			continue
+		}
+		if err := deltaGuard.check(&d); err != nil {
 			actionRecord.Error = err.Error()
-			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, err))
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏸ %s %s 跳过: %v", d.Symbol, d.Action, err))
+			record.Decisions = append(record.Decisions, actionRecord)
+			continue
+		}
+
+		_, actionSpan := tracing.Start(executeCtx, "trader.execute_decision", attribute.String("symbol", d.Symbol), attribute.String("action", d.Action))
+		execErr := at.executeDecisionWithRecord(&d, &actionRecord)
+		tracing.End(actionSpan, execErr)
+		if execErr != nil {
+			actionRecord.ErrorClass = string(ErrorClassOf(execErr))
+			at.log().Error("执行决策失败", "symbol", d.Symbol, "action", d.Action, "class", actionRecord.ErrorClass, "error", execErr)
+			actionRecord.Error = execErr.Error()
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败: %v", d.Symbol, d.Action, execErr))
+			at.recordFailedOrder(&actionRecord)
 		} else {
 			actionRecord.Success = true
+			deltaGuard.apply(&d)
 			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action))
 			// 成功执行后短暂延迟
 			time.Sleep(1 * time.Second)
@@ -426,12 +707,26 @@ func (at *AutoTrader) runCycle() error {
 
 		record.Decisions = append(record.Decisions, actionRecord)
 	}
+	executeSpan.End()
+	publishEvent(at.id, EventOrdersExecuted, map[string]interface{}{
+		"cycle":         cycle,
+		"actions":       record.Decisions,
+		"execution_log": record.ExecutionLog,
+	})
 
 	// 9. 保存决策记录
-	if err := at.decisionLogger.LogDecision(record); err != nil {
-		log.Printf("⚠ 保存决策记录失败: %v", err)
+	_, logSpan := tracing.Start(traceCtx, "trader.log_decision")
+	logErr := at.decisionLogger.LogDecision(record)
+	tracing.End(logSpan, logErr)
+	if logErr != nil {
+		at.log().Warn("保存决策记录失败", "error", logErr)
 	}
 
+	// 10. 推送给前端
+	publishEvent(at.id, EventDecision, record)
+	at.publishPositionsIfChanged()
+	at.publishStatus()
+
 	return nil
 }
 
@@ -440,6 +735,7 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 1. 获取账户信息
 	balance, err := at.trader.GetBalance()
 	if err != nil {
+		ExchangeHealth.Record(at.exchange, err)
 		return nil, fmt.Errorf("获取账户余额失败: %w", err)
 	}
 
@@ -463,6 +759,7 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 
 	// 2. 获取持仓信息
 	positions, err := at.trader.GetPositions()
+	ExchangeHealth.Record(at.exchange, err)
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
@@ -510,7 +807,7 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		}
 		updateTime := at.positionFirstSeenTime[posKey]
 
-		positionInfos = append(positionInfos, decision.PositionInfo{
+		positionInfo := decision.PositionInfo{
 			Symbol:           symbol,
 			Side:             side,
 			EntryPrice:       entryPrice,
@@ -522,8 +819,20 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 			LiquidationPrice: liquidationPrice,
 			MarginUsed:       marginUsed,
 			UpdateTime:       updateTime,
-		})
+		}
+		positionInfos = append(positionInfos, positionInfo)
+		at.updateTrackedPosition(positionInfo)
 	}
+	at.fillFundingFees(positionInfos)
+
+	// 上一周期还在、现在消失的持仓是被交易所侧平掉的
+	at.detectExchangeExits(currentPositionKeys)
+
+	// 撤掉已无对应持仓的残留挂单
+	at.cleanupOrphanOrders(currentPositionKeys)
+
+	// 交易所侧平仓已回填盈亏，核对钱包余额变化是否都能由已实现盈亏解释
+	at.checkBalanceDrift(totalWalletBalance, time.Now())
 
 	// 清理已平仓的持仓记录
 	for key := range at.positionFirstSeenTime {
@@ -532,11 +841,13 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		}
 	}
 
-	// 3. 获取交易员的候选币种池
-	candidateCoins, err := at.getCandidateCoins()
+	// 3. 获取交易员的候选币种池，合并用户信号源和外部推送的信号币种
+	candidateCoins, err := at.loadCandidatePool(time.Now())
 	if err != nil {
 		return nil, fmt.Errorf("获取候选币种失败: %w", err)
 	}
+	signals := at.ActiveSignals(time.Now())
+	candidateCoins = mergeSignalCandidates(candidateCoins, signals)
 
 	// 4. 计算总盈亏
 	totalPnL := totalEquity - at.initialBalance
@@ -554,7 +865,7 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 假设每3分钟一个周期，100个周期 = 5小时，足够覆盖大部分交易
 	performance, err := at.decisionLogger.AnalyzePerformance(100)
 	if err != nil {
-		log.Printf("⚠️  分析历史表现失败: %v", err)
+		at.log().Warn("分析历史表现失败", "error", err)
 		// 不影响主流程，继续执行（但设置performance为nil以避免传递错误数据）
 		performance = nil
 	}
@@ -566,6 +877,10 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		CallCount:       at.callCount,
 		BTCETHLeverage:  at.config.BTCETHLeverage,  // 使用配置的杠杆倍数
 		AltcoinLeverage: at.config.AltcoinLeverage, // 使用配置的杠杆倍数
+		Limits:          at.GetLimits(),            // 管理员上限优先于配置
+		MaxNetDeltaPct:  getMaxNetDelta(),
+		MaxCandidates:   at.config.MaxCandidates,
+		Liquidity:       at.config.Liquidity,
 		Account: decision.AccountInfo{
 			TotalEquity:      totalEquity,
 			AvailableBalance: availableBalance,
@@ -574,10 +889,13 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 			MarginUsed:       totalMarginUsed,
 			MarginUsedPct:    marginUsedPct,
 			PositionCount:    len(positionInfos),
+			NetDelta:         netDelta(positionInfos),
 		},
 		Positions:      positionInfos,
 		CandidateCoins: candidateCoins,
 		Performance:    performance, // 添加历史表现分析
+		FailedOrders:   at.failedOrders,
+		Signals:        signals,
 	}
 
 	return ctx, nil
@@ -604,7 +922,7 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 
 // executeOpenLongWithRecord 执行开多仓并记录详细信息
 func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  📈 开多仓: %s", decision.Symbol)
+	at.log().Info("开多仓", "symbol", decision.Symbol)
 
 	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
 	positions, err := at.trader.GetPositions()
@@ -626,15 +944,19 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
 	actionRecord.Quantity = quantity
 	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.StopLoss = decision.StopLoss
+	actionRecord.TakeProfit = decision.TakeProfit
 
 	// 设置仓位模式
 	if err := at.trader.SetMarginMode(decision.Symbol, at.config.IsCrossMargin); err != nil {
-		log.Printf("  ⚠️ 设置仓位模式失败: %v", err)
+		at.log().Warn("设置仓位模式失败", "symbol", decision.Symbol, "error", err)
 		// 继续执行，不影响交易
 	}
 
 	// 开仓
-	order, err := at.trader.OpenLong(decision.Symbol, quantity, decision.Leverage)
+	order, err := at.placeOrder(decision.Action, decision.Symbol, quantity, marketData.CurrentPrice, func() (map[string]interface{}, error) {
+		return at.trader.OpenLong(decision.Symbol, quantity, decision.Leverage)
+	})
 	if err != nil {
 		return err
 	}
@@ -643,27 +965,34 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
+	at.recordFill(order, actionRecord)
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.log().Info("开仓成功", "symbol", decision.Symbol, "order_id", order["orderId"], "quantity", quantity)
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_long"
 	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.trackOpenedPosition(decision.Symbol, "long", quantity, marketData.CurrentPrice, decision.StopLoss, decision.TakeProfit)
 
 	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
-	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
-	}
+	at.setProtectiveOrders(decision.Symbol, "long", quantity, decision.StopLoss, decision.TakeProfit)
+
+	publishEvent(at.id, EventPositionOpened, PositionOpenedEvent{
+		Symbol:     decision.Symbol,
+		Side:       "long",
+		Quantity:   quantity,
+		Price:      marketData.CurrentPrice,
+		Leverage:   decision.Leverage,
+		StopLoss:   decision.StopLoss,
+		TakeProfit: decision.TakeProfit,
+	})
 
 	return nil
 }
 
 // executeOpenShortWithRecord 执行开空仓并记录详细信息
 func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  📉 开空仓: %s", decision.Symbol)
+	at.log().Info("开空仓", "symbol", decision.Symbol)
 
 	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
 	positions, err := at.trader.GetPositions()
@@ -685,15 +1014,19 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
 	actionRecord.Quantity = quantity
 	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.StopLoss = decision.StopLoss
+	actionRecord.TakeProfit = decision.TakeProfit
 
 	// 设置仓位模式
 	if err := at.trader.SetMarginMode(decision.Symbol, at.config.IsCrossMargin); err != nil {
-		log.Printf("  ⚠️ 设置仓位模式失败: %v", err)
+		at.log().Warn("设置仓位模式失败", "symbol", decision.Symbol, "error", err)
 		// 继续执行，不影响交易
 	}
 
 	// 开仓
-	order, err := at.trader.OpenShort(decision.Symbol, quantity, decision.Leverage)
+	order, err := at.placeOrder(decision.Action, decision.Symbol, quantity, marketData.CurrentPrice, func() (map[string]interface{}, error) {
+		return at.trader.OpenShort(decision.Symbol, quantity, decision.Leverage)
+	})
 	if err != nil {
 		return err
 	}
@@ -702,27 +1035,34 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
+	at.recordFill(order, actionRecord)
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.log().Info("开仓成功", "symbol", decision.Symbol, "order_id", order["orderId"], "quantity", quantity)
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_short"
 	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.trackOpenedPosition(decision.Symbol, "short", quantity, marketData.CurrentPrice, decision.StopLoss, decision.TakeProfit)
 
 	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
-	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
-	}
+	at.setProtectiveOrders(decision.Symbol, "short", quantity, decision.StopLoss, decision.TakeProfit)
+
+	publishEvent(at.id, EventPositionOpened, PositionOpenedEvent{
+		Symbol:     decision.Symbol,
+		Side:       "short",
+		Quantity:   quantity,
+		Price:      marketData.CurrentPrice,
+		Leverage:   decision.Leverage,
+		StopLoss:   decision.StopLoss,
+		TakeProfit: decision.TakeProfit,
+	})
 
 	return nil
 }
 
 // executeCloseLongWithRecord 执行平多仓并记录详细信息
 func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  🔄 平多仓: %s", decision.Symbol)
+	at.log().Info("平多仓", "symbol", decision.Symbol)
 
 	// 获取当前价格
 	marketData, err := market.Get(decision.Symbol)
@@ -732,7 +1072,9 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 平仓
-	order, err := at.trader.CloseLong(decision.Symbol, 0) // 0 = 全部平仓
+	order, err := at.placeOrder(decision.Action, decision.Symbol, 0, marketData.CurrentPrice, func() (map[string]interface{}, error) {
+		return at.trader.CloseLong(decision.Symbol, 0) // 0 = 全部平仓
+	})
 	if err != nil {
 		return err
 	}
@@ -741,14 +1083,16 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
+	at.recordFill(order, actionRecord)
+	at.recordAIClose(decision.Symbol, "long", marketData.CurrentPrice, actionRecord)
 
-	log.Printf("  ✓ 平仓成功")
+	at.log().Info("平仓成功", "symbol", decision.Symbol)
 	return nil
 }
 
 // executeCloseShortWithRecord 执行平空仓并记录详细信息
 func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  🔄 平空仓: %s", decision.Symbol)
+	at.log().Info("平空仓", "symbol", decision.Symbol)
 
 	// 获取当前价格
 	marketData, err := market.Get(decision.Symbol)
@@ -758,7 +1102,9 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	actionRecord.Price = marketData.CurrentPrice
 
 	// 平仓
-	order, err := at.trader.CloseShort(decision.Symbol, 0) // 0 = 全部平仓
+	order, err := at.placeOrder(decision.Action, decision.Symbol, 0, marketData.CurrentPrice, func() (map[string]interface{}, error) {
+		return at.trader.CloseShort(decision.Symbol, 0) // 0 = 全部平仓
+	})
 	if err != nil {
 		return err
 	}
@@ -767,8 +1113,10 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
+	at.recordFill(order, actionRecord)
+	at.recordAIClose(decision.Symbol, "short", marketData.CurrentPrice, actionRecord)
 
-	log.Printf("  ✓ 平仓成功")
+	at.log().Info("平仓成功", "symbol", decision.Symbol)
 	return nil
 }
 
@@ -782,6 +1130,16 @@ func (at *AutoTrader) GetName() string {
 	return at.name
 }
 
+// GetUserID 获取所属用户ID
+func (at *AutoTrader) GetUserID() string {
+	return at.config.UserID
+}
+
+// IsRunning 是否正在运行
+func (at *AutoTrader) IsRunning() bool {
+	return at.isRunning
+}
+
 // GetAIModel 获取AI模型
 func (at *AutoTrader) GetAIModel() string {
 	return at.aiModel
@@ -812,6 +1170,30 @@ func (at *AutoTrader) GetSystemPromptTemplate() string {
 	return at.systemPromptTemplate
 }
 
+// GetAIClient 当前使用的AI客户端
+func (at *AutoTrader) GetAIClient() mcp.AIClient {
+	return at.mcpClient
+}
+
+// SetAIClient 替换AI客户端（如用 mcp.RecordedClient 回放录制的响应做确定性测试）
+func (at *AutoTrader) SetAIClient(client mcp.AIClient) {
+	at.mcpClient = client
+}
+
+// SetLimits 设置管理员上限（下一个决策周期生效）
+func (at *AutoTrader) SetLimits(limits decision.Limits) {
+	at.limitsMu.Lock()
+	defer at.limitsMu.Unlock()
+	at.limits = limits
+}
+
+// GetLimits 获取管理员上限
+func (at *AutoTrader) GetLimits() decision.Limits {
+	at.limitsMu.RLock()
+	defer at.limitsMu.RUnlock()
+	return at.limits
+}
+
 // GetDecisionLogger 获取决策日志记录器
 func (at *AutoTrader) GetDecisionLogger() *logger.DecisionLogger {
 	return at.decisionLogger
@@ -824,6 +1206,8 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		aiProvider = "Qwen"
 	}
 
+	usedWeight, weightLimit := market.GetAPIWeightUsage()
+
 	return map[string]interface{}{
 		"trader_id":       at.id,
 		"trader_name":     at.name,
@@ -838,6 +1222,18 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		"stop_until":      at.stopUntil.Format(time.RFC3339),
 		"last_reset_time": at.lastResetTime.Format(time.RFC3339),
 		"ai_provider":     aiProvider,
+		"stale_symbols":   at.staleSymbols,
+		"stale_threshold": market.GetStaleThreshold().String(),
+		"api_weight_used": usedWeight,
+		"api_weight_max":  weightLimit,
+		"reconciliation":  at.GetReconcileReport(),
+		"circuit_breaker": at.GetCircuitBreakerTrip(),
+		"loss_cooldown":   at.getLossCooldownStatus(),
+		"limits":          at.GetLimits(),
+		"balance_drift":   at.GetBalanceDriftAlerts(),
+		"schedule":        at.getScheduleStatus(),
+		"paused":          at.IsPaused(),
+		"heartbeat":       at.GetHeartbeat(time.Now()),
 	}
 }
 
@@ -1013,7 +1409,8 @@ func sortDecisionsByPriority(decisions []decision.Decision) []decision.Decision
 }
 
 // getCandidateCoins 获取交易员的候选币种列表
-func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
+// 使用AI500+OI Top时同时返回数据过期而被丢弃的币种
+func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, []pool.DroppedCoin, error) {
 	if len(at.tradingCoins) == 0 {
 		// 使用数据库配置的默认币种列表
 		var candidateCoins []decision.CandidateCoin
@@ -1027,30 +1424,22 @@ func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
 					Sources: []string{"default"}, // 标记为数据库默认币种
 				})
 			}
-			log.Printf("📋 [%s] 使用数据库默认币种: %d个币种 %v",
-				at.name, len(candidateCoins), at.defaultCoins)
-			return candidateCoins, nil
+			at.log().Info("使用数据库默认币种", "count", len(candidateCoins), "coins", at.defaultCoins)
+			return candidateCoins, nil, nil
 		} else {
 			// 如果数据库中没有配置默认币种，则使用AI500+OI Top作为fallback
 			const ai500Limit = 20 // AI500取前20个评分最高的币种
 
 			mergedPool, err := pool.GetMergedCoinPool(ai500Limit)
 			if err != nil {
-				return nil, fmt.Errorf("获取合并币种池失败: %w", err)
+				return nil, nil, fmt.Errorf("获取合并币种池失败: %w", err)
 			}
 
-			// 构建候选币种列表（包含来源信息）
-			for _, symbol := range mergedPool.AllSymbols {
-				sources := mergedPool.SymbolSources[symbol]
-				candidateCoins = append(candidateCoins, decision.CandidateCoin{
-					Symbol:  symbol,
-					Sources: sources, // "ai500" 和/或 "oi_top"
-				})
-			}
+			// 构建候选币种列表（包含来源信息，按合并得分排序）
+			candidateCoins = mergePoolCandidates(candidateCoins, mergedPool.Candidates) // 来源 "ai500" 和/或 "oi_top"
 
-			log.Printf("📋 [%s] 数据库无默认币种配置，使用AI500+OI Top: AI500前%d + OI_Top20 = 总计%d个候选币种",
-				at.name, ai500Limit, len(candidateCoins))
-			return candidateCoins, nil
+			at.log().Info("数据库无默认币种配置，使用AI500+OI Top", "ai500_limit", ai500Limit, "count", len(candidateCoins), "dropped", len(mergedPool.Dropped))
+			return candidateCoins, mergedPool.Dropped, nil
 		}
 	} else {
 		// 使用自定义币种列表
@@ -1064,9 +1453,8 @@ func (at *AutoTrader) getCandidateCoins() ([]decision.CandidateCoin, error) {
 			})
 		}
 
-		log.Printf("📋 [%s] 使用自定义币种: %d个币种 %v",
-			at.name, len(candidateCoins), at.tradingCoins)
-		return candidateCoins, nil
+		at.log().Info("使用自定义币种", "count", len(candidateCoins), "coins", at.tradingCoins)
+		return candidateCoins, nil, nil
 	}
 }
 
@@ -0,0 +1,111 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	maxBalanceDriftAlerts = 20  // 状态接口保留的最近偏差告警数
+	minBalanceDriftUSD    = 1.0 // 偏差绝对值下限，避免小账户因手续费误报
+)
+
+var (
+	balanceDriftMu  sync.RWMutex
+	balanceDriftPct = 2.0 // 两次检查之间无法由已实现盈亏解释的余额变化超过此百分比时告警（0 关闭）
+)
+
+// SetBalanceDriftThreshold 设置余额偏差告警阈值（占钱包余额百分比，0 关闭）
+func SetBalanceDriftThreshold(pct float64) error {
+	if pct < 0 {
+		return fmt.Errorf("余额偏差阈值不能为负数: %.2f", pct)
+	}
+	balanceDriftMu.Lock()
+	defer balanceDriftMu.Unlock()
+	balanceDriftPct = pct
+	return nil
+}
+
+// getBalanceDriftThreshold 获取余额偏差告警阈值
+func getBalanceDriftThreshold() float64 {
+	balanceDriftMu.RLock()
+	defer balanceDriftMu.RUnlock()
+	return balanceDriftPct
+}
+
+// BalanceDriftAlert 交易所钱包余额变化与决策日志已实现盈亏不一致（充提、机器人外的手动交易等）
+type BalanceDriftAlert struct {
+	Time           time.Time `json:"time"`
+	Since          time.Time `json:"since"` // 上一次检查时间
+	PreviousWallet float64   `json:"previous_wallet"`
+	Wallet         float64   `json:"wallet"`
+	RealizedPnL    float64   `json:"realized_pnl"` // 期间日志记录的已实现盈亏
+	Trades         int       `json:"trades"`
+	Drift          float64   `json:"drift"` // 余额变化 - 已实现盈亏
+	DriftPct       float64   `json:"drift_pct"`
+}
+
+// balanceCheckpoint 上一次余额检查
+type balanceCheckpoint struct {
+	time   time.Time
+	wallet float64
+}
+
+// checkBalanceDrift 比较本次与上次检查之间的钱包余额变化和日志中的已实现盈亏，偏差超过阈值时告警
+// 需要在交易所侧平仓已回填结果之后调用，否则这些平仓会被算作偏差
+func (at *AutoTrader) checkBalanceDrift(wallet float64, now time.Time) *BalanceDriftAlert {
+	at.driftMu.Lock()
+	prev := at.driftCheckpoint
+	at.driftCheckpoint = &balanceCheckpoint{time: now, wallet: wallet}
+	at.driftMu.Unlock()
+
+	threshold := getBalanceDriftThreshold()
+	if prev == nil || threshold <= 0 {
+		return nil
+	}
+
+	realized, trades, err := at.decisionLogger.GetRealizedPnLBetween(prev.time, now)
+	if err != nil {
+		at.log().Warn("余额偏差检查失败", "error", err)
+		return nil
+	}
+	drift := (wallet - prev.wallet) - realized
+	if math.Abs(drift) <= math.Max(prev.wallet*threshold/100, minBalanceDriftUSD) {
+		return nil
+	}
+
+	alert := &BalanceDriftAlert{
+		Time:           now,
+		Since:          prev.time,
+		PreviousWallet: prev.wallet,
+		Wallet:         wallet,
+		RealizedPnL:    realized,
+		Trades:         trades,
+		Drift:          drift,
+	}
+	if prev.wallet > 0 {
+		alert.DriftPct = drift / prev.wallet * 100
+	}
+	at.log().Warn("余额偏差告警：可能有充提或机器人外的手动交易",
+		"wallet_before", prev.wallet, "wallet_after", wallet, "realized_pnl", realized,
+		"trades", trades, "unexplained", drift, "drift_pct", alert.DriftPct)
+
+	at.driftMu.Lock()
+	at.driftAlerts = append(at.driftAlerts, *alert)
+	if len(at.driftAlerts) > maxBalanceDriftAlerts {
+		at.driftAlerts = at.driftAlerts[len(at.driftAlerts)-maxBalanceDriftAlerts:]
+	}
+	at.driftMu.Unlock()
+	return alert
+}
+
+// GetBalanceDriftAlerts 获取最近的余额偏差告警（从旧到新）
+func (at *AutoTrader) GetBalanceDriftAlerts() []BalanceDriftAlert {
+	at.driftMu.Lock()
+	defer at.driftMu.Unlock()
+	alerts := make([]BalanceDriftAlert, len(at.driftAlerts))
+	copy(alerts, at.driftAlerts)
+	return alerts
+}
@@ -0,0 +1,43 @@
+package trader
+
+import (
+	"nofx/logger"
+	"testing"
+	"time"
+)
+
+func TestCheckBalanceDrift(t *testing.T) {
+	defer SetBalanceDriftThreshold(2)
+	SetBalanceDriftThreshold(2)
+
+	decisionLogger := logger.NewDecisionLogger(t.TempDir())
+	at := &AutoTrader{name: "test", decisionLogger: decisionLogger}
+	start := time.Now().Add(-time.Hour)
+
+	if alert := at.checkBalanceDrift(1000, start); alert != nil {
+		t.Fatal("首次检查只记录基准")
+	}
+
+	// 期间一笔盈利100的平仓，余额随之增加，不应告警
+	decisionLogger.LogDecision(&logger.DecisionRecord{Decisions: []logger.DecisionAction{
+		{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.1, Price: 60000, Timestamp: start, Success: true},
+	}})
+	decisionLogger.LabelOutcome("BTCUSDT", "long", 61000, start.Add(10*time.Minute), logger.ExitReasonTakeProfit)
+	if alert := at.checkBalanceDrift(1099, start.Add(20*time.Minute)); alert != nil {
+		t.Errorf("余额变化与已实现盈亏一致（含少量手续费）不应告警: %+v", alert)
+	}
+
+	// 没有平仓，余额凭空减少300（提现或手动交易）
+	alert := at.checkBalanceDrift(799, start.Add(40*time.Minute))
+	if alert == nil || alert.Drift != -300 || alert.Trades != 0 {
+		t.Fatalf("无法解释的余额变化应告警: %+v", alert)
+	}
+	if alerts := at.GetBalanceDriftAlerts(); len(alerts) != 1 {
+		t.Errorf("状态中应保留告警: %+v", alerts)
+	}
+
+	SetBalanceDriftThreshold(0)
+	if alert := at.checkBalanceDrift(100, start.Add(50*time.Minute)); alert != nil {
+		t.Error("关闭后不应告警")
+	}
+}
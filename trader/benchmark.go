@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/logger"
+	"nofx/market"
+	"sync"
+	"time"
+)
+
+const (
+	benchmarkSymbol    = "BTCUSDT"
+	benchmarkCacheTTL  = 3 * time.Minute // 约等于一个决策周期
+	maxBenchmarkBasket = 5               // 候选币种组合最多取前N个，控制请求数量
+)
+
+var (
+	historicalPriceMu    sync.Mutex
+	historicalPriceCache = make(map[string]float64) // symbol@分钟时间戳 -> 价格（历史价格不会变化）
+)
+
+// historicalPrice 获取指定时间的价格（按分钟缓存）
+func historicalPrice(symbol string, t time.Time) (float64, error) {
+	key := fmt.Sprintf("%s@%d", symbol, t.Truncate(time.Minute).Unix())
+
+	historicalPriceMu.Lock()
+	price, ok := historicalPriceCache[key]
+	historicalPriceMu.Unlock()
+	if ok {
+		return price, nil
+	}
+
+	price, err := market.NewAPIClient().GetPriceAt(symbol, t)
+	if err != nil {
+		return 0, err
+	}
+
+	historicalPriceMu.Lock()
+	historicalPriceCache[key] = price
+	historicalPriceMu.Unlock()
+	return price, nil
+}
+
+// GetBenchmark 与同一区间内买入持有BTC和候选币种组合的收益对比
+func (at *AutoTrader) GetBenchmark() (*logger.BenchmarkComparison, error) {
+	at.benchmarkMu.Lock()
+	defer at.benchmarkMu.Unlock()
+
+	if at.benchmark != nil && time.Since(at.benchmarkTime) < benchmarkCacheTTL {
+		return at.benchmark, nil
+	}
+
+	first, last, candidates, err := at.decisionLogger.GetEquityBounds()
+	if err != nil {
+		return nil, err
+	}
+	basket := candidates
+	if len(basket) > maxBenchmarkBasket {
+		basket = basket[:maxBenchmarkBasket]
+	}
+
+	startPrices := make(map[string]float64)
+	endPrices := make(map[string]float64)
+	for _, symbol := range append([]string{benchmarkSymbol}, basket...) {
+		if _, done := startPrices[symbol]; done {
+			continue
+		}
+		start, err := historicalPrice(symbol, first.Time)
+		if err != nil {
+			continue
+		}
+		end, err := historicalPrice(symbol, last.Time)
+		if err != nil {
+			continue
+		}
+		startPrices[symbol], endPrices[symbol] = start, end
+	}
+
+	comparison, err := logger.CompareWithBenchmark(first, last, startPrices, endPrices, benchmarkSymbol, basket)
+	if err != nil {
+		return nil, fmt.Errorf("计算基准收益失败: %w", err)
+	}
+	at.benchmark = comparison
+	at.benchmarkTime = time.Now()
+	return comparison, nil
+}
@@ -3,7 +3,10 @@ package trader
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"nofx/market"
 	"strconv"
 	"sync"
 	"time"
@@ -27,6 +30,13 @@ type FuturesTrader struct {
 
 	// 缓存有效期（15秒）
 	cacheDuration time.Duration
+
+	// 交易规则缓存（tickSize/stepSize/minNotional，1小时刷新）
+	symbolFilters     map[string]*SymbolFilters
+	filtersCacheTime  time.Time
+	filtersCacheMutex sync.RWMutex
+
+	exchangeLog
 }
 
 // NewFuturesTrader 创建合约交易器
@@ -34,15 +44,46 @@ func NewFuturesTrader(apiKey, secretKey string) *FuturesTrader {
 	return NewFuturesTraderWithProxy(apiKey, secretKey, "")
 }
 
+// defaultProxyURL 交易员未单独配置代理时使用的默认代理，为空时直连
+var defaultProxyURL string
+
+// SetDefaultProxyURL 设置交易所API的默认代理（启动时调用）
+func SetDefaultProxyURL(proxyURL string) {
+	defaultProxyURL = proxyURL
+}
+
+// ValidateProxyURL 代理地址必须是 http、https 或 socks5 的完整URL
+func ValidateProxyURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("不是有效的代理地址: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("代理地址只支持 http、https、socks5: %q", value)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("代理地址缺少主机: %q", value)
+	}
+	return nil
+}
+
 // NewFuturesTraderWithProxy 创建带代理的合约交易器
 func NewFuturesTraderWithProxy(apiKey, secretKey, proxyUrl string) *FuturesTrader {
 	var client *futures.Client
 	if proxyUrl != "" {
 		client = futures.NewProxiedClient(apiKey, secretKey, proxyUrl)
-		log.Printf("✓ 使用代理连接币安API: %s", proxyUrl)
+		slog.Info("使用代理连接币安API", "proxy", proxyUrl)
 	} else {
 		client = futures.NewClient(apiKey, secretKey)
-		log.Printf("✓ 使用直连连接币安API")
+		slog.Info("使用直连连接币安API")
+	}
+
+	// 交易请求与行情请求共享权重预算，下单类请求优先
+	client.HTTPClient = &http.Client{
+		Timeout:   client.HTTPClient.Timeout,
+		Transport: market.WrapRateLimitedTransport(client.HTTPClient.Transport, true),
 	}
 
 	return &FuturesTrader{
@@ -58,16 +99,16 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	if t.cachedBalance != nil && time.Since(t.balanceCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.balanceCacheTime)
 		t.balanceCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的账户余额（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		t.log().Debug("使用缓存的账户余额", "cache_age_seconds", cacheAge.Seconds())
 		return t.cachedBalance, nil
 	}
 	t.balanceCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用币安API获取账户余额...")
+	t.log().Debug("余额缓存过期，正在调用币安API获取账户余额")
 	account, err := t.client.NewGetAccountService().Do(context.Background())
 	if err != nil {
-		log.Printf("❌ 币安API调用失败: %v", err)
+		t.log().Error("币安API调用失败", "error", err)
 		return nil, fmt.Errorf("获取账户信息失败: %w", err)
 	}
 
@@ -76,10 +117,10 @@ func (t *FuturesTrader) GetBalance() (map[string]interface{}, error) {
 	result["availableBalance"], _ = strconv.ParseFloat(account.AvailableBalance, 64)
 	result["totalUnrealizedProfit"], _ = strconv.ParseFloat(account.TotalUnrealizedProfit, 64)
 
-	log.Printf("✓ 币安API返回: 总余额=%s, 可用=%s, 未实现盈亏=%s",
-		account.TotalWalletBalance,
-		account.AvailableBalance,
-		account.TotalUnrealizedProfit)
+	t.log().Debug("币安API返回账户余额",
+		"total_wallet_balance", account.TotalWalletBalance,
+		"available_balance", account.AvailableBalance,
+		"total_unrealized_profit", account.TotalUnrealizedProfit)
 
 	// 更新缓存
 	t.balanceCacheMutex.Lock()
@@ -97,13 +138,13 @@ func (t *FuturesTrader) GetPositions() ([]map[string]interface{}, error) {
 	if t.cachedPositions != nil && time.Since(t.positionsCacheTime) < t.cacheDuration {
 		cacheAge := time.Since(t.positionsCacheTime)
 		t.positionsCacheMutex.RUnlock()
-		log.Printf("✓ 使用缓存的持仓信息（缓存时间: %.1f秒前）", cacheAge.Seconds())
+		t.log().Debug("使用缓存的持仓信息", "cache_age_seconds", cacheAge.Seconds())
 		return t.cachedPositions, nil
 	}
 	t.positionsCacheMutex.RUnlock()
 
 	// 缓存过期或不存在，调用API
-	log.Printf("🔄 缓存过期，正在调用币安API获取持仓信息...")
+	t.log().Debug("持仓缓存过期，正在调用币安API获取持仓信息")
 	positions, err := t.client.NewGetPositionRiskService().Do(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("获取持仓失败: %w", err)
@@ -167,20 +208,20 @@ func (t *FuturesTrader) SetMarginMode(symbol string, isCrossMargin bool) error {
 	if err != nil {
 		// 如果错误信息包含"No need to change"，说明仓位模式已经是目标值
 		if contains(err.Error(), "No need to change margin type") {
-			log.Printf("  ✓ %s 仓位模式已是 %s", symbol, marginModeStr)
+			t.log().Debug("仓位模式无需切换", "symbol", symbol, "margin_mode", marginModeStr)
 			return nil
 		}
 		// 如果有持仓，无法更改仓位模式，但不影响交易
 		if contains(err.Error(), "Margin type cannot be changed if there exists position") {
-			log.Printf("  ⚠️ %s 有持仓，无法更改仓位模式，继续使用当前模式", symbol)
+			t.log().Warn("有持仓，无法更改仓位模式，继续使用当前模式", "symbol", symbol)
 			return nil
 		}
-		log.Printf("  ⚠️ 设置仓位模式失败: %v", err)
+		t.log().Warn("设置仓位模式失败", "symbol", symbol, "error", err)
 		// 不返回错误，让交易继续
 		return nil
 	}
 
-	log.Printf("  ✓ %s 仓位模式已设置为 %s", symbol, marginModeStr)
+	t.log().Info("仓位模式已设置", "symbol", symbol, "margin_mode", marginModeStr)
 	return nil
 }
 
@@ -202,7 +243,7 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 
 	// 如果当前杠杆已经是目标杠杆，跳过
 	if currentLeverage == leverage && currentLeverage > 0 {
-		log.Printf("  ✓ %s 杠杆已是 %dx，无需切换", symbol, leverage)
+		t.log().Debug("杠杆无需切换", "symbol", symbol, "leverage", leverage)
 		return nil
 	}
 
@@ -215,16 +256,16 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 	if err != nil {
 		// 如果错误信息包含"No need to change"，说明杠杆已经是目标值
 		if contains(err.Error(), "No need to change") {
-			log.Printf("  ✓ %s 杠杆已是 %dx", symbol, leverage)
+			t.log().Debug("杠杆无需切换", "symbol", symbol, "leverage", leverage)
 			return nil
 		}
 		return fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
-	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	t.log().Info("杠杆已切换", "symbol", symbol, "leverage", leverage)
 
 	// 切换杠杆后等待5秒（避免冷却期错误）
-	log.Printf("  ⏱ 等待5秒冷却期...")
+	t.log().Debug("等待5秒冷却期")
 	time.Sleep(5 * time.Second)
 
 	return nil
@@ -234,7 +275,7 @@ func (t *FuturesTrader) SetLeverage(symbol string, leverage int) error {
 func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
+		t.log().Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "error", err)
 	}
 
 	// 设置杠杆
@@ -244,11 +285,14 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 
 	// 注意：仓位模式应该由调用方（AutoTrader）在开仓前通过 SetMarginMode 设置
 
-	// 格式化数量到正确精度
+	// 格式化数量到正确精度，并在下单前校验最小数量/最小名义价值
 	quantityStr, err := t.FormatQuantity(symbol, quantity)
 	if err != nil {
 		return nil, err
 	}
+	if err := t.validateOrder(symbol, quantity); err != nil {
+		return nil, err
+	}
 
 	// 创建市价买入订单
 	order, err := t.client.NewCreateOrderService().
@@ -257,19 +301,20 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 		PositionSide(futures.PositionSideTypeLong).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交均价，用于计算滑点
 		Do(context.Background())
 
 	if err != nil {
 		return nil, fmt.Errorf("开多仓失败: %w", err)
 	}
 
-	log.Printf("✓ 开多仓成功: %s 数量: %s", symbol, quantityStr)
-	log.Printf("  订单ID: %d", order.OrderID)
+	t.log().Info("开多仓成功", "symbol", symbol, "quantity", quantityStr, "order_id", order.OrderID)
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"] = order.AvgPrice
 	return result, nil
 }
 
@@ -277,7 +322,7 @@ func (t *FuturesTrader) OpenLong(symbol string, quantity float64, leverage int)
 func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单（清理旧的止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败（可能没有委托单）: %v", err)
+		t.log().Warn("取消旧委托单失败（可能没有委托单）", "symbol", symbol, "error", err)
 	}
 
 	// 设置杠杆
@@ -287,11 +332,14 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 
 	// 注意：仓位模式应该由调用方（AutoTrader）在开仓前通过 SetMarginMode 设置
 
-	// 格式化数量到正确精度
+	// 格式化数量到正确精度，并在下单前校验最小数量/最小名义价值
 	quantityStr, err := t.FormatQuantity(symbol, quantity)
 	if err != nil {
 		return nil, err
 	}
+	if err := t.validateOrder(symbol, quantity); err != nil {
+		return nil, err
+	}
 
 	// 创建市价卖出订单
 	order, err := t.client.NewCreateOrderService().
@@ -300,19 +348,20 @@ func (t *FuturesTrader) OpenShort(symbol string, quantity float64, leverage int)
 		PositionSide(futures.PositionSideTypeShort).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交均价，用于计算滑点
 		Do(context.Background())
 
 	if err != nil {
 		return nil, fmt.Errorf("开空仓失败: %w", err)
 	}
 
-	log.Printf("✓ 开空仓成功: %s 数量: %s", symbol, quantityStr)
-	log.Printf("  订单ID: %d", order.OrderID)
+	t.log().Info("开空仓成功", "symbol", symbol, "quantity", quantityStr, "order_id", order.OrderID)
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"] = order.AvgPrice
 	return result, nil
 }
 
@@ -350,23 +399,25 @@ func (t *FuturesTrader) CloseLong(symbol string, quantity float64) (map[string]i
 		PositionSide(futures.PositionSideTypeLong).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交均价，用于计算滑点
 		Do(context.Background())
 
 	if err != nil {
 		return nil, fmt.Errorf("平多仓失败: %w", err)
 	}
 
-	log.Printf("✓ 平多仓成功: %s 数量: %s", symbol, quantityStr)
+	t.log().Info("平多仓成功", "symbol", symbol, "quantity", quantityStr)
 
 	// 平仓后取消该币种的所有挂单（止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		t.log().Warn("取消挂单失败", "symbol", symbol, "error", err)
 	}
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"] = order.AvgPrice
 	return result, nil
 }
 
@@ -404,23 +455,25 @@ func (t *FuturesTrader) CloseShort(symbol string, quantity float64) (map[string]
 		PositionSide(futures.PositionSideTypeShort).
 		Type(futures.OrderTypeMarket).
 		Quantity(quantityStr).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT). // 返回成交均价，用于计算滑点
 		Do(context.Background())
 
 	if err != nil {
 		return nil, fmt.Errorf("平空仓失败: %w", err)
 	}
 
-	log.Printf("✓ 平空仓成功: %s 数量: %s", symbol, quantityStr)
+	t.log().Info("平空仓成功", "symbol", symbol, "quantity", quantityStr)
 
 	// 平仓后取消该币种的所有挂单（止损止盈单）
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		t.log().Warn("取消挂单失败", "symbol", symbol, "error", err)
 	}
 
 	result := make(map[string]interface{})
 	result["orderId"] = order.OrderID
 	result["symbol"] = order.Symbol
 	result["status"] = order.Status
+	result["avgPrice"] = order.AvgPrice
 	return result, nil
 }
 
@@ -434,10 +487,65 @@ func (t *FuturesTrader) CancelAllOrders(symbol string) error {
 		return fmt.Errorf("取消挂单失败: %w", err)
 	}
 
-	log.Printf("  ✓ 已取消 %s 的所有挂单", symbol)
+	t.log().Info("已取消所有挂单", "symbol", symbol)
 	return nil
 }
 
+// CancelOrder 按订单ID撤单
+func (t *FuturesTrader) CancelOrder(symbol string, orderID int64) error {
+	_, err := t.client.NewCancelOrderService().
+		Symbol(symbol).
+		OrderID(orderID).
+		Do(context.Background())
+	if err != nil {
+		return fmt.Errorf("撤单失败: %w", err)
+	}
+	return nil
+}
+
+// GetFundingFees 获取该币种自 since 起的资金费净收入（负数表示支付）
+func (t *FuturesTrader) GetFundingFees(symbol string, since time.Time) (float64, error) {
+	incomes, err := t.client.NewGetIncomeHistoryService().
+		Symbol(symbol).
+		IncomeType("FUNDING_FEE").
+		StartTime(since.UnixMilli()).
+		Limit(1000).
+		Do(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("获取资金费流水失败: %w", err)
+	}
+
+	total := 0.0
+	for _, income := range incomes {
+		amount, _ := strconv.ParseFloat(income.Income, 64)
+		total += amount
+	}
+	return total, nil
+}
+
+// GetOpenOrders 获取所有币种的当前挂单
+func (t *FuturesTrader) GetOpenOrders() ([]map[string]interface{}, error) {
+	orders, err := t.client.NewListOpenOrdersService().Do(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	result := make([]map[string]interface{}, 0, len(orders))
+	for _, order := range orders {
+		stopPrice, _ := strconv.ParseFloat(order.StopPrice, 64)
+		quantity, _ := strconv.ParseFloat(order.OrigQuantity, 64)
+		result = append(result, map[string]interface{}{
+			"orderId":      order.OrderID,
+			"symbol":       order.Symbol,
+			"positionSide": string(order.PositionSide),
+			"type":         string(order.Type),
+			"stopPrice":    stopPrice,
+			"quantity":     quantity,
+		})
+	}
+	return result, nil
+}
+
 // GetMarketPrice 获取市场价格
 func (t *FuturesTrader) GetMarketPrice(symbol string) (float64, error) {
 	prices, err := t.client.NewListPricesService().Symbol(symbol).Do(context.Background())
@@ -489,7 +597,7 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		Side(side).
 		PositionSide(posSide).
 		Type(futures.OrderTypeStopMarket).
-		StopPrice(fmt.Sprintf("%.8f", stopPrice)).
+		StopPrice(t.formatPrice(symbol, stopPrice)).
 		Quantity(quantityStr).
 		WorkingType(futures.WorkingTypeContractPrice).
 		ClosePosition(true).
@@ -499,7 +607,7 @@ func (t *FuturesTrader) SetStopLoss(symbol string, positionSide string, quantity
 		return fmt.Errorf("设置止损失败: %w", err)
 	}
 
-	log.Printf("  止损价设置: %.4f", stopPrice)
+	t.log().Info("止损价设置", "symbol", symbol, "side", positionSide, "stop_price", stopPrice)
 	return nil
 }
 
@@ -527,7 +635,7 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 		Side(side).
 		PositionSide(posSide).
 		Type(futures.OrderTypeTakeProfitMarket).
-		StopPrice(fmt.Sprintf("%.8f", takeProfitPrice)).
+		StopPrice(t.formatPrice(symbol, takeProfitPrice)).
 		Quantity(quantityStr).
 		WorkingType(futures.WorkingTypeContractPrice).
 		ClosePosition(true).
@@ -537,33 +645,88 @@ func (t *FuturesTrader) SetTakeProfit(symbol string, positionSide string, quanti
 		return fmt.Errorf("设置止盈失败: %w", err)
 	}
 
-	log.Printf("  止盈价设置: %.4f", takeProfitPrice)
+	t.log().Info("止盈价设置", "symbol", symbol, "side", positionSide, "take_profit_price", takeProfitPrice)
 	return nil
 }
 
-// GetSymbolPrecision 获取交易对的数量精度
-func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
+// getSymbolFilters 获取交易对的下单规则（缓存1小时，一次拉取全部交易对）
+func (t *FuturesTrader) getSymbolFilters(symbol string) (*SymbolFilters, error) {
+	t.filtersCacheMutex.RLock()
+	if t.symbolFilters != nil && time.Since(t.filtersCacheTime) < time.Hour {
+		f, ok := t.symbolFilters[symbol]
+		t.filtersCacheMutex.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("未找到 %s 的交易规则", symbol)
+		}
+		return f, nil
+	}
+	t.filtersCacheMutex.RUnlock()
+
 	exchangeInfo, err := t.client.NewExchangeInfoService().Do(context.Background())
 	if err != nil {
-		return 0, fmt.Errorf("获取交易规则失败: %w", err)
-	}
-
-	for _, s := range exchangeInfo.Symbols {
-		if s.Symbol == symbol {
-			// 从LOT_SIZE filter获取精度
-			for _, filter := range s.Filters {
-				if filter["filterType"] == "LOT_SIZE" {
-					stepSize := filter["stepSize"].(string)
-					precision := calculatePrecision(stepSize)
-					log.Printf("  %s 数量精度: %d (stepSize: %s)", symbol, precision, stepSize)
-					return precision, nil
-				}
-			}
+		return nil, fmt.Errorf("获取交易规则失败: %w", err)
+	}
+
+	filters := make(map[string]*SymbolFilters, len(exchangeInfo.Symbols))
+	for i := range exchangeInfo.Symbols {
+		s := &exchangeInfo.Symbols[i]
+		f := &SymbolFilters{Symbol: s.Symbol}
+		if lot := s.LotSizeFilter(); lot != nil {
+			f.StepSize, _ = strconv.ParseFloat(lot.StepSize, 64)
+			f.MinQty, _ = strconv.ParseFloat(lot.MinQuantity, 64)
 		}
+		if price := s.PriceFilter(); price != nil {
+			f.TickSize, _ = strconv.ParseFloat(price.TickSize, 64)
+		}
+		if notional := s.MinNotionalFilter(); notional != nil {
+			f.MinNotional, _ = strconv.ParseFloat(notional.Notional, 64)
+		}
+		filters[s.Symbol] = f
+	}
+
+	t.filtersCacheMutex.Lock()
+	t.symbolFilters = filters
+	t.filtersCacheTime = time.Now()
+	t.filtersCacheMutex.Unlock()
+
+	f, ok := filters[symbol]
+	if !ok {
+		return nil, fmt.Errorf("未找到 %s 的交易规则", symbol)
 	}
+	return f, nil
+}
 
-	log.Printf("  ⚠ %s 未找到精度信息，使用默认精度3", symbol)
-	return 3, nil // 默认精度为3
+// GetSymbolPrecision 获取交易对的数量精度
+func (t *FuturesTrader) GetSymbolPrecision(symbol string) (int, error) {
+	filters, err := t.getSymbolFilters(symbol)
+	if err != nil || filters.StepSize <= 0 {
+		t.log().Warn("未找到精度信息，使用默认精度3", "symbol", symbol)
+		return 3, nil // 默认精度为3
+	}
+	return stepPrecision(filters.StepSize), nil
+}
+
+// validateOrder 下单前校验数量和名义价值，避免被交易所拒单
+func (t *FuturesTrader) validateOrder(symbol string, quantity float64) error {
+	filters, err := t.getSymbolFilters(symbol)
+	if err != nil {
+		t.log().Warn("获取交易规则失败，跳过下单前校验", "symbol", symbol, "error", err)
+		return nil
+	}
+	price, err := t.GetMarketPrice(symbol)
+	if err != nil {
+		price = 0 // 价格获取失败时只校验最小数量
+	}
+	return filters.ValidateOrder(quantity, price)
+}
+
+// formatPrice 按tickSize格式化价格（获取规则失败时保留8位小数）
+func (t *FuturesTrader) formatPrice(symbol string, price float64) string {
+	filters, err := t.getSymbolFilters(symbol)
+	if err != nil || filters.TickSize <= 0 {
+		return fmt.Sprintf("%.8f", price)
+	}
+	return filters.FormatPrice(price)
 }
 
 // calculatePrecision 从stepSize计算精度
@@ -609,16 +772,14 @@ func trimTrailingZeros(s string) string {
 	return s
 }
 
-// FormatQuantity 格式化数量到正确的精度
+// FormatQuantity 格式化数量到正确的精度（按stepSize向下取整）
 func (t *FuturesTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
-	precision, err := t.GetSymbolPrecision(symbol)
-	if err != nil {
+	filters, err := t.getSymbolFilters(symbol)
+	if err != nil || filters.StepSize <= 0 {
 		// 如果获取失败，使用默认格式
 		return fmt.Sprintf("%.3f", quantity), nil
 	}
-
-	format := fmt.Sprintf("%%.%df", precision)
-	return fmt.Sprintf(format, quantity), nil
+	return filters.FormatQuantity(quantity), nil
 }
 
 // 辅助函数
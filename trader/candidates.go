@@ -0,0 +1,117 @@
+package trader
+
+import (
+	"nofx/decision"
+	"nofx/pool"
+	"slices"
+	"time"
+)
+
+// CandidateOffered 候选币种已发给AI（CandidateStatus.Status）
+const CandidateOffered = "offered"
+
+// CandidateStatus 一个候选币种在最近一个周期的处理结果
+type CandidateStatus struct {
+	decision.CandidateCoin
+	Status string `json:"status"` // "offered" 或未发给AI的原因（decision.Skip*）
+}
+
+// CandidateReport 最近一个周期的有效候选列表，用于排查AI看到了哪些币种
+type CandidateReport struct {
+	Cycle         int                `json:"cycle"`
+	At            time.Time          `json:"at"`
+	PoolFetchedAt time.Time          `json:"pool_fetched_at"` // 候选币种池的获取时间，设置了刷新间隔时可能早于本周期
+	MaxAge        string             `json:"max_age"`         // 信号源数据的最长有效期，0表示不过期
+	Candidates    []CandidateStatus  `json:"candidates"`      // 按候选列表顺序
+	Dropped       []pool.DroppedCoin `json:"dropped"`         // 信号源数据过期而未进入候选列表的币种
+}
+
+// candidatePool 交易员缓存的候选币种池
+type candidatePool struct {
+	coins     []decision.CandidateCoin
+	dropped   []pool.DroppedCoin
+	fetchedAt time.Time
+}
+
+// loadCandidatePool 获取候选币种池并合并用户信号源；设置了刷新间隔时，间隔内复用上一次的结果
+// 返回的列表是副本，之后合并外部信号、按行情排序不影响缓存
+func (at *AutoTrader) loadCandidatePool(now time.Time) ([]decision.CandidateCoin, error) {
+	cached := at.candidatePool
+	if interval := at.config.PoolRefreshInterval; interval > 0 && !cached.fetchedAt.IsZero() && now.Sub(cached.fetchedAt) < interval {
+		at.log().Debug("复用候选币种池", "fetched_at", cached.fetchedAt, "refresh_interval", interval, "count", len(cached.coins))
+		return cloneCandidates(cached.coins), nil
+	}
+
+	coins, dropped, err := at.getCandidateCoins()
+	if err != nil {
+		return nil, err
+	}
+	coins, sourceDropped := at.mergeSourceCandidates(coins)
+	at.candidatePool = candidatePool{coins: coins, dropped: append(dropped, sourceDropped...), fetchedAt: now}
+	return cloneCandidates(coins), nil
+}
+
+// cloneCandidates 复制候选币种列表（包括来源列表）
+func cloneCandidates(coins []decision.CandidateCoin) []decision.CandidateCoin {
+	clone := make([]decision.CandidateCoin, len(coins))
+	for i, coin := range coins {
+		coin.Sources = slices.Clone(coin.Sources)
+		clone[i] = coin
+	}
+	return clone
+}
+
+// newCandidateReport 根据已获取行情的决策上下文生成候选报告
+func newCandidateReport(cycle int, ctx *decision.Context, cp candidatePool) *CandidateReport {
+	report := &CandidateReport{
+		Cycle:         cycle,
+		At:            time.Now().UTC(),
+		PoolFetchedAt: cp.fetchedAt.UTC(),
+		MaxAge:        pool.MaxDataAge().String(),
+		Candidates:    make([]CandidateStatus, 0, len(ctx.CandidateCoins)),
+		Dropped:       cp.dropped,
+	}
+	for _, coin := range ctx.CandidateCoins {
+		status := CandidateOffered
+		if reason, ok := ctx.SkippedCandidates[coin.Symbol]; ok {
+			status = reason
+		} else if _, ok := ctx.MarketDataMap[coin.Symbol]; !ok {
+			status = decision.SkipFetchFailed
+		}
+		report.Candidates = append(report.Candidates, CandidateStatus{CandidateCoin: coin, Status: status})
+	}
+	if report.Dropped == nil {
+		report.Dropped = []pool.DroppedCoin{}
+	}
+	return report
+}
+
+// GetCandidateReport 最近一个周期的候选报告，还没有完成过决策周期时为nil
+func (at *AutoTrader) GetCandidateReport() *CandidateReport {
+	at.candidatesMu.Lock()
+	defer at.candidatesMu.Unlock()
+	return at.candidateReport
+}
+
+// mergePoolCandidates 把信号源合并后的候选币种加入列表，已在列表中的币种追加来源并累加得分
+func mergePoolCandidates(coins []decision.CandidateCoin, candidates []pool.Candidate) []decision.CandidateCoin {
+	index := make(map[string]int, len(coins))
+	for i, coin := range coins {
+		index[coin.Symbol] = i
+	}
+	for _, c := range candidates {
+		i, ok := index[c.Symbol]
+		if !ok {
+			index[c.Symbol] = len(coins)
+			coins = append(coins, decision.CandidateCoin{Symbol: c.Symbol, Sources: c.Sources, Score: c.Score})
+			continue
+		}
+		for _, source := range c.Sources {
+			if !slices.Contains(coins[i].Sources, source) {
+				coins[i].Sources = append(coins[i].Sources, source)
+			}
+		}
+		coins[i].Score += c.Score
+	}
+	return coins
+}
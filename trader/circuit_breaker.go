@@ -0,0 +1,131 @@
+package trader
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	circuitBreakerMu      sync.RWMutex
+	circuitBreakerDropPct = 10.0             // 窗口内净值回撤超过此百分比触发熔断（<=0 关闭）
+	circuitBreakerWindow  = 30 * time.Minute // 回撤统计窗口
+)
+
+// SetEquityCircuitBreaker 设置净值熔断：window 时间内净值从高点回撤超过 dropPct% 时暂停开仓（dropPct<=0 关闭）
+func SetEquityCircuitBreaker(dropPct float64, window time.Duration) error {
+	if dropPct >= 100 {
+		return fmt.Errorf("熔断回撤百分比必须小于100: %.2f", dropPct)
+	}
+	if dropPct > 0 && window <= 0 {
+		return fmt.Errorf("熔断统计窗口必须大于0")
+	}
+	circuitBreakerMu.Lock()
+	defer circuitBreakerMu.Unlock()
+	circuitBreakerDropPct = dropPct
+	circuitBreakerWindow = window
+	return nil
+}
+
+// getEquityCircuitBreaker 获取当前熔断参数
+func getEquityCircuitBreaker() (float64, time.Duration) {
+	circuitBreakerMu.RLock()
+	defer circuitBreakerMu.RUnlock()
+	return circuitBreakerDropPct, circuitBreakerWindow
+}
+
+// equitySample 一次净值采样
+type equitySample struct {
+	time   time.Time
+	equity float64
+}
+
+// CircuitBreakerTrip 熔断触发记录（需要手动恢复）
+type CircuitBreakerTrip struct {
+	Time       time.Time `json:"time"`
+	PeakEquity float64   `json:"peak_equity"` // 窗口内最高净值
+	Equity     float64   `json:"equity"`      // 触发时净值
+	DropPct    float64   `json:"drop_pct"`
+	Window     string    `json:"window"`
+}
+
+// checkCircuitBreaker 记录本周期净值，窗口内回撤超过阈值时触发熔断；已触发时返回 true
+func (at *AutoTrader) checkCircuitBreaker(equity float64, now time.Time) bool {
+	at.breakerMu.Lock()
+	defer at.breakerMu.Unlock()
+
+	if at.breakerTrip != nil {
+		return true
+	}
+	dropPct, window := getEquityCircuitBreaker()
+	if dropPct <= 0 {
+		at.equitySamples = nil
+		return false
+	}
+
+	kept := at.equitySamples[:0]
+	for _, s := range at.equitySamples {
+		if now.Sub(s.time) <= window {
+			kept = append(kept, s)
+		}
+	}
+	at.equitySamples = append(kept, equitySample{time: now, equity: equity})
+
+	peak := 0.0
+	for _, s := range at.equitySamples {
+		if s.equity > peak {
+			peak = s.equity
+		}
+	}
+	if peak <= 0 {
+		return false
+	}
+	drop := (peak - equity) / peak * 100
+	if drop < dropPct {
+		return false
+	}
+
+	at.breakerTrip = &CircuitBreakerTrip{
+		Time:       now,
+		PeakEquity: peak,
+		Equity:     equity,
+		DropPct:    drop,
+		Window:     window.String(),
+	}
+	at.log().Error("净值熔断，已暂停开仓，需手动恢复",
+		"window", window, "peak", peak, "equity", equity, "drop_pct", drop)
+	publishEvent(at.id, EventDrawdownAlert, *at.breakerTrip)
+	return true
+}
+
+// ResumeFromCircuitBreaker 手动解除熔断，清空净值采样后重新开始统计
+func (at *AutoTrader) ResumeFromCircuitBreaker() error {
+	at.breakerMu.Lock()
+	if at.breakerTrip == nil {
+		at.breakerMu.Unlock()
+		return fmt.Errorf("交易员未处于熔断状态")
+	}
+	at.breakerTrip = nil
+	at.equitySamples = nil
+	at.breakerMu.Unlock()
+
+	at.log().Info("已手动解除净值熔断")
+	// 运行中的交易员在下个周期结束时保存，避免和周期并发读写状态
+	if !at.isRunning {
+		at.saveRuntimeState()
+	}
+	at.publishStatus()
+	return nil
+}
+
+// GetCircuitBreakerTrip 获取当前熔断记录（未熔断时为nil）
+func (at *AutoTrader) GetCircuitBreakerTrip() *CircuitBreakerTrip {
+	at.breakerMu.Lock()
+	defer at.breakerMu.Unlock()
+	return at.breakerTrip
+}
+
+// isOpenAction 是否为开仓动作
+func isOpenAction(action string) bool {
+	return action == "open_long" || action == "open_short"
+}
@@ -0,0 +1,54 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckCircuitBreaker(t *testing.T) {
+	defer SetEquityCircuitBreaker(10, 30*time.Minute)
+	if err := SetEquityCircuitBreaker(10, 30*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetEquityCircuitBreaker(120, time.Minute); err == nil {
+		t.Error("回撤百分比>=100应返回错误")
+	}
+
+	at := &AutoTrader{name: "test"}
+	start := time.Now()
+
+	// 窗口外的高点不计入
+	at.checkCircuitBreaker(1000, start)
+	if at.checkCircuitBreaker(880, start.Add(40*time.Minute)) {
+		t.Fatal("超出窗口的净值下跌不应触发熔断")
+	}
+	if at.checkCircuitBreaker(850, start.Add(45*time.Minute)) {
+		t.Fatal("窗口内回撤未达阈值不应触发熔断")
+	}
+	if !at.checkCircuitBreaker(780, start.Add(50*time.Minute)) {
+		t.Fatal("窗口内回撤超过10%应触发熔断")
+	}
+	trip := at.GetCircuitBreakerTrip()
+	if trip == nil || trip.PeakEquity != 880 || trip.Equity != 780 {
+		t.Fatalf("熔断记录错误: %+v", trip)
+	}
+
+	// 熔断后即使净值恢复也保持，直到手动恢复
+	if !at.checkCircuitBreaker(1000, start.Add(55*time.Minute)) {
+		t.Error("熔断需要手动恢复")
+	}
+	if err := at.ResumeFromCircuitBreaker(); err != nil {
+		t.Fatal(err)
+	}
+	if err := at.ResumeFromCircuitBreaker(); err == nil {
+		t.Error("未熔断时恢复应返回错误")
+	}
+	if at.checkCircuitBreaker(780, start.Add(60*time.Minute)) {
+		t.Error("恢复后应重新统计净值")
+	}
+
+	SetEquityCircuitBreaker(0, 0)
+	if at.checkCircuitBreaker(1, start.Add(61*time.Minute)) {
+		t.Error("关闭熔断后不应触发")
+	}
+}
@@ -0,0 +1,76 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/logger"
+	"time"
+)
+
+// EquitySample 一次独立于决策周期的净值采样（AI循环停止或出错时也会记录）
+type EquitySample struct {
+	Timestamp    time.Time                 `json:"timestamp"`
+	CycleNumber  int                       `json:"cycle_number"`  // 采样时最近一次决策周期编号
+	AccountState logger.AccountSnapshot    `json:"account_state"` // 与决策记录相同，TotalUnrealizedProfit 存的是总盈亏
+	Positions    []logger.PositionSnapshot `json:"positions"`
+}
+
+// SampleEquity 查询一次账户净值和持仓，不调用AI也不影响交易循环
+func (at *AutoTrader) SampleEquity(now time.Time) (*EquitySample, error) {
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return nil, fmt.Errorf("获取余额失败: %w", err)
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+	available, _ := balance["availableBalance"].(float64)
+	totalEquity := wallet + unrealized
+
+	sample := &EquitySample{Timestamp: now, CycleNumber: at.callCount}
+	marginUsed := 0.0
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		quantity, _ := pos["positionAmt"].(float64)
+		if quantity < 0 {
+			quantity = -quantity
+		}
+		markPrice, _ := pos["markPrice"].(float64)
+		entryPrice, _ := pos["entryPrice"].(float64)
+		pnl, _ := pos["unRealizedProfit"].(float64)
+		liquidation, _ := pos["liquidationPrice"].(float64)
+		leverage, _ := pos["leverage"].(float64)
+		if leverage <= 0 {
+			leverage = 10
+		}
+		marginUsed += quantity * markPrice / leverage
+
+		sample.Positions = append(sample.Positions, logger.PositionSnapshot{
+			Symbol:           symbol,
+			Side:             side,
+			PositionAmt:      quantity,
+			EntryPrice:       entryPrice,
+			MarkPrice:        markPrice,
+			UnrealizedProfit: pnl,
+			Leverage:         leverage,
+			LiquidationPrice: liquidation,
+		})
+	}
+
+	marginUsedPct := 0.0
+	if totalEquity > 0 {
+		marginUsedPct = marginUsed / totalEquity * 100
+	}
+	sample.AccountState = logger.AccountSnapshot{
+		TotalBalance:          totalEquity,
+		AvailableBalance:      available,
+		TotalUnrealizedProfit: totalEquity - at.initialBalance,
+		PositionCount:         len(positions),
+		MarginUsedPct:         marginUsedPct,
+	}
+	return sample, nil
+}
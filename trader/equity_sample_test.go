@@ -0,0 +1,35 @@
+package trader
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSampleEquity(t *testing.T) {
+	price := 100.0
+	now := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	exchange := newTestSimulatedExchange(&price, &now)
+	if _, err := exchange.OpenLong("BTCUSDT", 2, 5); err != nil {
+		t.Fatal(err)
+	}
+	price = 110
+
+	// AI循环未运行时也能采样
+	at := &AutoTrader{name: "test", trader: exchange, initialBalance: 1000, callCount: 3}
+	sample, err := at.SampleEquity(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equity := balanceOf(t, exchange) + 20
+	if math.Abs(sample.AccountState.TotalBalance-equity) > 1e-9 || math.Abs(sample.AccountState.TotalUnrealizedProfit-(equity-1000)) > 1e-9 {
+		t.Errorf("净值或总盈亏错误: %+v", sample.AccountState)
+	}
+	if sample.CycleNumber != 3 || sample.AccountState.PositionCount != 1 || len(sample.Positions) != 1 || sample.Positions[0].MarkPrice != 110 {
+		t.Errorf("持仓快照错误: %+v", sample)
+	}
+	// 保证金 = 2*110/5
+	if want := 44 / equity * 100; math.Abs(sample.AccountState.MarginUsedPct-want) > 1e-9 {
+		t.Errorf("保证金使用率 %.4f，期望 %.4f", sample.AccountState.MarginUsedPct, want)
+	}
+}
@@ -0,0 +1,189 @@
+package trader
+
+import (
+	"fmt"
+	"log/slog"
+	"nofx/decision"
+	"nofx/logger"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 推送事件类型
+const (
+	EventEquity    = "equity"    // 账户净值
+	EventDecision  = "decision"  // 新的决策记录
+	EventPositions = "positions" // 持仓变化
+	EventStatus    = "status"    // 交易员状态
+
+	// 决策周期的各个阶段
+	EventCycleStarted   = "cycle_started"
+	EventPromptBuilt    = "prompt_built"
+	EventAIResponded    = "ai_responded"
+	EventOrdersExecuted = "orders_executed"
+
+	// 交易事件（可通过 webhook 推送给外部系统）
+	EventPositionOpened = "position_opened" // 开仓成功
+	EventPositionClosed = "position_closed" // 平仓（AI平仓、交易所止损止盈、强平等）
+	EventStopHit        = "stop_hit"        // 止损单触发或强平（同时也会推送 position_closed）
+	EventTraderError    = "trader_error"    // 交易员因异常停止
+	EventDrawdownAlert  = "drawdown_alert"  // 净值回撤触发熔断
+)
+
+// TradeEventTypes 可订阅的交易事件类型
+var TradeEventTypes = []string{EventPositionOpened, EventPositionClosed, EventStopHit, EventTraderError, EventDrawdownAlert}
+
+// IsTradeEvent 是否为交易事件
+func IsTradeEvent(eventType string) bool {
+	for _, t := range TradeEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCycleEvent 是否为决策周期阶段事件
+func IsCycleEvent(eventType string) bool {
+	switch eventType {
+	case EventCycleStarted, EventPromptBuilt, EventAIResponded, EventOrdersExecuted:
+		return true
+	}
+	return false
+}
+
+// Event 交易员推送给前端的实时事件
+type Event struct {
+	Type     string      `json:"type"`
+	TraderID string      `json:"trader_id"`
+	Time     time.Time   `json:"time"`
+	Data     interface{} `json:"data"`
+}
+
+var (
+	eventMu          sync.RWMutex
+	eventSubscribers = make(map[int]chan Event)
+	nextSubscriberID int
+)
+
+// SubscribeEvents 订阅所有交易员的事件，返回事件通道和取消订阅函数（消费不及时时丢弃事件，不阻塞交易）
+func SubscribeEvents(buffer int) (<-chan Event, func()) {
+	eventMu.Lock()
+	defer eventMu.Unlock()
+
+	id := nextSubscriberID
+	nextSubscriberID++
+	ch := make(chan Event, buffer)
+	eventSubscribers[id] = ch
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			eventMu.Lock()
+			defer eventMu.Unlock()
+			delete(eventSubscribers, id)
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// publishEvent 向所有订阅者广播事件
+func publishEvent(traderID, eventType string, data interface{}) {
+	event := Event{Type: eventType, TraderID: traderID, Time: time.Now(), Data: data}
+
+	eventMu.RLock()
+	defer eventMu.RUnlock()
+	for _, ch := range eventSubscribers {
+		select {
+		case ch <- event:
+		default:
+			slog.Warn("事件订阅者处理过慢，丢弃事件", "trader_id", traderID, "type", eventType)
+		}
+	}
+}
+
+// hasEventSubscribers 是否有订阅者（没有时跳过需要请求交易所的推送）
+func hasEventSubscribers() bool {
+	eventMu.RLock()
+	defer eventMu.RUnlock()
+	return len(eventSubscribers) > 0
+}
+
+// publishStatus 推送当前状态
+func (at *AutoTrader) publishStatus() {
+	if !hasEventSubscribers() {
+		return
+	}
+	publishEvent(at.id, EventStatus, at.GetStatus())
+}
+
+// publishPositionsIfChanged 持仓（币种/方向/数量）与上次推送不同时推送最新持仓
+func (at *AutoTrader) publishPositionsIfChanged() {
+	if !hasEventSubscribers() {
+		return
+	}
+	positions, err := at.GetPositions()
+	if err != nil {
+		at.log().Warn("获取持仓失败，跳过推送", "error", err)
+		return
+	}
+
+	keys := make([]string, 0, len(positions))
+	for _, pos := range positions {
+		keys = append(keys, fmt.Sprintf("%v_%v_%v", pos["symbol"], pos["side"], pos["quantity"]))
+	}
+	sort.Strings(keys)
+	signature := strings.Join(keys, ",")
+	if signature == at.lastPositionsSig {
+		return
+	}
+	at.lastPositionsSig = signature
+	publishEvent(at.id, EventPositions, positions)
+}
+
+// PositionOpenedEvent 开仓事件数据
+type PositionOpenedEvent struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`
+	Quantity   float64 `json:"quantity"`
+	Price      float64 `json:"price"`
+	Leverage   int     `json:"leverage"`
+	StopLoss   float64 `json:"stop_loss"`
+	TakeProfit float64 `json:"take_profit"`
+}
+
+// PositionClosedEvent 平仓事件数据
+type PositionClosedEvent struct {
+	Symbol     string   `json:"symbol"`
+	Side       string   `json:"side"`
+	Price      float64  `json:"price"`
+	ExitReason string   `json:"exit_reason"`
+	PnL        *float64 `json:"pnl,omitempty"` // 回填开仓结果失败时为空
+	RMultiple  *float64 `json:"r_multiple,omitempty"`
+}
+
+// publishPositionClosed 推送平仓事件，止损和强平额外推送 stop_hit
+func (at *AutoTrader) publishPositionClosed(event PositionClosedEvent) {
+	publishEvent(at.id, EventPositionClosed, event)
+	if event.ExitReason == logger.ExitReasonStopLoss || event.ExitReason == logger.ExitReasonLiquidation {
+		publishEvent(at.id, EventStopHit, event)
+	}
+}
+
+// publishAIResponded 推送AI响应阶段：耗时、解析出的决策或失败原因
+func (at *AutoTrader) publishAIResponded(cycle int, start time.Time, fd *decision.FullDecision, err error) {
+	data := map[string]interface{}{"cycle": cycle}
+	if !start.IsZero() {
+		data["duration_ms"] = time.Since(start).Milliseconds()
+	}
+	if fd != nil {
+		data["decisions"] = fd.Decisions
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	publishEvent(at.id, EventAIResponded, data)
+}
@@ -0,0 +1,59 @@
+package trader
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSubscribeEvents(t *testing.T) {
+	if hasEventSubscribers() {
+		t.Fatal("初始不应有订阅者")
+	}
+	events, unsubscribe := SubscribeEvents(1)
+	if !hasEventSubscribers() {
+		t.Fatal("订阅后应有订阅者")
+	}
+
+	publishEvent("t1", EventStatus, "first")
+	publishEvent("t1", EventStatus, "dropped") // 缓冲已满，丢弃而不是阻塞
+	if e := <-events; e.TraderID != "t1" || e.Type != EventStatus || e.Data != "first" {
+		t.Errorf("事件内容错误: %+v", e)
+	}
+	select {
+	case e := <-events:
+		t.Errorf("缓冲满时应丢弃事件: %+v", e)
+	default:
+	}
+
+	unsubscribe()
+	unsubscribe() // 重复取消不应panic
+	if _, ok := <-events; ok {
+		t.Error("取消订阅后通道应关闭")
+	}
+	if hasEventSubscribers() {
+		t.Error("取消订阅后不应有订阅者")
+	}
+}
+
+func TestPublishAIResponded(t *testing.T) {
+	events, unsubscribe := SubscribeEvents(1)
+	defer unsubscribe()
+
+	at := &AutoTrader{id: "t1"}
+	at.publishAIResponded(3, time.Time{}, nil, fmt.Errorf("调用AI API失败"))
+	e := <-events
+	if !IsCycleEvent(e.Type) || e.Type != EventAIResponded {
+		t.Fatalf("应为AI响应阶段事件: %+v", e)
+	}
+	data := e.Data.(map[string]interface{})
+	if data["cycle"] != 3 || data["error"] != "调用AI API失败" {
+		t.Errorf("事件内容错误: %+v", data)
+	}
+	if _, ok := data["duration_ms"]; ok {
+		t.Error("prompt未构建时不应有耗时")
+	}
+	if IsCycleEvent(EventStatus) {
+		t.Error("状态事件不属于决策周期阶段")
+	}
+}
@@ -0,0 +1,144 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"nofx/decision"
+	"nofx/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrorClass 下单失败的原因分类，写入决策日志并在下个周期告知AI
+type ErrorClass string
+
+const (
+	ErrClassInsufficientMargin ErrorClass = "insufficient_margin" // 保证金或余额不足
+	ErrClassPrecision          ErrorClass = "precision"           // 数量/价格精度、最小下单量或最小名义价值不满足
+	ErrClassRateLimit          ErrorClass = "rate_limit"          // 请求过于频繁
+	ErrClassNetwork            ErrorClass = "network"             // 网络错误或超时，订单可能已到达交易所
+	ErrClassRejected           ErrorClass = "rejected"            // 交易所因其他原因拒绝
+)
+
+// errorPatterns 各交易所错误信息中的关键字（小写），按顺序匹配
+// 币安/Aster 的错误格式为 "<APIError> code=-2019, msg=Margin is insufficient."
+var errorPatterns = []struct {
+	class    ErrorClass
+	patterns []string
+}{
+	{ErrClassRateLimit, []string{"code=-1003", "code=-1015", "too many", "rate limit"}},
+	{ErrClassInsufficientMargin, []string{"code=-2019", "code=-2018", "insufficient", "not enough margin"}},
+	{ErrClassPrecision, []string{"code=-1111", "code=-1013", "code=-4003", "code=-4164", "precision", "lot_size", "min_notional", "invalid size", "tick size", "quantity less than"}},
+	{ErrClassNetwork, []string{"timeout", "connection reset", "connection refused", "no such host", "broken pipe", "unexpected eof"}},
+}
+
+// ExecutionError 分类后的下单错误
+type ExecutionError struct {
+	Class    ErrorClass
+	Op       string // open_long / open_short / close_long / close_short
+	Attempts int
+	Err      error
+}
+
+func (e *ExecutionError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%s 失败（%s，已尝试%d次）: %v", e.Op, e.Class, e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("%s 失败（%s）: %v", e.Op, e.Class, e.Err)
+}
+
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError 判断交易所错误的类别
+func ClassifyError(err error) ErrorClass {
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrClassNetwork
+	}
+	msg := strings.ToLower(err.Error())
+	for _, p := range errorPatterns {
+		for _, pattern := range p.patterns {
+			if strings.Contains(msg, pattern) {
+				return p.class
+			}
+		}
+	}
+	return ErrClassRejected
+}
+
+// ErrorClassOf 错误链中的下单错误类别，不是下单错误时返回空
+func ErrorClassOf(err error) ErrorClass {
+	var execErr *ExecutionError
+	if errors.As(err, &execErr) {
+		return execErr.Class
+	}
+	return ""
+}
+
+// recordFailedOrder 记录执行失败的决策，下个周期的prompt中告知AI失败原因
+func (at *AutoTrader) recordFailedOrder(action *logger.DecisionAction) {
+	class := action.ErrorClass
+	if class == "" {
+		class = "other" // 下单前的检查未通过（如已有同方向持仓）
+	}
+	at.failedOrders = append(at.failedOrders, decision.FailedOrder{
+		Symbol:     action.Symbol,
+		Action:     action.Action,
+		ErrorClass: class,
+		Error:      action.Error,
+	})
+}
+
+// RetryPolicy 下单重试策略：限频总是重试；网络错误只重试平仓，
+// 开仓请求可能已经成交，重试会重复开仓（下个周期的持仓检查会发现已成交的订单）
+type RetryPolicy struct {
+	MaxAttempts int           // 最多尝试次数（含第一次），<=1 不重试
+	Backoff     time.Duration // 第一次重试前的等待，之后每次翻倍
+}
+
+var (
+	retryPolicyMu    sync.RWMutex
+	orderRetryPolicy = RetryPolicy{MaxAttempts: 3, Backoff: time.Second}
+	retrySleep       = time.Sleep // 测试时替换
+)
+
+// SetOrderRetryPolicy 设置下单重试策略
+func SetOrderRetryPolicy(policy RetryPolicy) error {
+	if policy.Backoff < 0 {
+		return fmt.Errorf("重试等待时间不能为负数")
+	}
+	retryPolicyMu.Lock()
+	defer retryPolicyMu.Unlock()
+	orderRetryPolicy = policy
+	return nil
+}
+
+// getOrderRetryPolicy 获取当前下单重试策略
+func getOrderRetryPolicy() RetryPolicy {
+	retryPolicyMu.RLock()
+	defer retryPolicyMu.RUnlock()
+	return orderRetryPolicy
+}
+
+// retryable 该类错误是否可以重试
+func retryable(class ErrorClass, op string) bool {
+	switch class {
+	case ErrClassRateLimit:
+		return true
+	case ErrClassNetwork:
+		return !isOpenAction(op)
+	}
+	return false
+}
+
+// placeOrder 按重试策略调用交易所下单，失败时返回 *ExecutionError
+// quantity、price 为下单数量和当时的行情价，只用于订单记录
+func (at *AutoTrader) placeOrder(op, symbol string, quantity, price float64, place func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	return at.submitOrder(op, symbol, quantity, price, getOrderRetryPolicy(), place)
+}
@@ -0,0 +1,88 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want ErrorClass
+	}{
+		{errors.New("<APIError> code=-2019, msg=Margin is insufficient."), ErrClassInsufficientMargin},
+		{errors.New("Insufficient margin to place order"), ErrClassInsufficientMargin},
+		{errors.New("<APIError> code=-1111, msg=Precision is over the maximum defined for this asset."), ErrClassPrecision},
+		{errors.New("<APIError> code=-4164, msg=Order's notional must be no smaller than 5"), ErrClassPrecision},
+		{errors.New("<APIError> code=-1003, msg=Too many requests."), ErrClassRateLimit},
+		{fmt.Errorf("下单失败: %w", errors.New("read tcp: connection reset by peer")), ErrClassNetwork},
+		{errors.New("<APIError> code=-4131, msg=The counterparty's best price does not meet the PERCENT_PRICE filter limit."), ErrClassRejected},
+	}
+	for _, c := range cases {
+		if got := ClassifyError(c.err); got != c.want {
+			t.Errorf("ClassifyError(%q) = %s, want %s", c.err, got, c.want)
+		}
+	}
+}
+
+func TestPlaceOrderRetry(t *testing.T) {
+	var slept []time.Duration
+	retrySleep = func(d time.Duration) { slept = append(slept, d) }
+	t.Cleanup(func() { retrySleep = time.Sleep })
+	if err := SetOrderRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetOrderRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Second}) })
+
+	at := &AutoTrader{name: "test"}
+	failing := func(errs ...error) (func() (map[string]interface{}, error), *int) {
+		calls := 0
+		return func() (map[string]interface{}, error) {
+			calls++
+			if calls <= len(errs) {
+				return nil, errs[calls-1]
+			}
+			return map[string]interface{}{"orderId": int64(1)}, nil
+		}, &calls
+	}
+
+	// 限频重试后成功，等待时间翻倍
+	place, calls := failing(errors.New("too many requests"), errors.New("too many requests"))
+	if _, err := at.placeOrder("open_long", "BTCUSDT", 0, 0, place); err != nil || *calls != 3 {
+		t.Fatalf("限频应重试直到成功: err=%v calls=%d", err, *calls)
+	}
+	if len(slept) != 2 || slept[0] != time.Second || slept[1] != 2*time.Second {
+		t.Errorf("重试等待应翻倍: %v", slept)
+	}
+
+	// 保证金不足不重试
+	place, calls = failing(errors.New("code=-2019, msg=Margin is insufficient."))
+	_, err := at.placeOrder("open_long", "BTCUSDT", 0, 0, place)
+	if ErrorClassOf(err) != ErrClassInsufficientMargin || *calls != 1 {
+		t.Errorf("保证金不足不应重试: err=%v calls=%d", err, *calls)
+	}
+
+	// 网络错误：开仓不重试（可能已成交），平仓重试
+	netErr := errors.New("i/o timeout")
+	place, calls = failing(netErr)
+	if _, err := at.placeOrder("open_short", "ETHUSDT", 0, 0, place); ErrorClassOf(err) != ErrClassNetwork || *calls != 1 {
+		t.Errorf("开仓遇到网络错误不应重试: err=%v calls=%d", err, *calls)
+	}
+	place, calls = failing(netErr)
+	if _, err := at.placeOrder("close_short", "ETHUSDT", 0, 0, place); err != nil || *calls != 2 {
+		t.Errorf("平仓遇到网络错误应重试: err=%v calls=%d", err, *calls)
+	}
+
+	// 达到最多尝试次数后返回分类错误
+	place, calls = failing(errors.New("rate limit"), errors.New("rate limit"), errors.New("rate limit"))
+	_, err = at.placeOrder("close_long", "BTCUSDT", 0, 0, place)
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) || execErr.Attempts != 3 || *calls != 3 {
+		t.Errorf("应在第3次后放弃: %v calls=%d", err, *calls)
+	}
+	if ErrorClassOf(errors.New("other")) != "" {
+		t.Error("非下单错误不应有类别")
+	}
+}
@@ -0,0 +1,159 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+	"time"
+)
+
+// trackedPosition 上一周期看到的持仓，用于发现交易所侧的平仓（止损/止盈/强平）
+type trackedPosition struct {
+	symbol           string
+	side             string
+	quantity         float64
+	entryPrice       float64
+	markPrice        float64
+	liquidationPrice float64
+	stopLoss         float64 // 开仓时设置的止损（重启后未知为0）
+	takeProfit       float64 // 开仓时设置的止盈（重启后未知为0）
+	fundingFee       float64 // 持仓期间累计资金费净收入
+}
+
+// trackOpenedPosition 开仓成功后开始跟踪，保证下一周期前触发的止损止盈也能被发现
+func (at *AutoTrader) trackOpenedPosition(symbol, side string, quantity, price, stopLoss, takeProfit float64) {
+	at.trackedPositions[symbol+"_"+side] = &trackedPosition{
+		symbol:     symbol,
+		side:       side,
+		quantity:   quantity,
+		entryPrice: price,
+		markPrice:  price,
+		stopLoss:   stopLoss,
+		takeProfit: takeProfit,
+	}
+}
+
+// updateTrackedPosition 用最新持仓刷新跟踪状态（保留开仓时的止损止盈）
+func (at *AutoTrader) updateTrackedPosition(pos decision.PositionInfo) {
+	key := pos.Symbol + "_" + pos.Side
+	tracked, ok := at.trackedPositions[key]
+	if !ok {
+		tracked = &trackedPosition{symbol: pos.Symbol, side: pos.Side}
+		at.trackedPositions[key] = tracked
+	}
+	tracked.quantity = pos.Quantity
+	tracked.entryPrice = pos.EntryPrice
+	tracked.markPrice = pos.MarkPrice
+	tracked.liquidationPrice = pos.LiquidationPrice
+}
+
+// detectExchangeExits 找出上一周期还在、本周期消失且不是AI平掉的持仓，生成平仓动作并回填开仓结果
+func (at *AutoTrader) detectExchangeExits(currentKeys map[string]bool) {
+	for key, tracked := range at.trackedPositions {
+		if currentKeys[key] {
+			continue
+		}
+		delete(at.trackedPositions, key)
+
+		price := tracked.markPrice
+		if data, err := market.Get(tracked.symbol); err == nil && data.CurrentPrice > 0 {
+			price = data.CurrentPrice
+		}
+		reason, exitPrice := inferExitReason(tracked, price)
+
+		at.log().Info("仓位已在交易所侧平仓", "symbol", tracked.symbol, "side", tracked.side, "reason", reason, "exit_price", exitPrice)
+		at.finishProtectiveOrders(key, reason, exitPrice)
+		at.pendingExits = append(at.pendingExits, logger.DecisionAction{
+			Action:     "close_" + tracked.side,
+			Symbol:     tracked.symbol,
+			Quantity:   tracked.quantity,
+			Price:      exitPrice,
+			Timestamp:  time.Now(),
+			Success:    true,
+			ExitReason: reason,
+		})
+		at.labelOutcome(tracked, exitPrice, reason)
+	}
+}
+
+// recordAIClose AI平仓成功后停止跟踪并回填开仓结果，有成交均价时按成交均价计算
+func (at *AutoTrader) recordAIClose(symbol, side string, price float64, actionRecord *logger.DecisionAction) {
+	pos := at.untrackPosition(symbol, side)
+	actionRecord.ExitReason = logger.ExitReasonAI
+	if actionRecord.FillPrice > 0 {
+		price = actionRecord.FillPrice
+	}
+	at.labelOutcome(pos, price, logger.ExitReasonAI)
+}
+
+// labelOutcome 回填开仓决策的结果（失败只记录日志），写入平仓记录并推送平仓事件
+func (at *AutoTrader) labelOutcome(pos *trackedPosition, price float64, reason string) {
+	symbol, side := pos.symbol, pos.side
+	event := PositionClosedEvent{Symbol: symbol, Side: side, Price: price, ExitReason: reason}
+	defer func() { at.publishPositionClosed(event) }()
+
+	outcome, err := at.decisionLogger.LabelOutcome(symbol, side, price, time.Now(), reason)
+	at.recordClosedPosition(pos, price, reason, outcome)
+	if err != nil {
+		at.log().Warn("回填开仓结果失败", "symbol", symbol, "side", side, "error", err)
+		return
+	}
+	event.PnL, event.RMultiple = &outcome.PnL, &outcome.RMultiple
+	at.log().Info("开仓结果", "symbol", symbol, "side", side, "reason", reason,
+		"pnl", outcome.PnL, "r_multiple", outcome.RMultiple, "holding_minutes", outcome.HoldingMinutes)
+	at.recordTradeResult(outcome.PnL, time.Now())
+}
+
+// popPendingExits 取出待写入决策记录的交易所侧平仓动作
+func (at *AutoTrader) popPendingExits() ([]logger.DecisionAction, []string) {
+	actions := at.pendingExits
+	at.pendingExits = nil
+
+	logs := make([]string, 0, len(actions))
+	for _, a := range actions {
+		logs = append(logs, fmt.Sprintf("📌 %s %s 交易所侧平仓 (%s)", a.Symbol, a.Action, a.ExitReason))
+	}
+	return actions, logs
+}
+
+// inferExitReason 根据当前价格推断仓位消失的原因及成交价
+// 价格从开仓价朝某个价位走过一半以上才认为是它触发的；多个价位都满足时取离当前价最近的
+func inferExitReason(pos *trackedPosition, price float64) (string, float64) {
+	reached := func(level float64) bool {
+		if level <= 0 || pos.entryPrice <= 0 {
+			return false
+		}
+		return (price-pos.entryPrice)*(level-pos.entryPrice) > 0 &&
+			math.Abs(price-pos.entryPrice) >= math.Abs(level-pos.entryPrice)/2
+	}
+
+	reason, exitPrice := logger.ExitReasonUnknown, price
+	bestDistance := math.Inf(1)
+	for _, candidate := range []struct {
+		reason string
+		level  float64
+	}{
+		{logger.ExitReasonStopLoss, pos.stopLoss},
+		{logger.ExitReasonTakeProfit, pos.takeProfit},
+	} {
+		if !reached(candidate.level) {
+			continue
+		}
+		if d := math.Abs(price - candidate.level); d < bestDistance {
+			reason, exitPrice, bestDistance = candidate.reason, candidate.level, d
+		}
+	}
+	if reason != logger.ExitReasonUnknown {
+		return reason, exitPrice
+	}
+
+	// 没有可匹配的止损止盈时，价格越过强平价视为强平
+	if pos.liquidationPrice > 0 {
+		if (pos.side == "long" && price <= pos.liquidationPrice) || (pos.side == "short" && price >= pos.liquidationPrice) {
+			return logger.ExitReasonLiquidation, pos.liquidationPrice
+		}
+	}
+	return reason, exitPrice
+}
@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"nofx/logger"
+	"testing"
+)
+
+func TestInferExitReason(t *testing.T) {
+	long := &trackedPosition{side: "long", entryPrice: 100, stopLoss: 95, takeProfit: 110, liquidationPrice: 80}
+
+	cases := []struct {
+		name      string
+		pos       *trackedPosition
+		price     float64
+		reason    string
+		exitPrice float64
+	}{
+		{"跌破止损", long, 94.5, logger.ExitReasonStopLoss, 95},
+		{"止损后反弹但仍靠近止损", long, 97, logger.ExitReasonStopLoss, 95},
+		{"突破止盈", long, 111, logger.ExitReasonTakeProfit, 110},
+		{"价格几乎没动", long, 100.5, logger.ExitReasonUnknown, 100.5},
+		{"未知止损止盈时越过强平价", &trackedPosition{side: "long", entryPrice: 100, liquidationPrice: 80}, 79, logger.ExitReasonLiquidation, 80},
+		{"空仓止损", &trackedPosition{side: "short", entryPrice: 100, stopLoss: 104, takeProfit: 90}, 104.2, logger.ExitReasonStopLoss, 104},
+	}
+	for _, c := range cases {
+		reason, exitPrice := inferExitReason(c.pos, c.price)
+		if reason != c.reason || exitPrice != c.exitPrice {
+			t.Errorf("%s: 得到 %s@%.2f，期望 %s@%.2f", c.name, reason, exitPrice, c.reason, c.exitPrice)
+		}
+	}
+}
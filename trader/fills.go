@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"nofx/logger"
+	"strconv"
+)
+
+// orderFillPrice 交易所返回的成交均价，没有返回或尚未成交时返回0
+// 币安/Aster 返回字符串，模拟盘返回 float64
+func orderFillPrice(order map[string]interface{}) float64 {
+	switch v := order["avgPrice"].(type) {
+	case float64:
+		return v
+	case string:
+		price, _ := strconv.ParseFloat(v, 64)
+		return price
+	}
+	return 0
+}
+
+// recordFill 记录成交均价及相对下单时行情价的滑点
+func (at *AutoTrader) recordFill(order map[string]interface{}, actionRecord *logger.DecisionAction) {
+	fill := orderFillPrice(order)
+	if fill <= 0 || actionRecord.Price <= 0 {
+		return
+	}
+	actionRecord.FillPrice = fill
+	actionRecord.SlippageBps = logger.SlippageBps(actionRecord.Action, actionRecord.Price, fill)
+	at.log().Debug("成交滑点", "symbol", actionRecord.Symbol, "action", actionRecord.Action,
+		"price", actionRecord.Price, "fill_price", fill, "slippage_bps", actionRecord.SlippageBps)
+}
@@ -0,0 +1,50 @@
+package trader
+
+import (
+	"fmt"
+)
+
+// FlattenPositions 市价平掉所有持仓并撤掉对应币种的挂单，返回平掉的仓位数
+// 紧急操作：调用前应先停止交易员，避免下一周期AI重新开仓；平仓结果由交易所侧平仓检测或启动对账回填
+func (at *AutoTrader) FlattenPositions() (int, error) {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	closed := 0
+	var firstErr error
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		markPrice, _ := pos["markPrice"].(float64)
+
+		var err error
+		switch side {
+		case "long":
+			_, err = at.sendOrder("close_long", symbol, 0, markPrice, func() (map[string]interface{}, error) {
+				return at.trader.CloseLong(symbol, 0)
+			})
+		case "short":
+			_, err = at.sendOrder("close_short", symbol, 0, markPrice, func() (map[string]interface{}, error) {
+				return at.trader.CloseShort(symbol, 0)
+			})
+		default:
+			continue
+		}
+		if err != nil {
+			at.log().Error("一键平仓失败", "symbol", symbol, "side", side, "error", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("平仓 %s %s 失败: %w", symbol, side, err)
+			}
+			continue
+		}
+		if err := at.trader.CancelAllOrders(symbol); err != nil {
+			at.log().Warn("撤销挂单失败", "symbol", symbol, "error", err)
+		}
+		closed++
+	}
+
+	at.log().Info("一键平仓完成", "closed", closed, "total", len(positions))
+	return closed, firstErr
+}
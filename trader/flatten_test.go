@@ -0,0 +1,33 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlattenPositions(t *testing.T) {
+	costs := DefaultSimulationCosts()
+	costs.SlippageBps = 0
+	exchange := NewSimulatedExchangeWithCosts(1000, costs)
+	exchange.SetReplayFeed(map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000}, time.Now())
+	if _, err := exchange.OpenLong("BTCUSDT", 0.01, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exchange.OpenShort("ETHUSDT", 0.1, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	at := &AutoTrader{name: "test", trader: exchange}
+	at.setProtectiveOrders("BTCUSDT", "long", 0.01, 58000, 66000)
+
+	closed, err := at.FlattenPositions()
+	if err != nil || closed != 2 {
+		t.Fatalf("应平掉2个仓位: closed=%d err=%v", closed, err)
+	}
+	if positions, _ := exchange.GetPositions(); len(positions) != 0 {
+		t.Errorf("平仓后不应有持仓: %v", positions)
+	}
+	if orders, _ := exchange.GetOpenOrders(); len(orders) != 0 {
+		t.Errorf("平仓后不应有挂单: %v", orders)
+	}
+}
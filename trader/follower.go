@@ -0,0 +1,337 @@
+package trader
+
+import (
+	"fmt"
+	"log/slog"
+	"nofx/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFollowActivity 每个跟单保留的最近操作条数
+const maxFollowActivity = 50
+
+// FollowConfig 跟单配置：按领单交易员的决策在跟随者自己的账户上下单
+type FollowConfig struct {
+	ID             string
+	UserID         string
+	LeaderID       string
+	Multiplier     float64 // 跟单名义价值 = 领单名义价值 × Multiplier
+	MaxLeverage    int     // 杠杆上限，0 表示跟随领单
+	MaxPositionUSD float64 // 单个仓位名义价值上限，0 不限制
+	MaxPositions   int     // 同时持仓数上限，0 不限制
+	MaxDrawdownPct float64 // 净值从跟单以来的高点回撤超过此百分比后停止开仓（平仓照常跟随），0 不限制
+}
+
+// FollowActivity 一次跟单操作
+type FollowActivity struct {
+	Time     time.Time `json:"time"`
+	Symbol   string    `json:"symbol"`
+	Action   string    `json:"action"`
+	Quantity float64   `json:"quantity,omitempty"`
+	Leverage int       `json:"leverage,omitempty"`
+	Skipped  string    `json:"skipped,omitempty"` // 被风控跳过的原因
+	Error    string    `json:"error,omitempty"`
+}
+
+// FollowerStatus 跟单运行状态
+type FollowerStatus struct {
+	Running    bool             `json:"running"`
+	PeakEquity float64          `json:"peak_equity"`
+	Equity     float64          `json:"equity"`
+	Halted     bool             `json:"halted"` // 回撤超限，已停止开仓
+	Activity   []FollowActivity `json:"activity"`
+}
+
+// Follower 订阅领单交易员的决策流并镜像到跟随者账户
+type Follower struct {
+	config FollowConfig
+	trader Trader
+
+	mu          sync.Mutex
+	peakEquity  float64
+	equity      float64
+	halted      bool
+	activity    []FollowActivity
+	unsubscribe func()
+}
+
+// NewFollower 创建跟单执行器
+func NewFollower(config FollowConfig, trader Trader) *Follower {
+	return &Follower{config: config, trader: trader}
+}
+
+// followLog 带跟单上下文（follow_id、user_id、leader_id）的结构化日志
+func followLog(config FollowConfig) *slog.Logger {
+	return slog.With("follow_id", config.ID, "user_id", config.UserID, "leader_id", config.LeaderID)
+}
+
+// Start 开始跟单（非阻塞）
+func (f *Follower) Start() {
+	f.mu.Lock()
+	if f.unsubscribe != nil {
+		f.mu.Unlock()
+		return
+	}
+	events, unsubscribe := SubscribeEvents(64)
+	f.unsubscribe = unsubscribe
+	f.mu.Unlock()
+
+	followLog(f.config).Info("开始跟单", "multiplier", f.config.Multiplier)
+	go func() {
+		for event := range events {
+			f.HandleEvent(event)
+		}
+	}()
+}
+
+// Stop 停止跟单（已有持仓保留）
+func (f *Follower) Stop() {
+	f.mu.Lock()
+	unsubscribe := f.unsubscribe
+	f.unsubscribe = nil
+	f.mu.Unlock()
+
+	if unsubscribe != nil {
+		unsubscribe()
+		followLog(f.config).Info("跟单已停止")
+	}
+}
+
+// LeaderID 领单交易员ID
+func (f *Follower) LeaderID() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.config.LeaderID
+}
+
+// UpdateConfig 修改倍数和风控上限（领单交易员不变）
+func (f *Follower) UpdateConfig(config FollowConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	config.LeaderID = f.config.LeaderID
+	f.config = config
+	// 放宽回撤上限后允许恢复开仓
+	f.halted = false
+}
+
+// Status 运行状态和最近的跟单操作（新的在前）
+func (f *Follower) Status() FollowerStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	activity := make([]FollowActivity, len(f.activity))
+	for i, a := range f.activity {
+		activity[len(f.activity)-1-i] = a
+	}
+	return FollowerStatus{
+		Running:    f.unsubscribe != nil,
+		PeakEquity: f.peakEquity,
+		Equity:     f.equity,
+		Halted:     f.halted,
+		Activity:   activity,
+	}
+}
+
+// HandleEvent 处理一条领单事件：决策记录中成功的开平仓，以及交易所侧平仓（止损、止盈、强平等）
+func (f *Follower) HandleEvent(event Event) {
+	f.mu.Lock()
+	config := f.config
+	f.mu.Unlock()
+	if event.TraderID != config.LeaderID {
+		return
+	}
+
+	switch event.Type {
+	case EventDecision:
+		record, ok := event.Data.(*logger.DecisionRecord)
+		if !ok {
+			return
+		}
+		for _, action := range record.Decisions {
+			// 交易所侧平仓会单独推送 position_closed，这里只跟随AI决策
+			if !action.Success || action.ExitReason != "" {
+				continue
+			}
+			switch action.Action {
+			case "open_long", "open_short":
+				f.mirrorOpen(config, action)
+			case "close_long":
+				f.mirrorClose(action.Symbol, "long")
+			case "close_short":
+				f.mirrorClose(action.Symbol, "short")
+			}
+		}
+	case EventPositionClosed:
+		if closed, ok := event.Data.(PositionClosedEvent); ok && closed.ExitReason != logger.ExitReasonAI {
+			f.mirrorClose(closed.Symbol, closed.Side)
+		}
+	}
+}
+
+// record 记录一次跟单操作
+func (f *Follower) record(a FollowActivity) {
+	a.Time = time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.activity = append(f.activity, a)
+	if len(f.activity) > maxFollowActivity {
+		f.activity = f.activity[len(f.activity)-maxFollowActivity:]
+	}
+}
+
+// updateEquity 刷新净值和高点，回撤超限时停止开仓
+func (f *Follower) updateEquity(config FollowConfig) (bool, error) {
+	balance, err := f.trader.GetBalance()
+	if err != nil {
+		return false, fmt.Errorf("获取余额失败: %w", err)
+	}
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+	equity := wallet + unrealized
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.equity = equity
+	if equity > f.peakEquity {
+		f.peakEquity = equity
+	}
+	if config.MaxDrawdownPct > 0 && f.peakEquity > 0 && (f.peakEquity-equity)/f.peakEquity*100 >= config.MaxDrawdownPct {
+		if !f.halted {
+			followLog(config).Warn("跟单净值回撤超过上限，停止开仓", "max_drawdown_pct", config.MaxDrawdownPct)
+		}
+		f.halted = true
+	}
+	return f.halted, nil
+}
+
+// followerPositions 跟随者当前持仓，key 为 symbol_side
+func (f *Follower) followerPositions() (map[string]bool, error) {
+	positions, err := f.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	held := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		held[symbol+"_"+side] = true
+	}
+	return held, nil
+}
+
+// mirrorOpen 按倍数和风控上限跟随开仓
+func (f *Follower) mirrorOpen(config FollowConfig, action logger.DecisionAction) {
+	side := strings.TrimPrefix(action.Action, "open_")
+	activity := FollowActivity{Symbol: action.Symbol, Action: action.Action}
+	skip := func(reason string) {
+		activity.Skipped = reason
+		f.record(activity)
+	}
+	fail := func(err error) {
+		followLog(config).Error("跟单开仓失败", "symbol", action.Symbol, "side", side, "error", err)
+		activity.Error = err.Error()
+		f.record(activity)
+	}
+
+	halted, err := f.updateEquity(config)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if halted {
+		skip(fmt.Sprintf("净值回撤超过 %.2f%%，停止开仓", config.MaxDrawdownPct))
+		return
+	}
+
+	held, err := f.followerPositions()
+	if err != nil {
+		fail(err)
+		return
+	}
+	if held[action.Symbol+"_"+side] {
+		skip("已有同方向持仓")
+		return
+	}
+	if config.MaxPositions > 0 && len(held) >= config.MaxPositions {
+		skip(fmt.Sprintf("持仓数已达上限 %d", config.MaxPositions))
+		return
+	}
+
+	notional := action.Quantity * action.Price * config.Multiplier
+	if config.MaxPositionUSD > 0 && notional > config.MaxPositionUSD {
+		notional = config.MaxPositionUSD
+	}
+	leverage := action.Leverage
+	if config.MaxLeverage > 0 && leverage > config.MaxLeverage {
+		leverage = config.MaxLeverage
+	}
+	price, err := f.trader.GetMarketPrice(action.Symbol)
+	if err != nil || price <= 0 {
+		fail(fmt.Errorf("获取 %s 价格失败: %v", action.Symbol, err))
+		return
+	}
+	quantity := notional / price
+	if quantity <= 0 {
+		skip("跟单仓位为0")
+		return
+	}
+	activity.Quantity = quantity
+	activity.Leverage = leverage
+
+	if side == "long" {
+		_, err = f.trader.OpenLong(action.Symbol, quantity, leverage)
+	} else {
+		_, err = f.trader.OpenShort(action.Symbol, quantity, leverage)
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// 止损止盈价与领单一致
+	positionSide := strings.ToUpper(side)
+	if action.StopLoss > 0 {
+		if err := f.trader.SetStopLoss(action.Symbol, positionSide, quantity, action.StopLoss); err != nil {
+			followLog(config).Warn("跟单设置止损失败", "symbol", action.Symbol, "error", err)
+		}
+	}
+	if action.TakeProfit > 0 {
+		if err := f.trader.SetTakeProfit(action.Symbol, positionSide, quantity, action.TakeProfit); err != nil {
+			followLog(config).Warn("跟单设置止盈失败", "symbol", action.Symbol, "error", err)
+		}
+	}
+	followLog(config).Info("跟单开仓", "symbol", action.Symbol, "side", side, "quantity", quantity, "leverage", leverage)
+	f.record(activity)
+}
+
+// mirrorClose 跟随平仓；跟随者没有该仓位时（未跟上开仓或已被自己的止损平掉）忽略
+func (f *Follower) mirrorClose(symbol, side string) {
+	held, err := f.followerPositions()
+	if err != nil {
+		followLog(f.config).Warn("跟单获取持仓失败，跳过平仓", "symbol", symbol, "side", side, "error", err)
+		return
+	}
+	if !held[symbol+"_"+side] {
+		return
+	}
+
+	activity := FollowActivity{Symbol: symbol, Action: "close_" + side}
+	if side == "long" {
+		_, err = f.trader.CloseLong(symbol, 0)
+	} else {
+		_, err = f.trader.CloseShort(symbol, 0)
+	}
+	if err != nil {
+		followLog(f.config).Error("跟单平仓失败", "symbol", symbol, "side", side, "error", err)
+		activity.Error = err.Error()
+		f.record(activity)
+		return
+	}
+	if err := f.trader.CancelAllOrders(symbol); err != nil {
+		followLog(f.config).Warn("跟单撤销挂单失败", "symbol", symbol, "error", err)
+	}
+	followLog(f.config).Info("跟单平仓", "symbol", symbol, "side", side)
+	f.record(activity)
+}
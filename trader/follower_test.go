@@ -0,0 +1,100 @@
+package trader
+
+import (
+	"math"
+	"nofx/logger"
+	"testing"
+	"time"
+)
+
+func newTestFollower(t *testing.T, config FollowConfig) (*Follower, *SimulatedExchange) {
+	t.Helper()
+	costs := DefaultSimulationCosts()
+	costs.SlippageBps = 0
+	exchange := NewSimulatedExchangeWithCosts(1000, costs)
+	exchange.SetReplayFeed(map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000, "SOLUSDT": 150}, time.Now())
+	config.ID, config.LeaderID = "f1", "leader"
+	return NewFollower(config, exchange), exchange
+}
+
+func decisionEvent(actions ...logger.DecisionAction) Event {
+	return Event{TraderID: "leader", Type: EventDecision, Data: &logger.DecisionRecord{Decisions: actions}}
+}
+
+func TestFollowerMirrorsOpenWithMultiplierAndCaps(t *testing.T) {
+	f, exchange := newTestFollower(t, FollowConfig{Multiplier: 0.5, MaxLeverage: 3, MaxPositionUSD: 200})
+
+	f.HandleEvent(decisionEvent(
+		// 领单名义价值 0.01×60000=600，×0.5=300，被单仓位上限压到200
+		logger.DecisionAction{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.01, Price: 60000, Leverage: 10, StopLoss: 58000, Success: true},
+		logger.DecisionAction{Action: "open_short", Symbol: "ETHUSDT", Quantity: 0.1, Price: 3000, Leverage: 2, Success: false},
+	))
+	// 其他交易员的决策不跟随
+	f.HandleEvent(Event{TraderID: "other", Type: EventDecision, Data: &logger.DecisionRecord{Decisions: []logger.DecisionAction{
+		{Action: "open_long", Symbol: "SOLUSDT", Quantity: 1, Price: 150, Leverage: 2, Success: true},
+	}}})
+
+	positions, _ := exchange.GetPositions()
+	if len(positions) != 1 {
+		t.Fatalf("应只跟随成功的领单开仓: %v", positions)
+	}
+	pos := positions[0]
+	if qty := pos["positionAmt"].(float64); math.Abs(qty*60000-200) > 1e-6 {
+		t.Errorf("跟单名义价值应为200，实际 %.4f", qty*60000)
+	}
+	if lev := pos["leverage"].(float64); lev != 3 {
+		t.Errorf("杠杆应被限制为3倍，实际 %.0f", lev)
+	}
+	if orders, _ := exchange.GetOpenOrders(); len(orders) != 1 {
+		t.Errorf("应按领单止损价挂止损单: %v", orders)
+	}
+}
+
+func TestFollowerMirrorsCloses(t *testing.T) {
+	f, exchange := newTestFollower(t, FollowConfig{Multiplier: 1})
+	f.HandleEvent(decisionEvent(
+		logger.DecisionAction{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.001, Price: 60000, Leverage: 5, Success: true},
+		logger.DecisionAction{Action: "open_short", Symbol: "ETHUSDT", Quantity: 0.01, Price: 3000, Leverage: 5, Success: true},
+	))
+
+	// AI平仓随决策记录跟随
+	f.HandleEvent(decisionEvent(logger.DecisionAction{Action: "close_long", Symbol: "BTCUSDT", Success: true}))
+	// 领单止损触发
+	f.HandleEvent(Event{TraderID: "leader", Type: EventPositionClosed, Data: PositionClosedEvent{Symbol: "ETHUSDT", Side: "short", ExitReason: logger.ExitReasonStopLoss}})
+	// 跟随者已无仓位时重复的平仓事件被忽略
+	f.HandleEvent(Event{TraderID: "leader", Type: EventPositionClosed, Data: PositionClosedEvent{Symbol: "BTCUSDT", Side: "long", ExitReason: logger.ExitReasonAI}})
+
+	if positions, _ := exchange.GetPositions(); len(positions) != 0 {
+		t.Errorf("应跟随所有平仓: %v", positions)
+	}
+	if status := f.Status(); len(status.Activity) != 4 || status.Activity[0].Action != "close_short" {
+		t.Errorf("应记录2次开仓和2次平仓（新的在前）: %+v", status.Activity)
+	}
+}
+
+func TestFollowerRiskCaps(t *testing.T) {
+	f, exchange := newTestFollower(t, FollowConfig{Multiplier: 1, MaxPositions: 1, MaxDrawdownPct: 10})
+	open := func(symbol string, price float64) {
+		f.HandleEvent(decisionEvent(logger.DecisionAction{Action: "open_long", Symbol: symbol, Quantity: 500 / price, Price: price, Leverage: 1, Success: true}))
+	}
+
+	open("BTCUSDT", 60000)
+	open("ETHUSDT", 3000)
+	if positions, _ := exchange.GetPositions(); len(positions) != 1 {
+		t.Fatalf("持仓数上限为1: %v", positions)
+	}
+	if status := f.Status(); status.Activity[0].Skipped == "" {
+		t.Errorf("超过持仓数上限应记录跳过原因: %+v", status.Activity[0])
+	}
+
+	// BTC 下跌40%使净值回撤约20%，之后停止开仓
+	exchange.SetReplayFeed(map[string]float64{"BTCUSDT": 60000 * 0.6, "ETHUSDT": 3000, "SOLUSDT": 150}, time.Now())
+	f.HandleEvent(decisionEvent(logger.DecisionAction{Action: "close_long", Symbol: "BTCUSDT", Success: true}))
+	open("SOLUSDT", 150)
+	if positions, _ := exchange.GetPositions(); len(positions) != 0 {
+		t.Errorf("回撤超限后不应再开仓: %v", positions)
+	}
+	if status := f.Status(); !status.Halted {
+		t.Errorf("回撤超限后应停止开仓: %+v", status)
+	}
+}
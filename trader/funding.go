@@ -0,0 +1,115 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/decision"
+	"nofx/logger"
+	"sync"
+	"time"
+)
+
+var (
+	fundingCostMu       sync.RWMutex
+	fundingCostClosePct = 0.0 // 累计支付的资金费超过浮盈的此百分比时自动平仓（<=0 关闭）
+)
+
+// SetFundingCostLimit 设置资金费预算：持仓累计支付的资金费超过浮盈的 pct% 时自动平仓（pct<=0 关闭）
+func SetFundingCostLimit(pct float64) error {
+	if pct < 0 {
+		return fmt.Errorf("资金费预算百分比不能为负数: %.2f", pct)
+	}
+	fundingCostMu.Lock()
+	defer fundingCostMu.Unlock()
+	fundingCostClosePct = pct
+	return nil
+}
+
+// getFundingCostLimit 获取当前资金费预算百分比
+func getFundingCostLimit() float64 {
+	fundingCostMu.RLock()
+	defer fundingCostMu.RUnlock()
+	return fundingCostClosePct
+}
+
+// fillFundingFees 为每个持仓填入自首次出现以来的累计资金费（交易器不支持时保持为0）
+func (at *AutoTrader) fillFundingFees(positions []decision.PositionInfo) {
+	provider, ok := at.trader.(FundingFeeProvider)
+	if !ok {
+		return
+	}
+	for i := range positions {
+		pos := &positions[i]
+		since := time.UnixMilli(pos.UpdateTime)
+		fee, err := provider.GetFundingFees(pos.Symbol, since)
+		if err != nil {
+			at.log().Warn("获取资金费失败", "symbol", pos.Symbol, "error", err)
+			continue
+		}
+		pos.FundingFee = fee
+		if tracked, ok := at.trackedPositions[pos.Symbol+"_"+pos.Side]; ok {
+			tracked.fundingFee = fee
+		}
+	}
+}
+
+// fundingCostExceeded 持仓支付的资金费是否超过浮盈的 pct%（亏损持仓不适用）
+func fundingCostExceeded(pos decision.PositionInfo, pct float64) bool {
+	if pct <= 0 || pos.UnrealizedPnL <= 0 || pos.FundingFee >= 0 {
+		return false
+	}
+	return -pos.FundingFee > pos.UnrealizedPnL*pct/100
+}
+
+// closeFundingHeavyPositions 平掉资金费超出预算的持仓，并从上下文中移除，避免AI再对其决策
+func (at *AutoTrader) closeFundingHeavyPositions(ctx *decision.Context, record *logger.DecisionRecord) {
+	pct := getFundingCostLimit()
+	if pct <= 0 {
+		return
+	}
+
+	kept := ctx.Positions[:0]
+	for _, pos := range ctx.Positions {
+		if !fundingCostExceeded(pos, pct) {
+			kept = append(kept, pos)
+			continue
+		}
+
+		at.log().Warn("已支付资金费超过浮盈预算，自动平仓", "symbol", pos.Symbol, "side", pos.Side,
+			"funding_paid", -pos.FundingFee, "unrealized_pnl", pos.UnrealizedPnL, "budget_pct", pct)
+		actionRecord := logger.DecisionAction{
+			Action:     "close_" + pos.Side,
+			Symbol:     pos.Symbol,
+			Quantity:   pos.Quantity,
+			Price:      pos.MarkPrice,
+			Timestamp:  time.Now(),
+			ExitReason: logger.ExitReasonFunding,
+		}
+
+		order, err := at.sendOrder(actionRecord.Action, pos.Symbol, 0, pos.MarkPrice, func() (map[string]interface{}, error) {
+			if pos.Side == "long" {
+				return at.trader.CloseLong(pos.Symbol, 0)
+			}
+			return at.trader.CloseShort(pos.Symbol, 0)
+		})
+		if err != nil {
+			at.log().Error("资金费超预算平仓失败", "symbol", pos.Symbol, "error", err)
+			actionRecord.Error = err.Error()
+			record.Decisions = append(record.Decisions, actionRecord)
+			kept = append(kept, pos)
+			continue
+		}
+
+		actionRecord.Success = true
+		if orderID, ok := order["orderId"].(int64); ok {
+			actionRecord.OrderID = orderID
+		}
+		record.Decisions = append(record.Decisions, actionRecord)
+		record.ExecutionLog = append(record.ExecutionLog,
+			fmt.Sprintf("💸 %s %s 资金费超预算自动平仓 (资金费 %.2f / 浮盈 %.2f)", pos.Symbol, actionRecord.Action, pos.FundingFee, pos.UnrealizedPnL))
+
+		at.labelOutcome(at.untrackPosition(pos.Symbol, pos.Side), pos.MarkPrice, logger.ExitReasonFunding)
+	}
+	ctx.Positions = kept
+	ctx.Account.PositionCount = len(kept)
+	ctx.Account.NetDelta = netDelta(kept)
+}
@@ -0,0 +1,91 @@
+package trader
+
+import (
+	"math"
+	"nofx/decision"
+	"nofx/logger"
+	"testing"
+	"time"
+)
+
+func TestSimulatedExchangeGetFundingFees(t *testing.T) {
+	price := 100.0
+	now := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	s := newTestSimulatedExchange(&price, &now)
+	s.OpenLong("BTCUSDT", 10, 5)
+
+	// 跨过 08:00 和 16:00 两个结算点，触发结算
+	now = time.Date(2025, 1, 1, 17, 0, 0, 0, time.UTC)
+	balanceOf(t, s)
+
+	fee, _ := s.GetFundingFees("BTCUSDT", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if math.Abs(fee+0.2) > 1e-9 {
+		t.Errorf("多头应支付0.2资金费，实际 %.6f", fee)
+	}
+	fee, _ = s.GetFundingFees("BTCUSDT", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	if math.Abs(fee+0.1) > 1e-9 {
+		t.Errorf("since之后只有一次结算，实际 %.6f", fee)
+	}
+	if fee, _ := s.GetFundingFees("ETHUSDT", time.Time{}); fee != 0 {
+		t.Errorf("其他币种不应有资金费: %.6f", fee)
+	}
+}
+
+func TestFundingCostExceeded(t *testing.T) {
+	cases := []struct {
+		name string
+		pos  decision.PositionInfo
+		pct  float64
+		want bool
+	}{
+		{"超过浮盈一半", decision.PositionInfo{UnrealizedPnL: 10, FundingFee: -6}, 50, true},
+		{"未超过", decision.PositionInfo{UnrealizedPnL: 10, FundingFee: -4}, 50, false},
+		{"收取资金费", decision.PositionInfo{UnrealizedPnL: 10, FundingFee: 6}, 50, false},
+		{"亏损持仓不适用", decision.PositionInfo{UnrealizedPnL: -10, FundingFee: -6}, 50, false},
+		{"关闭", decision.PositionInfo{UnrealizedPnL: 10, FundingFee: -60}, 0, false},
+	}
+	for _, c := range cases {
+		if got := fundingCostExceeded(c.pos, c.pct); got != c.want {
+			t.Errorf("%s: 期望 %v，实际 %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestCloseFundingHeavyPositions(t *testing.T) {
+	defer SetFundingCostLimit(0)
+	SetFundingCostLimit(50)
+
+	costs := DefaultSimulationCosts()
+	costs.SlippageBps = 0
+	exchange := NewSimulatedExchangeWithCosts(1000, costs)
+	exchange.SetReplayFeed(map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000}, time.Now())
+	exchange.OpenLong("BTCUSDT", 0.01, 5)
+	exchange.OpenLong("ETHUSDT", 0.1, 5)
+
+	at := &AutoTrader{
+		name:             "test",
+		trader:           exchange,
+		decisionLogger:   logger.NewDecisionLogger(t.TempDir()),
+		trackedPositions: make(map[string]*trackedPosition),
+	}
+	ctx := &decision.Context{Positions: []decision.PositionInfo{
+		{Symbol: "BTCUSDT", Side: "long", Quantity: 0.01, MarkPrice: 60000, UnrealizedPnL: 10, FundingFee: -8},
+		{Symbol: "ETHUSDT", Side: "long", Quantity: 0.1, MarkPrice: 3000, UnrealizedPnL: 10, FundingFee: -2},
+	}}
+	record := &logger.DecisionRecord{}
+	at.closeFundingHeavyPositions(ctx, record)
+
+	if len(ctx.Positions) != 1 || ctx.Positions[0].Symbol != "ETHUSDT" {
+		t.Fatalf("只应平掉BTC持仓，剩余: %+v", ctx.Positions)
+	}
+	if ctx.Account.PositionCount != 1 {
+		t.Errorf("持仓数量应更新为1，实际 %d", ctx.Account.PositionCount)
+	}
+	if len(record.Decisions) != 1 || record.Decisions[0].ExitReason != logger.ExitReasonFunding || !record.Decisions[0].Success {
+		t.Errorf("应记录一条资金费平仓动作: %+v", record.Decisions)
+	}
+	positions, _ := exchange.GetPositions()
+	if len(positions) != 1 {
+		t.Errorf("交易所应只剩1个持仓: %v", positions)
+	}
+}
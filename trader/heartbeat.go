@@ -0,0 +1,122 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// 交易员健康状态
+const (
+	HealthOK        = "ok"
+	HealthStopped   = "stopped"
+	HealthStalled   = "stalled"    // 长时间没有完成周期（AI或交易所请求卡住）
+	HealthErrorLoop = "error_loop" // 连续多个周期失败
+)
+
+const (
+	stallIntervals     = 3               // 超过扫描间隔的多少倍没有完成周期视为卡住
+	minStallTimeout    = 5 * time.Minute // 卡住判定的最短时间，给启动对账和慢速AI留出余量
+	errorLoopThreshold = 5               // 连续失败多少个周期视为错误循环
+)
+
+// Heartbeat 交易员的周期心跳
+type Heartbeat struct {
+	Health            string     `json:"health"`
+	LastCycleAt       *time.Time `json:"last_cycle_at,omitempty"`    // 最近一次完成周期（无论成败）
+	LastSuccessAt     *time.Time `json:"last_success_at,omitempty"`  // 最近一次成功的周期
+	CycleStartedAt    *time.Time `json:"cycle_started_at,omitempty"` // 进行中的周期开始时间
+	ConsecutiveErrors int        `json:"consecutive_errors"`
+	LastError         string     `json:"last_error,omitempty"`
+	StallTimeout      string     `json:"stall_timeout"`
+}
+
+// heartbeatState 心跳的内部状态，由 heartbeatMu 保护
+type heartbeatState struct {
+	runStartedAt      time.Time
+	cycleStartedAt    time.Time
+	lastCycleAt       time.Time
+	lastSuccessAt     time.Time
+	consecutiveErrors int
+	lastError         string
+}
+
+// StallTimeout 超过这么久没有完成周期视为卡住
+func (at *AutoTrader) StallTimeout() time.Duration {
+	return max(stallIntervals*at.config.ScanInterval, minStallTimeout)
+}
+
+// markRunStarted Run 开始时重置心跳，重启后重新计算卡住和连续失败
+func (at *AutoTrader) markRunStarted(now time.Time) {
+	at.heartbeatMu.Lock()
+	defer at.heartbeatMu.Unlock()
+	at.heartbeat.runStartedAt = now
+	at.heartbeat.cycleStartedAt = time.Time{}
+	at.heartbeat.consecutiveErrors = 0
+}
+
+// markCycleStarted 记录周期开始
+func (at *AutoTrader) markCycleStarted(now time.Time) {
+	at.heartbeatMu.Lock()
+	defer at.heartbeatMu.Unlock()
+	at.heartbeat.cycleStartedAt = now
+}
+
+// markCycleFinished 记录周期结果；被 Stop 中断的周期不计入
+func (at *AutoTrader) markCycleFinished(now time.Time, err error) {
+	at.heartbeatMu.Lock()
+	defer at.heartbeatMu.Unlock()
+	at.heartbeat.cycleStartedAt = time.Time{}
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	at.heartbeat.lastCycleAt = now
+	if err != nil {
+		at.heartbeat.consecutiveErrors++
+		at.heartbeat.lastError = err.Error()
+		return
+	}
+	at.heartbeat.lastSuccessAt = now
+	at.heartbeat.consecutiveErrors = 0
+}
+
+// GetHeartbeat 心跳和据此判断的健康状态
+func (at *AutoTrader) GetHeartbeat(now time.Time) Heartbeat {
+	at.heartbeatMu.Lock()
+	defer at.heartbeatMu.Unlock()
+
+	h := at.heartbeat
+	timeout := at.StallTimeout()
+	hb := Heartbeat{
+		Health:            HealthOK,
+		LastCycleAt:       optionalTime(h.lastCycleAt),
+		LastSuccessAt:     optionalTime(h.lastSuccessAt),
+		CycleStartedAt:    optionalTime(h.cycleStartedAt),
+		ConsecutiveErrors: h.consecutiveErrors,
+		LastError:         h.lastError,
+		StallTimeout:      timeout.String(),
+	}
+
+	// 本次运行还没完成周期时从启动时间算起
+	last := h.lastCycleAt
+	if last.Before(h.runStartedAt) {
+		last = h.runStartedAt
+	}
+	switch {
+	case !at.isRunning:
+		hb.Health = HealthStopped
+	case h.consecutiveErrors >= errorLoopThreshold:
+		hb.Health = HealthErrorLoop
+	case !last.IsZero() && now.Sub(last) > timeout:
+		hb.Health = HealthStalled
+	}
+	return hb
+}
+
+// optionalTime 零值返回nil，JSON中省略
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,59 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	at := &AutoTrader{name: "test", config: AutoTraderConfig{ScanInterval: 3 * time.Minute}}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if at.StallTimeout() != 9*time.Minute {
+		t.Fatalf("卡住判定应为扫描间隔的3倍: %s", at.StallTimeout())
+	}
+	if hb := at.GetHeartbeat(start); hb.Health != HealthStopped {
+		t.Errorf("未运行时应为 stopped: %s", hb.Health)
+	}
+
+	at.isRunning = true
+	at.markRunStarted(start)
+	if hb := at.GetHeartbeat(start.Add(8 * time.Minute)); hb.Health != HealthOK {
+		t.Errorf("启动后未超时应正常: %s", hb.Health)
+	}
+	at.markCycleStarted(start)
+	if hb := at.GetHeartbeat(start.Add(10 * time.Minute)); hb.Health != HealthStalled || hb.CycleStartedAt == nil {
+		t.Errorf("周期超过卡住判定时间未完成应为 stalled: %+v", hb)
+	}
+
+	now := start.Add(10 * time.Minute)
+	at.markCycleFinished(now, nil)
+	if hb := at.GetHeartbeat(now.Add(time.Minute)); hb.Health != HealthOK || hb.LastSuccessAt == nil || !hb.LastSuccessAt.Equal(now) {
+		t.Errorf("完成周期后应恢复正常: %+v", hb)
+	}
+
+	for i := 0; i < errorLoopThreshold-1; i++ {
+		at.markCycleFinished(now, fmt.Errorf("获取行情失败"))
+	}
+	at.markCycleFinished(now, context.Canceled) // 被停止中断不计入
+	if hb := at.GetHeartbeat(now); hb.Health != HealthOK || hb.ConsecutiveErrors != errorLoopThreshold-1 {
+		t.Errorf("连续失败未达阈值: %+v", hb)
+	}
+	at.markCycleFinished(now, errors.New("AI调用失败"))
+	if hb := at.GetHeartbeat(now); hb.Health != HealthErrorLoop || hb.LastError != "AI调用失败" {
+		t.Errorf("连续失败达到阈值应为 error_loop: %+v", hb)
+	}
+
+	// 重新运行后重新计数
+	at.markRunStarted(now)
+	if hb := at.GetHeartbeat(now); hb.Health != HealthOK || hb.ConsecutiveErrors != 0 {
+		t.Errorf("重启后应重置连续失败: %+v", hb)
+	}
+
+	fast := &AutoTrader{config: AutoTraderConfig{ScanInterval: time.Minute}}
+	if fast.StallTimeout() != minStallTimeout {
+		t.Errorf("卡住判定不应短于 %s: %s", minStallTimeout, fast.StallTimeout())
+	}
+}
@@ -2,9 +2,8 @@ package trader
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
 
 	"github.com/ethereum/go-ethereum/crypto"
@@ -18,6 +17,8 @@ type HyperliquidTrader struct {
 	walletAddr    string
 	meta          *hyperliquid.Meta // 缓存meta信息（包含精度等）
 	isCrossMargin bool              // 是否为全仓模式
+
+	exchangeLog
 }
 
 // NewHyperliquidTrader 创建Hyperliquid交易器
@@ -55,7 +56,7 @@ func NewHyperliquidTrader(privateKeyHex string, walletAddr string, testnet bool)
 		nil,        // SpotMeta will be fetched automatically
 	)
 
-	log.Printf("✓ Hyperliquid交易器初始化成功 (testnet=%v, wallet=%s)", testnet, walletAddr)
+	slog.Info("Hyperliquid交易器初始化成功", "testnet", testnet, "wallet", walletAddr)
 
 	// 获取meta信息（包含精度等配置）
 	meta, err := exchange.Info().Meta(ctx)
@@ -74,22 +75,20 @@ func NewHyperliquidTrader(privateKeyHex string, walletAddr string, testnet bool)
 
 // GetBalance 获取账户余额
 func (t *HyperliquidTrader) GetBalance() (map[string]interface{}, error) {
-	log.Printf("🔄 正在调用Hyperliquid API获取账户余额...")
+	t.log().Debug("正在调用Hyperliquid API获取账户余额")
 
 	// 获取账户状态
 	accountState, err := t.exchange.Info().UserState(t.ctx, t.walletAddr)
 	if err != nil {
-		log.Printf("❌ Hyperliquid API调用失败: %v", err)
+		t.log().Error("Hyperliquid API调用失败", "error", err)
 		return nil, fmt.Errorf("获取账户信息失败: %w", err)
 	}
 
 	// 解析余额信息（MarginSummary字段都是string）
 	result := make(map[string]interface{})
 
-	// 🔍 调试：打印API返回的完整CrossMarginSummary结构
-	summaryJSON, _ := json.MarshalIndent(accountState.MarginSummary, "  ", "  ")
-	log.Printf("🔍 [DEBUG] Hyperliquid API CrossMarginSummary完整数据:")
-	log.Printf("%s", string(summaryJSON))
+	// 调试：记录API返回的完整MarginSummary结构
+	t.log().Debug("Hyperliquid API MarginSummary完整数据", "margin_summary", accountState.MarginSummary)
 
 	accountValue, _ := strconv.ParseFloat(accountState.MarginSummary.AccountValue, 64)
 	totalMarginUsed, _ := strconv.ParseFloat(accountState.MarginSummary.TotalMarginUsed, 64)
@@ -113,12 +112,12 @@ func (t *HyperliquidTrader) GetBalance() (map[string]interface{}, error) {
 	result["availableBalance"] = accountValue - totalMarginUsed   // 可用余额（总净值 - 占用保证金）
 	result["totalUnrealizedProfit"] = totalUnrealizedPnl          // 未实现盈亏
 
-	log.Printf("✓ Hyperliquid 账户: 总净值=%.2f (钱包%.2f+未实现%.2f), 可用=%.2f, 保证金占用=%.2f",
-		accountValue,
-		walletBalanceWithoutUnrealized,
-		totalUnrealizedPnl,
-		result["availableBalance"],
-		totalMarginUsed)
+	t.log().Debug("Hyperliquid账户余额",
+		"account_value", accountValue,
+		"wallet_balance", walletBalanceWithoutUnrealized,
+		"unrealized_pnl", totalUnrealizedPnl,
+		"available_balance", result["availableBalance"],
+		"margin_used", totalMarginUsed)
 
 	return result, nil
 }
@@ -197,7 +196,7 @@ func (t *HyperliquidTrader) SetMarginMode(symbol string, isCrossMargin bool) err
 	if !isCrossMargin {
 		marginModeStr = "逐仓"
 	}
-	log.Printf("  ✓ %s 将使用 %s 模式", symbol, marginModeStr)
+	t.log().Info("设置仓位模式", "symbol", symbol, "margin_mode", marginModeStr)
 	return nil
 }
 
@@ -213,7 +212,7 @@ func (t *HyperliquidTrader) SetLeverage(symbol string, leverage int) error {
 		return fmt.Errorf("设置杠杆失败: %w", err)
 	}
 
-	log.Printf("  ✓ %s 杠杆已切换为 %dx", symbol, leverage)
+	t.log().Info("杠杆已切换", "symbol", symbol, "leverage", leverage)
 	return nil
 }
 
@@ -221,7 +220,7 @@ func (t *HyperliquidTrader) SetLeverage(symbol string, leverage int) error {
 func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败: %v", err)
+		t.log().Warn("取消旧委托单失败", "symbol", symbol, "error", err)
 	}
 
 	// 设置杠杆
@@ -240,11 +239,11 @@ func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage i
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
-	log.Printf("  📏 数量精度处理: %.8f -> %.8f (szDecimals=%d)", quantity, roundedQuantity, t.getSzDecimals(coin))
+	t.log().Debug("数量精度处理", "symbol", symbol, "quantity", quantity, "rounded", roundedQuantity, "sz_decimals", t.getSzDecimals(coin))
 
 	// ⚠️ 关键：价格也需要处理为5位有效数字
 	aggressivePrice := t.roundPriceToSigfigs(price * 1.01)
-	log.Printf("  💰 价格精度处理: %.8f -> %.8f (5位有效数字)", price*1.01, aggressivePrice)
+	t.log().Debug("价格精度处理（5位有效数字）", "symbol", symbol, "price", price*1.01, "rounded", aggressivePrice)
 
 	// 创建市价买入订单（使用IOC limit order with aggressive price）
 	order := hyperliquid.CreateOrderRequest{
@@ -265,7 +264,7 @@ func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage i
 		return nil, fmt.Errorf("开多仓失败: %w", err)
 	}
 
-	log.Printf("✓ 开多仓成功: %s 数量: %.4f", symbol, roundedQuantity)
+	t.log().Info("开多仓成功", "symbol", symbol, "quantity", roundedQuantity)
 
 	result := make(map[string]interface{})
 	result["orderId"] = 0 // Hyperliquid没有返回order ID
@@ -279,7 +278,7 @@ func (t *HyperliquidTrader) OpenLong(symbol string, quantity float64, leverage i
 func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
 	// 先取消该币种的所有委托单
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消旧委托单失败: %v", err)
+		t.log().Warn("取消旧委托单失败", "symbol", symbol, "error", err)
 	}
 
 	// 设置杠杆
@@ -298,11 +297,11 @@ func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
-	log.Printf("  📏 数量精度处理: %.8f -> %.8f (szDecimals=%d)", quantity, roundedQuantity, t.getSzDecimals(coin))
+	t.log().Debug("数量精度处理", "symbol", symbol, "quantity", quantity, "rounded", roundedQuantity, "sz_decimals", t.getSzDecimals(coin))
 
 	// ⚠️ 关键：价格也需要处理为5位有效数字
 	aggressivePrice := t.roundPriceToSigfigs(price * 0.99)
-	log.Printf("  💰 价格精度处理: %.8f -> %.8f (5位有效数字)", price*0.99, aggressivePrice)
+	t.log().Debug("价格精度处理（5位有效数字）", "symbol", symbol, "price", price*0.99, "rounded", aggressivePrice)
 
 	// 创建市价卖出订单
 	order := hyperliquid.CreateOrderRequest{
@@ -323,7 +322,7 @@ func (t *HyperliquidTrader) OpenShort(symbol string, quantity float64, leverage
 		return nil, fmt.Errorf("开空仓失败: %w", err)
 	}
 
-	log.Printf("✓ 开空仓成功: %s 数量: %.4f", symbol, roundedQuantity)
+	t.log().Info("开空仓成功", "symbol", symbol, "quantity", roundedQuantity)
 
 	result := make(map[string]interface{})
 	result["orderId"] = 0
@@ -365,11 +364,11 @@ func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[stri
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
-	log.Printf("  📏 数量精度处理: %.8f -> %.8f (szDecimals=%d)", quantity, roundedQuantity, t.getSzDecimals(coin))
+	t.log().Debug("数量精度处理", "symbol", symbol, "quantity", quantity, "rounded", roundedQuantity, "sz_decimals", t.getSzDecimals(coin))
 
 	// ⚠️ 关键：价格也需要处理为5位有效数字
 	aggressivePrice := t.roundPriceToSigfigs(price * 0.99)
-	log.Printf("  💰 价格精度处理: %.8f -> %.8f (5位有效数字)", price*0.99, aggressivePrice)
+	t.log().Debug("价格精度处理（5位有效数字）", "symbol", symbol, "price", price*0.99, "rounded", aggressivePrice)
 
 	// 创建平仓订单（卖出 + ReduceOnly）
 	order := hyperliquid.CreateOrderRequest{
@@ -390,11 +389,11 @@ func (t *HyperliquidTrader) CloseLong(symbol string, quantity float64) (map[stri
 		return nil, fmt.Errorf("平多仓失败: %w", err)
 	}
 
-	log.Printf("✓ 平多仓成功: %s 数量: %.4f", symbol, roundedQuantity)
+	t.log().Info("平多仓成功", "symbol", symbol, "quantity", roundedQuantity)
 
 	// 平仓后取消该币种的所有挂单
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		t.log().Warn("取消挂单失败", "symbol", symbol, "error", err)
 	}
 
 	result := make(map[string]interface{})
@@ -437,11 +436,11 @@ func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[str
 
 	// ⚠️ 关键：根据币种精度要求，四舍五入数量
 	roundedQuantity := t.roundToSzDecimals(coin, quantity)
-	log.Printf("  📏 数量精度处理: %.8f -> %.8f (szDecimals=%d)", quantity, roundedQuantity, t.getSzDecimals(coin))
+	t.log().Debug("数量精度处理", "symbol", symbol, "quantity", quantity, "rounded", roundedQuantity, "sz_decimals", t.getSzDecimals(coin))
 
 	// ⚠️ 关键：价格也需要处理为5位有效数字
 	aggressivePrice := t.roundPriceToSigfigs(price * 1.01)
-	log.Printf("  💰 价格精度处理: %.8f -> %.8f (5位有效数字)", price*1.01, aggressivePrice)
+	t.log().Debug("价格精度处理（5位有效数字）", "symbol", symbol, "price", price*1.01, "rounded", aggressivePrice)
 
 	// 创建平仓订单（买入 + ReduceOnly）
 	order := hyperliquid.CreateOrderRequest{
@@ -462,11 +461,11 @@ func (t *HyperliquidTrader) CloseShort(symbol string, quantity float64) (map[str
 		return nil, fmt.Errorf("平空仓失败: %w", err)
 	}
 
-	log.Printf("✓ 平空仓成功: %s 数量: %.4f", symbol, roundedQuantity)
+	t.log().Info("平空仓成功", "symbol", symbol, "quantity", roundedQuantity)
 
 	// 平仓后取消该币种的所有挂单
 	if err := t.CancelAllOrders(symbol); err != nil {
-		log.Printf("  ⚠ 取消挂单失败: %v", err)
+		t.log().Warn("取消挂单失败", "symbol", symbol, "error", err)
 	}
 
 	result := make(map[string]interface{})
@@ -492,12 +491,12 @@ func (t *HyperliquidTrader) CancelAllOrders(symbol string) error {
 		if order.Coin == coin {
 			_, err := t.exchange.Cancel(t.ctx, coin, order.Oid)
 			if err != nil {
-				log.Printf("  ⚠ 取消订单失败 (oid=%d): %v", order.Oid, err)
+				t.log().Warn("取消订单失败", "symbol", symbol, "oid", order.Oid, "error", err)
 			}
 		}
 	}
 
-	log.Printf("  ✓ 已取消 %s 的所有挂单", symbol)
+	t.log().Info("已取消所有挂单", "symbol", symbol)
 	return nil
 }
 
@@ -556,7 +555,7 @@ func (t *HyperliquidTrader) SetStopLoss(symbol string, positionSide string, quan
 		return fmt.Errorf("设置止损失败: %w", err)
 	}
 
-	log.Printf("  止损价设置: %.4f", roundedStopPrice)
+	t.log().Info("止损价设置", "symbol", symbol, "side", positionSide, "stop_price", roundedStopPrice)
 	return nil
 }
 
@@ -593,7 +592,7 @@ func (t *HyperliquidTrader) SetTakeProfit(symbol string, positionSide string, qu
 		return fmt.Errorf("设置止盈失败: %w", err)
 	}
 
-	log.Printf("  止盈价设置: %.4f", roundedTakeProfitPrice)
+	t.log().Info("止盈价设置", "symbol", symbol, "side", positionSide, "take_profit_price", roundedTakeProfitPrice)
 	return nil
 }
 
@@ -610,7 +609,7 @@ func (t *HyperliquidTrader) FormatQuantity(symbol string, quantity float64) (str
 // getSzDecimals 获取币种的数量精度
 func (t *HyperliquidTrader) getSzDecimals(coin string) int {
 	if t.meta == nil {
-		log.Printf("⚠️  meta信息为空，使用默认精度4")
+		t.log().Warn("meta信息为空，使用默认精度4", "coin", coin)
 		return 4 // 默认精度
 	}
 
@@ -621,7 +620,7 @@ func (t *HyperliquidTrader) getSzDecimals(coin string) int {
 		}
 	}
 
-	log.Printf("⚠️  未找到 %s 的精度信息，使用默认精度4", coin)
+	t.log().Warn("未找到精度信息，使用默认精度4", "coin", coin)
 	return 4 // 默认精度
 }
 
@@ -1,5 +1,10 @@
 package trader
 
+import (
+	"log/slog"
+	"time"
+)
+
 // Trader 交易器统一接口
 // 支持多个交易平台（币安、Hyperliquid等）
 type Trader interface {
@@ -42,3 +47,38 @@ type Trader interface {
 	// FormatQuantity 格式化数量到正确的精度
 	FormatQuantity(symbol string, quantity float64) (string, error)
 }
+
+// OpenOrderLister 可查询当前挂单的交易器（可选能力，启动对账时用于检查止损止盈单）
+type OpenOrderLister interface {
+	// GetOpenOrders 获取所有币种的当前挂单
+	// 每项包含 orderId (int64), symbol, positionSide (LONG/SHORT), type (STOP_MARKET/TAKE_PROFIT_MARKET...), stopPrice, quantity
+	GetOpenOrders() ([]map[string]interface{}, error)
+}
+
+// FundingFeeProvider 可查询资金费流水的交易器（可选能力）
+type FundingFeeProvider interface {
+	// GetFundingFees 获取该币种自 since 起的资金费净收入（负数表示支付）
+	// 交易所按币种结算，双向持仓时为两个方向的合计
+	GetFundingFees(symbol string, since time.Time) (float64, error)
+}
+
+// OrderCanceller 可按订单ID撤单的交易器（可选能力，清理残留挂单时只撤对应方向）
+type OrderCanceller interface {
+	CancelOrder(symbol string, orderID int64) error
+}
+
+// exchangeLog 交易所实现内嵌的日志上下文，由NewExchangeTrader设置为所属交易员的logger
+type exchangeLog struct {
+	logger *slog.Logger
+}
+
+func (e *exchangeLog) log() *slog.Logger {
+	if e.logger == nil {
+		return slog.Default()
+	}
+	return e.logger
+}
+
+func (e *exchangeLog) setLogger(l *slog.Logger) {
+	e.logger = l
+}
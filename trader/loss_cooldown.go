@@ -0,0 +1,81 @@
+package trader
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	lossCooldownMu     sync.RWMutex
+	maxConsecutiveLoss = 3                // 连续亏损达到此笔数进入冷却（<=0 关闭）
+	lossCooldownPeriod = 60 * time.Minute // 冷却时长
+)
+
+// SetLossCooldown 设置连续亏损冷却：连续亏损 maxLosses 笔后只分析不开仓，cooldown 后自动恢复（maxLosses<=0 关闭）
+func SetLossCooldown(maxLosses int, cooldown time.Duration) error {
+	if maxLosses > 0 && cooldown <= 0 {
+		return fmt.Errorf("冷却时长必须大于0")
+	}
+	lossCooldownMu.Lock()
+	defer lossCooldownMu.Unlock()
+	maxConsecutiveLoss = maxLosses
+	lossCooldownPeriod = cooldown
+	return nil
+}
+
+// getLossCooldown 获取当前连续亏损冷却参数
+func getLossCooldown() (int, time.Duration) {
+	lossCooldownMu.RLock()
+	defer lossCooldownMu.RUnlock()
+	return maxConsecutiveLoss, lossCooldownPeriod
+}
+
+// recordTradeResult 记录一笔平仓盈亏，连续亏损达到上限时进入冷却
+func (at *AutoTrader) recordTradeResult(pnl float64, now time.Time) {
+	at.lossStreakMu.Lock()
+	defer at.lossStreakMu.Unlock()
+
+	if pnl >= 0 {
+		at.lossStreak = 0
+		return
+	}
+	at.lossStreak++
+
+	maxLosses, cooldown := getLossCooldown()
+	if maxLosses <= 0 || at.lossStreak < maxLosses {
+		return
+	}
+	at.cooldownUntil = now.Add(cooldown)
+	at.log().Warn("连续亏损，进入只分析模式", "loss_streak", at.lossStreak, "cooldown", cooldown, "until", at.cooldownUntil)
+	// 冷却结束后重新计数
+	at.lossStreak = 0
+}
+
+// lossCooldownRemaining 连续亏损冷却剩余时间（不在冷却中为0）
+func (at *AutoTrader) lossCooldownRemaining(now time.Time) time.Duration {
+	at.lossStreakMu.Lock()
+	defer at.lossStreakMu.Unlock()
+	if now.Before(at.cooldownUntil) {
+		return at.cooldownUntil.Sub(now)
+	}
+	return 0
+}
+
+// getLossCooldownStatus 连续亏损冷却状态（用于API）
+func (at *AutoTrader) getLossCooldownStatus() map[string]interface{} {
+	at.lossStreakMu.Lock()
+	defer at.lossStreakMu.Unlock()
+
+	maxLosses, cooldown := getLossCooldown()
+	status := map[string]interface{}{
+		"loss_streak":            at.lossStreak,
+		"max_consecutive_losses": maxLosses,
+		"cooldown":               cooldown.String(),
+		"analysis_only":          time.Now().Before(at.cooldownUntil),
+	}
+	if !at.cooldownUntil.IsZero() {
+		status["cooldown_until"] = at.cooldownUntil.Format(time.RFC3339)
+	}
+	return status
+}
@@ -0,0 +1,48 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLossCooldown(t *testing.T) {
+	defer SetLossCooldown(3, 60*time.Minute)
+	if err := SetLossCooldown(3, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLossCooldown(2, 0); err == nil {
+		t.Error("冷却时长为0应返回错误")
+	}
+
+	at := &AutoTrader{name: "test"}
+	now := time.Now()
+
+	at.recordTradeResult(-10, now)
+	at.recordTradeResult(-5, now)
+	at.recordTradeResult(3, now) // 盈利打断连亏
+	at.recordTradeResult(-1, now)
+	at.recordTradeResult(-1, now)
+	if at.lossCooldownRemaining(now) != 0 {
+		t.Fatal("连亏未达3笔不应冷却")
+	}
+
+	at.recordTradeResult(-1, now)
+	if remaining := at.lossCooldownRemaining(now); remaining != time.Hour {
+		t.Fatalf("连亏3笔应冷却1小时，得到 %s", remaining)
+	}
+	if at.lossCooldownRemaining(now.Add(time.Hour+time.Second)) != 0 {
+		t.Error("冷却到期后应自动恢复")
+	}
+	if at.lossStreak != 0 {
+		t.Errorf("进入冷却后连亏应重新计数: %d", at.lossStreak)
+	}
+
+	SetLossCooldown(0, 0)
+	other := &AutoTrader{name: "disabled"}
+	for i := 0; i < 5; i++ {
+		other.recordTradeResult(-1, now)
+	}
+	if other.lossCooldownRemaining(now) != 0 {
+		t.Error("关闭后不应进入冷却")
+	}
+}
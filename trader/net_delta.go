@@ -0,0 +1,112 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/decision"
+	"sync"
+)
+
+var (
+	netDeltaMu     sync.RWMutex
+	maxNetDeltaPct = 0.0 // 净方向敞口占净值的上限百分比（<=0 不限制）
+)
+
+// SetMaxNetDelta 设置净方向敞口上限：开仓后 |多头名义价值 - 空头名义价值| 不能超过净值的 pct%（pct<=0 不限制）
+func SetMaxNetDelta(pct float64) error {
+	if pct < 0 {
+		return fmt.Errorf("净敞口上限百分比不能为负数: %.2f", pct)
+	}
+	netDeltaMu.Lock()
+	defer netDeltaMu.Unlock()
+	maxNetDeltaPct = pct
+	return nil
+}
+
+// getMaxNetDelta 获取当前净方向敞口上限百分比
+func getMaxNetDelta() float64 {
+	netDeltaMu.RLock()
+	defer netDeltaMu.RUnlock()
+	return maxNetDeltaPct
+}
+
+// netDelta 持仓的净方向敞口（多头为正，空头为负）
+func netDelta(positions []decision.PositionInfo) float64 {
+	net := 0.0
+	for _, pos := range positions {
+		net += signedNotional(pos.Side, pos.Quantity*pos.MarkPrice)
+	}
+	return net
+}
+
+// signedNotional 按方向给名义价值加符号
+func signedNotional(side string, notional float64) float64 {
+	if side == "short" {
+		return -notional
+	}
+	return notional
+}
+
+// netDeltaGuard 本周期执行决策时跟踪净敞口，拒绝让账户过度单边的开仓
+type netDeltaGuard struct {
+	maxPct   float64
+	equity   float64
+	notional map[string]float64 // symbol_side -> 带符号名义价值
+}
+
+// newNetDeltaGuard 以当前持仓为起点
+func newNetDeltaGuard(ctx *decision.Context, maxPct float64) *netDeltaGuard {
+	g := &netDeltaGuard{
+		maxPct:   maxPct,
+		equity:   ctx.Account.TotalEquity,
+		notional: make(map[string]float64),
+	}
+	for _, pos := range ctx.Positions {
+		g.notional[pos.Symbol+"_"+pos.Side] += signedNotional(pos.Side, pos.Quantity*pos.MarkPrice)
+	}
+	return g
+}
+
+// net 当前净敞口
+func (g *netDeltaGuard) net() float64 {
+	net := 0.0
+	for _, v := range g.notional {
+		net += v
+	}
+	return net
+}
+
+// check 开仓后净敞口超过上限且比现在更单边时返回错误（减小净敞口的开仓总是允许）
+func (g *netDeltaGuard) check(d *decision.Decision) error {
+	if g.maxPct <= 0 || g.equity <= 0 || !isOpenAction(d.Action) {
+		return nil
+	}
+	current := g.net()
+	projected := current + signedNotional(openSide(d.Action), d.PositionSizeUSD)
+	limit := g.equity * g.maxPct / 100
+	if math.Abs(projected) <= limit || math.Abs(projected) <= math.Abs(current) {
+		return nil
+	}
+	return fmt.Errorf("开仓后净敞口 %.0f USDT 超过上限 %.0f USDT（净值的 %.0f%%）", projected, limit, g.maxPct)
+}
+
+// apply 决策执行成功后更新净敞口
+func (g *netDeltaGuard) apply(d *decision.Decision) {
+	switch d.Action {
+	case "open_long", "open_short":
+		side := openSide(d.Action)
+		g.notional[d.Symbol+"_"+side] += signedNotional(side, d.PositionSizeUSD)
+	case "close_long":
+		delete(g.notional, d.Symbol+"_long")
+	case "close_short":
+		delete(g.notional, d.Symbol+"_short")
+	}
+}
+
+// openSide 开仓动作对应的持仓方向
+func openSide(action string) string {
+	if action == "open_short" {
+		return "short"
+	}
+	return "long"
+}
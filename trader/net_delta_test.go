@@ -0,0 +1,54 @@
+package trader
+
+import (
+	"nofx/decision"
+	"testing"
+)
+
+func TestNetDeltaGuard(t *testing.T) {
+	ctx := &decision.Context{
+		Account: decision.AccountInfo{TotalEquity: 1000},
+		Positions: []decision.PositionInfo{
+			{Symbol: "BTCUSDT", Side: "long", Quantity: 0.02, MarkPrice: 60000},
+			{Symbol: "ETHUSDT", Side: "short", Quantity: 0.1, MarkPrice: 3000},
+		},
+	}
+	if got := netDelta(ctx.Positions); got != 900 {
+		t.Fatalf("净敞口应为900，实际 %.2f", got)
+	}
+
+	g := newNetDeltaGuard(ctx, 100)
+	cases := []struct {
+		name    string
+		d       decision.Decision
+		wantErr bool
+	}{
+		{"同向超过上限", decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 200}, true},
+		{"刚好达到上限", decision.Decision{Symbol: "SOLUSDT", Action: "open_long", PositionSizeUSD: 100}, false},
+		{"反向开仓减小净敞口", decision.Decision{Symbol: "SOLUSDT", Action: "open_short", PositionSizeUSD: 1500}, false},
+		{"反向开仓翻成过度空头", decision.Decision{Symbol: "SOLUSDT", Action: "open_short", PositionSizeUSD: 2000}, true},
+		{"平仓不受限制", decision.Decision{Symbol: "ETHUSDT", Action: "close_short"}, false},
+	}
+	for _, c := range cases {
+		if err := g.check(&c.d); (err != nil) != c.wantErr {
+			t.Errorf("%s: 期望错误=%v，实际 %v", c.name, c.wantErr, err)
+		}
+	}
+
+	// 平掉空仓后净多头更大，同样规模的开多不再允许
+	g.apply(&decision.Decision{Symbol: "ETHUSDT", Action: "close_short"})
+	if g.net() != 1200 {
+		t.Fatalf("平空后净敞口应为1200，实际 %.2f", g.net())
+	}
+	if err := g.check(&decision.Decision{Symbol: "SOLUSDT", Action: "open_short", PositionSizeUSD: 100}); err != nil {
+		t.Errorf("已超上限时减小净敞口的开仓应允许: %v", err)
+	}
+	g.apply(&decision.Decision{Symbol: "SOLUSDT", Action: "open_short", PositionSizeUSD: 300})
+	if g.net() != 900 {
+		t.Errorf("开空后净敞口应为900，实际 %.2f", g.net())
+	}
+
+	if err := newNetDeltaGuard(ctx, 0).check(&decision.Decision{Action: "open_long", PositionSizeUSD: 1e6}); err != nil {
+		t.Errorf("上限为0时不应限制: %v", err)
+	}
+}
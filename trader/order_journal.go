@@ -0,0 +1,200 @@
+package trader
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"nofx/logger"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 订单状态
+const (
+	OrderStatusSubmitted = "submitted" // 已提交交易所，等待结果（重试时仍为此状态）
+	OrderStatusOpen      = "open"      // 挂在交易所上（条件单，或市价单未立即成交）
+	OrderStatusFilled    = "filled"
+	OrderStatusRejected  = "rejected"
+	OrderStatusUnknown   = "unknown" // 网络错误，订单可能已到达交易所
+	OrderStatusCanceled  = "canceled"
+	OrderStatusTriggered = "triggered" // 止损/止盈条件单已触发
+)
+
+// 订单类型
+const (
+	OrderTypeMarket     = "market"
+	OrderTypeStopLoss   = "stop_loss"
+	OrderTypeTakeProfit = "take_profit"
+)
+
+// CancelReasonPositionClosed 条件单因对应持仓已不存在而撤销
+const CancelReasonPositionClosed = "position_closed"
+
+// OrderUpdate 订单的一次状态变化，同一 ClientOrderID 的多次更新构成订单的生命周期
+type OrderUpdate struct {
+	TraderID        string
+	ClientOrderID   string // 系统生成的订单标识（不发送给交易所）
+	ExchangeOrderID string // 交易所订单ID，交易所未返回时为空
+	Symbol          string
+	Action          string // open_long / close_short 等，条件单为其平仓方向
+	Type            string
+	Status          string
+	Quantity        float64 // 0 表示全部平仓
+	Price           float64 // 下单时的行情价
+	FillPrice       float64 // 成交均价，未知时为0
+	StopPrice       float64 // 条件单触发价
+	Reason          string  // 拒绝/撤销原因或重试说明
+	Time            time.Time
+}
+
+// OrderJournal 持久化订单生命周期，由 manager 注入数据库实现
+type OrderJournal interface {
+	RecordOrder(update OrderUpdate) error
+}
+
+// SetOrderJournal 设置订单记录器（nil 表示不记录），应在启动交易员前调用
+func (at *AutoTrader) SetOrderJournal(journal OrderJournal) {
+	at.orderJournal = journal
+}
+
+// journalOrder 记录订单状态变化，写入失败只记录日志，不影响交易
+func (at *AutoTrader) journalOrder(update OrderUpdate) {
+	if at.orderJournal == nil || update.ClientOrderID == "" {
+		return
+	}
+	update.TraderID = at.id
+	if update.Time.IsZero() {
+		update.Time = time.Now()
+	}
+	if err := at.orderJournal.RecordOrder(update); err != nil {
+		at.log().Warn("记录订单状态失败", "client_order_id", update.ClientOrderID, "status", update.Status, "error", err)
+	}
+}
+
+// newClientOrderID 生成订单标识
+func newClientOrderID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "nofx-" + hex.EncodeToString(b)
+}
+
+// submitOrder 按重试策略下单并记录订单从提交到成交/拒绝的过程，失败时返回 *ExecutionError
+func (at *AutoTrader) submitOrder(op, symbol string, quantity, price float64, policy RetryPolicy, place func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	update := OrderUpdate{
+		ClientOrderID: newClientOrderID(),
+		Symbol:        symbol,
+		Action:        op,
+		Type:          OrderTypeMarket,
+		Status:        OrderStatusSubmitted,
+		Quantity:      quantity,
+		Price:         price,
+	}
+	at.journalOrder(update)
+
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		order, err := place()
+		if err == nil {
+			at.journalOrder(orderResult(update, order))
+			return order, nil
+		}
+		class := ClassifyError(err)
+		if attempt >= policy.MaxAttempts || !retryable(class, op) {
+			update.Status, update.Reason = OrderStatusRejected, fmt.Sprintf("%s: %v", class, err)
+			if class == ErrClassNetwork {
+				update.Status = OrderStatusUnknown
+			}
+			at.journalOrder(update)
+			return nil, &ExecutionError{Class: class, Op: op, Attempts: attempt, Err: err}
+		}
+		at.log().Warn("下单失败，稍后重试", "symbol", symbol, "action", op, "class", class, "attempt", attempt, "backoff", backoff, "error", err)
+		update.Reason = fmt.Sprintf("第%d次尝试失败（%s），重试: %v", attempt, class, err)
+		at.journalOrder(update)
+		retrySleep(backoff)
+		backoff *= 2
+	}
+}
+
+// sendOrder 不重试地下单并记录订单，返回交易所的原始错误（用于平仓保护、一键平仓等自动操作）
+func (at *AutoTrader) sendOrder(op, symbol string, quantity, price float64, place func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	order, err := at.submitOrder(op, symbol, quantity, price, RetryPolicy{MaxAttempts: 1}, place)
+	var execErr *ExecutionError
+	if errors.As(err, &execErr) {
+		return nil, execErr.Err
+	}
+	return order, err
+}
+
+// orderResult 根据交易所返回的订单生成状态更新
+func orderResult(update OrderUpdate, order map[string]interface{}) OrderUpdate {
+	update.Reason = ""
+	update.FillPrice = orderFillPrice(order)
+	switch id := order["orderId"].(type) {
+	case int64:
+		if id != 0 {
+			update.ExchangeOrderID = strconv.FormatInt(id, 10)
+		}
+	case string:
+		update.ExchangeOrderID = id
+	}
+
+	// 币安返回 futures.OrderStatusType，模拟盘和 Hyperliquid 返回字符串
+	switch strings.ToUpper(fmt.Sprint(order["status"])) {
+	case "NEW", "PARTIALLY_FILLED":
+		update.Status = OrderStatusOpen
+	case "CANCELED", "EXPIRED":
+		update.Status = OrderStatusCanceled
+	case "REJECTED":
+		update.Status = OrderStatusRejected
+	default:
+		update.Status = OrderStatusFilled
+	}
+	return update
+}
+
+// placeProtectiveOrder 挂出止损或止盈单并记录，返回订单标识（失败时为空）
+func (at *AutoTrader) placeProtectiveOrder(orderType, symbol, side string, quantity, stopPrice float64, set func() error) string {
+	update := OrderUpdate{
+		ClientOrderID: newClientOrderID(),
+		Symbol:        symbol,
+		Action:        "close_" + side,
+		Type:          orderType,
+		Status:        OrderStatusOpen,
+		Quantity:      quantity,
+		StopPrice:     stopPrice,
+	}
+	if err := set(); err != nil {
+		update.Status, update.Reason = OrderStatusRejected, err.Error()
+		at.journalOrder(update)
+		return ""
+	}
+	at.journalOrder(update)
+	return update.ClientOrderID
+}
+
+// finishProtectiveOrders 交易所侧平仓后把触发平仓的条件单记为已触发，
+// 其余条件单随后由 cleanupOrphanOrders 撤掉并记为已撤销
+func (at *AutoTrader) finishProtectiveOrders(key, exitReason string, exitPrice float64) {
+	order, ok := at.placedOrders[key]
+	if !ok {
+		return
+	}
+	switch exitReason {
+	case logger.ExitReasonStopLoss:
+		at.journalOrder(OrderUpdate{ClientOrderID: order.stopLossID, Status: OrderStatusTriggered, FillPrice: exitPrice})
+		order.stopLossID = ""
+	case logger.ExitReasonTakeProfit:
+		at.journalOrder(OrderUpdate{ClientOrderID: order.takeProfitID, Status: OrderStatusTriggered, FillPrice: exitPrice})
+		order.takeProfitID = ""
+	}
+	at.placedOrders[key] = order
+}
+
+// cancelProtectiveOrders 记录该方向的条件单已撤销
+func (at *AutoTrader) cancelProtectiveOrders(order placedOrder, reason string) {
+	for _, id := range []string{order.stopLossID, order.takeProfitID} {
+		at.journalOrder(OrderUpdate{ClientOrderID: id, Status: OrderStatusCanceled, Reason: reason})
+	}
+}
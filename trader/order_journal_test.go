@@ -0,0 +1,101 @@
+package trader
+
+import (
+	"errors"
+	"nofx/logger"
+	"testing"
+	"time"
+)
+
+// memJournal 记录到内存的订单记录器
+type memJournal struct {
+	updates []OrderUpdate
+}
+
+func (j *memJournal) RecordOrder(u OrderUpdate) error {
+	j.updates = append(j.updates, u)
+	return nil
+}
+
+// statuses 某笔订单依次经历的状态
+func (j *memJournal) statuses(id string) []string {
+	var list []string
+	for _, u := range j.updates {
+		if u.ClientOrderID == id {
+			list = append(list, u.Status)
+		}
+	}
+	return list
+}
+
+func TestSubmitOrderJournal(t *testing.T) {
+	retrySleep = func(time.Duration) {}
+	t.Cleanup(func() { retrySleep = time.Sleep })
+
+	journal := &memJournal{}
+	at := &AutoTrader{id: "t1", name: "test", orderJournal: journal}
+
+	calls := 0
+	_, err := at.submitOrder("open_long", "BTCUSDT", 0.01, 60000, RetryPolicy{MaxAttempts: 3}, func() (map[string]interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("too many requests")
+		}
+		return map[string]interface{}{"orderId": int64(42), "status": "FILLED", "avgPrice": "60010"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := journal.updates[0].ClientOrderID
+	if got := journal.statuses(id); len(got) != 3 || got[0] != OrderStatusSubmitted || got[1] != OrderStatusSubmitted || got[2] != OrderStatusFilled {
+		t.Fatalf("重试后成交的状态变化错误: %v", got)
+	}
+	last := journal.updates[2]
+	if last.TraderID != "t1" || last.ExchangeOrderID != "42" || last.FillPrice != 60010 || last.Quantity != 0.01 || last.Reason != "" {
+		t.Errorf("成交记录错误: %+v", last)
+	}
+
+	// 网络错误的开仓不重试，状态未知
+	journal.updates = nil
+	_, err = at.submitOrder("open_short", "ETHUSDT", 1, 3000, RetryPolicy{MaxAttempts: 3}, func() (map[string]interface{}, error) {
+		return nil, errors.New("i/o timeout")
+	})
+	if err == nil || len(journal.updates) != 2 || journal.updates[1].Status != OrderStatusUnknown {
+		t.Errorf("网络错误应记为状态未知: %v %+v", err, journal.updates)
+	}
+
+	// sendOrder 返回交易所原始错误
+	rejected := errors.New("code=-2019, msg=Margin is insufficient.")
+	if _, err := at.sendOrder("close_long", "BTCUSDT", 0, 0, func() (map[string]interface{}, error) { return nil, rejected }); err != rejected {
+		t.Errorf("sendOrder 应返回原始错误: %v", err)
+	}
+	if u := journal.updates[len(journal.updates)-1]; u.Status != OrderStatusRejected || u.Reason == "" {
+		t.Errorf("拒绝应记录原因: %+v", u)
+	}
+}
+
+func TestProtectiveOrderJournal(t *testing.T) {
+	exchange := NewSimulatedExchange(1000)
+	exchange.SetReplayFeed(map[string]float64{"BTCUSDT": 60000}, time.Now())
+	if _, err := exchange.OpenLong("BTCUSDT", 0.01, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	journal := &memJournal{}
+	at := &AutoTrader{name: "test", trader: exchange, orderJournal: journal}
+	at.setProtectiveOrders("BTCUSDT", "long", 0.01, 58000, 66000)
+	order := at.placedOrders["BTCUSDT_long"]
+	if order.stopLossID == "" || order.takeProfitID == "" {
+		t.Fatalf("止损止盈单应记录订单标识: %+v", order)
+	}
+
+	// 止损触发后，止损单记为已触发，止盈单撤销时记为已撤销
+	at.finishProtectiveOrders("BTCUSDT_long", logger.ExitReasonStopLoss, 58000)
+	at.cleanupOrphanOrders(map[string]bool{})
+	if got := journal.statuses(order.stopLossID); len(got) != 2 || got[1] != OrderStatusTriggered {
+		t.Errorf("止损单状态错误: %v", got)
+	}
+	if got := journal.statuses(order.takeProfitID); len(got) != 2 || got[1] != OrderStatusCanceled {
+		t.Errorf("止盈单状态错误: %v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package trader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// placedOrder 系统为某个持仓挂出的止损/止盈单
+type placedOrder struct {
+	symbol       string
+	side         string // long / short
+	placedAt     time.Time
+	stopLossID   string // 订单记录中的标识，重启后接管的挂单为空
+	takeProfitID string
+}
+
+// setProtectiveOrders 设置止损止盈，成功挂出的单会被记录，持仓消失后自动撤掉
+func (at *AutoTrader) setProtectiveOrders(symbol, side string, quantity, stopLoss, takeProfit float64) {
+	positionSide := strings.ToUpper(side)
+	stopLossID := at.placeProtectiveOrder(OrderTypeStopLoss, symbol, side, quantity, stopLoss, func() error {
+		err := at.trader.SetStopLoss(symbol, positionSide, quantity, stopLoss)
+		if err != nil {
+			at.log().Warn("设置止损失败", "symbol", symbol, "side", side, "error", err)
+		}
+		return err
+	})
+	takeProfitID := at.placeProtectiveOrder(OrderTypeTakeProfit, symbol, side, quantity, takeProfit, func() error {
+		err := at.trader.SetTakeProfit(symbol, positionSide, quantity, takeProfit)
+		if err != nil {
+			at.log().Warn("设置止盈失败", "symbol", symbol, "side", side, "error", err)
+		}
+		return err
+	})
+	if stopLossID != "" || takeProfitID != "" {
+		at.recordPlacedOrder(symbol, side, stopLossID, takeProfitID)
+	}
+}
+
+// recordPlacedOrder 记录该持仓方向上有系统挂出的条件单，ID 为订单记录中的标识（未知时为空）
+func (at *AutoTrader) recordPlacedOrder(symbol, side, stopLossID, takeProfitID string) {
+	if at.placedOrders == nil {
+		at.placedOrders = make(map[string]placedOrder)
+	}
+	at.placedOrders[symbol+"_"+side] = placedOrder{
+		symbol:       symbol,
+		side:         side,
+		placedAt:     time.Now(),
+		stopLossID:   stopLossID,
+		takeProfitID: takeProfitID,
+	}
+}
+
+// cleanupOrphanOrders 撤掉已经没有对应持仓的系统挂单（例如在交易所页面手动平仓后残留的止损单）
+// 交易器支持按订单撤单时只撤该方向的单；否则只在该币种两个方向都没有持仓时撤掉整个币种的挂单
+func (at *AutoTrader) cleanupOrphanOrders(currentKeys map[string]bool) {
+	var orphaned []string
+	for key := range at.placedOrders {
+		if !currentKeys[key] {
+			orphaned = append(orphaned, key)
+		}
+	}
+	if len(orphaned) == 0 {
+		return
+	}
+	sort.Strings(orphaned)
+
+	lister, canList := at.trader.(OpenOrderLister)
+	canceller, canCancel := at.trader.(OrderCanceller)
+	perOrder := canList && canCancel
+	var openOrders []map[string]interface{}
+	if perOrder {
+		var err error
+		if openOrders, err = lister.GetOpenOrders(); err != nil {
+			at.log().Warn("获取挂单失败，改为按币种撤单", "error", err)
+			perOrder = false
+		}
+	}
+
+	cancelledSymbols := make(map[string]bool)
+	for _, key := range orphaned {
+		order := at.placedOrders[key]
+		if perOrder {
+			cancelled, err := cancelSideOrders(canceller, openOrders, order.symbol, order.side)
+			if err != nil {
+				at.log().Warn("撤销残留挂单失败", "symbol", order.symbol, "side", order.side, "error", err)
+				continue
+			}
+			if cancelled > 0 {
+				at.log().Info("仓位已不存在，撤销残留挂单", "symbol", order.symbol, "side", order.side, "cancelled", cancelled)
+			}
+			at.cancelProtectiveOrders(order, CancelReasonPositionClosed)
+			delete(at.placedOrders, key)
+			continue
+		}
+
+		if currentKeys[order.symbol+"_"+oppositeSide(order.side)] {
+			// 只能按币种撤单，反方向持仓的止损止盈还要保留
+			continue
+		}
+		if !cancelledSymbols[order.symbol] {
+			if err := at.trader.CancelAllOrders(order.symbol); err != nil {
+				at.log().Warn("撤销残留挂单失败", "symbol", order.symbol, "error", err)
+				continue
+			}
+			cancelledSymbols[order.symbol] = true
+			at.log().Info("已无持仓，撤销该币种的残留挂单", "symbol", order.symbol)
+		}
+		at.cancelProtectiveOrders(order, CancelReasonPositionClosed)
+		delete(at.placedOrders, key)
+	}
+}
+
+// cancelSideOrders 撤销指定币种、方向（含单向持仓模式的BOTH）的挂单，返回撤销数量
+func cancelSideOrders(canceller OrderCanceller, openOrders []map[string]interface{}, symbol, side string) (int, error) {
+	cancelled := 0
+	for _, o := range openOrders {
+		orderSymbol, _ := o["symbol"].(string)
+		positionSide, _ := o["positionSide"].(string)
+		if orderSymbol != symbol || (!strings.EqualFold(positionSide, side) && positionSide != "BOTH") {
+			continue
+		}
+		orderID, ok := o["orderId"].(int64)
+		if !ok {
+			return cancelled, fmt.Errorf("挂单缺少订单ID")
+		}
+		if err := canceller.CancelOrder(symbol, orderID); err != nil {
+			return cancelled, err
+		}
+		cancelled++
+	}
+	return cancelled, nil
+}
+
+// oppositeSide 反方向
+func oppositeSide(side string) string {
+	if side == "long" {
+		return "short"
+	}
+	return "long"
+}
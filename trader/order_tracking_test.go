@@ -0,0 +1,67 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCleanupOrphanOrders(t *testing.T) {
+	costs := DefaultSimulationCosts()
+	costs.SlippageBps = 0
+	exchange := NewSimulatedExchangeWithCosts(1000, costs)
+	exchange.SetReplayFeed(map[string]float64{"BTCUSDT": 60000}, time.Now())
+	if _, err := exchange.OpenLong("BTCUSDT", 0.01, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exchange.OpenShort("BTCUSDT", 0.01, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	at := &AutoTrader{name: "test", trader: exchange}
+	at.setProtectiveOrders("BTCUSDT", "short", 0.01, 62000, 54000)
+
+	// 模拟交易所会随平仓撤单，真实交易所手动平仓后条件单会残留：先平仓再挂单
+	if _, err := exchange.CloseLong("BTCUSDT", 0); err != nil {
+		t.Fatal(err)
+	}
+	at.setProtectiveOrders("BTCUSDT", "long", 0.01, 58000, 66000)
+	if orders, _ := exchange.GetOpenOrders(); len(orders) != 4 {
+		t.Fatalf("应有4个挂单: %v", orders)
+	}
+	at.cleanupOrphanOrders(map[string]bool{"BTCUSDT_short": true})
+
+	orders, _ := exchange.GetOpenOrders()
+	if len(orders) != 2 {
+		t.Fatalf("应只撤掉多仓的2个挂单，剩余: %v", orders)
+	}
+	for _, o := range orders {
+		if o["positionSide"] != "SHORT" {
+			t.Errorf("空仓挂单不应被撤: %v", o)
+		}
+	}
+	if _, ok := at.placedOrders["BTCUSDT_long"]; ok {
+		t.Error("已清理的挂单不应继续跟踪")
+	}
+	if _, ok := at.placedOrders["BTCUSDT_short"]; !ok {
+		t.Error("仍有持仓的挂单应继续跟踪")
+	}
+
+	// 没有按单撤单能力时，两边持仓都消失才按币种撤单
+	at.trader = &orderListOnly{exchange}
+	at.cleanupOrphanOrders(map[string]bool{"BTCUSDT_long": true})
+	if orders, _ := exchange.GetOpenOrders(); len(orders) != 2 {
+		t.Errorf("反方向仍有持仓时不应按币种撤单: %v", orders)
+	}
+	at.cleanupOrphanOrders(map[string]bool{})
+	if orders, _ := exchange.GetOpenOrders(); len(orders) != 0 {
+		t.Errorf("两边都平仓后应没有挂单: %v", orders)
+	}
+	if len(at.placedOrders) != 0 {
+		t.Errorf("跟踪记录应清空: %v", at.placedOrders)
+	}
+}
+
+// orderListOnly 屏蔽模拟交易所的按单撤单能力
+type orderListOnly struct {
+	Trader
+}
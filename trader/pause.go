@@ -0,0 +1,27 @@
+package trader
+
+// SetPaused 手动暂停或恢复决策：暂停期间交易员保持运行但跳过决策周期，持仓和止损止盈单不受影响
+// 返回状态是否发生变化
+func (at *AutoTrader) SetPaused(paused bool) bool {
+	at.pauseMu.Lock()
+	changed := at.paused != paused
+	at.paused = paused
+	at.pauseMu.Unlock()
+	if !changed {
+		return false
+	}
+	if paused {
+		at.log().Info("交易员已手动暂停决策")
+	} else {
+		at.log().Info("交易员已恢复决策")
+	}
+	at.saveRuntimeState()
+	return true
+}
+
+// IsPaused 是否被手动暂停
+func (at *AutoTrader) IsPaused() bool {
+	at.pauseMu.Lock()
+	defer at.pauseMu.Unlock()
+	return at.paused
+}
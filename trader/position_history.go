@@ -0,0 +1,114 @@
+package trader
+
+import (
+	"math"
+	"nofx/logger"
+	"time"
+)
+
+// ClosedPosition 一笔已平仓持仓的实际结果
+type ClosedPosition struct {
+	TraderID       string
+	Symbol         string
+	Side           string
+	EntryPrice     float64
+	ExitPrice      float64
+	Quantity       float64
+	Fees           float64 // 按吃单费率估算的开平仓手续费
+	FundingFee     float64 // 持仓期间资金费净收入（负数表示支付）
+	PnL            float64 // 按开平仓价计算的毛盈亏
+	RealizedPnL    float64 // PnL - Fees + FundingFee
+	RMultiple      float64 // 毛盈亏 / 开仓时止损风险，无止损时为0
+	HoldingMinutes float64
+	ExitReason     string
+	OpenedAt       time.Time // 重启前开的仓且首次出现时间未知时为零值
+	ClosedAt       time.Time
+}
+
+// PositionHistory 持久化平仓记录，由 manager 注入数据库实现
+type PositionHistory interface {
+	RecordClosedPosition(p ClosedPosition) error
+}
+
+// SetPositionHistory 设置平仓记录器（nil 表示不记录），应在启动交易员前调用
+func (at *AutoTrader) SetPositionHistory(history PositionHistory) {
+	at.positionHistory = history
+}
+
+// untrackPosition 停止跟踪持仓并返回最后一次看到的状态，没有跟踪记录时只含币种和方向
+func (at *AutoTrader) untrackPosition(symbol, side string) *trackedPosition {
+	key := symbol + "_" + side
+	pos, ok := at.trackedPositions[key]
+	if !ok {
+		return &trackedPosition{symbol: symbol, side: side}
+	}
+	delete(at.trackedPositions, key)
+	return pos
+}
+
+// takerFeeRate 估算手续费用的吃单费率：模拟交易所用其费用设置，实盘按币安普通用户费率估算
+func (at *AutoTrader) takerFeeRate() float64 {
+	if sim, ok := at.trader.(*SimulatedExchange); ok {
+		return sim.costs.TakerFeeRate
+	}
+	return simTakerFeeRate
+}
+
+// closedPosition 根据平仓前的持仓状态计算平仓结果，outcome 为回填到开仓决策的结果（可能为nil）
+// 持仓数量或开仓价未知时退回开仓决策的结果，两者都没有时返回nil
+func (at *AutoTrader) closedPosition(pos *trackedPosition, exitPrice float64, reason string, outcome *logger.DecisionOutcome, now time.Time) *ClosedPosition {
+	p := &ClosedPosition{
+		TraderID:   at.id,
+		Symbol:     pos.symbol,
+		Side:       pos.side,
+		EntryPrice: pos.entryPrice,
+		ExitPrice:  exitPrice,
+		Quantity:   pos.quantity,
+		FundingFee: pos.fundingFee,
+		ExitReason: reason,
+		ClosedAt:   now,
+	}
+	if firstSeen := at.positionFirstSeenTime[pos.symbol+"_"+pos.side]; firstSeen > 0 {
+		p.OpenedAt = time.UnixMilli(firstSeen)
+		p.HoldingMinutes = now.Sub(p.OpenedAt).Minutes()
+	} else if outcome != nil {
+		p.HoldingMinutes = outcome.HoldingMinutes
+	}
+
+	direction := 1.0
+	if pos.side == "short" {
+		direction = -1.0
+	}
+	switch {
+	case pos.quantity > 0 && pos.entryPrice > 0:
+		p.PnL = (exitPrice - pos.entryPrice) * pos.quantity * direction
+		if pos.stopLoss > 0 {
+			if risk := pos.quantity * math.Abs(pos.entryPrice-pos.stopLoss); risk > 0 {
+				p.RMultiple = p.PnL / risk
+			}
+		}
+	case outcome != nil:
+		p.PnL, p.RMultiple = outcome.PnL, outcome.RMultiple
+	default:
+		return nil
+	}
+
+	p.Fees = (p.EntryPrice + p.ExitPrice) * p.Quantity * at.takerFeeRate()
+	p.RealizedPnL = p.PnL - p.Fees + p.FundingFee
+	return p
+}
+
+// recordClosedPosition 写入平仓记录，失败只记录日志
+func (at *AutoTrader) recordClosedPosition(pos *trackedPosition, exitPrice float64, reason string, outcome *logger.DecisionOutcome) {
+	if at.positionHistory == nil {
+		return
+	}
+	p := at.closedPosition(pos, exitPrice, reason, outcome, time.Now())
+	if p == nil {
+		at.log().Warn("持仓数量未知，不写入平仓记录", "symbol", pos.symbol, "side", pos.side)
+		return
+	}
+	if err := at.positionHistory.RecordClosedPosition(*p); err != nil {
+		at.log().Warn("写入平仓记录失败", "symbol", pos.symbol, "side", pos.side, "error", err)
+	}
+}
@@ -0,0 +1,43 @@
+package trader
+
+import (
+	"math"
+	"nofx/logger"
+	"testing"
+	"time"
+)
+
+func TestClosedPosition(t *testing.T) {
+	now := time.Now()
+	at := &AutoTrader{
+		id:                    "t1",
+		trader:                NewSimulatedExchangeWithCosts(1000, DefaultSimulationCosts()),
+		positionFirstSeenTime: map[string]int64{"BTCUSDT_short": now.Add(-90 * time.Minute).UnixMilli()},
+	}
+
+	pos := &trackedPosition{symbol: "BTCUSDT", side: "short", quantity: 0.1, entryPrice: 60000, stopLoss: 61000, fundingFee: -1.5}
+	p := at.closedPosition(pos, 59000, logger.ExitReasonTakeProfit, nil, now)
+	if p == nil {
+		t.Fatal("应生成平仓记录")
+	}
+	wantFees := (60000 + 59000) * 0.1 * simTakerFeeRate
+	if p.PnL != 100 || p.RMultiple != 1 || math.Abs(p.Fees-wantFees) > 1e-9 {
+		t.Errorf("空仓盈亏计算错误: %+v", p)
+	}
+	if math.Abs(p.RealizedPnL-(100-wantFees-1.5)) > 1e-9 {
+		t.Errorf("已实现盈亏应扣除手续费和资金费: %+v", p)
+	}
+	if math.Round(p.HoldingMinutes) != 90 || p.OpenedAt.IsZero() {
+		t.Errorf("持仓时间错误: %+v", p)
+	}
+
+	// 持仓数量未知时退回开仓决策的结果
+	outcome := &logger.DecisionOutcome{PnL: -20, RMultiple: -0.5, HoldingMinutes: 30}
+	p = at.closedPosition(&trackedPosition{symbol: "ETHUSDT", side: "long"}, 3000, logger.ExitReasonStopLoss, outcome, now)
+	if p == nil || p.PnL != -20 || p.RMultiple != -0.5 || p.HoldingMinutes != 30 || !p.OpenedAt.IsZero() {
+		t.Errorf("应使用开仓决策的结果: %+v", p)
+	}
+	if at.closedPosition(&trackedPosition{symbol: "ETHUSDT", side: "long"}, 3000, logger.ExitReasonUnknown, nil, now) != nil {
+		t.Error("无法计算盈亏时不应生成记录")
+	}
+}
@@ -0,0 +1,291 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"nofx/logger"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 孤儿持仓（交易所有、决策日志中没有）的处理策略
+const (
+	OrphanPolicyAdopt = "adopt" // 接管：纳入跟踪，由AI在后续周期决定去留
+	OrphanPolicyClose = "close" // 平仓并撤掉该币种挂单
+)
+
+// 对账发现的不一致类型
+const (
+	MismatchOrphanPosition  = "orphan_position"   // 交易所有持仓，决策日志中没有
+	MismatchMissingPosition = "missing_position"  // 决策日志认为持仓中，交易所已没有（离线期间被平仓）
+	MismatchQuantity        = "quantity_mismatch" // 持仓数量与日志不一致
+	MismatchNoStopLoss      = "no_stop_loss"      // 持仓没有止损挂单
+	MismatchOrphanOrder     = "orphan_order"      // 没有对应持仓的挂单
+)
+
+// quantityTolerance 数量相对误差在此范围内视为一致（精度截断、手续费抵扣等）
+const quantityTolerance = 0.01
+
+var (
+	orphanPolicyMu sync.RWMutex
+	orphanPolicy   = OrphanPolicyAdopt
+)
+
+// SetOrphanPositionPolicy 设置启动对账时孤儿持仓的处理策略
+func SetOrphanPositionPolicy(policy string) error {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if policy != OrphanPolicyAdopt && policy != OrphanPolicyClose {
+		return fmt.Errorf("不支持的孤儿持仓策略: %s", policy)
+	}
+	orphanPolicyMu.Lock()
+	defer orphanPolicyMu.Unlock()
+	orphanPolicy = policy
+	return nil
+}
+
+// getOrphanPositionPolicy 获取当前孤儿持仓策略
+func getOrphanPositionPolicy() string {
+	orphanPolicyMu.RLock()
+	defer orphanPolicyMu.RUnlock()
+	return orphanPolicy
+}
+
+// ReconcileMismatch 一条不一致记录
+type ReconcileMismatch struct {
+	Type     string  `json:"type"`
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side,omitempty"`
+	Expected float64 `json:"expected"` // 日志中的数量
+	Actual   float64 `json:"actual"`   // 交易所的数量
+	Action   string  `json:"action"`   // adopted / closed / cancelled / labeled / none
+	Error    string  `json:"error,omitempty"`
+}
+
+// ReconcileReport 启动对账结果
+type ReconcileReport struct {
+	Time          time.Time           `json:"time"`
+	Policy        string              `json:"policy"`
+	Positions     int                 `json:"positions"`      // 交易所当前持仓数
+	OrdersChecked bool                `json:"orders_checked"` // 交易所是否支持查询挂单
+	Mismatches    []ReconcileMismatch `json:"mismatches"`
+	Error         string              `json:"error,omitempty"` // 对账本身失败的原因
+}
+
+// exchangePosition 对账用的交易所持仓
+type exchangePosition struct {
+	symbol           string
+	side             string
+	quantity         float64
+	entryPrice       float64
+	markPrice        float64
+	liquidationPrice float64
+}
+
+// reconcileOnStart 启动时核对决策日志与交易所的持仓和挂单，按策略处理孤儿持仓，结果通过状态接口返回
+func (at *AutoTrader) reconcileOnStart() {
+	report := &ReconcileReport{
+		Time:       time.Now(),
+		Policy:     getOrphanPositionPolicy(),
+		Mismatches: []ReconcileMismatch{},
+	}
+	defer func() {
+		at.reconcileMu.Lock()
+		at.reconcileReport = report
+		at.reconcileMu.Unlock()
+	}()
+
+	expected, err := at.decisionLogger.GetExpectedPositions()
+	if err != nil {
+		report.Error = fmt.Sprintf("读取决策日志失败: %v", err)
+		at.log().Warn("启动对账失败", "error", report.Error)
+		return
+	}
+	rawPositions, err := at.trader.GetPositions()
+	if err != nil {
+		report.Error = fmt.Sprintf("获取交易所持仓失败: %v", err)
+		at.log().Warn("启动对账失败", "error", report.Error)
+		return
+	}
+	actual := parseExchangePositions(rawPositions)
+	report.Positions = len(actual)
+
+	var orders []map[string]interface{}
+	if lister, ok := at.trader.(OpenOrderLister); ok {
+		if orders, err = lister.GetOpenOrders(); err != nil {
+			at.log().Warn("获取挂单失败，跳过挂单检查", "error", err)
+		} else {
+			report.OrdersChecked = true
+		}
+	}
+
+	mismatches := compareState(expected, actual, orders, report.OrdersChecked)
+	closed := make(map[string]bool)
+	for i := range mismatches {
+		m := &mismatches[i]
+		at.resolveMismatch(m, report.Policy, expected)
+		if m.Action == "closed" {
+			closed[m.Symbol+"_"+m.Side] = true
+		}
+	}
+	report.Mismatches = mismatches
+
+	// 保留的持仓带上日志中的止损止盈开始跟踪，重启后仍能判断交易所侧平仓原因
+	for key, pos := range actual {
+		if closed[key] {
+			continue
+		}
+		var stopLoss, takeProfit float64
+		if exp, ok := expected[key]; ok {
+			stopLoss, takeProfit = exp.StopLoss, exp.TakeProfit
+		}
+		at.trackOpenedPosition(pos.symbol, pos.side, pos.quantity, pos.entryPrice, stopLoss, takeProfit)
+		at.trackedPositions[key].markPrice = pos.markPrice
+		at.trackedPositions[key].liquidationPrice = pos.liquidationPrice
+		// 重启前挂出的止损止盈同样需要在持仓消失后撤掉
+		at.recordPlacedOrder(pos.symbol, pos.side, "", "")
+	}
+
+	if len(mismatches) == 0 {
+		at.log().Info("启动对账完成，持仓与决策日志一致", "positions", len(actual))
+		return
+	}
+	at.log().Warn("启动对账发现不一致", "count", len(mismatches), "policy", report.Policy)
+	for _, m := range mismatches {
+		at.log().Warn("对账不一致", "type", m.Type, "symbol", m.Symbol, "side", m.Side,
+			"expected", m.Expected, "actual", m.Actual, "action", m.Action, "error", m.Error)
+	}
+}
+
+// resolveMismatch 按策略处理单条不一致，并记录采取的动作
+func (at *AutoTrader) resolveMismatch(m *ReconcileMismatch, policy string, expected map[string]*logger.ExpectedPosition) {
+	m.Action = "none"
+
+	switch m.Type {
+	case MismatchOrphanPosition:
+		if policy != OrphanPolicyClose {
+			m.Action = "adopted"
+			return
+		}
+		_, err := at.sendOrder("close_"+m.Side, m.Symbol, 0, 0, func() (map[string]interface{}, error) {
+			if m.Side == "long" {
+				return at.trader.CloseLong(m.Symbol, 0)
+			}
+			return at.trader.CloseShort(m.Symbol, 0)
+		})
+		if err != nil {
+			// 平仓失败时仍然接管，避免持仓脱离跟踪
+			m.Error = err.Error()
+			m.Action = "adopted"
+			return
+		}
+		if err := at.trader.CancelAllOrders(m.Symbol); err != nil {
+			at.log().Warn("取消挂单失败", "symbol", m.Symbol, "error", err)
+		}
+		m.Action = "closed"
+
+	case MismatchMissingPosition:
+		exp := expected[m.Symbol+"_"+m.Side]
+		price := exp.EntryPrice
+		if p, err := at.trader.GetMarketPrice(m.Symbol); err == nil && p > 0 {
+			price = p
+		}
+		pos := &trackedPosition{symbol: m.Symbol, side: m.Side, quantity: exp.Quantity, entryPrice: exp.EntryPrice, stopLoss: exp.StopLoss}
+		at.labelOutcome(pos, price, logger.ExitReasonUnknown)
+		m.Action = "labeled"
+
+	case MismatchOrphanOrder:
+		if policy == OrphanPolicyClose {
+			if err := at.trader.CancelAllOrders(m.Symbol); err != nil {
+				m.Error = err.Error()
+				return
+			}
+			m.Action = "cancelled"
+		}
+	}
+}
+
+// parseExchangePositions 转换交易所持仓，key 为 symbol_side
+func parseExchangePositions(raw []map[string]interface{}) map[string]*exchangePosition {
+	positions := make(map[string]*exchangePosition, len(raw))
+	for _, p := range raw {
+		pos := &exchangePosition{}
+		pos.symbol, _ = p["symbol"].(string)
+		pos.side, _ = p["side"].(string)
+		pos.quantity, _ = p["positionAmt"].(float64)
+		pos.entryPrice, _ = p["entryPrice"].(float64)
+		pos.markPrice, _ = p["markPrice"].(float64)
+		pos.liquidationPrice, _ = p["liquidationPrice"].(float64)
+		pos.quantity = math.Abs(pos.quantity)
+		if pos.symbol == "" || pos.quantity == 0 {
+			continue
+		}
+		positions[pos.symbol+"_"+pos.side] = pos
+	}
+	return positions
+}
+
+// compareState 比较日志推算的持仓与交易所实际状态（不执行任何操作），结果按币种排序
+func compareState(expected map[string]*logger.ExpectedPosition, actual map[string]*exchangePosition, orders []map[string]interface{}, checkOrders bool) []ReconcileMismatch {
+	mismatches := []ReconcileMismatch{}
+
+	for key, pos := range actual {
+		exp, ok := expected[key]
+		if !ok {
+			mismatches = append(mismatches, ReconcileMismatch{Type: MismatchOrphanPosition, Symbol: pos.symbol, Side: pos.side, Actual: pos.quantity})
+			continue
+		}
+		if exp.Quantity > 0 && math.Abs(pos.quantity-exp.Quantity)/exp.Quantity > quantityTolerance {
+			mismatches = append(mismatches, ReconcileMismatch{Type: MismatchQuantity, Symbol: pos.symbol, Side: pos.side, Expected: exp.Quantity, Actual: pos.quantity})
+		}
+	}
+	for key, exp := range expected {
+		if _, ok := actual[key]; !ok {
+			mismatches = append(mismatches, ReconcileMismatch{Type: MismatchMissingPosition, Symbol: exp.Symbol, Side: exp.Side, Expected: exp.Quantity})
+		}
+	}
+
+	if checkOrders {
+		hasStop := make(map[string]bool)
+		orderSymbols := make(map[string]bool)
+		for _, o := range orders {
+			symbol, _ := o["symbol"].(string)
+			orderType, _ := o["type"].(string)
+			positionSide, _ := o["positionSide"].(string)
+			orderSymbols[symbol] = true
+			if orderType == "STOP_MARKET" || orderType == "STOP" {
+				hasStop[symbol+"_"+strings.ToLower(positionSide)] = true
+				hasStop[symbol+"_both"] = true
+			}
+		}
+
+		positionSymbols := make(map[string]bool)
+		for key, pos := range actual {
+			positionSymbols[pos.symbol] = true
+			if !hasStop[key] && !hasStop[pos.symbol+"_both"] {
+				mismatches = append(mismatches, ReconcileMismatch{Type: MismatchNoStopLoss, Symbol: pos.symbol, Side: pos.side, Actual: pos.quantity})
+			}
+		}
+		for symbol := range orderSymbols {
+			if !positionSymbols[symbol] {
+				mismatches = append(mismatches, ReconcileMismatch{Type: MismatchOrphanOrder, Symbol: symbol})
+			}
+		}
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].Symbol != mismatches[j].Symbol {
+			return mismatches[i].Symbol < mismatches[j].Symbol
+		}
+		return mismatches[i].Type < mismatches[j].Type
+	})
+	return mismatches
+}
+
+// GetReconcileReport 获取最近一次启动对账的结果（尚未对账时为nil）
+func (at *AutoTrader) GetReconcileReport() *ReconcileReport {
+	at.reconcileMu.Lock()
+	defer at.reconcileMu.Unlock()
+	return at.reconcileReport
+}
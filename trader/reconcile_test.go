@@ -0,0 +1,111 @@
+package trader
+
+import (
+	"nofx/logger"
+	"testing"
+	"time"
+)
+
+func TestCompareState(t *testing.T) {
+	expected := map[string]*logger.ExpectedPosition{
+		"BTCUSDT_long":  {Symbol: "BTCUSDT", Side: "long", Quantity: 0.1},
+		"ETHUSDT_short": {Symbol: "ETHUSDT", Side: "short", Quantity: 1},
+		"SOLUSDT_long":  {Symbol: "SOLUSDT", Side: "long", Quantity: 10},
+	}
+	actual := map[string]*exchangePosition{
+		"BTCUSDT_long":  {symbol: "BTCUSDT", side: "long", quantity: 0.1005},
+		"ETHUSDT_short": {symbol: "ETHUSDT", side: "short", quantity: 0.5},
+		"XRPUSDT_long":  {symbol: "XRPUSDT", side: "long", quantity: 100},
+	}
+	orders := []map[string]interface{}{
+		{"symbol": "BTCUSDT", "positionSide": "LONG", "type": "STOP_MARKET"},
+		{"symbol": "ETHUSDT", "positionSide": "SHORT", "type": "TAKE_PROFIT_MARKET"},
+		{"symbol": "DOGEUSDT", "positionSide": "LONG", "type": "STOP_MARKET"},
+	}
+
+	got := make(map[string]bool)
+	for _, m := range compareState(expected, actual, orders, true) {
+		got[m.Type+":"+m.Symbol] = true
+	}
+	want := []string{
+		MismatchQuantity + ":ETHUSDT",
+		MismatchMissingPosition + ":SOLUSDT",
+		MismatchOrphanPosition + ":XRPUSDT",
+		MismatchNoStopLoss + ":ETHUSDT",
+		MismatchNoStopLoss + ":XRPUSDT",
+		MismatchOrphanOrder + ":DOGEUSDT",
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("缺少不一致记录 %s", w)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("不一致记录数量错误: %v", got)
+	}
+
+	if mismatches := compareState(expected, actual, nil, false); len(mismatches) != 3 {
+		t.Errorf("不检查挂单时只应比较持仓，得到 %+v", mismatches)
+	}
+}
+
+func TestReconcileOnStartClosePolicy(t *testing.T) {
+	defer SetOrphanPositionPolicy(OrphanPolicyAdopt)
+	if err := SetOrphanPositionPolicy("Close"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetOrphanPositionPolicy("ignore"); err == nil {
+		t.Error("不支持的策略应返回错误")
+	}
+
+	costs := DefaultSimulationCosts()
+	costs.SlippageBps = 0
+	exchange := NewSimulatedExchangeWithCosts(1000, costs)
+	exchange.SetReplayFeed(map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000}, time.Now())
+	if _, err := exchange.OpenLong("BTCUSDT", 0.01, 5); err != nil {
+		t.Fatal(err)
+	}
+	exchange.SetStopLoss("BTCUSDT", "LONG", 0.01, 58000)
+	if _, err := exchange.OpenShort("ETHUSDT", 0.1, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	decisionLogger := logger.NewDecisionLogger(t.TempDir())
+	decisionLogger.LogDecision(&logger.DecisionRecord{Decisions: []logger.DecisionAction{
+		{Action: "open_long", Symbol: "BTCUSDT", Quantity: 0.01, Price: 60000, StopLoss: 58000, TakeProfit: 66000, Success: true},
+	}})
+
+	at := &AutoTrader{
+		name:             "test",
+		trader:           exchange,
+		decisionLogger:   decisionLogger,
+		trackedPositions: make(map[string]*trackedPosition),
+	}
+	at.reconcileOnStart()
+
+	report := at.GetReconcileReport()
+	if report == nil || report.Error != "" || !report.OrdersChecked {
+		t.Fatalf("对账报告错误: %+v", report)
+	}
+	closed := false
+	for _, m := range report.Mismatches {
+		if m.Type == MismatchOrphanPosition && m.Symbol == "ETHUSDT" && m.Action == "closed" {
+			closed = true
+		}
+	}
+	if !closed {
+		t.Errorf("close策略下孤儿ETH空仓应被平掉: %+v", report.Mismatches)
+	}
+
+	positions, _ := exchange.GetPositions()
+	if len(positions) != 1 || positions[0]["symbol"] != "BTCUSDT" {
+		t.Errorf("应只剩BTC持仓: %v", positions)
+	}
+	tracked := at.trackedPositions["BTCUSDT_long"]
+	if tracked == nil || tracked.stopLoss != 58000 || tracked.takeProfit != 66000 {
+		t.Errorf("BTC持仓应带着日志中的止损止盈开始跟踪: %+v", tracked)
+	}
+	if _, ok := at.trackedPositions["ETHUSDT_short"]; ok {
+		t.Error("已平掉的孤儿持仓不应被跟踪")
+	}
+}
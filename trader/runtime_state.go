@@ -0,0 +1,108 @@
+package trader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// runtimeStateFile 运行时状态文件名，放在交易员的决策日志目录下
+const runtimeStateFile = "runtime_state.json"
+
+// RuntimeState 需要跨重启保留的运行时状态，避免部署或崩溃后运行时长、周期数清零，风控状态丢失
+type RuntimeState struct {
+	StartTime         time.Time           `json:"start_time"`
+	CallCount         int                 `json:"call_count"`
+	StopUntil         time.Time           `json:"stop_until"`             // 风控暂停截止时间
+	LossStreak        int                 `json:"loss_streak"`            // 当前连续亏损笔数
+	CooldownUntil     time.Time           `json:"cooldown_until"`         // 连续亏损冷却截止时间
+	BreakerTrip       *CircuitBreakerTrip `json:"breaker_trip,omitempty"` // 净值熔断，需手动恢复
+	PositionFirstSeen map[string]int64    `json:"position_first_seen"`    // 持仓首次出现时间（毫秒）
+	Paused            bool                `json:"paused,omitempty"`       // 手动暂停决策
+	SavedAt           time.Time           `json:"saved_at"`
+}
+
+// runtimeState 当前运行时状态
+func (at *AutoTrader) runtimeState() RuntimeState {
+	state := RuntimeState{
+		StartTime:         at.startTime,
+		CallCount:         at.callCount,
+		StopUntil:         at.stopUntil,
+		BreakerTrip:       at.GetCircuitBreakerTrip(),
+		PositionFirstSeen: at.positionFirstSeenTime,
+		Paused:            at.IsPaused(),
+		SavedAt:           time.Now(),
+	}
+	at.lossStreakMu.Lock()
+	state.LossStreak, state.CooldownUntil = at.lossStreak, at.cooldownUntil
+	at.lossStreakMu.Unlock()
+	return state
+}
+
+// saveRuntimeState 写入状态文件（先写临时文件再改名，崩溃时不会留下半个文件）
+func (at *AutoTrader) saveRuntimeState() {
+	if at.stateFile == "" {
+		return
+	}
+	at.stateMu.Lock()
+	defer at.stateMu.Unlock()
+
+	data, err := json.MarshalIndent(at.runtimeState(), "", "  ")
+	if err == nil {
+		tmp := at.stateFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, at.stateFile)
+		}
+	}
+	if err != nil {
+		at.log().Warn("保存运行时状态失败", "error", err)
+	}
+}
+
+// restoreRuntimeState 启动时读取上次保存的状态；没有状态文件时保持初始值
+func (at *AutoTrader) restoreRuntimeState() error {
+	if at.stateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(at.stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取运行时状态失败: %w", err)
+	}
+	var state RuntimeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("解析运行时状态失败: %w", err)
+	}
+
+	if !state.StartTime.IsZero() {
+		at.startTime = state.StartTime
+	}
+	at.callCount = state.CallCount
+	at.stopUntil = state.StopUntil
+	at.lossStreakMu.Lock()
+	at.lossStreak, at.cooldownUntil = state.LossStreak, state.CooldownUntil
+	at.lossStreakMu.Unlock()
+	at.breakerMu.Lock()
+	at.breakerTrip = state.BreakerTrip
+	at.breakerMu.Unlock()
+	if state.PositionFirstSeen != nil {
+		at.positionFirstSeenTime = state.PositionFirstSeen
+	}
+	at.pauseMu.Lock()
+	at.paused = state.Paused
+	at.pauseMu.Unlock()
+
+	at.log().Info("已恢复运行时状态",
+		"saved_at", state.SavedAt.Format(time.RFC3339),
+		"call_count", state.CallCount,
+		"paused_until", state.StopUntil,
+		"cooldown_until", state.CooldownUntil,
+		"circuit_breaker", state.BreakerTrip != nil,
+		"paused", state.Paused,
+	)
+	return nil
+}
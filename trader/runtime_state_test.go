@@ -0,0 +1,55 @@
+package trader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRuntimeStateSurvivesRestart(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), runtimeStateFile)
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	cooldown := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+
+	before := &AutoTrader{name: "test", stateFile: stateFile, startTime: start, callCount: 42,
+		positionFirstSeenTime: map[string]int64{"BTCUSDT_long": 1700000000000}}
+	before.cooldownUntil = cooldown
+	before.lossStreak = 2
+	before.breakerTrip = &CircuitBreakerTrip{PeakEquity: 1000, Equity: 850, DropPct: 15}
+	before.paused = true
+	before.saveRuntimeState()
+
+	after := &AutoTrader{name: "test", stateFile: stateFile, startTime: time.Now(), positionFirstSeenTime: map[string]int64{}}
+	if err := after.restoreRuntimeState(); err != nil {
+		t.Fatal(err)
+	}
+	if !after.startTime.Equal(start) || after.callCount != 42 || after.lossStreak != 2 || !after.cooldownUntil.Equal(cooldown) {
+		t.Errorf("运行时长、周期数和冷却状态应恢复: %v %d %d %v", after.startTime, after.callCount, after.lossStreak, after.cooldownUntil)
+	}
+	if trip := after.GetCircuitBreakerTrip(); trip == nil || trip.DropPct != 15 {
+		t.Errorf("净值熔断应保留到手动恢复: %+v", trip)
+	}
+	if !after.IsPaused() {
+		t.Error("手动暂停应在重启后保留")
+	}
+	if after.positionFirstSeenTime["BTCUSDT_long"] != 1700000000000 {
+		t.Errorf("持仓首次出现时间应恢复: %v", after.positionFirstSeenTime)
+	}
+
+	// 手动恢复熔断后（交易员未运行）立即保存
+	if err := after.ResumeFromCircuitBreaker(); err != nil {
+		t.Fatal(err)
+	}
+	again := &AutoTrader{name: "test", stateFile: stateFile}
+	if err := again.restoreRuntimeState(); err != nil || again.GetCircuitBreakerTrip() != nil {
+		t.Errorf("解除的熔断不应在重启后恢复: %v %+v", err, again.GetCircuitBreakerTrip())
+	}
+
+	if err := os.WriteFile(stateFile, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&AutoTrader{stateFile: stateFile}).restoreRuntimeState(); err == nil {
+		t.Error("损坏的状态文件应返回错误")
+	}
+}
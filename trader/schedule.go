@@ -0,0 +1,194 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdayNames 时段规则中的星期缩写
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Schedule 交易时段：时段外交易员保持运行但跳过决策周期，已有持仓和止损止盈单不受影响
+type Schedule struct {
+	Spec     string
+	Location *time.Location
+	windows  []scheduleWindow
+}
+
+// scheduleWindow 一条时段规则，end<=start 表示跨越午夜（属于开始那天）
+type scheduleWindow struct {
+	days       [7]bool
+	start, end int // 当天的分钟数
+}
+
+// ParseSchedule 解析交易时段，规则之间用分号分隔，如 "mon-fri 08:00-22:00; sat 10:00-14:00"
+// 星期支持 mon..sun、范围（fri-mon 可跨周）、逗号列表和 *，timezone 为IANA时区名，空表示UTC
+func ParseSchedule(spec, timezone string) (*Schedule, error) {
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("无效的时区 %q: %w", timezone, err)
+		}
+	}
+
+	s := &Schedule{Spec: spec, Location: loc}
+	for _, rule := range strings.Split(spec, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		fields := strings.Fields(rule)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("时段规则 %q 格式应为 \"<星期> <HH:MM>-<HH:MM>\"", rule)
+		}
+		days, err := parseScheduleDays(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		start, end, err := parseScheduleHours(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, scheduleWindow{days: days, start: start, end: end})
+	}
+	if len(s.windows) == 0 {
+		return nil, fmt.Errorf("交易时段不能为空")
+	}
+	return s, nil
+}
+
+// parseScheduleDays 解析星期部分
+func parseScheduleDays(field string) ([7]bool, error) {
+	var days [7]bool
+	if field == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	for _, part := range strings.Split(strings.ToLower(field), ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return days, fmt.Errorf("无效的星期 %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return days, fmt.Errorf("无效的星期 %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseScheduleHours 解析 HH:MM-HH:MM，结束时间允许 24:00
+func parseScheduleHours(field string) (int, int, error) {
+	from, to, ok := strings.Cut(field, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("时间段 %q 格式应为 HH:MM-HH:MM", field)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == 24*60 {
+		return 0, 0, fmt.Errorf("开始时间不能为 24:00")
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("时间段 %q 的开始和结束时间相同", field)
+	}
+	return start, end, nil
+}
+
+// parseClock 解析 HH:MM 为当天的分钟数
+func parseClock(value string) (int, error) {
+	var h, m int
+	if n, err := fmt.Sscanf(value, "%d:%d", &h, &m); err != nil || n != 2 || len(value) != 5 {
+		return 0, fmt.Errorf("无效的时间 %q，应为 HH:MM", value)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("无效的时间 %q", value)
+	}
+	return h*60 + m, nil
+}
+
+// Active 指定时刻是否处于交易时段
+func (s *Schedule) Active(t time.Time) bool {
+	t = t.In(s.Location)
+	day := t.Weekday()
+	prev := (day + 6) % 7
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range s.windows {
+		if w.start < w.end {
+			if w.days[day] && minute >= w.start && minute < w.end {
+				return true
+			}
+			continue
+		}
+		// 跨午夜：开始当天的晚上，或前一天开始的时段延续到今天凌晨
+		if (w.days[day] && minute >= w.start) || (w.days[prev] && minute < w.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSchedule 设置交易时段（nil 表示全天运行），下一个决策周期生效
+func (at *AutoTrader) SetSchedule(schedule *Schedule) {
+	at.scheduleMu.Lock()
+	defer at.scheduleMu.Unlock()
+	at.schedule = schedule
+}
+
+// GetSchedule 获取交易时段，未设置时返回nil
+func (at *AutoTrader) GetSchedule() *Schedule {
+	at.scheduleMu.Lock()
+	defer at.scheduleMu.Unlock()
+	return at.schedule
+}
+
+// checkSchedule 判断本周期是否在交易时段内，只在进出时段时打印日志
+func (at *AutoTrader) checkSchedule(now time.Time) bool {
+	at.scheduleMu.Lock()
+	defer at.scheduleMu.Unlock()
+
+	active := at.schedule == nil || at.schedule.Active(now)
+	if active == at.outOfSchedule {
+		if active {
+			at.log().Info("进入交易时段，恢复决策")
+		} else {
+			at.log().Info("不在交易时段，暂停决策（持仓保留）", "schedule", at.schedule.Spec, "timezone", at.schedule.Location.String())
+		}
+		at.outOfSchedule = !active
+	}
+	return active
+}
+
+// getScheduleStatus 交易时段状态（用于API），未设置时返回nil
+func (at *AutoTrader) getScheduleStatus() map[string]interface{} {
+	schedule := at.GetSchedule()
+	if schedule == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"spec":     schedule.Spec,
+		"timezone": schedule.Location.String(),
+		"active":   schedule.Active(time.Now()),
+	}
+}
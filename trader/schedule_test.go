@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	s, err := ParseSchedule("mon-fri 08:00-22:00; sat 22:00-02:00", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024-01-01 是周一
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"周一开始", at(1, 8, 0), true},
+		{"周一开始前", at(1, 7, 59), false},
+		{"周五结束", at(5, 22, 0), false},
+		{"周五结束前", at(5, 21, 59), true},
+		{"周六白天", at(6, 12, 0), false},
+		{"周六晚上", at(6, 23, 0), true},
+		{"跨午夜到周日凌晨", at(7, 1, 30), true},
+		{"周日凌晨结束", at(7, 2, 0), false},
+	}
+	for _, c := range cases {
+		if got := s.Active(c.t); got != c.want {
+			t.Errorf("%s: Active(%s) = %v, want %v", c.name, c.t, got, c.want)
+		}
+	}
+
+	// 时区按本地时间判断：上海 09:00 = UTC 01:00
+	sh, err := ParseSchedule("* 09:00-24:00", "Asia/Shanghai")
+	if err != nil {
+		t.Skipf("系统缺少时区数据: %v", err)
+	}
+	if !sh.Active(at(1, 1, 0)) || sh.Active(at(1, 0, 59)) {
+		t.Error("应按设置的时区判断交易时段")
+	}
+
+	weekend, err := ParseSchedule("fri-mon 00:00-24:00", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !weekend.Active(at(7, 12, 0)) || !weekend.Active(at(1, 12, 0)) || weekend.Active(at(3, 12, 0)) {
+		t.Error("星期范围应支持跨周")
+	}
+
+	for _, bad := range []string{"", "mon", "xyz 08:00-10:00", "mon 8:00-10:00", "mon 08:00-08:00", "mon 25:00-26:00", "mon 10:00"} {
+		if _, err := ParseSchedule(bad, ""); err == nil {
+			t.Errorf("ParseSchedule(%q) 应返回错误", bad)
+		}
+	}
+	if _, err := ParseSchedule("mon 08:00-10:00", "Mars/Base"); err == nil {
+		t.Error("无效时区应返回错误")
+	}
+}
+
+func TestCheckSchedule(t *testing.T) {
+	s, err := ParseSchedule("mon 08:00-10:00", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trader := &AutoTrader{name: "test"}
+	monday := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	if !trader.checkSchedule(monday.Add(5 * time.Hour)) {
+		t.Error("未设置时段时应全天运行")
+	}
+
+	trader.SetSchedule(s)
+	if !trader.checkSchedule(monday) {
+		t.Error("时段内应运行")
+	}
+	if trader.checkSchedule(monday.Add(2*time.Hour)) || !trader.outOfSchedule {
+		t.Error("时段外应暂停")
+	}
+	trader.SetSchedule(nil)
+	if !trader.checkSchedule(monday.Add(2*time.Hour)) || trader.outOfSchedule {
+		t.Error("清除时段后应恢复")
+	}
+}
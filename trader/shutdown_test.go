@@ -0,0 +1,42 @@
+package trader
+
+import (
+	"context"
+	"nofx/logger"
+	"testing"
+	"time"
+)
+
+func TestStopWakesRunAndWaitReturns(t *testing.T) {
+	at := &AutoTrader{
+		name:             "test",
+		trader:           NewSimulatedExchange(1000),
+		decisionLogger:   logger.NewDecisionLogger(t.TempDir()),
+		trackedPositions: make(map[string]*trackedPosition),
+		config:           AutoTraderConfig{ScanInterval: time.Hour},
+		stopUntil:        time.Now().Add(time.Hour), // 周期直接记录暂停，不调用AI
+	}
+
+	// 未运行时 Wait 立即返回
+	if err := at.Wait(context.Background()); err != nil {
+		t.Fatalf("未运行时不应等待: %v", err)
+	}
+
+	events, unsubscribe := SubscribeEvents(16)
+	defer unsubscribe()
+	go at.Run()
+	// 等第一个周期记录完决策，Run 进入等待下一周期
+	for event := range events {
+		if event.Type == EventDecision {
+			break
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	at.Stop()
+	if err := at.Wait(ctx); err != nil {
+		t.Fatalf("Stop 后 Run 应立即退出，而不是等到下一个周期: %v", err)
+	}
+	at.Stop() // 重复停止不应panic
+}
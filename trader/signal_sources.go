@@ -0,0 +1,48 @@
+package trader
+
+import (
+	"context"
+	"nofx/decision"
+	"nofx/pool"
+	"time"
+)
+
+// signalSourceTimeout 每个周期请求用户信号源的总超时，超时的信号源本周期跳过
+const signalSourceTimeout = 20 * time.Second
+
+// SetSignalSources 替换用户注册的信号源，下一个决策周期生效
+func (at *AutoTrader) SetSignalSources(sources []pool.SignalSource) {
+	at.sourcesMu.Lock()
+	defer at.sourcesMu.Unlock()
+	at.signalSources = sources
+}
+
+// SignalSources 当前使用的用户信号源
+func (at *AutoTrader) SignalSources() []pool.SignalSource {
+	at.sourcesMu.Lock()
+	defer at.sourcesMu.Unlock()
+	return at.signalSources
+}
+
+// mergeSourceCandidates 请求用户信号源，去重评分后把币种合并到候选列表，来源标记为信号源名称；
+// 单个信号源失败只记录日志，不影响本周期决策。返回数据过期而被丢弃的币种
+func (at *AutoTrader) mergeSourceCandidates(coins []decision.CandidateCoin) ([]decision.CandidateCoin, []pool.DroppedCoin) {
+	sources := at.SignalSources()
+	if len(sources) == 0 {
+		return coins, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), signalSourceTimeout)
+	defer cancel()
+
+	results := pool.FetchAll(ctx, sources)
+	for _, r := range results {
+		if r.Err != nil {
+			at.log().Warn("获取信号源数据失败", "source", r.Source, "error", r.Err)
+		}
+	}
+	candidates, dropped := pool.MergeResults(results, pool.MaxDataAge(), time.Now())
+	if len(dropped) > 0 {
+		at.log().Info("丢弃数据过期的信号源币种", "count", len(dropped), "max_age", pool.MaxDataAge())
+	}
+	return mergePoolCandidates(coins, candidates), dropped
+}
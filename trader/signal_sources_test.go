@@ -0,0 +1,104 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"nofx/decision"
+	"nofx/market"
+	"nofx/pool"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeSource 返回固定结果的信号源
+type fakeSource struct {
+	name  string
+	coins []pool.SourceCoin
+	err   error
+}
+
+func (f fakeSource) Name() string { return f.name }
+
+func (f fakeSource) Fetch(ctx context.Context) ([]pool.SourceCoin, error) { return f.coins, f.err }
+
+func TestMergeSourceCandidates(t *testing.T) {
+	at := &AutoTrader{id: "t1"}
+	base := []decision.CandidateCoin{{Symbol: "BTCUSDT", Sources: []string{"default"}}}
+	if coins, _ := at.mergeSourceCandidates(base); !reflect.DeepEqual(coins, base) {
+		t.Errorf("没有信号源时候选币种不应变化: %+v", coins)
+	}
+
+	stale := time.Now().Add(-pool.MaxDataAge() - time.Minute)
+	at.SetSignalSources([]pool.SignalSource{
+		fakeSource{name: "feed", coins: []pool.SourceCoin{{Symbol: "BTCUSDT"}, {Symbol: "ARBUSDT"}}},
+		fakeSource{name: "old", coins: []pool.SourceCoin{{Symbol: "DOGEUSDT", FetchedAt: stale}}},
+		fakeSource{name: "down", err: errors.New("timeout")},
+	})
+	coins, dropped := at.mergeSourceCandidates(base)
+	want := []decision.CandidateCoin{
+		{Symbol: "BTCUSDT", Sources: []string{"default", "feed"}, Score: 100},
+		{Symbol: "ARBUSDT", Sources: []string{"feed"}, Score: 50},
+	}
+	if !reflect.DeepEqual(coins, want) {
+		t.Errorf("合并信号源错误: %+v", coins)
+	}
+	if len(dropped) != 1 || dropped[0].Symbol != "DOGEUSDT" || dropped[0].Source != "old" {
+		t.Errorf("过期的信号源币种应被丢弃: %+v", dropped)
+	}
+}
+
+func TestNewCandidateReport(t *testing.T) {
+	ctx := &decision.Context{
+		CandidateCoins:    []decision.CandidateCoin{{Symbol: "BTCUSDT"}, {Symbol: "ETHUSDT"}, {Symbol: "PEPEUSDT"}},
+		MarketDataMap:     map[string]*market.Data{"BTCUSDT": {}},
+		SkippedCandidates: map[string]string{"PEPEUSDT": decision.SkipLowLiquidity},
+	}
+	report := newCandidateReport(3, ctx, candidatePool{})
+	var got []string
+	for _, c := range report.Candidates {
+		got = append(got, c.Symbol+":"+c.Status)
+	}
+	want := []string{"BTCUSDT:offered", "ETHUSDT:fetch_failed", "PEPEUSDT:low_liquidity"}
+	if !reflect.DeepEqual(got, want) || report.Cycle != 3 || report.Dropped == nil {
+		t.Errorf("候选报告错误: %v %+v", got, report)
+	}
+}
+
+// countingSource 记录请求次数的信号源
+type countingSource struct {
+	calls *int
+}
+
+func (s countingSource) Name() string { return "feed" }
+
+func (s countingSource) Fetch(ctx context.Context) ([]pool.SourceCoin, error) {
+	*s.calls++
+	return []pool.SourceCoin{{Symbol: "ARBUSDT"}}, nil
+}
+
+func TestLoadCandidatePoolRefresh(t *testing.T) {
+	calls := 0
+	at := &AutoTrader{id: "t1", tradingCoins: []string{"btc"}, config: AutoTraderConfig{PoolRefreshInterval: 10 * time.Minute}}
+	at.SetSignalSources([]pool.SignalSource{countingSource{calls: &calls}})
+
+	now := time.Now()
+	coins, err := at.loadCandidatePool(now)
+	if err != nil || len(coins) != 2 || calls != 1 {
+		t.Fatalf("获取候选币种池失败: %+v %v", coins, err)
+	}
+	coins[0].Sources[0] = "changed"
+
+	coins, _ = at.loadCandidatePool(now.Add(5 * time.Minute))
+	if calls != 1 || coins[0].Sources[0] != "custom" {
+		t.Errorf("刷新间隔内应复用未被修改的缓存: calls=%d %+v", calls, coins)
+	}
+	if at.loadCandidatePool(now.Add(11 * time.Minute)); calls != 2 {
+		t.Errorf("超过刷新间隔应重新获取: calls=%d", calls)
+	}
+
+	at.config.PoolRefreshInterval = 0
+	if at.loadCandidatePool(now.Add(11 * time.Minute)); calls != 3 {
+		t.Errorf("未设置刷新间隔时每个周期都应获取: calls=%d", calls)
+	}
+}
@@ -0,0 +1,90 @@
+package trader
+
+import (
+	"nofx/decision"
+	"slices"
+	"sort"
+	"time"
+)
+
+// maxActiveSignals 每个交易员保留的未过期信号上限，超出时丢弃最早过期的
+const maxActiveSignals = 50
+
+// signalSource 外部信号在候选币种中的来源标记
+const signalSource = "signal"
+
+// PushSignals 保存外部推送的信号（同一币种只保留最新的一条），返回当前未过期的信号数
+func (at *AutoTrader) PushSignals(signals []decision.ExternalSignal) int {
+	at.signalsMu.Lock()
+	defer at.signalsMu.Unlock()
+
+	if at.signals == nil {
+		at.signals = make(map[string]decision.ExternalSignal)
+	}
+	for _, s := range signals {
+		s.Symbol = normalizeSymbol(s.Symbol)
+		at.signals[s.Symbol] = s
+	}
+	active := at.pruneSignalsLocked(time.Now())
+	if len(signals) > 0 {
+		at.log().Info("收到外部信号", "count", len(signals), "active", len(active))
+	}
+	return len(active)
+}
+
+// ActiveSignals 未过期的外部信号，按收到时间排序
+func (at *AutoTrader) ActiveSignals(now time.Time) []decision.ExternalSignal {
+	at.signalsMu.Lock()
+	defer at.signalsMu.Unlock()
+	return at.pruneSignalsLocked(now)
+}
+
+// pruneSignalsLocked 删除过期和超出上限的信号，返回剩余的信号（按收到时间排序）
+func (at *AutoTrader) pruneSignalsLocked(now time.Time) []decision.ExternalSignal {
+	active := make([]decision.ExternalSignal, 0, len(at.signals))
+	for symbol, s := range at.signals {
+		if !now.Before(s.ExpiresAt) {
+			delete(at.signals, symbol)
+			continue
+		}
+		active = append(active, s)
+	}
+	if len(active) > maxActiveSignals {
+		sort.Slice(active, func(i, j int) bool { return active[i].ExpiresAt.After(active[j].ExpiresAt) })
+		for _, s := range active[maxActiveSignals:] {
+			delete(at.signals, s.Symbol)
+		}
+		active = active[:maxActiveSignals]
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ReceivedAt.Before(active[j].ReceivedAt) })
+	return active
+}
+
+// mergeSignalCandidates 把信号币种加入候选列表，已在列表中的币种追加来源标记
+func mergeSignalCandidates(coins []decision.CandidateCoin, signals []decision.ExternalSignal) []decision.CandidateCoin {
+	symbols := make([]string, 0, len(signals))
+	for _, s := range signals {
+		symbols = append(symbols, s.Symbol)
+	}
+	return mergeCandidates(coins, symbols, signalSource)
+}
+
+// mergeCandidates 把 source 给出的币种加入候选列表，已在列表中的币种追加来源标记
+func mergeCandidates(coins []decision.CandidateCoin, symbols []string, source string) []decision.CandidateCoin {
+	index := make(map[string]int, len(coins))
+	for i, coin := range coins {
+		index[coin.Symbol] = i
+	}
+	for _, symbol := range symbols {
+		i, ok := index[symbol]
+		if !ok {
+			index[symbol] = len(coins)
+			coins = append(coins, decision.CandidateCoin{Symbol: symbol, Sources: []string{source}})
+			continue
+		}
+		if !slices.Contains(coins[i].Sources, source) {
+			coins[i].Sources = append(coins[i].Sources, source)
+		}
+	}
+	return coins
+}
@@ -0,0 +1,36 @@
+package trader
+
+import (
+	"nofx/decision"
+	"testing"
+	"time"
+)
+
+func TestSignals(t *testing.T) {
+	at := &AutoTrader{id: "t1"}
+	now := time.Now()
+	active := at.PushSignals([]decision.ExternalSignal{
+		{Symbol: "sol", Side: "long", ReceivedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{Symbol: "DOGEUSDT", ReceivedAt: now, ExpiresAt: now.Add(-time.Minute)},
+	})
+	if active != 1 {
+		t.Fatalf("过期信号不应保留: %d", active)
+	}
+	// 同一币种的新信号覆盖旧信号
+	at.PushSignals([]decision.ExternalSignal{{Symbol: "SOLUSDT", Side: "short", ReceivedAt: now, ExpiresAt: now.Add(time.Hour)}})
+	signals := at.ActiveSignals(now)
+	if len(signals) != 1 || signals[0].Symbol != "SOLUSDT" || signals[0].Side != "short" {
+		t.Errorf("信号错误: %+v", signals)
+	}
+	if signals := at.ActiveSignals(now.Add(2 * time.Hour)); len(signals) != 0 {
+		t.Errorf("信号应已过期: %+v", signals)
+	}
+
+	coins := mergeSignalCandidates(
+		[]decision.CandidateCoin{{Symbol: "BTCUSDT", Sources: []string{"custom"}}},
+		[]decision.ExternalSignal{{Symbol: "BTCUSDT"}, {Symbol: "SOLUSDT"}},
+	)
+	if len(coins) != 2 || len(coins[0].Sources) != 2 || coins[1].Sources[0] != signalSource {
+		t.Errorf("合并候选币种错误: %+v", coins)
+	}
+}
@@ -0,0 +1,751 @@
+package trader
+
+import (
+	"fmt"
+	"nofx/market"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	simTakerFeeRate          = 0.0004        // 模拟吃单手续费率（0.04%）
+	simSlippageBps           = 2.0           // 模拟市价单滑点（基点）
+	simMaintenanceMarginRate = 0.005         // 维持保证金率（0.5%）
+	simFundingInterval       = 8 * time.Hour // 资金费结算间隔（00/08/16 UTC）
+)
+
+// simPosition 模拟持仓
+type simPosition struct {
+	symbol      string
+	side        string // "long" 或 "short"
+	quantity    float64
+	entryPrice  float64
+	markPrice   float64
+	leverage    int
+	margin      float64   // 占用保证金（逐仓）
+	stopLoss    float64   // 当前止损价（用于计算R倍数）
+	openTime    time.Time // 开仓时间
+	nextFunding time.Time // 下次资金费结算时间
+}
+
+// SimulatedTrade 模拟交易所的一笔平仓记录
+type SimulatedTrade struct {
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"`
+	Quantity   float64   `json:"quantity"`
+	Leverage   int       `json:"leverage"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitPrice  float64   `json:"exit_price"`
+	StopLoss   float64   `json:"stop_loss"`
+	PnL        float64   `json:"pnl"` // 扣除平仓手续费后的已实现盈亏
+	OpenTime   time.Time `json:"open_time"`
+	CloseTime  time.Time `json:"close_time"`
+	Liquidated bool      `json:"liquidated"`
+}
+
+// simFunding 一次资金费结算
+type simFunding struct {
+	symbol string
+	time   time.Time
+	amount float64 // 净收入，负数表示支付
+}
+
+// simOrder 模拟止损/止盈条件单
+type simOrder struct {
+	id           int64
+	symbol       string
+	side         string // 对应持仓方向 "long" 或 "short"
+	quantity     float64
+	triggerPrice float64
+	isStopLoss   bool
+}
+
+// SimulatedExchange 纸面交易用的模拟交易所
+// 使用真实行情成交，模拟手续费、滑点、资金费和强平；保证金按逐仓计算，状态只保存在内存中
+type SimulatedExchange struct {
+	mu sync.Mutex
+
+	walletBalance float64
+	positions     map[string]*simPosition // key: symbol_side
+	orders        []*simOrder
+	leverage      map[string]int
+	nextOrderID   int64
+	closedTrades  []SimulatedTrade
+
+	costs        SimulationCosts
+	totalFees    float64
+	totalFunding float64
+	fundingLog   []simFunding
+
+	priceFunc    func(symbol string) (float64, error)
+	fundingFunc  func(symbol string) (float64, error)
+	slippageFunc func(symbol string, notional float64, isBuy bool) float64 // 为空时使用固定滑点
+	now          func() time.Time
+
+	exchangeLog
+}
+
+// NewSimulatedExchange 创建模拟交易所（使用SetPaperTradingCosts设置的费用）
+func NewSimulatedExchange(initialBalance float64) *SimulatedExchange {
+	return NewSimulatedExchangeWithCosts(initialBalance, getPaperTradingCosts())
+}
+
+// NewSimulatedExchangeWithCosts 使用指定费用与滑点模型创建模拟交易所
+func NewSimulatedExchangeWithCosts(initialBalance float64, costs SimulationCosts) *SimulatedExchange {
+	s := &SimulatedExchange{
+		walletBalance: initialBalance,
+		positions:     make(map[string]*simPosition),
+		leverage:      make(map[string]int),
+		costs:         costs,
+		priceFunc:     simulatedMarketPrice,
+		fundingFunc:   market.GetFundingRate,
+		now:           time.Now,
+	}
+	if costs.SlippageModel == SlippageModelDepth {
+		s.slippageFunc = liveDepthSlippage(costs.SlippageBps)
+	}
+	return s
+}
+
+// simulatedMarketPrice 优先使用WebSocket缓存的最新价格，没有缓存时走REST
+func simulatedMarketPrice(symbol string) (float64, error) {
+	symbol = market.Normalize(symbol)
+	if market.WSMonitorCli != nil {
+		if klines, err := market.WSMonitorCli.GetCurrentKlines(symbol, "3m"); err == nil && len(klines) > 0 {
+			return klines[len(klines)-1].Close, nil
+		}
+	}
+	return market.NewAPIClient().GetCurrentPrice(symbol)
+}
+
+// SetReplayFeed 回测时使用记录的价格和时间代替实时行情（回放数据中没有历史资金费率，不结算资金费）
+func (s *SimulatedExchange) SetReplayFeed(prices map[string]float64, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.priceFunc = func(symbol string) (float64, error) {
+		price, ok := prices[market.Normalize(symbol)]
+		if !ok {
+			return 0, fmt.Errorf("回放数据中没有%s的价格", symbol)
+		}
+		return price, nil
+	}
+	s.fundingFunc = func(string) (float64, error) { return 0, nil }
+	s.now = func() time.Time { return now }
+}
+
+// SetReplaySlippage 回测时使用prompt中记录的参考规模滑点（symbol -> 仓位规模USD -> 基点）
+// 只在depth模型下生效；某币种没有记录时退回固定滑点
+func (s *SimulatedExchange) SetReplaySlippage(curves map[string]map[float64]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.costs.SlippageModel != SlippageModelDepth {
+		return
+	}
+	fallback := s.costs.SlippageBps
+	s.slippageFunc = func(symbol string, notional float64, isBuy bool) float64 {
+		if bps, ok := interpolateSlippage(curves[market.Normalize(symbol)], notional); ok {
+			return bps
+		}
+		return fallback
+	}
+}
+
+// GetClosedTrades 获取所有已平仓交易（包括止损止盈触发和强平）
+func (s *SimulatedExchange) GetClosedTrades() []SimulatedTrade {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SimulatedTrade(nil), s.closedTrades...)
+}
+
+// GetBalance 获取账户余额
+func (s *SimulatedExchange) GetBalance() (map[string]interface{}, error) {
+	s.refresh()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unrealized, usedMargin := 0.0, 0.0
+	for _, pos := range s.positions {
+		unrealized += pos.unrealizedPnL()
+		usedMargin += pos.margin
+	}
+
+	available := s.walletBalance + unrealized - usedMargin
+	if available < 0 {
+		available = 0
+	}
+
+	return map[string]interface{}{
+		"totalWalletBalance":    s.walletBalance,
+		"availableBalance":      available,
+		"totalUnrealizedProfit": unrealized,
+		"totalFees":             s.totalFees,
+		"totalFunding":          s.totalFunding,
+	}, nil
+}
+
+// GetPositions 获取所有持仓
+func (s *SimulatedExchange) GetPositions() ([]map[string]interface{}, error) {
+	s.refresh()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []map[string]interface{}
+	for _, pos := range s.positions {
+		amt := pos.quantity
+		if pos.side == "short" {
+			amt = -amt
+		}
+		result = append(result, map[string]interface{}{
+			"symbol":           pos.symbol,
+			"side":             pos.side,
+			"positionAmt":      amt,
+			"entryPrice":       pos.entryPrice,
+			"markPrice":        pos.markPrice,
+			"unRealizedProfit": pos.unrealizedPnL(),
+			"leverage":         float64(pos.leverage),
+			"liquidationPrice": pos.liquidationPrice(),
+		})
+	}
+	return result, nil
+}
+
+// OpenLong 开多仓
+func (s *SimulatedExchange) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return s.open(symbol, "long", quantity, leverage)
+}
+
+// OpenShort 开空仓
+func (s *SimulatedExchange) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return s.open(symbol, "short", quantity, leverage)
+}
+
+// CloseLong 平多仓（quantity=0表示全部平仓）
+func (s *SimulatedExchange) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return s.close(symbol, "long", quantity)
+}
+
+// CloseShort 平空仓（quantity=0表示全部平仓）
+func (s *SimulatedExchange) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return s.close(symbol, "short", quantity)
+}
+
+// SetLeverage 设置杠杆
+func (s *SimulatedExchange) SetLeverage(symbol string, leverage int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leverage[market.Normalize(symbol)] = leverage
+	return nil
+}
+
+// SetMarginMode 模拟交易所统一按逐仓计算，这里只记录日志
+func (s *SimulatedExchange) SetMarginMode(symbol string, isCrossMargin bool) error {
+	if isCrossMargin {
+		s.log().Info("模拟交易所请求全仓模式，按逐仓计算保证金和强平价", "symbol", symbol)
+	}
+	return nil
+}
+
+// GetMarketPrice 获取市场价格
+func (s *SimulatedExchange) GetMarketPrice(symbol string) (float64, error) {
+	return s.priceFunc(symbol)
+}
+
+// SetStopLoss 设置止损单（同一持仓只保留最新的止损单）
+func (s *SimulatedExchange) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return s.placeTriggerOrder(symbol, positionSide, quantity, stopPrice, true)
+}
+
+// SetTakeProfit 设置止盈单（同一持仓只保留最新的止盈单）
+func (s *SimulatedExchange) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return s.placeTriggerOrder(symbol, positionSide, quantity, takeProfitPrice, false)
+}
+
+// CancelAllOrders 取消该币种的所有挂单
+func (s *SimulatedExchange) CancelAllOrders(symbol string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeOrders(market.Normalize(symbol), "")
+	return nil
+}
+
+// GetOpenOrders 获取当前的止损/止盈条件单
+func (s *SimulatedExchange) GetOpenOrders() ([]map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]map[string]interface{}, 0, len(s.orders))
+	for _, o := range s.orders {
+		orderType := "TAKE_PROFIT_MARKET"
+		if o.isStopLoss {
+			orderType = "STOP_MARKET"
+		}
+		result = append(result, map[string]interface{}{
+			"orderId":      o.id,
+			"symbol":       o.symbol,
+			"positionSide": strings.ToUpper(o.side),
+			"type":         orderType,
+			"stopPrice":    o.triggerPrice,
+			"quantity":     o.quantity,
+		})
+	}
+	return result, nil
+}
+
+// GetFundingFees 获取该币种自 since 起的资金费净收入（负数表示支付）
+func (s *SimulatedExchange) GetFundingFees(symbol string, since time.Time) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	symbol = market.Normalize(symbol)
+	total := 0.0
+	for _, f := range s.fundingLog {
+		if f.symbol == symbol && !f.time.Before(since) {
+			total += f.amount
+		}
+	}
+	return total, nil
+}
+
+// CancelOrder 按订单ID撤销条件单
+func (s *SimulatedExchange) CancelOrder(symbol string, orderID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, o := range s.orders {
+		if o.id == orderID && o.symbol == market.Normalize(symbol) {
+			s.removeOrderByID(orderID)
+			return nil
+		}
+	}
+	return fmt.Errorf("订单不存在: %d", orderID)
+}
+
+// FormatQuantity 格式化数量
+func (s *SimulatedExchange) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return fmt.Sprintf("%.6f", quantity), nil
+}
+
+// open 按当前价格加滑点市价开仓，同方向已有持仓时加仓并重新计算均价
+func (s *SimulatedExchange) open(symbol, side string, quantity float64, leverage int) (map[string]interface{}, error) {
+	symbol = market.Normalize(symbol)
+	if quantity <= 0 {
+		return nil, fmt.Errorf("开仓数量必须大于0")
+	}
+
+	price, err := s.priceFunc(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s价格失败: %w", symbol, err)
+	}
+
+	// 深度滑点可能需要请求订单簿，放在锁外估算
+	slippageBps := s.estimateSlippage(symbol, quantity*price, side == "long")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if leverage <= 0 {
+		leverage = s.leverage[symbol]
+	}
+	if leverage <= 0 {
+		leverage = 1
+	}
+	s.leverage[symbol] = leverage
+
+	// 与真实交易所一致：开仓前清理该币种旧的止损止盈单
+	s.removeOrders(symbol, "")
+
+	fillPrice := applySlippage(price, slippageBps, side == "long")
+	notional := quantity * fillPrice
+	margin := notional / float64(leverage)
+	fee := notional * s.costs.TakerFeeRate
+
+	if available := s.availableLocked(); margin+fee > available {
+		return nil, fmt.Errorf("可用余额不足: 需要 %.2f USDT（保证金%.2f + 手续费%.2f），可用 %.2f USDT", margin+fee, margin, fee, available)
+	}
+
+	key := symbol + "_" + side
+	if pos, ok := s.positions[key]; ok {
+		total := pos.quantity + quantity
+		pos.entryPrice = (pos.entryPrice*pos.quantity + fillPrice*quantity) / total
+		pos.quantity = total
+		pos.margin += margin
+		pos.leverage = leverage
+		pos.markPrice = price
+	} else {
+		s.positions[key] = &simPosition{
+			symbol:      symbol,
+			side:        side,
+			quantity:    quantity,
+			entryPrice:  fillPrice,
+			markPrice:   price,
+			leverage:    leverage,
+			margin:      margin,
+			openTime:    s.now(),
+			nextFunding: s.now().UTC().Truncate(simFundingInterval).Add(simFundingInterval),
+		}
+	}
+
+	s.walletBalance -= fee
+	s.totalFees += fee
+	s.nextOrderID++
+
+	s.log().Info("模拟开仓", "symbol", symbol, "side", sideName(side), "quantity", quantity, "fill_price", fillPrice, "fee", fee)
+
+	return map[string]interface{}{
+		"orderId":  s.nextOrderID,
+		"symbol":   symbol,
+		"status":   "FILLED",
+		"avgPrice": fillPrice,
+	}, nil
+}
+
+// close 按当前价格加滑点市价平仓
+func (s *SimulatedExchange) close(symbol, side string, quantity float64) (map[string]interface{}, error) {
+	symbol = market.Normalize(symbol)
+
+	price, err := s.priceFunc(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s价格失败: %w", symbol, err)
+	}
+
+	slippageBps := s.estimateSlippage(symbol, s.closeQuantity(symbol, side, quantity)*price, side == "short")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pos, ok := s.positions[symbol+"_"+side]
+	if !ok {
+		return nil, fmt.Errorf("没有找到 %s 的%s仓", symbol, sideName(side))
+	}
+	if quantity <= 0 || quantity > pos.quantity {
+		quantity = pos.quantity
+	}
+
+	pos.markPrice = price
+	fillPrice := applySlippage(price, slippageBps, side == "short")
+	pnl := s.closeLocked(pos, quantity, fillPrice, s.costs.TakerFeeRate)
+	s.nextOrderID++
+
+	s.log().Info("模拟平仓", "symbol", symbol, "side", sideName(side), "quantity", quantity, "fill_price", fillPrice, "realized_pnl", pnl)
+
+	return map[string]interface{}{
+		"orderId":     s.nextOrderID,
+		"symbol":      symbol,
+		"status":      "FILLED",
+		"avgPrice":    fillPrice,
+		"realizedPnl": pnl,
+	}, nil
+}
+
+// closeQuantity 实际要平掉的数量（quantity<=0或超过持仓时平全部），持仓不存在时返回0
+func (s *SimulatedExchange) closeQuantity(symbol, side string, quantity float64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pos, ok := s.positions[symbol+"_"+side]
+	if !ok {
+		return 0
+	}
+	if quantity <= 0 || quantity > pos.quantity {
+		return pos.quantity
+	}
+	return quantity
+}
+
+// closeLocked 平掉部分或全部持仓，返回扣除手续费后的已实现盈亏（调用方需持有锁）
+func (s *SimulatedExchange) closeLocked(pos *simPosition, quantity, exitPrice, feeRate float64) float64 {
+	direction := 1.0
+	if pos.side == "short" {
+		direction = -1.0
+	}
+
+	fee := quantity * exitPrice * feeRate
+	pnl := (exitPrice-pos.entryPrice)*quantity*direction - fee
+
+	s.walletBalance += pnl
+	s.totalFees += fee
+	s.recordTrade(pos, quantity, exitPrice, pnl, false)
+
+	pos.margin -= pos.margin * quantity / pos.quantity
+	pos.quantity -= quantity
+	if pos.quantity <= 1e-12 {
+		delete(s.positions, pos.symbol+"_"+pos.side)
+		s.removeOrders(pos.symbol, pos.side)
+	}
+	return pnl
+}
+
+// recordTrade 记录一笔平仓（调用方需持有锁）
+func (s *SimulatedExchange) recordTrade(pos *simPosition, quantity, exitPrice, pnl float64, liquidated bool) {
+	s.closedTrades = append(s.closedTrades, SimulatedTrade{
+		Symbol:     pos.symbol,
+		Side:       pos.side,
+		Quantity:   quantity,
+		Leverage:   pos.leverage,
+		EntryPrice: pos.entryPrice,
+		ExitPrice:  exitPrice,
+		StopLoss:   pos.stopLoss,
+		PnL:        pnl,
+		OpenTime:   pos.openTime,
+		CloseTime:  s.now(),
+		Liquidated: liquidated,
+	})
+}
+
+// placeTriggerOrder 记录止损/止盈条件单，价格触发后在refresh中按市价成交
+func (s *SimulatedExchange) placeTriggerOrder(symbol, positionSide string, quantity, triggerPrice float64, isStopLoss bool) error {
+	symbol = market.Normalize(symbol)
+	side := "long"
+	if positionSide == "SHORT" {
+		side = "short"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.orders[:0]
+	for _, o := range s.orders {
+		if o.symbol == symbol && o.side == side && o.isStopLoss == isStopLoss {
+			continue
+		}
+		kept = append(kept, o)
+	}
+	if pos, ok := s.positions[symbol+"_"+side]; ok && isStopLoss {
+		pos.stopLoss = triggerPrice
+	}
+
+	s.nextOrderID++
+	s.orders = append(kept, &simOrder{
+		id:           s.nextOrderID,
+		symbol:       symbol,
+		side:         side,
+		quantity:     quantity,
+		triggerPrice: triggerPrice,
+		isStopLoss:   isStopLoss,
+	})
+	return nil
+}
+
+// removeOrders 删除指定币种（side为空时不区分方向）的条件单（调用方需持有锁）
+func (s *SimulatedExchange) removeOrders(symbol, side string) {
+	kept := s.orders[:0]
+	for _, o := range s.orders {
+		if o.symbol == symbol && (side == "" || o.side == side) {
+			continue
+		}
+		kept = append(kept, o)
+	}
+	s.orders = kept
+}
+
+// refresh 用最新价格更新持仓，依次结算资金费、检查强平、触发止损止盈
+func (s *SimulatedExchange) refresh() {
+	s.mu.Lock()
+	now := s.now()
+	symbols := make(map[string]bool)
+	fundingDue := make(map[string]bool)
+	quantities := make(map[string]float64, len(s.positions))
+	for key, pos := range s.positions {
+		symbols[pos.symbol] = true
+		quantities[key] = pos.quantity
+		if !now.Before(pos.nextFunding) {
+			fundingDue[pos.symbol] = true
+		}
+	}
+	s.mu.Unlock()
+
+	// 网络请求不持有锁
+	prices := make(map[string]float64, len(symbols))
+	for symbol := range symbols {
+		price, err := s.priceFunc(symbol)
+		if err != nil {
+			s.log().Warn("模拟交易所获取价格失败", "symbol", symbol, "error", err)
+			continue
+		}
+		prices[symbol] = price
+	}
+	rates := make(map[string]float64, len(fundingDue))
+	for symbol := range fundingDue {
+		rate, err := s.fundingFunc(symbol)
+		if err != nil {
+			s.log().Warn("模拟交易所获取资金费率失败", "symbol", symbol, "error", err)
+			continue
+		}
+		rates[symbol] = rate
+	}
+	// 按整仓平仓规模估算条件单成交滑点
+	slippage := make(map[string]float64, len(quantities))
+	for key, quantity := range quantities {
+		symbol, side := splitPositionKey(key)
+		if price, ok := prices[symbol]; ok {
+			slippage[key] = s.estimateSlippage(symbol, quantity*price, side == "short")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, pos := range s.positions {
+		price, ok := prices[pos.symbol]
+		if !ok {
+			continue
+		}
+		pos.markPrice = price
+
+		if rate, ok := rates[pos.symbol]; ok {
+			s.settleFunding(pos, rate, now)
+		}
+
+		if pos.isLiquidated() {
+			s.log().Warn("模拟仓位被强平", "symbol", pos.symbol, "side", sideName(pos.side),
+				"mark_price", price, "liquidation_price", pos.liquidationPrice(), "margin_lost", pos.margin)
+			s.walletBalance -= pos.margin
+			s.recordTrade(pos, pos.quantity, price, -pos.margin, true)
+			delete(s.positions, key)
+			s.removeOrders(pos.symbol, pos.side)
+		}
+	}
+
+	s.triggerOrders(prices, slippage)
+}
+
+// settleFunding 结算已经过去的资金费时间点（多头在正费率时支付，空头收取）
+func (s *SimulatedExchange) settleFunding(pos *simPosition, rate float64, now time.Time) {
+	for !now.Before(pos.nextFunding) {
+		payment := pos.quantity * pos.markPrice * rate
+		if pos.side == "short" {
+			payment = -payment
+		}
+		s.walletBalance -= payment
+		s.totalFunding -= payment
+		s.fundingLog = append(s.fundingLog, simFunding{symbol: pos.symbol, time: pos.nextFunding, amount: -payment})
+		pos.nextFunding = pos.nextFunding.Add(simFundingInterval)
+		s.log().Info("模拟资金费结算", "symbol", pos.symbol, "side", sideName(pos.side), "rate", rate, "amount", -payment)
+	}
+}
+
+// triggerOrders 检查条件单是否触发，触发后按当前价格加滑点成交（调用方需持有锁）
+// 设置TakeProfitMaker时止盈按触发价以maker费率成交
+func (s *SimulatedExchange) triggerOrders(prices, slippage map[string]float64) {
+	pending := append([]*simOrder(nil), s.orders...)
+	for _, o := range pending {
+		price, ok := prices[o.symbol]
+		if !ok {
+			continue
+		}
+		pos, ok := s.positions[o.symbol+"_"+o.side]
+		if !ok {
+			continue
+		}
+
+		// 多仓：止损向下触发、止盈向上触发；空仓相反
+		triggered := false
+		if (o.side == "long") == o.isStopLoss {
+			triggered = price <= o.triggerPrice
+		} else {
+			triggered = price >= o.triggerPrice
+		}
+		if !triggered {
+			continue
+		}
+
+		quantity := o.quantity
+		if quantity <= 0 || quantity > pos.quantity {
+			quantity = pos.quantity
+		}
+		fillPrice := applySlippage(price, slippage[o.symbol+"_"+o.side], o.side == "short")
+		feeRate := s.costs.TakerFeeRate
+		if !o.isStopLoss && s.costs.TakeProfitMaker {
+			fillPrice = o.triggerPrice
+			feeRate = s.costs.MakerFeeRate
+		}
+		s.removeOrderByID(o.id)
+		pnl := s.closeLocked(pos, quantity, fillPrice, feeRate)
+
+		kind := "止盈"
+		if o.isStopLoss {
+			kind = "止损"
+		}
+		s.log().Info("模拟条件单触发", "symbol", o.symbol, "side", sideName(o.side), "kind", kind,
+			"trigger_price", o.triggerPrice, "fill_price", fillPrice, "realized_pnl", pnl)
+	}
+}
+
+// removeOrderByID 删除指定条件单（调用方需持有锁）
+func (s *SimulatedExchange) removeOrderByID(id int64) {
+	for i, o := range s.orders {
+		if o.id == id {
+			s.orders = append(s.orders[:i], s.orders[i+1:]...)
+			return
+		}
+	}
+}
+
+// availableLocked 计算可用余额（调用方需持有锁）
+func (s *SimulatedExchange) availableLocked() float64 {
+	available := s.walletBalance
+	for _, pos := range s.positions {
+		available += pos.unrealizedPnL() - pos.margin
+	}
+	return available
+}
+
+// estimateSlippage 按滑点模型估算市价单滑点（基点）
+func (s *SimulatedExchange) estimateSlippage(symbol string, notional float64, isBuy bool) float64 {
+	if s.slippageFunc != nil {
+		return s.slippageFunc(symbol, notional, isBuy)
+	}
+	return s.costs.SlippageBps
+}
+
+// splitPositionKey 拆分持仓key（symbol_side）
+func splitPositionKey(key string) (symbol, side string) {
+	i := strings.LastIndex(key, "_")
+	return key[:i], key[i+1:]
+}
+
+// applySlippage 市价单成交价：买入向上滑点，卖出向下滑点
+func applySlippage(price, slippageBps float64, isBuy bool) float64 {
+	slippage := price * slippageBps / 10000
+	if isBuy {
+		return price + slippage
+	}
+	return price - slippage
+}
+
+// unrealizedPnL 按标记价计算未实现盈亏
+func (p *simPosition) unrealizedPnL() float64 {
+	if p.side == "short" {
+		return (p.entryPrice - p.markPrice) * p.quantity
+	}
+	return (p.markPrice - p.entryPrice) * p.quantity
+}
+
+// liquidationPrice 逐仓强平价：亏损吃掉保证金直到只剩维持保证金
+func (p *simPosition) liquidationPrice() float64 {
+	marginRate := p.margin / (p.entryPrice * p.quantity)
+	if p.side == "short" {
+		return p.entryPrice * (1 + marginRate - simMaintenanceMarginRate)
+	}
+	return p.entryPrice * (1 - marginRate + simMaintenanceMarginRate)
+}
+
+// isLiquidated 标记价是否触及强平价
+func (p *simPosition) isLiquidated() bool {
+	if p.side == "short" {
+		return p.markPrice >= p.liquidationPrice()
+	}
+	return p.markPrice <= p.liquidationPrice()
+}
+
+func sideName(side string) string {
+	if side == "short" {
+		return "空"
+	}
+	return "多"
+}
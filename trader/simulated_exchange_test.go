@@ -0,0 +1,89 @@
+package trader
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func newTestSimulatedExchange(price *float64, now *time.Time) *SimulatedExchange {
+	s := NewSimulatedExchange(1000)
+	s.costs.SlippageBps = 0
+	s.priceFunc = func(string) (float64, error) { return *price, nil }
+	s.fundingFunc = func(string) (float64, error) { return 0.0001, nil }
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func balanceOf(t *testing.T, s *SimulatedExchange) float64 {
+	t.Helper()
+	balance, err := s.GetBalance()
+	if err != nil {
+		t.Fatalf("GetBalance失败: %v", err)
+	}
+	return balance["totalWalletBalance"].(float64)
+}
+
+func TestSimulatedExchangeOpenClose(t *testing.T) {
+	price := 100.0
+	now := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	s := newTestSimulatedExchange(&price, &now)
+
+	if _, err := s.OpenLong("BTCUSDT", 2, 5); err != nil {
+		t.Fatalf("开仓失败: %v", err)
+	}
+	price = 110
+	if _, err := s.CloseLong("BTCUSDT", 0); err != nil {
+		t.Fatalf("平仓失败: %v", err)
+	}
+
+	// 盈利 20，手续费 (200+220)*0.0004
+	want := 1000 + 20 - 420*simTakerFeeRate
+	if got := balanceOf(t, s); math.Abs(got-want) > 1e-9 {
+		t.Errorf("余额 %.6f，期望 %.6f", got, want)
+	}
+	if positions, _ := s.GetPositions(); len(positions) != 0 {
+		t.Errorf("全部平仓后不应有持仓: %v", positions)
+	}
+}
+
+func TestSimulatedExchangeStopLossAndLiquidation(t *testing.T) {
+	price := 100.0
+	now := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	s := newTestSimulatedExchange(&price, &now)
+
+	s.OpenShort("ETHUSDT", 1, 10)
+	s.SetStopLoss("ETHUSDT", "SHORT", 1, 105)
+
+	price = 106
+	if positions, _ := s.GetPositions(); len(positions) != 0 {
+		t.Fatalf("止损应已触发: %v", positions)
+	}
+
+	// 10倍多仓，跌破约 90.5 被强平，损失全部保证金
+	price = 100
+	s.OpenLong("ETHUSDT", 1, 10)
+	before := balanceOf(t, s)
+	price = 90
+	if positions, _ := s.GetPositions(); len(positions) != 0 {
+		t.Fatalf("应已被强平: %v", positions)
+	}
+	if got := balanceOf(t, s); math.Abs(before-got-10) > 1e-9 {
+		t.Errorf("强平应损失保证金10，实际损失 %.6f", before-got)
+	}
+}
+
+func TestSimulatedExchangeFunding(t *testing.T) {
+	price := 100.0
+	now := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	s := newTestSimulatedExchange(&price, &now)
+
+	s.OpenLong("BTCUSDT", 10, 5)
+	before := balanceOf(t, s)
+
+	// 跨过 08:00 和 16:00 两个结算点，正费率多头支付 2 × 1000 × 0.0001
+	now = time.Date(2025, 1, 1, 17, 0, 0, 0, time.UTC)
+	if got := balanceOf(t, s); math.Abs(before-got-0.2) > 1e-9 {
+		t.Errorf("资金费应为0.2，实际 %.6f", before-got)
+	}
+}
@@ -0,0 +1,130 @@
+package trader
+
+import (
+	"fmt"
+	"log/slog"
+	"nofx/market"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 滑点模型
+const (
+	SlippageModelFixed = "fixed" // 固定基点
+	SlippageModelDepth = "depth" // 按订单簿深度估算
+)
+
+// SimulationCosts 模拟交易的费用与滑点设置（纸面交易和回测共用）
+type SimulationCosts struct {
+	MakerFeeRate    float64 `json:"maker_fee_rate"`    // 挂单手续费率
+	TakerFeeRate    float64 `json:"taker_fee_rate"`    // 吃单手续费率
+	SlippageModel   string  `json:"slippage_model"`    // "fixed" 或 "depth"
+	SlippageBps     float64 `json:"slippage_bps"`      // 固定滑点；depth模式下作为缺少深度数据时的兜底
+	TakeProfitMaker bool    `json:"take_profit_maker"` // 止盈按限价挂单成交（maker费率、无滑点），默认与实盘TAKE_PROFIT_MARKET一致
+}
+
+// DefaultSimulationCosts 默认费用（币安USDT合约普通用户费率）
+func DefaultSimulationCosts() SimulationCosts {
+	return SimulationCosts{
+		MakerFeeRate:  0.0002,
+		TakerFeeRate:  simTakerFeeRate,
+		SlippageModel: SlippageModelFixed,
+		SlippageBps:   simSlippageBps,
+	}
+}
+
+// Validate 检查费用设置是否合法
+func (c SimulationCosts) Validate() error {
+	if c.MakerFeeRate < 0 || c.MakerFeeRate > 0.01 || c.TakerFeeRate < 0 || c.TakerFeeRate > 0.01 {
+		return fmt.Errorf("手续费率必须在0~1%%之间")
+	}
+	if c.SlippageBps < 0 || c.SlippageBps > 500 {
+		return fmt.Errorf("滑点必须在0~500基点之间")
+	}
+	if c.SlippageModel != SlippageModelFixed && c.SlippageModel != SlippageModelDepth {
+		return fmt.Errorf("不支持的滑点模型: %s", c.SlippageModel)
+	}
+	return nil
+}
+
+var (
+	paperCostsMu sync.RWMutex
+	paperCosts   = DefaultSimulationCosts()
+)
+
+// SetPaperTradingCosts 设置纸面交易员使用的费用与滑点（只影响之后创建的模拟交易所）
+func SetPaperTradingCosts(costs SimulationCosts) error {
+	costs.SlippageModel = strings.ToLower(strings.TrimSpace(costs.SlippageModel))
+	if err := costs.Validate(); err != nil {
+		return err
+	}
+	paperCostsMu.Lock()
+	defer paperCostsMu.Unlock()
+	paperCosts = costs
+	return nil
+}
+
+// getPaperTradingCosts 获取当前纸面交易费用设置
+func getPaperTradingCosts() SimulationCosts {
+	paperCostsMu.RLock()
+	defer paperCostsMu.RUnlock()
+	return paperCosts
+}
+
+// liveDepthSlippage 实时拉取订单簿估算滑点，深度不足时按比例放大，失败时退回固定滑点
+func liveDepthSlippage(fallbackBps float64) func(symbol string, notional float64, isBuy bool) float64 {
+	return func(symbol string, notional float64, isBuy bool) float64 {
+		ob, err := market.NewAPIClient().GetOrderBook(symbol, 100)
+		if err != nil {
+			slog.Warn("模拟交易所获取订单簿失败，使用固定滑点", "symbol", symbol, "error", err)
+			return fallbackBps
+		}
+		bps, filled := ob.EstimateSlippage(notional, isBuy)
+		if !filled {
+			depth := 0.0
+			levels := ob.Bids
+			if isBuy {
+				levels = ob.Asks
+			}
+			for _, level := range levels {
+				depth += level.Price * level.Quantity
+			}
+			if depth > 0 {
+				bps *= notional / depth
+			}
+		}
+		return bps
+	}
+}
+
+// interpolateSlippage 按记录的参考规模滑点曲线线性插值，超出最大规模时按比例外推
+func interpolateSlippage(curve map[float64]float64, notional float64) (float64, bool) {
+	if len(curve) == 0 || notional <= 0 {
+		return 0, false
+	}
+
+	sizes := make([]float64, 0, len(curve))
+	for size := range curve {
+		if size > 0 {
+			sizes = append(sizes, size)
+		}
+	}
+	if len(sizes) == 0 {
+		return 0, false
+	}
+	sort.Float64s(sizes)
+
+	if notional <= sizes[0] {
+		return curve[sizes[0]] * notional / sizes[0], true
+	}
+	for i := 1; i < len(sizes); i++ {
+		if notional <= sizes[i] {
+			lo, hi := sizes[i-1], sizes[i]
+			weight := (notional - lo) / (hi - lo)
+			return curve[lo] + (curve[hi]-curve[lo])*weight, true
+		}
+	}
+	last := sizes[len(sizes)-1]
+	return curve[last] * notional / last, true
+}
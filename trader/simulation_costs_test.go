@@ -0,0 +1,41 @@
+package trader
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInterpolateSlippage(t *testing.T) {
+	curve := map[float64]float64{10000: 1, 50000: 3, 200000: 12}
+
+	cases := []struct {
+		notional float64
+		want     float64
+	}{
+		{5000, 0.5},  // 小于最小规模按比例缩小
+		{30000, 2},   // 区间内线性插值
+		{200000, 12}, // 正好落在参考点
+		{400000, 24}, // 超出最大规模按比例外推
+	}
+	for _, c := range cases {
+		got, ok := interpolateSlippage(curve, c.notional)
+		if !ok || math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("notional=%.0f: 得到 %.4f(%v)，期望 %.4f", c.notional, got, ok, c.want)
+		}
+	}
+
+	if _, ok := interpolateSlippage(nil, 10000); ok {
+		t.Error("没有滑点曲线时应返回ok=false")
+	}
+}
+
+func TestSimulationCostsValidate(t *testing.T) {
+	if err := DefaultSimulationCosts().Validate(); err != nil {
+		t.Errorf("默认费用应合法: %v", err)
+	}
+	costs := DefaultSimulationCosts()
+	costs.SlippageModel = "random"
+	if err := costs.Validate(); err == nil {
+		t.Error("未知滑点模型应校验失败")
+	}
+}
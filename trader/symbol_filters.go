@@ -0,0 +1,74 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// SymbolFilters 交易对下单规则（来自交易所exchangeInfo）
+type SymbolFilters struct {
+	Symbol      string
+	TickSize    float64 // 价格最小变动单位
+	StepSize    float64 // 数量最小变动单位
+	MinQty      float64 // 最小下单数量
+	MinNotional float64 // 最小名义价值（数量×价格）
+}
+
+// RoundQuantity 数量向下取整到stepSize（向下取整避免超出可用保证金）
+func (f *SymbolFilters) RoundQuantity(quantity float64) float64 {
+	return floorToStep(quantity, f.StepSize)
+}
+
+// RoundPrice 价格四舍五入到tickSize
+func (f *SymbolFilters) RoundPrice(price float64) float64 {
+	if f.TickSize <= 0 {
+		return price
+	}
+	return math.Round(price/f.TickSize) * f.TickSize
+}
+
+// FormatQuantity 按stepSize取整并格式化数量
+func (f *SymbolFilters) FormatQuantity(quantity float64) string {
+	return strconv.FormatFloat(f.RoundQuantity(quantity), 'f', stepPrecision(f.StepSize), 64)
+}
+
+// FormatPrice 按tickSize取整并格式化价格
+func (f *SymbolFilters) FormatPrice(price float64) string {
+	return strconv.FormatFloat(f.RoundPrice(price), 'f', stepPrecision(f.TickSize), 64)
+}
+
+// ValidateOrder 检查取整后的数量是否满足最小数量和最小名义价值
+func (f *SymbolFilters) ValidateOrder(quantity, price float64) error {
+	qty := f.RoundQuantity(quantity)
+	if qty <= 0 || qty < f.MinQty {
+		return fmt.Errorf("%s 下单数量 %.8f 低于最小数量 %.8f", f.Symbol, qty, f.MinQty)
+	}
+	if f.MinNotional > 0 && price > 0 && qty*price < f.MinNotional {
+		return fmt.Errorf("%s 订单名义价值 %.2f USDT 低于最小要求 %.2f USDT", f.Symbol, qty*price, f.MinNotional)
+	}
+	return nil
+}
+
+// floorToStep 向下取整到step的整数倍（加微小偏移避免浮点误差导致少一个step）
+func floorToStep(value, step float64) float64 {
+	if step <= 0 {
+		return value
+	}
+	steps := math.Floor(value/step + 1e-9)
+	return roundTo(steps*step, stepPrecision(step))
+}
+
+// stepPrecision 计算step对应的小数位数
+func stepPrecision(step float64) int {
+	if step <= 0 {
+		return 8
+	}
+	return calculatePrecision(strconv.FormatFloat(step, 'f', -1, 64))
+}
+
+// roundTo 四舍五入到指定小数位（消除浮点误差）
+func roundTo(value float64, precision int) float64 {
+	pow := math.Pow(10, float64(precision))
+	return math.Round(value*pow) / pow
+}
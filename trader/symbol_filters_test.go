@@ -0,0 +1,41 @@
+package trader
+
+import "testing"
+
+// TestSymbolFiltersRounding 测试数量/价格按交易规则取整
+func TestSymbolFiltersRounding(t *testing.T) {
+	f := &SymbolFilters{Symbol: "SOLUSDT", TickSize: 0.01, StepSize: 0.1, MinQty: 0.1, MinNotional: 5}
+
+	if got := f.FormatQuantity(1.2999); got != "1.2" {
+		t.Errorf("数量取整错误: %s", got)
+	}
+	if got := f.FormatQuantity(0.3); got != "0.3" {
+		t.Errorf("数量浮点误差处理错误: %s", got)
+	}
+	if got := f.FormatPrice(153.456); got != "153.46" {
+		t.Errorf("价格取整错误: %s", got)
+	}
+
+	btc := &SymbolFilters{Symbol: "BTCUSDT", TickSize: 0.1, StepSize: 0.001, MinQty: 0.001, MinNotional: 100}
+	if got := btc.FormatQuantity(0.0129); got != "0.012" {
+		t.Errorf("BTC数量取整错误: %s", got)
+	}
+	if got := btc.FormatPrice(65000.06); got != "65000.1" {
+		t.Errorf("BTC价格取整错误: %s", got)
+	}
+}
+
+// TestSymbolFiltersValidateOrder 测试最小数量和最小名义价值校验
+func TestSymbolFiltersValidateOrder(t *testing.T) {
+	f := &SymbolFilters{Symbol: "BTCUSDT", TickSize: 0.1, StepSize: 0.001, MinQty: 0.001, MinNotional: 100}
+
+	if err := f.ValidateOrder(0.0009, 60000); err == nil {
+		t.Error("数量低于最小步长时应报错")
+	}
+	if err := f.ValidateOrder(0.001, 60000); err == nil {
+		t.Error("名义价值60 USDT低于100时应报错")
+	}
+	if err := f.ValidateOrder(0.002, 60000); err != nil {
+		t.Errorf("合法订单不应报错: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"nofx/logger"
+	"time"
+)
+
+// metricsCacheTTL 绩效指标需要读取全部决策记录，竞赛数据刷新时复用缓存
+const metricsCacheTTL = 5 * time.Minute
+
+// GetTradeMetrics 全部历史的绩效指标（夏普、索提诺、期望、平均R、连续盈亏、持仓时间占比）
+func (at *AutoTrader) GetTradeMetrics() (*logger.TradeMetrics, error) {
+	at.metricsMu.Lock()
+	defer at.metricsMu.Unlock()
+
+	if at.metrics != nil && time.Since(at.metricsTime) < metricsCacheTTL {
+		return at.metrics, nil
+	}
+
+	stats, err := at.decisionLogger.GetStatistics()
+	if err != nil {
+		return nil, err
+	}
+	metrics := stats.Metrics
+	if metrics == nil {
+		metrics = &logger.TradeMetrics{}
+	}
+	at.metrics = metrics
+	at.metricsTime = time.Now()
+	return metrics, nil
+}
@@ -0,0 +1,2 @@
+// Package web 内嵌到后端的前端文件，只在 -tags embedweb 构建时包含，默认构建由nginx等单独提供前端
+package web
@@ -0,0 +1,22 @@
+//go:build embedweb
+
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// dist 前端构建产物，-tags embedweb 构建前需先执行 npm run build
+//
+//go:embed all:dist
+var dist embed.FS
+
+// Dist 内嵌的前端文件（根目录为 dist）
+func Dist() fs.FS {
+	files, err := fs.Sub(dist, "dist")
+	if err != nil {
+		panic(err)
+	}
+	return files
+}
@@ -0,0 +1,10 @@
+//go:build !embedweb
+
+package web
+
+import "io/fs"
+
+// Dist 未内嵌前端时返回nil
+func Dist() fs.FS {
+	return nil
+}
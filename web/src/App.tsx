@@ -5,6 +5,7 @@ import { EquityChart } from './components/EquityChart';
 import { AITradersPage } from './components/AITradersPage';
 import { LoginPage } from './components/LoginPage';
 import { RegisterPage } from './components/RegisterPage';
+import { ResetPasswordPage } from './components/ResetPasswordPage';
 import { CompetitionPage } from './components/CompetitionPage';
 import { LandingPage } from './pages/LandingPage';
 import HeaderBar from './components/landing/HeaderBar';
@@ -13,6 +14,7 @@ import { LanguageProvider, useLanguage } from './contexts/LanguageContext';
 import { AuthProvider, useAuth } from './contexts/AuthContext';
 import { t, type Language } from './i18n/translations';
 import { useSystemConfig } from './hooks/useSystemConfig';
+import { useTraderStream } from './hooks/useTraderStream';
 import TestPromptPage from './pages/TestPromptPage';
 import type {
   SystemStatus,
@@ -108,6 +110,12 @@ function App() {
     }
   }, [traders, selectedTraderId]);
 
+  // WebSocket推送可用时不再轮询状态/账户/持仓/最新决策
+  const streaming = useTraderStream(
+    currentPage === 'trader' && user && token ? selectedTraderId : undefined,
+    token
+  );
+
   // 如果在trader页面，获取该trader的数据
   const { data: status } = useSWR<SystemStatus>(
     currentPage === 'trader' && selectedTraderId
@@ -115,7 +123,7 @@ function App() {
       : null,
     () => api.getStatus(selectedTraderId),
     {
-      refreshInterval: 15000, // 15秒刷新（配合后端15秒缓存）
+      refreshInterval: streaming ? 0 : 15000, // 15秒刷新（配合后端15秒缓存），有推送时不轮询
       revalidateOnFocus: false, // 禁用聚焦时重新验证，减少请求
       dedupingInterval: 10000, // 10秒去重，防止短时间内重复请求
     }
@@ -127,7 +135,7 @@ function App() {
       : null,
     () => api.getAccount(selectedTraderId),
     {
-      refreshInterval: 15000, // 15秒刷新（配合后端15秒缓存）
+      refreshInterval: streaming ? 0 : 15000, // 15秒刷新（配合后端15秒缓存），有推送时不轮询
       revalidateOnFocus: false, // 禁用聚焦时重新验证，减少请求
       dedupingInterval: 10000, // 10秒去重，防止短时间内重复请求
     }
@@ -139,7 +147,7 @@ function App() {
       : null,
     () => api.getPositions(selectedTraderId),
     {
-      refreshInterval: 15000, // 15秒刷新（配合后端15秒缓存）
+      refreshInterval: streaming ? 0 : 15000, // 15秒刷新（配合后端15秒缓存），有推送时不轮询
       revalidateOnFocus: false, // 禁用聚焦时重新验证，减少请求
       dedupingInterval: 10000, // 10秒去重，防止短时间内重复请求
     }
@@ -151,7 +159,7 @@ function App() {
       : null,
     () => api.getLatestDecisions(selectedTraderId),
     {
-      refreshInterval: 30000, // 30秒刷新（决策更新频率较低）
+      refreshInterval: streaming ? 0 : 30000, // 30秒刷新（决策更新频率较低），有推送时不轮询
       revalidateOnFocus: false,
       dedupingInterval: 20000,
     }
@@ -217,6 +225,9 @@ function App() {
   if (route === '/register') {
     return <RegisterPage />;
   }
+  if (route === '/reset-password') {
+    return <ResetPasswordPage />;
+  }
   if (route === '/test-prompt') {
     return (
       <div className="min-h-screen bg-gray-50">
@@ -37,6 +37,8 @@ interface AITradersPageProps {
 export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
   const { language } = useLanguage();
   const { user, token } = useAuth();
+  // 观察者只能查看共享的交易员，不能管理交易员和密钥
+  const isViewer = user?.role === 'viewer';
   const [showCreateModal, setShowCreateModal] = useState(false);
   const [showEditModal, setShowEditModal] = useState(false);
   const [showModelModal, setShowModelModal] = useState(false);
@@ -63,8 +65,8 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
   // 加载AI模型和交易所配置
   useEffect(() => {
     const loadConfigs = async () => {
-      if (!user || !token) {
-        // 未登录时只加载公开的支持模型和交易所
+      if (!user || !token || isViewer) {
+        // 未登录或观察者只加载公开的支持模型和交易所
         try {
           const [supportedModels, supportedExchanges] = await Promise.all([
             api.getSupportedModels(),
@@ -105,7 +107,7 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
       }
     };
     loadConfigs();
-  }, [user, token]);
+  }, [user, token, isViewer]);
 
   // 显示所有用户的模型和交易所配置（用于调试）
   const configuredModels = allModels || [];
@@ -503,6 +505,7 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
           </div>
         </div>
 
+        {!isViewer && (
         <div className="flex gap-2 md:gap-3 w-full md:w-auto overflow-x-auto flex-wrap md:flex-nowrap">
           <button
             onClick={handleAddModel}
@@ -555,6 +558,7 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
             {t('createTrader', language)}
           </button>
         </div>
+        )}
       </div>
 
       {/* Configuration Status */}
@@ -678,6 +682,11 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
                   <div className="min-w-0">
                     <div className="font-bold text-base md:text-lg truncate" style={{ color: '#EAECEF' }}>
                       {trader.trader_name}
+                      {trader.is_shared && (
+                        <span className="ml-2 text-xs font-normal px-2 py-0.5 rounded" style={{ background: 'rgba(99, 102, 241, 0.1)', color: '#6366F1' }}>
+                          {t('sharedReadOnly', language)}
+                        </span>
+                      )}
                     </div>
                     <div className="text-xs md:text-sm truncate" style={{
                       color: trader.ai_model.includes('deepseek') ? '#60a5fa' : '#c084fc'
@@ -712,6 +721,8 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
                       {t('view', language)}
                     </button>
 
+                    {!isViewer && !trader.is_shared && (
+                    <>
                     <button
                       onClick={() => handleEditTrader(trader.trader_id)}
                       disabled={trader.is_running}
@@ -742,6 +753,8 @@ export function AITradersPage({ onTraderSelect }: AITradersPageProps) {
                     >
                       <Trash2 className="w-3 h-3 md:w-4 md:h-4" />
                     </button>
+                    </>
+                    )}
                   </div>
                 </div>
               </div>
@@ -3,15 +3,18 @@ import { useAuth } from '../contexts/AuthContext';
 import { useLanguage } from '../contexts/LanguageContext';
 import { t } from '../i18n/translations';
 import HeaderBar from './landing/HeaderBar';
+import { OTPRebind } from './OTPRebind';
 
 export function LoginPage() {
   const { language } = useLanguage();
-  const { login, verifyOTP } = useAuth();
-  const [step, setStep] = useState<'login' | 'otp'>('login');
+  const { login, verifyOTP, loginWithRecoveryCode, completeRegistration } = useAuth();
+  const [step, setStep] = useState<'login' | 'otp' | 'recovery' | 'rebind' | 'setup'>('login');
   const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
   const [otpCode, setOtpCode] = useState('');
+  const [recoveryCode, setRecoveryCode] = useState('');
   const [userID, setUserID] = useState('');
+  const [setupSecret, setSetupSecret] = useState({ otpSecret: '', qrCodeURL: '' });
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
 
@@ -27,6 +30,10 @@ export function LoginPage() {
         setUserID(result.userID);
         setStep('otp');
       }
+    } else if (result.requiresOTPSetup && result.userID) {
+      setUserID(result.userID);
+      setSetupSecret({ otpSecret: result.otpSecret || '', qrCodeURL: result.qrCodeURL || '' });
+      setStep('setup');
     } else {
       setError(result.message || t('loginFailed', language));
     }
@@ -39,7 +46,8 @@ export function LoginPage() {
     setError('');
     setLoading(true);
 
-    const result = await verifyOTP(userID, otpCode);
+    // 重新绑定时用新密钥的验证码完成设置
+    const result = step === 'setup' ? await completeRegistration(userID, otpCode) : await verifyOTP(userID, otpCode);
     
     if (!result.success) {
       setError(result.message || t('verificationFailed', language));
@@ -49,6 +57,28 @@ export function LoginPage() {
     setLoading(false);
   };
 
+  const handleRecoveryLogin = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setLoading(true);
+
+    const result = await loginWithRecoveryCode(email, password, recoveryCode.trim());
+
+    if (result.success) {
+      // 恢复码已作废，引导用户立即绑定新的验证器
+      setStep('rebind');
+    } else {
+      setError(result.message || t('verificationFailed', language));
+    }
+
+    setLoading(false);
+  };
+
+  const goHome = () => {
+    window.history.pushState({}, '', '/');
+    window.dispatchEvent(new PopStateEvent('popstate'));
+  };
+
   return (
     <div className="min-h-screen" style={{ background: 'var(--brand-black)' }}>
       <HeaderBar 
@@ -78,7 +108,7 @@ export function LoginPage() {
               登录 NOFX
             </h1>
             <p className="text-sm mt-2" style={{ color: 'var(--text-secondary)' }}>
-              {step === 'login' ? '请输入您的邮箱和密码' : '请输入两步验证码'}
+              {step === 'login' ? '请输入您的邮箱和密码' : step === 'recovery' ? '请输入恢复码' : step === 'rebind' ? '请重新绑定验证器' : '请输入两步验证码'}
             </p>
           </div>
 
@@ -130,17 +160,94 @@ export function LoginPage() {
               >
                 {loading ? t('loading', language) : t('loginButton', language)}
               </button>
+
+              <button
+                type="button"
+                onClick={() => {
+                  window.history.pushState({}, '', '/reset-password');
+                  window.dispatchEvent(new PopStateEvent('popstate'));
+                }}
+                className="w-full text-xs hover:underline"
+                style={{ color: 'var(--text-secondary)' }}
+              >
+                {t('forgotPassword', language)}
+              </button>
+            </form>
+          ) : step === 'rebind' ? (
+            <OTPRebind password={password} onDone={goHome} />
+          ) : step === 'recovery' ? (
+            <form onSubmit={handleRecoveryLogin} className="space-y-4">
+              <div>
+                <label className="block text-sm font-semibold mb-2" style={{ color: 'var(--brand-light-gray)' }}>
+                  {t('recoveryCode', language)}
+                </label>
+                <input
+                  type="text"
+                  value={recoveryCode}
+                  onChange={(e) => setRecoveryCode(e.target.value)}
+                  className="w-full px-3 py-2 rounded text-center text-xl font-mono"
+                  style={{ background: 'var(--brand-black)', border: '1px solid var(--panel-border)', color: 'var(--brand-light-gray)' }}
+                  placeholder={t('recoveryCodePlaceholder', language)}
+                  required
+                />
+              </div>
+
+              {error && (
+                <div className="text-sm px-3 py-2 rounded" style={{ background: 'var(--binance-red-bg)', color: 'var(--binance-red)' }}>
+                  {error}
+                </div>
+              )}
+
+              <div className="flex gap-3">
+                <button
+                  type="button"
+                  onClick={() => {
+                    setError('');
+                    setStep('otp');
+                  }}
+                  className="flex-1 px-4 py-2 rounded text-sm font-semibold"
+                  style={{ background: 'var(--panel-bg-hover)', color: 'var(--text-secondary)' }}
+                >
+                  {t('useOTPCode', language)}
+                </button>
+                <button
+                  type="submit"
+                  disabled={loading || !recoveryCode.trim()}
+                  className="flex-1 px-4 py-2 rounded text-sm font-semibold transition-all hover:scale-105 disabled:opacity-50"
+                  style={{ background: '#F0B90B', color: '#000' }}
+                >
+                  {loading ? t('loading', language) : t('signInWithRecoveryCode', language)}
+                </button>
+              </div>
             </form>
           ) : (
             <form onSubmit={handleOTPVerify} className="space-y-4">
               <div className="text-center mb-4">
                 <div className="text-4xl mb-2">📱</div>
                 <p className="text-sm" style={{ color: '#848E9C' }}>
-                  {t('scanQRCodeInstructions', language)}<br />
+                  {step === 'setup' ? t('rebindAuthenticatorDesc', language) : t('scanQRCodeInstructions', language)}<br />
                   {t('enterOTPCode', language)}
                 </p>
               </div>
 
+              {step === 'setup' && setupSecret.qrCodeURL && (
+                <div className="bg-white p-2 rounded text-center">
+                  <img
+                    src={`https://api.qrserver.com/v1/create-qr-code/?size=150x150&data=${encodeURIComponent(setupSecret.qrCodeURL)}`}
+                    alt="QR Code"
+                    className="mx-auto"
+                  />
+                </div>
+              )}
+              {step === 'setup' && setupSecret.otpSecret && (
+                <code
+                  className="block px-2 py-1 text-xs rounded font-mono text-center"
+                  style={{ background: 'var(--panel-bg-hover)', color: 'var(--brand-light-gray)' }}
+                >
+                  {setupSecret.otpSecret}
+                </code>
+              )}
+
               <div>
                 <label className="block text-sm font-semibold mb-2" style={{ color: 'var(--brand-light-gray)' }}>
                   {t('otpCode', language)}
@@ -181,6 +288,20 @@ export function LoginPage() {
                   {loading ? t('loading', language) : t('verifyOTP', language)}
                 </button>
               </div>
+
+              {step === 'otp' && (
+              <button
+                type="button"
+                onClick={() => {
+                  setError('');
+                  setStep('recovery');
+                }}
+                className="w-full text-xs hover:underline"
+                style={{ color: 'var(--text-secondary)' }}
+              >
+                {t('useRecoveryCode', language)}
+              </button>
+              )}
             </form>
           )}
         </div>
@@ -0,0 +1,122 @@
+import React, { useEffect, useState } from 'react';
+import { useLanguage } from '../contexts/LanguageContext';
+import { t } from '../i18n/translations';
+import { api } from '../lib/api';
+import { RecoveryCodes } from './RecoveryCodes';
+
+// 恢复码登录后重新绑定验证器：生成新密钥 → 输入新验证码确认 → 展示新的恢复码
+export function OTPRebind({ password, onDone }: { password: string; onDone: () => void }) {
+  const { language } = useLanguage();
+  const [otpSecret, setOtpSecret] = useState('');
+  const [qrCodeURL, setQrCodeURL] = useState('');
+  const [otpCode, setOtpCode] = useState('');
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([]);
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  useEffect(() => {
+    let cancelled = false;
+    api
+      .resetOTP(password)
+      .then((data) => {
+        if (cancelled) return;
+        setOtpSecret(data.otp_secret);
+        setQrCodeURL(data.qr_code_url);
+      })
+      .catch((err: Error) => {
+        if (!cancelled) setError(err.message);
+      });
+    return () => {
+      cancelled = true;
+    };
+  }, [password]);
+
+  const handleConfirm = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setLoading(true);
+    try {
+      const data = await api.confirmOTPReset(otpCode);
+      setRecoveryCodes(data.recovery_codes);
+    } catch (err) {
+      setError((err as Error).message || t('verificationFailed', language));
+    }
+    setLoading(false);
+  };
+
+  if (recoveryCodes.length > 0) {
+    return (
+      <div className="space-y-4">
+        <RecoveryCodes codes={recoveryCodes} description={t('newRecoveryCodesDesc', language)} />
+        <button
+          onClick={onDone}
+          className="w-full px-4 py-2 rounded text-sm font-semibold transition-all hover:scale-105"
+          style={{ background: '#F0B90B', color: '#000' }}
+        >
+          {t('done', language)}
+        </button>
+      </div>
+    );
+  }
+
+  return (
+    <form onSubmit={handleConfirm} className="space-y-4">
+      <div className="text-center">
+        <div className="text-4xl mb-2">🔐</div>
+        <h3 className="text-lg font-semibold mb-2" style={{ color: '#EAECEF' }}>
+          {t('rebindAuthenticator', language)}
+        </h3>
+        <p className="text-sm" style={{ color: '#848E9C' }}>
+          {t('rebindAuthenticatorDesc', language)}
+        </p>
+      </div>
+
+      {qrCodeURL && (
+        <div className="bg-white p-2 rounded text-center">
+          <img
+            src={`https://api.qrserver.com/v1/create-qr-code/?size=150x150&data=${encodeURIComponent(qrCodeURL)}`}
+            alt="QR Code"
+            className="mx-auto"
+          />
+        </div>
+      )}
+      {otpSecret && (
+        <div>
+          <p className="text-xs mb-1" style={{ color: '#848E9C' }}>{t('otpSecret', language)}</p>
+          <code
+            className="block px-2 py-1 text-xs rounded font-mono"
+            style={{ background: 'var(--panel-bg-hover)', color: 'var(--brand-light-gray)' }}
+          >
+            {otpSecret}
+          </code>
+        </div>
+      )}
+
+      <input
+        type="text"
+        value={otpCode}
+        onChange={(e) => setOtpCode(e.target.value.replace(/\D/g, '').slice(0, 6))}
+        className="w-full px-3 py-2 rounded text-center text-2xl font-mono"
+        style={{ background: 'var(--brand-black)', border: '1px solid var(--panel-border)', color: 'var(--brand-light-gray)' }}
+        placeholder={t('otpPlaceholder', language)}
+        maxLength={6}
+        required
+      />
+
+      {error && (
+        <div className="text-sm px-3 py-2 rounded" style={{ background: 'var(--binance-red-bg)', color: 'var(--binance-red)' }}>
+          {error}
+        </div>
+      )}
+
+      <button
+        type="submit"
+        disabled={loading || !otpSecret || otpCode.length !== 6}
+        className="w-full px-4 py-2 rounded text-sm font-semibold transition-all hover:scale-105 disabled:opacity-50"
+        style={{ background: '#F0B90B', color: '#000' }}
+      >
+        {loading ? t('loading', language) : t('verifyOTP', language)}
+      </button>
+    </form>
+  );
+}
@@ -0,0 +1,37 @@
+import { useLanguage } from '../contexts/LanguageContext';
+import { t } from '../i18n/translations';
+
+// 一次性恢复码列表（明文只在生成时展示一次）
+export function RecoveryCodes({ codes, description }: { codes: string[]; description?: string }) {
+  const { language } = useLanguage();
+
+  return (
+    <div className="p-3 rounded" style={{ background: 'var(--brand-black)', border: '1px solid var(--panel-border)' }}>
+      <p className="text-sm font-semibold mb-2" style={{ color: 'var(--brand-light-gray)' }}>
+        {t('recoveryCodesTitle', language)}
+      </p>
+      <p className="text-xs mb-2" style={{ color: 'var(--text-secondary)' }}>
+        {description || t('recoveryCodesDesc', language)}
+      </p>
+      <div className="grid grid-cols-2 gap-1 mb-2">
+        {codes.map((code) => (
+          <code
+            key={code}
+            className="px-2 py-1 text-xs rounded font-mono text-center"
+            style={{ background: 'var(--panel-bg-hover)', color: 'var(--brand-light-gray)' }}
+          >
+            {code}
+          </code>
+        ))}
+      </div>
+      <button
+        type="button"
+        onClick={() => navigator.clipboard.writeText(codes.join('\n'))}
+        className="px-2 py-1 text-xs rounded"
+        style={{ background: 'var(--brand-yellow)', color: 'var(--brand-black)' }}
+      >
+        {t('copyAll', language)}
+      </button>
+    </div>
+  );
+}
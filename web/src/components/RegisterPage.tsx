@@ -4,6 +4,7 @@ import { useLanguage } from '../contexts/LanguageContext';
 import { t } from '../i18n/translations';
 import { getSystemConfig } from '../lib/config';
 import HeaderBar from './landing/HeaderBar';
+import { RecoveryCodes } from './RecoveryCodes';
 
 export function RegisterPage() {
   const { language } = useLanguage();
@@ -18,6 +19,7 @@ export function RegisterPage() {
   const [userID, setUserID] = useState('');
   const [otpSecret, setOtpSecret] = useState('');
   const [qrCodeURL, setQrCodeURL] = useState('');
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([]);
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
 
@@ -57,6 +59,7 @@ export function RegisterPage() {
       setUserID(result.userID);
       setOtpSecret(result.otpSecret || '');
       setQrCodeURL(result.qrCodeURL || '');
+      setRecoveryCodes(result.recoveryCodes || []);
       setStep('setup-otp');
     } else {
       setError(result.message || t('registrationFailed', language));
@@ -275,6 +278,8 @@ export function RegisterPage() {
                     {t('authStep3Desc', language)}
                   </p>
                 </div>
+
+                {recoveryCodes.length > 0 && <RecoveryCodes codes={recoveryCodes} />}
               </div>
 
               <button
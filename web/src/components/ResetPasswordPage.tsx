@@ -0,0 +1,181 @@
+import React, { useState } from 'react';
+import { useLanguage } from '../contexts/LanguageContext';
+import { t } from '../i18n/translations';
+import { api } from '../lib/api';
+import HeaderBar from './landing/HeaderBar';
+
+// 找回密码：没有token时申请重置邮件，带token（邮件链接）时设置新密码
+export function ResetPasswordPage() {
+  const { language } = useLanguage();
+  const token = new URLSearchParams(window.location.search).get('token') || '';
+  const [email, setEmail] = useState('');
+  const [password, setPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+  const [done, setDone] = useState(false);
+
+  const goLogin = () => {
+    window.history.pushState({}, '', '/login');
+    window.dispatchEvent(new PopStateEvent('popstate'));
+  };
+
+  const handleRequest = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setLoading(true);
+    try {
+      const data = await api.requestPasswordReset(email);
+      setMessage(data.message);
+      setDone(true);
+    } catch (err) {
+      setError((err as Error).message);
+    }
+    setLoading(false);
+  };
+
+  const handleConfirm = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    if (password !== confirmPassword) {
+      setError(t('passwordMismatch', language));
+      return;
+    }
+    setLoading(true);
+    try {
+      await api.confirmPasswordReset(token, password);
+      setMessage(t('passwordResetSuccess', language));
+      setDone(true);
+    } catch (err) {
+      setError((err as Error).message);
+    }
+    setLoading(false);
+  };
+
+  const inputStyle = { background: 'var(--brand-black)', border: '1px solid var(--panel-border)', color: 'var(--brand-light-gray)' };
+
+  return (
+    <div className="min-h-screen" style={{ background: 'var(--brand-black)' }}>
+      <HeaderBar
+        onLoginClick={goLogin}
+        isLoggedIn={false}
+        isHomePage={false}
+        currentPage="login"
+        language={language}
+        onLanguageChange={() => {}}
+        onPageChange={(page) => {
+          if (page === 'competition') {
+            window.location.href = '/competition';
+          }
+        }}
+      />
+
+      <div className="flex items-center justify-center pt-20" style={{ minHeight: 'calc(100vh - 80px)' }}>
+        <div className="w-full max-w-md">
+          <div className="text-center mb-8">
+            <div className="w-16 h-16 mx-auto mb-4 flex items-center justify-center">
+              <img src="/icons/nofx.svg" alt="NoFx Logo" className="w-16 h-16 object-contain" />
+            </div>
+            <h1 className="text-2xl font-bold" style={{ color: 'var(--brand-light-gray)' }}>
+              {t('resetPasswordTitle', language)}
+            </h1>
+            {!token && (
+              <p className="text-sm mt-2" style={{ color: 'var(--text-secondary)' }}>
+                {t('resetPasswordDesc', language)}
+              </p>
+            )}
+          </div>
+
+          <div className="rounded-lg p-6" style={{ background: 'var(--panel-bg)', border: '1px solid var(--panel-border)' }}>
+            {done ? (
+              <div className="space-y-4">
+                <p className="text-sm text-center" style={{ color: 'var(--brand-light-gray)' }}>
+                  {message}
+                </p>
+                <button
+                  onClick={goLogin}
+                  className="w-full px-4 py-2 rounded text-sm font-semibold transition-all hover:scale-105"
+                  style={{ background: 'var(--brand-yellow)', color: 'var(--brand-black)' }}
+                >
+                  {t('backToLogin', language)}
+                </button>
+              </div>
+            ) : (
+              <form onSubmit={token ? handleConfirm : handleRequest} className="space-y-4">
+                {token ? (
+                  <>
+                    <div>
+                      <label className="block text-sm font-semibold mb-2" style={{ color: 'var(--brand-light-gray)' }}>
+                        {t('newPassword', language)}
+                      </label>
+                      <input
+                        type="password"
+                        value={password}
+                        onChange={(e) => setPassword(e.target.value)}
+                        className="w-full px-3 py-2 rounded"
+                        style={inputStyle}
+                        minLength={6}
+                        required
+                      />
+                    </div>
+                    <div>
+                      <label className="block text-sm font-semibold mb-2" style={{ color: 'var(--brand-light-gray)' }}>
+                        {t('confirmNewPassword', language)}
+                      </label>
+                      <input
+                        type="password"
+                        value={confirmPassword}
+                        onChange={(e) => setConfirmPassword(e.target.value)}
+                        className="w-full px-3 py-2 rounded"
+                        style={inputStyle}
+                        minLength={6}
+                        required
+                      />
+                    </div>
+                  </>
+                ) : (
+                  <div>
+                    <label className="block text-sm font-semibold mb-2" style={{ color: 'var(--brand-light-gray)' }}>
+                      {t('email', language)}
+                    </label>
+                    <input
+                      type="email"
+                      value={email}
+                      onChange={(e) => setEmail(e.target.value)}
+                      className="w-full px-3 py-2 rounded"
+                      style={inputStyle}
+                      placeholder={t('emailPlaceholder', language)}
+                      required
+                    />
+                  </div>
+                )}
+
+                {error && (
+                  <div className="text-sm px-3 py-2 rounded" style={{ background: 'var(--binance-red-bg)', color: 'var(--binance-red)' }}>
+                    {error}
+                  </div>
+                )}
+
+                <button
+                  type="submit"
+                  disabled={loading}
+                  className="w-full px-4 py-2 rounded text-sm font-semibold transition-all hover:scale-105 disabled:opacity-50"
+                  style={{ background: 'var(--brand-yellow)', color: 'var(--brand-black)' }}
+                >
+                  {loading ? t('loading', language) : t(token ? 'setNewPassword' : 'sendResetLink', language)}
+                </button>
+              </form>
+            )}
+          </div>
+
+          <div className="text-center mt-6">
+            <button onClick={goLogin} className="text-sm hover:underline" style={{ color: 'var(--text-secondary)' }}>
+              {t('backToLogin', language)}
+            </button>
+          </div>
+        </div>
+      </div>
+    </div>
+  );
+}
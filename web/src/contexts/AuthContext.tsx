@@ -4,14 +4,16 @@ import { getSystemConfig } from '../lib/config';
 interface User {
   id: string;
   email: string;
+  role?: 'admin' | 'user' | 'viewer';
 }
 
 interface AuthContextType {
   user: User | null;
   token: string | null;
-  login: (email: string, password: string) => Promise<{ success: boolean; message?: string; userID?: string; requiresOTP?: boolean }>;
-  register: (email: string, password: string, betaCode?: string) => Promise<{ success: boolean; message?: string; userID?: string; otpSecret?: string; qrCodeURL?: string }>;
+  login: (email: string, password: string) => Promise<{ success: boolean; message?: string; userID?: string; requiresOTP?: boolean; requiresOTPSetup?: boolean; otpSecret?: string; qrCodeURL?: string }>;
+  register: (email: string, password: string, betaCode?: string) => Promise<{ success: boolean; message?: string; userID?: string; otpSecret?: string; qrCodeURL?: string; recoveryCodes?: string[] }>;
   verifyOTP: (userID: string, otpCode: string) => Promise<{ success: boolean; message?: string }>;
+  loginWithRecoveryCode: (email: string, password: string, recoveryCode: string) => Promise<{ success: boolean; message?: string; token?: string; remaining?: number }>;
   completeRegistration: (userID: string, otpCode: string) => Promise<{ success: boolean; message?: string }>;
   logout: () => void;
   isLoading: boolean;
@@ -30,7 +32,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
       .then(data => {
         if (data.admin_mode) {
           // 管理员模式下，模拟admin用户
-          setUser({ id: 'admin', email: 'admin@localhost' });
+          setUser({ id: 'admin', email: 'admin@localhost', role: 'admin' });
           setToken('admin-mode');
         } else {
           // 非管理员模式，检查本地存储中是否有token
@@ -79,6 +81,16 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
             message: data.message,
           };
         }
+      } else if (data.requires_otp_setup) {
+        // 注册未完成或管理员重置了验证器，需要重新绑定
+        return {
+          success: false,
+          requiresOTPSetup: true,
+          userID: data.user_id,
+          otpSecret: data.otp_secret,
+          qrCodeURL: data.qr_code_url,
+          message: data.error,
+        };
       } else {
         return { success: false, message: data.error };
       }
@@ -112,6 +124,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
           userID: data.user_id,
           otpSecret: data.otp_secret,
           qrCodeURL: data.qr_code_url,
+          recoveryCodes: data.recovery_codes,
           message: data.message,
         };
       } else {
@@ -136,7 +149,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
 
       if (response.ok) {
         // 登录成功，保存token和用户信息
-        const userInfo = { id: data.user_id, email: data.email };
+        const userInfo = { id: data.user_id, email: data.email, role: data.role };
         setToken(data.token);
         setUser(userInfo);
         localStorage.setItem('auth_token', data.token);
@@ -155,6 +168,35 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     }
   };
 
+  // 恢复码登录后不跳转首页，由调用方引导用户重新绑定验证器
+  const loginWithRecoveryCode = async (email: string, password: string, recoveryCode: string) => {
+    try {
+      const response = await fetch('/api/login/recovery', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ email, password, recovery_code: recoveryCode }),
+      });
+
+      const data = await response.json();
+
+      if (response.ok) {
+        const userInfo = { id: data.user_id, email: data.email, role: data.role };
+        setToken(data.token);
+        setUser(userInfo);
+        localStorage.setItem('auth_token', data.token);
+        localStorage.setItem('auth_user', JSON.stringify(userInfo));
+
+        return { success: true, message: data.message, token: data.token, remaining: data.remaining_recovery_codes };
+      } else {
+        return { success: false, message: data.error };
+      }
+    } catch (error) {
+      return { success: false, message: '恢复码登录失败，请重试' };
+    }
+  };
+
   const completeRegistration = async (userID: string, otpCode: string) => {
     try {
       const response = await fetch('/api/complete-registration', {
@@ -169,7 +211,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
 
       if (response.ok) {
         // 注册完成，自动登录
-        const userInfo = { id: data.user_id, email: data.email };
+        const userInfo = { id: data.user_id, email: data.email, role: data.role };
         setToken(data.token);
         setUser(userInfo);
         localStorage.setItem('auth_token', data.token);
@@ -203,6 +245,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
         login,
         register,
         verifyOTP,
+        loginWithRecoveryCode,
         completeRegistration,
         logout,
         isLoading,
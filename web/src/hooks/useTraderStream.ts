@@ -0,0 +1,90 @@
+import { useEffect, useState } from 'react';
+import { mutate } from 'swr';
+import type { DecisionRecord } from '../types';
+
+const RECONNECT_DELAY = 5000;
+const MAX_DECISIONS = 5; // 与 /api/decisions/latest 返回条数一致
+
+interface TraderEvent {
+  type: 'equity' | 'decision' | 'positions' | 'status' | string;
+  trader_id: string;
+  time: string;
+  data: unknown;
+}
+
+// 通过 /api/ws 订阅交易员的实时推送，直接写入SWR缓存；连接可用时调用方可以停止轮询
+export function useTraderStream(traderId: string | undefined, token: string | null) {
+  const [connected, setConnected] = useState(false);
+
+  useEffect(() => {
+    if (!traderId) return;
+
+    let ws: WebSocket | null = null;
+    let reconnectTimer: ReturnType<typeof setTimeout> | undefined;
+    let closed = false;
+
+    const connect = () => {
+      const protocol = window.location.protocol === 'https:' ? 'wss' : 'ws';
+      const query = token ? `?token=${encodeURIComponent(token)}` : '';
+      ws = new WebSocket(`${protocol}://${window.location.host}/api/ws${query}`);
+
+      ws.onopen = () => {
+        setConnected(true);
+        ws?.send(JSON.stringify({ action: 'subscribe', trader_id: traderId }));
+      };
+
+      ws.onmessage = (msg) => {
+        let event: TraderEvent;
+        try {
+          event = JSON.parse(msg.data);
+        } catch {
+          return;
+        }
+        if (event.trader_id !== traderId) return;
+
+        switch (event.type) {
+          case 'equity':
+            mutate(`account-${traderId}`, event.data, false);
+            break;
+          case 'positions':
+            mutate(`positions-${traderId}`, event.data, false);
+            break;
+          case 'status':
+            mutate(`status-${traderId}`, event.data, false);
+            break;
+          case 'decision':
+            mutate<DecisionRecord[]>(
+              `decisions/latest-${traderId}`,
+              (current) =>
+                [event.data as DecisionRecord, ...(current ?? [])].slice(0, MAX_DECISIONS),
+              false
+            );
+            // 统计数据由决策记录汇总而来，有新决策时刷新
+            mutate(`statistics-${traderId}`);
+            break;
+          case 'error':
+            console.warn('WebSocket订阅失败:', event);
+            break;
+        }
+      };
+
+      ws.onclose = () => {
+        setConnected(false);
+        if (!closed) {
+          reconnectTimer = setTimeout(connect, RECONNECT_DELAY);
+        }
+      };
+    };
+
+    connect();
+
+    return () => {
+      closed = true;
+      clearTimeout(reconnectTimer);
+      ws?.close();
+      setConnected(false);
+    };
+  }, [traderId, token]);
+
+  return connected;
+}
@@ -311,6 +311,30 @@ export const translations = {
     authStep3Desc: 'After setup, continue to enter the 6-digit code',
     setupCompleteContinue: 'I have completed setup, continue',
     copy: 'Copy',
+    recoveryCodesTitle: 'Recovery codes',
+    recoveryCodesDesc: 'Save these one-time codes somewhere safe. If you lose your phone, each code lets you sign in once and bind a new authenticator.',
+    copyAll: 'Copy all',
+    useRecoveryCode: 'Lost your authenticator? Use a recovery code',
+    useOTPCode: 'Use authenticator code',
+    recoveryCode: 'Recovery code',
+    recoveryCodePlaceholder: 'xxxxx-xxxxx',
+    signInWithRecoveryCode: 'Sign in with recovery code',
+    rebindAuthenticator: 'Bind a new authenticator',
+    rebindAuthenticatorDesc: 'Scan the new QR code, then enter the 6-digit code to confirm. Your old recovery codes will stop working.',
+    newRecoveryCodesDesc: 'Authenticator rebound. These new recovery codes replace the old ones:',
+    remainingRecoveryCodes: 'Recovery codes left',
+    done: 'Done',
+    forgotPassword: 'Forgot password?',
+    resetPasswordTitle: 'Reset password',
+    resetPasswordDesc: 'Enter your account email and we will send you a reset link',
+    sendResetLink: 'Send reset link',
+    newPassword: 'New password',
+    confirmNewPassword: 'Confirm new password',
+    setNewPassword: 'Set new password',
+    passwordMismatch: 'Passwords do not match',
+    passwordResetSuccess: 'Password updated, please sign in with your new password',
+    backToLogin: 'Back to login',
+    sharedReadOnly: 'Shared · read-only',
     completeRegistration: 'Complete Registration',
     completeRegistrationSubtitle: 'to complete registration',
     loginSuccess: 'Login successful',
@@ -725,6 +749,30 @@ export const translations = {
     authStep3Desc: '设置完成后，点击下方按钮输入6位验证码',
     setupCompleteContinue: '我已完成设置，继续',
     copy: '复制',
+    recoveryCodesTitle: '恢复码',
+    recoveryCodesDesc: '请妥善保存这些一次性恢复码。手机丢失时，每个恢复码可以登录一次并重新绑定验证器。',
+    copyAll: '全部复制',
+    useRecoveryCode: '丢失验证器？使用恢复码',
+    useOTPCode: '使用验证器验证码',
+    recoveryCode: '恢复码',
+    recoveryCodePlaceholder: 'xxxxx-xxxxx',
+    signInWithRecoveryCode: '使用恢复码登录',
+    rebindAuthenticator: '重新绑定验证器',
+    rebindAuthenticatorDesc: '扫描新的二维码并输入6位验证码确认，确认后旧的恢复码将失效。',
+    newRecoveryCodesDesc: '验证器已重新绑定，以下新的恢复码替代旧的恢复码：',
+    remainingRecoveryCodes: '剩余恢复码',
+    done: '完成',
+    forgotPassword: '忘记密码？',
+    resetPasswordTitle: '重置密码',
+    resetPasswordDesc: '输入账户邮箱，我们将发送密码重置链接',
+    sendResetLink: '发送重置链接',
+    newPassword: '新密码',
+    confirmNewPassword: '确认新密码',
+    setNewPassword: '设置新密码',
+    passwordMismatch: '两次输入的密码不一致',
+    passwordResetSuccess: '密码已重置，请使用新密码登录',
+    backToLogin: '返回登录',
+    sharedReadOnly: '共享 · 只读',
     completeRegistration: '完成注册',
     completeRegistrationSubtitle: '以完成注册',
     loginSuccess: '登录成功',
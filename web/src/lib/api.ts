@@ -358,4 +358,50 @@ export const api = {
 
     return data.data;
   },
+
+  // 重新绑定验证器：先生成新的OTP密钥，再用新验证码确认（返回新的恢复码）
+  async resetOTP(password: string): Promise<{ otp_secret: string; qr_code_url: string }> {
+    const res = await fetch(`${API_BASE}/user/otp/reset`, {
+      method: 'POST',
+      headers: getAuthHeaders(),
+      body: JSON.stringify({ password }),
+    });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || '生成OTP密钥失败');
+    return data;
+  },
+
+  async confirmOTPReset(otpCode: string): Promise<{ recovery_codes: string[] }> {
+    const res = await fetch(`${API_BASE}/user/otp/confirm`, {
+      method: 'POST',
+      headers: getAuthHeaders(),
+      body: JSON.stringify({ otp_code: otpCode }),
+    });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || '确认OTP失败');
+    return data;
+  },
+
+  // 找回密码：发送重置邮件 / 使用邮件中的token设置新密码（无需登录）
+  async requestPasswordReset(email: string): Promise<{ message: string }> {
+    const res = await fetch(`${API_BASE}/password-reset/request`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ email }),
+    });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || '发送重置邮件失败');
+    return data;
+  },
+
+  async confirmPasswordReset(token: string, newPassword: string): Promise<{ message: string }> {
+    const res = await fetch(`${API_BASE}/password-reset/confirm`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ token, new_password: newPassword }),
+    });
+    const data = await res.json();
+    if (!res.ok) throw new Error(data.error || '重置密码失败');
+    return data;
+  },
 };
@@ -91,6 +91,7 @@ export interface TraderInfo {
   ai_model: string;
   exchange_id?: string;
   is_running?: boolean;
+  is_shared?: boolean;
   custom_prompt?: string;
 }
 
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRedirects webhook请求最多跟随的重定向次数
+const maxRedirects = 3
+
+// ErrForbiddenTarget 目标地址指向本机、内网或链路本地地址，防止webhook被用来探测内网（SSRF）
+var ErrForbiddenTarget = errors.New("webhook地址不能指向本机、内网或链路本地地址")
+
+// reservedPrefixes net.IP 分类方法之外、同样不应从公网访问的地址段
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本网络
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级NAT
+	netip.MustParsePrefix("198.18.0.0/15"), // 基准测试
+}
+
+// isForbiddenAddr 是否为本机、内网、链路本地（含云厂商元数据 169.254.169.254）等地址
+func isForbiddenAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL 检查webhook地址：必须是 http(s)，主机名为IP时不能是受限地址
+// 域名在此只做格式检查，实际连接的IP由 safeControl 在拨号时校验（覆盖DNS重绑定）
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook地址必须是 http(s) URL")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenTarget
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && isForbiddenAddr(addr) {
+		return ErrForbiddenTarget
+	}
+	return nil
+}
+
+// safeControl 在DNS解析之后、建立连接之前检查目标IP
+func safeControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("无法解析连接地址 %s: %w", address, err)
+	}
+	if isForbiddenAddr(addrPort.Addr()) {
+		return ErrForbiddenTarget
+	}
+	return nil
+}
+
+// NewSafeClient 创建只允许访问公网地址的HTTP客户端，重定向目标同样校验（也用于用户信号源）
+func NewSafeClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: safeControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // 经代理转发时拨号检查只能看到代理地址
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("重定向次数超过%d次", maxRedirects)
+			}
+			return ValidateURL(req.URL.String())
+		},
+	}
+}
@@ -0,0 +1,177 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"nofx/config"
+	"nofx/trader"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 请求头
+const (
+	SignatureHeader = "X-NOFX-Signature" // sha256=<hex(HMAC-SHA256(secret, timestamp + "." + body))>
+	TimestampHeader = "X-NOFX-Timestamp" // Unix秒，接收方应拒绝时间偏差过大的请求以防重放
+	EventHeader     = "X-NOFX-Event"
+	DeliveryHeader  = "X-NOFX-Delivery" // 投递ID，重试时不变，可用于去重
+)
+
+// EventTest 手动发送的测试事件
+const EventTest = "test"
+
+// Payload 推送给webhook的JSON内容
+type Payload struct {
+	ID       string      `json:"id"`
+	Event    string      `json:"event"`
+	TraderID string      `json:"trader_id"`
+	Time     time.Time   `json:"time"`
+	Data     interface{} `json:"data"`
+}
+
+// Sign 计算签名：对 "timestamp.body" 做 HMAC-SHA256
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名（供接收方参考实现和测试使用）
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// GenerateSecret 生成签名密钥
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Dispatcher 订阅交易员事件并推送到用户注册的webhook
+type Dispatcher struct {
+	database    *config.Database
+	client      *http.Client
+	retryDelays []time.Duration // 失败后的重试间隔
+}
+
+// NewDispatcher 创建推送器
+func NewDispatcher(database *config.Database) *Dispatcher {
+	return &Dispatcher{
+		database:    database,
+		client:      NewSafeClient(10 * time.Second),
+		retryDelays: []time.Duration{5 * time.Second, 30 * time.Second},
+	}
+}
+
+// Start 开始订阅交易事件（阻塞，调用方使用 go 启动）
+func (d *Dispatcher) Start() {
+	events, unsubscribe := trader.SubscribeEvents(256)
+	defer unsubscribe()
+
+	slog.Info("Webhook推送已启动")
+	for event := range events {
+		if trader.IsTradeEvent(event.Type) {
+			d.Dispatch(event)
+		}
+	}
+}
+
+// Dispatch 把事件异步推送给交易员所有者订阅了该事件的webhook
+func (d *Dispatcher) Dispatch(event trader.Event) {
+	owner, err := d.database.GetTraderOwner(event.TraderID)
+	if err != nil {
+		return
+	}
+	hooks, err := d.database.GetWebhooks(owner)
+	if err != nil {
+		slog.Warn("获取用户的webhook失败", "user_id", owner, "error", err)
+		return
+	}
+
+	payload := Payload{
+		ID:       uuid.New().String(),
+		Event:    event.Type,
+		TraderID: event.TraderID,
+		Time:     event.Time,
+		Data:     event.Data,
+	}
+	for _, hook := range hooks {
+		if hook.Matches(event.TraderID, event.Type) {
+			go d.Deliver(hook, payload)
+		}
+	}
+}
+
+// Deliver 投递一次事件，失败时按 retryDelays 重试，并记录最终结果
+func (d *Dispatcher) Deliver(hook *config.Webhook, payload Payload) error {
+	var status int
+	var err error
+	for attempt := 0; ; attempt++ {
+		status, err = d.Send(hook, payload)
+		if err == nil || attempt >= len(d.retryDelays) {
+			break
+		}
+		time.Sleep(d.retryDelays[attempt])
+	}
+
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+		slog.Warn("Webhook推送失败", "user_id", hook.UserID, "trader_id", payload.TraderID, "webhook_id", hook.ID, "url", hook.URL, "event", payload.Event, "error", err)
+	}
+	if recErr := d.database.RecordWebhookDelivery(hook.ID, status, errMsg); recErr != nil {
+		slog.Warn("记录webhook投递结果失败", "webhook_id", hook.ID, "error", recErr)
+	}
+	return err
+}
+
+// Send 发送一次请求，返回HTTP状态码；非2xx视为失败
+func (d *Dispatcher) Send(hook *config.Webhook, payload Payload) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("序列化事件失败: %w", err)
+	}
+
+	if err := ValidateURL(hook.URL); err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "NOFX-Webhook/1.0")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, payload.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("返回状态码 %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"nofx/config"
+	"nofx/trader"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestDatabase(t *testing.T) *config.Database {
+	t.Helper()
+	database, err := config.NewDatabase(filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"stop_hit"}`)
+	sig := Sign("secret", 1700000000, body)
+	if !Verify("secret", 1700000000, body, sig) {
+		t.Fatal("签名应校验通过")
+	}
+	if Verify("secret", 1700000001, body, sig) || Verify("other", 1700000000, body, sig) {
+		t.Error("时间戳或密钥不同时签名应不匹配")
+	}
+}
+
+func TestDeliverSignsAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	var got Payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if !Verify("s3cret", ts, body, r.Header.Get(SignatureHeader)) {
+			t.Errorf("签名校验失败")
+		}
+		if r.Header.Get(EventHeader) != trader.EventPositionOpened {
+			t.Errorf("事件头错误: %s", r.Header.Get(EventHeader))
+		}
+		json.Unmarshal(body, &got)
+	}))
+	defer srv.Close()
+
+	database := newTestDatabase(t)
+	hook := &config.Webhook{ID: "h1", UserID: "u1", URL: srv.URL, Secret: "s3cret"}
+	if err := database.CreateWebhook(hook); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDispatcher(database)
+	d.client = srv.Client() // 测试服务器在本机，绕过内网地址限制
+	d.retryDelays = []time.Duration{time.Millisecond}
+	payload := Payload{ID: "d1", Event: trader.EventPositionOpened, TraderID: "t1", Time: time.Now()}
+	if err := d.Deliver(hook, payload); err != nil {
+		t.Fatalf("重试后应投递成功: %v", err)
+	}
+	if calls.Load() != 2 || got.ID != "d1" {
+		t.Errorf("应重试一次后成功: calls=%d payload=%+v", calls.Load(), got)
+	}
+
+	saved, err := database.GetWebhook("u1", "h1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.LastStatus != http.StatusOK || saved.LastError != "" || saved.LastDeliveredAt == nil {
+		t.Errorf("应记录最近一次投递结果: %+v", saved)
+	}
+}
+
+func TestWebhookMatches(t *testing.T) {
+	all := &config.Webhook{}
+	filtered := &config.Webhook{TraderID: "t1", Events: []string{trader.EventStopHit}}
+
+	if !all.Matches("t9", trader.EventDrawdownAlert) {
+		t.Error("未设置过滤条件时应匹配所有事件")
+	}
+	if !filtered.Matches("t1", trader.EventStopHit) {
+		t.Error("应匹配订阅的交易员和事件")
+	}
+	if filtered.Matches("t2", trader.EventStopHit) || filtered.Matches("t1", trader.EventPositionOpened) {
+		t.Error("不应匹配其他交易员或未订阅的事件")
+	}
+}
+
+func TestRejectsPrivateTargets(t *testing.T) {
+	for _, raw := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"https://10.0.0.5/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:192.168.1.1]/hook",
+		"http://localhost/hook",
+		"http://100.64.0.1/hook",
+	} {
+		if err := ValidateURL(raw); !errors.Is(err, ErrForbiddenTarget) {
+			t.Errorf("%s 应被拒绝, got %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"https://hooks.example.com/nofx", "http://8.8.8.8/hook"} {
+		if err := ValidateURL(raw); err != nil {
+			t.Errorf("%s 应允许, got %v", raw, err)
+		}
+	}
+	if err := ValidateURL("ftp://example.com"); err == nil {
+		t.Error("非 http(s) 地址应被拒绝")
+	}
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	// 拨号时按解析后的IP检查，域名解析到内网（DNS重绑定）同样会被拦截
+	client := NewSafeClient(time.Second)
+	if _, err := client.Get(srv.URL); !errors.Is(err, ErrForbiddenTarget) {
+		t.Errorf("连接本机地址应被拒绝, got %v", err)
+	}
+
+	// 重定向到内网地址同样拒绝
+	redirect, _ := http.NewRequest(http.MethodGet, "http://169.254.169.254/latest/meta-data/", nil)
+	if err := client.CheckRedirect(redirect, []*http.Request{{}}); !errors.Is(err, ErrForbiddenTarget) {
+		t.Errorf("重定向到内网地址应被拒绝, got %v", err)
+	}
+
+	d := NewDispatcher(newTestDatabase(t))
+	if status, err := d.Send(&config.Webhook{URL: srv.URL, Secret: "s"}, Payload{ID: "d1", Event: EventTest}); !errors.Is(err, ErrForbiddenTarget) || status != 0 {
+		t.Errorf("Send 应拒绝内网地址, got %d %v", status, err)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("受限地址不应收到请求, hits=%d", hits.Load())
+	}
+}